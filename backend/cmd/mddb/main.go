@@ -327,7 +327,7 @@ func mainImpl() error {
 		return fmt.Errorf("failed to initialize root repo: %w", err)
 	}
 
-	fileStore, err := content.NewFileStoreService(*dataDir, gitMgr, wsService, orgService, &serverCfg.Quotas.ResourceQuotas)
+	fileStore, err := content.NewFileStoreService(*dataDir, gitMgr, wsService, orgService, &serverCfg.Quotas)
 	if err != nil {
 		return fmt.Errorf("failed to initialize file store: %w", err)
 	}
@@ -347,6 +347,24 @@ func mainImpl() error {
 		}
 	}
 
+	// Cleanup organization invitations expired for more than 30 days.
+	if count, err := orgInvService.CleanupExpired(30 * 24 * time.Hour); err != nil {
+		slog.WarnContext(ctx, "Failed to cleanup expired invitations", "error", err)
+	} else if count > 0 {
+		slog.InfoContext(ctx, "Cleaned up expired invitations", "count", count)
+		if err := rootRepo.CommitDBChanges(ctx, git.Author{}, fmt.Sprintf("cleanup %d expired invitations", count)); err != nil {
+			slog.WarnContext(ctx, "Failed to commit invitation cleanup", "error", err)
+		}
+	}
+
+	loginAttemptService, err := identity.NewLoginAttemptService(filepath.Join(dbDir, "login_attempts.jsonl"))
+	if err != nil {
+		return fmt.Errorf("failed to initialize login attempt service: %w", err)
+	}
+	if _, err := loginAttemptService.CleanupExpired(); err != nil {
+		slog.WarnContext(ctx, "Failed to cleanup expired login attempts", "error", err)
+	}
+
 	notificationService, err := identity.NewNotificationService(filepath.Join(dbDir, "notifications.jsonl"))
 	if err != nil {
 		return fmt.Errorf("failed to initialize notification service: %w", err)
@@ -357,11 +375,29 @@ func mainImpl() error {
 		return fmt.Errorf("failed to initialize push subscription service: %w", err)
 	}
 
+	auditService, err := identity.NewAuditService(filepath.Join(dbDir, "audit.jsonl"))
+	if err != nil {
+		return fmt.Errorf("failed to initialize audit service: %w", err)
+	}
+	// Keep one year of audit entries.
+	if count, err := auditService.CleanupExpired(365 * 24 * time.Hour); err != nil {
+		slog.WarnContext(ctx, "Failed to cleanup expired audit entries", "error", err)
+	} else if count > 0 {
+		slog.InfoContext(ctx, "Cleaned up expired audit entries", "count", count)
+		if err := rootRepo.CommitDBChanges(ctx, git.Author{}, fmt.Sprintf("cleanup %d expired audit entries", count)); err != nil {
+			slog.WarnContext(ctx, "Failed to commit audit cleanup", "error", err)
+		}
+	}
+
 	// Initialize email verification service and email service (nil if SMTP not configured)
 	var emailVerificationService *identity.EmailVerificationService
 	var emailService *email.Service
 	if !serverCfg.SMTP.IsZero() {
-		emailService = &email.Service{Config: serverCfg.SMTP}
+		templates, err := email.LoadTemplates(filepath.Join(*dataDir, "email_templates"))
+		if err != nil {
+			return fmt.Errorf("failed to load email templates: %w", err)
+		}
+		emailService = &email.Service{Config: serverCfg.SMTP, Templates: templates}
 		slog.InfoContext(ctx, "SMTP configured", "host", serverCfg.SMTP.Host, "port", serverCfg.SMTP.Port)
 
 		emailVerificationService, err = identity.NewEmailVerificationService(filepath.Join(dbDir, "email_verifications.jsonl"))
@@ -430,18 +466,29 @@ func mainImpl() error {
 		OrgMembership:    orgMemService,
 		WSMembership:     wsMemService,
 		Session:          sessionService,
+		LoginAttempts:    loginAttemptService,
 		EmailVerif:       emailVerificationService,
 		Email:            emailService,
 		RootRepo:         rootRepo,
 		SyncService:      syncService,
 		Notification:     notificationService,
 		PushSubscription: pushSubscriptionService,
+		Audit:            auditService,
 		Broker:           sse.NewBroker(),
 	}
 
+	// Reload server_config.json on edits without restarting.
+	liveCfg := storage.NewConfig(serverCfg)
+	cfgWatcher, err := storage.NewConfigWatcher(*dataDir, liveCfg)
+	if err != nil {
+		return fmt.Errorf("failed to watch server config: %w", err)
+	}
+	go cfgWatcher.Run(ctx)
+
 	buildVersion, buildGoVersion, buildRevision, buildDirty := getBuildInfo()
 	cfg := &server.Config{
 		ServerConfig: serverCfg,
+		Live:         liveCfg,
 		DataDir:      *dataDir,
 		BaseURL:      *baseURL,
 		Version:      buildVersion,
@@ -471,6 +518,9 @@ func mainImpl() error {
 	// Start notification cleanup goroutine (runs once on startup, then daily).
 	go runNotificationCleanup(ctx, notificationService, rootRepo, &serverCfg.Quotas)
 
+	// Start workspace maintenance goroutine (runs daily).
+	go runWorkspaceMaintenance(ctx, gitMgr, fileStore)
+
 	// Run server in goroutine
 	serverErr := make(chan error, 1)
 	go func() {
@@ -737,6 +787,30 @@ func runNotificationCleanup(ctx context.Context, notifSvc *identity.Notification
 	}
 }
 
+// runWorkspaceMaintenance periodically packs the workspace git repositories,
+// which accumulate loose objects since every mutation is a commit, and logs
+// the links pointing to missing nodes or assets.
+func runWorkspaceMaintenance(ctx context.Context, gitMgr *git.Manager, fileStore *content.FileStoreService) {
+	ticker := time.NewTicker(24 * time.Hour)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			start := time.Now()
+			if err := gitMgr.MaintainAll(ctx); err != nil {
+				slog.WarnContext(ctx, "Git maintenance failed", "error", err)
+			} else {
+				slog.InfoContext(ctx, "Git maintenance", "duration", time.Since(start))
+			}
+			if err := fileStore.LogInvalidLinks(ctx); err != nil {
+				slog.WarnContext(ctx, "Link validation failed", "error", err)
+			}
+		}
+	}
+}
+
 // watchExecutable watches the current executable for modifications and calls
 // stop to trigger graceful shutdown when detected. This enables seamless
 // restarts during development.
@@ -12,10 +12,13 @@ import (
 	"fmt"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"strings"
 	"syscall"
+	"time"
 
 	"github.com/maruel/mddb/backend/internal/notion"
+	"github.com/maruel/mddb/backend/internal/storage/git"
 )
 
 func main() {
@@ -36,6 +39,12 @@ func run() error {
 	includeContent := flag.Bool("include-content", true, "Fetch page content (blocks)")
 	maxDepth := flag.Int("max-depth", 0, "Max nesting depth for blocks (0=unlimited)")
 	dryRun := flag.Bool("dry-run", false, "Show what would be imported without importing")
+	estimate := flag.Bool("estimate", false, "With -dry-run, estimate the API calls and asset size; makes the same requests as the import")
+	since := flag.String("since", "", "Only sync what was edited since this RFC 3339 time, e.g. the start of the previous import")
+	idMap := flag.String("id-map", "", "Notion to mddb ID mapping file kept between runs (default: notion_id_mapping.jsonl in the workspace)")
+	rps := flag.Float64("rps", notion.DefaultRequestsPerSecond, "Max Notion API requests per second (0=unlimited)")
+	concurrency := flag.Int("concurrency", notion.DefaultConcurrency, "Max concurrent Notion API requests, within the -rps limit")
+	progressMode := flag.String("progress", "text", "Progress output: text, or json for one JSON object per line on stdout")
 	flag.Parse()
 
 	// Validate required flags
@@ -50,6 +59,17 @@ func run() error {
 		return errors.New("--workspace is required")
 	}
 
+	var progress notion.ProgressReporter
+	switch *progressMode {
+	case "text":
+		progress = &notion.CLIProgress{Out: os.Stdout, Err: os.Stderr}
+	case "json":
+		progress = &notion.JSONProgress{Out: os.Stdout}
+	default:
+		return fmt.Errorf("--progress must be text or json, got %q", *progressMode)
+	}
+	text := *progressMode == "text"
+
 	// Parse multi-value flags
 	var dbIDs, pgIDs []string
 	if *databaseIDs != "" {
@@ -65,6 +85,14 @@ func run() error {
 		}
 	}
 
+	var syncSince time.Time
+	if *since != "" {
+		var err error
+		if syncSince, err = time.Parse(time.RFC3339, *since); err != nil {
+			return fmt.Errorf("--since: %w", err)
+		}
+	}
+
 	// Parse view manifest if provided
 	var manifest *notion.ViewManifest
 	if *viewsManifest != "" {
@@ -80,12 +108,8 @@ func run() error {
 	defer stop()
 
 	// Create client and extractor
-	client := notion.NewClient(*token)
-	writer := notion.NewWriter(*outputDir, *workspaceID)
-	progress := &notion.CLIProgress{
-		Out: os.Stdout,
-		Err: os.Stderr,
-	}
+	client := notion.NewClientWithOptions(*token, notion.ClientOptions{RequestsPerSecond: *rps})
+	writer := notion.NewWriterWithOptions(*outputDir, *workspaceID, notion.WriterOptions{IDMapPath: *idMap})
 	extractor := notion.NewExtractor(client, writer, progress)
 
 	opts := notion.ExtractOptions{
@@ -93,14 +117,19 @@ func run() error {
 		PageIDs:        pgIDs,
 		IncludeContent: *includeContent,
 		MaxDepth:       *maxDepth,
+		Concurrency:    *concurrency,
+		Estimate:       *estimate,
 		Manifest:       manifest,
+		SyncSince:      syncSince,
 	}
 
-	// Print header
-	fmt.Println("Notion Import")
-	fmt.Println("=============")
-	fmt.Println("Connecting to Notion API...")
-	fmt.Println()
+	// Print header; stdout only carries JSON lines in json mode.
+	if text {
+		fmt.Println("Notion Import")
+		fmt.Println("=============")
+		fmt.Println("Connecting to Notion API...")
+		fmt.Println()
+	}
 
 	// Dry run or full extraction
 	if *dryRun {
@@ -114,11 +143,18 @@ func run() error {
 
 	// Full extraction
 	stats, err := extractor.Extract(ctx, opts)
+	// Commit what was written, even on failure; the server discards
+	// uncommitted changes of a workspace when it starts.
+	if cerr := commitWorkspace(*outputDir, *workspaceID, stats); cerr != nil {
+		err = errors.Join(err, cerr)
+	}
 	if err != nil {
 		return fmt.Errorf("extraction failed: %w", err)
 	}
 
-	fmt.Printf("\nOutput: %s/%s/\n", *outputDir, *workspaceID)
+	if text {
+		fmt.Printf("\nOutput: %s/%s/\n", *outputDir, *workspaceID)
+	}
 
 	if stats.Errors > 0 {
 		return fmt.Errorf("%d errors occurred during import", stats.Errors)
@@ -126,3 +162,30 @@ func run() error {
 
 	return nil
 }
+
+// commitWorkspace commits the workspace directory when it is a git
+// repository, i.e. the workspace of an mddb data directory.
+func commitWorkspace(outputDir, workspaceID string, stats *notion.ExtractStats) error {
+	if _, err := os.Stat(filepath.Join(outputDir, workspaceID, ".git")); err != nil {
+		return nil //nolint:nilerr // not a git repository, nothing to commit
+	}
+	ctx := context.Background()
+	repo, err := git.NewManager(outputDir, "", "").Repo(ctx, workspaceID)
+	if err != nil {
+		return err
+	}
+	summary := "import: notion"
+	if stats != nil {
+		summary = fmt.Sprintf("import: notion, %d pages and %d databases", stats.Pages, stats.Databases)
+	}
+	err = repo.CommitTx(ctx, git.Author{}, func() (string, []string, error) {
+		return git.AppendTrailers(summary,
+			git.Trailer{Key: git.TrailerOp, Value: "import"},
+			git.Trailer{Key: git.TrailerType, Value: "node"},
+		), []string{"."}, nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to commit the import: %w", err)
+	}
+	return nil
+}
@@ -11,6 +11,7 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"slices"
 	"sort"
 	"strings"
 )
@@ -64,6 +65,7 @@ type HandlerSig struct {
 	MethodName   string
 	RequestType  string
 	ResponseType string
+	Doc          string // Doc comment, without comment markers
 }
 
 // DTOType represents a parsed request struct with its field tags.
@@ -98,6 +100,7 @@ type Endpoint struct {
 	HandlerName  string
 	RequestType  string
 	ResponseType string
+	Doc          string
 	PathFields   []FieldInfo
 	QueryFields  []FieldInfo
 	JSONFields   []FieldInfo
@@ -142,7 +145,7 @@ func parseHandlerSignatures(dir string) (map[string]*HandlerSig, error) {
 		}
 
 		path := filepath.Join(dir, entry.Name())
-		f, err := parser.ParseFile(fset, path, nil, 0)
+		f, err := parser.ParseFile(fset, path, nil, parser.ParseComments)
 		if err != nil {
 			return nil, fmt.Errorf("parse %s: %w", entry.Name(), err)
 		}
@@ -202,6 +205,7 @@ func extractSignature(fn *ast.FuncDecl) *HandlerSig {
 		MethodName:   fn.Name.Name,
 		RequestType:  reqType,
 		ResponseType: respType,
+		Doc:          strings.TrimSpace(fn.Doc.Text()),
 	}
 }
 
@@ -415,6 +419,11 @@ func matchEndpoints(routes []Route, dtoTypes map[string]*DTOType, handlerSigs ma
 			continue
 		}
 
+		if methodHelpers[r.Method] == "" {
+			errs = append(errs, "unsupported method "+r.Method+" for "+r.HandlerName)
+			continue
+		}
+
 		sig := handlerSigs[r.HandlerName]
 		if sig == nil {
 			errs = append(errs, "no handler signature found for "+r.HandlerName)
@@ -437,6 +446,7 @@ func matchEndpoints(routes []Route, dtoTypes map[string]*DTOType, handlerSigs ma
 			HandlerName:  r.HandlerName,
 			RequestType:  sig.RequestType,
 			ResponseType: sig.ResponseType,
+			Doc:          sig.Doc,
 			PathFields:   dto.PathFields,
 			QueryFields:  dto.QueryFields,
 			JSONFields:   dto.JSONFields,
@@ -452,6 +462,16 @@ func matchEndpoints(routes []Route, dtoTypes map[string]*DTOType, handlerSigs ma
 	return endpoints, nil
 }
 
+// methodHelpers maps HTTP methods to the generated client helper. delete is a
+// reserved word in TypeScript.
+var methodHelpers = map[string]string{
+	http.MethodGet:    "get",
+	http.MethodPost:   "post",
+	http.MethodPut:    "put",
+	http.MethodPatch:  "patch",
+	http.MethodDelete: "del",
+}
+
 // ScopeType represents the scope of an endpoint.
 type ScopeType int
 
@@ -687,8 +707,8 @@ export class APIError extends Error {
   }
 }
 
-async function get<T>(fetchFn: FetchFn, url: string): Promise<T> {
-  const res = await fetchFn(url);
+async function get<T>(fetchFn: FetchFn, url: string, signal?: AbortSignal): Promise<T> {
+  const res = await fetchFn(url, { signal });
   if (!res.ok) {
     const error = (await res.json()) as ErrorResponse;
     throw new APIError(res.status, error);
@@ -696,8 +716,8 @@ async function get<T>(fetchFn: FetchFn, url: string): Promise<T> {
   return res.json() as Promise<T>;
 }
 
-async function post<T>(fetchFn: FetchFn, url: string, body?: object): Promise<T> {
-  const init: RequestInit = { method: 'POST' };
+async function send<T>(fetchFn: FetchFn, method: string, url: string, body?: object, signal?: AbortSignal): Promise<T> {
+  const init: RequestInit = { method, signal };
   if (body) {
     init.headers = { 'Content-Type': 'application/json' };
     init.body = JSON.stringify(body);
@@ -712,6 +732,16 @@ async function post<T>(fetchFn: FetchFn, url: string, body?: object): Promise<T>
 
 `)
 
+	// One helper per HTTP method in use; unused ones would fail noUnusedLocals.
+	for _, m := range []string{http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete} {
+		if !slices.ContainsFunc(endpoints, func(ep *Endpoint) bool { return ep.Method == m }) {
+			continue
+		}
+		fmt.Fprintf(&b, "function %s<T>(fetchFn: FetchFn, url: string, body?: object, signal?: AbortSignal): Promise<T> {\n", methodHelpers[m])
+		fmt.Fprintf(&b, "  return send<T>(fetchFn, '%s', url, body, signal);\n", m)
+		b.WriteString("}\n\n")
+	}
+
 	// Build namespace trees
 	globalTree := buildNamespaceTree(endpoints, ScopeGlobal)
 	orgTree := buildNamespaceTree(endpoints, ScopeOrg)
@@ -786,10 +816,11 @@ func writeNamespaceNode(b *strings.Builder, node *NamespaceNode, indent string,
 	}
 }
 
-// writeMethod writes a single method using get/post helpers.
+// writeMethod writes a single method using the helper of its HTTP method.
 func writeMethod(b *strings.Builder, m *NamespaceMethod, indent string, scope ScopeType) {
 	ep := m.Endpoint
 	isGet := ep.Method == http.MethodGet
+	helper := methodHelpers[ep.Method]
 
 	// Build function signature
 	var params []string
@@ -804,8 +835,11 @@ func writeMethod(b *strings.Builder, m *NamespaceMethod, indent string, scope Sc
 	if hasQuery || hasBody {
 		params = append(params, "options: "+ep.RequestType)
 	}
+	// Every method can be canceled.
+	params = append(params, "signal?: AbortSignal")
 
 	paramStr := strings.Join(params, ", ")
+	writeJSDoc(b, ep.Doc, indent)
 
 	// Build URL template
 	urlTemplate := ep.Path
@@ -824,19 +858,19 @@ func writeMethod(b *strings.Builder, m *NamespaceMethod, indent string, scope Sc
 
 	// Simple GET: no query params
 	if isGet && !hasQuery {
-		fmt.Fprintf(b, "%s%s: (%s) => get<%s>(fetchFn, `%s`),\n", indent, m.Name, paramStr, ep.ResponseType, urlTemplate)
+		fmt.Fprintf(b, "%s%s: (%s) => get<%s>(fetchFn, `%s`, signal),\n", indent, m.Name, paramStr, ep.ResponseType, urlTemplate)
 		return
 	}
 
-	// Simple POST: no query params, no body
+	// Simple POST/PUT/PATCH/DELETE: no query params, no body
 	if !isGet && !hasQuery && !hasBody {
-		fmt.Fprintf(b, "%s%s: (%s) => post<%s>(fetchFn, `%s`),\n", indent, m.Name, paramStr, ep.ResponseType, urlTemplate)
+		fmt.Fprintf(b, "%s%s: (%s) => %s<%s>(fetchFn, `%s`, undefined, signal),\n", indent, m.Name, paramStr, helper, ep.ResponseType, urlTemplate)
 		return
 	}
 
-	// POST with body only (no query params): pass options directly
+	// Body only (no query params): pass options directly
 	if !isGet && hasBody && !hasQuery {
-		fmt.Fprintf(b, "%s%s: (%s) => post<%s>(fetchFn, `%s`, options),\n", indent, m.Name, paramStr, ep.ResponseType, urlTemplate)
+		fmt.Fprintf(b, "%s%s: (%s) => %s<%s>(fetchFn, `%s`, options, signal),\n", indent, m.Name, paramStr, helper, ep.ResponseType, urlTemplate)
 		return
 	}
 
@@ -854,11 +888,33 @@ func writeMethod(b *strings.Builder, m *NamespaceMethod, indent string, scope Sc
 
 	switch {
 	case isGet:
-		fmt.Fprintf(b, "%s  return get<%s>(fetchFn, url);\n", indent, ep.ResponseType)
+		fmt.Fprintf(b, "%s  return get<%s>(fetchFn, url, signal);\n", indent, ep.ResponseType)
 	case hasBody:
-		fmt.Fprintf(b, "%s  return post<%s>(fetchFn, url, options);\n", indent, ep.ResponseType)
+		fmt.Fprintf(b, "%s  return %s<%s>(fetchFn, url, options, signal);\n", indent, helper, ep.ResponseType)
 	default:
-		fmt.Fprintf(b, "%s  return post<%s>(fetchFn, url);\n", indent, ep.ResponseType)
+		fmt.Fprintf(b, "%s  return %s<%s>(fetchFn, url, undefined, signal);\n", indent, helper, ep.ResponseType)
 	}
 	fmt.Fprintf(b, "%s},\n", indent)
 }
+
+// writeJSDoc writes doc as a JSDoc block. Nothing is written for an empty doc.
+func writeJSDoc(b *strings.Builder, doc, indent string) {
+	if doc == "" {
+		return
+	}
+	doc = strings.ReplaceAll(doc, "*/", "*\\/")
+	lines := strings.Split(doc, "\n")
+	if len(lines) == 1 {
+		fmt.Fprintf(b, "%s/** %s */\n", indent, doc)
+		return
+	}
+	fmt.Fprintf(b, "%s/**\n", indent)
+	for _, line := range lines {
+		if line == "" {
+			fmt.Fprintf(b, "%s *\n", indent)
+		} else {
+			fmt.Fprintf(b, "%s * %s\n", indent, line)
+		}
+	}
+	fmt.Fprintf(b, "%s */\n", indent)
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "update the golden files")
+
+func TestGenerateTypeScript(t *testing.T) {
+	dtoTypes, err := parseDTOTypes("testdata/dto/request.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	handlerSigs, err := parseHandlerSignatures("testdata/handlers")
+	if err != nil {
+		t.Fatal(err)
+	}
+	routes, err := parseRoutes("testdata/router.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	endpoints, err := matchEndpoints(routes, dtoTypes, handlerSigs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := filepath.Join(t.TempDir(), "api.gen.ts")
+	if err := generateTypeScript(out, endpoints); err != nil {
+		t.Fatal(err)
+	}
+	got, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	golden := "testdata/api.gen.ts"
+	if *update {
+		if err := os.WriteFile(golden, got, 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != string(want) {
+		t.Errorf("generated output differs from %s; run go test -update and review the diff\n%s", golden, got)
+	}
+}
@@ -0,0 +1,46 @@
+package dto
+
+type HealthRequest struct{}
+
+type ListUsersRequest struct {
+	OrgID string `path:"orgID" tstype:"-"`
+}
+
+type RemoveUserRequest struct {
+	OrgID  string `path:"orgID" tstype:"-"`
+	UserID string `json:"user_id"`
+}
+
+type GetNodeRequest struct {
+	WsID string `path:"wsID" tstype:"-"`
+	ID   string `path:"id" tstype:"-"`
+}
+
+type SearchRequest struct {
+	WsID  string `path:"wsID" tstype:"-"`
+	Query string `query:"q" json:"q"`
+	Limit int    `query:"limit" json:"limit,omitempty"`
+}
+
+type ReplaceNodeRequest struct {
+	WsID    string `path:"wsID" tstype:"-"`
+	ID      string `path:"id" tstype:"-"`
+	Content string `json:"content"`
+}
+
+type PatchTitleRequest struct {
+	WsID   string `path:"wsID" tstype:"-"`
+	ID     string `path:"id" tstype:"-"`
+	DryRun bool   `query:"dry_run" json:"dry_run,omitempty"`
+	Title  string `json:"title"`
+}
+
+type DeleteNodeRequest struct {
+	WsID string `path:"wsID" tstype:"-"`
+	ID   string `path:"id" tstype:"-"`
+}
+
+type DeleteTagsRequest struct {
+	WsID string   `path:"wsID" tstype:"-"`
+	Tags []string `json:"tags"`
+}
@@ -0,0 +1,48 @@
+package handlers
+
+// GetHealth returns the server health.
+func (h *HealthHandler) GetHealth(ctx context.Context, req *dto.HealthRequest) (*dto.HealthResponse, error) {
+	return nil, nil
+}
+
+// ListUsers returns all users in the organization.
+//
+// Users are sorted by name. A comment closing */ is escaped.
+func (h *UserHandler) ListUsers(ctx context.Context, orgID ksid.ID, _ *identity.User, _ *dto.ListUsersRequest) (*dto.ListUsersResponse, error) {
+	return nil, nil
+}
+
+func (h *UserHandler) RemoveUser(ctx context.Context, orgID ksid.ID, _ *identity.User, _ *dto.RemoveUserRequest) (*dto.OkResponse, error) {
+	return nil, nil
+}
+
+// GetNode retrieves a node's metadata
+// including its children.
+func (h *NodeHandler) GetNode(ctx context.Context, wsID ksid.ID, _ *identity.User, _ *dto.GetNodeRequest) (*dto.NodeResponse, error) {
+	return nil, nil
+}
+
+// Search searches the workspace.
+func (h *NodeHandler) Search(ctx context.Context, wsID ksid.ID, _ *identity.User, _ *dto.SearchRequest) (*dto.SearchResponse, error) {
+	return nil, nil
+}
+
+// ReplaceNode replaces a node's content.
+func (h *NodeHandler) ReplaceNode(ctx context.Context, wsID ksid.ID, _ *identity.User, _ *dto.ReplaceNodeRequest) (*dto.NodeResponse, error) {
+	return nil, nil
+}
+
+// PatchTitle renames a node.
+func (h *NodeHandler) PatchTitle(ctx context.Context, wsID ksid.ID, _ *identity.User, _ *dto.PatchTitleRequest) (*dto.NodeResponse, error) {
+	return nil, nil
+}
+
+// DeleteNode deletes a node.
+func (h *NodeHandler) DeleteNode(ctx context.Context, wsID ksid.ID, _ *identity.User, _ *dto.DeleteNodeRequest) (*dto.OkResponse, error) {
+	return nil, nil
+}
+
+// DeleteTags removes tags from the workspace.
+func (h *NodeHandler) DeleteTags(ctx context.Context, wsID ksid.ID, _ *identity.User, _ *dto.DeleteTagsRequest) (*dto.OkResponse, error) {
+	return nil, nil
+}
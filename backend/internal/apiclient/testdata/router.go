@@ -0,0 +1,13 @@
+package server
+
+func newRouter() {
+	mux.Handle("GET /api/v1/health", Wrap(healthh.GetHealth))
+	mux.Handle("GET /api/v1/organizations/{orgID}/users", WrapOrgAuth(userh.ListUsers, svc, hcfg, limiters))
+	mux.Handle("POST /api/v1/organizations/{orgID}/users/remove", WrapOrgAuth(userh.RemoveUser, svc, hcfg, limiters))
+	mux.Handle("GET /api/v1/workspaces/{wsID}/nodes/{id}", WrapWSAuth(nodeh.GetNode, svc, hcfg, limiters))
+	mux.Handle("GET /api/v1/workspaces/{wsID}/search", WrapWSAuth(nodeh.Search, svc, hcfg, limiters))
+	mux.Handle("PUT /api/v1/workspaces/{wsID}/nodes/{id}", WrapWSAuth(nodeh.ReplaceNode, svc, hcfg, limiters))
+	mux.Handle("PATCH /api/v1/workspaces/{wsID}/nodes/{id}/title", WrapWSAuth(nodeh.PatchTitle, svc, hcfg, limiters))
+	mux.Handle("DELETE /api/v1/workspaces/{wsID}/nodes/{id}", WrapWSAuth(nodeh.DeleteNode, svc, hcfg, limiters))
+	mux.Handle("DELETE /api/v1/workspaces/{wsID}/tags", WrapWSAuth(nodeh.DeleteTags, svc, hcfg, limiters))
+}
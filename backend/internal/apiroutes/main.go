@@ -7,7 +7,9 @@ import (
 	"go/ast"
 	"go/parser"
 	"go/token"
+	"io"
 	"os"
+	"path/filepath"
 	"sort"
 	"strings"
 )
@@ -19,19 +21,59 @@ type route struct {
 	Path    string
 	Role    string
 	Handler string
+	// Request and Response are the DTO type names, empty for raw handlers.
+	Request  string
+	Response string
 }
 
 func main() {
 	flag.Parse()
 	// Paths relative to backend/internal/server/ where go:generate runs.
 	routerPath := "router.go"
+	handlersDir := "handlers"
 
-	fset := token.NewFileSet()
-	f, err := parser.ParseFile(fset, routerPath, nil, parser.ParseComments)
+	routes, err := parseRoutes(routerPath)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "parse error: %v\n", err)
 		os.Exit(1)
 	}
+	sigs, err := parseHandlerDTOs(handlersDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "parse handlers error: %v\n", err)
+		os.Exit(1)
+	}
+	matchDTOs(routes, sigs)
+
+	// Group routes by prefix
+	groups := groupRoutes(routes)
+
+	outPath := "../../../sdk/API.md"
+	out, err := os.Create(outPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "create file: %v\n", err)
+		os.Exit(1)
+	}
+	if err := writeMarkdown(out, groups); err != nil {
+		fmt.Fprintf(os.Stderr, "write error: %v\n", err)
+		os.Exit(1)
+	}
+	if err := out.Close(); err != nil {
+		fmt.Fprintf(os.Stderr, "close file: %v\n", err)
+		os.Exit(1)
+	}
+	if !*quiet {
+		fmt.Fprintf(os.Stderr, "Generated sdk/API.md with %d routes\n", len(routes))
+	}
+}
+
+// parseRoutes returns the routes registered in the router file, sorted by
+// path then method.
+func parseRoutes(path string) ([]route, error) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, path, nil, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
 
 	var routes []route
 
@@ -74,26 +116,86 @@ func main() {
 		}
 		return routes[i].Method < routes[j].Method
 	})
+	return routes, nil
+}
 
-	// Group routes by prefix
-	groups := groupRoutes(routes)
+// handlerDTOs is the request and response DTO type names of a handler method.
+type handlerDTOs struct {
+	Request  string
+	Response string
+}
 
-	outPath := "../../../sdk/API.md"
-	out, err := os.Create(outPath)
+// parseHandlerDTOs returns the DTO types used by each handler method in dir,
+// keyed by method name. Handlers not taking a *dto.XxxRequest, like raw HTTP
+// handlers, are omitted.
+func parseHandlerDTOs(dir string) (map[string]handlerDTOs, error) {
+	entries, err := os.ReadDir(dir)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "create file: %v\n", err)
-		os.Exit(1)
+		return nil, err
 	}
-	if err := writeMarkdown(out, groups); err != nil {
-		fmt.Fprintf(os.Stderr, "write error: %v\n", err)
-		os.Exit(1)
+	sigs := make(map[string]handlerDTOs)
+	fset := token.NewFileSet()
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".go") || strings.HasSuffix(entry.Name(), "_test.go") {
+			continue
+		}
+		f, err := parser.ParseFile(fset, filepath.Join(dir, entry.Name()), nil, 0)
+		if err != nil {
+			return nil, fmt.Errorf("parse %s: %w", entry.Name(), err)
+		}
+		for _, decl := range f.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok || fn.Recv == nil || fn.Type.Results == nil {
+				continue
+			}
+			var sig handlerDTOs
+			for _, p := range fn.Type.Params.List {
+				if name := dtoName(p.Type); strings.HasSuffix(name, "Request") {
+					sig.Request = name
+				}
+			}
+			if sig.Request == "" {
+				continue
+			}
+			for _, r := range fn.Type.Results.List {
+				if name := dtoName(r.Type); name != "" {
+					sig.Response = name
+					break
+				}
+			}
+			sigs[fn.Name.Name] = sig
+		}
 	}
-	if err := out.Close(); err != nil {
-		fmt.Fprintf(os.Stderr, "close file: %v\n", err)
-		os.Exit(1)
+	return sigs, nil
+}
+
+// dtoName returns Foo for *dto.Foo and "" otherwise.
+func dtoName(e ast.Expr) string {
+	star, ok := e.(*ast.StarExpr)
+	if !ok {
+		return ""
 	}
-	if !*quiet {
-		fmt.Fprintf(os.Stderr, "Generated sdk/API.md with %d routes\n", len(routes))
+	sel, ok := star.X.(*ast.SelectorExpr)
+	if !ok {
+		return ""
+	}
+	if x, ok := sel.X.(*ast.Ident); !ok || x.Name != "dto" {
+		return ""
+	}
+	return sel.Sel.Name
+}
+
+// matchDTOs fills the request and response types of routes from the handler
+// signatures.
+func matchDTOs(routes []route, sigs map[string]handlerDTOs) {
+	for i := range routes {
+		name := routes[i].Handler
+		if j := strings.LastIndexByte(name, '.'); j >= 0 {
+			name = name[j+1:]
+		}
+		sig := sigs[name]
+		routes[i].Request = sig.Request
+		routes[i].Response = sig.Response
 	}
 }
 
@@ -217,7 +319,7 @@ func categorize(path string) string {
 	}
 }
 
-func writeMarkdown(out *os.File, groups []routeGroup) error {
+func writeMarkdown(out io.Writer, groups []routeGroup) error {
 	lines := []string{
 		"# mddb API Reference",
 		"",
@@ -245,14 +347,14 @@ func writeMarkdown(out *os.File, groups []routeGroup) error {
 		if _, err := fmt.Fprintf(out, "## %s\n\n", g.Name); err != nil {
 			return err
 		}
-		if _, err := fmt.Fprintln(out, "| Method | Path | Auth |"); err != nil {
+		if _, err := fmt.Fprintln(out, "| Method | Path | Auth | Request | Response |"); err != nil {
 			return err
 		}
-		if _, err := fmt.Fprintln(out, "|--------|------|------|"); err != nil {
+		if _, err := fmt.Fprintln(out, "|--------|------|------|---------|----------|"); err != nil {
 			return err
 		}
 		for _, r := range g.Routes {
-			if _, err := fmt.Fprintf(out, "| %s | `%s` | %s |\n", r.Method, r.Path, r.Role); err != nil {
+			if _, err := fmt.Fprintf(out, "| %s | `%s` | %s | %s | %s |\n", r.Method, r.Path, r.Role, dtoCell(r.Request), dtoCell(r.Response)); err != nil {
 				return err
 			}
 		}
@@ -262,3 +364,11 @@ func writeMarkdown(out *os.File, groups []routeGroup) error {
 	}
 	return nil
 }
+
+// dtoCell formats a DTO type name for a table cell.
+func dtoCell(name string) string {
+	if name == "" {
+		return "—"
+	}
+	return "`" + name + "`"
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWriteMarkdown(t *testing.T) {
+	routes, err := parseRoutes("testdata/router.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sigs, err := parseHandlerDTOs("testdata/handlers")
+	if err != nil {
+		t.Fatal(err)
+	}
+	matchDTOs(routes, sigs)
+	var b strings.Builder
+	if err := writeMarkdown(&b, groupRoutes(routes)); err != nil {
+		t.Fatal(err)
+	}
+	got := b.String()
+	for _, want := range []string{
+		"| Method | Path | Auth | Request | Response |\n",
+		"| POST | `/api/v1/auth/login` | public | `LoginRequest` | `AuthResponse` |\n",
+		"| GET | `/api/v1/organizations/{orgID}/users` | org:Admin | `ListUsersRequest` | `ListUsersResponse` |\n",
+		"| GET | `/api/v1/workspaces/{wsID}/nodes/{id}` | ws:Viewer | `GetNodeRequest` | `NodeResponse` |\n",
+		"| GET | `/api/v1/auth/email/verify` | public | — | — |\n",
+		"| GET | `/api/v1/workspaces/{wsID}/pages/{id}/assets/{name}` | ws:Viewer | — | — |\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("missing %q in:\n%s", want, got)
+		}
+	}
+}
@@ -0,0 +1,23 @@
+package handlers
+
+func (h *HealthHandler) GetHealth(ctx context.Context, req *dto.HealthRequest) (*dto.HealthResponse, error) {
+	return nil, nil
+}
+
+func (h *UserHandler) ListUsers(ctx context.Context, orgID ksid.ID, _ *identity.User, _ *dto.ListUsersRequest) (*dto.ListUsersResponse, error) {
+	return nil, nil
+}
+
+func (h *AuthHandler) Login(ctx context.Context, req *dto.LoginRequest) (*dto.AuthResponse, error) {
+	return nil, nil
+}
+
+func (h *AuthHandler) VerifyEmail(w http.ResponseWriter, r *http.Request) {
+}
+
+func (h *NodeHandler) GetNode(ctx context.Context, wsID ksid.ID, _ *identity.User, _ *dto.GetNodeRequest) (*dto.NodeResponse, error) {
+	return nil, nil
+}
+
+func (h *AssetHandler) Serve(w http.ResponseWriter, r *http.Request, wsID ksid.ID, _ *identity.User) {
+}
@@ -0,0 +1,10 @@
+package server
+
+func newRouter() {
+	mux.Handle("GET /api/v1/health", Wrap(healthh.GetHealth))
+	mux.Handle("GET /api/v1/organizations/{orgID}/users", WrapOrgAuth(userh.ListUsers, svc, hcfg, identity.OrgRoleAdmin, limiters))
+	mux.Handle("POST /api/v1/auth/login", Wrap(authh.Login))
+	mux.Handle("GET /api/v1/workspaces/{wsID}/nodes/{id}", WrapWSAuth(nodeh.GetNode, svc, hcfg, identity.WSRoleViewer, limiters))
+	mux.HandleFunc("GET /api/v1/auth/email/verify", authh.VerifyEmail)
+	mux.Handle("GET /api/v1/workspaces/{wsID}/pages/{id}/assets/{name}", WrapAuthRaw(assetsh.Serve, svc, hcfg, identity.WSRoleViewer, limiters))
+}
@@ -4,13 +4,18 @@
 package email
 
 import (
+	"bytes"
 	"context"
 	"crypto/tls"
 	"errors"
 	"fmt"
 	"log/slog"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
 	"net"
 	"net/smtp"
+	"net/textproto"
 	"strconv"
 	"strings"
 	"time"
@@ -57,41 +62,142 @@ func (c *Config) Validate() error {
 	return nil
 }
 
+// Sender delivers an already formatted message.
+//
+// The default sender speaks SMTP; tests can inject a fake.
+type Sender interface {
+	SendMail(ctx context.Context, from string, to []string, msg []byte) error
+}
+
+// Permanent marks err as not worth retrying, like a rejected recipient.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
 // Service provides email sending functionality.
 type Service struct {
 	Config Config
+	// Sender delivers messages. Defaults to SMTP using Config.
+	Sender Sender
+	// MaxAttempts is the number of delivery attempts on transient failures.
+	// Defaults to 3.
+	MaxAttempts int
+	// Backoff is the delay before the first retry, doubled after each
+	// attempt. Defaults to 1s.
+	Backoff time.Duration
+	// Templates renders the verification and invitation emails. Defaults to
+	// the built-in templates.
+	Templates *Templates
 }
 
-// Send sends an email.
+// Send sends a plain text email.
 func (s *Service) Send(ctx context.Context, to, subject, body string) error {
-	return s.sendMail(ctx, []string{to}, subject, body)
+	return s.sendMail(ctx, []string{to}, &Message{Subject: subject, Text: body})
 }
 
 // SendVerification sends an email verification email with a magic link.
 func (s *Service) SendVerification(ctx context.Context, to, name, verifyURL string, locale Locale) error {
-	subject, body := VerificationEmail(locale, name, verifyURL)
-	return s.Send(ctx, to, subject, body)
+	return s.sendTemplate(ctx, to, TemplateVerification, locale, &VerificationData{Name: name, URL: verifyURL})
 }
 
 // SendOrgInvitation sends an organization invitation email.
 func (s *Service) SendOrgInvitation(ctx context.Context, to, orgName, inviterName, role, acceptURL string, locale Locale) error {
-	subject, body := OrgInvitationEmail(locale, orgName, inviterName, role, acceptURL)
-	return s.Send(ctx, to, subject, body)
+	return s.sendTemplate(ctx, to, TemplateOrgInvitation, locale, &OrgInvitationData{
+		OrgName: orgName, InviterName: inviterName, Role: role, URL: acceptURL,
+	})
 }
 
 // SendWSInvitation sends a workspace invitation email.
 func (s *Service) SendWSInvitation(ctx context.Context, to, wsName, orgName, inviterName, role, acceptURL string, locale Locale) error {
-	subject, body := WSInvitationEmail(locale, wsName, orgName, inviterName, role, acceptURL)
-	return s.Send(ctx, to, subject, body)
+	return s.sendTemplate(ctx, to, TemplateWSInvitation, locale, &WSInvitationData{
+		WSName: wsName, OrgName: orgName, InviterName: inviterName, Role: role, URL: acceptURL,
+	})
 }
 
-// SendMultiple sends an email to multiple recipients.
+// SendMultiple sends a plain text email to multiple recipients.
 func (s *Service) SendMultiple(ctx context.Context, to []string, subject, body string) error {
-	return s.sendMail(ctx, to, subject, body)
+	return s.sendMail(ctx, to, &Message{Subject: subject, Text: body})
+}
+
+func (s *Service) sendTemplate(ctx context.Context, to, name string, locale Locale, data any) error {
+	msg, err := s.Templates.Render(name, locale, data)
+	if err != nil {
+		return err
+	}
+	return s.sendMail(ctx, []string{to}, msg)
+}
+
+// sendMail delivers the message, retrying transient failures with exponential
+// backoff.
+func (s *Service) sendMail(ctx context.Context, to []string, m *Message) error {
+	sender := s.Sender
+	if sender == nil {
+		sender = &smtpSender{config: s.Config}
+	}
+	attempts := s.MaxAttempts
+	if attempts <= 0 {
+		attempts = 3
+	}
+	delay := s.Backoff
+	if delay <= 0 {
+		delay = time.Second
+	}
+	msg, err := buildMessage(s.Config.From, to, m)
+	if err != nil {
+		return err
+	}
+	for i := 1; ; i++ {
+		err := sender.SendMail(ctx, s.Config.From, to, msg)
+		if err == nil {
+			slog.InfoContext(ctx, "Email sent", "to", to, "subject", m.Subject)
+			return nil
+		}
+		if i >= attempts || isPermanent(err) {
+			return err
+		}
+		slog.WarnContext(ctx, "Email send failed, retrying", "to", to, "attempt", i, "err", err)
+		select {
+		case <-ctx.Done():
+			return errors.Join(err, ctx.Err())
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+}
+
+// isPermanent returns true if retrying err cannot succeed: errors marked with
+// Permanent and SMTP 5xx replies.
+func isPermanent(err error) bool {
+	var p *permanentError
+	if errors.As(err, &p) {
+		return true
+	}
+	var tp *textproto.Error
+	return errors.As(err, &tp) && tp.Code >= 500
+}
+
+type permanentError struct {
+	err error
 }
 
-func (s *Service) sendMail(ctx context.Context, to []string, subject, body string) error {
-	addr := net.JoinHostPort(s.Config.Host, strconv.Itoa(int(s.Config.Port)))
+func (e *permanentError) Error() string {
+	return e.err.Error()
+}
+
+func (e *permanentError) Unwrap() error {
+	return e.err
+}
+
+// smtpSender sends messages over SMTP with STARTTLS and authentication.
+type smtpSender struct {
+	config Config
+}
+
+func (s *smtpSender) SendMail(ctx context.Context, from string, to []string, msg []byte) error {
+	addr := net.JoinHostPort(s.config.Host, strconv.Itoa(int(s.config.Port)))
 
 	// Connect with timeout
 	dialer := &net.Dialer{Timeout: 10 * time.Second}
@@ -100,7 +206,7 @@ func (s *Service) sendMail(ctx context.Context, to []string, subject, body strin
 		return fmt.Errorf("dial: %w", err)
 	}
 
-	client, err := smtp.NewClient(conn, s.Config.Host)
+	client, err := smtp.NewClient(conn, s.config.Host)
 	if err != nil {
 		_ = conn.Close()
 		return fmt.Errorf("smtp client: %w", err)
@@ -113,7 +219,7 @@ func (s *Service) sendMail(ctx context.Context, to []string, subject, body strin
 
 	// STARTTLS
 	tlsConfig := &tls.Config{
-		ServerName: s.Config.Host,
+		ServerName: s.config.Host,
 		MinVersion: tls.VersionTLS12,
 	}
 	if err := client.StartTLS(tlsConfig); err != nil {
@@ -121,13 +227,13 @@ func (s *Service) sendMail(ctx context.Context, to []string, subject, body strin
 	}
 
 	// Auth
-	auth := smtp.PlainAuth("", s.Config.Username, s.Config.Password, s.Config.Host)
+	auth := smtp.PlainAuth("", s.config.Username, s.config.Password, s.config.Host)
 	if err := client.Auth(auth); err != nil {
 		return fmt.Errorf("auth: %w", err)
 	}
 
 	// Set sender
-	if err := client.Mail(s.Config.From); err != nil {
+	if err := client.Mail(from); err != nil {
 		return fmt.Errorf("mail from: %w", err)
 	}
 
@@ -143,33 +249,55 @@ func (s *Service) sendMail(ctx context.Context, to []string, subject, body strin
 	if err != nil {
 		return fmt.Errorf("data: %w", err)
 	}
-
-	msg := buildMessage(s.Config.From, to, subject, body)
-	if _, err := w.Write([]byte(msg)); err != nil {
+	if _, err := w.Write(msg); err != nil {
 		return fmt.Errorf("write: %w", err)
 	}
 	if err := w.Close(); err != nil {
 		return fmt.Errorf("close: %w", err)
 	}
-
-	slog.InfoContext(ctx, "Email sent", "to", to, "subject", subject)
 	return nil
 }
 
-func buildMessage(from string, to []string, subject, body string) string {
-	var sb strings.Builder
-	sb.WriteString("From: ")
-	sb.WriteString(from)
-	sb.WriteString("\r\n")
-	sb.WriteString("To: ")
-	sb.WriteString(strings.Join(to, ", "))
-	sb.WriteString("\r\n")
-	sb.WriteString("Subject: ")
-	sb.WriteString(subject)
-	sb.WriteString("\r\n")
-	sb.WriteString("MIME-Version: 1.0\r\n")
-	sb.WriteString("Content-Type: text/plain; charset=\"utf-8\"\r\n")
-	sb.WriteString("\r\n")
-	sb.WriteString(body)
-	return sb.String()
+// buildMessage formats m as a MIME message, multipart/alternative when it has
+// an HTML body.
+func buildMessage(from string, to []string, m *Message) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString("From: " + from + "\r\n")
+	buf.WriteString("To: " + strings.Join(to, ", ") + "\r\n")
+	buf.WriteString("Subject: " + mime.QEncoding.Encode("utf-8", m.Subject) + "\r\n")
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	if m.HTML == "" {
+		buf.WriteString("Content-Type: text/plain; charset=\"utf-8\"\r\n")
+		buf.WriteString("\r\n")
+		buf.WriteString(m.Text)
+		return buf.Bytes(), nil
+	}
+
+	mw := multipart.NewWriter(&buf)
+	buf.WriteString("Content-Type: multipart/alternative; boundary=\"" + mw.Boundary() + "\"\r\n")
+	buf.WriteString("\r\n")
+	// Clients show the last part they support, so HTML comes last.
+	for _, part := range []struct{ contentType, body string }{
+		{"text/plain; charset=\"utf-8\"", m.Text},
+		{"text/html; charset=\"utf-8\"", m.HTML},
+	} {
+		w, err := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		qp := quotedprintable.NewWriter(w)
+		if _, err := qp.Write([]byte(part.body)); err != nil {
+			return nil, err
+		}
+		if err := qp.Close(); err != nil {
+			return nil, err
+		}
+	}
+	if err := mw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
 }
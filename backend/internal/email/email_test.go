@@ -0,0 +1,86 @@
+package email
+
+import (
+	"context"
+	"errors"
+	"net/textproto"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeSender fails with errs in order, then succeeds.
+type fakeSender struct {
+	errs  []error
+	calls int
+	msg   []byte
+}
+
+func (f *fakeSender) SendMail(_ context.Context, _ string, _ []string, msg []byte) error {
+	f.calls++
+	f.msg = msg
+	if len(f.errs) == 0 {
+		return nil
+	}
+	err := f.errs[0]
+	f.errs = f.errs[1:]
+	return err
+}
+
+func TestSend(t *testing.T) {
+	transient := errors.New("connection reset")
+
+	t.Run("RetryTransient", func(t *testing.T) {
+		sender := &fakeSender{errs: []error{transient, transient}}
+		s := &Service{Config: Config{From: "mddb@example.com"}, Sender: sender, Backoff: time.Millisecond}
+		if err := s.Send(t.Context(), "joe@example.com", "Hi", "body"); err != nil {
+			t.Fatalf("Send failed: %v", err)
+		}
+		if sender.calls != 3 {
+			t.Errorf("calls = %d, want 3", sender.calls)
+		}
+		if msg := string(sender.msg); !strings.Contains(msg, "To: joe@example.com\r\n") || !strings.HasSuffix(msg, "\r\n\r\nbody") {
+			t.Errorf("unexpected message:\n%s", msg)
+		}
+	})
+
+	t.Run("MaxAttempts", func(t *testing.T) {
+		sender := &fakeSender{errs: []error{transient, transient, transient}}
+		s := &Service{Sender: sender, MaxAttempts: 2, Backoff: time.Millisecond}
+		if err := s.Send(t.Context(), "joe@example.com", "Hi", "body"); !errors.Is(err, transient) {
+			t.Fatalf("Send = %v, want %v", err, transient)
+		}
+		if sender.calls != 2 {
+			t.Errorf("calls = %d, want 2", sender.calls)
+		}
+	})
+
+	t.Run("Permanent", func(t *testing.T) {
+		for _, err := range []error{
+			Permanent(errors.New("bad recipient")),
+			&textproto.Error{Code: 550, Msg: "mailbox unavailable"},
+		} {
+			sender := &fakeSender{errs: []error{err}}
+			s := &Service{Sender: sender, Backoff: time.Millisecond}
+			if got := s.Send(t.Context(), "nobody@example.com", "Hi", "body"); !errors.Is(got, err) {
+				t.Errorf("Send = %v, want %v", got, err)
+			}
+			if sender.calls != 1 {
+				t.Errorf("%v: calls = %d, want 1", err, sender.calls)
+			}
+		}
+	})
+
+	t.Run("Canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(t.Context())
+		cancel()
+		sender := &fakeSender{errs: []error{transient}}
+		s := &Service{Sender: sender, Backoff: time.Hour}
+		if err := s.Send(ctx, "joe@example.com", "Hi", "body"); !errors.Is(err, context.Canceled) {
+			t.Errorf("Send = %v, want context.Canceled", err)
+		}
+		if sender.calls != 1 {
+			t.Errorf("calls = %d, want 1", sender.calls)
+		}
+	})
+}
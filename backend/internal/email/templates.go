@@ -1,8 +1,18 @@
-// Provides localized email templates.
+// Provides localized email templates, overridable from a directory.
 
 package email
 
-import "fmt"
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	texttemplate "text/template"
+)
 
 // Locale represents a supported language code.
 type Locale string
@@ -28,29 +38,53 @@ func ParseLocale(s string) Locale {
 	}
 }
 
-// emailTemplates holds localized email content.
-type emailTemplates struct {
-	// Email verification
-	VerificationSubject string
-	VerificationBody    string
+// Template names.
+const (
+	TemplateVerification  = "verification"
+	TemplateOrgInvitation = "org_invitation"
+	TemplateWSInvitation  = "ws_invitation"
+)
+
+// VerificationData is the data of the verification template.
+type VerificationData struct {
+	Name string
+	URL  string
+}
+
+// OrgInvitationData is the data of the organization invitation template.
+type OrgInvitationData struct {
+	OrgName     string
+	InviterName string
+	Role        string
+	URL         string
+}
 
-	// Organization invitation
-	OrgInvitationSubject string
-	OrgInvitationBody    string
+// WSInvitationData is the data of the workspace invitation template.
+type WSInvitationData struct {
+	WSName      string
+	OrgName     string
+	InviterName string
+	Role        string
+	URL         string
+}
 
-	// Workspace invitation
-	WSInvitationSubject string
-	WSInvitationBody    string
+// Message is a rendered email.
+type Message struct {
+	Subject string
+	Text    string
+	// HTML is the alternative HTML body. Empty sends a plain text email.
+	HTML string
 }
 
-var templates = map[Locale]*emailTemplates{
+// builtinSources holds the localized text templates. Each defines a
+// "subject" template; the rest is the plain text body.
+var builtinSources = map[Locale]map[string]string{
 	LocaleEN: {
-		VerificationSubject: "Verify your email address",
-		VerificationBody: `Hi %s,
+		TemplateVerification: `{{define "subject"}}Verify your email address{{end}}Hi {{.Name}},
 
 Please verify your email address by clicking the link below:
 
-%s
+{{.URL}}
 
 This link will expire in 24 hours.
 
@@ -58,14 +92,13 @@ If you didn't request this verification, you can safely ignore this email.
 
 - The mddb Team
 `,
-		OrgInvitationSubject: "You've been invited to join %s",
-		OrgInvitationBody: `Hi,
+		TemplateOrgInvitation: `{{define "subject"}}You've been invited to join {{.OrgName}}{{end}}Hi,
 
-%s has invited you to join the organization "%s" as %s.
+{{.InviterName}} has invited you to join the organization "{{.OrgName}}" as {{.Role}}.
 
 Click the link below to accept the invitation:
 
-%s
+{{.URL}}
 
 This invitation will expire in 7 days.
 
@@ -73,14 +106,13 @@ If you weren't expecting this invitation, you can safely ignore this email.
 
 - The mddb Team
 `,
-		WSInvitationSubject: "You've been invited to join %s",
-		WSInvitationBody: `Hi,
+		TemplateWSInvitation: `{{define "subject"}}You've been invited to join {{.WSName}}{{end}}Hi,
 
-%s has invited you to join the workspace "%s" (in organization "%s") as %s.
+{{.InviterName}} has invited you to join the workspace "{{.WSName}}" (in organization "{{.OrgName}}") as {{.Role}}.
 
 Click the link below to accept the invitation:
 
-%s
+{{.URL}}
 
 This invitation will expire in 7 days.
 
@@ -90,12 +122,11 @@ If you weren't expecting this invitation, you can safely ignore this email.
 `,
 	},
 	LocaleFR: {
-		VerificationSubject: "Vérifiez votre adresse e-mail",
-		VerificationBody: `Bonjour %s,
+		TemplateVerification: `{{define "subject"}}Vérifiez votre adresse e-mail{{end}}Bonjour {{.Name}},
 
 Veuillez vérifier votre adresse e-mail en cliquant sur le lien ci-dessous :
 
-%s
+{{.URL}}
 
 Ce lien expirera dans 24 heures.
 
@@ -103,14 +134,13 @@ Si vous n'avez pas demandé cette vérification, vous pouvez ignorer cet e-mail.
 
 - L'équipe mddb
 `,
-		OrgInvitationSubject: "Vous avez été invité(e) à rejoindre %s",
-		OrgInvitationBody: `Bonjour,
+		TemplateOrgInvitation: `{{define "subject"}}Vous avez été invité(e) à rejoindre {{.OrgName}}{{end}}Bonjour,
 
-%s vous a invité(e) à rejoindre l'organisation « %s » en tant que %s.
+{{.InviterName}} vous a invité(e) à rejoindre l'organisation « {{.OrgName}} » en tant que {{.Role}}.
 
 Cliquez sur le lien ci-dessous pour accepter l'invitation :
 
-%s
+{{.URL}}
 
 Cette invitation expirera dans 7 jours.
 
@@ -118,14 +148,13 @@ Si vous n'attendiez pas cette invitation, vous pouvez ignorer cet e-mail.
 
 - L'équipe mddb
 `,
-		WSInvitationSubject: "Vous avez été invité(e) à rejoindre %s",
-		WSInvitationBody: `Bonjour,
+		TemplateWSInvitation: `{{define "subject"}}Vous avez été invité(e) à rejoindre {{.WSName}}{{end}}Bonjour,
 
-%s vous a invité(e) à rejoindre l'espace de travail « %s » (dans l'organisation « %s ») en tant que %s.
+{{.InviterName}} vous a invité(e) à rejoindre l'espace de travail « {{.WSName}} » (dans l'organisation « {{.OrgName}} ») en tant que {{.Role}}.
 
 Cliquez sur le lien ci-dessous pour accepter l'invitation :
 
-%s
+{{.URL}}
 
 Cette invitation expirera dans 7 jours.
 
@@ -135,12 +164,11 @@ Si vous n'attendiez pas cette invitation, vous pouvez ignorer cet e-mail.
 `,
 	},
 	LocaleDE: {
-		VerificationSubject: "Bestätigen Sie Ihre E-Mail-Adresse",
-		VerificationBody: `Hallo %s,
+		TemplateVerification: `{{define "subject"}}Bestätigen Sie Ihre E-Mail-Adresse{{end}}Hallo {{.Name}},
 
 Bitte bestätigen Sie Ihre E-Mail-Adresse, indem Sie auf den folgenden Link klicken:
 
-%s
+{{.URL}}
 
 Dieser Link läuft in 24 Stunden ab.
 
@@ -148,14 +176,13 @@ Wenn Sie diese Bestätigung nicht angefordert haben, können Sie diese E-Mail ig
 
 - Das mddb-Team
 `,
-		OrgInvitationSubject: "Sie wurden eingeladen, %s beizutreten",
-		OrgInvitationBody: `Hallo,
+		TemplateOrgInvitation: `{{define "subject"}}Sie wurden eingeladen, {{.OrgName}} beizutreten{{end}}Hallo,
 
-%s hat Sie eingeladen, der Organisation „%s" als %s beizutreten.
+{{.InviterName}} hat Sie eingeladen, der Organisation „{{.OrgName}}" als {{.Role}} beizutreten.
 
 Klicken Sie auf den folgenden Link, um die Einladung anzunehmen:
 
-%s
+{{.URL}}
 
 Diese Einladung läuft in 7 Tagen ab.
 
@@ -163,14 +190,13 @@ Wenn Sie diese Einladung nicht erwartet haben, können Sie diese E-Mail ignorier
 
 - Das mddb-Team
 `,
-		WSInvitationSubject: "Sie wurden eingeladen, %s beizutreten",
-		WSInvitationBody: `Hallo,
+		TemplateWSInvitation: `{{define "subject"}}Sie wurden eingeladen, {{.WSName}} beizutreten{{end}}Hallo,
 
-%s hat Sie eingeladen, dem Arbeitsbereich „%s" (in der Organisation „%s") als %s beizutreten.
+{{.InviterName}} hat Sie eingeladen, dem Arbeitsbereich „{{.WSName}}" (in der Organisation „{{.OrgName}}") als {{.Role}} beizutreten.
 
 Klicken Sie auf den folgenden Link, um die Einladung anzunehmen:
 
-%s
+{{.URL}}
 
 Diese Einladung läuft in 7 Tagen ab.
 
@@ -180,12 +206,11 @@ Wenn Sie diese Einladung nicht erwartet haben, können Sie diese E-Mail ignorier
 `,
 	},
 	LocaleES: {
-		VerificationSubject: "Verifica tu dirección de correo electrónico",
-		VerificationBody: `Hola %s,
+		TemplateVerification: `{{define "subject"}}Verifica tu dirección de correo electrónico{{end}}Hola {{.Name}},
 
 Por favor, verifica tu dirección de correo electrónico haciendo clic en el siguiente enlace:
 
-%s
+{{.URL}}
 
 Este enlace caducará en 24 horas.
 
@@ -193,14 +218,13 @@ Si no solicitaste esta verificación, puedes ignorar este correo electrónico.
 
 - El equipo de mddb
 `,
-		OrgInvitationSubject: "Has sido invitado/a a unirte a %s",
-		OrgInvitationBody: `Hola,
+		TemplateOrgInvitation: `{{define "subject"}}Has sido invitado/a a unirte a {{.OrgName}}{{end}}Hola,
 
-%s te ha invitado a unirte a la organización "%s" como %s.
+{{.InviterName}} te ha invitado a unirte a la organización "{{.OrgName}}" como {{.Role}}.
 
 Haz clic en el siguiente enlace para aceptar la invitación:
 
-%s
+{{.URL}}
 
 Esta invitación caducará en 7 días.
 
@@ -208,14 +232,13 @@ Si no esperabas esta invitación, puedes ignorar este correo electrónico.
 
 - El equipo de mddb
 `,
-		WSInvitationSubject: "Has sido invitado/a a unirte a %s",
-		WSInvitationBody: `Hola,
+		TemplateWSInvitation: `{{define "subject"}}Has sido invitado/a a unirte a {{.WSName}}{{end}}Hola,
 
-%s te ha invitado a unirte al espacio de trabajo "%s" (en la organización "%s") como %s.
+{{.InviterName}} te ha invitado a unirte al espacio de trabajo "{{.WSName}}" (en la organización "{{.OrgName}}") como {{.Role}}.
 
 Haz clic en el siguiente enlace para aceptar la invitación:
 
-%s
+{{.URL}}
 
 Esta invitación caducará en 7 días.
 
@@ -226,30 +249,169 @@ Si no esperabas esta invitación, puedes ignorar este correo electrónico.
 	},
 }
 
-// getTemplates returns templates for the given locale, falling back to English.
-func getTemplates(locale Locale) *emailTemplates {
-	if t, ok := templates[locale]; ok {
-		return t
+// builtinHTML wraps the plain text body in HTML when no HTML template is
+// provided: each paragraph becomes a <p> and the link becomes clickable.
+var builtinHTML = htmltemplate.Must(htmltemplate.New("html").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>{{.Subject}}</title></head>
+<body style="font-family: sans-serif; line-height: 1.5; max-width: 600px;">
+{{- range .Paragraphs}}
+{{if eq . $.URL}}<p><a href="{{.}}">{{.}}</a></p>{{else}}<p>{{.}}</p>{{end}}
+{{- end}}
+</body>
+</html>
+`))
+
+var builtinText = func() map[Locale]map[string]*texttemplate.Template {
+	out := make(map[Locale]map[string]*texttemplate.Template, len(builtinSources))
+	for locale, sources := range builtinSources {
+		out[locale] = make(map[string]*texttemplate.Template, len(sources))
+		for name, src := range sources {
+			out[locale][name] = texttemplate.Must(texttemplate.New(name).Parse(src))
+		}
 	}
-	return templates[DefaultLocale]
+	return out
+}()
+
+// Templates renders emails from the built-in templates and overrides loaded
+// from a directory.
+//
+// The zero value renders the built-in templates.
+type Templates struct {
+	text map[string]*texttemplate.Template
+	html map[string]*htmltemplate.Template
 }
 
-// VerificationEmail returns localized subject and body for email verification.
-func VerificationEmail(locale Locale, name, verifyURL string) (subject, body string) {
-	t := getTemplates(locale)
-	return t.VerificationSubject, fmt.Sprintf(t.VerificationBody, name, verifyURL)
+// LoadTemplates loads template overrides from dir. A missing directory is not
+// an error.
+//
+// Files are named after the template and optionally the locale, the latter
+// taking precedence:
+//
+//	verification.txt     plain text body, may {{define "subject"}}
+//	verification.fr.txt  plain text body for French
+//	verification.html    HTML body
+//
+// Templates are executed with VerificationData, OrgInvitationData or
+// WSInvitationData.
+func LoadTemplates(dir string) (*Templates, error) {
+	t := &Templates{
+		text: map[string]*texttemplate.Template{},
+		html: map[string]*htmltemplate.Template{},
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return t, nil
+		}
+		return nil, err
+	}
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		ext := filepath.Ext(e.Name())
+		key := strings.TrimSuffix(e.Name(), ext)
+		if ext != ".txt" && ext != ".html" {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, e.Name())) //nolint:gosec // G304: files listed from the configured directory
+		if err != nil {
+			return nil, err
+		}
+		if ext == ".txt" {
+			tmpl, err := texttemplate.New(key).Parse(string(data))
+			if err != nil {
+				return nil, fmt.Errorf("email template %s: %w", e.Name(), err)
+			}
+			t.text[key] = tmpl
+		} else {
+			tmpl, err := htmltemplate.New(key).Parse(string(data))
+			if err != nil {
+				return nil, fmt.Errorf("email template %s: %w", e.Name(), err)
+			}
+			t.html[key] = tmpl
+		}
+	}
+	return t, nil
 }
 
-// OrgInvitationEmail returns localized subject and body for organization invitation.
-func OrgInvitationEmail(locale Locale, orgName, inviterName, role, acceptURL string) (subject, body string) {
-	t := getTemplates(locale)
-	return fmt.Sprintf(t.OrgInvitationSubject, orgName),
-		fmt.Sprintf(t.OrgInvitationBody, inviterName, orgName, role, acceptURL)
+// Render renders the named template in locale with data.
+func (t *Templates) Render(name string, locale Locale, data any) (*Message, error) {
+	locale = ParseLocale(string(locale))
+	builtin := builtinText[locale][name]
+	if builtin == nil {
+		return nil, fmt.Errorf("unknown email template %q", name)
+	}
+	var textTmpl *texttemplate.Template
+	var htmlTmpl *htmltemplate.Template
+	if t != nil {
+		textTmpl = t.text[name+"."+string(locale)]
+		if textTmpl == nil {
+			textTmpl = t.text[name]
+		}
+		htmlTmpl = t.html[name+"."+string(locale)]
+		if htmlTmpl == nil {
+			htmlTmpl = t.html[name]
+		}
+	}
+	if textTmpl == nil {
+		textTmpl = builtin
+	}
+
+	subjectTmpl := textTmpl.Lookup("subject")
+	if subjectTmpl == nil {
+		subjectTmpl = builtin.Lookup("subject")
+	}
+	var buf bytes.Buffer
+	if err := subjectTmpl.Execute(&buf, data); err != nil {
+		return nil, fmt.Errorf("email template %s subject: %w", name, err)
+	}
+	msg := &Message{Subject: strings.TrimSpace(buf.String())}
+	buf.Reset()
+	if err := textTmpl.Execute(&buf, data); err != nil {
+		return nil, fmt.Errorf("email template %s: %w", name, err)
+	}
+	msg.Text = buf.String()
+
+	buf.Reset()
+	if htmlTmpl != nil {
+		if err := htmlTmpl.Execute(&buf, data); err != nil {
+			return nil, fmt.Errorf("email template %s html: %w", name, err)
+		}
+	} else {
+		if err := builtinHTML.Execute(&buf, map[string]any{
+			"Subject":    msg.Subject,
+			"Paragraphs": paragraphs(msg.Text),
+			"URL":        templateURL(data),
+		}); err != nil {
+			return nil, fmt.Errorf("email template %s html: %w", name, err)
+		}
+	}
+	msg.HTML = buf.String()
+	return msg, nil
 }
 
-// WSInvitationEmail returns localized subject and body for workspace invitation.
-func WSInvitationEmail(locale Locale, wsName, orgName, inviterName, role, acceptURL string) (subject, body string) {
-	t := getTemplates(locale)
-	return fmt.Sprintf(t.WSInvitationSubject, wsName),
-		fmt.Sprintf(t.WSInvitationBody, inviterName, wsName, orgName, role, acceptURL)
+// paragraphs splits text on blank lines.
+func paragraphs(text string) []string {
+	var out []string
+	for p := range strings.SplitSeq(text, "\n\n") {
+		if p = strings.TrimSpace(p); p != "" {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// templateURL returns the link of a template's data.
+func templateURL(data any) string {
+	switch d := data.(type) {
+	case *VerificationData:
+		return d.URL
+	case *OrgInvitationData:
+		return d.URL
+	case *WSInvitationData:
+		return d.URL
+	}
+	return ""
 }
@@ -0,0 +1,165 @@
+package email
+
+import (
+	"bytes"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRender(t *testing.T) {
+	t.Run("Builtin", func(t *testing.T) {
+		for _, locale := range []Locale{LocaleEN, LocaleFR, LocaleDE, LocaleES} {
+			for name, data := range map[string]any{
+				TemplateVerification:  &VerificationData{Name: "Joe", URL: "https://x/verify"},
+				TemplateOrgInvitation: &OrgInvitationData{OrgName: "Acme", InviterName: "Ann", Role: "admin", URL: "https://x/org"},
+				TemplateWSInvitation:  &WSInvitationData{WSName: "Docs", OrgName: "Acme", InviterName: "Ann", Role: "editor", URL: "https://x/ws"},
+			} {
+				msg, err := (*Templates)(nil).Render(name, locale, data)
+				if err != nil {
+					t.Fatalf("%s/%s: %v", locale, name, err)
+				}
+				url := templateURL(data)
+				if msg.Subject == "" || strings.Contains(msg.Subject, "{{") {
+					t.Errorf("%s/%s: subject %q", locale, name, msg.Subject)
+				}
+				if !strings.Contains(msg.Text, url) || strings.Contains(msg.Text, "subject") {
+					t.Errorf("%s/%s: text %q", locale, name, msg.Text)
+				}
+				if !strings.Contains(msg.HTML, `<a href="`+url+`">`) {
+					t.Errorf("%s/%s: html %q", locale, name, msg.HTML)
+				}
+			}
+		}
+	})
+
+	t.Run("Escaped", func(t *testing.T) {
+		data := &VerificationData{Name: `<b>Joe & "co"</b>`, URL: "https://x/verify?a=1&b=2"}
+		msg, err := (*Templates)(nil).Render(TemplateVerification, LocaleEN, data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(msg.Text, "Hi <b>Joe & \"co\"</b>,") {
+			t.Errorf("text not interpolated: %q", msg.Text)
+		}
+		if strings.Contains(msg.HTML, "<b>") || !strings.Contains(msg.HTML, "&lt;b&gt;Joe &amp; &#34;co&#34;&lt;/b&gt;") {
+			t.Errorf("html not escaped: %q", msg.HTML)
+		}
+		if !strings.Contains(msg.HTML, `href="https://x/verify?a=1&amp;b=2"`) {
+			t.Errorf("link not escaped: %q", msg.HTML)
+		}
+	})
+
+	t.Run("Overrides", func(t *testing.T) {
+		dir := t.TempDir()
+		for name, content := range map[string]string{
+			"verification.txt":      `{{define "subject"}}Welcome {{.Name}}{{end}}Go to {{.URL}}`,
+			"verification.fr.txt":   `Allez sur {{.URL}}`,
+			"verification.html":     `<p>Hello {{.Name}}, <a href="{{.URL}}">verify</a></p>`,
+			"org_invitation.fr.txt": `Rejoignez {{.OrgName}}`,
+			"README.md":             `ignored`,
+		} {
+			if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600); err != nil {
+				t.Fatal(err)
+			}
+		}
+		tmpl, err := LoadTemplates(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data := &VerificationData{Name: "<Joe>", URL: "https://x/v"}
+
+		msg, err := tmpl.Render(TemplateVerification, LocaleEN, data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := Message{Subject: "Welcome <Joe>", Text: "Go to https://x/v", HTML: `<p>Hello &lt;Joe&gt;, <a href="https://x/v">verify</a></p>`}
+		if *msg != want {
+			t.Errorf("Render = %+v, want %+v", *msg, want)
+		}
+
+		// The locale specific text wins; it has no subject so the built-in
+		// one is used.
+		msg, err = tmpl.Render(TemplateVerification, LocaleFR, data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if msg.Subject != "Vérifiez votre adresse e-mail" || msg.Text != "Allez sur https://x/v" || !strings.HasPrefix(msg.HTML, "<p>Hello") {
+			t.Errorf("Render(fr) = %+v", *msg)
+		}
+
+		// Templates without override use the built-in ones.
+		msg, err = tmpl.Render(TemplateWSInvitation, LocaleEN, &WSInvitationData{WSName: "Docs", URL: "https://x/ws"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if msg.Subject != "You've been invited to join Docs" {
+			t.Errorf("Render(ws) subject = %q", msg.Subject)
+		}
+
+		if _, err := tmpl.Render("unknown", LocaleEN, data); err == nil {
+			t.Error("expected error for unknown template")
+		}
+		if _, err := LoadTemplates(filepath.Join(dir, "missing")); err != nil {
+			t.Errorf("missing directory: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, "bad.txt"), []byte("{{"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := LoadTemplates(dir); err == nil {
+			t.Error("expected error for invalid template")
+		}
+	})
+}
+
+func TestSendMultipart(t *testing.T) {
+	sender := &fakeSender{}
+	s := &Service{Config: Config{From: "mddb@example.com"}, Sender: sender, Backoff: time.Millisecond}
+	if err := s.SendVerification(t.Context(), "joe@example.com", "Joé <dev>", "https://x/verify?t=1&u=2", LocaleFR); err != nil {
+		t.Fatal(err)
+	}
+	m, err := mail.ReadMessage(bytes.NewReader(sender.msg))
+	if err != nil {
+		t.Fatal(err)
+	}
+	subject, err := new(mime.WordDecoder).DecodeHeader(m.Header.Get("Subject"))
+	if err != nil || subject != "Vérifiez votre adresse e-mail" {
+		t.Errorf("subject = %q, %v", subject, err)
+	}
+	mediaType, params, err := mime.ParseMediaType(m.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/alternative" {
+		t.Fatalf("Content-Type = %q, %v", m.Header.Get("Content-Type"), err)
+	}
+	parts := map[string]string{}
+	r := multipart.NewReader(m.Body, params["boundary"])
+	for {
+		p, err := r.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, err := io.ReadAll(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ct, _, _ := mime.ParseMediaType(p.Header.Get("Content-Type"))
+		parts[ct] = string(body)
+	}
+	if len(parts) != 2 {
+		t.Fatalf("got parts %v", parts)
+	}
+	if text := parts["text/plain"]; !strings.Contains(text, "Bonjour Joé <dev>,") || !strings.Contains(text, "https://x/verify?t=1&u=2") {
+		t.Errorf("text part:\n%s", text)
+	}
+	if html := parts["text/html"]; !strings.Contains(html, "Bonjour Joé &lt;dev&gt;,") || !strings.Contains(html, `<a href="https://x/verify?t=1&amp;u=2">`) {
+		t.Errorf("html part:\n%s", html)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"errors"
 	"io"
 	"reflect"
+	"strconv"
 )
 
 // BlobRef is a content-addressed blob reference in format "sha256:<BASE32>-<size>".
@@ -45,6 +46,21 @@ func (r BlobRef) IsZero() bool {
 	return r == ""
 }
 
+// Size returns the size in bytes encoded in the reference.
+func (r BlobRef) Size() (int64, error) {
+	if err := r.Validate(); err != nil {
+		return 0, err
+	}
+	if r.IsZero() {
+		return 0, errUnsetBlob
+	}
+	n, err := strconv.ParseInt(string(r[60:]), 10, 64)
+	if err != nil {
+		return 0, errInvalidBlobRef
+	}
+	return n, nil
+}
+
 // Blob represents a reference to content-addressed binary data stored externally.
 //
 // Blob fields store only a reference in the JSONL table; actual data lives in
@@ -76,6 +92,22 @@ func (b *Blob) Reader() (io.ReadCloser, error) {
 	return b.store.open(b.Ref)
 }
 
+// Open opens the blob file for streaming read and verifies its content.
+//
+// Unlike [Blob.Reader], the content is hashed while it is read; reaching the
+// end of the content or closing the reader returns an error if the bytes do
+// not match the SHA-256 in the ref. Use it to detect silent disk corruption.
+// The caller must close the returned ReadCloser.
+func (b *Blob) Open() (io.ReadCloser, error) {
+	if b.IsZero() {
+		return nil, errUnsetBlob
+	}
+	if b.store == nil {
+		return nil, errNoBlobStore
+	}
+	return b.store.openVerified(b.Ref)
+}
+
 // Clone returns a shallow copy of the blob with the same ref and store reference.
 func (b *Blob) Clone() Blob {
 	return Blob{Ref: b.Ref, store: b.store}
@@ -102,10 +134,12 @@ func (b *Blob) UnmarshalJSON(data []byte) error {
 //
 
 var (
-	blobType          = reflect.TypeFor[Blob]()
-	errUnsetBlob      = errors.New("blob is unset")
-	errNoBlobStore    = errors.New("blob has no store reference")
-	errInvalidBlobRef = errors.New("invalid blob ref")
+	blobType            = reflect.TypeFor[Blob]()
+	errUnsetBlob        = errors.New("blob is unset")
+	errNoBlobStore      = errors.New("blob has no store reference")
+	errInvalidBlobRef   = errors.New("invalid blob ref")
+	errCorruptBlob      = errors.New("blob size does not match ref")
+	errBlobHashMismatch = errors.New("blob content does not match ref")
 )
 
 // blobFields returns pointers to all Blob fields in a struct using reflection.
@@ -13,6 +13,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 )
 
 // base32Enc uses base32 "Extended Hex" alphabet (0-9A-V) which is ASCII-sorted
@@ -76,12 +77,17 @@ func (w *BlobWriter) Close() (Blob, error) {
 		return Blob{}, errors.Join(fmt.Errorf("failed to create blob subdirectory: %w", err), os.Remove(w.tmpPath))
 	}
 
-	// If blob already exists (same content), just remove temp.
+	// If blob already exists (same content), just remove temp. Refresh its
+	// mtime so a concurrent GC treats it as freshly written.
 	targetPath := w.store.pathForRef(ref)
 	if _, err := os.Stat(targetPath); err == nil {
 		if err := os.Remove(w.tmpPath); err != nil {
 			return Blob{}, fmt.Errorf("failed to remove temp file: %w", err)
 		}
+		now := time.Now()
+		if err := os.Chtimes(targetPath, now, now); err != nil {
+			return Blob{}, fmt.Errorf("failed to touch existing blob: %w", err)
+		}
 		return Blob{Ref: ref, store: w.store}, nil
 	}
 	if err := os.Rename(w.tmpPath, targetPath); err != nil {
@@ -109,6 +115,10 @@ const (
 	// emptyBlobRef is the ref for empty content (SHA-256 of nothing with size 0).
 	// Used as an optimization to avoid file I/O for empty blobs.
 	emptyBlobRef = BlobRef("sha256:SEOC8GKOVGE196NRUJ49IRTP4GJQSGF4CIDP6J54IMCHMU2IN1AG-0")
+
+	// blobGCGracePeriod is how long an unreferenced blob is kept by [Table.GCBlobs]
+	// before being considered orphaned.
+	blobGCGracePeriod = time.Hour
 )
 
 // blobStore manages content-addressed files in a directory.
@@ -139,22 +149,106 @@ func (bs *blobStore) newBlob() (*BlobWriter, error) {
 	}, nil
 }
 
-// open returns a ReadCloser for the blob with the given ref.
-func (bs *blobStore) open(ref BlobRef) (io.ReadCloser, error) {
-	if err := ref.Validate(); err != nil {
+// open returns a seekable reader for the blob with the given ref.
+//
+// Returns an error wrapping fs.ErrNotExist if the blob file is missing, or
+// errCorruptBlob if the file size does not match the size encoded in the ref.
+func (bs *blobStore) open(ref BlobRef) (io.ReadSeekCloser, error) {
+	size, err := ref.Size()
+	if err != nil {
 		return nil, err
 	}
 	// Optimization: empty blob has no file, return empty reader.
 	if ref == emptyBlobRef {
-		return io.NopCloser(strings.NewReader("")), nil
+		return nopSeekCloser{strings.NewReader("")}, nil
 	}
 	f, err := os.Open(bs.pathForRef(ref))
 	if err != nil {
 		return nil, fmt.Errorf("failed to open blob: %w", err)
 	}
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, errors.Join(fmt.Errorf("failed to stat blob: %w", err), f.Close())
+	}
+	if fi.Size() != size {
+		return nil, errors.Join(fmt.Errorf("%w: %s has %d bytes", errCorruptBlob, ref, fi.Size()), f.Close())
+	}
 	return f, nil
 }
 
+// openVerified returns a reader for the blob with the given ref that hashes
+// the content as it is read and fails if it does not match the ref.
+//
+// The mismatch is reported by Read in place of io.EOF and by Close. Close
+// reads any remaining bytes so that a partially read blob is still verified.
+func (bs *blobStore) openVerified(ref BlobRef) (io.ReadCloser, error) {
+	f, err := bs.open(ref)
+	if err != nil {
+		return nil, err
+	}
+	return &verifyingReader{r: f, ref: ref, hasher: sha256.New()}, nil
+}
+
+// verifyingReader checks that the bytes read from a blob hash to its ref.
+type verifyingReader struct {
+	r      io.ReadCloser
+	ref    BlobRef
+	hasher hash.Hash
+	err    error // set once the end of the content was reached
+	done   bool
+}
+
+// Read implements io.Reader, returning errBlobHashMismatch instead of io.EOF
+// when the content does not match the ref.
+func (v *verifyingReader) Read(p []byte) (int, error) {
+	if v.done {
+		if v.err != nil {
+			return 0, v.err
+		}
+		return 0, io.EOF
+	}
+	n, err := v.r.Read(p)
+	v.hasher.Write(p[:n])
+	if err == io.EOF {
+		if err = v.verify(); err == nil {
+			err = io.EOF
+		}
+	}
+	return n, err
+}
+
+// Close drains the unread content, closes the file and returns
+// errBlobHashMismatch if the content does not match the ref.
+func (v *verifyingReader) Close() error {
+	var errs []error
+	if !v.done {
+		if _, err := io.Copy(v.hasher, v.r); err != nil {
+			errs = append(errs, fmt.Errorf("failed to read blob: %w", err))
+		} else {
+			errs = append(errs, v.verify())
+		}
+	} else {
+		errs = append(errs, v.err)
+	}
+	return errors.Join(append(errs, v.r.Close())...)
+}
+
+// verify compares the hash of the content read so far with the ref.
+func (v *verifyingReader) verify() error {
+	v.done = true
+	if got := base32Enc.EncodeToString(v.hasher.Sum(nil)); !strings.HasPrefix(string(v.ref), blobRefPrefix+got+"-") {
+		v.err = fmt.Errorf("%w: %s hashes to %s", errBlobHashMismatch, v.ref, got)
+	}
+	return v.err
+}
+
+// nopSeekCloser adds a no-op Close to an io.ReadSeeker.
+type nopSeekCloser struct {
+	io.ReadSeeker
+}
+
+func (nopSeekCloser) Close() error { return nil }
+
 // remove removes a blob by ref.
 //
 // Returns nil if the blob doesn't exist.
@@ -174,24 +268,28 @@ func (bs *blobStore) remove(ref BlobRef) error {
 
 // gc removes blobs not in usedRefs and cleans up unknown entries.
 //
-// This is a stop-the-world GC: caller should ensure no writes are in progress.
-// Returns all errors encountered joined together.
-func (bs *blobStore) gc(usedRefs map[BlobRef]int) error {
+// Orphaned blobs and temp files modified less than minAge ago are kept, so a
+// concurrent writer that has not yet attached its blob to a row is not raced.
+// Pass 0 for a stop-the-world GC when no writes can be in progress.
+// Returns the number and total size of removed orphan blobs, and all errors
+// encountered joined together.
+func (bs *blobStore) gc(usedRefs map[BlobRef]int, minAge time.Duration) (removed int, bytes int64, err error) {
 	entries, err := os.ReadDir(bs.dir)
 	if err != nil {
 		if os.IsNotExist(err) {
-			return nil
+			return 0, 0, nil
 		}
-		return fmt.Errorf("failed to read blob directory: %w", err)
+		return 0, 0, fmt.Errorf("failed to read blob directory: %w", err)
 	}
 
+	cutoff := time.Now().Add(-minAge)
 	var errs []error
 	for _, entry := range entries {
 		name := entry.Name()
 
 		// Clean up tmp directory contents.
 		if name == tmpDirName {
-			if err := bs.cleanupTmpDir(filepath.Join(bs.dir, name)); err != nil {
+			if err := bs.cleanupTmpDir(filepath.Join(bs.dir, name), cutoff); err != nil {
 				errs = append(errs, err)
 			}
 			continue
@@ -231,18 +329,32 @@ func (bs *blobStore) gc(usedRefs map[BlobRef]int) error {
 			}
 
 			// Remove orphaned blobs.
-			if usedRefs[ref] == 0 {
-				if err := os.Remove(filePath); err != nil {
-					errs = append(errs, fmt.Errorf("failed to remove orphan blob %s: %w", ref, err))
+			if usedRefs[ref] != 0 {
+				continue
+			}
+			info, err := file.Info()
+			if err != nil {
+				if !os.IsNotExist(err) {
+					errs = append(errs, fmt.Errorf("failed to stat blob %s: %w", ref, err))
 				}
+				continue
+			}
+			if info.ModTime().After(cutoff) {
+				continue
+			}
+			if err := os.Remove(filePath); err != nil {
+				errs = append(errs, fmt.Errorf("failed to remove orphan blob %s: %w", ref, err))
+				continue
 			}
+			removed++
+			bytes += info.Size()
 		}
 	}
-	return errors.Join(errs...)
+	return removed, bytes, errors.Join(errs...)
 }
 
-// cleanupTmpDir removes all .tmp files from the given directory.
-func (bs *blobStore) cleanupTmpDir(dir string) error {
+// cleanupTmpDir removes .tmp files last modified before cutoff from the given directory.
+func (bs *blobStore) cleanupTmpDir(dir string, cutoff time.Time) error {
 	entries, err := os.ReadDir(dir)
 	if err != nil {
 		if os.IsNotExist(err) {
@@ -252,10 +364,14 @@ func (bs *blobStore) cleanupTmpDir(dir string) error {
 	}
 	var errs []error
 	for _, entry := range entries {
-		if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".tmp") {
-			if err := os.Remove(filepath.Join(dir, entry.Name())); err != nil {
-				errs = append(errs, fmt.Errorf("failed to remove temp file %s: %w", entry.Name(), err))
-			}
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".tmp") {
+			continue
+		}
+		if info, err := entry.Info(); err != nil || info.ModTime().After(cutoff) {
+			continue
+		}
+		if err := os.Remove(filepath.Join(dir, entry.Name())); err != nil {
+			errs = append(errs, fmt.Errorf("failed to remove temp file %s: %w", entry.Name(), err))
 		}
 	}
 	return errors.Join(errs...)
@@ -1,6 +1,7 @@
 package jsonldb
 
 import (
+	"bytes"
 	"errors"
 	"io"
 	"io/fs"
@@ -270,9 +271,13 @@ func TestBlobStore(t *testing.T) {
 		}
 
 		// Keep only first blob
-		if err := store.gc(map[BlobRef]int{refs[0]: 1}); err != nil {
+		removed, size, err := store.gc(map[BlobRef]int{refs[0]: 1}, 0)
+		if err != nil {
 			t.Fatalf("GC() error = %v", err)
 		}
+		if removed != 2 || size != 2 {
+			t.Errorf("GC() = %d blobs, %d bytes; want 2, 2", removed, size)
+		}
 
 		// First blob should exist
 		if _, err := os.Stat(store.pathForRef(refs[0])); err != nil {
@@ -302,7 +307,7 @@ func TestBlobStore(t *testing.T) {
 		}
 
 		// gc should clean up tmp files
-		if err := store.gc(map[BlobRef]int{}); err != nil {
+		if _, _, err := store.gc(map[BlobRef]int{}, 0); err != nil {
 			t.Fatalf("gc() error = %v", err)
 		}
 
@@ -402,4 +407,88 @@ func TestBlobStore(t *testing.T) {
 			t.Errorf("blob size = %d, want %d", info.Size(), len(content))
 		}
 	})
+	t.Run("Verify", func(t *testing.T) {
+		store := &blobStore{dir: filepath.Join(t.TempDir(), "blobs")}
+		write := func(t *testing.T, content []byte) Blob {
+			w, err := store.newBlob()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := w.Write(content); err != nil {
+				t.Fatal(err)
+			}
+			blob, err := w.Close()
+			if err != nil {
+				t.Fatal(err)
+			}
+			return blob
+		}
+
+		t.Run("large", func(t *testing.T) {
+			content := bytes.Repeat([]byte("0123456789abcdef"), 5<<20/16)
+			blob := write(t, content)
+			r, err := blob.Open()
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := io.ReadAll(r)
+			if err != nil {
+				t.Fatalf("ReadAll() error = %v", err)
+			}
+			if err := r.Close(); err != nil {
+				t.Fatalf("Close() error = %v", err)
+			}
+			if !bytes.Equal(got, content) {
+				t.Errorf("read %d bytes, want %d", len(got), len(content))
+			}
+		})
+
+		t.Run("empty", func(t *testing.T) {
+			blob := write(t, nil)
+			r, err := blob.Open()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := r.Close(); err != nil {
+				t.Errorf("Close() error = %v", err)
+			}
+		})
+
+		t.Run("corrupt", func(t *testing.T) {
+			blob := write(t, []byte("original content"))
+			// Same size, different bytes: only the hash can tell.
+			if err := os.WriteFile(store.pathForRef(blob.Ref), []byte("corrupt content!"), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			r, err := blob.Open()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := io.ReadAll(r); !errors.Is(err, errBlobHashMismatch) {
+				t.Errorf("ReadAll() error = %v, want errBlobHashMismatch", err)
+			}
+			if err := r.Close(); !errors.Is(err, errBlobHashMismatch) {
+				t.Errorf("Close() error = %v, want errBlobHashMismatch", err)
+			}
+
+			// Closing without reading still verifies the content.
+			r, err = blob.Open()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := r.Close(); !errors.Is(err, errBlobHashMismatch) {
+				t.Errorf("Close() unread error = %v, want errBlobHashMismatch", err)
+			}
+
+			// The unverified reader is unaffected.
+			r, err = blob.Reader()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := r.Close(); err != nil {
+				t.Errorf("Reader().Close() error = %v", err)
+			}
+		})
+	})
 }
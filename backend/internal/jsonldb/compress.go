@@ -0,0 +1,92 @@
+// Gzip encoding of table files named with the .gz extension.
+
+package jsonldb
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// compressedExt is the extension of gzip-compressed table files, e.g.
+// mytable.jsonl.gz.
+const compressedExt = ".gz"
+
+// isCompressedPath reports whether the table file at path is gzip-compressed.
+func isCompressedPath(path string) bool {
+	return strings.HasSuffix(path, compressedExt)
+}
+
+// gunzip decompresses data. Concatenated gzip members, as produced by
+// appending rows, are decompressed as one stream.
+//
+// A truncated trailing member, left by an append interrupted before it was
+// synced, is dropped: its row was never reported as written. compact reports
+// that data holds more than one member or a truncated one, so that the file
+// should be rewritten as a single member.
+func gunzip(data []byte) (out []byte, compact bool, err error) {
+	if len(data) == 0 {
+		return nil, false, nil
+	}
+	r := bytes.NewReader(data)
+	zr, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, false, err
+	}
+	var buf bytes.Buffer
+	for members := 0; ; members++ {
+		zr.Multistream(false)
+		n := buf.Len()
+		if _, err := io.Copy(&buf, zr); err != nil {
+			if members == 0 || !errors.Is(err, io.ErrUnexpectedEOF) {
+				return nil, false, err
+			}
+			buf.Truncate(n)
+			return buf.Bytes(), true, nil
+		}
+		if err := zr.Reset(r); err != nil {
+			if errors.Is(err, io.EOF) {
+				return buf.Bytes(), members > 0, nil
+			}
+			if errors.Is(err, io.ErrUnexpectedEOF) {
+				return buf.Bytes(), true, nil
+			}
+			return nil, false, err
+		}
+	}
+}
+
+// gzipBytes compresses data into a standalone gzip member.
+func gzipBytes(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(data); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// gzipWriter wraps write so that what it produces is gzip-compressed into w.
+func gzipWriter(write func(w *bufio.Writer) error) func(w *bufio.Writer) error {
+	return func(w *bufio.Writer) error {
+		zw := gzip.NewWriter(w)
+		bw := bufio.NewWriter(zw)
+		if err := write(bw); err != nil {
+			return err
+		}
+		if err := bw.Flush(); err != nil {
+			return fmt.Errorf("failed to flush writer: %w", err)
+		}
+		if err := zw.Close(); err != nil {
+			return fmt.Errorf("failed to compress table file: %w", err)
+		}
+		return nil
+	}
+}
@@ -14,10 +14,33 @@
 // The tradeoff is lower throughput under high contention, but this is acceptable
 // for local file storage with low concurrency.
 //
+// # Read-only Access
+//
+// [OpenTableReadOnly] loads a table that must never be modified, such as a
+// snapshot or a file in a non-writable directory. Mutating methods return
+// [ErrReadOnly] and nothing is written to disk, not even blob cleanup.
+//
+// # Backups
+//
+// [Table.Snapshot] streams the schema header and live rows of a table as an
+// uncompressed JSONL file. [Table.RestoreFrom] atomically replaces a table with
+// such a snapshot and notifies observers so indexes are rebuilt.
+//
 // # Secondary Indexes
 //
 // [UniqueIndex] and [Index] provide O(1) lookups by arbitrary keys, staying
-// synchronized with table mutations via [TableObserver].
+// synchronized with table mutations via [TableObserver]. Observers receive the
+// previous row on update and delete, so other caches can be maintained from
+// the diff too.
+//
+// # Row IDs
+//
+// Rows are keyed by [ksid.ID]. [ksid.NewID] packs a 48-bit count of 10µs
+// intervals since 2026 with a 15-bit sequence, and is serialized within the
+// process, so IDs are unique and strictly increasing while the clock does not
+// go backward. Deployments running several processes call [ksid.InitIDSlice]
+// to partition the sequence. [Table.Append] accepts an ID lower than the last
+// row's, e.g. after the clock was set back, and keeps the file sorted.
 //
 // # Blob Storage
 //
@@ -25,8 +48,12 @@
 // content-addressed files in a sibling directory (mytable.jsonl → mytable.blobs/),
 // with only the reference stored in the JSONL row. Use [Table.NewBlob] to create
 // blobs via streaming writes, then assign the returned [Blob] to row fields.
-// Blob files are automatically deduplicated by content hash and garbage collected
-// when no longer referenced.
+// Use [Table.OpenBlob] to stream a blob back with seeking, e.g. to serve HTTP
+// range requests without loading it in memory.
+// Blob files are automatically deduplicated by content hash and deleted when
+// their last reference goes away. Orphans left by abandoned writes are reclaimed
+// when the table is loaded, unless [TableOptions].SkipLoadGC is set, or on
+// demand with [Table.GCBlobs].
 //
 // # File Format
 //
@@ -34,4 +61,18 @@
 // Rows are sorted by ID on load if out of order (handles clock drift, manual edits).
 // Blob references use the format "sha256:<BASE32HEX>-<size>" for self-describing,
 // content-addressed storage with compact, case-insensitive-safe encoding.
+//
+// A table file whose name ends in .gz, e.g. mytable.jsonl.gz, holds the same
+// JSONL gzip-compressed; the API is unchanged and the file is decompressed into
+// the cache on load. Appended rows are written as additional gzip members, and
+// rewrites compress the whole file as one; loading a table compacts appended
+// members and drops a trailing one truncated by a crash. Blobs are not
+// compressed.
+//
+// # Schema Migration
+//
+// The schema header records the columns of the row type that wrote the file.
+// When they no longer match, e.g. after a field was renamed, a [MigrateFunc]
+// passed to [NewTableWithOptions] transforms each stored row on load, and the
+// file is rewritten with the current header.
 package jsonldb
@@ -0,0 +1,80 @@
+// Replaces files so that they survive a crash.
+
+package jsonldb
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// WriteFileDurable replaces the file at path with data the way tables rewrite
+// their file, so that a crash at any point leaves either the previous or the
+// new content, never a torn file. It is meant for files stored next to tables,
+// e.g. metadata.
+func WriteFileDurable(path string, data []byte) error {
+	return writeFileDurable(path, func(w *bufio.Writer) error {
+		if _, err := w.Write(data); err != nil {
+			return fmt.Errorf("failed to write %s: %w", filepath.Base(path), err)
+		}
+		return nil
+	})
+}
+
+// writeFileDurable replaces the file at path with what write produces.
+//
+// The content is written to a temporary file that is synced and then renamed
+// over path, and the directory is synced so the rename itself survives a
+// crash.
+func writeFileDurable(path string, write func(w *bufio.Writer) error) (err error) {
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	defer func() {
+		if cerr := f.Close(); cerr != nil && err == nil {
+			err = fmt.Errorf("failed to close temporary file: %w", cerr)
+		}
+		if err == nil {
+			err = os.Rename(f.Name(), path)
+		}
+		if err == nil {
+			err = syncDir(filepath.Dir(path))
+		}
+		if err != nil {
+			_ = os.Remove(f.Name())
+		}
+	}()
+	if err := f.Chmod(0o644); err != nil { //nolint:gosec // G302: 0o644 is intentional for user data files
+		return fmt.Errorf("failed to set file mode: %w", err)
+	}
+	writer := bufio.NewWriter(f)
+	if err := write(writer); err != nil {
+		return err
+	}
+	if err := writer.Flush(); err != nil {
+		return fmt.Errorf("failed to flush writer: %w", err)
+	}
+	if err := f.Sync(); err != nil {
+		return fmt.Errorf("failed to sync temporary file: %w", err)
+	}
+	return nil
+}
+
+// syncDir flushes the directory entries of dir to disk, making renames and
+// file creations in it durable.
+func syncDir(dir string) error {
+	d, err := os.Open(dir) //nolint:gosec // G304: dir is the directory of the file being replaced
+	if err != nil {
+		return err
+	}
+	err = d.Sync()
+	if cerr := d.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to sync directory: %w", err)
+	}
+	return nil
+}
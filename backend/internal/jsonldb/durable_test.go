@@ -0,0 +1,30 @@
+package jsonldb
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteFileDurable(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "metadata.json")
+	for _, content := range []string{"first", "second"} {
+		if err := WriteFileDurable(path, []byte(content)); err != nil {
+			t.Fatal(err)
+		}
+		if got, err := os.ReadFile(path); err != nil || string(got) != content {
+			t.Fatalf("got %q, %v", got, err)
+		}
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("temporary files left behind: %v", entries)
+	}
+	if err := WriteFileDurable(filepath.Join(dir, "missing", "x.json"), nil); err == nil {
+		t.Error("want error in a missing directory")
+	}
+}
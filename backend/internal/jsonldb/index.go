@@ -3,7 +3,9 @@
 package jsonldb
 
 import (
+	"cmp"
 	"iter"
+	"slices"
 	"sync"
 
 	"github.com/maruel/ksid"
@@ -163,3 +165,155 @@ func (idx *Index[K, T]) OnDelete(row T) {
 	}
 	idx.mu.Unlock()
 }
+
+// RangeIndex provides ordered lookups by a non-unique secondary key, e.g. a
+// timestamp.
+//
+// Keys are kept in a sorted slice, so lookups are O(log n) and insertions
+// are cheap when keys mostly grow, as timestamps do. The index is built from
+// existing table data when created and kept synchronized via the
+// [TableObserver] interface. All operations are concurrent-safe.
+type RangeIndex[K cmp.Ordered, T Row[T]] struct {
+	table   *Table[T]
+	keyFunc func(T) K
+	mu      sync.Mutex
+	entries []rangeEntry[K] // sorted by key, then ID
+}
+
+type rangeEntry[K cmp.Ordered] struct {
+	key K
+	id  ksid.ID
+}
+
+func (e rangeEntry[K]) compare(o rangeEntry[K]) int {
+	if c := cmp.Compare(e.key, o.key); c != 0 {
+		return c
+	}
+	return e.id.Compare(o.id)
+}
+
+// NewRangeIndex creates a range index on the given table.
+//
+// The keyFunc extracts the index key from each row. Multiple rows may share
+// the same key; they are returned in ID order.
+func NewRangeIndex[K cmp.Ordered, T Row[T]](table *Table[T], keyFunc func(T) K) *RangeIndex[K, T] {
+	idx := &RangeIndex[K, T]{
+		table:   table,
+		keyFunc: keyFunc,
+	}
+	table.AddObserver(idx)
+	return idx
+}
+
+// Range returns an iterator over the rows with lo <= key < hi, in key order.
+func (idx *RangeIndex[K, T]) Range(lo, hi K) iter.Seq[T] {
+	return func(yield func(T) bool) {
+		// Copy IDs under lock to avoid holding lock during iteration.
+		idx.mu.Lock()
+		start := idx.searchLocked(lo, false)
+		end := idx.searchLocked(hi, false)
+		ids := make([]ksid.ID, 0, max(0, end-start))
+		for _, e := range idx.entries[start:max(start, end)] {
+			ids = append(ids, e.id)
+		}
+		idx.mu.Unlock()
+		idx.yieldRows(ids, yield)
+	}
+}
+
+// After returns up to limit rows with key > k, in key order. A limit of 0 or
+// less returns all of them.
+func (idx *RangeIndex[K, T]) After(k K, limit int) []T {
+	idx.mu.Lock()
+	start := idx.searchLocked(k, true)
+	entries := idx.entries[start:]
+	if limit > 0 && len(entries) > limit {
+		entries = entries[:limit]
+	}
+	ids := make([]ksid.ID, 0, len(entries))
+	for _, e := range entries {
+		ids = append(ids, e.id)
+	}
+	idx.mu.Unlock()
+	var out []T
+	idx.yieldRows(ids, func(row T) bool {
+		out = append(out, row)
+		return true
+	})
+	return out
+}
+
+// searchLocked returns the position of the first entry with key >= k, or
+// key > k when after is true.
+func (idx *RangeIndex[K, T]) searchLocked(k K, after bool) int {
+	i, _ := slices.BinarySearchFunc(idx.entries, k, func(e rangeEntry[K], k K) int {
+		if c := cmp.Compare(e.key, k); c != 0 || !after {
+			return c
+		}
+		return -1
+	})
+	return i
+}
+
+// yieldRows yields the current version of the rows, skipping the ones deleted
+// since the IDs were collected.
+func (idx *RangeIndex[K, T]) yieldRows(ids []ksid.ID, yield func(T) bool) {
+	for _, id := range ids {
+		row := idx.table.Get(id)
+		var zero T
+		if any(row) == any(zero) {
+			continue // Row was deleted between snapshot and lookup
+		}
+		if !yield(row) {
+			return
+		}
+	}
+}
+
+func (idx *RangeIndex[K, T]) insertLocked(e rangeEntry[K]) {
+	// Fast path for keys appended in order.
+	if n := len(idx.entries); n == 0 || idx.entries[n-1].compare(e) < 0 {
+		idx.entries = append(idx.entries, e)
+		return
+	}
+	i, found := slices.BinarySearchFunc(idx.entries, e, rangeEntry[K].compare)
+	if !found {
+		idx.entries = slices.Insert(idx.entries, i, e)
+	}
+}
+
+func (idx *RangeIndex[K, T]) removeLocked(e rangeEntry[K]) {
+	if i, found := slices.BinarySearchFunc(idx.entries, e, rangeEntry[K].compare); found {
+		idx.entries = slices.Delete(idx.entries, i, i+1)
+	}
+}
+
+// OnAppend implements [TableObserver].
+func (idx *RangeIndex[K, T]) OnAppend(row T) {
+	e := rangeEntry[K]{key: idx.keyFunc(row), id: row.GetID()}
+	idx.mu.Lock()
+	idx.insertLocked(e)
+	idx.mu.Unlock()
+}
+
+// OnUpdate implements [TableObserver].
+func (idx *RangeIndex[K, T]) OnUpdate(prev, curr T) {
+	oldKey := idx.keyFunc(prev)
+	newKey := idx.keyFunc(curr)
+	if oldKey == newKey {
+		return
+	}
+	id := curr.GetID()
+	idx.mu.Lock()
+	idx.removeLocked(rangeEntry[K]{key: oldKey, id: id})
+	idx.insertLocked(rangeEntry[K]{key: newKey, id: id})
+	idx.mu.Unlock()
+}
+
+// OnDelete implements [TableObserver].
+func (idx *RangeIndex[K, T]) OnDelete(row T) {
+	e := rangeEntry[K]{key: idx.keyFunc(row), id: row.GetID()}
+	idx.mu.Lock()
+	idx.removeLocked(e)
+	idx.mu.Unlock()
+}
@@ -354,3 +354,164 @@ func collectIDs(seq iter.Seq[*testRow]) []int {
 	}
 	return ids
 }
+
+func TestRangeIndex(t *testing.T) {
+	ids := func(rows []*testRow) []int {
+		var out []int
+		for _, r := range rows {
+			out = append(out, r.ID)
+		}
+		return out
+	}
+
+	t.Run("Bounds", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "test.jsonl")
+		table, err := NewTable[*testRow](path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// Keys out of ID order, with a duplicate.
+		for _, r := range []*testRow{{ID: 1, Name: "c"}, {ID: 2, Name: "a"}, {ID: 3, Name: "e"}, {ID: 4, Name: "c"}} {
+			if err := table.Append(r); err != nil {
+				t.Fatal(err)
+			}
+		}
+		byName := NewRangeIndex(table, func(r *testRow) string { return r.Name })
+		if err := table.Append(&testRow{ID: 5, Name: "b"}); err != nil {
+			t.Fatal(err)
+		}
+
+		tests := []struct {
+			name   string
+			lo, hi string
+			want   []int
+		}{
+			{"all", "", "z", []int{2, 5, 1, 4, 3}},
+			{"lo inclusive", "c", "z", []int{1, 4, 3}},
+			{"hi exclusive", "a", "c", []int{2, 5}},
+			{"single key", "c", "d", []int{1, 4}},
+			{"between keys", "bb", "cc", []int{1, 4}},
+			{"empty", "c", "c", nil},
+			{"inverted", "e", "a", nil},
+			{"past end", "f", "z", nil},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				if got := ids(slices.Collect(byName.Range(tt.lo, tt.hi))); !slices.Equal(got, tt.want) {
+					t.Errorf("Range(%q, %q) = %v, want %v", tt.lo, tt.hi, got, tt.want)
+				}
+			})
+		}
+
+		afterTests := []struct {
+			k     string
+			limit int
+			want  []int
+		}{
+			{"", 0, []int{2, 5, 1, 4, 3}},
+			{"a", 0, []int{5, 1, 4, 3}},
+			{"b", 2, []int{1, 4}},
+			{"c", 0, []int{3}},
+			{"e", 0, nil},
+		}
+		for _, tt := range afterTests {
+			if got := ids(byName.After(tt.k, tt.limit)); !slices.Equal(got, tt.want) {
+				t.Errorf("After(%q, %d) = %v, want %v", tt.k, tt.limit, got, tt.want)
+			}
+		}
+	})
+
+	t.Run("Sync", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "test.jsonl")
+		table, err := NewTable[*testRow](path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		byName := NewRangeIndex(table, func(r *testRow) string { return r.Name })
+		for _, r := range []*testRow{{ID: 1, Name: "a"}, {ID: 2, Name: "b"}, {ID: 3, Name: "c"}} {
+			if err := table.Append(r); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if _, err := table.Update(&testRow{ID: 1, Name: "d"}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := table.Update(&testRow{ID: 2, Name: "b"}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := table.Delete(ksid.ID(3)); err != nil {
+			t.Fatal(err)
+		}
+		if got := ids(slices.Collect(byName.Range("a", "z"))); !slices.Equal(got, []int{2, 1}) {
+			t.Errorf("after update and delete: %v, want [2 1]", got)
+		}
+		if got := byName.After("b", 0); len(got) != 1 || got[0].Name != "d" {
+			t.Errorf("After(b) = %v, want the updated row", got)
+		}
+	})
+
+	t.Run("EarlyTermination", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "test.jsonl")
+		table, err := NewTable[*testRow](path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		byName := NewRangeIndex(table, func(r *testRow) string { return r.Name })
+		for i := 1; i <= 5; i++ {
+			if err := table.Append(&testRow{ID: i, Name: "k"}); err != nil {
+				t.Fatal(err)
+			}
+		}
+		next, stop := iter.Pull(byName.Range("k", "l"))
+		defer stop()
+		if r, ok := next(); !ok || r.ID != 1 {
+			t.Errorf("first = %v, %v", r, ok)
+		}
+	})
+}
+
+// benchRangeTable returns a table of n rows keyed by their index.
+func benchRangeTable(b *testing.B, n int) (*Table[*testRow], *RangeIndex[int, *testRow]) {
+	b.Helper()
+	table, err := NewTable[*testRow](filepath.Join(b.TempDir(), "bench.jsonl"))
+	if err != nil {
+		b.Fatal(err)
+	}
+	for i := 1; i <= n; i++ {
+		if err := table.Append(&testRow{ID: i, Name: "row"}); err != nil {
+			b.Fatal(err)
+		}
+	}
+	return table, NewRangeIndex(table, func(r *testRow) int { return r.ID })
+}
+
+// The two benchmarks select the same 100 rows out of 100k.
+const benchRangeRows = 100_000
+
+func BenchmarkRangeIndex_Range(b *testing.B) {
+	_, byID := benchRangeTable(b, benchRangeRows)
+	for b.Loop() {
+		n := 0
+		for range byID.Range(50_000, 50_100) {
+			n++
+		}
+		if n != 100 {
+			b.Fatalf("got %d rows", n)
+		}
+	}
+}
+
+func BenchmarkRangeIndex_FullIter(b *testing.B) {
+	table, _ := benchRangeTable(b, benchRangeRows)
+	for b.Loop() {
+		n := 0
+		for r := range table.Iter(0) {
+			if r.ID >= 50_000 && r.ID < 50_100 {
+				n++
+			}
+		}
+		if n != 100 {
+			b.Fatalf("got %d rows", n)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+// Implements point-in-time snapshots of a Table and restoring from them.
+
+package jsonldb
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+)
+
+// Snapshot writes the current state of the table to w.
+//
+// The snapshot is an uncompressed JSONL file in the table format: the schema
+// header followed by the live rows in ID order, so it can also be opened with
+// [NewTable]. Blob contents are not included, only their references.
+//
+// The reader lock is held while writing so the snapshot is consistent.
+func (t *Table[T]) Snapshot(w io.Writer) error {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	bw := bufio.NewWriter(w)
+	if err := writeSchemaHeader(bw, t.schema); err != nil {
+		return err
+	}
+	if err := writeRows(bw, t.rows); err != nil {
+		return err
+	}
+	if err := bw.Flush(); err != nil {
+		return fmt.Errorf("failed to flush snapshot: %w", err)
+	}
+	return nil
+}
+
+// RestoreFrom replaces the whole content of the table, schema header
+// included, with the snapshot read from r.
+//
+// The snapshot is fully read and validated before anything changes, then the
+// table file is replaced atomically; on error the table is left as is. Rows
+// are migrated like on load when the table has a [MigrateFunc].
+//
+// Observers are notified of the deletion of every previous row then of the
+// append of every restored row, which rebuilds indexes. Blob files are kept:
+// rows referencing blobs that were since deleted cannot read them, and blobs
+// no longer referenced are removed by [Table.GCBlobs].
+func (t *Table[T]) RestoreFrom(r io.Reader) error {
+	if t.readOnly {
+		return ErrReadOnly
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return fmt.Errorf("failed to read snapshot: %w", err)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	schema, rows, byID, _, err := t.decodeLocked(data, "snapshot")
+	if err != nil {
+		return err
+	}
+	if schema.Version == "" {
+		return errSnapshotNoHeader
+	}
+	err = t.writeFileLocked(func(w *bufio.Writer) error {
+		if err := writeSchemaHeader(w, schema); err != nil {
+			return err
+		}
+		return writeRows(w, rows)
+	})
+	if err != nil {
+		return err
+	}
+
+	prev := t.rows
+	t.schema = schema
+	t.rows = rows
+	t.byID = byID
+	t.blobRefCount = make(map[BlobRef]int)
+	for _, row := range rows {
+		t.trackBlobRefsLocked(row)
+	}
+	for _, obs := range t.observers {
+		for _, row := range prev {
+			obs.OnDelete(row)
+		}
+		for _, row := range rows {
+			obs.OnAppend(row)
+		}
+	}
+	return nil
+}
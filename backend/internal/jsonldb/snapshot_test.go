@@ -0,0 +1,158 @@
+package jsonldb
+
+import (
+	"bytes"
+	"errors"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestSnapshot(t *testing.T) {
+	rowsOf := func(table *Table[*testRow]) []testRow {
+		var out []testRow
+		for r := range table.Iter(0) {
+			out = append(out, *r)
+		}
+		return out
+	}
+	newTable := func(t *testing.T) *Table[*testRow] {
+		t.Helper()
+		table, err := NewTable[*testRow](filepath.Join(t.TempDir(), "test.jsonl"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i, name := range []string{"alice", "bob", "carol", "dave"} {
+			if err := table.Append(&testRow{ID: i + 1, Name: name}); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return table
+	}
+
+	t.Run("RestoreFrom", func(t *testing.T) {
+		table := newTable(t)
+		byName := NewUniqueIndex(table, func(r *testRow) string { return r.Name })
+		byLetter := NewIndex(table, func(r *testRow) string { return r.Name[:1] })
+		if _, err := table.Update(&testRow{ID: 2, Name: "bill"}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := table.Delete(3); err != nil {
+			t.Fatal(err)
+		}
+		if err := table.SetProperties([]byte(`{"v":1}`)); err != nil {
+			t.Fatal(err)
+		}
+		want := rowsOf(table)
+
+		var snap bytes.Buffer
+		if err := table.Snapshot(&snap); err != nil {
+			t.Fatal(err)
+		}
+		// Compacted: the header and one line per live row.
+		if got := strings.Count(snap.String(), "\n"); got != 1+len(want) {
+			t.Errorf("snapshot has %d lines, want %d:\n%s", got, 1+len(want), snap.String())
+		}
+
+		if err := table.Append(&testRow{ID: 5, Name: "eve"}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := table.Update(&testRow{ID: 1, Name: "anna"}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := table.Delete(4); err != nil {
+			t.Fatal(err)
+		}
+		if err := table.SetProperties([]byte(`{"v":2}`)); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := table.RestoreFrom(&snap); err != nil {
+			t.Fatal(err)
+		}
+		if got := rowsOf(table); !slices.Equal(got, want) {
+			t.Errorf("rows = %v, want %v", got, want)
+		}
+		if got := string(table.Properties()); got != `{"v":1}` {
+			t.Errorf("properties = %s", got)
+		}
+		for _, r := range want {
+			if got := byName.Get(r.Name); got == nil || got.ID != r.ID {
+				t.Errorf("byName.Get(%q) = %v, want ID %d", r.Name, got, r.ID)
+			}
+		}
+		for _, name := range []string{"anna", "eve", "bob", "carol"} {
+			if got := byName.Get(name); got != nil {
+				t.Errorf("byName.Get(%q) = %v, want nil", name, got)
+			}
+		}
+		var aRows []int
+		for r := range byLetter.Iter("a") {
+			aRows = append(aRows, r.ID)
+		}
+		if !slices.Equal(aRows, []int{1}) {
+			t.Errorf("byLetter.Iter(a) = %v", aRows)
+		}
+		if got := slices.Collect(byLetter.Iter("e")); len(got) != 0 {
+			t.Errorf("byLetter.Iter(e) = %v", got)
+		}
+
+		// The file was replaced too.
+		reopened, err := NewTable[*testRow](table.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := rowsOf(reopened); !slices.Equal(got, want) {
+			t.Errorf("reopened rows = %v, want %v", got, want)
+		}
+		// The restored table keeps working.
+		if err := table.Append(&testRow{ID: 6, Name: "frank"}); err != nil {
+			t.Fatal(err)
+		}
+		if got := byName.Get("frank"); got == nil || got.ID != 6 {
+			t.Errorf("byName.Get(frank) = %v", got)
+		}
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		table := newTable(t)
+		want := rowsOf(table)
+		var snap bytes.Buffer
+		if err := table.Snapshot(&snap); err != nil {
+			t.Fatal(err)
+		}
+		header, _, _ := strings.Cut(snap.String(), "\n")
+		for name, in := range map[string]string{
+			"Empty":       "",
+			"BadHeader":   "{\n",
+			"BadRow":      header + "\n{\n",
+			"ZeroID":      header + "\n{\"id\":0}\n",
+			"DuplicateID": header + "\n{\"id\":1}\n{\"id\":1}\n",
+		} {
+			t.Run(name, func(t *testing.T) {
+				if err := table.RestoreFrom(strings.NewReader(in)); err == nil {
+					t.Fatal("expected error")
+				}
+				if got := rowsOf(table); !slices.Equal(got, want) {
+					t.Errorf("rows = %v, want %v", got, want)
+				}
+			})
+		}
+	})
+
+	t.Run("ReadOnly", func(t *testing.T) {
+		table := newTable(t)
+		var snap bytes.Buffer
+		if err := table.Snapshot(&snap); err != nil {
+			t.Fatal(err)
+		}
+		ro, err := OpenTableReadOnly[*testRow](table.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := ro.RestoreFrom(&snap); !errors.Is(err, ErrReadOnly) {
+			t.Errorf("got %v, want ErrReadOnly", err)
+		}
+	})
+}
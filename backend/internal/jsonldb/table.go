@@ -8,6 +8,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"iter"
 	"os"
 	"path/filepath"
@@ -15,12 +16,20 @@ import (
 	"sort"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/maruel/ksid"
 )
 
 var errZeroID = errors.New("row has zero ID")
 
+// ErrReadOnly is returned by mutating methods of a table opened with [OpenTableReadOnly].
+var ErrReadOnly = errors.New("table is read-only")
+
+var errNotExpirer = errors.New("row type does not implement Expirer")
+
+var errSnapshotNoHeader = errors.New("snapshot has no schema header")
+
 // Row is implemented by types that can be stored in a [Table].
 type Row[T any] interface {
 	// Clone returns a deep copy of the row.
@@ -39,8 +48,24 @@ type Row[T any] interface {
 	Validate() error
 }
 
+// Expirer is optionally implemented by row types that have a time-to-live.
+//
+// Rows of such types can be removed in bulk with [Table.PurgeExpired].
+type Expirer interface {
+	// Expiry returns when the row expires. The zero time means never.
+	//
+	// The method is not named ExpiresAt so that row types can keep a field by that name.
+	Expiry() time.Time
+}
+
 // TableObserver receives notifications about table mutations.
 //
+// OnUpdate receives both the previous and the new value of the row so caches
+// can compute a diff, and OnDelete the removed row. Batch operations such as
+// ModifyMany and PurgeExpired notify once per row. Observers are notified of
+// every mutation persisted to disk, even when cleaning up unreferenced blobs
+// fails afterward. The rows are owned by the table and must not be modified.
+//
 // Observers are called synchronously while the table lock is held.
 // Implementations must not call back into the table or acquire locks
 // that could cause deadlock.
@@ -69,7 +94,11 @@ type Table[T Row[T]] struct {
 	byID         map[ksid.ID]int // maps ID to index in rows
 	blobRefCount map[BlobRef]int
 	observers    []TableObserver[T]
-	blobStore    blobStore // lazily initialized for tables with blob fields
+	blobStore    blobStore   // lazily initialized for tables with blob fields
+	readOnly     bool        // set by OpenTableReadOnly; immutable after construction
+	migrate      MigrateFunc // set by NewTableWithOptions; only used by load
+	skipLoadGC   bool        // set by NewTableWithOptions; only used by load
+	compressed   bool        // the file is gzip-compressed; see isCompressedPath
 }
 
 // AddObserver registers an observer to receive mutation notifications.
@@ -91,12 +120,49 @@ func (t *Table[T]) AddObserver(obs TableObserver[T]) {
 // Data is written to a temp file; Close() finalizes and returns a Blob
 // that can be assigned to row fields before Append().
 func (t *Table[T]) NewBlob() (*BlobWriter, error) {
+	if t.readOnly {
+		return nil, ErrReadOnly
+	}
 	return t.blobStore.newBlob()
 }
 
+// OpenBlob opens the blob's content for streaming, seekable reads.
+//
+// The blob is resolved against this table's blob directory, so it works for
+// blobs obtained from any row of the table. Returns an error if the ref is
+// invalid, the blob file is missing, or its size does not match the ref.
+// The caller must close the returned reader.
+func (t *Table[T]) OpenBlob(b Blob) (io.ReadSeekCloser, error) {
+	if b.IsZero() {
+		return nil, errUnsetBlob
+	}
+	return t.blobStore.open(b.Ref)
+}
+
+// BlobSize returns the size in bytes of the blob, as encoded in its ref.
+func (t *Table[T]) BlobSize(b Blob) (int64, error) {
+	return b.Ref.Size()
+}
+
+// GCBlobs deletes blob files in the table's blob directory that no row references.
+//
+// The write lock is held while scanning so rows cannot gain or lose references
+// mid-scan. Blobs and temp files written within the last hour are kept, since a
+// concurrent [Table.NewBlob] caller may not have appended its row yet.
+// Returns the number of blobs removed and the bytes reclaimed.
+func (t *Table[T]) GCBlobs() (removed int, bytes int64, err error) {
+	if t.readOnly {
+		return 0, 0, ErrReadOnly
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.blobStore.gc(t.blobRefCount, blobGCGracePeriod)
+}
+
 // deriveBlobDir returns the blob directory path for a table file.
-// Example: mytable.jsonl → mytable.blobs/.
+// Example: mytable.jsonl → mytable.blobs/, mytable.jsonl.gz → mytable.blobs/.
 func deriveBlobDir(tablePath string) string {
+	tablePath = strings.TrimSuffix(tablePath, compressedExt)
 	ext := filepath.Ext(tablePath)
 	if ext != "" {
 		return strings.TrimSuffix(tablePath, ext) + blobDirSuffix
@@ -122,6 +188,9 @@ func (t *Table[T]) Properties() json.RawMessage {
 // SetProperties sets the application-specific schema properties and persists the change.
 // The entire table is rewritten to update the header.
 func (t *Table[T]) SetProperties(props json.RawMessage) error {
+	if t.readOnly {
+		return ErrReadOnly
+	}
 	t.mu.Lock()
 	defer t.mu.Unlock()
 	t.schema.Properties = props
@@ -146,6 +215,9 @@ func (t *Table[T]) Get(id ksid.ID) T {
 // Blobs are only deleted if no other rows reference them.
 func (t *Table[T]) Delete(id ksid.ID) (T, error) {
 	var zero T
+	if t.readOnly {
+		return zero, ErrReadOnly
+	}
 	t.mu.Lock()
 	defer t.mu.Unlock()
 
@@ -169,14 +241,14 @@ func (t *Table[T]) Delete(id ksid.ID) (T, error) {
 		return zero, err
 	}
 
+	for _, obs := range t.observers {
+		obs.OnDelete(deleted)
+	}
+
 	// Decrement blob refcounts, delete blobs with refcount 0.
 	if err := t.untrackBlobRefsLocked(deleted); err != nil {
 		return zero, fmt.Errorf("failed to untrack blobs: %w", err)
 	}
-
-	for _, obs := range t.observers {
-		obs.OnDelete(deleted)
-	}
 	return deleted, nil
 }
 
@@ -213,6 +285,9 @@ func (t *Table[T]) untrackBlobRefsLocked(row T) error {
 // Returns an error if validation fails. The entire table is rewritten to disk on success.
 func (t *Table[T]) Update(row T) (T, error) {
 	var zero T
+	if t.readOnly {
+		return zero, ErrReadOnly
+	}
 	if err := row.Validate(); err != nil {
 		return zero, fmt.Errorf("invalid row: %w", err)
 	}
@@ -231,15 +306,15 @@ func (t *Table[T]) Update(row T) (T, error) {
 		return zero, err
 	}
 
+	for _, obs := range t.observers {
+		obs.OnUpdate(prev, row)
+	}
+
 	// Update blob refcounts: track new first to avoid deleting shared blobs.
 	t.trackBlobRefsLocked(row)
 	if err := t.untrackBlobRefsLocked(prev); err != nil {
 		return zero, fmt.Errorf("failed to untrack old blobs: %w", err)
 	}
-
-	for _, obs := range t.observers {
-		obs.OnUpdate(prev, row)
-	}
 	return prev, nil
 }
 
@@ -260,6 +335,9 @@ func (t *Table[T]) Update(row T) (T, error) {
 // state is rolled back.
 func (t *Table[T]) Modify(id ksid.ID, fn func(row T) error) (T, error) {
 	var zero T
+	if t.readOnly {
+		return zero, ErrReadOnly
+	}
 	t.mu.Lock()
 	defer t.mu.Unlock()
 
@@ -284,25 +362,190 @@ func (t *Table[T]) Modify(id ksid.ID, fn func(row T) error) (T, error) {
 		return zero, err
 	}
 
+	for _, obs := range t.observers {
+		obs.OnUpdate(prev, row)
+	}
+
 	// Update blob refcounts: track new first to avoid deleting shared blobs.
 	t.trackBlobRefsLocked(row)
 	if err := t.untrackBlobRefsLocked(prev); err != nil {
 		return zero, fmt.Errorf("failed to untrack old blobs: %w", err)
 	}
+	return row.Clone(), nil
+}
+
+// ModifyMany atomically reads, modifies, and writes several rows.
+//
+// It is like Modify for all the rows in ids at once: fn receives clones of the
+// current rows in the order of ids and either all the modified rows are
+// written in a single rewrite of the file or none is. Returns the modified
+// rows on success, or an error if a row doesn't exist, an ID is repeated or
+// validation fails.
+func (t *Table[T]) ModifyMany(ids []ksid.ID, fn func(rows []T) error) ([]T, error) {
+	if t.readOnly {
+		return nil, ErrReadOnly
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
 
-	for _, obs := range t.observers {
-		obs.OnUpdate(prev, row)
+	idxs := make([]int, len(ids))
+	prev := make([]T, len(ids))
+	rows := make([]T, len(ids))
+	for i, id := range ids {
+		idx, ok := t.byID[id]
+		if !ok {
+			return nil, fmt.Errorf("row %s not found", id)
+		}
+		if slices.Contains(idxs[:i], idx) {
+			return nil, fmt.Errorf("row %s listed twice", id)
+		}
+		idxs[i] = idx
+		prev[i] = t.rows[idx]
+		rows[i] = prev[i].Clone()
 	}
-	return row.Clone(), nil
+
+	if err := fn(rows); err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		if err := row.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid row after modify: %w", err)
+		}
+	}
+
+	for i, idx := range idxs {
+		t.rows[idx] = rows[i]
+	}
+	if err := t.saveLocked(); err != nil {
+		for i, idx := range idxs {
+			t.rows[idx] = prev[i] // Rollback on save failure
+		}
+		return nil, err
+	}
+
+	out := make([]T, len(rows))
+	var errs []error
+	for i, row := range rows {
+		for _, obs := range t.observers {
+			obs.OnUpdate(prev[i], row)
+		}
+		// Update blob refcounts: track new first to avoid deleting shared blobs.
+		t.trackBlobRefsLocked(row)
+		if err := t.untrackBlobRefsLocked(prev[i]); err != nil {
+			errs = append(errs, err)
+		}
+		out[i] = row.Clone()
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, fmt.Errorf("failed to untrack old blobs: %w", err)
+	}
+	return out, nil
+}
+
+// PurgeExpired removes every row whose [Expirer.Expiry] is at or before now.
+//
+// The row type must implement [Expirer]. All expired rows are removed in a single
+// pass under the write lock followed by one compacting rewrite of the file.
+// Returns the number of rows removed. If the disk write fails, the in-memory
+// state is rolled back.
+func (t *Table[T]) PurgeExpired(now time.Time) (int, error) {
+	if t.readOnly {
+		return 0, ErrReadOnly
+	}
+	var zero T
+	if _, ok := any(zero).(Expirer); !ok {
+		return 0, errNotExpirer
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	kept := make([]T, 0, len(t.rows))
+	var expired []T
+	for _, row := range t.rows {
+		if e := any(row).(Expirer).Expiry(); !e.IsZero() && !e.After(now) {
+			expired = append(expired, row)
+		} else {
+			kept = append(kept, row)
+		}
+	}
+	if len(expired) == 0 {
+		return 0, nil
+	}
+
+	prev := t.rows
+	t.rows = kept
+	if err := t.saveLocked(); err != nil {
+		t.rows = prev // Rollback on save failure
+		return 0, err
+	}
+	t.byID = make(map[ksid.ID]int, len(t.rows))
+	for i, row := range t.rows {
+		t.byID[row.GetID()] = i
+	}
+
+	var errs []error
+	for _, row := range expired {
+		if err := t.untrackBlobRefsLocked(row); err != nil {
+			errs = append(errs, err)
+		}
+		for _, obs := range t.observers {
+			obs.OnDelete(row)
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return len(expired), fmt.Errorf("failed to untrack blobs: %w", err)
+	}
+	return len(expired), nil
 }
 
 // NewTable creates a Table and loads existing data from the JSONL file at path.
 //
 // If the file doesn't exist, an empty table is created and the schema is
 // auto-discovered from type T via reflection.
+// A path ending in .gz, e.g. mytable.jsonl.gz, is stored gzip-compressed.
 // Returns an error if the file exists but cannot be read or contains invalid data.
 func NewTable[T Row[T]](path string) (*Table[T], error) {
-	table := &Table[T]{path: path}
+	return openTable[T](path, false, TableOptions{})
+}
+
+// MigrateFunc transforms a row, as stored in the JSONL file, into the JSON of
+// the current row type.
+type MigrateFunc func(row json.RawMessage) (json.RawMessage, error)
+
+// TableOptions configures a Table opened with [NewTableWithOptions].
+type TableOptions struct {
+	// Migrate is called on each row on load when the columns in the schema
+	// header differ from the ones discovered from the row type, e.g. after a
+	// field was renamed. The file is then rewritten with the current header so
+	// rows are migrated once. nil loads rows as is.
+	Migrate MigrateFunc
+	// SkipLoadGC keeps unreferenced blobs on load. Loading otherwise removes
+	// them without grace period, which races with a [Table.NewBlob] caller of
+	// another Table of the same file and deletes files the caller may need to
+	// track, e.g. when the blob directory is committed to git. Orphans are
+	// then only removed by [Table.GCBlobs].
+	SkipLoadGC bool
+}
+
+// NewTableWithOptions is [NewTable] configured by opts.
+func NewTableWithOptions[T Row[T]](path string, opts TableOptions) (*Table[T], error) {
+	return openTable[T](path, false, opts)
+}
+
+// OpenTableReadOnly loads the JSONL file at path without write capabilities.
+//
+// Nothing is ever written to disk: orphaned blobs are not collected on load, and
+// every mutating method (Append, Update, Delete, Modify, SetProperties, NewBlob,
+// GCBlobs) returns [ErrReadOnly]. This allows reading a snapshot or a file in a
+// directory that isn't writable. As with [NewTable], a missing file yields an
+// empty table.
+func OpenTableReadOnly[T Row[T]](path string) (*Table[T], error) {
+	return openTable[T](path, true, TableOptions{})
+}
+
+func openTable[T Row[T]](path string, readOnly bool, opts TableOptions) (*Table[T], error) {
+	table := &Table[T]{path: path, readOnly: readOnly, migrate: opts.Migrate, skipLoadGC: opts.SkipLoadGC, compressed: isCompressedPath(path)}
 	if err := table.load(); err != nil {
 		return nil, err
 	}
@@ -335,14 +578,60 @@ func (t *Table[T]) load() error {
 		}
 		return fmt.Errorf("failed to read table file %s: %w", t.path, err)
 	}
+	compact := false
+	if t.compressed {
+		if data, compact, err = gunzip(data); err != nil {
+			return fmt.Errorf("failed to decompress table file %s: %w", t.path, err)
+		}
+	}
 
-	n := bytes.Count(data, []byte{'\n'})
-	t.rows = make([]T, 0, n)
-	t.byID = make(map[ksid.ID]int, n)
+	schema, rows, byID, migrated, err := t.decodeLocked(data, t.path)
+	if err != nil {
+		return err
+	}
+	t.schema = schema
+	t.rows = rows
+	t.byID = byID
 	t.blobRefCount = make(map[BlobRef]int)
+	for _, row := range rows {
+		t.trackBlobRefsLocked(row)
+	}
+
+	// Clean up orphaned blob files; read-only tables never write to disk.
+	if t.readOnly {
+		return nil
+	}
+	if migrated {
+		if err := t.saveLocked(); err != nil {
+			return fmt.Errorf("failed to save migrated table: %w", err)
+		}
+	} else if compact {
+		// Each appended row is a gzip member of its own, larger than the row.
+		if err := t.saveLocked(); err != nil {
+			return fmt.Errorf("failed to compact table file: %w", err)
+		}
+	}
+	if t.skipLoadGC {
+		return nil
+	}
+	if _, _, err := t.blobStore.gc(t.blobRefCount, 0); err != nil {
+		return fmt.Errorf("failed to run blob GC: %w", err)
+	}
+	return nil
+}
+
+// decodeLocked parses the content of a table file; name identifies it in
+// errors. Rows are migrated if needed, validated and sorted by ID, and byID
+// indexes them. migrated reports that the header was replaced by the current
+// schema of T. The table itself is not modified. Caller must hold t.mu.
+func (t *Table[T]) decodeLocked(data []byte, name string) (schema schemaHeader, rows []T, byID map[ksid.ID]int, migrated bool, err error) {
+	n := bytes.Count(data, []byte{'\n'})
+	rows = make([]T, 0, n)
+	byID = make(map[ksid.ID]int, n)
 	lineNum := 0
 	var prevID ksid.ID
 	needsSort := false
+	var migrateTo []column // Columns of T when rows need to be migrated
 	for line := range bytes.SplitSeq(data, []byte{'\n'}) {
 		if len(line) == 0 {
 			continue
@@ -351,59 +640,71 @@ func (t *Table[T]) load() error {
 
 		// First line is the schema header
 		if lineNum == 1 {
-			if err := json.Unmarshal(line, &t.schema); err != nil {
-				return fmt.Errorf("failed to unmarshal schema header in %s: %w", t.path, err)
+			if err := json.Unmarshal(line, &schema); err != nil {
+				return schema, nil, nil, false, fmt.Errorf("failed to unmarshal schema header in %s: %w", name, err)
 			}
-			if err := t.schema.Validate(); err != nil {
-				return fmt.Errorf("invalid schema header in %s: %w", t.path, err)
+			if err := schema.Validate(); err != nil {
+				return schema, nil, nil, false, fmt.Errorf("invalid schema header in %s: %w", name, err)
+			}
+			if t.migrate != nil {
+				columns, err := schemaFromType[T]()
+				if err != nil {
+					return schema, nil, nil, false, fmt.Errorf("failed to discover schema from type: %w", err)
+				}
+				if !slices.Equal(schema.Columns, columns) {
+					migrateTo = columns
+				}
 			}
 			continue
 		}
 
 		// Subsequent lines are rows
+		if migrateTo != nil {
+			if line, err = t.migrate(line); err != nil {
+				return schema, nil, nil, false, fmt.Errorf("failed to migrate row in %s line %d: %w", name, lineNum, err)
+			}
+		}
 		var row T
 		if err := json.Unmarshal(line, &row); err != nil {
-			return fmt.Errorf("failed to unmarshal row in %s: %w", t.path, err)
+			return schema, nil, nil, false, fmt.Errorf("failed to unmarshal row in %s: %w", name, err)
 		}
 		// Inject blob store reference if row has blob fields
 		t.injectBlobStoreLocked(row)
-		// Track blob references for refcount
-		t.trackBlobRefsLocked(row)
 		if err := row.Validate(); err != nil {
-			return fmt.Errorf("invalid row in %s line %d: %w", t.path, lineNum, err)
+			return schema, nil, nil, false, fmt.Errorf("invalid row in %s line %d: %w", name, lineNum, err)
 		}
 		id := row.GetID()
 		if id.IsZero() {
-			return fmt.Errorf("row in %s line %d has zero ID", t.path, lineNum)
+			return schema, nil, nil, false, fmt.Errorf("row in %s line %d has zero ID", name, lineNum)
 		}
-		if _, exists := t.byID[id]; exists {
-			return fmt.Errorf("duplicate ID %s in %s line %d", id, t.path, lineNum)
+		if _, exists := byID[id]; exists {
+			return schema, nil, nil, false, fmt.Errorf("duplicate ID %s in %s line %d", id, name, lineNum)
 		}
 		// Check if rows are in sorted order (needed for Iter with startID)
 		if id < prevID {
 			needsSort = true
 		}
 		prevID = id
-		t.byID[id] = len(t.rows)
-		t.rows = append(t.rows, row)
+		byID[id] = len(rows)
+		rows = append(rows, row)
 	}
 
 	// Sort by ID if rows were out of order (e.g., clock drift, manual editing)
 	if needsSort {
-		slices.SortFunc(t.rows, func(a, b T) int {
+		slices.SortFunc(rows, func(a, b T) int {
 			return a.GetID().Compare(b.GetID())
 		})
 		// Rebuild index after sorting
-		for i, row := range t.rows {
-			t.byID[row.GetID()] = i
+		for i, row := range rows {
+			byID[row.GetID()] = i
 		}
 	}
 
-	// Clean up orphaned blob files.
-	if err := t.blobStore.gc(t.blobRefCount); err != nil {
-		return fmt.Errorf("failed to run blob GC: %w", err)
+	if migrateTo != nil {
+		schema.Version = currentVersion
+		schema.Columns = migrateTo
 	}
-	return nil
+	return schema, rows, byID, migrateTo != nil, nil
 }
 
 // injectBlobStoreLocked sets the store reference on all blob fields in the row. Caller must hold t.mu.
@@ -440,12 +741,41 @@ func (t *Table[T]) Iter(startID ksid.ID) iter.Seq[T] {
 	}
 }
 
+// Query returns clones of the rows for which match returns true, skipping the
+// first offset matches and returning at most limit rows, along with the total
+// number of matches.
+//
+// A nil match matches every row and a limit of 0 or less returns every match
+// after offset. match is called on the cached rows and must not modify them.
+// The reader lock is held once for the whole scan.
+func (t *Table[T]) Query(match func(T) bool, offset, limit int) ([]T, int) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	offset = max(0, offset)
+	var out []T
+	total := 0
+	for _, row := range t.rows {
+		if match != nil && !match(row) {
+			continue
+		}
+		if total >= offset && (limit <= 0 || len(out) < limit) {
+			out = append(out, row.Clone())
+		}
+		total++
+	}
+	return out, total
+}
+
 // Append adds a new row to the table and persists it.
 //
 // Returns an error if the row fails validation, has a zero ID, or has a duplicate ID.
 // If the new row's ID is less than the last row's ID (e.g., clock drift), the row is
 // inserted at the correct position and the entire file is rewritten to maintain sorted order.
 func (t *Table[T]) Append(row T) (err error) {
+	if t.readOnly {
+		return ErrReadOnly
+	}
 	if err := row.Validate(); err != nil {
 		return fmt.Errorf("invalid row: %w", err)
 	}
@@ -489,6 +819,14 @@ func (t *Table[T]) Append(row T) (err error) {
 		if err != nil {
 			return fmt.Errorf("failed to marshal row: %w", err)
 		}
+		data = append(data, '\n')
+		if t.compressed {
+			// A gzip stream may hold several members, so the row is appended
+			// as its own member.
+			if data, err = gzipBytes(data); err != nil {
+				return fmt.Errorf("failed to compress row: %w", err)
+			}
+		}
 
 		f, err := os.OpenFile(t.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644) //nolint:gosec // G302: 0o644 is intentional for user data files
 		if err != nil {
@@ -499,9 +837,14 @@ func (t *Table[T]) Append(row T) (err error) {
 				err = fmt.Errorf("failed to close table file: %w", cerr)
 			}
 		}()
-		if _, err := f.Write(append(data, '\n')); err != nil {
+		if _, err := f.Write(data); err != nil {
 			return fmt.Errorf("failed to write row: %w", err)
 		}
+		// The row is appended in place rather than by rewriting the file, so
+		// sync it before reporting success.
+		if err := f.Sync(); err != nil {
+			return fmt.Errorf("failed to sync table file: %w", err)
+		}
 
 		t.byID[id] = len(t.rows)
 		t.rows = append(t.rows, row)
@@ -517,75 +860,59 @@ func (t *Table[T]) Append(row T) (err error) {
 }
 
 // saveSchemaHeaderLocked writes just the schema header as the first line. Caller must hold t.mu.
-func (t *Table[T]) saveSchemaHeaderLocked() (err error) {
-	f, err := os.Create(t.path)
-	if err != nil {
-		return fmt.Errorf("failed to create table file: %w", err)
-	}
-	defer func() {
-		if cerr := f.Close(); cerr != nil && err == nil {
-			err = fmt.Errorf("failed to close table file: %w", cerr)
-		}
-	}()
-
-	writer := bufio.NewWriter(f)
-	headerData, err := json.Marshal(t.schema)
-	if err != nil {
-		return fmt.Errorf("failed to marshal schema header: %w", err)
-	}
-	if _, err := writer.Write(headerData); err != nil {
-		return fmt.Errorf("failed to write schema header: %w", err)
-	}
-	if err := writer.WriteByte('\n'); err != nil {
-		return fmt.Errorf("failed to write newline: %w", err)
-	}
-	if err := writer.Flush(); err != nil {
-		return fmt.Errorf("failed to flush writer: %w", err)
-	}
-	return nil
+func (t *Table[T]) saveSchemaHeaderLocked() error {
+	return t.writeFileLocked(func(w *bufio.Writer) error {
+		return writeSchemaHeader(w, t.schema)
+	})
 }
 
 // saveLocked writes the schema header and all rows to the file. Caller must hold t.mu.
-func (t *Table[T]) saveLocked() (err error) {
-	f, err := os.Create(t.path)
-	if err != nil {
-		return fmt.Errorf("failed to create table file: %w", err)
-	}
-	defer func() {
-		if cerr := f.Close(); cerr != nil && err == nil {
-			err = fmt.Errorf("failed to close table file: %w", cerr)
+func (t *Table[T]) saveLocked() error {
+	return t.writeFileLocked(func(w *bufio.Writer) error {
+		if err := writeSchemaHeader(w, t.schema); err != nil {
+			return err
 		}
-	}()
+		return writeRows(w, t.rows)
+	})
+}
 
-	writer := bufio.NewWriter(f)
+// writeFileLocked replaces the table file with what write produces, compressed
+// if the table is. Caller must hold t.mu.
+func (t *Table[T]) writeFileLocked(write func(w *bufio.Writer) error) error {
+	if t.compressed {
+		write = gzipWriter(write)
+	}
+	return writeFileDurable(t.path, write)
+}
 
-	// Write schema header as first line
-	headerData, err := json.Marshal(t.schema)
+// writeSchemaHeader writes the schema header line.
+func writeSchemaHeader(w *bufio.Writer, schema schemaHeader) error {
+	headerData, err := json.Marshal(schema)
 	if err != nil {
 		return fmt.Errorf("failed to marshal schema header: %w", err)
 	}
-	if _, err := writer.Write(headerData); err != nil {
+	if _, err := w.Write(headerData); err != nil {
 		return fmt.Errorf("failed to write schema header: %w", err)
 	}
-	if err := writer.WriteByte('\n'); err != nil {
+	if err := w.WriteByte('\n'); err != nil {
 		return fmt.Errorf("failed to write newline: %w", err)
 	}
+	return nil
+}
 
-	// Write rows
-	for _, row := range t.rows {
+// writeRows writes one line per row.
+func writeRows[T Row[T]](w *bufio.Writer, rows []T) error {
+	for _, row := range rows {
 		data, err := json.Marshal(row)
 		if err != nil {
 			return fmt.Errorf("failed to marshal row: %w", err)
 		}
-		if _, err := writer.Write(data); err != nil {
+		if _, err := w.Write(data); err != nil {
 			return fmt.Errorf("failed to write row: %w", err)
 		}
-		if err := writer.WriteByte('\n'); err != nil {
+		if err := w.WriteByte('\n'); err != nil {
 			return fmt.Errorf("failed to write newline: %w", err)
 		}
 	}
-	if err := writer.Flush(); err != nil {
-		return fmt.Errorf("failed to flush writer: %w", err)
-	}
 	return nil
 }
@@ -4,11 +4,15 @@ import (
 	"bytes"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"os"
 	"path/filepath"
 	"slices"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/maruel/ksid"
 )
@@ -97,6 +101,58 @@ func (r *blobTestRow) Validate() error {
 	return nil
 }
 
+// titleRow is testRow after its Name field was renamed to Title.
+type titleRow struct {
+	ID    int    `json:"id"`
+	Title string `json:"title"`
+}
+
+func (r *titleRow) Clone() *titleRow {
+	c := *r
+	return &c
+}
+
+func (r *titleRow) GetID() ksid.ID {
+	return ksid.ID(r.ID) //nolint:gosec // test code with small integers
+}
+
+func (r *titleRow) Validate() error {
+	return nil
+}
+
+// ttlRow is a row type with an expiry for testing PurgeExpired.
+type ttlRow struct {
+	ID        int   `json:"id"`
+	ExpiresAt int64 `json:"expires_at"` // Unix seconds; 0 = never
+}
+
+func (r *ttlRow) Clone() *ttlRow {
+	c := *r
+	return &c
+}
+
+func (r *ttlRow) GetID() ksid.ID {
+	return ksid.ID(r.ID) //nolint:gosec // test code
+}
+
+func (r *ttlRow) Validate() error {
+	return nil
+}
+
+func (r *ttlRow) Expiry() time.Time {
+	if r.ExpiresAt == 0 {
+		return time.Time{}
+	}
+	return time.Unix(r.ExpiresAt, 0)
+}
+
+// ttlDeletes records the IDs of rows deleted from a ttlRow table.
+type ttlDeletes []ksid.ID
+
+func (d *ttlDeletes) OnAppend(*ttlRow)      {}
+func (d *ttlDeletes) OnUpdate(_, _ *ttlRow) {}
+func (d *ttlDeletes) OnDelete(r *ttlRow)    { *d = append(*d, r.GetID()) }
+
 // setupTable creates a table in the test's temp directory.
 func setupTable(t *testing.T) (table *Table[*testRow], path string) {
 	path = filepath.Join(t.TempDir(), "test.jsonl")
@@ -113,18 +169,22 @@ type mockObserver struct {
 	appends []int
 	updates [][2]int // [prev, curr]
 	deletes []int
+	values  []string // Row names, e.g. "update a->b"
 }
 
 func (m *mockObserver) OnAppend(row *testRow) {
 	m.appends = append(m.appends, row.ID)
+	m.values = append(m.values, "append "+row.Name)
 }
 
 func (m *mockObserver) OnUpdate(prev, curr *testRow) {
 	m.updates = append(m.updates, [2]int{prev.ID, curr.ID})
+	m.values = append(m.values, "update "+prev.Name+"->"+curr.Name)
 }
 
 func (m *mockObserver) OnDelete(row *testRow) {
 	m.deletes = append(m.deletes, row.ID)
+	m.values = append(m.values, "delete "+row.Name)
 }
 
 // TestTable tests all Table methods using table-driven tests.
@@ -498,6 +558,167 @@ not valid json
 		})
 	})
 
+	t.Run("OpenTableReadOnly", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "test.jsonl")
+		rw, err := NewTable[*blobTestRow](path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w, err := rw.NewBlob()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte("content")); err != nil {
+			t.Fatal(err)
+		}
+		blob, err := w.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := rw.Append(&blobTestRow{ID: 1, Name: "one", Content: blob}); err != nil {
+			t.Fatal(err)
+		}
+		if err := rw.Append(&blobTestRow{ID: 2, Name: "two"}); err != nil {
+			t.Fatal(err)
+		}
+		// An orphan that a writable open would garbage collect.
+		w, err = rw.NewBlob()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte("orphan")); err != nil {
+			t.Fatal(err)
+		}
+		orphan, err := w.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		before, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		ro, err := OpenTableReadOnly[*blobTestRow](path)
+		if err != nil {
+			t.Fatalf("OpenTableReadOnly() error: %v", err)
+		}
+		t.Run("reads", func(t *testing.T) {
+			if ro.Len() != 2 {
+				t.Errorf("Len() = %d, want 2", ro.Len())
+			}
+			if got := ro.Get(ksid.ID(2)); got == nil || got.Name != "two" {
+				t.Errorf("Get(2) = %+v", got)
+			}
+			n := 0
+			for range ro.Iter(0) {
+				n++
+			}
+			if n != 2 {
+				t.Errorf("Iter() yielded %d rows, want 2", n)
+			}
+			r, err := ro.OpenBlob(ro.Get(ksid.ID(1)).Content)
+			if err != nil {
+				t.Fatalf("OpenBlob() error: %v", err)
+			}
+			data, err := io.ReadAll(r)
+			_ = r.Close()
+			if err != nil || string(data) != "content" {
+				t.Errorf("blob = %q, %v", data, err)
+			}
+		})
+		t.Run("mutations", func(t *testing.T) {
+			tests := []struct {
+				name string
+				fn   func() error
+			}{
+				{"Append", func() error { return ro.Append(&blobTestRow{ID: 3, Name: "three"}) }},
+				{"Update", func() error { _, err := ro.Update(&blobTestRow{ID: 1, Name: "x"}); return err }},
+				{"Delete", func() error { _, err := ro.Delete(ksid.ID(1)); return err }},
+				{"Modify", func() error {
+					_, err := ro.Modify(ksid.ID(1), func(*blobTestRow) error { return nil })
+					return err
+				}},
+				{"SetProperties", func() error { return ro.SetProperties(json.RawMessage(`{}`)) }},
+				{"NewBlob", func() error { _, err := ro.NewBlob(); return err }},
+				{"GCBlobs", func() error { _, _, err := ro.GCBlobs(); return err }},
+			}
+			for _, tt := range tests {
+				t.Run(tt.name, func(t *testing.T) {
+					if err := tt.fn(); !errors.Is(err, ErrReadOnly) {
+						t.Errorf("error = %v, want ErrReadOnly", err)
+					}
+				})
+			}
+			if ro.Len() != 2 {
+				t.Errorf("Len() = %d after rejected mutations, want 2", ro.Len())
+			}
+		})
+		t.Run("no disk writes", func(t *testing.T) {
+			after, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(before, after) {
+				t.Error("table file changed")
+			}
+			if _, err := os.Stat(rw.blobStore.pathForRef(orphan.Ref)); err != nil {
+				t.Errorf("orphan blob collected by read-only open: %v", err)
+			}
+		})
+	})
+
+	t.Run("Query", func(t *testing.T) {
+		table, _ := setupTable(t)
+		for i := 1; i <= 10; i++ {
+			if err := table.Append(&testRow{ID: i * 10, Name: fmt.Sprintf("Row %d", i)}); err != nil {
+				t.Fatal(err)
+			}
+		}
+		even := func(r *testRow) bool { return r.ID%20 == 0 }
+		none := func(*testRow) bool { return false }
+
+		tests := []struct {
+			name      string
+			match     func(*testRow) bool
+			offset    int
+			limit     int
+			wantIDs   []int
+			wantTotal int
+		}{
+			{"all", nil, 0, 0, []int{10, 20, 30, 40, 50, 60, 70, 80, 90, 100}, 10},
+			{"page", nil, 2, 3, []int{30, 40, 50}, 10},
+			{"predicate", even, 0, 0, []int{20, 40, 60, 80, 100}, 5},
+			{"predicate page", even, 1, 2, []int{40, 60}, 5},
+			{"predicate last page", even, 4, 2, []int{100}, 5},
+			{"offset beyond results", even, 5, 2, nil, 5},
+			{"negative offset", even, -1, 1, []int{20}, 5},
+			{"no match", none, 0, 0, nil, 0},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				rows, total := table.Query(tt.match, tt.offset, tt.limit)
+				if total != tt.wantTotal {
+					t.Errorf("total = %d, want %d", total, tt.wantTotal)
+				}
+				var ids []int
+				for _, r := range rows {
+					ids = append(ids, r.ID)
+				}
+				if !slices.Equal(ids, tt.wantIDs) {
+					t.Errorf("IDs = %v, want %v", ids, tt.wantIDs)
+				}
+			})
+		}
+
+		t.Run("returns clones", func(t *testing.T) {
+			rows, _ := table.Query(nil, 0, 1)
+			rows[0].Name = "mutated"
+			if got := table.Get(ksid.ID(10)); got.Name != "Row 1" {
+				t.Errorf("cached row mutated: %q", got.Name)
+			}
+		})
+	})
+
 	t.Run("Iter", func(t *testing.T) {
 		t.Run("valid", func(t *testing.T) {
 			table, _ := setupTable(t)
@@ -609,6 +830,50 @@ not valid json
 			})
 		})
 
+		t.Run("concurrent NewID", func(t *testing.T) {
+			table, path := setupTable(t)
+			const goroutines, perGoroutine = 16, 100
+			var wg sync.WaitGroup
+			errs := make(chan error, goroutines)
+			for g := range goroutines {
+				wg.Go(func() {
+					var last ksid.ID
+					for i := range perGoroutine {
+						id := ksid.NewID()
+						if id <= last {
+							errs <- fmt.Errorf("goroutine %d: ID %d not after %d", g, id, last)
+							return
+						}
+						last = id
+						row := &testRow{ID: int(id), Name: fmt.Sprint(g, i)} //nolint:gosec // IDs are below 2^63
+						if err := table.Append(row); err != nil {
+							errs <- err
+							return
+						}
+					}
+				})
+			}
+			wg.Wait()
+			close(errs)
+			for err := range errs {
+				t.Error(err)
+			}
+			table2, err := NewTable[*testRow](path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if table2.Len() != goroutines*perGoroutine {
+				t.Errorf("Len() = %d, want %d", table2.Len(), goroutines*perGoroutine)
+			}
+			var last ksid.ID
+			for row := range table2.Iter(0) {
+				if row.GetID() <= last {
+					t.Fatalf("rows out of order: %d after %d", row.GetID(), last)
+				}
+				last = row.GetID()
+			}
+		})
+
 		t.Run("errors", func(t *testing.T) {
 			t.Run("zero ID", func(t *testing.T) {
 				table, _ := setupTable(t)
@@ -969,6 +1234,14 @@ not valid json
 				t.Fatalf("orphan blob missing before reload: %v", err)
 			}
 
+			// SkipLoadGC keeps the orphan.
+			if _, err := NewTableWithOptions[*blobTestRow](path, TableOptions{SkipLoadGC: true}); err != nil {
+				t.Fatalf("reload error: %v", err)
+			}
+			if _, err := os.Stat(orphanPath); err != nil {
+				t.Errorf("orphan blob removed with SkipLoadGC: %v", err)
+			}
+
 			// Reload table - GC runs automatically
 			if _, err := NewTable[*blobTestRow](path); err != nil {
 				t.Fatalf("reload error: %v", err)
@@ -984,6 +1257,65 @@ not valid json
 			}
 		})
 
+		t.Run("GCBlobs", func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "test.jsonl")
+			table, err := NewTable[*blobTestRow](path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			newBlob := func(data string) Blob {
+				w, err := table.NewBlob()
+				if err != nil {
+					t.Fatal(err)
+				}
+				if _, err := w.Write([]byte(data)); err != nil {
+					t.Fatal(err)
+				}
+				b, err := w.Close()
+				if err != nil {
+					t.Fatal(err)
+				}
+				return b
+			}
+			kept := newBlob("still referenced")
+			if err := table.Append(&blobTestRow{ID: 1, Name: "kept", Content: kept}); err != nil {
+				t.Fatal(err)
+			}
+			// Written but never attached to a row, e.g. an abandoned upload.
+			dropped := newBlob("abandoned")
+
+			// Fresh blobs are within the grace period and must survive.
+			removed, size, err := table.GCBlobs()
+			if err != nil {
+				t.Fatalf("GCBlobs() error: %v", err)
+			}
+			if removed != 0 || size != 0 {
+				t.Errorf("GCBlobs() = %d, %d; want nothing removed within grace period", removed, size)
+			}
+
+			old := time.Now().Add(-2 * blobGCGracePeriod)
+			for _, b := range []Blob{kept, dropped} {
+				if err := os.Chtimes(table.blobStore.pathForRef(b.Ref), old, old); err != nil {
+					t.Fatal(err)
+				}
+			}
+			removed, size, err = table.GCBlobs()
+			if err != nil {
+				t.Fatalf("GCBlobs() error: %v", err)
+			}
+			if removed != 1 || size != int64(len("abandoned")) {
+				t.Errorf("GCBlobs() = %d, %d; want 1, %d", removed, size, len("abandoned"))
+			}
+			if _, err := os.Stat(table.blobStore.pathForRef(dropped.Ref)); !os.IsNotExist(err) {
+				t.Error("unreferenced blob still exists after GC")
+			}
+			r, err := table.OpenBlob(kept)
+			if err != nil {
+				t.Fatalf("referenced blob removed: %v", err)
+			}
+			_ = r.Close()
+		})
+
 		t.Run("lazy store creation", func(t *testing.T) {
 			path := filepath.Join(t.TempDir(), "test.jsonl")
 			table, err := NewTable[*blobTestRow](path)
@@ -1011,6 +1343,87 @@ not valid json
 				t.Errorf("blob tmp directory not created: %v", err)
 			}
 		})
+
+		t.Run("OpenBlob", func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "test.jsonl")
+			table, err := NewTable[*blobTestRow](path)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// 4 MiB of non-repeating data, written in chunks.
+			const size = 4 << 20
+			data := make([]byte, size)
+			for i := range data {
+				data[i] = byte(i * 7 / 3)
+			}
+			w, err := table.NewBlob()
+			if err != nil {
+				t.Fatal(err)
+			}
+			for chunk := range slices.Chunk(data, 64<<10) {
+				if _, err := w.Write(chunk); err != nil {
+					t.Fatal(err)
+				}
+			}
+			blob, err := w.Close()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := table.Append(&blobTestRow{ID: 1, Name: "big", Content: blob}); err != nil {
+				t.Fatal(err)
+			}
+
+			if n, err := table.BlobSize(blob); err != nil || n != size {
+				t.Errorf("BlobSize() = %d, %v; want %d", n, err, size)
+			}
+
+			loaded := table.Get(ksid.ID(1))
+			r, err := table.OpenBlob(loaded.Content)
+			if err != nil {
+				t.Fatalf("OpenBlob() error: %v", err)
+			}
+			defer func() { _ = r.Close() }()
+			const off = size - 1000
+			if pos, err := r.Seek(off, io.SeekStart); err != nil || pos != off {
+				t.Fatalf("Seek() = %d, %v", pos, err)
+			}
+			tail, err := io.ReadAll(r)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(tail, data[off:]) {
+				t.Errorf("read %d bytes after seek, content mismatch", len(tail))
+			}
+			if end, err := r.Seek(0, io.SeekEnd); err != nil || end != size {
+				t.Errorf("Seek(end) = %d, %v; want %d", end, err, size)
+			}
+
+			t.Run("unset", func(t *testing.T) {
+				if _, err := table.OpenBlob(Blob{}); !errors.Is(err, errUnsetBlob) {
+					t.Errorf("OpenBlob(unset) error = %v, want errUnsetBlob", err)
+				}
+			})
+			t.Run("invalid ref", func(t *testing.T) {
+				if _, err := table.OpenBlob(Blob{Ref: "sha256:bogus"}); !errors.Is(err, errInvalidBlobRef) {
+					t.Errorf("OpenBlob(invalid) error = %v, want errInvalidBlobRef", err)
+				}
+			})
+			t.Run("missing", func(t *testing.T) {
+				ref := BlobRef("sha256:" + strings.Repeat("A", 52) + "-100")
+				if _, err := table.OpenBlob(Blob{Ref: ref}); !errors.Is(err, os.ErrNotExist) {
+					t.Errorf("OpenBlob(missing) error = %v, want os.ErrNotExist", err)
+				}
+			})
+			t.Run("corrupt", func(t *testing.T) {
+				if err := os.Truncate(table.blobStore.pathForRef(blob.Ref), 10); err != nil {
+					t.Fatal(err)
+				}
+				if _, err := table.OpenBlob(blob); !errors.Is(err, errCorruptBlob) {
+					t.Errorf("OpenBlob(truncated) error = %v, want errCorruptBlob", err)
+				}
+			})
+		})
 	})
 
 	t.Run("Modify", func(t *testing.T) {
@@ -1116,6 +1529,168 @@ not valid json
 		})
 	})
 
+	t.Run("ModifyMany", func(t *testing.T) {
+		t.Run("success", func(t *testing.T) {
+			table, path := setupTable(t)
+			_ = table.Append(&testRow{ID: 1, Name: "one"})
+			_ = table.Append(&testRow{ID: 2, Name: "two"})
+			_ = table.Append(&testRow{ID: 3, Name: "three"})
+
+			rows, err := table.ModifyMany([]ksid.ID{3, 1}, func(rows []*testRow) error {
+				rows[0].Name, rows[1].Name = rows[1].Name, rows[0].Name
+				return nil
+			})
+			if err != nil {
+				t.Fatalf("ModifyMany error: %v", err)
+			}
+			if len(rows) != 2 || rows[0].Name != "one" || rows[1].Name != "three" {
+				t.Errorf("ModifyMany returned %+v", rows)
+			}
+
+			// Verify persisted
+			reloaded, err := NewTable[*testRow](path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for id, want := range map[ksid.ID]string{1: "three", 2: "two", 3: "one"} {
+				if got := reloaded.Get(id); got.Name != want {
+					t.Errorf("row %d = %q, want %q", id, got.Name, want)
+				}
+			}
+		})
+
+		t.Run("not found", func(t *testing.T) {
+			table, _ := setupTable(t)
+			_ = table.Append(&testRow{ID: 1, Name: "one"})
+
+			_, err := table.ModifyMany([]ksid.ID{1, 999}, func(rows []*testRow) error {
+				rows[0].Name = "modified"
+				return nil
+			})
+			if err == nil {
+				t.Error("ModifyMany with a non-existent row should return error")
+			}
+			if got := table.Get(ksid.ID(1)); got.Name != "one" {
+				t.Errorf("Row changed despite error: %q", got.Name)
+			}
+		})
+
+		t.Run("duplicate", func(t *testing.T) {
+			table, _ := setupTable(t)
+			_ = table.Append(&testRow{ID: 1, Name: "one"})
+
+			if _, err := table.ModifyMany([]ksid.ID{1, 1}, func(rows []*testRow) error { return nil }); err == nil {
+				t.Error("ModifyMany with a repeated ID should return error")
+			}
+		})
+
+		t.Run("validation error", func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "test.jsonl")
+			table, _ := NewTable[*validatingRow](path)
+			_ = table.Append(&validatingRow{ID: 1, Name: "valid"})
+			_ = table.Append(&validatingRow{ID: 2, Name: "valid"})
+
+			_, err := table.ModifyMany([]ksid.ID{1, 2}, func(rows []*validatingRow) error {
+				rows[0].Name = "changed"
+				rows[1].FailValidate = true
+				return nil
+			})
+			if err == nil {
+				t.Error("ModifyMany with an invalid result should return error")
+			}
+			if got := table.Get(ksid.ID(1)); got.Name != "valid" {
+				t.Errorf("Row changed despite validation error: %q", got.Name)
+			}
+		})
+
+		t.Run("notifies observers", func(t *testing.T) {
+			table, _ := setupTable(t)
+			_ = table.Append(&testRow{ID: 1, Name: "one"})
+			_ = table.Append(&testRow{ID: 2, Name: "two"})
+
+			obs := &mockObserver{}
+			table.AddObserver(obs)
+
+			_, _ = table.ModifyMany([]ksid.ID{1, 2}, func(rows []*testRow) error { return nil })
+
+			if len(obs.updates) != 2 {
+				t.Errorf("Observer updates = %d, want 2", len(obs.updates))
+			}
+		})
+	})
+
+	t.Run("PurgeExpired", func(t *testing.T) {
+		t.Run("removes only expired rows", func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "test.jsonl")
+			table, err := NewTable[*ttlRow](path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var deleted ttlDeletes
+			table.AddObserver(&deleted)
+			const now = 1000
+			rows := []*ttlRow{
+				{ID: 1, ExpiresAt: now - 10}, // expired
+				{ID: 2, ExpiresAt: now + 10}, // live
+				{ID: 3, ExpiresAt: 0},        // never expires
+				{ID: 4, ExpiresAt: now},      // expires exactly now
+				{ID: 5, ExpiresAt: now - 1},  // expired
+				{ID: 6, ExpiresAt: now + 1},  // live
+			}
+			for _, r := range rows {
+				if err := table.Append(r); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			n, err := table.PurgeExpired(time.Unix(now, 0))
+			if err != nil {
+				t.Fatalf("PurgeExpired() error: %v", err)
+			}
+			if n != 3 {
+				t.Errorf("PurgeExpired() = %d, want 3", n)
+			}
+			if want := (ttlDeletes{1, 4, 5}); !slices.Equal(deleted, want) {
+				t.Errorf("OnDelete calls = %v, want %v", deleted, want)
+			}
+			check := func(t *testing.T, table *Table[*ttlRow]) {
+				t.Helper()
+				var ids []ksid.ID
+				for r := range table.Iter(0) {
+					ids = append(ids, r.GetID())
+				}
+				if want := []ksid.ID{2, 3, 6}; !slices.Equal(ids, want) {
+					t.Errorf("remaining rows = %v, want %v", ids, want)
+				}
+				for _, id := range []ksid.ID{2, 3, 6} {
+					if table.Get(id) == nil {
+						t.Errorf("Get(%d) = nil after purge", id)
+					}
+				}
+			}
+			check(t, table)
+
+			// Persisted in a single rewrite.
+			reloaded, err := NewTable[*ttlRow](path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			check(t, reloaded)
+
+			// Nothing left to purge.
+			if n, err := table.PurgeExpired(time.Unix(now, 0)); err != nil || n != 0 {
+				t.Errorf("second PurgeExpired() = %d, %v; want 0", n, err)
+			}
+		})
+
+		t.Run("requires Expirer", func(t *testing.T) {
+			table, _ := setupTable(t)
+			if _, err := table.PurgeExpired(time.Now()); !errors.Is(err, errNotExpirer) {
+				t.Errorf("PurgeExpired() error = %v, want errNotExpirer", err)
+			}
+		})
+	})
+
 	t.Run("Observers", func(t *testing.T) {
 		t.Run("notifies on operations", func(t *testing.T) {
 			table, _ := setupTable(t)
@@ -1148,6 +1723,48 @@ not valid json
 			}
 		})
 
+		t.Run("previous values", func(t *testing.T) {
+			table, _ := setupTable(t)
+			obs := &mockObserver{}
+			table.AddObserver(obs)
+
+			for _, r := range []*testRow{{ID: 1, Name: "a"}, {ID: 2, Name: "b"}} {
+				if err := table.Append(r); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if _, err := table.Update(&testRow{ID: 1, Name: "a2"}); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := table.Modify(ksid.ID(2), func(row *testRow) error {
+				row.Name = "b2"
+				return nil
+			}); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := table.ModifyMany([]ksid.ID{2, 1}, func(rows []*testRow) error {
+				for _, row := range rows {
+					row.Name += "3"
+				}
+				return nil
+			}); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := table.Delete(ksid.ID(1)); err != nil {
+				t.Fatal(err)
+			}
+			want := []string{
+				"append a", "append b",
+				"update a->a2",
+				"update b->b2",
+				"update b2->b23", "update a2->a23",
+				"delete a23",
+			}
+			if !slices.Equal(obs.values, want) {
+				t.Errorf("observer values =\n%q\nwant\n%q", obs.values, want)
+			}
+		})
+
 		t.Run("multiple observers", func(t *testing.T) {
 			table, _ := setupTable(t)
 
@@ -1384,6 +2001,58 @@ not valid json
 				t.Errorf("column 'name' type = %q, want %q", colType, columnTypeText)
 			}
 		})
+
+		t.Run("rewrite keeps only live rows", func(t *testing.T) {
+			table, path := setupTable(t)
+			for i := 1; i <= 50; i++ {
+				if err := table.Append(&testRow{ID: i, Name: "v1"}); err != nil {
+					t.Fatal(err)
+				}
+			}
+			for round := range 5 {
+				for i := 1; i <= 50; i++ {
+					if _, err := table.Update(&testRow{ID: i, Name: fmt.Sprintf("v%d", round+2)}); err != nil {
+						t.Fatal(err)
+					}
+				}
+			}
+			for i := 1; i <= 50; i += 2 {
+				if _, err := table.Delete(ksid.ID(i)); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			content, err := os.ReadFile(path) //nolint:gosec // G304: path is controlled by test
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := bytes.Count(content, []byte{'\n'}); got != 1+25 {
+				t.Errorf("file has %d lines, want header + 25 rows", got)
+			}
+			entries, err := os.ReadDir(filepath.Dir(path))
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, e := range entries {
+				if e.Name() != filepath.Base(path) {
+					t.Errorf("leftover file %q", e.Name())
+				}
+			}
+
+			reloaded, err := NewTable[*testRow](path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			rows := slices.Collect(reloaded.Iter(0))
+			if len(rows) != 25 {
+				t.Fatalf("reloaded %d rows, want 25", len(rows))
+			}
+			for _, r := range rows {
+				if r.ID%2 != 0 || r.Name != "v6" {
+					t.Errorf("unexpected row %+v", r)
+				}
+			}
+		})
 	})
 }
 
@@ -1449,6 +2118,245 @@ func TestRow(t *testing.T) {
 	})
 }
 
+func TestNewTableWithOptions(t *testing.T) {
+	t.Run("Migrate", func(t *testing.T) {
+		old, path := setupTable(t)
+		for i, name := range []string{"One", "Two"} {
+			if err := old.Append(&testRow{ID: i + 1, Name: name}); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := old.SetProperties(json.RawMessage(`{"k":"v"}`)); err != nil {
+			t.Fatal(err)
+		}
+
+		calls := 0
+		rename := func(row json.RawMessage) (json.RawMessage, error) {
+			calls++
+			var m map[string]any
+			if err := json.Unmarshal(row, &m); err != nil {
+				return nil, err
+			}
+			m["title"] = m["name"]
+			delete(m, "name")
+			return json.Marshal(m)
+		}
+		open := func() *Table[*titleRow] {
+			t.Helper()
+			table, err := NewTableWithOptions[*titleRow](path, TableOptions{Migrate: rename})
+			if err != nil {
+				t.Fatal(err)
+			}
+			return table
+		}
+
+		table := open()
+		if calls != 2 {
+			t.Errorf("migrate called %d times, want 2", calls)
+		}
+		var titles []string
+		for r := range table.Iter(0) {
+			titles = append(titles, r.Title)
+		}
+		if want := []string{"One", "Two"}; !slices.Equal(titles, want) {
+			t.Errorf("titles = %q, want %q", titles, want)
+		}
+		if got := string(table.Properties()); got != `{"k":"v"}` {
+			t.Errorf("properties = %s", got)
+		}
+
+		// The file was rewritten with the new header so it is migrated once.
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		header, rows, _ := bytes.Cut(data, []byte{'\n'})
+		if !bytes.Contains(header, []byte(`"name":"title"`)) || bytes.Contains(rows, []byte(`"name"`)) {
+			t.Errorf("file not migrated:\n%s", data)
+		}
+		open()
+		if calls != 2 {
+			t.Errorf("migrate called %d times after reopening, want 2", calls)
+		}
+	})
+
+	t.Run("MigrateError", func(t *testing.T) {
+		old, path := setupTable(t)
+		if err := old.Append(&testRow{ID: 1, Name: "One"}); err != nil {
+			t.Fatal(err)
+		}
+		before, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fail := func(json.RawMessage) (json.RawMessage, error) { return nil, errors.New("boom") }
+		if _, err := NewTableWithOptions[*titleRow](path, TableOptions{Migrate: fail}); err == nil {
+			t.Fatal("expected error")
+		}
+		if after, _ := os.ReadFile(path); !bytes.Equal(before, after) {
+			t.Errorf("file changed on failed migration:\n%s", after)
+		}
+	})
+
+	t.Run("SameSchema", func(t *testing.T) {
+		old, path := setupTable(t)
+		if err := old.Append(&testRow{ID: 1, Name: "One"}); err != nil {
+			t.Fatal(err)
+		}
+		never := func(json.RawMessage) (json.RawMessage, error) { return nil, errors.New("unexpected migration") }
+		table, err := NewTableWithOptions[*testRow](path, TableOptions{Migrate: never})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := table.Get(1); got == nil || got.Name != "One" {
+			t.Errorf("Get(1) = %+v", got)
+		}
+	})
+}
+
+func TestCompressedTable(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "test.jsonl.gz")
+	plainPath := filepath.Join(dir, "test.jsonl")
+	table, err := NewTable[*testRow](path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	plain, err := NewTable[*testRow](plainPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 1; i <= 200; i++ {
+		row := &testRow{ID: i, Name: strings.Repeat(fmt.Sprintf("row %d ", i%7), 10)}
+		if err := table.Append(row); err != nil {
+			t.Fatal(err)
+		}
+		if err := plain.Append(row.Clone()); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Rows appended in place as separate gzip members are read back, and the
+	// file is compacted into one member.
+	appended, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reloaded, err := NewTable[*testRow](path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reloaded.Len() != 200 {
+		t.Fatalf("Len() = %d, want 200", reloaded.Len())
+	}
+	if fi, err := os.Stat(path); err != nil {
+		t.Fatal(err)
+	} else if fi.Size()*4 > appended.Size() {
+		t.Errorf("file is %d bytes after load, %d bytes before: not compacted", fi.Size(), appended.Size())
+	}
+	if _, err := reloaded.Update(&testRow{ID: 5, Name: "updated"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := reloaded.Delete(ksid.ID(6)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := plain.Update(&testRow{ID: 5, Name: "updated"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := plain.Delete(ksid.ID(6)); err != nil {
+		t.Fatal(err)
+	}
+
+	// Rewritten files round-trip too.
+	reloaded, err = NewTable[*testRow](path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []*testRow
+	for row := range reloaded.Iter(0) {
+		got = append(got, row)
+	}
+	var want []*testRow
+	for row := range plain.Iter(0) {
+		want = append(want, row)
+	}
+	if len(got) != 199 || len(got) != len(want) {
+		t.Fatalf("got %d rows, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if *got[i] != *want[i] {
+			t.Errorf("row %d: got %+v, want %+v", i, got[i], want[i])
+		}
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(data, []byte{0x1f, 0x8b}) {
+		t.Fatalf("file is not gzip-compressed: %q", data[:min(len(data), 16)])
+	}
+	plainData, err := os.ReadFile(plainPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data)*4 > len(plainData) {
+		t.Errorf("compressed file is %d bytes, plain file is %d bytes", len(data), len(plainData))
+	}
+	if dec, compact, err := gunzip(data); err != nil || compact || !bytes.Equal(dec, plainData) {
+		t.Errorf("decompressed content differs from the plain file: %v", err)
+	}
+}
+
+func TestCompressedTableTornAppend(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.jsonl.gz")
+	table, err := NewTable[*testRow](path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 1; i <= 3; i++ {
+		if err := table.Append(&testRow{ID: i, Name: "row"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Simulate a crash in the middle of appending a fourth row.
+	member, err := gzipBytes([]byte(`{"id":4,"name":"torn"}` + "\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, n := range []int{5, len(member) / 2, len(member) - 1} {
+		if err := os.WriteFile(path, append(slices.Clip(data), member[:n]...), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		reloaded, err := NewTable[*testRow](path)
+		if err != nil {
+			t.Fatalf("%d bytes of the member: %v", n, err)
+		}
+		if reloaded.Len() != 3 || reloaded.Get(ksid.ID(4)) != nil {
+			t.Errorf("%d bytes of the member: Len() = %d, want 3", n, reloaded.Len())
+		}
+		// The file was rewritten without the torn member.
+		if _, err := NewTable[*testRow](path); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// A truncated first member, holding the schema header, is still an error.
+	if data, err = os.ReadFile(path); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, data[:len(data)/2], 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewTable[*testRow](path); err == nil {
+		t.Error("want error for a truncated first member")
+	}
+}
+
 func TestDeriveBlobDir(t *testing.T) {
 	tests := []struct {
 		path string
@@ -1458,6 +2366,7 @@ func TestDeriveBlobDir(t *testing.T) {
 		{"/path/to/table.jsonl", "/path/to/table.blobs"},
 		{"noext", "noext.blobs"},
 		{"/path/file.data", "/path/file.blobs"},
+		{"/path/to/table.jsonl.gz", "/path/to/table.blobs"},
 	}
 	for _, tt := range tests {
 		t.Run(tt.path, func(t *testing.T) {
@@ -5,25 +5,38 @@ package notion
 import (
 	"crypto/sha256"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
+	"net/netip"
 	"net/url"
 	"os"
 	"path"
 	"path/filepath"
 	"strings"
 	"sync"
+	"syscall"
 	"time"
 
 	"github.com/maruel/ksid"
 )
 
+// DefaultMaxAssetBytes is the default size limit of one downloaded asset.
+const DefaultMaxAssetBytes = 100 << 20
+
+// errAssetTooLarge is returned when an asset exceeds the size limit.
+var errAssetTooLarge = errors.New("asset too large")
+
 // AssetDownloader handles downloading and caching of Notion assets.
 type AssetDownloader struct {
-	client    *http.Client
-	outputDir string
-	mu        sync.Mutex
+	client       *http.Client
+	outputDir    string
+	throttle     func()                       // optional, called before each download
+	maxBytes     int64                        // size limit of one asset
+	checkStorage func(additional int64) error // optional, rejects assets over the storage quota
+	mu           sync.Mutex
 
 	// downloaded tracks URL -> local path mapping
 	downloaded map[string]string
@@ -38,13 +51,46 @@ type AssetDownloader struct {
 func NewAssetDownloader(outputDir string) *AssetDownloader {
 	return &AssetDownloader{
 		client: &http.Client{
-			Timeout: 60 * time.Second,
+			Timeout:   60 * time.Second,
+			Transport: publicTransport(),
 		},
 		outputDir:  outputDir,
+		maxBytes:   DefaultMaxAssetBytes,
 		downloaded: make(map[string]string),
 	}
 }
 
+// publicTransport returns an HTTP transport that only connects to public
+// addresses. Covers and icons can point anywhere, so without it an import
+// could be used to reach the server's internal network.
+//
+// The check runs on the resolved address of every connection, including
+// redirects, so DNS can't be used to get around it.
+func publicTransport() *http.Transport {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.Proxy = nil
+	t.DialContext = (&net.Dialer{
+		Timeout: 30 * time.Second,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			ap, err := netip.ParseAddrPort(address)
+			if err != nil {
+				return err
+			}
+			if !isPublicAddr(ap.Addr()) {
+				return fmt.Errorf("refusing to connect to non-public address %s", ap.Addr())
+			}
+			return nil
+		},
+	}).DialContext
+	return t
+}
+
+// isPublicAddr reports whether ip is a globally routable unicast address.
+func isPublicAddr(ip netip.Addr) bool {
+	ip = ip.Unmap()
+	return ip.IsGlobalUnicast() && !ip.IsPrivate() && !ip.IsLoopback() && !ip.IsLinkLocalUnicast()
+}
+
 // DownloadAsset downloads an asset from URL and returns the local path.
 // Returns empty string if the URL is external (not a Notion-hosted file).
 // The asset is stored in {nodeDir}/assets/{hash}-{filename}.
@@ -58,7 +104,15 @@ func (d *AssetDownloader) DownloadAsset(nodeID ksid.ID, assetURL string) (string
 		d.Skipped++
 		return assetURL, nil // Return original URL for external assets
 	}
+	return d.download(nodeID, assetURL)
+}
 
+// download fetches assetURL into the node directory, wherever it is hosted,
+// and returns the local path.
+func (d *AssetDownloader) download(nodeID ksid.ID, assetURL string) (string, error) {
+	if assetURL == "" {
+		return "", nil
+	}
 	d.mu.Lock()
 	if localPath, ok := d.downloaded[assetURL]; ok {
 		d.mu.Unlock()
@@ -99,6 +153,9 @@ func (d *AssetDownloader) DownloadAsset(nodeID ksid.ID, assetURL string) (string
 	localPath := filepath.Join(nodeDir, uniqueFilename)
 
 	// Download the file
+	if d.throttle != nil {
+		d.throttle()
+	}
 	resp, err := d.client.Get(assetURL)
 	if err != nil {
 		d.Errors++
@@ -110,6 +167,16 @@ func (d *AssetDownloader) DownloadAsset(nodeID ksid.ID, assetURL string) (string
 		d.Errors++
 		return "", fmt.Errorf("download failed: status %d", resp.StatusCode)
 	}
+	if resp.ContentLength > d.maxBytes {
+		d.Errors++
+		return "", fmt.Errorf("%w: %d bytes", errAssetTooLarge, resp.ContentLength)
+	}
+	if d.checkStorage != nil {
+		if err := d.checkStorage(max(resp.ContentLength, 0)); err != nil {
+			d.Errors++
+			return "", err
+		}
+	}
 
 	// Create local file
 	f, err := os.Create(localPath) //nolint:gosec // G304: localPath is constructed from validated nodeID
@@ -118,7 +185,13 @@ func (d *AssetDownloader) DownloadAsset(nodeID ksid.ID, assetURL string) (string
 		return "", fmt.Errorf("failed to create file: %w", err)
 	}
 
-	if _, err := io.Copy(f, resp.Body); err != nil {
+	// Read one byte past the limit to tell a file of exactly maxBytes from a
+	// larger one.
+	n, err := io.Copy(f, io.LimitReader(resp.Body, d.maxBytes+1))
+	if err == nil && n > d.maxBytes {
+		err = errAssetTooLarge
+	}
+	if err != nil {
 		_ = f.Close()
 		_ = os.Remove(localPath) // Clean up partial file
 		d.Errors++
@@ -128,6 +201,15 @@ func (d *AssetDownloader) DownloadAsset(nodeID ksid.ID, assetURL string) (string
 		d.Errors++
 		return "", fmt.Errorf("failed to close file: %w", err)
 	}
+	// The size may not have been known upfront; the file now counts in the
+	// usage.
+	if d.checkStorage != nil {
+		if err := d.checkStorage(0); err != nil {
+			_ = os.Remove(localPath)
+			d.Errors++
+			return "", err
+		}
+	}
 
 	// Store relative path for use in markdown (just filename, same directory as index.md)
 	relativePath := uniqueFilename
@@ -199,8 +281,8 @@ func (d *AssetDownloader) ProcessFileValue(nodeID ksid.ID, fv *FileValue) (strin
 	return d.DownloadAsset(nodeID, assetURL)
 }
 
-// ProcessIcon downloads an icon if it's a file and returns the result.
-// Returns the emoji string for emoji icons, or local path for file-based icons.
+// ProcessIcon downloads an icon image and returns the result.
+// Returns the emoji string for emoji icons, or local path for image icons.
 func (d *AssetDownloader) ProcessIcon(nodeID ksid.ID, icon *Icon) (string, error) {
 	if icon == nil {
 		return "", nil
@@ -211,20 +293,28 @@ func (d *AssetDownloader) ProcessIcon(nodeID ksid.ID, icon *Icon) (string, error
 		return icon.Emoji, nil
 	case "file":
 		if icon.File != nil {
-			return d.DownloadAsset(nodeID, icon.File.URL)
+			return d.download(nodeID, icon.File.URL)
 		}
 	case "external":
 		if icon.External != nil {
-			return d.DownloadAsset(nodeID, icon.External.URL)
+			return d.download(nodeID, icon.External.URL)
 		}
 	}
 	return "", nil
 }
 
 // ProcessCover downloads a cover image and returns the local path.
-func (d *AssetDownloader) ProcessCover(nodeID ksid.ID, cover *File) (string, error) {
+// Unlike inline assets, external covers are downloaded too since the page
+// header would break when the remote image goes away.
+func (d *AssetDownloader) ProcessCover(nodeID ksid.ID, cover *Cover) (string, error) {
 	if cover == nil {
 		return "", nil
 	}
-	return d.DownloadAsset(nodeID, cover.URL)
+	switch {
+	case cover.File != nil:
+		return d.download(nodeID, cover.File.URL)
+	case cover.External != nil:
+		return d.download(nodeID, cover.External.URL)
+	}
+	return "", nil
 }
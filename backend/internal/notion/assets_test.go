@@ -3,11 +3,17 @@
 package notion
 
 import (
+	"errors"
 	"net/http"
 	"net/http/httptest"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/maruel/ksid"
+	"github.com/maruel/mddb/backend/internal/storage/content"
 )
 
 func TestAssetDownloader_ExternalURLsPassThrough(t *testing.T) {
@@ -77,3 +83,148 @@ func TestAssetDownloader_RelativePathIsJustFilename(t *testing.T) {
 	// This matches how workspace_store.go stores assets:
 	// filePath := filepath.Join(dir, assetName)  // Same dir as index.md
 }
+
+func TestAssetDownloader_Limits(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(strings.Repeat("x", 10)))
+	}))
+	defer server.Close()
+	nodeID := ksid.NewID()
+
+	t.Run("NonPublicAddress", func(t *testing.T) {
+		d := NewAssetDownloader(t.TempDir())
+		if _, err := d.download(nodeID, server.URL+"/a.png"); err == nil || !strings.Contains(err.Error(), "non-public address") {
+			t.Fatalf("got %v, want a non-public address error", err)
+		}
+	})
+
+	t.Run("TooLarge", func(t *testing.T) {
+		dir := t.TempDir()
+		d := NewAssetDownloader(dir)
+		d.client = server.Client()
+		d.maxBytes = 9
+		if _, err := d.download(nodeID, server.URL+"/a.png"); !errors.Is(err, errAssetTooLarge) {
+			t.Fatalf("got %v, want errAssetTooLarge", err)
+		}
+		if entries, _ := os.ReadDir(filepath.Join(dir, nodeID.String())); len(entries) != 0 {
+			t.Errorf("partial file kept: %v", entries)
+		}
+		d.maxBytes = 10
+		if _, err := d.download(nodeID, server.URL+"/a.png"); err != nil {
+			t.Fatalf("exactly at the limit: %v", err)
+		}
+	})
+
+	t.Run("StorageQuota", func(t *testing.T) {
+		dir := t.TempDir()
+		d := NewAssetDownloader(dir)
+		d.client = server.Client()
+		d.checkStorage = func(additional int64) error {
+			if additional != 0 {
+				return nil
+			}
+			// The downloaded file now counts in the usage.
+			return content.ErrQuotaExceeded
+		}
+		if _, err := d.download(nodeID, server.URL+"/a.png"); !errors.Is(err, content.ErrQuotaExceeded) {
+			t.Fatalf("got %v, want ErrQuotaExceeded", err)
+		}
+		if entries, _ := os.ReadDir(filepath.Join(dir, nodeID.String())); len(entries) != 0 {
+			t.Errorf("file over quota kept: %v", entries)
+		}
+	})
+}
+
+func TestIsPublicAddr(t *testing.T) {
+	for _, tc := range []struct {
+		addr string
+		want bool
+	}{
+		{"8.8.8.8", true},
+		{"2606:4700::1111", true},
+		{"127.0.0.1", false},
+		{"::1", false},
+		{"10.1.2.3", false},
+		{"192.168.0.1", false},
+		{"169.254.169.254", false},
+		{"fe80::1", false},
+		{"fd00::1", false},
+		{"0.0.0.0", false},
+		{"::ffff:127.0.0.1", false},
+	} {
+		if got := isPublicAddr(netip.MustParseAddr(tc.addr)); got != tc.want {
+			t.Errorf("isPublicAddr(%s) = %v, want %v", tc.addr, got, tc.want)
+		}
+	}
+}
+
+func TestMapPageIconCover(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing.png" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte("png"))
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	downloader := NewAssetDownloader(dir)
+	downloader.client = server.Client() // The test server is on loopback.
+	throttled := 0
+	downloader.throttle = func() { throttled++ }
+	m := NewMapper()
+
+	page := &Page{
+		Icon:  &Icon{Type: "emoji", Emoji: "🚀"},
+		Cover: &Cover{Type: "external", External: &File{URL: server.URL + "/cover.png"}},
+	}
+	node := &content.Node{ID: ksid.NewID(), Title: "Launch", Type: content.NodeTypeDocument}
+	if errs := m.MapPageIconCover(node, page, downloader); len(errs) != 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	if node.Icon != "🚀" {
+		t.Errorf("Icon = %q", node.Icon)
+	}
+	if node.Cover == "" || strings.Contains(node.Cover, "/") {
+		t.Errorf("Cover = %q, want a local file name", node.Cover)
+	}
+	if _, err := os.Stat(filepath.Join(dir, node.ID.String(), node.Cover)); err != nil {
+		t.Errorf("cover not saved: %v", err)
+	}
+	if throttled != 1 || downloader.Downloaded != 1 {
+		t.Errorf("throttled = %d, downloaded = %d, want 1 and 1", throttled, downloader.Downloaded)
+	}
+
+	// The icon and cover end up in the front matter.
+	w := NewWriter(dir, "")
+	if err := w.WriteNode(node, "body"); err != nil {
+		t.Fatal(err)
+	}
+	md, err := os.ReadFile(filepath.Join(dir, node.ID.String(), "index.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(md), "icon: 🚀\n") || !strings.Contains(string(md), "cover: "+node.Cover+"\n") {
+		t.Errorf("front matter missing icon or cover:\n%s", md)
+	}
+
+	// A failed cover download is reported, not fatal.
+	page.Icon = &Icon{Type: "file", File: &File{URL: server.URL + "/icon.png"}}
+	page.Cover = &Cover{Type: "file", File: &File{URL: server.URL + "/missing.png"}}
+	node = &content.Node{ID: ksid.NewID()}
+	errs := m.MapPageIconCover(node, page, downloader)
+	if len(errs) != 1 || !strings.HasPrefix(errs[0].Error(), "cover:") {
+		t.Errorf("errs = %v, want one cover error", errs)
+	}
+	if node.Icon == "" || node.Cover != "" {
+		t.Errorf("Icon = %q, Cover = %q", node.Icon, node.Cover)
+	}
+
+	// Without content, emoji icons are kept and nothing is downloaded.
+	page.Icon = &Icon{Type: "emoji", Emoji: "📝"}
+	node = &content.Node{ID: ksid.NewID()}
+	if errs := m.MapPageIconCover(node, page, nil); len(errs) != 0 || node.Icon != "📝" || node.Cover != "" {
+		t.Errorf("without assets: errs = %v, node = %+v", errs, node)
+	}
+}
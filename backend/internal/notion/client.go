@@ -9,7 +9,10 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strconv"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -18,36 +21,58 @@ const (
 	BaseURL = "https://api.notion.com/v1"
 	// APIVersion is the pinned Notion API version.
 	APIVersion = "2022-06-28"
-	// MinInterval is the minimum time between requests (3 req/sec).
-	MinInterval = 334 * time.Millisecond
+	// DefaultRequestsPerSecond is the average rate Notion allows per integration.
+	DefaultRequestsPerSecond = 3
 )
 
+// ClientOptions configures a Client.
+type ClientOptions struct {
+	// RequestsPerSecond caps the request rate. 0 means unlimited.
+	RequestsPerSecond float64
+	// HTTPClient is used to send requests. nil means a client with a 30s
+	// timeout.
+	HTTPClient *http.Client
+}
+
 // Client is a rate-limited Notion API client.
 type Client struct {
 	token       string
 	httpClient  *http.Client
+	minInterval time.Duration
 	lastRequest time.Time
 	mu          sync.Mutex
+	requests    atomic.Int64 // API requests sent, for DryRun estimates
 }
 
-// NewClient creates a new Notion API client.
+// NewClient creates a new Notion API client limited to
+// DefaultRequestsPerSecond.
 func NewClient(token string) *Client {
-	return &Client{
-		token: token,
-		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
-		},
+	return NewClientWithOptions(token, ClientOptions{RequestsPerSecond: DefaultRequestsPerSecond})
+}
+
+// NewClientWithOptions creates a new Notion API client configured by opts.
+func NewClientWithOptions(token string, opts ClientOptions) *Client {
+	c := &Client{token: token, httpClient: opts.HTTPClient}
+	if c.httpClient == nil {
+		c.httpClient = &http.Client{Timeout: 30 * time.Second}
+	}
+	if opts.RequestsPerSecond > 0 {
+		c.minInterval = time.Duration(float64(time.Second) / opts.RequestsPerSecond)
 	}
+	return c
 }
 
 // throttle ensures rate limiting between requests.
 func (c *Client) throttle() {
+	if c.minInterval <= 0 {
+		return
+	}
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
 	elapsed := time.Since(c.lastRequest)
-	if elapsed < MinInterval {
-		time.Sleep(MinInterval - elapsed)
+	if elapsed < c.minInterval {
+		time.Sleep(c.minInterval - elapsed)
 	}
 	c.lastRequest = time.Now()
 }
@@ -55,6 +80,7 @@ func (c *Client) throttle() {
 // do performs an HTTP request with rate limiting.
 func (c *Client) do(ctx context.Context, method, path string, body any) ([]byte, error) {
 	c.throttle()
+	c.requests.Add(1)
 
 	var bodyReader io.Reader
 	if body != nil {
@@ -96,6 +122,37 @@ func (c *Client) do(ctx context.Context, method, path string, body any) ([]byte,
 	return respBody, nil
 }
 
+// assetSize returns the size in bytes of the file at assetURL without
+// downloading it, or -1 if the host does not tell. Notion's presigned URLs
+// only allow GET, so it asks for the first byte and reads the total from
+// Content-Range.
+func (c *Client) assetSize(ctx context.Context, assetURL string) (int64, error) {
+	c.throttle()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, assetURL, http.NoBody)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Range", "bytes=0-0")
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("request failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	switch resp.StatusCode {
+	case http.StatusPartialContent:
+		if _, total, ok := strings.Cut(resp.Header.Get("Content-Range"), "/"); ok {
+			if n, err := strconv.ParseInt(total, 10, 64); err == nil {
+				return n, nil
+			}
+		}
+		return -1, nil
+	case http.StatusOK:
+		return resp.ContentLength, nil
+	default:
+		return 0, fmt.Errorf("status %d", resp.StatusCode)
+	}
+}
+
 // BotUser represents the bot user response from /users/me.
 type BotUser struct {
 	Object    string `json:"object"`
@@ -0,0 +1,71 @@
+package notion
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeTransport answers every request with an empty bot user.
+type fakeTransport struct {
+	calls atomic.Int32
+}
+
+func (f *fakeTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	f.calls.Add(1)
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       io.NopCloser(strings.NewReader(`{"object":"user"}`)),
+		Request:    req,
+	}, nil
+}
+
+func TestClient_RateLimit(t *testing.T) {
+	const n = 5
+	run := func(t *testing.T, rps float64) time.Duration {
+		ft := &fakeTransport{}
+		c := NewClientWithOptions("tok", ClientOptions{RequestsPerSecond: rps, HTTPClient: &http.Client{Transport: ft}})
+		start := time.Now()
+		for range n {
+			if _, err := c.GetMe(t.Context()); err != nil {
+				t.Fatal(err)
+			}
+		}
+		elapsed := time.Since(start)
+		if got := ft.calls.Load(); got != n {
+			t.Fatalf("transport got %d calls, want %d", got, n)
+		}
+		return elapsed
+	}
+
+	t.Run("Limited", func(t *testing.T) {
+		// The first request goes out immediately, then one every 50ms.
+		elapsed := run(t, 20)
+		if want := (n - 1) * 50 * time.Millisecond; elapsed < want {
+			t.Errorf("%d requests took %s, want at least %s", n, elapsed, want)
+		}
+		if elapsed > 2*time.Second {
+			t.Errorf("%d requests took %s, want well under 2s", n, elapsed)
+		}
+	})
+
+	t.Run("Unlimited", func(t *testing.T) {
+		if elapsed := run(t, 0); elapsed > 100*time.Millisecond {
+			t.Errorf("%d unthrottled requests took %s", n, elapsed)
+		}
+	})
+
+	t.Run("Default", func(t *testing.T) {
+		c := NewClient("tok")
+		if want := time.Second / DefaultRequestsPerSecond; c.minInterval != want {
+			t.Errorf("minInterval = %s, want %s", c.minInterval, want)
+		}
+		if c.httpClient == nil || c.httpClient.Timeout != 30*time.Second {
+			t.Errorf("unexpected default http client: %+v", c.httpClient)
+		}
+	})
+}
@@ -21,9 +21,42 @@ type ExtractOptions struct {
 	// Behavior
 	IncludeContent bool // fetch page content (blocks)
 	MaxDepth       int  // max nesting depth (0 = unlimited)
+	Concurrency    int  // concurrent fetches, all sharing the client rate limit (0 = 1)
+	Estimate       bool // DryRun only: query databases, fetch content and probe assets to estimate the cost
+
+	// Assets
+	MaxAssetBytes int64                        // size limit of one downloaded asset (0 = DefaultMaxAssetBytes)
+	CheckStorage  func(additional int64) error // optional, rejects an asset that would exceed the storage quota
 
 	// View manifest for importing views
 	Manifest *ViewManifest
+
+	// SyncSince makes the extraction an incremental sync of a previous one
+	// using the same ID mapping: only the pages and database rows edited at or
+	// after it are fetched and written, updating the existing nodes and
+	// records in place. Zero means a full import.
+	//
+	// Pages are only discovered by search, so the child pages of an unchanged
+	// page listed in PageIDs are not synced.
+	SyncSince time.Time
+}
+
+// unchanged reports whether an item last edited at edited is skipped by a
+// sync.
+func (o *ExtractOptions) unchanged(edited time.Time) bool {
+	return !o.SyncSince.IsZero() && edited.Before(o.SyncSince)
+}
+
+// rowQuery returns the query of database rows: the rows edited since
+// SyncSince for a sync, all of them otherwise.
+func (o *ExtractOptions) rowQuery() *QueryOptions {
+	if o.SyncSince.IsZero() {
+		return nil
+	}
+	return &QueryOptions{Filter: map[string]any{
+		"timestamp":        "last_edited_time",
+		"last_edited_time": map[string]string{"on_or_after": o.SyncSince.UTC().Format(time.RFC3339)},
+	}}
 }
 
 // Extractor orchestrates the extraction of Notion data.
@@ -33,7 +66,10 @@ type Extractor struct {
 	writer   *Writer
 	progress ProgressReporter
 	assets   *AssetDownloader
-	imported map[string]bool // Track already-imported Notion IDs
+	imported map[string]bool              // Track already-imported Notion IDs
+	sem      chan struct{}                // Bounds concurrent fetches
+	blocks   map[string]*pending[[]Block] // Prefetched page content by Notion ID
+	stats    *ExtractStats                // Stats of the current extraction
 }
 
 // NewExtractor creates a new extractor.
@@ -49,17 +85,30 @@ func NewExtractor(client *Client, writer *Writer, progress ProgressReporter) *Ex
 	}
 }
 
+// discovered holds the content found by discoverContent.
+type discovered struct {
+	databases []*Database
+	pages     []Page
+	unchanged []string // Notion IDs of the pages skipped by a sync
+	archived  []string // Notion IDs of the pages and databases archived in Notion
+}
+
 // databaseData holds fetched data for a database during extraction.
 type databaseData struct {
-	db   *Database
-	node *content.Node
-	rows []Page
+	db        *Database
+	node      *content.Node
+	rows      []Page
+	relations map[string]string // Mapper.PendingRelations of this database
 }
 
 // Extract performs the full extraction based on options.
 func (e *Extractor) Extract(ctx context.Context, opts ExtractOptions) (*ExtractStats, error) {
+	// Stop the fetches still running ahead when returning early.
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
 	startTime := time.Now()
 	stats := &ExtractStats{}
+	e.stats = stats
 
 	// Ensure workspace directory exists
 	if err := e.writer.EnsureWorkspace(); err != nil {
@@ -73,24 +122,42 @@ func (e *Extractor) Extract(ctx context.Context, opts ExtractOptions) (*ExtractS
 		existingIDs = make(map[string]ksid.ID)
 	}
 	if len(existingIDs) > 0 {
-		e.progress.OnProgress(0, fmt.Sprintf("Loaded %d existing ID mappings", len(existingIDs)))
+		e.progress.OnProgress(0, ProgressItem{Phase: PhaseSetup, Message: fmt.Sprintf("Loaded %d existing ID mappings", len(existingIDs))})
 		e.mapper = NewMapperWithIDs(existingIDs)
 	}
 
-	// Clear nodes manifest for fresh import (IDs are preserved via mapping)
-	if err := e.writer.ClearNodesManifest(); err != nil {
-		e.progress.OnWarning(fmt.Sprintf("Failed to clear nodes manifest: %v", err))
+	// Clear nodes manifest for fresh import (IDs are preserved via mapping).
+	// A sync keeps the entries of the nodes it doesn't update.
+	if opts.SyncSince.IsZero() {
+		if err := e.writer.ClearNodesManifest(); err != nil {
+			e.progress.OnWarning(fmt.Sprintf("Failed to clear nodes manifest: %v", err))
+		}
 	}
 
 	// Create asset downloader and import tracker
 	e.assets = NewAssetDownloader(e.writer.workspacePath())
+	e.assets.throttle = e.client.throttle
+	if opts.MaxAssetBytes > 0 {
+		e.assets.maxBytes = opts.MaxAssetBytes
+	}
+	e.assets.checkStorage = opts.CheckStorage
 	e.imported = make(map[string]bool)
+	e.sem = make(chan struct{}, max(opts.Concurrency, 1))
+	e.blocks = make(map[string]*pending[[]Block])
 
 	// Discover content
-	databases, pages, err := e.discoverContent(ctx, opts)
+	found, err := e.discoverContent(ctx, opts)
 	if err != nil {
 		return nil, fmt.Errorf("failed to discover content: %w", err)
 	}
+	databases, pages := found.databases, found.pages
+	for _, id := range found.unchanged {
+		e.imported[id] = true
+		stats.Unchanged++
+	}
+	for _, id := range found.archived {
+		e.trashNode(id)
+	}
 
 	total := len(databases) + len(pages)
 	e.progress.OnStart(total)
@@ -103,20 +170,37 @@ func (e *Extractor) Extract(ctx context.Context, opts ExtractOptions) (*ExtractS
 		e.mapper.AssignNodeID(pages[i].ID)
 	}
 
+	// Start fetching database rows and page content. Only the fetches run
+	// concurrently; mapping, writing and progress stay in this goroutine.
+	dbIDs := make([]string, len(databases))
+	for i := range databases {
+		dbIDs[i] = databases[i].ID
+	}
+	dbRows := prefetch(ctx, e.sem, dbIDs, func(ctx context.Context, id string) ([]Page, error) {
+		return e.client.QueryDatabaseAll(ctx, id, opts.rowQuery())
+	})
+	pageIDs := make([]string, len(pages))
+	for i := range pages {
+		pageIDs[i] = pages[i].ID
+	}
+	e.prefetchBlocks(ctx, pageIDs, opts)
+
 	// Phase 1: Fetch all database rows and map databases
 	dbDataList := make([]*databaseData, 0, len(databases))
 	for i := range databases {
+		e.mapper.ClearPendingRelations()
 		node, err := e.mapper.MapDatabase(databases[i])
 		if err != nil {
 			e.progress.OnError(fmt.Errorf("database %s: failed to map: %w", databases[i].ID, err))
 			stats.Errors++
 			continue
 		}
+		relations := e.mapper.PendingRelations
 
 		// Download icon and cover
-		e.mapper.MapDatabaseIconCover(node, databases[i], e.assets)
+		e.warnIconCover(node, e.mapper.MapDatabaseIconCover(node, databases[i], e.iconAssets(opts)))
 
-		rows, err := e.client.QueryDatabaseAll(ctx, databases[i].ID, nil)
+		rows, err := dbRows[i].wait()
 		if err != nil {
 			e.progress.OnError(fmt.Errorf("database %s: failed to query: %w", databases[i].ID, err))
 			stats.Errors++
@@ -125,13 +209,16 @@ func (e *Extractor) Extract(ctx context.Context, opts ExtractOptions) (*ExtractS
 
 		// Pre-assign mddb IDs to all rows
 		for j := range rows {
-			e.mapper.AssignRecordID(rows[j].ID)
+			if !rows[j].Archived {
+				e.mapper.AssignRecordID(rows[j].ID)
+			}
 		}
 
 		dbDataList = append(dbDataList, &databaseData{
-			db:   databases[i],
-			node: node,
-			rows: rows,
+			db:        databases[i],
+			node:      node,
+			rows:      rows,
+			relations: relations,
 		})
 	}
 
@@ -139,7 +226,7 @@ func (e *Extractor) Extract(ctx context.Context, opts ExtractOptions) (*ExtractS
 	current := 0
 	for _, data := range dbDataList {
 		current++
-		e.progress.OnProgress(current, "Database: "+richTextToPlain(data.db.Title))
+		e.progress.OnProgress(current, ProgressItem{Phase: PhaseDatabases, ID: data.db.ID, Message: "Database: " + richTextToPlain(data.db.Title)})
 
 		// Mark as imported to prevent duplicate extraction from child blocks
 		e.imported[data.db.ID] = true
@@ -150,7 +237,8 @@ func (e *Extractor) Extract(ctx context.Context, opts ExtractOptions) (*ExtractS
 		}
 
 		// Resolve relation target IDs in schema
-		e.mapper.ResolveRelations(data.node)
+		e.mapper.PendingRelations = data.relations
+		e.warnUnresolvedRelations(data.db, e.mapper.ResolveRelations(data.node))
 
 		// Write node and manifest entry
 		if err := e.writer.WriteNode(data.node, ""); err != nil {
@@ -162,37 +250,21 @@ func (e *Extractor) Extract(ctx context.Context, opts ExtractOptions) (*ExtractS
 			e.progress.OnError(fmt.Errorf("database %s: failed to write manifest: %w", data.db.ID, err))
 		}
 
-		// Map and write records (set asset context for file downloads)
-		e.mapper.SetAssetContext(e.assets, data.node.ID)
-		var records []*content.DataRecord
-		for i := range data.rows {
-			record, err := e.mapper.MapDatabasePage(&data.rows[i], data.db.Properties)
-			if err != nil {
-				e.progress.OnWarning(fmt.Sprintf("Failed to map row %s: %v", data.rows[i].ID, err))
-				continue
-			}
-			records = append(records, record)
-		}
-
-		// Clear existing data for re-import (IDs preserved via mapping)
-		if err := e.writer.ClearNodeData(data.node.ID); err != nil {
-			e.progress.OnWarning(fmt.Sprintf("Failed to clear existing data: %v", err))
-		}
-		if err := e.writer.WriteRecords(data.node.ID, data.node.Properties, records); err != nil {
+		n, err := e.writeRecords(data.node, data.db, data.rows, opts)
+		if err != nil {
 			e.progress.OnError(fmt.Errorf("database %s: failed to write records: %w", data.db.ID, err))
 			stats.Errors++
 			continue
 		}
 
 		stats.Databases++
-		stats.Records += len(records)
+		stats.Records += n
 	}
 
 	// Phase 3: Extract standalone pages
 	for i := range pages {
 		current++
-		title := extractPageTitle(&pages[i])
-		e.progress.OnProgress(current, "Page: "+title)
+		e.progress.OnProgress(current, ProgressItem{Phase: PhasePages, ID: pages[i].ID, Message: "Page: " + extractPageTitle(&pages[i])})
 
 		if err := e.extractPage(ctx, &pages[i], opts); err != nil {
 			e.progress.OnError(fmt.Errorf("page %s: %w", pages[i].ID, err))
@@ -204,13 +276,19 @@ func (e *Extractor) Extract(ctx context.Context, opts ExtractOptions) (*ExtractS
 
 	// Gather asset stats
 	if e.assets != nil {
-		stats.Assets = e.assets.Downloaded
+		stats.Assets = e.assets.Downloaded + e.assets.Skipped
+		stats.AssetsDownloaded = e.assets.Downloaded
 	}
 
 	// Save ID mapping for future incremental imports
 	if err := e.writer.SaveIDMapping(e.mapper.NotionToMddb); err != nil {
 		e.progress.OnWarning(fmt.Sprintf("Failed to save ID mapping: %v", err))
 	}
+	if !opts.SyncSince.IsZero() {
+		if err := e.writer.CompactNodesManifest(); err != nil {
+			e.progress.OnWarning(fmt.Sprintf("Failed to compact nodes manifest: %v", err))
+		}
+	}
 
 	stats.Duration = time.Since(startTime)
 	e.progress.OnComplete(*stats)
@@ -218,28 +296,47 @@ func (e *Extractor) Extract(ctx context.Context, opts ExtractOptions) (*ExtractS
 }
 
 // discoverContent finds all databases and pages to extract.
-func (e *Extractor) discoverContent(ctx context.Context, opts ExtractOptions) ([]*Database, []Page, error) {
-	var databases []*Database
-	var pages []Page
+//
+// Archived pages and databases are set aside to be trashed, and so are the
+// pages a sync skips, without fetching them when found by search.
+func (e *Extractor) discoverContent(ctx context.Context, opts ExtractOptions) (*discovered, error) {
+	d := &discovered{}
+	addDatabase := func(db *Database) {
+		if db.Archived {
+			d.archived = append(d.archived, db.ID)
+		} else {
+			d.databases = append(d.databases, db)
+		}
+	}
+	addPage := func(page *Page) {
+		switch {
+		case page.Archived:
+			d.archived = append(d.archived, page.ID)
+		case opts.unchanged(page.LastEditedTime):
+			d.unchanged = append(d.unchanged, page.ID)
+		default:
+			d.pages = append(d.pages, *page)
+		}
+	}
 
 	// If specific IDs provided, fetch those directly
 	if len(opts.DatabaseIDs) > 0 {
-		for _, id := range opts.DatabaseIDs {
-			db, err := e.client.GetDatabase(ctx, id)
+		dbs, errs := fetchAll(ctx, e.sem, opts.DatabaseIDs, e.client.GetDatabase)
+		for i, err := range errs {
 			if err != nil {
-				return nil, nil, fmt.Errorf("failed to get database %s: %w", id, err)
+				return nil, fmt.Errorf("failed to get database %s: %w", opts.DatabaseIDs[i], err)
 			}
-			databases = append(databases, db)
+			addDatabase(dbs[i])
 		}
 	}
 
 	if len(opts.PageIDs) > 0 {
-		for _, id := range opts.PageIDs {
-			page, err := e.client.GetPage(ctx, id)
+		found, errs := fetchAll(ctx, e.sem, opts.PageIDs, e.client.GetPage)
+		for i, err := range errs {
 			if err != nil {
-				return nil, nil, fmt.Errorf("failed to get page %s: %w", id, err)
+				return nil, fmt.Errorf("failed to get page %s: %w", opts.PageIDs[i], err)
 			}
-			pages = append(pages, *page)
+			addPage(found[i])
 		}
 	}
 
@@ -251,18 +348,28 @@ func (e *Extractor) discoverContent(ctx context.Context, opts ExtractOptions) ([
 			Property: "object",
 		})
 		if err != nil {
-			return nil, nil, fmt.Errorf("failed to search databases: %w", err)
+			return nil, fmt.Errorf("failed to search databases: %w", err)
 		}
 
+		var ids []string
 		for i := range dbResults {
-			if dbResults[i].Object == "database" {
-				db, err := e.client.GetDatabase(ctx, dbResults[i].ID)
-				if err != nil {
-					e.progress.OnWarning(fmt.Sprintf("Failed to get database %s: %v", dbResults[i].ID, err))
-					continue
-				}
-				databases = append(databases, db)
+			if dbResults[i].Object != "database" {
+				continue
+			}
+			// Databases are synced even when not edited: their rows may be.
+			if dbResults[i].Archived {
+				d.archived = append(d.archived, dbResults[i].ID)
+				continue
+			}
+			ids = append(ids, dbResults[i].ID)
+		}
+		dbs, errs := fetchAll(ctx, e.sem, ids, e.client.GetDatabase)
+		for i, err := range errs {
+			if err != nil {
+				e.progress.OnWarning(fmt.Sprintf("Failed to get database %s: %v", ids[i], err))
+				continue
 			}
+			addDatabase(dbs[i])
 		}
 
 		// Search for pages (only standalone pages, not database rows)
@@ -271,27 +378,36 @@ func (e *Extractor) discoverContent(ctx context.Context, opts ExtractOptions) ([
 			Property: "object",
 		})
 		if err != nil {
-			return nil, nil, fmt.Errorf("failed to search pages: %w", err)
+			return nil, fmt.Errorf("failed to search pages: %w", err)
 		}
 
+		ids = nil
 		for i := range pageResults {
-			if pageResults[i].Object == "page" {
-				// Skip pages that are database rows
-				if pageResults[i].Parent.Type == "database_id" {
-					continue
-				}
-
-				page, err := e.client.GetPage(ctx, pageResults[i].ID)
-				if err != nil {
-					e.progress.OnWarning(fmt.Sprintf("Failed to get page %s: %v", pageResults[i].ID, err))
-					continue
-				}
-				pages = append(pages, *page)
+			r := &pageResults[i]
+			// Skip pages that are database rows
+			if r.Object != "page" || r.Parent.Type == "database_id" {
+				continue
+			}
+			switch {
+			case r.Archived:
+				d.archived = append(d.archived, r.ID)
+			case opts.unchanged(r.LastEditedTime):
+				d.unchanged = append(d.unchanged, r.ID)
+			default:
+				ids = append(ids, r.ID)
 			}
 		}
+		found, errs := fetchAll(ctx, e.sem, ids, e.client.GetPage)
+		for i, err := range errs {
+			if err != nil {
+				e.progress.OnWarning(fmt.Sprintf("Failed to get page %s: %v", ids[i], err))
+				continue
+			}
+			addPage(found[i])
+		}
 	}
 
-	return databases, pages, nil
+	return d, nil
 }
 
 // extractPage extracts a standalone page.
@@ -300,7 +416,15 @@ func (e *Extractor) extractPage(ctx context.Context, page *Page, opts ExtractOpt
 	if e.imported[page.ID] {
 		return nil
 	}
+	if page.Archived {
+		e.trashNode(page.ID)
+		return nil
+	}
 	e.imported[page.ID] = true
+	if opts.unchanged(page.LastEditedTime) {
+		e.stats.Unchanged++
+		return nil
+	}
 
 	// Map page to node
 	node, err := e.mapper.MapPage(page)
@@ -309,13 +433,13 @@ func (e *Extractor) extractPage(ctx context.Context, page *Page, opts ExtractOpt
 	}
 
 	// Download icon and cover
-	e.mapper.MapPageIconCover(node, page, e.assets)
+	e.warnIconCover(node, e.mapper.MapPageIconCover(node, page, e.iconAssets(opts)))
 
 	// Get page content if requested
 	var markdown string
 	var childRefs []ChildRef
 	if opts.IncludeContent {
-		blocks, err := e.client.GetBlockChildrenRecursive(ctx, page.ID, opts.MaxDepth)
+		blocks, err := e.pageBlocks(ctx, page.ID, opts)
 		if err != nil {
 			e.progress.OnWarning(fmt.Sprintf("Failed to get blocks for %s: %v", page.ID, err))
 		} else {
@@ -329,6 +453,7 @@ func (e *Extractor) extractPage(ctx context.Context, page *Page, opts ExtractOpt
 
 			// Use markdown converter with asset downloading and child links
 			converter := NewMarkdownConverterWithLinks(e.assets, node.ID, e.mapper)
+			converter.MaxDepth = opts.MaxDepth
 			markdown = converter.Convert(blocks)
 		}
 	}
@@ -340,11 +465,31 @@ func (e *Extractor) extractPage(ctx context.Context, page *Page, opts ExtractOpt
 	if err := e.writer.WriteNodeEntry(node); err != nil {
 		return fmt.Errorf("failed to write manifest: %w", err)
 	}
+	if !opts.SyncSince.IsZero() {
+		e.stats.Updated++
+	}
+
+	// Fetch child pages and their content ahead; they are extracted in order.
+	var childIDs []string
+	for _, ref := range childRefs {
+		if ref.Type == "page" && !e.imported[ref.ID] {
+			childIDs = append(childIDs, ref.ID)
+		}
+	}
+	childPages := make(map[string]*pending[*Page], len(childIDs))
+	for i, p := range prefetch(ctx, e.sem, childIDs, e.client.GetPage) {
+		childPages[childIDs[i]] = p
+	}
+	e.prefetchBlocks(ctx, childIDs, opts)
 
 	// Import child pages and databases
 	for _, ref := range childRefs {
 		if ref.Type == "page" {
-			childPage, err := e.client.GetPage(ctx, ref.ID)
+			p, ok := childPages[ref.ID]
+			if !ok {
+				continue // Already imported
+			}
+			childPage, err := p.wait()
 			if err != nil {
 				e.progress.OnWarning(fmt.Sprintf("Failed to get child page %s: %v", ref.ID, err))
 				continue
@@ -373,27 +518,33 @@ func (e *Extractor) extractDatabase(ctx context.Context, db *Database, opts Extr
 	if e.imported[db.ID] {
 		return nil
 	}
+	if db.Archived {
+		e.trashNode(db.ID)
+		return nil
+	}
 	e.imported[db.ID] = true
 
+	// Clear pending relations left over from the previous database
+	e.mapper.ClearPendingRelations()
+
 	node, err := e.mapper.MapDatabase(db)
 	if err != nil {
 		return fmt.Errorf("failed to map database: %w", err)
 	}
 
 	// Download icon and cover
-	e.mapper.MapDatabaseIconCover(node, db, e.assets)
+	e.warnIconCover(node, e.mapper.MapDatabaseIconCover(node, db, e.iconAssets(opts)))
 
-	// Clear pending relations for this database
-	e.mapper.ClearPendingRelations()
-
-	rows, err := e.client.QueryDatabaseAll(ctx, db.ID, nil)
+	rows, err := e.client.QueryDatabaseAll(ctx, db.ID, opts.rowQuery())
 	if err != nil {
 		return fmt.Errorf("failed to query database: %w", err)
 	}
 
 	// Pre-assign mddb IDs to all rows
 	for i := range rows {
-		e.mapper.AssignRecordID(rows[i].ID)
+		if !rows[i].Archived {
+			e.mapper.AssignRecordID(rows[i].ID)
+		}
 	}
 
 	// Apply views from manifest
@@ -402,7 +553,7 @@ func (e *Extractor) extractDatabase(ctx context.Context, db *Database, opts Extr
 	}
 
 	// Resolve relation target IDs in schema
-	e.mapper.ResolveRelations(node)
+	e.warnUnresolvedRelations(db, e.mapper.ResolveRelations(node))
 
 	// Write node and manifest entry
 	if err := e.writer.WriteNode(node, ""); err != nil {
@@ -412,10 +563,31 @@ func (e *Extractor) extractDatabase(ctx context.Context, db *Database, opts Extr
 		return fmt.Errorf("failed to write manifest: %w", err)
 	}
 
-	// Map and write records (set asset context for file downloads)
+	if _, err := e.writeRecords(node, db, rows, opts); err != nil {
+		return fmt.Errorf("failed to write records: %w", err)
+	}
+
+	return nil
+}
+
+// writeRecords maps the rows of db and writes them as the records of the
+// table node, returning the number written.
+//
+// A full import replaces all the records. A sync only got the rows edited
+// since, so it updates their records in place and deletes the records of the
+// archived ones.
+func (e *Extractor) writeRecords(node *content.Node, db *Database, rows []Page, opts ExtractOptions) (int, error) {
+	// Map records (set asset context for file downloads)
 	e.mapper.SetAssetContext(e.assets, node.ID)
 	var records []*content.DataRecord
+	var deleted []ksid.ID
 	for i := range rows {
+		if rows[i].Archived {
+			if id, ok := e.mapper.lookupID(rows[i].ID); ok {
+				deleted = append(deleted, id)
+			}
+			continue
+		}
 		record, err := e.mapper.MapDatabasePage(&rows[i], db.Properties)
 		if err != nil {
 			e.progress.OnWarning(fmt.Sprintf("Failed to map row %s: %v", rows[i].ID, err))
@@ -423,16 +595,106 @@ func (e *Extractor) extractDatabase(ctx context.Context, db *Database, opts Extr
 		}
 		records = append(records, record)
 	}
+	e.warnDanglingRelations(db)
 
-	// Clear existing data for re-import (IDs preserved via mapping)
-	if err := e.writer.ClearNodeData(node.ID); err != nil {
-		e.progress.OnWarning(fmt.Sprintf("Failed to clear existing data: %v", err))
+	if opts.SyncSince.IsZero() {
+		// Clear existing data for re-import (IDs preserved via mapping)
+		if err := e.writer.ClearNodeData(node.ID); err != nil {
+			e.progress.OnWarning(fmt.Sprintf("Failed to clear existing data: %v", err))
+		}
+		if err := e.writer.WriteRecords(node.ID, node.Properties, records); err != nil {
+			return 0, err
+		}
+		return len(records), nil
 	}
-	if err := e.writer.WriteRecords(node.ID, node.Properties, records); err != nil {
-		return fmt.Errorf("failed to write records: %w", err)
+
+	unchanged, removed, err := e.writer.MergeRecords(node.ID, node.Properties, records, deleted)
+	if err != nil {
+		return 0, err
 	}
+	e.stats.Updated += len(records)
+	e.stats.Unchanged += unchanged
+	e.stats.Trashed += removed
+	return len(records), nil
+}
 
-	return nil
+// trashNode trashes the node imported before from a Notion page or database
+// now archived.
+func (e *Extractor) trashNode(notionID string) {
+	e.imported[notionID] = true
+	id, ok := e.mapper.lookupID(notionID)
+	if !ok {
+		return
+	}
+	trashed, err := e.writer.TrashNode(id)
+	if err != nil {
+		e.progress.OnWarning(fmt.Sprintf("Failed to trash archived %s: %v", notionID, err))
+		return
+	}
+	if trashed {
+		e.stats.Trashed++
+	}
+}
+
+// prefetchBlocks starts fetching the content of pages for extractPage, unless
+// already started.
+func (e *Extractor) prefetchBlocks(ctx context.Context, ids []string, opts ExtractOptions) {
+	if !opts.IncludeContent {
+		return
+	}
+	var missing []string
+	for _, id := range ids {
+		if _, ok := e.blocks[id]; !ok {
+			missing = append(missing, id)
+		}
+	}
+	fetch := func(ctx context.Context, id string) ([]Block, error) {
+		return e.client.GetBlockChildrenRecursive(ctx, id, opts.MaxDepth)
+	}
+	for i, p := range prefetch(ctx, e.sem, missing, fetch) {
+		e.blocks[missing[i]] = p
+	}
+}
+
+// pageBlocks returns the content of a page, waiting for it if prefetched.
+func (e *Extractor) pageBlocks(ctx context.Context, id string, opts ExtractOptions) ([]Block, error) {
+	if p, ok := e.blocks[id]; ok {
+		delete(e.blocks, id)
+		return p.wait()
+	}
+	return e.client.GetBlockChildrenRecursive(ctx, id, opts.MaxDepth)
+}
+
+// iconAssets returns the downloader for icon and cover images, or nil when
+// content is not fetched.
+func (e *Extractor) iconAssets(opts ExtractOptions) *AssetDownloader {
+	if !opts.IncludeContent {
+		return nil
+	}
+	return e.assets
+}
+
+// warnIconCover reports icon and cover images of node that failed to download.
+func (e *Extractor) warnIconCover(node *content.Node, errs []error) {
+	for _, err := range errs {
+		e.progress.OnWarning(fmt.Sprintf("%q: failed to download %v", node.Title, err))
+	}
+}
+
+// warnUnresolvedRelations reports relation properties of db whose target
+// database is not part of the import.
+func (e *Extractor) warnUnresolvedRelations(db *Database, names []string) {
+	for _, name := range names {
+		e.progress.OnWarning(fmt.Sprintf("Database %q: relation %q targets a database that was not imported", richTextToPlain(db.Title), name))
+	}
+}
+
+// warnDanglingRelations reports the related pages referenced by the records of
+// db that are not part of the import.
+func (e *Extractor) warnDanglingRelations(db *Database) {
+	if dangling := e.mapper.TakeDanglingRelations(); len(dangling) > 0 {
+		e.progress.OnWarning(fmt.Sprintf("Database %q: %d related records were not imported; kept as dangling Notion references", richTextToPlain(db.Title), len(dangling)))
+	}
 }
 
 // ChildRef represents a reference to a child page or database found in block content.
@@ -473,28 +735,79 @@ func collectChildRefs(blocks []Block) []ChildRef {
 	return refs
 }
 
+// collectAssetURLs returns the URLs of the Notion-hosted files in blocks, which
+// an extraction downloads.
+func collectAssetURLs(blocks []Block) []string {
+	var urls []string
+	for i := range blocks {
+		block := &blocks[i]
+		for _, media := range []*MediaBlock{block.Image, block.Video, block.File, block.PDF} {
+			if media == nil {
+				continue
+			}
+			var u string
+			if media.File != nil {
+				u = media.File.URL
+			} else if media.External != nil {
+				u = media.External.URL
+			}
+			if isNotionAssetURL(u) {
+				urls = append(urls, u)
+			}
+		}
+		urls = append(urls, collectAssetURLs(block.Children)...)
+	}
+	return urls
+}
+
 // DryRunResult contains items that would be extracted during a dry run.
 type DryRunResult struct {
-	Databases []DryRunItem `json:"databases"`
-	Pages     []DryRunItem `json:"pages"`
+	Databases []DryRunItem    `json:"databases"`
+	Pages     []DryRunItem    `json:"pages"`
+	Estimate  *DryRunEstimate `json:"estimate,omitempty"` // only with ExtractOptions.Estimate
 }
 
 // DryRunItem represents an item that would be extracted.
 type DryRunItem struct {
-	ID    string `json:"id"`
-	Title string `json:"title"`
-	Type  string `json:"type"`
+	ID      string `json:"id"`
+	Title   string `json:"title"`
+	Type    string `json:"type"`
+	Records int    `json:"records,omitempty"` // rows of a database, only with an estimate
+}
+
+// DryRunEstimate is the cost of extracting the items of a dry run, to gauge
+// the time spent within the rate limit and the disk space needed.
+type DryRunEstimate struct {
+	// APICalls counts the Notion API requests: discovery, database queries
+	// and, with content, page blocks. Child pages found only in the content
+	// of other pages are not counted.
+	APICalls int `json:"api_calls"`
+	// Assets counts the Notion-hosted files in page content, which are
+	// downloaded. 0 without content.
+	Assets int `json:"assets"`
+	// AssetBytes is the total size of Assets. Files whose host does not report
+	// a size are not counted.
+	AssetBytes int64 `json:"asset_bytes"`
 }
 
 // DryRun discovers content without extracting it.
+//
+// With opts.Estimate, it also estimates the cost: it makes the same requests as
+// Extract would, querying databases and fetching page content, then probes the
+// size of the assets instead of downloading them. This takes about as long as
+// the extraction itself within the rate limit.
 func (e *Extractor) DryRun(ctx context.Context, opts ExtractOptions) (*DryRunResult, error) {
-	databases, pages, err := e.discoverContent(ctx, opts)
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	e.sem = make(chan struct{}, max(opts.Concurrency, 1))
+	start := e.client.requests.Load()
+	found, err := e.discoverContent(ctx, opts)
 	if err != nil {
 		return nil, err
 	}
+	databases, pages := found.databases, found.pages
 
 	result := &DryRunResult{}
-
 	for i := range databases {
 		result.Databases = append(result.Databases, DryRunItem{
 			ID:    databases[i].ID,
@@ -502,7 +815,6 @@ func (e *Extractor) DryRun(ctx context.Context, opts ExtractOptions) (*DryRunRes
 			Type:  "database",
 		})
 	}
-
 	for i := range pages {
 		result.Pages = append(result.Pages, DryRunItem{
 			ID:    pages[i].ID,
@@ -510,6 +822,64 @@ func (e *Extractor) DryRun(ctx context.Context, opts ExtractOptions) (*DryRunRes
 			Type:  "page",
 		})
 	}
+	if !opts.Estimate {
+		return result, nil
+	}
+
+	dbIDs := make([]string, len(databases))
+	for i := range databases {
+		dbIDs[i] = databases[i].ID
+	}
+	dbRows := prefetch(ctx, e.sem, dbIDs, func(ctx context.Context, id string) ([]Page, error) {
+		return e.client.QueryDatabaseAll(ctx, id, opts.rowQuery())
+	})
+	var pageBlocks []*pending[[]Block]
+	if opts.IncludeContent {
+		pageIDs := make([]string, len(pages))
+		for i := range pages {
+			pageIDs[i] = pages[i].ID
+		}
+		pageBlocks = prefetch(ctx, e.sem, pageIDs, func(ctx context.Context, id string) ([]Block, error) {
+			return e.client.GetBlockChildrenRecursive(ctx, id, opts.MaxDepth)
+		})
+	}
+
+	for i := range databases {
+		if rows, err := dbRows[i].wait(); err != nil {
+			e.progress.OnWarning(fmt.Sprintf("Failed to query database %s: %v", databases[i].ID, err))
+		} else {
+			result.Databases[i].Records = len(rows)
+		}
+	}
+
+	var assetURLs []string
+	seen := make(map[string]bool)
+	for i := range pageBlocks {
+		blocks, err := pageBlocks[i].wait()
+		if err != nil {
+			e.progress.OnWarning(fmt.Sprintf("Failed to get blocks for %s: %v", pages[i].ID, err))
+			continue
+		}
+		for _, u := range collectAssetURLs(blocks) {
+			if !seen[u] {
+				seen[u] = true
+				assetURLs = append(assetURLs, u)
+			}
+		}
+	}
+	result.Estimate = &DryRunEstimate{
+		APICalls: int(e.client.requests.Load() - start),
+		Assets:   len(assetURLs),
+	}
+
+	sizes, errs := fetchAll(ctx, e.sem, assetURLs, e.client.assetSize)
+	for i, err := range errs {
+		if err != nil {
+			e.progress.OnWarning(fmt.Sprintf("Failed to get the size of %s: %v", assetURLs[i], err))
+			continue
+		}
+		result.Estimate.AssetBytes += max(sizes[i], 0)
+	}
 
 	return result, nil
 }
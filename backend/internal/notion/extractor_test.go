@@ -0,0 +1,519 @@
+package notion
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/maruel/ksid"
+	"github.com/maruel/mddb/backend/internal/jsonldb"
+	"github.com/maruel/mddb/backend/internal/storage/content"
+)
+
+// slowTransport serves n standalone pages, each with one paragraph, taking
+// delay to answer every request. It records when requests start and how many
+// were in flight at once.
+type slowTransport struct {
+	n     int
+	delay time.Duration
+
+	mu          sync.Mutex
+	starts      []time.Time
+	inFlight    int
+	maxInFlight int
+}
+
+func (f *slowTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	f.mu.Lock()
+	f.starts = append(f.starts, time.Now())
+	f.inFlight++
+	f.maxInFlight = max(f.maxInFlight, f.inFlight)
+	f.mu.Unlock()
+	defer func() {
+		f.mu.Lock()
+		f.inFlight--
+		f.mu.Unlock()
+	}()
+	time.Sleep(f.delay)
+
+	text := func(s string) string {
+		return `[{"type":"text","text":{"content":"` + s + `"},"plain_text":"` + s + `"}]`
+	}
+	page := func(i int) string {
+		return fmt.Sprintf(`{"object":"page","id":"page-%d","parent":{"type":"workspace","workspace":true},`+
+			`"properties":{"title":{"type":"title","title":%s}}}`, i, text(fmt.Sprintf("Page %d", i)))
+	}
+	p := req.URL.Path
+	var i int
+	scan := func(format string) bool {
+		_, err := fmt.Sscanf(p, format, &i)
+		return err == nil
+	}
+	var body string
+	switch {
+	case strings.HasSuffix(p, "/search"):
+		b, _ := io.ReadAll(req.Body)
+		if bytes.Contains(b, []byte(`"database"`)) {
+			body = `{"results":[],"has_more":false}`
+		} else {
+			var pages []string
+			for i := range f.n {
+				pages = append(pages, page(i))
+			}
+			body = `{"results":[` + strings.Join(pages, ",") + `],"has_more":false}`
+		}
+	case scan("/v1/blocks/page-%d/children"):
+		body = `{"results":[{"object":"block","id":"b-` + fmt.Sprint(i) + `","type":"paragraph","paragraph":{"rich_text":` +
+			text(fmt.Sprintf("Body %d", i)) + `}}],"has_more":false}`
+	case scan("/v1/pages/page-%d"):
+		body = page(i)
+	default:
+		return &http.Response{StatusCode: http.StatusNotFound, Body: io.NopCloser(strings.NewReader(`{"object":"error","status":404,"code":"object_not_found","message":"` + p + `"}`)), Request: req}, nil
+	}
+	return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(body)), Request: req}, nil
+}
+
+// orderedProgress fails the test if progress is not reported in order. Setup
+// steps are reported at 0.
+type orderedProgress struct {
+	NullProgress
+	t    *testing.T
+	next int
+}
+
+func (p *orderedProgress) OnProgress(current int, item ProgressItem) {
+	if current == 0 {
+		return
+	}
+	p.next++
+	if current != p.next {
+		p.t.Errorf("OnProgress(%d, %+v), want %d", current, item, p.next)
+	}
+}
+
+func (p *orderedProgress) OnWarning(msg string) {
+	p.t.Errorf("unexpected warning: %s", msg)
+}
+
+func (p *orderedProgress) OnError(err error) {
+	p.t.Errorf("unexpected error: %v", err)
+}
+
+func TestExtractor_Concurrency(t *testing.T) {
+	const n = 12
+	const rps = 100
+	for _, concurrency := range []int{1, 4} {
+		t.Run(fmt.Sprint(concurrency), func(t *testing.T) {
+			ft := &slowTransport{n: n, delay: 30 * time.Millisecond}
+			client := NewClientWithOptions("tok", ClientOptions{RequestsPerSecond: rps, HTTPClient: &http.Client{Transport: ft}})
+			dir := t.TempDir()
+			writer := NewWriter(dir, "ws")
+			progress := &orderedProgress{t: t}
+			stats, err := NewExtractor(client, writer, progress).Extract(t.Context(), ExtractOptions{IncludeContent: true, Concurrency: concurrency})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if stats.Pages != n || stats.Errors != 0 {
+				t.Fatalf("unexpected stats: %+v", stats)
+			}
+			if progress.next != n {
+				t.Errorf("got %d progress updates, want %d", progress.next, n)
+			}
+
+			// Every page was written with its own content, whatever the order
+			// its requests completed in.
+			ids, err := writer.LoadIDMapping()
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i := range n {
+				id := ids[fmt.Sprintf("page-%d", i)]
+				if id.IsZero() {
+					t.Fatalf("page-%d was not imported", i)
+				}
+				data, err := os.ReadFile(filepath.Join(dir, "ws", id.String(), "index.md"))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if want := fmt.Sprintf("Body %d", i); !strings.Contains(string(data), want) {
+					t.Errorf("page-%d: want %q in:\n%s", i, want, data)
+				}
+			}
+
+			// The client rate limit holds across concurrent requests.
+			ft.mu.Lock()
+			defer ft.mu.Unlock()
+			if want := 2 + 2*n; len(ft.starts) != want {
+				t.Errorf("got %d requests, want %d", len(ft.starts), want)
+			}
+			starts := slices.SortedFunc(slices.Values(ft.starts), time.Time.Compare)
+			interval := time.Second / rps
+			for i := 1; i < len(starts); i++ {
+				if gap := starts[i].Sub(starts[i-1]); gap < interval/2 {
+					t.Errorf("requests %d and %d started %s apart, want about %s", i-1, i, gap, interval)
+				}
+			}
+			if got, want := starts[len(starts)-1].Sub(starts[0]), time.Duration(len(starts)-1)*interval-interval; got < want {
+				t.Errorf("%d requests took %s, want at least %s", len(starts), got, want)
+			}
+			if concurrency == 1 {
+				if ft.maxInFlight != 1 {
+					t.Errorf("got %d requests in flight, want 1", ft.maxInFlight)
+				}
+			} else if ft.maxInFlight < 2 || ft.maxInFlight > concurrency {
+				t.Errorf("got %d requests in flight, want between 2 and %d", ft.maxInFlight, concurrency)
+			}
+		})
+	}
+}
+
+// dryRunTransport serves a database with two rows and two pages, the first of
+// which has two Notion-hosted files and an external image.
+type dryRunTransport struct{}
+
+func (dryRunTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	text := func(s string) string {
+		return `[{"type":"text","text":{"content":"` + s + `"},"plain_text":"` + s + `"}]`
+	}
+	page := func(id string) string {
+		return `{"object":"page","id":"` + id + `","parent":{"type":"workspace","workspace":true},` +
+			`"properties":{"title":{"type":"title","title":` + text(id) + `}}}`
+	}
+	db := `{"object":"database","id":"db-1","parent":{"type":"workspace","workspace":true},"title":` + text("Tasks") + `,` +
+		`"properties":{"Name":{"id":"title","name":"Name","type":"title","title":{}}}}`
+	row := func(id string) string {
+		return `{"object":"page","id":"` + id + `","parent":{"type":"database_id","database_id":"db-1"},` +
+			`"properties":{"Name":{"type":"title","title":` + text(id) + `}}}`
+	}
+	media := func(id, typ, kind, u string) string {
+		return `{"object":"block","id":"` + id + `","type":"` + typ + `","` + typ + `":{"type":"` + kind + `","` + kind + `":{"url":"` + u + `"}}}`
+	}
+	const s3 = "https://prod-files-secure.s3.us-west-2.amazonaws.com/"
+	sizes := map[string]string{"/a.png": "1000", "/b.pdf": "2345"}
+
+	var body string
+	if req.URL.Host == "prod-files-secure.s3.us-west-2.amazonaws.com" {
+		if req.Header.Get("Range") != "bytes=0-0" {
+			return nil, fmt.Errorf("unexpected download of %s", req.URL)
+		}
+		h := http.Header{"Content-Range": []string{"bytes 0-0/" + sizes[req.URL.Path]}}
+		return &http.Response{StatusCode: http.StatusPartialContent, Header: h, Body: io.NopCloser(strings.NewReader("x")), Request: req}, nil
+	}
+	switch p := req.URL.Path; {
+	case strings.HasSuffix(p, "/search"):
+		b, _ := io.ReadAll(req.Body)
+		if bytes.Contains(b, []byte(`"database"`)) {
+			body = `{"results":[` + db + `],"has_more":false}`
+		} else {
+			body = `{"results":[` + page("page-1") + `,` + page("page-2") + `,` + row("row-1") + `],"has_more":false}`
+		}
+	case strings.HasSuffix(p, "/databases/db-1/query"):
+		body = `{"results":[` + row("row-1") + `,` + row("row-2") + `],"has_more":false}`
+	case strings.HasSuffix(p, "/databases/db-1"):
+		body = db
+	case strings.HasSuffix(p, "/pages/page-1"):
+		body = page("page-1")
+	case strings.HasSuffix(p, "/pages/page-2"):
+		body = page("page-2")
+	case strings.HasSuffix(p, "/blocks/page-1/children"):
+		body = `{"results":[` + media("b-1", "image", "file", s3+"a.png") + `,` + media("b-2", "pdf", "file", s3+"b.pdf") + `,` +
+			media("b-3", "image", "external", "https://example.com/c.png") + `],"has_more":false}`
+	case strings.HasSuffix(p, "/blocks/page-2/children"):
+		body = `{"results":[],"has_more":false}`
+	default:
+		return &http.Response{StatusCode: http.StatusNotFound, Body: io.NopCloser(strings.NewReader(`{"object":"error","status":404,"code":"object_not_found","message":"` + p + `"}`)), Request: req}, nil
+	}
+	return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(body)), Request: req}, nil
+}
+
+func TestExtractor_DryRunEstimate(t *testing.T) {
+	tests := []struct {
+		name           string
+		includeContent bool
+		estimate       bool
+		want           *DryRunEstimate
+		records        int
+	}{
+		// Discovery only, without the queries and content fetches.
+		{"Discover", true, false, nil, 0},
+		// 2 searches, 1 database, 2 pages and 1 query.
+		{"Metadata", false, true, &DryRunEstimate{APICalls: 6}, 2},
+		// Plus the blocks of the 2 pages.
+		{"Content", true, true, &DryRunEstimate{APICalls: 8, Assets: 2, AssetBytes: 3345}, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := NewClientWithOptions("tok", ClientOptions{HTTPClient: &http.Client{Transport: dryRunTransport{}}})
+			e := NewExtractor(client, NewWriter(t.TempDir(), "ws"), &orderedProgress{t: t})
+			out, err := e.DryRunJSON(t.Context(), ExtractOptions{IncludeContent: tt.includeContent, Estimate: tt.estimate, Concurrency: 2})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got DryRunResult
+			if err := json.Unmarshal([]byte(out), &got); err != nil {
+				t.Fatalf("invalid JSON: %v\n%s", err, out)
+			}
+			if !reflect.DeepEqual(got.Estimate, tt.want) {
+				t.Errorf("estimate = %+v, want %+v", got.Estimate, tt.want)
+			}
+			if n := client.requests.Load(); !tt.estimate && n != 5 {
+				t.Errorf("made %d requests, want the 5 of discovery", n)
+			}
+			if len(got.Databases) != 1 || got.Databases[0].Records != tt.records || len(got.Pages) != 2 {
+				t.Errorf("unexpected items:\n%s", out)
+			}
+		})
+	}
+}
+
+// schemaTransport serves a database with a select, a multi_select and a number
+// property. Its row was fetched after the number property was renamed from
+// Count to Amount.
+type schemaTransport struct{}
+
+func (schemaTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	text := func(s string) string {
+		return `[{"type":"text","text":{"content":"` + s + `"},"plain_text":"` + s + `"}]`
+	}
+	db := `{"object":"database","id":"db-1","parent":{"type":"workspace","workspace":true},"title":` + text("Tasks") + `,"properties":{` +
+		`"Name":{"id":"title","name":"Name","type":"title","title":{}},` +
+		`"Status":{"id":"st","name":"Status","type":"select","select":{"options":[` +
+		`{"id":"s-1","name":"Open","color":"green"},{"id":"s-2","name":"Closed","color":"red"}]}},` +
+		`"Tags":{"id":"tg","name":"Tags","type":"multi_select","multi_select":{"options":[` +
+		`{"id":"t-1","name":"a","color":"blue"},{"id":"t-2","name":"b","color":"gray"}]}},` +
+		`"Count":{"id":"cnt","name":"Count","type":"number","number":{"format":"number"}}}}`
+	row := `{"object":"page","id":"row-1","parent":{"type":"database_id","database_id":"db-1"},"properties":{` +
+		`"Name":{"id":"title","type":"title","title":` + text("First") + `},` +
+		`"Status":{"id":"st","type":"select","select":{"id":"s-2","name":"Closed","color":"red"}},` +
+		`"Tags":{"id":"tg","type":"multi_select","multi_select":[{"id":"t-1","name":"a"},{"id":"t-2","name":"b"}]},` +
+		`"Amount":{"id":"cnt","type":"number","number":3}}}`
+
+	var body string
+	switch p := req.URL.Path; {
+	case strings.HasSuffix(p, "/search"):
+		b, _ := io.ReadAll(req.Body)
+		if bytes.Contains(b, []byte(`"database"`)) {
+			body = `{"results":[` + db + `],"has_more":false}`
+		} else {
+			body = `{"results":[],"has_more":false}`
+		}
+	case strings.HasSuffix(p, "/databases/db-1/query"):
+		body = `{"results":[` + row + `],"has_more":false}`
+	case strings.HasSuffix(p, "/databases/db-1"):
+		body = db
+	default:
+		return &http.Response{StatusCode: http.StatusNotFound, Body: io.NopCloser(strings.NewReader(`{"object":"error","status":404,"code":"object_not_found","message":"` + p + `"}`)), Request: req}, nil
+	}
+	return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(body)), Request: req}, nil
+}
+
+func TestExtractor_DatabaseSchema(t *testing.T) {
+	dir := t.TempDir()
+	client := NewClientWithOptions("tok", ClientOptions{HTTPClient: &http.Client{Transport: schemaTransport{}}})
+	writer := NewWriter(dir, "ws")
+	stats, err := NewExtractor(client, writer, &orderedProgress{t: t}).Extract(t.Context(), ExtractOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Databases != 1 || stats.Records != 1 || stats.Errors != 0 {
+		t.Fatalf("unexpected stats: %+v", stats)
+	}
+	ids, err := writer.LoadIDMapping()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tableDir := filepath.Join(dir, "ws", ids["db-1"].String())
+
+	// The table metadata is written even without views, with the schema in
+	// a stable order.
+	raw, err := os.ReadFile(filepath.Join(tableDir, "metadata.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var meta TableMetadata
+	if err := json.Unmarshal(raw, &meta); err != nil {
+		t.Fatal(err)
+	}
+	if meta.Title != "Tasks" {
+		t.Errorf("title = %q", meta.Title)
+	}
+	want := []content.Property{
+		{Name: "Name", Type: content.PropertyTypeText},
+		{Name: "Count", Type: content.PropertyTypeNumber},
+		{Name: "Status", Type: content.PropertyTypeSelect, Options: []content.SelectOption{
+			{ID: "s-1", Name: "Open", Color: "green"}, {ID: "s-2", Name: "Closed", Color: "red"},
+		}},
+		{Name: "Tags", Type: content.PropertyTypeMultiSelect, Options: []content.SelectOption{
+			{ID: "t-1", Name: "a", Color: "blue"}, {ID: "t-2", Name: "b", Color: "gray"},
+		}},
+	}
+	if !reflect.DeepEqual(meta.Properties, want) {
+		t.Errorf("properties = %+v\nwant %+v", meta.Properties, want)
+	}
+
+	// The renamed property is stored under its schema name.
+	table, err := jsonldb.NewTable[*content.DataRecord](filepath.Join(tableDir, "data.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	record := table.Get(ids["row-1"])
+	if record == nil {
+		t.Fatal("row not written")
+	}
+	wantData := map[string]any{"Name": "First", "Status": "s-2", "Tags": []any{"t-1", "t-2"}, "Count": 3.0}
+	if !reflect.DeepEqual(record.Data, wantData) {
+		t.Errorf("data = %#v\nwant %#v", record.Data, wantData)
+	}
+}
+
+// syncTransport serves a workspace with three pages and a database with three
+// rows. Once synced is set, it serves the state after an edit: page-1 and
+// row-1 were renamed, page-3 and row-3 archived and row-4 added, while page-2
+// and row-2 were not edited. The database query only returns the rows edited
+// since the time of its filter.
+type syncTransport struct {
+	synced bool
+	query  []byte // body of the last database query
+}
+
+func (f *syncTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	const old, edited = "2026-01-01T00:00:00Z", "2026-07-01T00:00:00Z"
+	text := func(s string) string {
+		return `[{"type":"text","text":{"content":"` + s + `"},"plain_text":"` + s + `"}]`
+	}
+	item := func(id, parent, title, lastEdited string, archived bool) string {
+		return `{"object":"page","id":"` + id + `","parent":` + parent + `,"last_edited_time":"` + lastEdited + `",` +
+			`"archived":` + fmt.Sprint(archived) + `,"properties":{"Name":{"id":"title","type":"title","title":` + text(title) + `}}}`
+	}
+	page := func(id, title, lastEdited string, archived bool) string {
+		return item(id, `{"type":"workspace","workspace":true}`, title, lastEdited, archived)
+	}
+	row := func(id, title, lastEdited string, archived bool) string {
+		return item(id, `{"type":"database_id","database_id":"db-1"}`, title, lastEdited, archived)
+	}
+	db := `{"object":"database","id":"db-1","parent":{"type":"workspace","workspace":true},"last_edited_time":"` + old + `",` +
+		`"title":` + text("Tasks") + `,"properties":{"Name":{"id":"title","name":"Name","type":"title","title":{}}}}`
+	pages := map[string]string{
+		"page-1": page("page-1", "One", old, false),
+		"page-2": page("page-2", "Two", old, false),
+		"page-3": page("page-3", "Three", old, false),
+	}
+	rows := []string{row("row-1", "a", old, false), row("row-2", "b", old, false), row("row-3", "c", old, false)}
+	if f.synced {
+		pages["page-1"] = page("page-1", "One v2", edited, false)
+		pages["page-3"] = page("page-3", "Three", edited, true)
+		rows = []string{row("row-1", "a v2", edited, false), row("row-3", "c", edited, true), row("row-4", "d", edited, false)}
+	}
+
+	var body string
+	switch p := req.URL.Path; {
+	case strings.HasSuffix(p, "/search"):
+		b, _ := io.ReadAll(req.Body)
+		if bytes.Contains(b, []byte(`"database"`)) {
+			body = `{"results":[` + db + `],"has_more":false}`
+		} else {
+			body = `{"results":[` + pages["page-1"] + `,` + pages["page-2"] + `,` + pages["page-3"] + `],"has_more":false}`
+		}
+	case strings.HasSuffix(p, "/databases/db-1/query"):
+		f.query, _ = io.ReadAll(req.Body)
+		body = `{"results":[` + strings.Join(rows, ",") + `],"has_more":false}`
+	case strings.HasSuffix(p, "/databases/db-1"):
+		body = db
+	case strings.Contains(p, "/pages/"):
+		body = pages[p[strings.LastIndex(p, "/")+1:]]
+	}
+	if body == "" {
+		return &http.Response{StatusCode: http.StatusNotFound, Body: io.NopCloser(strings.NewReader(`{"object":"error","status":404,"code":"object_not_found","message":"` + req.URL.Path + `"}`)), Request: req}, nil
+	}
+	return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(body)), Request: req}, nil
+}
+
+func TestExtractor_Sync(t *testing.T) {
+	dir := t.TempDir()
+	transport := &syncTransport{}
+	client := NewClientWithOptions("tok", ClientOptions{HTTPClient: &http.Client{Transport: transport}})
+	if _, err := NewExtractor(client, NewWriter(dir, "ws"), &orderedProgress{t: t}).Extract(t.Context(), ExtractOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(transport.query, []byte("last_edited_time")) {
+		t.Errorf("full import filtered rows: %s", transport.query)
+	}
+
+	transport.synced = true
+	writer := NewWriter(dir, "ws")
+	since := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	stats, err := NewExtractor(client, writer, &orderedProgress{t: t}).Extract(t.Context(), ExtractOptions{SyncSince: since})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `"on_or_after":"2026-06-01T00:00:00Z"`; !bytes.Contains(transport.query, []byte(want)) {
+		t.Errorf("query = %s, want filter %s", transport.query, want)
+	}
+	// page-1, row-1 and row-4 updated; page-2 and row-2 unchanged; page-3
+	// trashed and row-3 deleted.
+	stats.Duration = 0
+	want := ExtractStats{Pages: 1, Databases: 1, Records: 2, Updated: 3, Unchanged: 2, Trashed: 2}
+	if *stats != want {
+		t.Errorf("stats = %+v\nwant %+v", *stats, want)
+	}
+
+	ids, err := writer.LoadIDMapping()
+	if err != nil {
+		t.Fatal(err)
+	}
+	wsDir := filepath.Join(dir, "ws")
+	if md, err := os.ReadFile(filepath.Join(wsDir, ids["page-1"].String(), "index.md")); err != nil || !strings.Contains(string(md), `"One v2"`) {
+		t.Errorf("page-1 not updated: %q, %v", md, err)
+	}
+	if _, err := os.Stat(filepath.Join(wsDir, ids["page-2"].String(), "index.md")); err != nil {
+		t.Errorf("page-2 lost: %v", err)
+	}
+	trashed := filepath.Join(wsDir, ".trash", ids["page-3"].String())
+	if _, err := os.Stat(filepath.Join(trashed, "index.md")); err != nil {
+		t.Errorf("page-3 not trashed: %v", err)
+	}
+	if _, err := os.Stat(trashed + ".json"); err != nil {
+		t.Errorf("page-3 trash info: %v", err)
+	}
+
+	// The manifest keeps one entry per node still present.
+	raw, err := os.ReadFile(filepath.Join(wsDir, "nodes.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for line := range bytes.Lines(raw) {
+		var entry NodeEntry
+		if err := json.Unmarshal(line, &entry); err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, entry.Title)
+	}
+	slices.Sort(got)
+	if wantTitles := []string{"One v2", "Tasks", "Two"}; !slices.Equal(got, wantTitles) {
+		t.Errorf("manifest = %q, want %q", got, wantTitles)
+	}
+
+	table, err := jsonldb.NewTable[*content.DataRecord](filepath.Join(wsDir, ids["db-1"].String(), "data.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := map[ksid.ID]string{}
+	for r := range table.Iter(0) {
+		names[r.ID] = fmt.Sprint(r.Data["Name"])
+	}
+	wantNames := map[ksid.ID]string{ids["row-1"]: "a v2", ids["row-2"]: "b", ids["row-4"]: "d"}
+	if !reflect.DeepEqual(names, wantNames) {
+		t.Errorf("records = %v, want %v", names, wantNames)
+	}
+}
@@ -5,6 +5,7 @@ package notion
 import (
 	"fmt"
 	"maps"
+	"slices"
 	"strings"
 	"time"
 
@@ -23,6 +24,10 @@ type Mapper struct {
 	// Asset context for downloading files (set before mapping records)
 	assets *AssetDownloader
 	nodeID ksid.ID
+
+	// dangling lists the Notion IDs of related pages missing from the import,
+	// found since the last TakeDanglingRelations call.
+	dangling []string
 }
 
 // NewMapper creates a new type mapper.
@@ -74,28 +79,47 @@ func parseDateValue(s string) any {
 }
 
 // ResolveRelations updates relation properties with resolved mddb node IDs.
-// Call this after all databases have been mapped.
-func (m *Mapper) ResolveRelations(node *content.Node) {
+// Call this after all databases have been mapped. It returns the names of the
+// relation properties whose target database is not part of the import.
+func (m *Mapper) ResolveRelations(node *content.Node) []string {
+	var unresolved []string
 	for i := range node.Properties {
 		prop := &node.Properties[i]
 		if prop.Type == content.PropertyTypeRelation && prop.RelationConfig != nil {
 			if notionDBID, ok := m.PendingRelations[prop.Name]; ok {
-				// Try exact match first
-				if mddbID, ok := m.NotionToMddb[notionDBID]; ok {
+				if mddbID, ok := m.lookupID(notionDBID); ok {
 					prop.RelationConfig.TargetNodeID = mddbID
 				} else {
-					// Try normalized ID (Notion sometimes uses UUIDs with/without dashes)
-					normalizedID := strings.ReplaceAll(notionDBID, "-", "")
-					for notionID, mddbID := range m.NotionToMddb {
-						if strings.ReplaceAll(notionID, "-", "") == normalizedID {
-							prop.RelationConfig.TargetNodeID = mddbID
-							break
-						}
-					}
+					unresolved = append(unresolved, prop.Name)
 				}
 			}
 		}
 	}
+	return unresolved
+}
+
+// TakeDanglingRelations returns the Notion IDs of related pages that were
+// referenced by records mapped since the previous call but are not part of the
+// import. Their values keep the Notion ID with a "notion:" prefix.
+func (m *Mapper) TakeDanglingRelations() []string {
+	d := m.dangling
+	m.dangling = nil
+	return d
+}
+
+// lookupID returns the mddb ID assigned to a Notion ID.
+func (m *Mapper) lookupID(notionID string) (ksid.ID, bool) {
+	if mddbID, ok := m.NotionToMddb[notionID]; ok {
+		return mddbID, true
+	}
+	// Notion sometimes uses UUIDs with or without dashes.
+	normalizedID := strings.ReplaceAll(notionID, "-", "")
+	for notionKey, mddbID := range m.NotionToMddb {
+		if strings.ReplaceAll(notionKey, "-", "") == normalizedID {
+			return mddbID, true
+		}
+	}
+	return 0, false
 }
 
 // ClearPendingRelations clears the pending relations map for the next database.
@@ -138,8 +162,9 @@ func (m *Mapper) MapDatabase(db *Database) (*content.Node, error) {
 		Modified: storage.ToTime(db.LastEditedTime),
 	}
 
-	// Convert properties to mddb schema
-	for name := range db.Properties {
+	// Convert properties to mddb schema. Notion does not expose the column
+	// order; put the title first and the rest by name so it is stable.
+	for _, name := range schemaNames(db.Properties) {
 		prop := db.Properties[name]
 		mddbProp := m.mapDBProperty(name, &prop)
 		if mddbProp != nil {
@@ -150,26 +175,24 @@ func (m *Mapper) MapDatabase(db *Database) (*content.Node, error) {
 	return node, nil
 }
 
-// MapDatabaseIconCover downloads and sets the icon and cover for a database node.
-// Call this after MapDatabase when you have an AssetDownloader available.
-func (m *Mapper) MapDatabaseIconCover(node *content.Node, db *Database, assets *AssetDownloader) {
-	if assets == nil {
-		return
-	}
-
-	// Process icon
-	if db.Icon != nil {
-		if icon, err := assets.ProcessIcon(node.ID, db.Icon); err == nil && icon != "" {
-			node.Icon = icon
+// schemaNames returns the property names of a database schema, the title
+// property first and the others sorted.
+func schemaNames(schema map[string]DBProperty) []string {
+	return slices.SortedFunc(maps.Keys(schema), func(a, b string) int {
+		if ta, tb := schema[a].Type == "title", schema[b].Type == "title"; ta != tb {
+			if ta {
+				return -1
+			}
+			return 1
 		}
-	}
+		return strings.Compare(a, b)
+	})
+}
 
-	// Process cover
-	if db.Cover != nil {
-		if cover, err := assets.ProcessCover(node.ID, db.Cover); err == nil && cover != "" {
-			node.Cover = cover
-		}
-	}
+// MapDatabaseIconCover downloads and sets the icon and cover for a database node.
+// Call this after MapDatabase. See mapIconCover.
+func (m *Mapper) MapDatabaseIconCover(node *content.Node, db *Database, assets *AssetDownloader) []error {
+	return mapIconCover(node, db.Icon, db.Cover, assets)
 }
 
 // mapDBProperty converts a Notion database property definition to mddb Property.
@@ -305,25 +328,37 @@ func (m *Mapper) MapPage(page *Page) (*content.Node, error) {
 }
 
 // MapPageIconCover downloads and sets the icon and cover for a page node.
-// Call this after MapPage when you have an AssetDownloader available.
-func (m *Mapper) MapPageIconCover(node *content.Node, page *Page, assets *AssetDownloader) {
+// Call this after MapPage. See mapIconCover.
+func (m *Mapper) MapPageIconCover(node *content.Node, page *Page, assets *AssetDownloader) []error {
+	return mapIconCover(node, page.Icon, page.Cover, assets)
+}
+
+// mapIconCover sets the icon and cover of node. Emoji icons are always kept;
+// images are only downloaded when assets is not nil. Download failures leave
+// the field empty and are returned so the caller can report them.
+func mapIconCover(node *content.Node, icon *Icon, cover *Cover, assets *AssetDownloader) []error {
+	if icon != nil && icon.Type == "emoji" {
+		node.Icon = icon.Emoji
+	}
 	if assets == nil {
-		return
+		return nil
 	}
-
-	// Process icon
-	if page.Icon != nil {
-		if icon, err := assets.ProcessIcon(node.ID, page.Icon); err == nil && icon != "" {
-			node.Icon = icon
+	var errs []error
+	if icon != nil && icon.Type != "emoji" {
+		if path, err := assets.ProcessIcon(node.ID, icon); err != nil {
+			errs = append(errs, fmt.Errorf("icon: %w", err))
+		} else {
+			node.Icon = path
 		}
 	}
-
-	// Process cover
-	if page.Cover != nil {
-		if cover, err := assets.ProcessCover(node.ID, page.Cover); err == nil && cover != "" {
-			node.Cover = cover
+	if cover != nil {
+		if path, err := assets.ProcessCover(node.ID, cover); err != nil {
+			errs = append(errs, fmt.Errorf("cover: %w", err))
+		} else {
+			node.Cover = path
 		}
 	}
+	return errs
 }
 
 // resolveParentID converts a Notion Parent to an mddb node ID.
@@ -344,19 +379,10 @@ func (m *Mapper) resolveParentID(parent Parent) ksid.ID {
 		return 0
 	}
 
-	// Try exact match first
-	if mddbID, ok := m.NotionToMddb[notionID]; ok {
+	if mddbID, ok := m.lookupID(notionID); ok {
 		return mddbID
 	}
 
-	// Try normalized ID (Notion sometimes uses UUIDs with/without dashes)
-	normalizedID := strings.ReplaceAll(notionID, "-", "")
-	for notionKey, mddbID := range m.NotionToMddb {
-		if strings.ReplaceAll(notionKey, "-", "") == normalizedID {
-			return mddbID
-		}
-	}
-
 	// Parent not yet imported - return zero ID
 	// This happens for:
 	// - Child pages/databases whose parents weren't selected for import
@@ -380,9 +406,20 @@ func (m *Mapper) MapDatabasePage(page *Page, schema map[string]DBProperty) (*con
 		Modified: storage.ToTime(page.LastEditedTime),
 	}
 
-	// Map each property value
+	// Map each property value. A property renamed after the schema was
+	// fetched has its new name in the row; store it under the schema name,
+	// matching by property ID.
+	var byID map[string]string
 	for name := range page.Properties {
 		propValue := page.Properties[name]
+		if _, ok := schema[name]; !ok && propValue.ID != "" {
+			if byID == nil {
+				byID = schemaNamesByID(schema)
+			}
+			if schemaName, ok := byID[propValue.ID]; ok {
+				name = schemaName
+			}
+		}
 		value := m.mapPropertyValue(&propValue, schema[name].Type)
 		if value != nil {
 			record.Data[name] = value
@@ -392,6 +429,17 @@ func (m *Mapper) MapDatabasePage(page *Page, schema map[string]DBProperty) (*con
 	return record, nil
 }
 
+// schemaNamesByID maps the property IDs of a database schema to their names.
+func schemaNamesByID(schema map[string]DBProperty) map[string]string {
+	byID := make(map[string]string, len(schema))
+	for name := range schema {
+		if id := schema[name].ID; id != "" {
+			byID[id] = name
+		}
+	}
+	return byID
+}
+
 // mapPropertyValue converts a Notion property value to an mddb value.
 // schemaType is preserved for future use with relation/rollup mapping.
 func (m *Mapper) mapPropertyValue(pv *PropertyValue, _ string) any {
@@ -399,7 +447,7 @@ func (m *Mapper) mapPropertyValue(pv *PropertyValue, _ string) any {
 	case "title":
 		return richTextToPlain(pv.Title)
 	case "rich_text":
-		return richTextToMarkdown(pv.RichText)
+		return richTextToMarkdownWith(pv.RichText, m.lookupID)
 	case "number":
 		if pv.Number != nil {
 			return *pv.Number
@@ -483,11 +531,13 @@ func (m *Mapper) mapPropertyValue(pv *PropertyValue, _ string) any {
 		// Return mddb IDs for resolved relations, Notion IDs for unresolved
 		var ids []string
 		for _, rel := range pv.Relation {
-			if mddbID, ok := m.NotionToMddb[rel.ID]; ok {
+			if mddbID, ok := m.lookupID(rel.ID); ok {
 				ids = append(ids, mddbID.String())
 			} else {
-				// Keep Notion ID as fallback (cross-database relations may not be resolved yet)
+				// Keep Notion ID as a dangling reference: the related
+				// database was not part of the import.
 				ids = append(ids, "notion:"+rel.ID)
+				m.dangling = append(m.dangling, rel.ID)
 			}
 		}
 		return ids
@@ -369,6 +369,47 @@ func TestResolveRelations(t *testing.T) {
 	}
 }
 
+func TestResolveRelations_Dangling(t *testing.T) {
+	m := NewMapper()
+	rowID := m.AssignRecordID("aaaaaaaa-0000-0000-0000-000000000001")
+	m.PendingRelations["Missing"] = "db-not-imported"
+	node := &content.Node{
+		Properties: []content.Property{
+			{Name: "Missing", Type: content.PropertyTypeRelation, RelationConfig: &content.RelationConfig{}},
+		},
+	}
+	if got := m.ResolveRelations(node); len(got) != 1 || got[0] != "Missing" {
+		t.Errorf("unresolved = %v, want [Missing]", got)
+	}
+	if !node.Properties[0].RelationConfig.TargetNodeID.IsZero() {
+		t.Error("unresolved relation should have no target")
+	}
+
+	page := &Page{
+		ID: "row-1",
+		Properties: map[string]PropertyValue{
+			"Missing": {Type: "relation", Relation: []RelationValue{
+				{ID: "aaaaaaaa000000000000000000000001"}, // same row, without dashes
+				{ID: "not-imported"},
+			}},
+		},
+	}
+	record, err := m.MapDatabasePage(page, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, _ := record.Data["Missing"].([]string)
+	if len(got) != 2 || got[0] != rowID.String() || got[1] != "notion:not-imported" {
+		t.Errorf("relation values = %v", got)
+	}
+	if d := m.TakeDanglingRelations(); len(d) != 1 || d[0] != "not-imported" {
+		t.Errorf("dangling = %v, want [not-imported]", d)
+	}
+	if d := m.TakeDanglingRelations(); len(d) != 0 {
+		t.Errorf("dangling not reset: %v", d)
+	}
+}
+
 func TestClearPendingRelations(t *testing.T) {
 	m := NewMapper()
 	m.PendingRelations["Tasks"] = "db-123"
@@ -14,6 +14,10 @@ type MarkdownConverter struct {
 	assets *AssetDownloader
 	nodeID ksid.ID
 	mapper *Mapper // For resolving child page/database links
+
+	// MaxDepth is the max nesting depth of blocks to render (0 = unlimited),
+	// like ExtractOptions.MaxDepth.
+	MaxDepth int
 }
 
 // NewMarkdownConverter creates a converter, optionally with asset downloading.
@@ -36,7 +40,7 @@ func NewMarkdownConverterWithLinks(assets *AssetDownloader, nodeID ksid.ID, mapp
 // Convert converts blocks to markdown, downloading assets if configured.
 func (c *MarkdownConverter) Convert(blocks []Block) string {
 	var sb strings.Builder
-	c.blocksToMarkdownRecursive(blocks, &sb, 0)
+	c.blocksToMarkdownRecursive(blocks, &sb, 0, 0)
 	return sb.String()
 }
 
@@ -82,54 +86,89 @@ func (c *MarkdownConverter) resolveMediaURL(media *MediaBlock) string {
 }
 
 // blocksToMarkdownRecursive converts blocks and their children to markdown.
-func (c *MarkdownConverter) blocksToMarkdownRecursive(blocks []Block, sb *strings.Builder, depth int) {
+// depth is the indentation level and level the nesting level of blocks.
+func (c *MarkdownConverter) blocksToMarkdownRecursive(blocks []Block, sb *strings.Builder, depth, level int) {
 	listState := &listState{}
 	for i := range blocks {
-		md := c.blockToMarkdown(&blocks[i], listState, depth)
+		b := &blocks[i]
+		md := c.blockToMarkdown(b, listState, depth)
 		if md != "" {
 			sb.WriteString(md)
 		}
 		// Recurse into children
-		if len(blocks[i].Children) > 0 {
-			// Special handling for tables
-			if blocks[i].Type == "table" {
-				renderTableChildren(blocks[i].Children, sb, blocks[i].Table)
-			} else {
-				c.blocksToMarkdownRecursive(blocks[i].Children, sb, depth+1)
-				// Close toggle if needed
-				if blocks[i].Type == "toggle" {
-					sb.WriteString(strings.Repeat("  ", depth) + "</details>\n\n")
-				}
+		if len(b.Children) > 0 && (c.MaxDepth <= 0 || level+1 < c.MaxDepth) {
+			switch b.Type {
+			case "table":
+				c.renderTableChildren(b.Children, sb, b.Table)
+			case "column_list", "column", "synced_block":
+				// Layout blocks are flattened: their content is rendered in
+				// sequence, without indentation.
+				c.blocksToMarkdownRecursive(b.Children, sb, depth, level+1)
+			default:
+				c.blocksToMarkdownRecursive(b.Children, sb, depth+1, level+1)
 			}
 		}
+		// Close toggle, even when it has no rendered children.
+		if b.Type == "toggle" && b.Toggle != nil {
+			sb.WriteString(strings.Repeat("  ", depth) + "</details>\n\n")
+		}
 	}
 }
 
-// renderTableChildren renders table rows with proper markdown table formatting.
-func renderTableChildren(rows []Block, sb *strings.Builder, tableInfo *TableBlock) {
+// renderTableChildren renders table rows as a GitHub-flavored markdown table.
+//
+// GFM tables require a header row; when the Notion table has no column header,
+// an empty one is emitted so all rows are rendered as data.
+func (c *MarkdownConverter) renderTableChildren(rows []Block, sb *strings.Builder, tableInfo *TableBlock) {
+	var table [][]string
+	width := 0
+	if tableInfo != nil {
+		width = tableInfo.TableWidth
+	}
 	for i := range rows {
 		row := &rows[i]
 		if row.Type == "table_row" && row.TableRow != nil {
-			numCells := len(row.TableRow.Cells)
-			cells := make([]string, 0, numCells)
+			cells := make([]string, 0, len(row.TableRow.Cells))
 			for _, cell := range row.TableRow.Cells {
-				cells = append(cells, richTextToMarkdown(cell))
-			}
-			sb.WriteString("| " + strings.Join(cells, " | ") + " |\n")
-
-			// Add separator after header row
-			if i == 0 && tableInfo != nil && tableInfo.HasColumnHeader {
-				seps := make([]string, numCells)
-				for j := range seps {
-					seps[j] = "---"
-				}
-				sb.WriteString("| " + strings.Join(seps, " | ") + " |\n")
+				cells = append(cells, tableCell(c.richText(cell)))
 			}
+			width = max(width, len(cells))
+			table = append(table, cells)
+		}
+	}
+	if len(table) == 0 {
+		return
+	}
+	writeRow := func(cells []string) {
+		for len(cells) < width {
+			cells = append(cells, "")
 		}
+		sb.WriteString("| " + strings.Join(cells, " | ") + " |\n")
+	}
+	if tableInfo == nil || !tableInfo.HasColumnHeader {
+		writeRow(nil)
+	} else {
+		writeRow(table[0])
+		table = table[1:]
+	}
+	seps := make([]string, width)
+	for j := range seps {
+		seps[j] = "---"
+	}
+	writeRow(seps)
+	for _, cells := range table {
+		writeRow(cells)
 	}
 	sb.WriteString("\n")
 }
 
+// tableCell escapes markdown text for use in a table cell, which cannot
+// contain pipes or line breaks.
+func tableCell(s string) string {
+	s = strings.ReplaceAll(s, "|", "\\|")
+	return strings.ReplaceAll(s, "\n", "<br>")
+}
+
 // listState tracks list context for proper markdown formatting.
 type listState struct {
 	numberedCount int
@@ -153,7 +192,7 @@ func (c *MarkdownConverter) blockToMarkdown(block *Block, ls *listState, depth i
 	switch block.Type {
 	case "paragraph":
 		if block.Paragraph != nil {
-			text := richTextToMarkdown(block.Paragraph.RichText)
+			text := c.richText(block.Paragraph.RichText)
 			if text == "" {
 				return "\n"
 			}
@@ -162,17 +201,17 @@ func (c *MarkdownConverter) blockToMarkdown(block *Block, ls *listState, depth i
 
 	case "heading_1":
 		if block.Heading1 != nil {
-			return "# " + richTextToMarkdown(block.Heading1.RichText) + "\n\n"
+			return "# " + c.richText(block.Heading1.RichText) + "\n\n"
 		}
 
 	case "heading_2":
 		if block.Heading2 != nil {
-			return "## " + richTextToMarkdown(block.Heading2.RichText) + "\n\n"
+			return "## " + c.richText(block.Heading2.RichText) + "\n\n"
 		}
 
 	case "heading_3":
 		if block.Heading3 != nil {
-			return "### " + richTextToMarkdown(block.Heading3.RichText) + "\n\n"
+			return "### " + c.richText(block.Heading3.RichText) + "\n\n"
 		}
 
 	case "bulleted_list_item":
@@ -182,7 +221,7 @@ func (c *MarkdownConverter) blockToMarkdown(block *Block, ls *listState, depth i
 				ls.inBulleted = true
 				prefix = "\n"
 			}
-			return prefix + indent + "- " + richTextToMarkdown(block.BulletedListItem.RichText) + "\n"
+			return prefix + indent + "- " + c.richText(block.BulletedListItem.RichText) + "\n"
 		}
 
 	case "numbered_list_item":
@@ -194,7 +233,7 @@ func (c *MarkdownConverter) blockToMarkdown(block *Block, ls *listState, depth i
 				prefix = "\n"
 			}
 			ls.numberedCount++
-			return fmt.Sprintf("%s%s%d. %s\n", prefix, indent, ls.numberedCount, richTextToMarkdown(block.NumberedListItem.RichText))
+			return fmt.Sprintf("%s%s%d. %s\n", prefix, indent, ls.numberedCount, c.richText(block.NumberedListItem.RichText))
 		}
 
 	case "to_do":
@@ -203,12 +242,12 @@ func (c *MarkdownConverter) blockToMarkdown(block *Block, ls *listState, depth i
 			if block.ToDo.Checked {
 				checkbox = "[x]"
 			}
-			return indent + "- " + checkbox + " " + richTextToMarkdown(block.ToDo.RichText) + "\n"
+			return indent + "- " + checkbox + " " + c.richText(block.ToDo.RichText) + "\n"
 		}
 
 	case "toggle":
 		if block.Toggle != nil {
-			return indent + "<details>\n" + indent + "<summary>" + richTextToMarkdown(block.Toggle.RichText) + "</summary>\n\n"
+			return indent + "<details>\n" + indent + "<summary>" + c.richText(block.Toggle.RichText) + "</summary>\n\n"
 		}
 
 	case "code":
@@ -222,7 +261,7 @@ func (c *MarkdownConverter) blockToMarkdown(block *Block, ls *listState, depth i
 
 	case "quote":
 		if block.Quote != nil {
-			lines := strings.Split(richTextToMarkdown(block.Quote.RichText), "\n")
+			lines := strings.Split(c.richText(block.Quote.RichText), "\n")
 			var quoted []string
 			for _, line := range lines {
 				quoted = append(quoted, "> "+line)
@@ -236,7 +275,7 @@ func (c *MarkdownConverter) blockToMarkdown(block *Block, ls *listState, depth i
 			if block.Callout.Icon != nil && block.Callout.Icon.Emoji != "" {
 				emoji = block.Callout.Icon.Emoji + " "
 			}
-			return "> " + emoji + richTextToMarkdown(block.Callout.RichText) + "\n\n"
+			return "> " + emoji + c.richText(block.Callout.RichText) + "\n\n"
 		}
 
 	case "divider":
@@ -315,7 +354,7 @@ func (c *MarkdownConverter) blockToMarkdown(block *Block, ls *listState, depth i
 		if block.TableRow != nil {
 			var cells []string
 			for _, cell := range block.TableRow.Cells {
-				cells = append(cells, richTextToMarkdown(cell))
+				cells = append(cells, tableCell(c.richText(cell)))
 			}
 			return "| " + strings.Join(cells, " | ") + " |\n"
 		}
@@ -336,11 +375,35 @@ func (c *MarkdownConverter) blockToMarkdown(block *Block, ls *listState, depth i
 	return ""
 }
 
+// richText converts rich text to markdown, resolving mentions of imported
+// pages and databases to internal links when link resolution is configured.
+func (c *MarkdownConverter) richText(rt []RichText) string {
+	if c.mapper == nil {
+		return richTextToMarkdown(rt)
+	}
+	return richTextToMarkdownWith(rt, c.mapper.lookupID)
+}
+
 // richTextToMarkdown converts rich text to markdown with formatting.
 func richTextToMarkdown(rt []RichText) string {
+	return richTextToMarkdownWith(rt, nil)
+}
+
+// richTextToMarkdownWith converts rich text to markdown with formatting.
+// Mentions of pages and databases that lookup resolves become links to the
+// imported node; lookup may be nil.
+func richTextToMarkdownWith(rt []RichText, lookup func(notionID string) (ksid.ID, bool)) string {
 	parts := make([]string, 0, len(rt))
 	for _, t := range rt {
-		text := t.PlainText
+		// Keep surrounding whitespace outside of the markers, "**bold **" is
+		// not emphasis.
+		text := strings.TrimSpace(t.PlainText)
+		if text == "" {
+			parts = append(parts, t.PlainText)
+			continue
+		}
+		lead := t.PlainText[:strings.Index(t.PlainText, text)]
+		trail := t.PlainText[len(lead)+len(text):]
 
 		// Apply annotations
 		if t.Annotations != nil {
@@ -351,7 +414,7 @@ func richTextToMarkdown(rt []RichText) string {
 				text = "**" + text + "**"
 			}
 			if t.Annotations.Italic {
-				text = "_" + text + "_"
+				text = "*" + text + "*"
 			}
 			if t.Annotations.Strikethrough {
 				text = "~~" + text + "~~"
@@ -362,11 +425,34 @@ func richTextToMarkdown(rt []RichText) string {
 		}
 
 		// Apply link
-		if t.Href != nil && *t.Href != "" {
-			text = "[" + text + "](" + *t.Href + ")"
+		href := ""
+		if t.Href != nil {
+			href = *t.Href
+		} else if t.Text != nil && t.Text.Link != nil {
+			href = t.Text.Link.URL
+		}
+		if id, ok := mentionTarget(t.Mention, lookup); ok {
+			href = "../" + id.String() + "/index.md"
+		}
+		if href != "" {
+			text = "[" + text + "](" + href + ")"
 		}
 
-		parts = append(parts, text)
+		parts = append(parts, lead+text+trail)
 	}
 	return strings.Join(parts, "")
 }
+
+// mentionTarget returns the mddb ID of the page or database mentioned by m.
+func mentionTarget(m *Mention, lookup func(notionID string) (ksid.ID, bool)) (ksid.ID, bool) {
+	if m == nil || lookup == nil {
+		return 0, false
+	}
+	switch {
+	case m.Page != nil:
+		return lookup(m.Page.ID)
+	case m.Database != nil:
+		return lookup(m.Database.ID)
+	}
+	return 0, false
+}
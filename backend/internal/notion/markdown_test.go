@@ -3,6 +3,7 @@
 package notion
 
 import (
+	"encoding/json"
 	"strings"
 	"testing"
 )
@@ -106,7 +107,7 @@ func TestRichTextToMarkdown(t *testing.T) {
 		{
 			"italic",
 			[]RichText{{PlainText: "italic", Annotations: &Annotations{Italic: true}}},
-			"_italic_",
+			"*italic*",
 		},
 		{
 			"code",
@@ -126,7 +127,22 @@ func TestRichTextToMarkdown(t *testing.T) {
 		{
 			"bold and italic",
 			[]RichText{{PlainText: "both", Annotations: &Annotations{Bold: true, Italic: true}}},
-			"_**both**_",
+			"***both***",
+		},
+		{
+			"surrounding whitespace",
+			[]RichText{{PlainText: "a "}, {PlainText: " bold ", Annotations: &Annotations{Bold: true}}, {PlainText: "c"}},
+			"a  **bold** c",
+		},
+		{
+			"annotated link",
+			[]RichText{{PlainText: "go", Annotations: &Annotations{Code: true, Strikethrough: true}, Text: &TextContent{Content: "go", Link: &Link{URL: "https://go.dev"}}}},
+			"[~~`go`~~](https://go.dev)",
+		},
+		{
+			"unresolved mention",
+			[]RichText{{Type: "mention", PlainText: "Other", Mention: &Mention{Type: "page", Page: &PageRef{ID: "abc"}}, Href: ptrStr("https://www.notion.so/abc")}},
+			"[Other](https://www.notion.so/abc)",
 		},
 	}
 
@@ -140,6 +156,33 @@ func TestRichTextToMarkdown(t *testing.T) {
 	}
 }
 
+func TestRichTextMention(t *testing.T) {
+	// Rich text as returned by the Notion API: a bold+italic run followed by a
+	// mention of a page that is part of the import and one that is not.
+	const fixture = `[
+		{"type": "text", "text": {"content": "See ", "link": null}, "annotations": {"bold": true, "italic": true, "strikethrough": false, "underline": false, "code": false, "color": "default"}, "plain_text": "See ", "href": null},
+		{"type": "mention", "mention": {"type": "page", "page": {"id": "1a2b3c4d-0000-4000-8000-000000000001"}}, "annotations": {"bold": false, "italic": true, "strikethrough": false, "underline": false, "code": false, "color": "default"}, "plain_text": "Design Doc", "href": "https://www.notion.so/1a2b3c4d000040008000000000000001"},
+		{"type": "text", "text": {"content": " and ", "link": null}, "plain_text": " and ", "href": null},
+		{"type": "mention", "mention": {"type": "page", "page": {"id": "ffffffff-0000-4000-8000-000000000002"}}, "plain_text": "Elsewhere", "href": "https://www.notion.so/ffffffff000040008000000000000002"}
+	]`
+	var rt []RichText
+	if err := json.Unmarshal([]byte(fixture), &rt); err != nil {
+		t.Fatal(err)
+	}
+	m := NewMapper()
+	id := m.AssignNodeID("1a2b3c4d000040008000000000000001")
+	c := NewMarkdownConverterWithLinks(nil, 0, m)
+	blocks := []Block{{Type: "paragraph", Paragraph: &ParagraphBlock{RichText: rt}}}
+	want := "***See*** [*Design Doc*](../" + id.String() + "/index.md) and [Elsewhere](https://www.notion.so/ffffffff000040008000000000000002)\n\n"
+	if got := c.Convert(blocks); got != want {
+		t.Errorf("Convert() =\n%q\nwant\n%q", got, want)
+	}
+	// Without link resolution, mentions keep their Notion URL.
+	if got := BlocksToMarkdown(blocks); strings.Contains(got, "index.md") {
+		t.Errorf("BlocksToMarkdown() = %q, want unresolved mentions", got)
+	}
+}
+
 func TestBlockToMarkdownImage(t *testing.T) {
 	block := Block{
 		Type: "image",
@@ -171,6 +214,85 @@ func TestBlockToMarkdownCallout(t *testing.T) {
 	}
 }
 
+func TestLayoutBlocksToMarkdown(t *testing.T) {
+	para := func(s string) Block {
+		return Block{Type: "paragraph", Paragraph: &ParagraphBlock{RichText: []RichText{{PlainText: s}}}}
+	}
+	row := func(cells ...string) Block {
+		r := &TableRowBlock{}
+		for _, c := range cells {
+			r.Cells = append(r.Cells, []RichText{{PlainText: c}})
+		}
+		return Block{Type: "table_row", TableRow: r}
+	}
+	toggle := func(s string, children ...Block) Block {
+		return Block{Type: "toggle", Toggle: &ToggleBlock{RichText: []RichText{{PlainText: s}}}, Children: children}
+	}
+	table3x3 := func(header bool) Block {
+		return Block{
+			Type:     "table",
+			Table:    &TableBlock{TableWidth: 3, HasColumnHeader: header},
+			Children: []Block{row("Name", "Qty", "Note"), row("a|b", "1", "x\ny"), row("c", "2")},
+		}
+	}
+	columns := Block{
+		Type:       "column_list",
+		ColumnList: &struct{}{},
+		Children: []Block{
+			{Type: "column", Column: &struct{}{}, Children: []Block{para("Left")}},
+			{Type: "column", Column: &struct{}{}, Children: []Block{para("Right")}},
+		},
+	}
+	nested := toggle("Outer", para("Body"), toggle("Inner", para("Deep")))
+
+	tests := []struct {
+		name     string
+		blocks   []Block
+		maxDepth int
+		want     string
+	}{
+		{
+			"table with header",
+			[]Block{table3x3(true)},
+			0,
+			"| Name | Qty | Note |\n| --- | --- | --- |\n| a\\|b | 1 | x<br>y |\n| c | 2 |  |\n\n",
+		},
+		{
+			"table without header",
+			[]Block{table3x3(false)},
+			0,
+			"|  |  |  |\n| --- | --- | --- |\n| Name | Qty | Note |\n| a\\|b | 1 | x<br>y |\n| c | 2 |  |\n\n",
+		},
+		{
+			"nested toggle",
+			[]Block{nested},
+			0,
+			"<details>\n<summary>Outer</summary>\n\n  Body\n\n  <details>\n  <summary>Inner</summary>\n\n    Deep\n\n  </details>\n\n</details>\n\n",
+		},
+		{
+			"nested toggle max depth",
+			[]Block{nested},
+			2,
+			"<details>\n<summary>Outer</summary>\n\n  Body\n\n  <details>\n  <summary>Inner</summary>\n\n  </details>\n\n</details>\n\n",
+		},
+		{
+			"columns",
+			[]Block{columns, para("After")},
+			0,
+			"Left\n\nRight\n\nAfter\n\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewMarkdownConverter(nil, 0)
+			c.MaxDepth = tt.maxDepth
+			if got := c.Convert(tt.blocks); got != tt.want {
+				t.Errorf("Convert() =\n%q\nwant\n%q", got, tt.want)
+			}
+		})
+	}
+}
+
 func ptrStr(s string) *string {
 	return &s
 }
@@ -0,0 +1,128 @@
+// Fetches Notion objects concurrently ahead of their sequential extraction.
+
+package notion
+
+import (
+	"context"
+	"sync"
+)
+
+// DefaultConcurrency is the default number of concurrent fetches. The client
+// rate limit still applies across all of them; concurrency hides the latency
+// of each request.
+const DefaultConcurrency = 4
+
+// prefetchAhead is how many results per concurrent fetch a prefetch may hold
+// before the caller waits for them. It bounds the memory of prefetched page
+// content while keeping every fetch slot busy.
+const prefetchAhead = 4
+
+// pending is the eventual result of a fetch.
+type pending[T any] struct {
+	v     T
+	err   error
+	done  chan struct{}
+	index int        // Position in the prefetch
+	ahead *lookahead // Of the prefetch
+}
+
+// wait blocks until the fetch completes and returns its result.
+func (p *pending[T]) wait() (T, error) {
+	p.ahead.consume(p.index)
+	<-p.done
+	return p.v, p.err
+}
+
+// lookahead tracks how far the caller of a prefetch got, so the fetches don't
+// run too far ahead of it.
+type lookahead struct {
+	mu       sync.Mutex
+	consumed int           // Results before this index were waited for or skipped
+	advanced chan struct{} // Closed when consumed grows
+}
+
+// consume records that the result at index i is being waited for. Earlier
+// results the caller skipped no longer count.
+func (l *lookahead) consume(i int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if i >= l.consumed {
+		l.consumed = i + 1
+		close(l.advanced)
+		l.advanced = make(chan struct{})
+	}
+}
+
+// waitFor blocks until the result at index i is less than n results ahead of
+// the caller.
+func (l *lookahead) waitFor(ctx context.Context, i, n int) error {
+	for {
+		l.mu.Lock()
+		ok, advanced := i < l.consumed+n, l.advanced
+		l.mu.Unlock()
+		if ok {
+			return nil
+		}
+		select {
+		case <-advanced:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// prefetch starts fetching every ID in the background and returns the pending
+// results in the order of ids.
+//
+// sem bounds the fetches in flight across all prefetch calls; slots are taken
+// in order so that the results the caller waits for first arrive first. Only
+// the fetches run concurrently: callers consume the results, map, write and
+// report progress from their own goroutine. At most prefetchAhead results per
+// slot of sem are fetched ahead of the one the caller waits for.
+//
+// ctx must be canceled once the results are no longer needed: results not
+// fetched yet then fail with its error, which ends the background goroutines.
+func prefetch[T any](ctx context.Context, sem chan struct{}, ids []string, fetch func(ctx context.Context, id string) (T, error)) []*pending[T] {
+	ahead := &lookahead{advanced: make(chan struct{})}
+	out := make([]*pending[T], len(ids))
+	for i := range out {
+		out[i] = &pending[T]{done: make(chan struct{}), index: i, ahead: ahead}
+	}
+	n := prefetchAhead * cap(sem)
+	go func() {
+		for i, id := range ids {
+			err := ahead.waitFor(ctx, i, n)
+			if err == nil {
+				select {
+				case sem <- struct{}{}:
+				case <-ctx.Done():
+					err = ctx.Err()
+				}
+			}
+			if err != nil {
+				for _, p := range out[i:] {
+					p.err = err
+					close(p.done)
+				}
+				return
+			}
+			go func() {
+				defer func() { <-sem }()
+				p := out[i]
+				p.v, p.err = fetch(ctx, id)
+				close(p.done)
+			}()
+		}
+	}()
+	return out
+}
+
+// fetchAll fetches every ID concurrently and waits for all of them.
+func fetchAll[T any](ctx context.Context, sem chan struct{}, ids []string, fetch func(ctx context.Context, id string) (T, error)) ([]T, []error) {
+	vals := make([]T, len(ids))
+	errs := make([]error, len(ids))
+	for i, p := range prefetch(ctx, sem, ids, fetch) {
+		vals[i], errs[i] = p.wait()
+	}
+	return vals, errs
+}
@@ -0,0 +1,56 @@
+// Tests for the concurrent prefetch of Notion objects.
+
+package notion
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestPrefetch(t *testing.T) {
+	ids := make([]string, 100)
+	for i := range ids {
+		ids[i] = strconv.Itoa(i)
+	}
+
+	t.Run("Lookahead", func(t *testing.T) {
+		sem := make(chan struct{}, 2)
+		var started atomic.Int32
+		fetch := func(ctx context.Context, id string) (string, error) {
+			started.Add(1)
+			return id, nil
+		}
+		out := prefetch(t.Context(), sem, ids, fetch)
+		if v, err := out[0].wait(); err != nil || v != "0" {
+			t.Fatalf("got %q, %v", v, err)
+		}
+		// Give the dispatcher time to run as far as it is allowed to.
+		time.Sleep(50 * time.Millisecond)
+		if n, want := int(started.Load()), 1+prefetchAhead*cap(sem); n != want {
+			t.Errorf("started %d fetches, want %d", n, want)
+		}
+		// Waiting for a later result skips the ones before it.
+		if v, err := out[50].wait(); err != nil || v != "50" {
+			t.Fatalf("got %q, %v", v, err)
+		}
+	})
+
+	t.Run("Cancel", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(t.Context())
+		sem := make(chan struct{}, 1)
+		out := prefetch(ctx, sem, ids, func(ctx context.Context, id string) (string, error) {
+			return id, ctx.Err()
+		})
+		if _, err := out[0].wait(); err != nil {
+			t.Fatal(err)
+		}
+		cancel()
+		if _, err := out[len(out)-1].wait(); !errors.Is(err, context.Canceled) {
+			t.Errorf("got %v, want context.Canceled", err)
+		}
+	})
+}
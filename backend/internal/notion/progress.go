@@ -3,6 +3,7 @@
 package notion
 
 import (
+	"encoding/json"
 	"fmt"
 	"io"
 	"time"
@@ -10,18 +11,41 @@ import (
 
 // ExtractStats contains statistics about an extraction operation.
 type ExtractStats struct {
-	Pages     int           `json:"pages"`
-	Databases int           `json:"databases"`
-	Records   int           `json:"records"`
-	Assets    int           `json:"assets"`
-	Errors    int           `json:"errors"`
-	Duration  time.Duration `json:"duration"`
+	Pages            int           `json:"pages"`
+	Databases        int           `json:"databases"`
+	Records          int           `json:"records"`
+	Assets           int           `json:"assets"`            // downloaded or kept as external links
+	AssetsDownloaded int           `json:"assets_downloaded"` // stored in the workspace, icons and covers included
+	Errors           int           `json:"errors"`
+	Duration         time.Duration `json:"duration"`
+
+	// Updated and Unchanged count the pages and records written and skipped by
+	// an incremental sync, see ExtractOptions.SyncSince.
+	Updated   int `json:"updated,omitempty"`
+	Unchanged int `json:"unchanged,omitempty"`
+	// Trashed counts the nodes trashed and, by a sync, the records deleted as
+	// they were archived in Notion.
+	Trashed int `json:"trashed,omitempty"`
+}
+
+// Phases of an extraction, reported in ProgressItem.Phase.
+const (
+	PhaseSetup     = "setup"     // before OnStart, e.g. loading the ID mapping
+	PhaseDatabases = "databases" // writing databases and their records
+	PhasePages     = "pages"     // extracting standalone pages
+)
+
+// ProgressItem describes the item an OnProgress call reports.
+type ProgressItem struct {
+	Phase   string // one of the Phase constants
+	ID      string // Notion ID of the database or page, empty in PhaseSetup
+	Message string // human-readable description
 }
 
 // ProgressReporter is the interface for reporting extraction progress.
 type ProgressReporter interface {
 	OnStart(total int)
-	OnProgress(current int, item string)
+	OnProgress(current int, item ProgressItem)
 	OnWarning(msg string)
 	OnError(err error)
 	OnComplete(stats ExtractStats)
@@ -39,8 +63,8 @@ func (p *CLIProgress) OnStart(total int) {
 }
 
 // OnProgress is called for each item processed.
-func (p *CLIProgress) OnProgress(current int, item string) {
-	_, _ = fmt.Fprintf(p.Out, "[%d] %s\n", current, item)
+func (p *CLIProgress) OnProgress(current int, item ProgressItem) {
+	_, _ = fmt.Fprintf(p.Out, "[%d] %s\n", current, item.Message)
 }
 
 // OnWarning is called for non-fatal issues.
@@ -60,7 +84,10 @@ func (p *CLIProgress) OnComplete(stats ExtractStats) {
 	_, _ = fmt.Fprintf(p.Out, "Databases: %d\n", stats.Databases)
 	_, _ = fmt.Fprintf(p.Out, "Pages:     %d\n", stats.Pages)
 	_, _ = fmt.Fprintf(p.Out, "Records:   %d\n", stats.Records)
-	_, _ = fmt.Fprintf(p.Out, "Assets:    %d\n", stats.Assets)
+	_, _ = fmt.Fprintf(p.Out, "Assets:    %d (%d downloaded)\n", stats.Assets, stats.AssetsDownloaded)
+	if stats.Updated+stats.Unchanged+stats.Trashed > 0 {
+		_, _ = fmt.Fprintf(p.Out, "Synced:    %d updated, %d unchanged, %d trashed\n", stats.Updated, stats.Unchanged, stats.Trashed)
+	}
 	if stats.Errors > 0 {
 		_, _ = fmt.Fprintf(p.Out, "Errors:    %d\n", stats.Errors)
 	}
@@ -72,10 +99,17 @@ type ProgressUpdate struct {
 	Type    string        `json:"type"` // "start", "progress", "warning", "error", "complete"
 	Current int           `json:"current,omitempty"`
 	Total   int           `json:"total,omitempty"`
+	Phase   string        `json:"phase,omitempty"`   // "progress" only, see ProgressItem
+	ItemID  string        `json:"item_id,omitempty"` // "progress" only, Notion ID of the item
 	Message string        `json:"message,omitempty"`
 	Stats   *ExtractStats `json:"stats,omitempty"`
 }
 
+// progressUpdate returns the "progress" update of item.
+func progressUpdate(current, total int, item ProgressItem) ProgressUpdate {
+	return ProgressUpdate{Type: "progress", Current: current, Total: total, Phase: item.Phase, ItemID: item.ID, Message: item.Message}
+}
+
 // ChannelProgress sends progress updates via a channel.
 type ChannelProgress struct {
 	Updates chan<- ProgressUpdate
@@ -94,8 +128,8 @@ func (p *ChannelProgress) OnStart(total int) {
 }
 
 // OnProgress is called for each item processed.
-func (p *ChannelProgress) OnProgress(current int, item string) {
-	p.Updates <- ProgressUpdate{Type: "progress", Current: current, Total: p.total, Message: item}
+func (p *ChannelProgress) OnProgress(current int, item ProgressItem) {
+	p.Updates <- progressUpdate(current, p.total, item)
 }
 
 // OnWarning is called for non-fatal issues.
@@ -113,6 +147,47 @@ func (p *ChannelProgress) OnComplete(stats ExtractStats) {
 	p.Updates <- ProgressUpdate{Type: "complete", Stats: &stats}
 }
 
+// JSONProgress writes each update to Out as one line of JSON, for consumers
+// that parse progress rather than display it.
+type JSONProgress struct {
+	Out   io.Writer
+	total int
+}
+
+// OnStart is called when extraction begins.
+func (p *JSONProgress) OnStart(total int) {
+	p.total = total
+	p.write(ProgressUpdate{Type: "start", Total: total})
+}
+
+// OnProgress is called for each item processed.
+func (p *JSONProgress) OnProgress(current int, item ProgressItem) {
+	p.write(progressUpdate(current, p.total, item))
+}
+
+// OnWarning is called for non-fatal issues.
+func (p *JSONProgress) OnWarning(msg string) {
+	p.write(ProgressUpdate{Type: "warning", Message: msg})
+}
+
+// OnError is called for errors during extraction.
+func (p *JSONProgress) OnError(err error) {
+	p.write(ProgressUpdate{Type: "error", Message: err.Error()})
+}
+
+// OnComplete is called when extraction finishes.
+func (p *JSONProgress) OnComplete(stats ExtractStats) {
+	p.write(ProgressUpdate{Type: "complete", Stats: &stats})
+}
+
+func (p *JSONProgress) write(u ProgressUpdate) {
+	b, err := json.Marshal(u)
+	if err != nil {
+		return
+	}
+	_, _ = p.Out.Write(append(b, '\n'))
+}
+
 // NullProgress discards all progress updates.
 type NullProgress struct{}
 
@@ -120,7 +195,7 @@ type NullProgress struct{}
 func (p *NullProgress) OnStart(total int) {}
 
 // OnProgress is called for each item processed.
-func (p *NullProgress) OnProgress(current int, item string) {}
+func (p *NullProgress) OnProgress(current int, item ProgressItem) {}
 
 // OnWarning is called for non-fatal issues.
 func (p *NullProgress) OnWarning(msg string) {}
@@ -0,0 +1,72 @@
+package notion
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+// The extractor accepts every implementation.
+var (
+	_ ProgressReporter = (*CLIProgress)(nil)
+	_ ProgressReporter = (*ChannelProgress)(nil)
+	_ ProgressReporter = (*JSONProgress)(nil)
+	_ ProgressReporter = (*NullProgress)(nil)
+)
+
+func TestJSONProgress(t *testing.T) {
+	const n = 2
+	client := NewClientWithOptions("tok", ClientOptions{HTTPClient: &http.Client{Transport: &slowTransport{n: n}}})
+	var out bytes.Buffer
+	stats, err := NewExtractor(client, NewWriter(t.TempDir(), "ws"), &JSONProgress{Out: &out}).Extract(t.Context(), ExtractOptions{IncludeContent: true, Concurrency: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var updates []ProgressUpdate
+	for s := bufio.NewScanner(&out); s.Scan(); {
+		var u ProgressUpdate
+		dec := json.NewDecoder(bytes.NewReader(s.Bytes()))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&u); err != nil {
+			t.Fatalf("invalid line %q: %v", s.Text(), err)
+		}
+		updates = append(updates, u)
+	}
+	if len(updates) < n+2 {
+		t.Fatalf("got %d updates, want at least %d: %+v", len(updates), n+2, updates)
+	}
+	var started bool
+	current := 0
+	for _, u := range updates[:len(updates)-1] {
+		switch u.Type {
+		case "start":
+			started = true
+			if u.Total != n {
+				t.Errorf("start total = %d, want %d", u.Total, n)
+			}
+		case "progress":
+			if u.Current == 0 {
+				if u.Phase != PhaseSetup {
+					t.Errorf("informational progress %+v, want phase %q", u, PhaseSetup)
+				}
+				continue // informational, before the start
+			}
+			current++
+			if !started || u.Current != current || u.Total != n || u.Message == "" || u.Phase != PhasePages || u.ItemID == "" {
+				t.Errorf("progress %+v, want current %d of %d after start", u, current, n)
+			}
+		default:
+			t.Errorf("unexpected update %+v", u)
+		}
+	}
+	if current != n {
+		t.Errorf("got %d progress updates, want %d", current, n)
+	}
+	last := updates[len(updates)-1]
+	if last.Type != "complete" || last.Stats == nil || last.Stats.Pages != stats.Pages || last.Stats.Pages != n {
+		t.Errorf("last update = %+v, want complete with %d pages", last, n)
+	}
+}
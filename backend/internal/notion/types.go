@@ -35,6 +35,7 @@ type SearchResult struct {
 	CreatedTime    time.Time `json:"created_time"`
 	LastEditedTime time.Time `json:"last_edited_time"`
 	Parent         Parent    `json:"parent"`
+	Archived       bool      `json:"archived"`
 
 	// For pages: properties contains PropertyValue
 	// For databases: properties contains DBProperty (schema definitions)
@@ -69,7 +70,7 @@ type Database struct {
 	Archived       bool                  `json:"archived"`
 	IsInline       bool                  `json:"is_inline"`
 	Icon           *Icon                 `json:"icon,omitempty"`
-	Cover          *File                 `json:"cover,omitempty"`
+	Cover          *Cover                `json:"cover,omitempty"`
 }
 
 // DBProperty represents a property definition in a database schema.
@@ -185,7 +186,7 @@ type Page struct {
 	Properties     map[string]PropertyValue `json:"properties"`
 	URL            string                   `json:"url"`
 	Icon           *Icon                    `json:"icon,omitempty"`
-	Cover          *File                    `json:"cover,omitempty"`
+	Cover          *Cover                   `json:"cover,omitempty"`
 }
 
 // Icon represents a page or database icon.
@@ -196,6 +197,13 @@ type Icon struct {
 	File     *File  `json:"file,omitempty"`
 }
 
+// Cover represents a page or database cover image.
+type Cover struct {
+	Type     string `json:"type"` // "external", "file"
+	External *File  `json:"external,omitempty"`
+	File     *File  `json:"file,omitempty"`
+}
+
 // PropertyValue represents a property value on a page.
 type PropertyValue struct {
 	ID   string `json:"id"`
@@ -3,10 +3,16 @@
 package notion
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io/fs"
+	"maps"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 	"sync"
 
 	"github.com/maruel/ksid"
@@ -15,20 +21,35 @@ import (
 	"github.com/maruel/mddb/backend/internal/storage/content"
 )
 
+// WriterOptions configures a Writer.
+type WriterOptions struct {
+	// IDMapPath is the JSONL file persisting the Notion ID to mddb ID mapping
+	// between runs. "" means notion_id_mapping.jsonl in the workspace.
+	IDMapPath string
+}
+
 // Writer writes extracted data to mddb storage format.
 type Writer struct {
 	OutputDir   string
 	WorkspaceID string
 
+	idMapPath string
+
 	mu     sync.Mutex
 	tables map[ksid.ID]*jsonldb.Table[*content.DataRecord]
 }
 
 // NewWriter creates a new writer for the given output directory and workspace.
 func NewWriter(outputDir, workspaceID string) *Writer {
+	return NewWriterWithOptions(outputDir, workspaceID, WriterOptions{})
+}
+
+// NewWriterWithOptions creates a new writer configured by opts.
+func NewWriterWithOptions(outputDir, workspaceID string, opts WriterOptions) *Writer {
 	return &Writer{
 		OutputDir:   outputDir,
 		WorkspaceID: workspaceID,
+		idMapPath:   opts.IDMapPath,
 		tables:      make(map[ksid.ID]*jsonldb.Table[*content.DataRecord]),
 	}
 }
@@ -57,12 +78,12 @@ func (w *Writer) WriteNode(node *content.Node, markdownContent string) error {
 
 	// Write index.md for documents/hybrids
 	if node.Type == content.NodeTypeDocument || node.Type == content.NodeTypeHybrid {
-		if err := w.writeMarkdown(nodeDir, node.Title, markdownContent); err != nil {
+		if err := w.writeMarkdown(nodeDir, node, markdownContent); err != nil {
 			return err
 		}
 	}
 
-	// Write metadata.json for tables/hybrids (views only, properties go in data.jsonl)
+	// Write metadata.json for tables/hybrids so the schema is known before records
 	if node.Type == content.NodeTypeTable || node.Type == content.NodeTypeHybrid {
 		if err := w.writeMetadata(nodeDir, node); err != nil {
 			return err
@@ -73,25 +94,37 @@ func (w *Writer) WriteNode(node *content.Node, markdownContent string) error {
 }
 
 // writeMarkdown writes the index.md file with front matter.
-func (w *Writer) writeMarkdown(nodeDir, title, mdContent string) error {
+func (w *Writer) writeMarkdown(nodeDir string, node *content.Node, mdContent string) error {
 	path := filepath.Join(nodeDir, "index.md")
 
 	// Create markdown with YAML front matter
-	md := fmt.Sprintf("---\ntitle: %q\n---\n\n%s", title, mdContent)
+	var fm strings.Builder
+	fmt.Fprintf(&fm, "title: %q\n", node.Title)
+	if node.Icon != "" {
+		fmt.Fprintf(&fm, "icon: %s\n", node.Icon)
+	}
+	if node.Cover != "" {
+		fmt.Fprintf(&fm, "cover: %s\n", node.Cover)
+	}
+	md := fmt.Sprintf("---\n%s---\n\n%s", fm.String(), mdContent)
 
 	return os.WriteFile(path, []byte(md), 0o644) //nolint:gosec // G306: 0o644 is intentional for readable files
 }
 
-// writeMetadata writes the metadata.json file (views only, properties go in data.jsonl).
+// writeMetadata writes the metadata.json file with the table title, schema
+// and views, in the format read by the content store.
 func (w *Writer) writeMetadata(nodeDir string, node *content.Node) error {
-	// Only write metadata.json if there are views
-	if len(node.Views) == 0 {
-		return nil
-	}
-
 	path := filepath.Join(nodeDir, "metadata.json")
 	meta := TableMetadata{
-		Views: node.Views,
+		Title:      node.Title,
+		Version:    "1.0",
+		Created:    node.Created,
+		Modified:   node.Modified,
+		Properties: node.Properties,
+		Views:      node.Views,
+	}
+	if meta.Properties == nil {
+		meta.Properties = []content.Property{}
 	}
 
 	data, err := json.MarshalIndent(meta, "", "  ")
@@ -104,7 +137,12 @@ func (w *Writer) writeMetadata(nodeDir string, node *content.Node) error {
 
 // TableMetadata is the structure stored in metadata.json.
 type TableMetadata struct {
-	Views []content.View `json:"views,omitempty"`
+	Title      string             `json:"title"`
+	Version    string             `json:"version"`
+	Created    storage.Time       `json:"created"`
+	Modified   storage.Time       `json:"modified"`
+	Properties []content.Property `json:"properties"`
+	Views      []content.View     `json:"views,omitempty"`
 }
 
 // NodeEntry is a manifest entry for a node (stored in nodes.jsonl).
@@ -168,6 +206,73 @@ func (w *Writer) WriteNodeEntry(node *content.Node) (rerr error) {
 	return nil
 }
 
+// CompactNodesManifest rewrites nodes.jsonl keeping only the last entry of
+// each node, as an incremental sync appends the entries of the nodes it
+// updates.
+func (w *Writer) CompactNodesManifest() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.rewriteNodesManifestLocked(0)
+}
+
+// rewriteNodesManifestLocked rewrites nodes.jsonl keeping only the last entry
+// of each node, in order, and dropping the entry of the node drop if not 0.
+func (w *Writer) rewriteNodesManifestLocked(drop ksid.ID) error {
+	path := filepath.Join(w.workspacePath(), "nodes.jsonl")
+	data, err := os.ReadFile(path) //nolint:gosec // G304: path is constructed from validated input
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to read nodes.jsonl: %w", err)
+	}
+	var lines [][]byte
+	var ids []ksid.ID
+	last := make(map[ksid.ID]int)
+	for line := range bytes.Lines(data) {
+		var entry NodeEntry
+		if err := json.Unmarshal(line, &entry); err != nil {
+			return fmt.Errorf("failed to parse nodes.jsonl: %w", err)
+		}
+		last[entry.ID] = len(lines)
+		lines = append(lines, line)
+		ids = append(ids, entry.ID)
+	}
+	var out []byte
+	for i, line := range lines {
+		if ids[i] != drop && last[ids[i]] == i {
+			out = append(out, line...)
+		}
+	}
+	return os.WriteFile(path, out, 0o644) //nolint:gosec // G306: 0o644 is intentional for readable files
+}
+
+// TrashNode moves a node's directory to the workspace trash, where the
+// content store lists it and can restore it, and removes its entry from
+// nodes.jsonl. Returns false if the node was never written.
+func (w *Writer) TrashNode(nodeID ksid.ID) (bool, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if _, err := os.Stat(w.nodePath(nodeID)); err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	delete(w.tables, nodeID)
+
+	// Nodes are written flat in the workspace, so they are restored at the
+	// root.
+	if _, err := content.MoveToTrash(w.workspacePath(), nodeID.String(), nodeID, 0); err != nil {
+		return false, err
+	}
+	if err := w.rewriteNodesManifestLocked(nodeID); err != nil {
+		return true, err
+	}
+	return true, nil
+}
+
 // ClearNodeData removes the data.jsonl file for a node to prepare for re-import.
 func (w *Writer) ClearNodeData(nodeID ksid.ID) error {
 	w.mu.Lock()
@@ -213,15 +318,8 @@ func (w *Writer) WriteRecords(nodeID ksid.ID, properties []content.Property, rec
 		return err
 	}
 
-	// Store properties in the table header
-	if len(properties) > 0 {
-		propsJSON, err := json.Marshal(properties)
-		if err != nil {
-			return fmt.Errorf("failed to marshal properties: %w", err)
-		}
-		if err := table.SetProperties(propsJSON); err != nil {
-			return fmt.Errorf("failed to set properties: %w", err)
-		}
+	if err := setTableProperties(table, properties); err != nil {
+		return err
 	}
 
 	for _, record := range records {
@@ -233,6 +331,59 @@ func (w *Writer) WriteRecords(nodeID ksid.ID, properties []content.Property, rec
 	return nil
 }
 
+// MergeRecords updates a table's data.jsonl file in place for an incremental
+// sync: records already present are replaced, new ones are appended and the
+// records whose IDs are in deleted are removed. Returns the number of records
+// left untouched and the number actually removed.
+func (w *Writer) MergeRecords(nodeID ksid.ID, properties []content.Property, records []*content.DataRecord, deleted []ksid.ID) (unchanged, removed int, err error) {
+	table, err := w.getTable(nodeID)
+	if err != nil {
+		return 0, 0, err
+	}
+	if err := setTableProperties(table, properties); err != nil {
+		return 0, 0, err
+	}
+
+	for _, record := range records {
+		prev, err := table.Update(record)
+		if err != nil {
+			return 0, 0, fmt.Errorf("failed to update record: %w", err)
+		}
+		if prev == nil {
+			if err := table.Append(record); err != nil {
+				return 0, 0, fmt.Errorf("failed to append record: %w", err)
+			}
+		}
+	}
+	for _, id := range deleted {
+		prev, err := table.Delete(id)
+		if err != nil {
+			return 0, 0, fmt.Errorf("failed to delete record: %w", err)
+		}
+		if prev != nil {
+			removed++
+		}
+	}
+
+	return table.Len() - len(records), removed, nil
+}
+
+// setTableProperties stores properties in the table header for schema-aware
+// deserialization.
+func setTableProperties(table *jsonldb.Table[*content.DataRecord], properties []content.Property) error {
+	if len(properties) == 0 {
+		return nil
+	}
+	propsJSON, err := json.Marshal(properties)
+	if err != nil {
+		return fmt.Errorf("failed to marshal properties: %w", err)
+	}
+	if err := table.SetProperties(propsJSON); err != nil {
+		return fmt.Errorf("failed to set properties: %w", err)
+	}
+	return nil
+}
+
 // AppendRecord appends a single record to a table's data.jsonl file.
 func (w *Writer) AppendRecord(nodeID ksid.ID, record *content.DataRecord) error {
 	table, err := w.getTable(nodeID)
@@ -247,21 +398,71 @@ func (w *Writer) AppendRecord(nodeID ksid.ID, record *content.DataRecord) error
 	return nil
 }
 
-// IDMapping stores the Notion ID to mddb ID mapping for incremental imports.
-type IDMapping struct {
+// IDMapEntry is a row of the ID mapping file, recording the mddb ID assigned
+// to a Notion page, database or row so that re-imports update it in place.
+type IDMapEntry struct {
+	ID       ksid.ID `json:"id" jsonschema:"description=mddb node or record ID"`
+	NotionID string  `json:"notion_id" jsonschema:"description=Notion UUID"`
+}
+
+// Clone returns a copy of the entry.
+func (e *IDMapEntry) Clone() *IDMapEntry {
+	c := *e
+	return &c
+}
+
+// GetID returns the mddb ID.
+func (e *IDMapEntry) GetID() ksid.ID {
+	return e.ID
+}
+
+// Validate checks that the entry is valid.
+func (e *IDMapEntry) Validate() error {
+	if e.ID.IsZero() {
+		return errors.New("id is required")
+	}
+	if e.NotionID == "" {
+		return errors.New("notion_id is required")
+	}
+	return nil
+}
+
+// legacyIDMapping is the JSON format of the ID mapping used before it moved
+// to JSONL. It is still read when no JSONL mapping exists.
+type legacyIDMapping struct {
 	Version int                `json:"version"`
 	IDs     map[string]ksid.ID `json:"ids"` // Notion ID -> mddb ID
 }
 
 // idMappingPath returns the path to the ID mapping file.
 func (w *Writer) idMappingPath() string {
-	return filepath.Join(w.workspacePath(), "notion_id_mapping.json")
+	if w.idMapPath != "" {
+		return w.idMapPath
+	}
+	return filepath.Join(w.workspacePath(), "notion_id_mapping.jsonl")
 }
 
 // LoadIDMapping loads the ID mapping from disk if it exists.
 // Returns an empty map if the file doesn't exist.
 func (w *Writer) LoadIDMapping() (map[string]ksid.ID, error) {
 	path := w.idMappingPath()
+	if _, err := os.Stat(path); errors.Is(err, fs.ErrNotExist) && w.idMapPath == "" {
+		return w.loadLegacyIDMapping()
+	}
+	table, err := jsonldb.OpenTableReadOnly[*IDMapEntry](path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read ID mapping: %w", err)
+	}
+	ids := make(map[string]ksid.ID, table.Len())
+	for e := range table.Iter(0) {
+		ids[e.NotionID] = e.ID
+	}
+	return ids, nil
+}
+
+// loadLegacyIDMapping loads notion_id_mapping.json from the workspace.
+func (w *Writer) loadLegacyIDMapping() (map[string]ksid.ID, error) {
+	path := filepath.Join(w.workspacePath(), "notion_id_mapping.json")
 	data, err := os.ReadFile(path) //nolint:gosec // G304: path is constructed from validated input
 	if err != nil {
 		if os.IsNotExist(err) {
@@ -270,30 +471,39 @@ func (w *Writer) LoadIDMapping() (map[string]ksid.ID, error) {
 		return nil, fmt.Errorf("failed to read ID mapping: %w", err)
 	}
 
-	var mapping IDMapping
+	var mapping legacyIDMapping
 	if err := json.Unmarshal(data, &mapping); err != nil {
 		return nil, fmt.Errorf("failed to parse ID mapping: %w", err)
 	}
-
+	if mapping.IDs == nil {
+		mapping.IDs = make(map[string]ksid.ID)
+	}
 	return mapping.IDs, nil
 }
 
-// SaveIDMapping saves the ID mapping to disk.
+// SaveIDMapping saves the ID mapping to disk, adding the entries that are
+// not recorded yet.
 func (w *Writer) SaveIDMapping(ids map[string]ksid.ID) error {
-	mapping := IDMapping{
-		Version: 1,
-		IDs:     ids,
+	path := w.idMappingPath()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil { //nolint:gosec // G301: 0o755 is intentional for data directories
+		return fmt.Errorf("failed to create ID mapping directory: %w", err)
 	}
-
-	data, err := json.MarshalIndent(mapping, "", "  ")
+	table, err := jsonldb.NewTable[*IDMapEntry](path)
 	if err != nil {
-		return fmt.Errorf("failed to marshal ID mapping: %w", err)
+		return fmt.Errorf("failed to open ID mapping: %w", err)
 	}
-
-	path := w.idMappingPath()
-	if err := os.WriteFile(path, data, 0o644); err != nil { //nolint:gosec // G306: 0o644 is intentional
-		return fmt.Errorf("failed to write ID mapping: %w", err)
+	notionIDs := slices.Sorted(maps.Keys(ids))
+	for _, notionID := range notionIDs {
+		entry := &IDMapEntry{ID: ids[notionID], NotionID: notionID}
+		switch existing := table.Get(entry.ID); {
+		case existing == nil:
+			err = table.Append(entry)
+		case existing.NotionID != notionID:
+			_, err = table.Update(entry)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to write ID mapping: %w", err)
+		}
 	}
-
 	return nil
 }
@@ -0,0 +1,156 @@
+package notion
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/maruel/ksid"
+)
+
+// fixtureTransport serves a tiny Notion workspace: one database with one row
+// and one standalone page with a paragraph.
+type fixtureTransport struct{}
+
+func (fixtureTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	title := func(s string) string {
+		return `[{"type":"text","text":{"content":"` + s + `"},"plain_text":"` + s + `"}]`
+	}
+	page := `{"object":"page","id":"page-1","parent":{"type":"workspace","workspace":true},` +
+		`"properties":{"title":{"type":"title","title":` + title("Notes") + `}}}`
+	db := `{"object":"database","id":"db-1","parent":{"type":"workspace","workspace":true},"title":` + title("Tasks") + `,` +
+		`"properties":{"Name":{"id":"title","name":"Name","type":"title","title":{}}}}`
+	row := `{"object":"page","id":"row-1","parent":{"type":"database_id","database_id":"db-1"},` +
+		`"properties":{"Name":{"type":"title","title":` + title("Write tests") + `}}}`
+
+	var body string
+	switch p := req.URL.Path; {
+	case strings.HasSuffix(p, "/search"):
+		b, _ := io.ReadAll(req.Body)
+		if bytes.Contains(b, []byte(`"database"`)) {
+			body = `{"results":[` + db + `],"has_more":false}`
+		} else {
+			body = `{"results":[` + page + `,` + row + `],"has_more":false}`
+		}
+	case strings.HasSuffix(p, "/databases/db-1/query"):
+		body = `{"results":[` + row + `],"has_more":false}`
+	case strings.HasSuffix(p, "/databases/db-1"):
+		body = db
+	case strings.HasSuffix(p, "/pages/page-1"):
+		body = page
+	case strings.HasSuffix(p, "/blocks/page-1/children"):
+		body = `{"results":[{"object":"block","id":"b-1","type":"paragraph","paragraph":{"rich_text":` + title("Hello") + `}}],"has_more":false}`
+	default:
+		return &http.Response{StatusCode: http.StatusNotFound, Body: io.NopCloser(strings.NewReader(`{"object":"error","status":404,"code":"object_not_found","message":"` + p + `"}`)), Request: req}, nil
+	}
+	return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(body)), Request: req}, nil
+}
+
+func TestWriter_Reimport(t *testing.T) {
+	dir := t.TempDir()
+	idMapPath := filepath.Join(t.TempDir(), "ids.jsonl")
+
+	run := func() {
+		client := NewClientWithOptions("tok", ClientOptions{HTTPClient: &http.Client{Transport: fixtureTransport{}}})
+		writer := NewWriterWithOptions(dir, "ws", WriterOptions{IDMapPath: idMapPath})
+		stats, err := NewExtractor(client, writer, &NullProgress{}).Extract(t.Context(), ExtractOptions{IncludeContent: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if stats.Pages != 1 || stats.Databases != 1 || stats.Records != 1 || stats.Errors != 0 {
+			t.Fatalf("unexpected stats: %+v", stats)
+		}
+	}
+	nodeDirs := func() []string {
+		entries, err := os.ReadDir(filepath.Join(dir, "ws"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var dirs []string
+		for _, e := range entries {
+			if e.IsDir() && e.Name() != "assets" {
+				dirs = append(dirs, e.Name())
+			}
+		}
+		return dirs
+	}
+
+	run()
+	first := nodeDirs()
+	if len(first) != 2 {
+		t.Fatalf("want 2 nodes after first import, got %v", first)
+	}
+	ids, err := NewWriterWithOptions(dir, "ws", WriterOptions{IDMapPath: idMapPath}).LoadIDMapping()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, notionID := range []string{"db-1", "row-1", "page-1"} {
+		if ids[notionID].IsZero() {
+			t.Errorf("%s missing from ID mapping: %v", notionID, ids)
+		}
+	}
+
+	run()
+	second := nodeDirs()
+	if strings.Join(first, ",") != strings.Join(second, ",") {
+		t.Errorf("nodes changed on re-import: %v -> %v", first, second)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "ws", ids["db-1"].String(), "data.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(string(data), ids["row-1"].String()); n != 1 {
+		t.Errorf("row written %d times after re-import:\n%s", n, data)
+	}
+	manifest, err := os.ReadFile(filepath.Join(dir, "ws", "nodes.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(string(manifest), `"id"`); n != 2 {
+		t.Errorf("want 2 manifest entries, got %d:\n%s", n, manifest)
+	}
+}
+
+func TestWriter_LegacyIDMapping(t *testing.T) {
+	dir := t.TempDir()
+	w := NewWriter(dir, "ws")
+	if err := w.EnsureWorkspace(); err != nil {
+		t.Fatal(err)
+	}
+	id := ksid.NewID()
+	legacy, err := json.Marshal(legacyIDMapping{Version: 1, IDs: map[string]ksid.ID{"page-1": id}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "ws", "notion_id_mapping.json"), legacy, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	ids, err := w.LoadIDMapping()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ids["page-1"] != id {
+		t.Fatalf("legacy mapping not loaded: %v", ids)
+	}
+
+	// Saving moves the mapping to JSONL, which wins from then on.
+	ids["page-2"] = ksid.NewID()
+	if err := w.SaveIDMapping(ids); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "ws", "notion_id_mapping.jsonl")); err != nil {
+		t.Fatal(err)
+	}
+	got, err := w.LoadIDMapping()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got["page-1"] != id || got["page-2"] != ids["page-2"] {
+		t.Errorf("round trip: got %v, want %v", got, ids)
+	}
+}
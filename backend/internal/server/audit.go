@@ -0,0 +1,118 @@
+// Records mutating requests to authenticated endpoints in the audit log.
+
+package server
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/maruel/ksid"
+	"github.com/maruel/mddb/backend/internal/server/handlers"
+	"github.com/maruel/mddb/backend/internal/storage/identity"
+)
+
+// auditKey is the context key of the *auditActor of a request.
+type auditKey struct{}
+
+// auditActor is filled in by the auth wrappers once the user is authenticated.
+type auditActor struct {
+	userID   ksid.ID
+	recorded bool // The entry was already written by recordAudit.
+}
+
+// setAuditActor records user as the actor of the audited request in ctx, if any.
+func setAuditActor(ctx context.Context, user *identity.User) {
+	if a, ok := ctx.Value(auditKey{}).(*auditActor); ok {
+		a.userID = user.ID
+	}
+}
+
+// auditMiddleware records every mutating request served by next by an
+// authenticated user in the audit log, including the ones rejected for missing
+// permissions or invalid input. Requests failing authentication are not
+// recorded: anyone can send them, so they would grow the log without bound.
+//
+// It wraps the auth wrappers, which report the authenticated user through the
+// request context. The wrappers call recordAudit before committing the DB so
+// the entry of a request that reached its handler is part of the request's
+// commit; the middleware records the requests rejected earlier.
+func auditMiddleware(svc *handlers.Services, next http.Handler) http.Handler {
+	if svc.Audit == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !isMutating(r.Method) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+		r = r.WithContext(context.WithValue(r.Context(), auditKey{}, &auditActor{}))
+		next.ServeHTTP(sw, r)
+		recordAudit(r.Context(), svc, r, sw.status)
+	})
+}
+
+// recordAudit records the mutating request r, answered with status, in the
+// audit log, once per request. It does nothing for requests not going through
+// auditMiddleware or whose user is not authenticated.
+func recordAudit(ctx context.Context, svc *handlers.Services, r *http.Request, status int) {
+	actor, ok := ctx.Value(auditKey{}).(*auditActor)
+	if !ok || actor.recorded || actor.userID.IsZero() {
+		return
+	}
+	actor.recorded = true
+	e := &identity.AuditEntry{
+		ActorID:  actor.userID,
+		Action:   r.Pattern,
+		TargetID: auditTarget(r),
+		Status:   status,
+	}
+	if e.Action == "" {
+		e.Action = r.Method + " " + r.URL.Path
+	}
+	if id, err := ksid.Parse(r.PathValue("orgID")); err == nil {
+		e.OrganizationID = id
+	}
+	if id, err := ksid.Parse(r.PathValue("wsID")); err == nil {
+		e.WorkspaceID = id
+		if ws, err := svc.Workspace.Get(id); err == nil {
+			e.OrganizationID = ws.OrganizationID
+		}
+	}
+	if err := svc.Audit.Record(e); err != nil {
+		slog.ErrorContext(ctx, "Failed to record audit entry", "err", err, "action", e.Action)
+	}
+}
+
+// auditTarget returns the value of the last path parameter of the route that
+// matched r, e.g. the record ID of a record update.
+func auditTarget(r *http.Request) string {
+	target := ""
+	for seg := range strings.SplitSeq(r.Pattern, "/") {
+		if name, ok := strings.CutPrefix(seg, "{"); ok {
+			name = strings.TrimSuffix(strings.TrimSuffix(name, "}"), "...")
+			if v := r.PathValue(name); v != "" {
+				target = v
+			}
+		}
+	}
+	return target
+}
+
+// statusWriter captures the status code of a response.
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (sw *statusWriter) WriteHeader(code int) {
+	sw.status = code
+	sw.ResponseWriter.WriteHeader(code)
+}
+
+// Unwrap returns the underlying ResponseWriter for http.ResponseController.
+func (sw *statusWriter) Unwrap() http.ResponseWriter {
+	return sw.ResponseWriter
+}
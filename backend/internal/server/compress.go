@@ -3,7 +3,7 @@
 // Compresses responses using zstd, brotli, or gzip at fast compression
 // levels. SSE streams are compressed with per-event flushing to preserve
 // real-time delivery. Skips responses that already have a Content-Encoding
-// (precompressed static files).
+// (precompressed static files) and partial content (Range responses).
 
 package server
 
@@ -84,6 +84,12 @@ func (cw *compressWriter) initOnce() {
 		return
 	}
 
+	// Skip partial content: Content-Range offsets refer to the uncompressed body.
+	if h.Get("Content-Range") != "" {
+		cw.skipCompress = true
+		return
+	}
+
 	// Compressed size differs from original; remove Content-Length.
 	h.Del("Content-Length")
 	h.Set("Content-Encoding", cw.encoding)
@@ -0,0 +1,74 @@
+// Cross-Origin Resource Sharing middleware for API endpoints.
+//
+// Disabled unless ServerConfig.CORS lists allowed origins, so the default is
+// same-origin only. Only /api/ routes get CORS headers; disallowed origins are
+// passed through without them so the browser blocks the response.
+
+package server
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/maruel/mddb/backend/internal/storage"
+)
+
+var (
+	defaultCORSMethods = []string{http.MethodGet, http.MethodPost, http.MethodDelete}
+	defaultCORSHeaders = []string{"Authorization", "Content-Type"}
+)
+
+// corsMiddleware returns a handler that applies cfg to /api/ requests.
+func corsMiddleware(cfg storage.CORSConfig, next http.Handler) http.Handler {
+	if len(cfg.AllowedOrigins) == 0 {
+		return next
+	}
+	wildcard := slices.Contains(cfg.AllowedOrigins, "*")
+	methods := cfg.AllowedMethods
+	if len(methods) == 0 {
+		methods = defaultCORSMethods
+	}
+	headers := cfg.AllowedHeaders
+	if len(headers) == 0 {
+		headers = defaultCORSHeaders
+	}
+	allowMethods := strings.Join(methods, ", ")
+	allowHeaders := strings.Join(headers, ", ")
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.URL.Path, "/api/") {
+			next.ServeHTTP(w, r)
+			return
+		}
+		h := w.Header()
+		h.Add("Vary", "Origin")
+		origin := r.Header.Get("Origin")
+		if origin == "" || (!wildcard && !slices.Contains(cfg.AllowedOrigins, origin)) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if wildcard {
+			h.Set("Access-Control-Allow-Origin", "*")
+		} else {
+			h.Set("Access-Control-Allow-Origin", origin)
+		}
+		if cfg.AllowCredentials {
+			h.Set("Access-Control-Allow-Credentials", "true")
+		}
+		if r.Method != http.MethodOptions || r.Header.Get("Access-Control-Request-Method") == "" {
+			h.Set("Access-Control-Expose-Headers", "X-RateLimit-Limit, X-RateLimit-Remaining, X-RateLimit-Reset, Retry-After")
+			next.ServeHTTP(w, r)
+			return
+		}
+		// Preflight: answer directly, the mux has no OPTIONS routes.
+		h.Add("Vary", "Access-Control-Request-Method")
+		h.Add("Vary", "Access-Control-Request-Headers")
+		h.Set("Access-Control-Allow-Methods", allowMethods)
+		h.Set("Access-Control-Allow-Headers", allowHeaders)
+		if cfg.MaxAgeSeconds > 0 {
+			h.Set("Access-Control-Max-Age", strconv.Itoa(cfg.MaxAgeSeconds))
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
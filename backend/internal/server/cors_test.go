@@ -0,0 +1,107 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/maruel/mddb/backend/internal/storage"
+)
+
+func TestCORSMiddleware(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	do := func(h http.Handler, method, path, origin string, preflight bool) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, http.NoBody)
+		if origin != "" {
+			req.Header.Set("Origin", origin)
+		}
+		if preflight {
+			req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("disabled by default", func(t *testing.T) {
+		h := corsMiddleware(storage.CORSConfig{}, ok)
+		w := do(h, http.MethodGet, "/api/v1/health", "https://evil.example", false)
+		if got := w.Header().Get("Access-Control-Allow-Origin"); got != "" {
+			t.Errorf("Access-Control-Allow-Origin = %q, want none", got)
+		}
+	})
+
+	t.Run("explicit list", func(t *testing.T) {
+		h := corsMiddleware(storage.CORSConfig{
+			AllowedOrigins:   []string{"https://app.example"},
+			AllowCredentials: true,
+			MaxAgeSeconds:    600,
+		}, ok)
+
+		tests := []struct {
+			name       string
+			method     string
+			path       string
+			origin     string
+			preflight  bool
+			wantOrigin string
+			wantStatus int
+		}{
+			{"allowed", http.MethodGet, "/api/v1/health", "https://app.example", false, "https://app.example", http.StatusOK},
+			{"disallowed", http.MethodGet, "/api/v1/health", "https://evil.example", false, "", http.StatusOK},
+			{"no origin", http.MethodGet, "/api/v1/health", "", false, "", http.StatusOK},
+			{"non api path", http.MethodGet, "/index.html", "https://app.example", false, "", http.StatusOK},
+			{"preflight allowed", http.MethodOptions, "/api/v1/auth/login", "https://app.example", true, "https://app.example", http.StatusNoContent},
+			{"preflight disallowed", http.MethodOptions, "/api/v1/auth/login", "https://evil.example", true, "", http.StatusOK},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				w := do(h, tt.method, tt.path, tt.origin, tt.preflight)
+				if w.Code != tt.wantStatus {
+					t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+				}
+				if got := w.Header().Get("Access-Control-Allow-Origin"); got != tt.wantOrigin {
+					t.Errorf("Access-Control-Allow-Origin = %q, want %q", got, tt.wantOrigin)
+				}
+				wantCreds := ""
+				if tt.wantOrigin != "" {
+					wantCreds = "true"
+				}
+				if got := w.Header().Get("Access-Control-Allow-Credentials"); got != wantCreds {
+					t.Errorf("Access-Control-Allow-Credentials = %q, want %q", got, wantCreds)
+				}
+			})
+		}
+
+		w := do(h, http.MethodOptions, "/api/v1/auth/login", "https://app.example", true)
+		if got := w.Header().Get("Access-Control-Allow-Methods"); got != "GET, POST, DELETE" {
+			t.Errorf("Access-Control-Allow-Methods = %q", got)
+		}
+		if got := w.Header().Get("Access-Control-Allow-Headers"); got != "Authorization, Content-Type" {
+			t.Errorf("Access-Control-Allow-Headers = %q", got)
+		}
+		if got := w.Header().Get("Access-Control-Max-Age"); got != "600" {
+			t.Errorf("Access-Control-Max-Age = %q, want %q", got, "600")
+		}
+	})
+
+	t.Run("wildcard", func(t *testing.T) {
+		h := corsMiddleware(storage.CORSConfig{
+			AllowedOrigins: []string{"*"},
+			AllowedMethods: []string{http.MethodGet},
+		}, ok)
+		w := do(h, http.MethodGet, "/api/v1/health", "https://any.example", false)
+		if got := w.Header().Get("Access-Control-Allow-Origin"); got != "*" {
+			t.Errorf("Access-Control-Allow-Origin = %q, want %q", got, "*")
+		}
+		w = do(h, http.MethodOptions, "/api/v1/health", "https://any.example", true)
+		if w.Code != http.StatusNoContent {
+			t.Errorf("preflight status = %d, want %d", w.Code, http.StatusNoContent)
+		}
+		if got := w.Header().Get("Access-Control-Allow-Methods"); got != "GET" {
+			t.Errorf("Access-Control-Allow-Methods = %q, want %q", got, "GET")
+		}
+	})
+}
@@ -83,6 +83,10 @@ const (
 	ErrorCodeQuotaExceeded ErrorCode = "QUOTA_EXCEEDED"
 	// ErrorCodePayloadTooLarge is returned when the request body exceeds the size limit.
 	ErrorCodePayloadTooLarge ErrorCode = "PAYLOAD_TOO_LARGE"
+	// ErrorCodeInsufficientStorage is returned when the server is low on disk space.
+	ErrorCodeInsufficientStorage ErrorCode = "INSUFFICIENT_STORAGE"
+	// ErrorCodeServiceUnavailable is returned when a server component is not ready.
+	ErrorCodeServiceUnavailable ErrorCode = "SERVICE_UNAVAILABLE"
 )
 
 // ErrorDetails defines the structured error information in a response.
@@ -285,6 +289,27 @@ func QuotaExceededInt64(resource string, limit int64) *APIError {
 		WithDetail("limit", limit)
 }
 
+// Conflict creates a 409 Conflict error.
+func Conflict(message string) *APIError {
+	return NewAPIError(http.StatusConflict, ErrorCodeConflict, message)
+}
+
+// StorageQuotaExceeded creates a 413 error when a write would exceed a
+// storage quota.
+func StorageQuotaExceeded() *APIError {
+	return NewAPIError(http.StatusRequestEntityTooLarge, ErrorCodeQuotaExceeded, "Quota exceeded")
+}
+
+// ServiceUnavailable creates a 503 error when the server cannot serve requests.
+func ServiceUnavailable(message string) *APIError {
+	return NewAPIError(http.StatusServiceUnavailable, ErrorCodeServiceUnavailable, message)
+}
+
+// InsufficientStorage creates a 507 error when the server disk is nearly full.
+func InsufficientStorage() *APIError {
+	return NewAPIError(http.StatusInsufficientStorage, ErrorCodeInsufficientStorage, "Server is out of disk space")
+}
+
 // PayloadTooLarge creates a 413 error for oversized request bodies.
 func PayloadTooLarge(maxBytes int64) *APIError {
 	return NewAPIError(http.StatusRequestEntityTooLarge, ErrorCodePayloadTooLarge,
@@ -8,6 +8,7 @@ import (
 	"unicode"
 
 	"github.com/maruel/ksid"
+	"github.com/maruel/mddb/backend/internal/storage/git"
 )
 
 const (
@@ -46,6 +47,14 @@ func validateEmail(email string) error {
 	return nil
 }
 
+// validateCommitHash checks that hash is a full hexadecimal git object name.
+func validateCommitHash(field, hash string) error {
+	if !git.IsCommitHash(hash) {
+		return InvalidField(field, "must be a full lowercase hexadecimal commit hash")
+	}
+	return nil
+}
+
 // validateFilters validates a slice of filters recursively.
 func validateFilters(filters []Filter) error {
 	seen := make(map[string]struct{})
@@ -229,12 +238,29 @@ func (r *GetPageRequest) Validate() error {
 	return nil
 }
 
+// GetPageHTMLRequest is a request to render a page's content to HTML.
+type GetPageHTMLRequest struct {
+	WsID ksid.ID `path:"wsID" tstype:"-"`
+	ID   ksid.ID `path:"id" tstype:"-"`
+}
+
+// Validate validates the get page HTML request fields.
+func (r *GetPageHTMLRequest) Validate() error {
+	if r.WsID.IsZero() {
+		return MissingField("wsID")
+	}
+	return nil
+}
+
 // UpdatePageRequest is a request to update a page's content.
 type UpdatePageRequest struct {
 	WsID    ksid.ID `path:"wsID" tstype:"-"`
 	ID      ksid.ID `path:"id" tstype:"-"`
 	Title   string  `json:"title"`
 	Content string  `json:"content"`
+	// ExpectedETag is the ETag of the page version the update is based on.
+	// When set and the page changed since, the update fails with 409 Conflict.
+	ExpectedETag string `json:"expected_etag,omitempty"`
 }
 
 // Validate validates the update page request fields.
@@ -303,9 +329,10 @@ func (r *DeleteNodeRequest) Validate() error {
 
 // ListNodeVersionsRequest is a request to list node version history.
 type ListNodeVersionsRequest struct {
-	WsID  ksid.ID `path:"wsID" tstype:"-"`
-	ID    ksid.ID `path:"id" tstype:"-"` // Node ID; 0 = root
-	Limit int     `query:"limit"`        // Max commits to return (1-1000, default 1000).
+	WsID   ksid.ID `path:"wsID" tstype:"-"`
+	ID     ksid.ID `path:"id" tstype:"-"` // Node ID; 0 = root
+	Limit  int     `query:"limit"`        // Max commits to return (1-1000, default 1000).
+	Before string  `query:"before"`       // Return commits older than this hash; empty starts at HEAD.
 }
 
 // Validate validates the list node versions request fields.
@@ -320,6 +347,9 @@ func (r *ListNodeVersionsRequest) Validate() error {
 	if r.Limit == 0 || r.Limit > MaxVersionsLimit {
 		r.Limit = MaxVersionsLimit
 	}
+	if r.Before != "" {
+		return validateCommitHash("before", r.Before)
+	}
 	return nil
 }
 
@@ -761,14 +791,13 @@ func (r *ServeAssetRequest) Validate() error {
 
 // --- Search ---
 
-// SearchRequest is a request to search pages and tables.
+// SearchRequest is a request to search pages.
 type SearchRequest struct {
-	WsID        ksid.ID `path:"wsID" tstype:"-"`
-	Query       string  `json:"query"`
-	Limit       int     `json:"limit,omitempty"`
-	MatchTitle  bool    `json:"match_title,omitempty"`
-	MatchBody   bool    `json:"match_body,omitempty"`
-	MatchFields bool    `json:"match_fields,omitempty"`
+	WsID       ksid.ID `path:"wsID" tstype:"-"`
+	Query      string  `json:"query"`
+	Limit      int     `json:"limit,omitempty"`
+	MatchTitle bool    `json:"match_title,omitempty"` // Search in titles
+	MatchBody  bool    `json:"match_body,omitempty"`  // Search in page bodies; both when neither is set
 }
 
 // Validate validates the search request fields.
@@ -836,6 +865,25 @@ func (r *CreateWSInvitationRequest) Validate() error {
 	return nil
 }
 
+// ResendOrgInvitationRequest is a request to extend an organization invitation
+// and send its email again.
+type ResendOrgInvitationRequest struct {
+	OrgID        ksid.ID `path:"orgID" tstype:"-"`
+	InvitationID ksid.ID `json:"invitation_id"`
+	Locale       string  `json:"locale,omitempty"` // Optional: language for invitation email (en, fr, de, es)
+}
+
+// Validate validates the resend organization invitation request fields.
+func (r *ResendOrgInvitationRequest) Validate() error {
+	if r.OrgID.IsZero() {
+		return MissingField("orgID")
+	}
+	if r.InvitationID.IsZero() {
+		return MissingField("invitation_id")
+	}
+	return nil
+}
+
 // ListOrgInvitationsRequest is a request to list invitations for an organization.
 type ListOrgInvitationsRequest struct {
 	OrgID ksid.ID `path:"orgID" tstype:"-"`
@@ -1041,6 +1089,13 @@ func (r *UpdateWorkspaceRequest) Validate() error {
 			return err
 		}
 	}
+	if r.Settings != nil {
+		switch r.Settings.LinkStyle {
+		case "", LinkStyleRelative, LinkStyleWikilink:
+		default:
+			return InvalidField("settings.link_style", "must be relative or wikilink")
+		}
+	}
 
 	return nil
 }
@@ -1287,6 +1342,14 @@ func (r *HealthRequest) Validate() error {
 	return nil
 }
 
+// ReadinessRequest is a request to check that the server components are ready.
+type ReadinessRequest struct{}
+
+// Validate is a no-op for ReadinessRequest.
+func (r *ReadinessRequest) Validate() error {
+	return nil
+}
+
 // --- OAuth Providers ---
 
 // ProvidersRequest is a request to list configured OAuth providers.
@@ -1299,6 +1362,19 @@ func (r *ProvidersRequest) Validate() error {
 
 // --- Sessions ---
 
+// RefreshTokenRequest is a request to exchange a refresh token for a new JWT.
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// Validate validates the refresh token request fields.
+func (r *RefreshTokenRequest) Validate() error {
+	if r.RefreshToken == "" {
+		return MissingField("refresh_token")
+	}
+	return nil
+}
+
 // LogoutRequest is a request to logout (revoke current session).
 type LogoutRequest struct{}
 
@@ -1460,6 +1536,7 @@ type QuotasConfigUpdate struct {
 	MaxUsers              int   `json:"max_users"`
 	MaxTotalStorageBytes  int64 `json:"max_total_storage_bytes"`
 	MaxEgressBandwidthBps int64 `json:"max_egress_bandwidth_bps"`
+	MinFreeDiskBytes      int64 `json:"min_free_disk_bytes"`
 }
 
 // RateLimitsConfigUpdate contains rate limit configuration fields for updates.
@@ -1671,3 +1748,27 @@ func (r *ResolveUsersRequest) Validate() error {
 	}
 	return nil
 }
+
+// ListAuditLogRequest is a request to list the audit log of an organization.
+type ListAuditLogRequest struct {
+	OrgID   ksid.ID `path:"orgID" tstype:"-"`
+	ActorID ksid.ID `query:"actor_id"` // Optional: only entries by this user
+	Since   int     `query:"since"`    // Optional: unix timestamp in seconds, inclusive
+	Until   int     `query:"until"`    // Optional: unix timestamp in seconds, exclusive
+	Offset  int     `query:"offset"`
+	Limit   int     `query:"limit"`
+}
+
+// Validate validates the list audit log request.
+func (r *ListAuditLogRequest) Validate() error {
+	if r.OrgID.IsZero() {
+		return MissingField("orgID")
+	}
+	if r.Since < 0 {
+		return InvalidField("since", "must be non-negative")
+	}
+	if r.Until < 0 {
+		return InvalidField("until", "must be non-negative")
+	}
+	return validatePagination(&r.Offset, &r.Limit, DefaultPageLimit, MaxPageLimit)
+}
@@ -3,6 +3,7 @@
 package dto
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/maruel/ksid"
@@ -136,4 +137,18 @@ func TestListNodeVersionsRequest_Validate(t *testing.T) {
 			t.Errorf("Limit = %d, want 100", req.Limit)
 		}
 	})
+	t.Run("accepts before hash", func(t *testing.T) {
+		req := &ListNodeVersionsRequest{WsID: wsID, Before: strings.Repeat("a1", 20)}
+		if err := req.Validate(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+	t.Run("rejects invalid before", func(t *testing.T) {
+		for _, before := range []string{"HEAD~1", "abc123", strings.Repeat("G", 40)} {
+			req := &ListNodeVersionsRequest{WsID: wsID, Before: before}
+			if err := req.Validate(); err == nil {
+				t.Errorf("expected error for before=%q", before)
+			}
+		}
+	})
 }
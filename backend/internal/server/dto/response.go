@@ -17,9 +17,21 @@ type OkResponse struct {
 
 // AuthResponse is a response containing authentication token and user info.
 // Used by login, register, and invitation acceptance endpoints.
+// RefreshToken is only set when a session was created.
 type AuthResponse struct {
-	Token string        `json:"token"`
-	User  *UserResponse `json:"user"`
+	Token        string        `json:"token"`
+	RefreshToken string        `json:"refresh_token,omitempty"`
+	ExpiresIn    int64         `json:"expires_in,omitempty"`
+	User         *UserResponse `json:"user"`
+}
+
+// RefreshTokenResponse is a response containing a new JWT and the refresh
+// token that replaces the one presented. ExpiresIn is the JWT lifetime in
+// seconds.
+type RefreshTokenResponse struct {
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int64  `json:"expires_in"`
 }
 
 // ProvidersResponse is a response containing the list of configured OAuth providers.
@@ -69,6 +81,7 @@ type DeleteNodeResponse = OkResponse
 // ListNodeVersionsResponse is a response containing node version history.
 type ListNodeVersionsResponse struct {
 	History []*Commit `json:"history"`
+	HasMore bool      `json:"has_more"` // Older commits exist; pass the last hash as before.
 }
 
 // GetNodeVersionResponse is a response containing node content at a version.
@@ -129,6 +142,10 @@ type DeleteViewResponse = OkResponse
 // ListRecordsResponse is a response containing a list of records.
 type ListRecordsResponse struct {
 	Records []DataRecordResponse `json:"records"`
+	Total   int                  `json:"total"`    // Number of records matching the filters, ignoring offset and limit.
+	Offset  int                  `json:"offset"`   // Offset of the first record returned.
+	Limit   int                  `json:"limit"`    // Effective limit, after the default and maximum are applied.
+	HasMore bool                 `json:"has_more"` // More records exist; pass offset+len(records) as offset.
 }
 
 // CreateRecordResponse is a response from creating a record.
@@ -202,6 +219,8 @@ type ServeAssetResponse struct {
 // SearchResponse is the response to a search request.
 type SearchResponse struct {
 	Results []SearchResult `json:"results"`
+	Total   int            `json:"total"`    // Number of matches.
+	HasMore bool           `json:"has_more"` // More matches exist beyond Results.
 }
 
 // --- Invitation Responses ---
@@ -293,6 +312,21 @@ type HealthResponse struct {
 	Dirty     bool   `json:"dirty"`
 }
 
+// ComponentStatus is the readiness of one server component.
+type ComponentStatus struct {
+	Name   string `json:"name"`            // "data_dir", "table_files", "git", "smtp" or "disk"
+	Status string `json:"status"`          // "ok", "disabled" or "error"
+	Error  string `json:"error,omitempty"` // Generic reason the component is not ready
+}
+
+// ReadinessResponse is a response from a readiness check. A server that is
+// not ready answers 503 with the components in the error details instead.
+type ReadinessResponse struct {
+	Status     string            `json:"status"`
+	Version    string            `json:"version"`
+	Components []ComponentStatus `json:"components"`
+}
+
 // --- User Responses ---
 
 // ListUsersResponse is a response containing a list of users.
@@ -489,6 +523,19 @@ type GetPageResponse struct {
 	Content  string  `json:"content" jsonschema:"description=Markdown content"`
 	Created  Time    `json:"created" jsonschema:"description=Page creation Unix timestamp"`
 	Modified Time    `json:"modified" jsonschema:"description=Last modification Unix timestamp"`
+	// ETag is sent in the ETag header rather than the body.
+	ETag string `json:"-"`
+}
+
+// EntityTag returns the HTTP entity tag of the page content.
+func (r *GetPageResponse) EntityTag() string {
+	return r.ETag
+}
+
+// GetPageHTMLResponse is the response containing a page rendered to HTML.
+type GetPageHTMLResponse struct {
+	ID   ksid.ID `json:"id" jsonschema:"description=Node identifier"`
+	HTML string  `json:"html" jsonschema:"description=Sanitized HTML rendering of the page content"`
 }
 
 // CreatePageResponse is a response from creating a page.
@@ -498,7 +545,8 @@ type CreatePageResponse struct {
 
 // UpdatePageResponse is a response from updating a page.
 type UpdatePageResponse struct {
-	ID ksid.ID `json:"id" jsonschema:"description=Node identifier"`
+	ID   ksid.ID `json:"id" jsonschema:"description=Node identifier"`
+	ETag string  `json:"etag" jsonschema:"description=Entity tag of the updated page"`
 }
 
 // UpdatePageFrontmatterResponse is a response from updating a page's icon and cover.
@@ -526,7 +574,9 @@ type CreateTableUnderParentResponse struct {
 
 // ListNodeChildrenResponse is a response containing children of a node.
 type ListNodeChildrenResponse struct {
-	Nodes []NodeResponse `json:"nodes" jsonschema:"description=Child nodes"`
+	Nodes   []NodeResponse `json:"nodes" jsonschema:"description=Child nodes"`
+	Total   int            `json:"total" jsonschema:"description=Number of child nodes"`
+	HasMore bool           `json:"has_more" jsonschema:"description=Always false; children are not paged"`
 }
 
 // GetNodeTitlesResponse is a response containing a map of node IDs to titles.
@@ -647,6 +697,7 @@ type QuotasConfigResponse struct {
 	MaxUsers              int   `json:"max_users" jsonschema:"description=Maximum total users"`
 	MaxTotalStorageBytes  int64 `json:"max_total_storage_bytes" jsonschema:"description=Maximum total storage in bytes"`
 	MaxEgressBandwidthBps int64 `json:"max_egress_bandwidth_bps" jsonschema:"description=Maximum egress bandwidth in bytes per second (0=unlimited)"`
+	MinFreeDiskBytes      int64 `json:"min_free_disk_bytes" jsonschema:"description=Free disk space to keep after a write in bytes (0=disabled)"`
 }
 
 // RateLimitsConfigResponse contains rate limit configuration for the response.
@@ -700,3 +751,11 @@ type PushSubscribeResponse = OkResponse
 
 // PushUnsubscribeResponse is a response from unsubscribing from push notifications.
 type PushUnsubscribeResponse = OkResponse
+
+// --- Audit Responses ---
+
+// ListAuditLogResponse is a response containing paginated audit log entries.
+type ListAuditLogResponse struct {
+	Entries []AuditEntryDTO `json:"entries"`
+	Total   int             `json:"total"`
+}
@@ -134,6 +134,8 @@ type ResourceQuotas struct {
 	MaxAssetSizeBytes     int64 `json:"max_asset_size_bytes" jsonschema:"description=Maximum single asset file size in bytes (-1=inherit, 0=disabled, positive=limit)"`
 	MaxTablesPerWorkspace int   `json:"max_tables_per_workspace" jsonschema:"description=Maximum tables per workspace (-1=inherit, 0=disabled, positive=limit)"`
 	MaxColumnsPerTable    int   `json:"max_columns_per_table" jsonschema:"description=Maximum columns per table (-1=inherit, 0=disabled, positive=limit)"`
+	MaxNodeDepth          int   `json:"max_node_depth" jsonschema:"description=Maximum node nesting depth (-1=inherit, 0=disabled, positive=limit)"`
+	MaxChildrenPerNode    int   `json:"max_children_per_node" jsonschema:"description=Maximum direct children per node (-1=inherit, 0=disabled, positive=limit)"`
 }
 
 // Validate checks that all resource quota values are valid for org/workspace layers.
@@ -161,6 +163,12 @@ func (q *ResourceQuotas) Validate(prefix string) error {
 	if q.MaxColumnsPerTable < -1 {
 		return InvalidField(prefix+"max_columns_per_table", "must be -1 (inherit), 0 (disabled), or positive")
 	}
+	if q.MaxNodeDepth < -1 {
+		return InvalidField(prefix+"max_node_depth", "must be -1 (inherit), 0 (disabled), or positive")
+	}
+	if q.MaxChildrenPerNode < -1 {
+		return InvalidField(prefix+"max_children_per_node", "must be -1 (inherit), 0 (disabled), or positive")
+	}
 	return nil
 }
 
@@ -190,11 +198,23 @@ type OrganizationSettings struct {
 
 // WorkspaceSettings represents workspace-wide settings.
 type WorkspaceSettings struct {
-	AllowedDomains []string `json:"allowed_domains,omitempty" jsonschema:"description=Additional email domain restrictions"`
-	PublicAccess   bool     `json:"public_access" jsonschema:"description=Whether content is publicly accessible"`
-	GitAutoPush    bool     `json:"git_auto_push" jsonschema:"description=Automatically push changes to remote"`
+	AllowedDomains []string  `json:"allowed_domains,omitempty" jsonschema:"description=Additional email domain restrictions"`
+	PublicAccess   bool      `json:"public_access" jsonschema:"description=Whether content is publicly accessible"`
+	GitAutoPush    bool      `json:"git_auto_push" jsonschema:"description=Automatically push changes to remote"`
+	LinkStyle      LinkStyle `json:"link_style,omitempty" jsonschema:"description=How links between pages are stored in page files (relative or wikilink)"`
 }
 
+// LinkStyle defines how links between pages are stored in page files.
+type LinkStyle string
+
+const (
+	// LinkStyleRelative stores links as relative paths like
+	// [text](../nodeID/index.md). It is the default.
+	LinkStyleRelative LinkStyle = "relative"
+	// LinkStyleWikilink stores links as wikilinks like [[nodeID|text]].
+	LinkStyleWikilink LinkStyle = "wikilink"
+)
+
 // Commit represents a commit in git history.
 type Commit struct {
 	Hash        string `json:"hash"`
@@ -389,3 +409,17 @@ type NotificationPrefsDTO struct {
 	Defaults  map[string]ChannelSetDTO `json:"defaults" jsonschema:"description=Default channels per notification type"`
 	Overrides map[string]ChannelSetDTO `json:"overrides" jsonschema:"description=User overrides per notification type"`
 }
+
+// --- Audit Types ---
+
+// AuditEntryDTO is the API representation of an audit log entry.
+type AuditEntryDTO struct {
+	ID          ksid.ID `json:"id" jsonschema:"description=Unique entry identifier"`
+	ActorID     ksid.ID `json:"actor_id,omitempty" jsonschema:"description=Authenticated user"`
+	ActorName   string  `json:"actor_name,omitempty" jsonschema:"description=Display name of actor"`
+	WorkspaceID ksid.ID `json:"ws_id,omitempty" jsonschema:"description=Workspace the request was scoped to, if any"`
+	Action      string  `json:"action" jsonschema:"description=Route pattern, e.g. POST /api/v1/workspaces/{wsID}/nodes/{id}/delete"`
+	TargetID    string  `json:"target_id,omitempty" jsonschema:"description=Value of the last path parameter of the route"`
+	Status      int     `json:"status" jsonschema:"description=HTTP status code of the response"`
+	CreatedAt   Time    `json:"created_at" jsonschema:"description=Request timestamp"`
+}
@@ -22,15 +22,15 @@ import (
 	"github.com/maruel/mddb/backend/internal/server/handlers"
 	"github.com/maruel/mddb/backend/internal/server/ratelimit"
 	"github.com/maruel/mddb/backend/internal/server/reqctx"
+	"github.com/maruel/mddb/backend/internal/storage"
 	"github.com/maruel/mddb/backend/internal/storage/git"
 	"github.com/maruel/mddb/backend/internal/storage/identity"
 )
 
-// addRequestMetadataToContext adds client IP and User-Agent to the context.
+// addRequestMetadataToContext adds the User-Agent to the context. The client
+// IP was added by clientIPMiddleware.
 func addRequestMetadataToContext(ctx context.Context, r *http.Request) context.Context {
-	ctx = reqctx.WithClientIP(ctx, reqctx.GetClientIP(r))
-	ctx = reqctx.WithUserAgent(ctx, r.Header.Get("User-Agent"))
-	return ctx
+	return reqctx.WithUserAgent(ctx, r.Header.Get("User-Agent"))
 }
 
 // isMutating returns true for HTTP methods that modify state.
@@ -80,8 +80,10 @@ func checkRateLimit(w http.ResponseWriter, tier *ratelimit.Tier, identifier stri
 // Returns false if an error occurred and was written to the response.
 func readAndDecodeBody[In any](ctx context.Context, w http.ResponseWriter, r *http.Request, input *In, cfg *handlers.Config) bool {
 	// Limit request body size
-	if cfg != nil && cfg.Quotas.MaxRequestBodyBytes > 0 {
-		r.Body = http.MaxBytesReader(w, r.Body, cfg.Quotas.MaxRequestBodyBytes)
+	if cfg != nil {
+		if limit := cfg.Current().Quotas.MaxRequestBodyBytes; limit > 0 {
+			r.Body = http.MaxBytesReader(w, r.Body, limit)
+		}
 	}
 
 	body, err := io.ReadAll(r.Body)
@@ -111,16 +113,23 @@ func readAndDecodeBody[In any](ctx context.Context, w http.ResponseWriter, r *ht
 	return true
 }
 
+// entityTagger is implemented by responses that carry an HTTP entity tag.
+type entityTagger interface {
+	EntityTag() string
+}
+
 // writeJSONResponse writes a JSON response or error response.
-func writeJSONResponse[Out any](ctx context.Context, w http.ResponseWriter, output *Out, err error) {
+//
+// When output has an entity tag, it is sent in the ETag header and a matching
+// If-None-Match gets 304 Not Modified without a body.
+func writeJSONResponse[Out any](ctx context.Context, w http.ResponseWriter, r *http.Request, output *Out, err error) {
 	if err != nil {
-		statusCode := http.StatusInternalServerError
+		statusCode := responseStatus(err)
 		errorCode := dto.ErrorCodeInternal
 		details := make(map[string]any)
 
 		var ewsErr dto.ErrorWithStatus
 		if errors.As(err, &ewsErr) {
-			statusCode = ewsErr.StatusCode()
 			errorCode = ewsErr.Code()
 			if d := ewsErr.Details(); d != nil {
 				details = d
@@ -132,6 +141,16 @@ func writeJSONResponse[Out any](ctx context.Context, w http.ResponseWriter, outp
 		return
 	}
 
+	if e, ok := any(output).(entityTagger); ok {
+		if etag := e.EntityTag(); etag != "" {
+			w.Header().Set("ETag", etag)
+			w.Header().Set("Cache-Control", "private, no-cache")
+			if etagMatches(r.Header.Get("If-None-Match"), etag) {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+		}
+	}
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	if err := json.NewEncoder(w).Encode(output); err != nil {
@@ -139,20 +158,47 @@ func writeJSONResponse[Out any](ctx context.Context, w http.ResponseWriter, outp
 	}
 }
 
+// responseStatus returns the HTTP status code writeJSONResponse answers with
+// for the handler error err.
+func responseStatus(err error) int {
+	if err == nil {
+		return http.StatusOK
+	}
+	var ewsErr dto.ErrorWithStatus
+	if errors.As(err, &ewsErr) {
+		return ewsErr.StatusCode()
+	}
+	return http.StatusInternalServerError
+}
+
+// etagMatches reports whether the If-None-Match header value matches etag,
+// using the weak comparison of RFC 9110.
+func etagMatches(ifNoneMatch, etag string) bool {
+	etag = strings.TrimPrefix(etag, "W/")
+	for candidate := range strings.SplitSeq(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
 // getRateLimitIdentifier returns the appropriate identifier for rate limiting based on scope.
 func getRateLimitIdentifier(tier *ratelimit.Tier, user *identity.User, r *http.Request) string {
 	if tier.Scope == ratelimit.ScopeUser && user != nil {
 		return user.ID.String()
 	}
-	return reqctx.GetClientIP(r)
+	return reqctx.ClientIP(r.Context())
 }
 
 // validateAuthWithContext validates JWT and session, updating context with session info.
 func validateAuthWithContext(ctx context.Context, r *http.Request, svc *handlers.Services, cfg *handlers.Config) (*authResult, context.Context, error) {
-	user, sessionID, tokenString, err := validateJWTAndSession(r, svc.User, svc.Session, cfg.JWTSecret)
+	user, sessionID, tokenString, err := validateJWTAndSession(r, svc.User, svc.Session, &cfg.ServerConfig)
 	if err != nil {
 		return nil, ctx, err
 	}
+	setAuditActor(ctx, user)
 	if !sessionID.IsZero() {
 		ctx = reqctx.WithSessionID(ctx, sessionID)
 	}
@@ -220,7 +266,7 @@ func Wrap[In any, PtrIn interface {
 		// Rate limit check for unauthenticated endpoints
 		var ok bool
 		if tier := limiters.MatchUnauth(r.Method, r.URL.Path); tier != nil {
-			w, ok = checkRateLimit(w, tier, reqctx.GetClientIP(r))
+			w, ok = checkRateLimit(w, tier, reqctx.ClientIP(ctx))
 			if !ok {
 				return
 			}
@@ -240,7 +286,7 @@ func Wrap[In any, PtrIn interface {
 		}
 
 		output, err := fn(ctx, PtrIn(input))
-		writeJSONResponse(ctx, w, output, err)
+		writeJSONResponse(ctx, w, r, output, err)
 	})
 }
 
@@ -255,7 +301,7 @@ func WrapWithSvc[In any, PtrIn interface {
 		// Rate limit check for unauthenticated endpoints
 		var ok bool
 		if tier := limiters.MatchUnauth(r.Method, r.URL.Path); tier != nil {
-			w, ok = checkRateLimit(w, tier, reqctx.GetClientIP(r))
+			w, ok = checkRateLimit(w, tier, reqctx.ClientIP(ctx))
 			if !ok {
 				return
 			}
@@ -276,7 +322,7 @@ func WrapWithSvc[In any, PtrIn interface {
 
 		output, err := fn(ctx, PtrIn(input))
 		commitDBIfMutating(ctx, r, svc.RootRepo, git.Author{})
-		writeJSONResponse(ctx, w, output, err)
+		writeJSONResponse(ctx, w, r, output, err)
 	})
 }
 
@@ -302,7 +348,7 @@ func WrapAuth[In any, PtrIn interface {
 	cfg *handlers.Config,
 	limiters *ratelimit.Limiters,
 ) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	return auditMiddleware(svc, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		ctx := addRequestMetadataToContext(r.Context(), r)
 
 		// Validate JWT and session
@@ -335,9 +381,10 @@ func WrapAuth[In any, PtrIn interface {
 		}
 
 		output, err := fn(ctx, auth.user, PtrIn(input))
+		recordAudit(ctx, svc, r, responseStatus(err))
 		commitDBIfMutating(ctx, r, svc.RootRepo, handlers.GitAuthor(auth.user))
-		writeJSONResponse(ctx, w, output, err)
-	})
+		writeJSONResponse(ctx, w, r, output, err)
+	}))
 }
 
 // WrapOrgAuth wraps an authenticated handler function for organization-scoped routes.
@@ -355,7 +402,7 @@ func WrapOrgAuth[In any, PtrIn interface {
 	requiredRole identity.OrganizationRole,
 	limiters *ratelimit.Limiters,
 ) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	return auditMiddleware(svc, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		ctx := addRequestMetadataToContext(r.Context(), r)
 
 		// Validate JWT and session
@@ -411,9 +458,10 @@ func WrapOrgAuth[In any, PtrIn interface {
 		}
 
 		output, err := fn(ctx, orgID, auth.user, PtrIn(input))
+		recordAudit(ctx, svc, r, responseStatus(err))
 		commitDBIfMutating(ctx, r, svc.RootRepo, handlers.GitAuthor(auth.user))
-		writeJSONResponse(ctx, w, output, err)
-	})
+		writeJSONResponse(ctx, w, r, output, err)
+	}))
 }
 
 // WrapWSAuth wraps an authenticated handler function for workspace-scoped routes.
@@ -432,7 +480,7 @@ func WrapWSAuth[In any, PtrIn interface {
 	requiredRole identity.WorkspaceRole,
 	limiters *ratelimit.Limiters,
 ) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	return auditMiddleware(svc, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		ctx := addRequestMetadataToContext(r.Context(), r)
 
 		// Validate JWT and session
@@ -481,10 +529,11 @@ func WrapWSAuth[In any, PtrIn interface {
 		}
 
 		output, err := fn(ctx, wsID, auth.user, PtrIn(input))
+		recordAudit(ctx, svc, r, responseStatus(err))
 		commitDBIfMutating(ctx, r, svc.RootRepo, handlers.GitAuthor(auth.user))
 		triggerAutoPush(svc, r.Method, wsID, err)
-		writeJSONResponse(ctx, w, output, err)
-	})
+		writeJSONResponse(ctx, w, r, output, err)
+	}))
 }
 
 // triggerAutoPush fires an async push if the request was mutating, successful,
@@ -507,13 +556,14 @@ func WrapAuthRaw(
 	requiredRole identity.WorkspaceRole,
 	limiters *ratelimit.Limiters,
 ) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	return auditMiddleware(svc, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		// Validate JWT and session (don't need context for raw handlers)
-		user, _, _, err := validateJWTAndSession(r, svc.User, svc.Session, cfg.JWTSecret)
+		user, _, _, err := validateJWTAndSession(r, svc.User, svc.Session, &cfg.ServerConfig)
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusUnauthorized)
 			return
 		}
+		setAuditActor(r.Context(), user)
 
 		// Rate limit check for authenticated endpoints
 		if tier := limiters.MatchAuth(r.Method, r.URL.Path); tier != nil {
@@ -545,17 +595,20 @@ func WrapAuthRaw(
 		// uploads — the asset handler enforces its own per-quota limit.
 		ct := r.Header.Get("Content-Type")
 		isMultipart := len(ct) >= 9 && ct[:9] == "multipart"
-		if !isMultipart && cfg != nil && cfg.Quotas.MaxRequestBodyBytes > 0 {
-			r.Body = http.MaxBytesReader(w, r.Body, cfg.Quotas.MaxRequestBodyBytes)
+		if !isMultipart && cfg != nil {
+			if limit := cfg.Current().Quotas.MaxRequestBodyBytes; limit > 0 {
+				r.Body = http.MaxBytesReader(w, r.Body, limit)
+			}
 		}
 
 		// Store user in context for raw handlers
 		ctx := reqctx.WithUser(r.Context(), user)
 		fn(w, r.WithContext(ctx))
 		// Commit DB changes for mutating raw handlers (e.g., asset upload)
+		recordAudit(ctx, svc, r, responseStatus(err))
 		commitDBIfMutating(ctx, r, svc.RootRepo, handlers.GitAuthor(user))
 		triggerAutoPush(svc, r.Method, wsID, nil)
-	})
+	}))
 }
 
 // WrapGlobalAdmin wraps a handler that requires global admin privileges.
@@ -571,14 +624,15 @@ func WrapGlobalAdmin[In any, PtrIn interface {
 	cfg *handlers.Config,
 	limiters *ratelimit.Limiters,
 ) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	return auditMiddleware(svc, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		ctx := addRequestMetadataToContext(r.Context(), r)
 
-		user, _, _, err := validateJWTAndSession(r, svc.User, svc.Session, cfg.JWTSecret)
+		user, _, _, err := validateJWTAndSession(r, svc.User, svc.Session, &cfg.ServerConfig)
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusUnauthorized)
 			return
 		}
+		setAuditActor(r.Context(), user)
 
 		if !user.IsGlobalAdmin {
 			http.Error(w, "Forbidden: global admin required", http.StatusForbidden)
@@ -608,9 +662,10 @@ func WrapGlobalAdmin[In any, PtrIn interface {
 		}
 
 		output, err := fn(ctx, user, PtrIn(input))
+		recordAudit(ctx, svc, r, responseStatus(err))
 		commitDBIfMutating(ctx, r, svc.RootRepo, handlers.GitAuthor(user))
-		writeJSONResponse(ctx, w, output, err)
-	})
+		writeJSONResponse(ctx, w, r, output, err)
+	}))
 }
 
 var (
@@ -627,7 +682,8 @@ var (
 // validateJWTAndSession extracts and validates the JWT token and session from the request.
 // Returns the user, session ID, token string, and any error.
 // If sessionService is nil, session validation is skipped (backwards compatible).
-func validateJWTAndSession(r *http.Request, userService *identity.UserService, sessionService *identity.SessionService, jwtSecret []byte) (*identity.User, ksid.ID, string, error) {
+// The token is verified with the keys of serverCfg matching its "kid" header.
+func validateJWTAndSession(r *http.Request, userService *identity.UserService, sessionService *identity.SessionService, serverCfg *storage.ServerConfig) (*identity.User, ksid.ID, string, error) {
 	authHeader := r.Header.Get("Authorization")
 	if authHeader == "" {
 		return nil, 0, "", errUnauthorized
@@ -643,7 +699,7 @@ func validateJWTAndSession(r *http.Request, userService *identity.UserService, s
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
 		}
-		return jwtSecret, nil
+		return jwtKeySet(serverCfg, token)
 	})
 
 	if err != nil || !token.Valid {
@@ -692,6 +748,25 @@ func validateJWTAndSession(r *http.Request, userService *identity.UserService, s
 	return user, sessionID, tokenString, nil
 }
 
+// jwtKeySet returns the verification keys for token, selected by its "kid"
+// header. Tokens without one are checked against the keys without an ID.
+func jwtKeySet(serverCfg *storage.ServerConfig, token *jwt.Token) (jwt.VerificationKeySet, error) {
+	kid := ""
+	if v, ok := token.Header["kid"]; ok {
+		if kid, ok = v.(string); !ok || kid == "" {
+			return jwt.VerificationKeySet{}, errInvalidToken
+		}
+	}
+	var set jwt.VerificationKeySet
+	for _, k := range serverCfg.JWTVerificationKeys(kid) {
+		set.Keys = append(set.Keys, k)
+	}
+	if len(set.Keys) == 0 {
+		return set, fmt.Errorf("unknown key id %q", kid)
+	}
+	return set, nil
+}
+
 // hasOrgPermission checks if the user's org role meets the required level.
 // Role hierarchy: owner > admin > member.
 func hasOrgPermission(userRole, requiredRole identity.OrganizationRole) bool {
@@ -0,0 +1,134 @@
+package server
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/maruel/mddb/backend/internal/server/dto"
+	"github.com/maruel/mddb/backend/internal/server/handlers"
+	"github.com/maruel/mddb/backend/internal/storage"
+	"github.com/maruel/mddb/backend/internal/storage/identity"
+)
+
+func TestReadAndDecodeBody(t *testing.T) {
+	const limit = 64
+	cfg := &handlers.Config{ServerConfig: storage.ServerConfig{Quotas: storage.DefaultServerQuotas()}}
+	cfg.Quotas.MaxRequestBodyBytes = limit
+
+	type payload struct {
+		Title string `json:"title"`
+	}
+
+	t.Run("within_limit", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"title":"ok"}`))
+		w := httptest.NewRecorder()
+		var in payload
+		if !readAndDecodeBody(t.Context(), w, r, &in, cfg) {
+			t.Fatalf("readAndDecodeBody() failed: %d %s", w.Code, w.Body.String())
+		}
+		if in.Title != "ok" {
+			t.Errorf("Title = %q, want %q", in.Title, "ok")
+		}
+	})
+
+	t.Run("too_large", func(t *testing.T) {
+		// A 1 MiB body must be rejected after reading just past the limit.
+		const size = 1 << 20
+		body := &countingReader{r: strings.NewReader(`{"title":"` + strings.Repeat("x", size) + `"}`)}
+		r := httptest.NewRequest(http.MethodPost, "/", body)
+		w := httptest.NewRecorder()
+		var in payload
+		if readAndDecodeBody(t.Context(), w, r, &in, cfg) {
+			t.Fatal("readAndDecodeBody() accepted an oversized body")
+		}
+		if w.Code != http.StatusRequestEntityTooLarge {
+			t.Errorf("status = %d, want %d", w.Code, http.StatusRequestEntityTooLarge)
+		}
+		if !strings.Contains(w.Body.String(), string(dto.ErrorCodePayloadTooLarge)) {
+			t.Errorf("body = %s, want %s error code", w.Body.String(), dto.ErrorCodePayloadTooLarge)
+		}
+		if body.n >= size {
+			t.Errorf("read %d bytes, want the read cut short near %d", body.n, limit)
+		}
+	})
+}
+
+// countingReader counts the bytes read through it.
+type countingReader struct {
+	r io.Reader
+	n int
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += n
+	return n, err
+}
+
+func TestValidateJWTAndSession_KeyRing(t *testing.T) {
+	userService, err := identity.NewUserService(filepath.Join(t.TempDir(), "users.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	user, err := userService.Create("joe@example.com", "password", "Joe")
+	if err != nil {
+		t.Fatal(err)
+	}
+	key1 := []byte("key-1-test-secret-32-bytes-long!")
+	key2 := []byte("key-2-test-secret-32-bytes-long!")
+	key3 := []byte("key-3-test-secret-32-bytes-long!")
+	sign := func(sc storage.ServerConfig) string {
+		t.Helper()
+		token, err := (&handlers.Config{ServerConfig: sc}).GenerateToken(user)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return token
+	}
+	valid := func(sc storage.ServerConfig, token string) bool {
+		r := httptest.NewRequest(http.MethodGet, "/", http.NoBody)
+		r.Header.Set("Authorization", "Bearer "+token)
+		_, _, _, err := validateJWTAndSession(r, userService, nil, &sc)
+		return err == nil
+	}
+
+	// A token from before key IDs were configured.
+	legacy := sign(storage.ServerConfig{JWTSecret: key1})
+
+	// First rotation: the legacy key stays accepted for tokens without a kid.
+	ring2 := storage.ServerConfig{JWTSecret: key2, JWTKeyID: "k2", JWTPreviousKeys: []storage.JWTKey{{Secret: key1}}}
+	token2 := sign(ring2)
+	if !valid(ring2, legacy) {
+		t.Error("legacy token rejected while its key is in the ring")
+	}
+	if !valid(ring2, token2) {
+		t.Error("token signed with the current key rejected")
+	}
+
+	// Second rotation drops the legacy key.
+	ring3 := storage.ServerConfig{JWTSecret: key3, JWTKeyID: "k3", JWTPreviousKeys: []storage.JWTKey{{ID: "k2", Secret: key2}}}
+	if !valid(ring3, token2) {
+		t.Error("token signed with a previous key rejected")
+	}
+	if !valid(ring3, sign(ring3)) {
+		t.Error("token signed with the current key rejected")
+	}
+	if valid(ring3, legacy) {
+		t.Error("legacy token accepted after its key was removed")
+	}
+	ring3.JWTPreviousKeys = nil
+	if valid(ring3, token2) {
+		t.Error("token accepted after its key was removed")
+	}
+
+	// A kid only selects keys; it cannot vouch for a token signed with
+	// another one.
+	forged := sign(storage.ServerConfig{JWTSecret: key1, JWTKeyID: "k3"})
+	if valid(ring3, forged) {
+		t.Error("token with a known kid but the wrong key accepted")
+	}
+}
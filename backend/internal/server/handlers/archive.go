@@ -0,0 +1,40 @@
+// Handles exporting a workspace as a zip archive.
+
+package handlers
+
+import (
+	"log/slog"
+	"mime"
+	"net/http"
+
+	"github.com/maruel/ksid"
+	"github.com/maruel/mddb/backend/internal/server/dto"
+)
+
+// ArchiveHandler handles workspace archive downloads.
+type ArchiveHandler struct {
+	Svc *Services
+}
+
+// ExportWorkspace streams the workspace's files as a zip archive download.
+// This is a raw http.HandlerFunc wrapped by WrapAuthRaw.
+func (h *ArchiveHandler) ExportWorkspace(w http.ResponseWriter, r *http.Request) {
+	wsID, err := ksid.Parse(r.PathValue("wsID"))
+	if err != nil {
+		writeErrorResponse(w, dto.BadRequest("invalid_ws_id"))
+		return
+	}
+	ws, err := h.Svc.FileStore.GetWorkspaceStore(r.Context(), wsID)
+	if err != nil {
+		writeErrorResponse(w, dto.Internal("workspace"))
+		return
+	}
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": wsID.String() + ".zip"}))
+	w.Header().Set("Cache-Control", "no-store")
+	// The status is sent with the first bytes, so a failure midway can only be
+	// logged; the client sees a truncated archive.
+	if err := ws.ExportZip(r.Context(), w); err != nil {
+		slog.ErrorContext(r.Context(), "Failed to export workspace", "err", err, "wsID", wsID)
+	}
+}
@@ -35,6 +35,10 @@ func init() {
 	}
 }
 
+// multipartOverheadBytes is the slack allowed on top of the asset size limit
+// for the multipart envelope around the uploaded file.
+const multipartOverheadBytes = 64 << 10
+
 // AssetHandler handles asset/file-related HTTP requests.
 type AssetHandler struct {
 	Svc *Services
@@ -58,25 +62,31 @@ func (h *AssetHandler) UploadNodeAssetHandler(w http.ResponseWriter, r *http.Req
 		return
 	}
 
-	// Use the server-level MaxAssetSizeBytes as the upload limit.
-	maxBytes := h.Cfg.Quotas.MaxAssetSizeBytes
+	ws, err := h.Svc.FileStore.GetWorkspaceStore(r.Context(), wsID)
+	if err != nil {
+		writeErrorResponse(w, dto.Internal("workspace"))
+		return
+	}
+
+	// The workspace's effective MaxAssetSizeBytes is the upload limit. The
+	// multipart body is allowed a little extra for part headers and boundaries.
+	maxBytes := ws.EffectiveQuotas().MaxAssetSizeBytes
+	maxBody := maxBytes + multipartOverheadBytes
 
-	// Fast pre-check via Content-Length before reading the body, so we can
-	// return a structured JSON error. (Browsers always send Content-Length for
-	// multipart uploads.)
-	if r.ContentLength > maxBytes {
+	// Fast pre-check via Content-Length so the body is never read. (Browsers
+	// always send Content-Length for multipart uploads.)
+	if r.ContentLength > maxBody {
 		writeErrorResponse(w, dto.PayloadTooLarge(maxBytes))
 		return
 	}
 
 	// MaxBytesReader as a hard backstop for requests without Content-Length.
-	// If it triggers, Go writes 413 automatically; the pre-check above covers
-	// the normal browser path with a structured JSON response.
-	r.Body = http.MaxBytesReader(w, r.Body, maxBytes)
+	r.Body = http.MaxBytesReader(w, r.Body, maxBody)
 	if err := r.ParseMultipartForm(maxBytes); err != nil {
 		var maxErr *http.MaxBytesError
 		if errors.As(err, &maxErr) {
-			return // Go already wrote 413 via MaxBytesReader
+			writeErrorResponse(w, dto.PayloadTooLarge(maxBytes))
+			return
 		}
 		writeErrorResponse(w, dto.BadRequest("form_parse"))
 		return
@@ -92,6 +102,14 @@ func (h *AssetHandler) UploadNodeAssetHandler(w http.ResponseWriter, r *http.Req
 			slog.Error("Failed to close uploaded file", "error", err)
 		}
 	}()
+	if content.ValidateAssetName(header.Filename) != nil {
+		writeErrorResponse(w, dto.BadRequest("invalid_asset_name"))
+		return
+	}
+	if header.Size > maxBytes {
+		writeErrorResponse(w, dto.PayloadTooLarge(maxBytes))
+		return
+	}
 
 	data, err := io.ReadAll(file)
 	if err != nil {
@@ -107,7 +125,7 @@ func (h *AssetHandler) UploadNodeAssetHandler(w http.ResponseWriter, r *http.Req
 	}
 
 	// Check server-wide storage quota before saving
-	maxStorage := h.Cfg.Quotas.MaxTotalStorageBytes
+	maxStorage := h.Cfg.Current().Quotas.MaxTotalStorageBytes
 	if err := h.Svc.FileStore.CheckServerStorageQuota(int64(len(data)), maxStorage); err != nil {
 		if errors.Is(err, content.ErrServerStorageQuotaExceeded) {
 			writeErrorResponse(w, dto.QuotaExceededInt64("total storage", maxStorage))
@@ -118,18 +136,6 @@ func (h *AssetHandler) UploadNodeAssetHandler(w http.ResponseWriter, r *http.Req
 	}
 
 	author := GitAuthor(user)
-	ws, err := h.Svc.FileStore.GetWorkspaceStore(r.Context(), wsID)
-	if err != nil {
-		writeErrorResponse(w, dto.Internal("workspace"))
-		return
-	}
-
-	// Enforce maximum asset file size using effective quotas
-	eq := ws.EffectiveQuotas()
-	if int64(len(data)) > eq.MaxAssetSizeBytes {
-		writeErrorResponse(w, dto.PayloadTooLarge(eq.MaxAssetSizeBytes))
-		return
-	}
 
 	// Verify node exists before saving asset
 	if _, err := ws.ReadNode(nodeID); err != nil {
@@ -140,7 +146,7 @@ func (h *AssetHandler) UploadNodeAssetHandler(w http.ResponseWriter, r *http.Req
 	asset, err := ws.SaveAsset(r.Context(), nodeID, header.Filename, data, author)
 	if err != nil {
 		slog.Error("Failed to save asset", "error", err, "nodeID", nodeID, "filename", header.Filename, "wsID", wsID, "author", author)
-		writeErrorResponse(w, dto.Internal("asset_save"))
+		writeErrorResponse(w, contentError(err, "asset_save"))
 		return
 	}
 
@@ -198,6 +204,10 @@ func (h *AssetHandler) ServeAssetFile(w http.ResponseWriter, r *http.Request) {
 		writeErrorResponse(w, dto.BadRequest("invalid_node_id"))
 		return
 	}
+	if content.ValidateAssetName(assetName) != nil {
+		writeErrorResponse(w, dto.BadRequest("invalid_asset_name"))
+		return
+	}
 
 	// Verify signature matches the path and expiry
 	path := fmt.Sprintf("%s/%s/%s", wsIDStr, nodeIDStr, assetName)
@@ -206,26 +216,83 @@ func (h *AssetHandler) ServeAssetFile(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	h.serveAsset(w, r, wsID, nodeID, assetName)
+}
+
+// DownloadNodeAsset streams an asset to an authenticated workspace member.
+// This is a raw http.HandlerFunc wrapped by WrapAuthRaw; it supports Range
+// requests so large media files can be seeked without loading them in memory.
+func (h *AssetHandler) DownloadNodeAsset(w http.ResponseWriter, r *http.Request) {
+	wsID, err := ksid.Parse(r.PathValue("wsID"))
+	if err != nil {
+		writeErrorResponse(w, dto.BadRequest("invalid_ws_id"))
+		return
+	}
+	nodeID, err := ksid.Parse(r.PathValue("id"))
+	if err != nil {
+		writeErrorResponse(w, dto.BadRequest("invalid_node_id"))
+		return
+	}
+	assetName := r.PathValue("name")
+	if content.ValidateAssetName(assetName) != nil {
+		writeErrorResponse(w, dto.BadRequest("invalid_asset_name"))
+		return
+	}
+	h.serveAsset(w, r, wsID, nodeID, assetName)
+}
+
+// assetCSP is the Content-Security-Policy of asset responses. It blocks
+// scripts, plugins and network access from documents such as SVG or HTML.
+const assetCSP = "default-src 'none'; img-src 'self' data:; media-src 'self'; style-src 'unsafe-inline'"
+
+// isActiveContentType reports whether a browser would render mimeType as a
+// document that can embed script.
+func isActiveContentType(mimeType string) bool {
+	mediaType, _, err := mime.ParseMediaType(mimeType)
+	if err != nil {
+		return true
+	}
+	switch mediaType {
+	case "text/html", "application/xhtml+xml", "image/svg+xml", "text/xml", "application/xml",
+		"text/javascript", "application/javascript", "application/x-shockwave-flash":
+		return true
+	}
+	return false
+}
+
+// serveAsset streams an asset file using http.ServeContent, which handles
+// Range, If-Range, If-Modified-Since and HEAD requests.
+func (h *AssetHandler) serveAsset(w http.ResponseWriter, r *http.Request, wsID, nodeID ksid.ID, assetName string) {
 	ws, err := h.Svc.FileStore.GetWorkspaceStore(r.Context(), wsID)
 	if err != nil {
 		writeErrorResponse(w, dto.Internal("workspace"))
 		return
 	}
-	data, err := ws.ReadAsset(nodeID, assetName)
+	f, info, err := ws.OpenAsset(nodeID, assetName)
 	if err != nil {
 		writeErrorResponse(w, dto.NotFound("asset"))
 		return
 	}
+	defer func() {
+		if err := f.Close(); err != nil {
+			slog.ErrorContext(r.Context(), "Failed to close asset", "err", err, "asset", assetName)
+		}
+	}()
 
 	mimeType := mime.TypeByExtension(filepath.Ext(assetName))
 	if mimeType == "" {
 		mimeType = "application/octet-stream"
 	}
 	w.Header().Set("Content-Type", mimeType)
-	w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+	// Uploaded files are untrusted: never let them run script, and download
+	// the types a browser would render as a document on our origin.
+	w.Header().Set("Content-Security-Policy", assetCSP)
+	if isActiveContentType(mimeType) {
+		w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": assetName}))
+	}
 	// Cache asset for the duration of the URL validity
 	w.Header().Set("Cache-Control", "private, max-age=3600")
-	if _, err := w.Write(data); err != nil {
-		slog.Error("Failed to write asset data", "error", err, "asset", assetName)
-	}
+	// http.ServeContent answers If-None-Match with 304 once ETag is set.
+	w.Header().Set("ETag", content.AssetETag(info))
+	http.ServeContent(w, r, assetName, info.ModTime(), f)
 }
@@ -1,6 +1,9 @@
 package handlers
 
 import (
+	"bytes"
+	"io"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
 	"strings"
@@ -8,7 +11,12 @@ import (
 	"time"
 
 	"github.com/maruel/ksid"
+	"github.com/maruel/mddb/backend/internal/server/dto"
+	"github.com/maruel/mddb/backend/internal/server/reqctx"
 	"github.com/maruel/mddb/backend/internal/storage"
+	"github.com/maruel/mddb/backend/internal/storage/content"
+	"github.com/maruel/mddb/backend/internal/storage/git"
+	"github.com/maruel/mddb/backend/internal/storage/identity"
 )
 
 func TestAssetHandler(t *testing.T) {
@@ -118,6 +126,180 @@ func TestAssetHandler(t *testing.T) {
 			}
 		})
 
+		t.Run("range_request", func(t *testing.T) {
+			svc, wsID := testServices(t)
+			ctx := t.Context()
+			if err := svc.FileStore.InitWorkspace(ctx, wsID); err != nil {
+				t.Fatalf("failed to init workspace: %v", err)
+			}
+			ws, err := svc.FileStore.GetWorkspaceStore(ctx, wsID)
+			if err != nil {
+				t.Fatalf("failed to get workspace store: %v", err)
+			}
+			author := git.Author{Name: "Test", Email: "test@test.com"}
+			node, err := ws.CreateNode(ctx, "Media", content.NodeTypeDocument, 0, author)
+			if err != nil {
+				t.Fatalf("failed to create node: %v", err)
+			}
+			data := []byte("0123456789abcdefghij")
+			if _, err := ws.SaveAsset(ctx, node.ID, "clip.mp4", data, author); err != nil {
+				t.Fatalf("failed to save asset: %v", err)
+			}
+			rah := &AssetHandler{Svc: svc, Cfg: cfg}
+
+			signed := cfg.GenerateSignedAssetURL(wsID, node.ID, "clip.mp4")
+			req := httptest.NewRequest(http.MethodGet, signed, http.NoBody)
+			req.SetPathValue("wsID", wsID.String())
+			req.SetPathValue("id", node.ID.String())
+			req.SetPathValue("name", "clip.mp4")
+			req.Header.Set("Range", "bytes=5-9")
+
+			w := httptest.NewRecorder()
+			rah.ServeAssetFile(w, req)
+
+			if w.Code != http.StatusPartialContent {
+				t.Fatalf("Expected status %d, got %d: %s", http.StatusPartialContent, w.Code, w.Body.String())
+			}
+			if got := w.Body.String(); got != "56789" {
+				t.Errorf("Expected body %q, got %q", "56789", got)
+			}
+			if got := w.Header().Get("Content-Range"); got != "bytes 5-9/20" {
+				t.Errorf("Expected Content-Range %q, got %q", "bytes 5-9/20", got)
+			}
+			if got := w.Header().Get("Content-Type"); got != "video/mp4" {
+				t.Errorf("Expected Content-Type %q, got %q", "video/mp4", got)
+			}
+
+			// Authenticated download path streams the same bytes.
+			req = httptest.NewRequest(http.MethodGet, "/api/v1/workspaces/x/nodes/y/assets/clip.mp4", http.NoBody)
+			req.SetPathValue("wsID", wsID.String())
+			req.SetPathValue("id", node.ID.String())
+			req.SetPathValue("name", "clip.mp4")
+			req.Header.Set("Range", "bytes=-3")
+			w = httptest.NewRecorder()
+			rah.DownloadNodeAsset(w, req)
+			if w.Code != http.StatusPartialContent {
+				t.Fatalf("Expected status %d, got %d", http.StatusPartialContent, w.Code)
+			}
+			if got := w.Body.String(); got != "hij" {
+				t.Errorf("Expected body %q, got %q", "hij", got)
+			}
+
+			// The ETag comes from the blob hash and is honored by If-None-Match.
+			req = httptest.NewRequest(http.MethodGet, "/api/v1/workspaces/x/nodes/y/assets/clip.mp4", http.NoBody)
+			req.SetPathValue("wsID", wsID.String())
+			req.SetPathValue("id", node.ID.String())
+			req.SetPathValue("name", "clip.mp4")
+			w = httptest.NewRecorder()
+			rah.DownloadNodeAsset(w, req)
+			etag := w.Header().Get("ETag")
+			if w.Code != http.StatusOK || etag == "" || w.Body.String() != string(data) {
+				t.Fatalf("Expected 200 with ETag, got %d, ETag %q", w.Code, etag)
+			}
+			req.Header.Set("If-None-Match", etag)
+			w = httptest.NewRecorder()
+			rah.DownloadNodeAsset(w, req)
+			if w.Code != http.StatusNotModified || w.Body.Len() != 0 {
+				t.Errorf("Expected 304 without body, got %d: %q", w.Code, w.Body.String())
+			}
+		})
+
+		t.Run("active_content", func(t *testing.T) {
+			svc, wsID := testServices(t)
+			ctx := t.Context()
+			if err := svc.FileStore.InitWorkspace(ctx, wsID); err != nil {
+				t.Fatalf("failed to init workspace: %v", err)
+			}
+			ws, err := svc.FileStore.GetWorkspaceStore(ctx, wsID)
+			if err != nil {
+				t.Fatalf("failed to get workspace store: %v", err)
+			}
+			author := git.Author{Name: "Test", Email: "test@test.com"}
+			node, err := ws.CreateNode(ctx, "Media", content.NodeTypeDocument, 0, author)
+			if err != nil {
+				t.Fatalf("failed to create node: %v", err)
+			}
+			svg := []byte(`<svg xmlns="http://www.w3.org/2000/svg"><script>alert(1)</script></svg>`)
+			if _, err := ws.SaveAsset(ctx, node.ID, "x.svg", svg, author); err != nil {
+				t.Fatalf("failed to save asset: %v", err)
+			}
+			if _, err := ws.SaveAsset(ctx, node.ID, "y.png", []byte("png"), author); err != nil {
+				t.Fatalf("failed to save asset: %v", err)
+			}
+			rah := &AssetHandler{Svc: svc, Cfg: cfg}
+			get := func(name string) http.Header {
+				req := httptest.NewRequest(http.MethodGet, cfg.GenerateSignedAssetURL(wsID, node.ID, name), http.NoBody)
+				req.SetPathValue("wsID", wsID.String())
+				req.SetPathValue("id", node.ID.String())
+				req.SetPathValue("name", name)
+				w := httptest.NewRecorder()
+				rah.ServeAssetFile(w, req)
+				if w.Code != http.StatusOK {
+					t.Fatalf("%s: status %d", name, w.Code)
+				}
+				return w.Header()
+			}
+
+			h := get("x.svg")
+			if got := h.Get("Content-Disposition"); got != `attachment; filename=x.svg` {
+				t.Errorf("SVG Content-Disposition = %q, want attachment", got)
+			}
+			if got := h.Get("Content-Security-Policy"); !strings.HasPrefix(got, "default-src 'none'") {
+				t.Errorf("SVG Content-Security-Policy = %q", got)
+			}
+			h = get("y.png")
+			if got := h.Get("Content-Disposition"); got != "" {
+				t.Errorf("PNG Content-Disposition = %q, want inline", got)
+			}
+			if got := h.Get("Content-Security-Policy"); got != assetCSP {
+				t.Errorf("PNG Content-Security-Policy = %q", got)
+			}
+		})
+
+		t.Run("path_traversal", func(t *testing.T) {
+			svc, wsID := testServices(t)
+			ctx := t.Context()
+			if err := svc.FileStore.InitWorkspace(ctx, wsID); err != nil {
+				t.Fatalf("failed to init workspace: %v", err)
+			}
+			ws, err := svc.FileStore.GetWorkspaceStore(ctx, wsID)
+			if err != nil {
+				t.Fatalf("failed to get workspace store: %v", err)
+			}
+			author := git.Author{Name: "Test", Email: "test@test.com"}
+			node, err := ws.CreateNode(ctx, "Media", content.NodeTypeDocument, 0, author)
+			if err != nil {
+				t.Fatalf("failed to create node: %v", err)
+			}
+			other, err := ws.CreatePageUnderParent(ctx, 0, "Other", "secret", author)
+			if err != nil {
+				t.Fatalf("failed to create page: %v", err)
+			}
+			rah := &AssetHandler{Svc: svc, Cfg: cfg}
+			// The router unescapes ..%2F in the {name} wildcard.
+			for _, name := range []string{"..", "../" + other.ID.String() + "/index.md", "../../../../etc/passwd"} {
+				req := httptest.NewRequest(http.MethodGet, cfg.GenerateSignedAssetURL(wsID, node.ID, name), http.NoBody)
+				req.SetPathValue("wsID", wsID.String())
+				req.SetPathValue("id", node.ID.String())
+				req.SetPathValue("name", name)
+				w := httptest.NewRecorder()
+				rah.ServeAssetFile(w, req)
+				if w.Code != http.StatusBadRequest || strings.Contains(w.Body.String(), "secret") {
+					t.Errorf("ServeAssetFile(%q): status %d: %s", name, w.Code, w.Body.String())
+				}
+
+				req = httptest.NewRequest(http.MethodGet, "/api/v1/workspaces/x/nodes/y/assets/z", http.NoBody)
+				req.SetPathValue("wsID", wsID.String())
+				req.SetPathValue("id", node.ID.String())
+				req.SetPathValue("name", name)
+				w = httptest.NewRecorder()
+				rah.DownloadNodeAsset(w, req)
+				if w.Code != http.StatusBadRequest || strings.Contains(w.Body.String(), "secret") {
+					t.Errorf("DownloadNodeAsset(%q): status %d: %s", name, w.Code, w.Body.String())
+				}
+			}
+		})
+
 		t.Run("invalid_signature", func(t *testing.T) {
 			expiry := time.Now().Add(time.Hour).Unix()
 
@@ -139,4 +321,108 @@ func TestAssetHandler(t *testing.T) {
 			}
 		})
 	})
+
+	t.Run("UploadNodeAsset", func(t *testing.T) {
+		const limit = 1024
+		svc, wsID := testServicesWith(t, func(w *identity.Workspace) {
+			w.Quotas.MaxAssetSizeBytes = limit
+		})
+		ctx := t.Context()
+		if err := svc.FileStore.InitWorkspace(ctx, wsID); err != nil {
+			t.Fatalf("failed to init workspace: %v", err)
+		}
+		ws, err := svc.FileStore.GetWorkspaceStore(ctx, wsID)
+		if err != nil {
+			t.Fatalf("failed to get workspace store: %v", err)
+		}
+		node, err := ws.CreateNode(ctx, "Uploads", content.NodeTypeDocument, 0, git.Author{Name: "Test", Email: "test@test.com"})
+		if err != nil {
+			t.Fatalf("failed to create node: %v", err)
+		}
+		uah := &AssetHandler{Svc: svc, Cfg: cfg}
+		user := &identity.User{ID: ksid.ID(1), Name: "Test"}
+
+		// upload posts body to the upload handler; a contentLength of -1 makes
+		// the request look chunked.
+		upload := func(t *testing.T, body io.Reader, contentType string, contentLength int64) *httptest.ResponseRecorder {
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/workspaces/x/nodes/y/assets", body)
+			req.SetPathValue("wsID", wsID.String())
+			req.SetPathValue("id", node.ID.String())
+			req.Header.Set("Content-Type", contentType)
+			req.ContentLength = contentLength
+			req = req.WithContext(reqctx.WithUser(req.Context(), user))
+			w := httptest.NewRecorder()
+			uah.UploadNodeAssetHandler(w, req)
+			return w
+		}
+		multipartBody := func(t *testing.T, size int) (*bytes.Buffer, string) {
+			var buf bytes.Buffer
+			mw := multipart.NewWriter(&buf)
+			fw, err := mw.CreateFormFile("file", "data.bin")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := fw.Write(bytes.Repeat([]byte("x"), size)); err != nil {
+				t.Fatal(err)
+			}
+			if err := mw.Close(); err != nil {
+				t.Fatal(err)
+			}
+			return &buf, mw.FormDataContentType()
+		}
+		wantTooLarge := func(t *testing.T, w *httptest.ResponseRecorder) {
+			t.Helper()
+			if w.Code != http.StatusRequestEntityTooLarge {
+				t.Fatalf("Expected status %d, got %d: %s", http.StatusRequestEntityTooLarge, w.Code, w.Body.String())
+			}
+			if !strings.Contains(w.Body.String(), string(dto.ErrorCodePayloadTooLarge)) {
+				t.Errorf("Expected %s error code, got %s", dto.ErrorCodePayloadTooLarge, w.Body.String())
+			}
+		}
+
+		t.Run("within_limit", func(t *testing.T) {
+			body, ct := multipartBody(t, limit)
+			w := upload(t, body, ct, int64(body.Len()))
+			if w.Code != http.StatusCreated {
+				t.Fatalf("Expected status %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+			}
+		})
+
+		t.Run("content_length_too_large", func(t *testing.T) {
+			// The body must not be read at all when Content-Length is over the cap.
+			body := &countingReader{r: strings.NewReader("")}
+			w := upload(t, body, "multipart/form-data; boundary=x", limit+multipartOverheadBytes+1)
+			wantTooLarge(t, w)
+			if body.n != 0 {
+				t.Errorf("Expected body to be unread, read %d bytes", body.n)
+			}
+		})
+
+		t.Run("file_too_large", func(t *testing.T) {
+			body, ct := multipartBody(t, limit+1)
+			wantTooLarge(t, upload(t, body, ct, int64(body.Len())))
+		})
+
+		t.Run("chunked_too_large", func(t *testing.T) {
+			const size = 4 * multipartOverheadBytes
+			buf, ct := multipartBody(t, size)
+			body := &countingReader{r: buf}
+			wantTooLarge(t, upload(t, body, ct, -1))
+			if body.n >= size {
+				t.Errorf("Expected the upload to be cut short, read %d bytes", body.n)
+			}
+		})
+	})
+}
+
+// countingReader counts the bytes read through it.
+type countingReader struct {
+	r io.Reader
+	n int
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += n
+	return n, err
 }
@@ -0,0 +1,53 @@
+// Handles the organization audit log API endpoint.
+
+package handlers
+
+import (
+	"context"
+	"time"
+
+	"github.com/maruel/ksid"
+	"github.com/maruel/mddb/backend/internal/server/dto"
+	"github.com/maruel/mddb/backend/internal/storage"
+	"github.com/maruel/mddb/backend/internal/storage/identity"
+)
+
+// AuditHandler handles audit log requests.
+type AuditHandler struct {
+	Svc *Services
+}
+
+// ListAuditLog returns the audit log of an organization, newest first.
+func (h *AuditHandler) ListAuditLog(_ context.Context, orgID ksid.ID, _ *identity.User, req *dto.ListAuditLogRequest) (*dto.ListAuditLogResponse, error) {
+	resp := &dto.ListAuditLogResponse{Entries: []dto.AuditEntryDTO{}}
+	if h.Svc.Audit == nil {
+		return resp, nil
+	}
+	f := identity.AuditFilter{ActorID: req.ActorID}
+	if req.Since > 0 {
+		f.Since = storage.ToTime(time.Unix(int64(req.Since), 0))
+	}
+	if req.Until > 0 {
+		f.Until = storage.ToTime(time.Unix(int64(req.Until), 0))
+	}
+	entries, total := h.Svc.Audit.ListByOrg(orgID, f, req.Limit, req.Offset)
+	for _, e := range entries {
+		d := dto.AuditEntryDTO{
+			ID:          e.ID,
+			ActorID:     e.ActorID,
+			WorkspaceID: e.WorkspaceID,
+			Action:      e.Action,
+			TargetID:    e.TargetID,
+			Status:      e.Status,
+			CreatedAt:   e.Created,
+		}
+		if !e.ActorID.IsZero() {
+			if actor, err := h.Svc.User.Get(e.ActorID); err == nil {
+				d.ActorName = actor.Name
+			}
+		}
+		resp.Entries = append(resp.Entries, d)
+	}
+	resp.Total = total
+	return resp, nil
+}
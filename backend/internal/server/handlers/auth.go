@@ -4,6 +4,7 @@ package handlers
 
 import (
 	"context"
+	"errors"
 	"log/slog"
 	"net/http"
 	"sync"
@@ -42,17 +43,22 @@ func (h *AuthHandler) Login(ctx context.Context, req *dto.LoginRequest) (*dto.Au
 		return nil, dto.MissingField("email or password")
 	}
 
-	user, err := h.svc.User.Authenticate(req.Email, req.Password)
-	if err != nil {
-		return nil, dto.NewAPIError(401, dto.ErrorCodeUnauthorized, "Invalid credentials")
-	}
-
 	// Get request metadata from context
 	clientIP := reqctx.ClientIP(ctx)
 	userAgent := reqctx.UserAgent(ctx)
 	countryCode := reqctx.CountryCode(ctx)
 
-	token, err := h.cfg.GenerateTokenWithSession(h.svc.Session, user, clientIP, userAgent, countryCode)
+	if err := h.svc.checkLoginLockout(loginAccountKey(req.Email), loginIPKey(clientIP)); err != nil {
+		return nil, err
+	}
+	user, err := h.svc.User.Authenticate(req.Email, req.Password)
+	if err != nil {
+		h.svc.recordLoginFailure(ctx, h.cfg, req.Email, clientIP)
+		return nil, dto.NewAPIError(401, dto.ErrorCodeUnauthorized, "Invalid credentials")
+	}
+	h.svc.resetLoginFailures(ctx, req.Email)
+
+	token, refreshToken, err := h.cfg.GenerateTokenWithSession(h.svc.Session, user, clientIP, userAgent, countryCode)
 	if err != nil {
 		return nil, dto.InternalWithError("Failed to generate token", err)
 	}
@@ -68,8 +74,10 @@ func (h *AuthHandler) Login(ctx context.Context, req *dto.LoginRequest) (*dto.Au
 	uwm.populateActiveContext(userResp)
 
 	return &dto.AuthResponse{
-		Token: token,
-		User:  userResp,
+		Token:        token,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int64(h.cfg.Auth.AccessTokenTTL().Seconds()),
+		User:         userResp,
 	}, nil
 }
 
@@ -80,8 +88,8 @@ func (h *AuthHandler) Register(ctx context.Context, req *dto.RegisterRequest) (*
 	}
 
 	// Check server-wide user quota
-	if h.cfg.Quotas.MaxUsers > 0 && h.svc.User.Count() >= h.cfg.Quotas.MaxUsers {
-		return nil, dto.QuotaExceeded("users", h.cfg.Quotas.MaxUsers)
+	if limit := h.cfg.Current().Quotas.MaxUsers; limit > 0 && h.svc.User.Count() >= limit {
+		return nil, dto.QuotaExceeded("users", limit)
 	}
 
 	// Check if user already exists
@@ -100,7 +108,7 @@ func (h *AuthHandler) Register(ctx context.Context, req *dto.RegisterRequest) (*
 	userAgent := reqctx.UserAgent(ctx)
 	countryCode := reqctx.CountryCode(ctx)
 
-	token, err := h.cfg.GenerateTokenWithSession(h.svc.Session, user, clientIP, userAgent, countryCode)
+	token, refreshToken, err := h.cfg.GenerateTokenWithSession(h.svc.Session, user, clientIP, userAgent, countryCode)
 	if err != nil {
 		return nil, dto.InternalWithError("Failed to generate token", err)
 	}
@@ -118,8 +126,10 @@ func (h *AuthHandler) Register(ctx context.Context, req *dto.RegisterRequest) (*
 	}
 
 	return &dto.AuthResponse{
-		Token: token,
-		User:  userResp,
+		Token:        token,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int64(h.cfg.Auth.AccessTokenTTL().Seconds()),
+		User:         userResp,
 	}, nil
 }
 
@@ -145,8 +155,8 @@ func (h *AuthHandler) CreateOrganization(ctx context.Context, user *identity.Use
 	}
 
 	// Check server-wide organization quota
-	if h.cfg.Quotas.MaxOrganizations > 0 && h.svc.Organization.Count() >= h.cfg.Quotas.MaxOrganizations {
-		return nil, dto.QuotaExceeded("organizations", h.cfg.Quotas.MaxOrganizations)
+	if limit := h.cfg.Current().Quotas.MaxOrganizations; limit > 0 && h.svc.Organization.Count() >= limit {
+		return nil, dto.QuotaExceeded("organizations", limit)
 	}
 
 	// Create the organization
@@ -162,7 +172,7 @@ func (h *AuthHandler) CreateOrganization(ctx context.Context, user *identity.Use
 
 	memberCount := h.svc.OrgMembership.CountOrgMemberships(org.ID)
 	workspaceCount := h.svc.Workspace.CountByOrg(org.ID)
-	return organizationToResponse(org, memberCount, workspaceCount, h.cfg.Quotas.ResourceQuotas), nil
+	return organizationToResponse(org, memberCount, workspaceCount, h.cfg.Current().Quotas.ResourceQuotas), nil
 }
 
 // populateActiveContext populates organization/workspace context in the UserResponse.
@@ -218,6 +228,28 @@ func (uwm *userWithMemberships) populateActiveContext(userResp *dto.UserResponse
 	}
 }
 
+// Refresh exchanges a refresh token for a new JWT and refresh token.
+//
+// Refresh tokens are single use. Replaying one that was already exchanged
+// revokes the whole session.
+func (h *AuthHandler) Refresh(ctx context.Context, req *dto.RefreshTokenRequest) (*dto.RefreshTokenResponse, error) {
+	token, refreshToken, err := h.cfg.RefreshSession(h.svc.Session, h.svc.User, req.RefreshToken)
+	if err != nil {
+		if errors.Is(err, identity.ErrRefreshTokenReused) {
+			slog.WarnContext(ctx, "Refresh token reused, session revoked")
+		}
+		if errors.Is(err, identity.ErrRefreshTokenInvalid) || errors.Is(err, identity.ErrRefreshTokenReused) {
+			return nil, dto.NewAPIError(401, dto.ErrorCodeUnauthorized, "Invalid refresh token")
+		}
+		return nil, dto.InternalWithError("Failed to refresh token", err)
+	}
+	return &dto.RefreshTokenResponse{
+		Token:        token,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int64(h.cfg.Auth.AccessTokenTTL().Seconds()),
+	}, nil
+}
+
 // Logout revokes the current session.
 func (h *AuthHandler) Logout(ctx context.Context, _ *identity.User, _ *dto.LogoutRequest) (*dto.LogoutResponse, error) {
 	sessionID := reqctx.SessionID(ctx)
@@ -237,8 +269,12 @@ func (h *AuthHandler) Logout(ctx context.Context, _ *identity.User, _ *dto.Logou
 func (h *AuthHandler) ListSessions(ctx context.Context, user *identity.User, _ *dto.ListSessionsRequest) (*dto.ListSessionsResponse, error) {
 	currentSessionID := reqctx.SessionID(ctx)
 
-	sessions := make([]dto.SessionResponse, 0, 8)
-	for session := range h.svc.Session.GetActiveByUserID(user.ID) {
+	active, err := h.svc.Session.ListByUser(user.ID)
+	if err != nil {
+		return nil, dto.InternalWithError("Failed to list sessions", err)
+	}
+	sessions := make([]dto.SessionResponse, 0, len(active))
+	for _, session := range active {
 		sessions = append(sessions, dto.SessionResponse{
 			ID:          session.ID,
 			DeviceInfo:  session.DeviceInfo,
@@ -273,22 +309,9 @@ func (h *AuthHandler) RevokeSession(ctx context.Context, user *identity.User, re
 
 // RevokeAllSessions revokes all sessions for the current user except the current one.
 func (h *AuthHandler) RevokeAllSessions(ctx context.Context, user *identity.User, _ *dto.RevokeAllSessionsRequest) (*dto.RevokeAllSessionsResponse, error) {
-	currentSessionID := reqctx.SessionID(ctx)
-
-	var toRevoke []ksid.ID
-	for session := range h.svc.Session.GetActiveByUserID(user.ID) {
-		if session.ID != currentSessionID {
-			toRevoke = append(toRevoke, session.ID)
-		}
-	}
-
-	revokedCount := 0
-	for _, id := range toRevoke {
-		if err := h.svc.Session.Revoke(id); err != nil {
-			slog.ErrorContext(ctx, "Failed to revoke session", "error", err, "session_id", id)
-			continue
-		}
-		revokedCount++
+	revokedCount, err := h.svc.Session.RevokeAll(user.ID, reqctx.SessionID(ctx))
+	if err != nil {
+		return nil, dto.InternalWithError("Failed to revoke sessions", err)
 	}
 
 	return &dto.RevokeAllSessionsResponse{RevokedCount: revokedCount}, nil
@@ -1,14 +1,18 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
 	"path/filepath"
 	"testing"
 
 	"github.com/maruel/mddb/backend/internal/server/dto"
+	"github.com/maruel/mddb/backend/internal/server/reqctx"
 	"github.com/maruel/mddb/backend/internal/storage"
 	"github.com/maruel/mddb/backend/internal/storage/content"
 	"github.com/maruel/mddb/backend/internal/storage/git"
 	"github.com/maruel/mddb/backend/internal/storage/identity"
+	"github.com/maruel/mddb/backend/internal/utils"
 )
 
 func TestRegister(t *testing.T) {
@@ -47,7 +51,7 @@ func TestRegister(t *testing.T) {
 
 	gitMgr := git.NewManager(tempDir, "test", "test@test.com")
 
-	serverQuotas := storage.DefaultResourceQuotas()
+	serverQuotas := storage.DefaultServerQuotas()
 	fileStore, err := content.NewFileStoreService(tempDir, gitMgr, wsService, orgService, &serverQuotas)
 	if err != nil {
 		t.Fatalf("NewFileStore failed: %v", err)
@@ -124,3 +128,281 @@ func TestRegister(t *testing.T) {
 		t.Error("Expected Alice to receive a token")
 	}
 }
+
+func TestRefresh(t *testing.T) {
+	ctx := t.Context()
+	tempDir := t.TempDir()
+	userService, err := identity.NewUserService(filepath.Join(tempDir, "users.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sessionService, err := identity.NewSessionService(filepath.Join(tempDir, "sessions.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	user, err := userService.Create("joe@example.com", "password", "Joe")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := &Config{
+		ServerConfig: storage.ServerConfig{
+			JWTSecret: []byte("test-secret-key-32-bytes-long!!!"),
+			Auth:      storage.AuthConfig{AccessTokenTTLSeconds: 900, RefreshTokenTTLSeconds: 3600},
+		},
+	}
+	authHandler := NewAuthHandler(&Services{User: userService, Session: sessionService}, cfg)
+
+	_, refresh1, err := cfg.GenerateTokenWithSession(sessionService, user, "127.0.0.1", "test", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := authHandler.Refresh(ctx, &dto.RefreshTokenRequest{RefreshToken: refresh1})
+	if err != nil {
+		t.Fatalf("Refresh failed: %v", err)
+	}
+	if resp.Token == "" || resp.RefreshToken == "" || resp.RefreshToken == refresh1 {
+		t.Errorf("expected a new token and a rotated refresh token, got %+v", resp)
+	}
+	if resp.ExpiresIn != 900 {
+		t.Errorf("ExpiresIn = %d, want 900", resp.ExpiresIn)
+	}
+	sessionID, _ := refreshTokenSessionID(resp.RefreshToken)
+	session, err := sessionService.Get(sessionID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if session.TokenHash != utils.HashToken(resp.Token) {
+		t.Error("session does not track the new JWT")
+	}
+
+	// Replaying the exchanged token revokes the session, including the
+	// refresh token just handed out.
+	wantUnauthorized := func(name, refresh string) {
+		t.Helper()
+		_, err := authHandler.Refresh(ctx, &dto.RefreshTokenRequest{RefreshToken: refresh})
+		var apiErr *dto.APIError
+		if !errors.As(err, &apiErr) || apiErr.StatusCode() != 401 {
+			t.Errorf("%s: got %v, want 401", name, err)
+		}
+	}
+	wantUnauthorized("replay", refresh1)
+	wantUnauthorized("after reuse", resp.RefreshToken)
+	if ok, _ := sessionService.IsValid(sessionID); ok {
+		t.Error("session should be revoked after reuse")
+	}
+	wantUnauthorized("malformed", "not-a-token")
+}
+
+func TestRevokeAllSessions(t *testing.T) {
+	tempDir := t.TempDir()
+	userService, err := identity.NewUserService(filepath.Join(tempDir, "users.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sessionService, err := identity.NewSessionService(filepath.Join(tempDir, "sessions.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	user, err := userService.Create("joe@example.com", "password", "Joe")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := &Config{
+		ServerConfig: storage.ServerConfig{
+			JWTSecret: []byte("test-secret-key-32-bytes-long!!!"),
+			Auth:      storage.AuthConfig{AccessTokenTTLSeconds: 900, RefreshTokenTTLSeconds: 3600},
+		},
+	}
+	authHandler := NewAuthHandler(&Services{User: userService, Session: sessionService}, cfg)
+
+	var refreshTokens []string
+	for _, device := range []string{"laptop", "phone", "tablet"} {
+		_, refresh, err := cfg.GenerateTokenWithSession(sessionService, user, "127.0.0.1", device, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		refreshTokens = append(refreshTokens, refresh)
+	}
+	currentID, _ := refreshTokenSessionID(refreshTokens[0])
+	ctx := reqctx.WithSessionID(t.Context(), currentID)
+
+	resp, err := authHandler.RevokeAllSessions(ctx, user, &dto.RevokeAllSessionsRequest{})
+	if err != nil {
+		t.Fatalf("RevokeAllSessions failed: %v", err)
+	}
+	if resp.RevokedCount != 2 {
+		t.Errorf("RevokedCount = %d, want 2", resp.RevokedCount)
+	}
+	list, err := authHandler.ListSessions(ctx, user, &dto.ListSessionsRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Sessions) != 1 || list.Sessions[0].ID != currentID || !list.Sessions[0].IsCurrent {
+		t.Errorf("ListSessions = %+v, want only the current session", list.Sessions)
+	}
+
+	// The revoked sessions can no longer authenticate nor be refreshed.
+	for _, refresh := range refreshTokens[1:] {
+		id, _ := refreshTokenSessionID(refresh)
+		if ok, _ := sessionService.IsValid(id); ok {
+			t.Errorf("session %s is still valid", id)
+		}
+		_, err := authHandler.Refresh(ctx, &dto.RefreshTokenRequest{RefreshToken: refresh})
+		var apiErr *dto.APIError
+		if !errors.As(err, &apiErr) || apiErr.StatusCode() != 401 {
+			t.Errorf("Refresh of a revoked session: got %v, want 401", err)
+		}
+	}
+	if _, err := authHandler.Refresh(ctx, &dto.RefreshTokenRequest{RefreshToken: refreshTokens[0]}); err != nil {
+		t.Errorf("Refresh of the current session failed: %v", err)
+	}
+}
+
+func TestSessionQuotaReload(t *testing.T) {
+	tempDir := t.TempDir()
+	userService, err := identity.NewUserService(filepath.Join(tempDir, "users.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sessionService, err := identity.NewSessionService(filepath.Join(tempDir, "sessions.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	user, err := userService.Create("joe@example.com", "password", "Joe")
+	if err != nil {
+		t.Fatal(err)
+	}
+	initial := storage.ServerConfig{
+		JWTSecret: []byte("test-secret-key-32-bytes-long!!!"),
+		Auth:      storage.AuthConfig{AccessTokenTTLSeconds: 900, RefreshTokenTTLSeconds: 3600},
+		Quotas:    storage.ServerQuotas{MaxSessionsPerUser: 3},
+	}
+	live := storage.NewConfig(&initial)
+	cfg := &Config{ServerConfig: initial, Live: live}
+
+	for range 2 {
+		if _, _, err := cfg.GenerateTokenWithSession(sessionService, user, "127.0.0.1", "test", ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Lowering the quota applies to the next login without a restart.
+	reloaded := *live.Current()
+	reloaded.Quotas.MaxSessionsPerUser = 2
+	live.Store(&reloaded)
+	_, _, err = cfg.GenerateTokenWithSession(sessionService, user, "127.0.0.1", "test", "")
+	var apiErr *dto.APIError
+	if !errors.As(err, &apiErr) || apiErr.Code() != dto.ErrorCodeQuotaExceeded {
+		t.Fatalf("GenerateTokenWithSession after reload: got %v, want %s", err, dto.ErrorCodeQuotaExceeded)
+	}
+
+	raised := *live.Current()
+	raised.Quotas.MaxSessionsPerUser = 4
+	live.Store(&raised)
+	if _, _, err := cfg.GenerateTokenWithSession(sessionService, user, "127.0.0.1", "test", ""); err != nil {
+		t.Fatalf("GenerateTokenWithSession after raising the quota: %v", err)
+	}
+}
+
+func TestLoginLockout(t *testing.T) {
+	tempDir := t.TempDir()
+	userService, err := identity.NewUserService(filepath.Join(tempDir, "users.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	orgService, err := identity.NewOrganizationService(filepath.Join(tempDir, "organizations.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	wsService, err := identity.NewWorkspaceService(filepath.Join(tempDir, "workspaces.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	orgMemService, err := identity.NewOrganizationMembershipService(filepath.Join(tempDir, "org_memberships.jsonl"), userService, orgService)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wsMemService, err := identity.NewWorkspaceMembershipService(filepath.Join(tempDir, "ws_memberships.jsonl"), wsService, orgService)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sessionService, err := identity.NewSessionService(filepath.Join(tempDir, "sessions.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	attempts, err := identity.NewLoginAttemptService(filepath.Join(tempDir, "login_attempts.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := userService.Create("joe@example.com", "password", "Joe"); err != nil {
+		t.Fatal(err)
+	}
+	svc := &Services{
+		User:          userService,
+		Organization:  orgService,
+		Workspace:     wsService,
+		OrgMembership: orgMemService,
+		WSMembership:  wsMemService,
+		Session:       sessionService,
+		LoginAttempts: attempts,
+	}
+	cfg := &Config{
+		ServerConfig: storage.ServerConfig{
+			JWTSecret:    []byte("test-secret-key-32-bytes-long!!!"),
+			LoginLockout: storage.LoginLockout{MaxAccountFailures: 3, MaxIPFailures: 5, WindowSeconds: 60, LockoutSeconds: 60},
+		},
+	}
+	authHandler := NewAuthHandler(svc, cfg)
+	login := func(ip, password string) int {
+		t.Helper()
+		ctx := reqctx.WithClientIP(t.Context(), ip)
+		_, err := authHandler.Login(ctx, &dto.LoginRequest{Email: "joe@example.com", Password: password})
+		if err == nil {
+			return 200
+		}
+		var apiErr *dto.APIError
+		if !errors.As(err, &apiErr) {
+			t.Fatalf("unexpected error %v", err)
+		}
+		return apiErr.StatusCode()
+	}
+
+	// A success resets the account counter.
+	for range 2 {
+		if got := login("10.0.0.1", "wrong"); got != 401 {
+			t.Fatalf("wrong password: got %d, want 401", got)
+		}
+	}
+	if got := login("10.0.0.1", "password"); got != 200 {
+		t.Fatalf("right password: got %d, want 200", got)
+	}
+
+	// The third failure in a row locks the account, even from another IP and
+	// with the right password.
+	for range 3 {
+		if got := login("10.0.0.2", "wrong"); got != 401 {
+			t.Fatalf("wrong password: got %d, want 401", got)
+		}
+	}
+	if got := login("10.0.0.3", "password"); got != 429 {
+		t.Errorf("locked account: got %d, want 429", got)
+	}
+
+	// Failures across accounts lock the IP.
+	if _, err := userService.Create("alice@example.com", "password", "Alice"); err != nil {
+		t.Fatal(err)
+	}
+	for i := range 5 {
+		ctx := reqctx.WithClientIP(t.Context(), "10.0.0.4")
+		email := fmt.Sprintf("user%d@example.com", i)
+		if _, err := authHandler.Login(ctx, &dto.LoginRequest{Email: email, Password: "x"}); err == nil {
+			t.Fatal("expected failure")
+		}
+	}
+	ctx := reqctx.WithClientIP(t.Context(), "10.0.0.4")
+	_, err = authHandler.Login(ctx, &dto.LoginRequest{Email: "alice@example.com", Password: "password"})
+	var apiErr *dto.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode() != 429 {
+		t.Errorf("locked IP: got %v, want 429", err)
+	}
+}
@@ -13,7 +13,7 @@ import (
 
 // GitAuthor returns the git.Author for a user.
 func GitAuthor(u *identity.User) git.Author {
-	return git.Author{Name: u.Name, Email: u.PreferredEmail()}
+	return git.Author{Name: u.Name, Email: u.PreferredEmail(), ID: u.ID.String()}
 }
 
 // --- Entity to DTO conversions ---
@@ -193,23 +193,21 @@ func commitsToDTO(commits []*git.Commit) []*dto.Commit {
 	return result
 }
 
-func searchResultToDTO(r *content.SearchResult) dto.SearchResult {
+func searchHitToDTO(h *content.SearchHit) dto.SearchResult {
 	return dto.SearchResult{
-		Type:     r.Type,
-		NodeID:   r.NodeID.String(),
-		RecordID: r.RecordID.String(),
-		Title:    r.Title,
-		Snippet:  r.Snippet,
-		Score:    r.Score,
-		Matches:  r.Matches,
-		Modified: r.Modified,
+		Type:     "page",
+		NodeID:   h.NodeID.String(),
+		Title:    h.Title,
+		Snippet:  h.Snippet,
+		Score:    h.Score,
+		Modified: h.Modified,
 	}
 }
 
-func searchResultsToDTO(results []content.SearchResult) []dto.SearchResult {
-	dtoResults := make([]dto.SearchResult, len(results))
-	for i := range results {
-		dtoResults[i] = searchResultToDTO(&results[i])
+func searchHitsToDTO(hits []content.SearchHit) []dto.SearchResult {
+	dtoResults := make([]dto.SearchResult, len(hits))
+	for i := range hits {
+		dtoResults[i] = searchHitToDTO(&hits[i])
 	}
 	return dtoResults
 }
@@ -280,6 +278,8 @@ func resourceQuotasToDTO(q storage.ResourceQuotas) dto.ResourceQuotas {
 		MaxAssetSizeBytes:     q.MaxAssetSizeBytes,
 		MaxTablesPerWorkspace: q.MaxTablesPerWorkspace,
 		MaxColumnsPerTable:    q.MaxColumnsPerTable,
+		MaxNodeDepth:          q.MaxNodeDepth,
+		MaxChildrenPerNode:    q.MaxChildrenPerNode,
 	}
 }
 
@@ -308,6 +308,7 @@ func workspaceSettingsToDTO(s identity.WorkspaceSettings) dto.WorkspaceSettings
 		AllowedDomains: s.AllowedDomains,
 		PublicAccess:   s.PublicAccess,
 		GitAutoPush:    s.GitAutoPush,
+		LinkStyle:      dto.LinkStyle(s.LinkStyle),
 	}
 }
 
@@ -386,6 +387,8 @@ func resourceQuotasToEntity(q dto.ResourceQuotas) storage.ResourceQuotas {
 		MaxAssetSizeBytes:     q.MaxAssetSizeBytes,
 		MaxTablesPerWorkspace: q.MaxTablesPerWorkspace,
 		MaxColumnsPerTable:    q.MaxColumnsPerTable,
+		MaxNodeDepth:          q.MaxNodeDepth,
+		MaxChildrenPerNode:    q.MaxChildrenPerNode,
 	}
 }
 
@@ -408,6 +411,7 @@ func workspaceSettingsToEntity(s dto.WorkspaceSettings) identity.WorkspaceSettin
 		AllowedDomains: s.AllowedDomains,
 		PublicAccess:   s.PublicAccess,
 		GitAutoPush:    s.GitAutoPush,
+		LinkStyle:      identity.LinkStyle(s.LinkStyle),
 	}
 }
 
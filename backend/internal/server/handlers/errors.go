@@ -9,6 +9,7 @@ import (
 	"net/http"
 
 	"github.com/maruel/mddb/backend/internal/server/dto"
+	"github.com/maruel/mddb/backend/internal/storage/content"
 )
 
 // writeErrorResponse writes an APIError as a JSON response.
@@ -42,3 +43,36 @@ func writeErrorResponse(w http.ResponseWriter, err error) {
 		slog.Error("Failed to encode error response", "error", err)
 	}
 }
+
+// contentError maps the content package's sentinel errors to API errors:
+// missing pages, tables and assets to 404, records not matching their table
+// schema and invalid titles to 400, move cycles and update conflicts to 409,
+// quota overflows to 413 and a nearly full disk to 507. Any other error
+// becomes a 500 with message. A page update conflict carries the current page
+// in its details.
+func contentError(err error, message string) *dto.APIError {
+	var conflict *content.ConflictError
+	switch {
+	case errors.As(err, &conflict):
+		return dto.Conflict("Page was modified since the expected version").
+			WithDetail("etag", conflict.ETag).
+			WithDetail("title", conflict.Current.Title).
+			WithDetail("content", conflict.Current.Content)
+	case errors.Is(err, content.ErrPageNotFound):
+		return dto.NotFound("page")
+	case errors.Is(err, content.ErrTableNotFound):
+		return dto.NotFound("table")
+	case errors.Is(err, content.ErrAssetNotFound):
+		return dto.NotFound("asset")
+	case errors.Is(err, content.ErrInvalidRecord), errors.Is(err, content.ErrInvalidTitle), errors.Is(err, content.ErrInvalidAssetName):
+		return dto.BadRequest(err.Error())
+	case errors.Is(err, content.ErrCycleDetected):
+		return dto.Conflict("Cannot move a node under one of its descendants")
+	case errors.Is(err, content.ErrQuotaExceeded):
+		return dto.StorageQuotaExceeded()
+	case errors.Is(err, content.ErrDiskFull):
+		return dto.InsufficientStorage()
+	default:
+		return dto.InternalWithError(message, err)
+	}
+}
@@ -3,11 +3,13 @@ package handlers
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 
 	"github.com/maruel/mddb/backend/internal/server/dto"
+	"github.com/maruel/mddb/backend/internal/storage/content"
 )
 
 func TestWriteErrorResponse(t *testing.T) {
@@ -128,3 +130,31 @@ func TestWriteErrorResponse(t *testing.T) {
 		}
 	})
 }
+
+func TestContentError(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+		wantCode   dto.ErrorCode
+	}{
+		{"page not found", fmt.Errorf("parent node not found: %w", content.ErrPageNotFound), http.StatusNotFound, dto.ErrorCodeNotFound},
+		{"table not found", content.ErrTableNotFound, http.StatusNotFound, dto.ErrorCodeNotFound},
+		{"asset not found", content.ErrAssetNotFound, http.StatusNotFound, dto.ErrorCodeNotFound},
+		{"invalid record", fmt.Errorf("%w: property \"Done\": want a checkbox, got string", content.ErrInvalidRecord), http.StatusBadRequest, dto.ErrorCodeValidationFailed},
+		{"invalid title", fmt.Errorf("%w: must be a single line", content.ErrInvalidTitle), http.StatusBadRequest, dto.ErrorCodeValidationFailed},
+		{"cycle", content.ErrCycleDetected, http.StatusConflict, dto.ErrorCodeConflict},
+		{"quota", content.ErrQuotaExceeded, http.StatusRequestEntityTooLarge, dto.ErrorCodeQuotaExceeded},
+		{"wrapped quota", content.ErrServerStorageQuotaExceeded, http.StatusRequestEntityTooLarge, dto.ErrorCodeQuotaExceeded},
+		{"disk full", content.ErrDiskFull, http.StatusInsufficientStorage, dto.ErrorCodeInsufficientStorage},
+		{"other", errors.New("disk on fire"), http.StatusInternalServerError, dto.ErrorCodeInternal},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := contentError(tt.err, "failed")
+			if got.StatusCode() != tt.wantStatus || got.Code() != tt.wantCode {
+				t.Errorf("got %d %s, want %d %s", got.StatusCode(), got.Code(), tt.wantStatus, tt.wantCode)
+			}
+		})
+	}
+}
@@ -108,6 +108,12 @@ func (h *GitRemoteHandler) PushGit(ctx context.Context, wsID ksid.ID, _ *identit
 		return nil, fmt.Errorf("failed to set git remote: %w", err)
 	}
 	if err := repo.Push(ctx, gitRemoteName, ws.GitRemote.Branch); err != nil {
+		switch {
+		case errors.Is(err, git.ErrAuthFailed):
+			return nil, dto.BadRequest("The git remote rejected the credentials")
+		case errors.Is(err, git.ErrNonFastForward):
+			return nil, dto.Conflict("The git remote has changes that are not in the workspace; pull first")
+		}
 		return nil, fmt.Errorf("failed to push to git remote: %w", err)
 	}
 
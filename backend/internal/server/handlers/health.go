@@ -3,17 +3,37 @@
 package handlers
 
 import (
+	"bufio"
 	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+	"time"
 
 	"github.com/maruel/mddb/backend/internal/server/dto"
 )
 
+// readinessCacheTTL is how long a readiness result is reused. The endpoint is
+// public and not rate limited, so the checks must not run on every request.
+const readinessCacheTTL = 5 * time.Second
+
 // HealthHandler handles health check requests.
 type HealthHandler struct {
-	Cfg *Config
+	Cfg     *Config
+	Svc     *Services // Only used by GetReadiness
+	DataDir string    // Only used by GetReadiness
+
+	mu         sync.Mutex
+	readyAt    time.Time             // When ready was computed
+	components []dto.ComponentStatus // Last readiness result
 }
 
-// GetHealth handles health check requests.
+// GetHealth handles health check requests. It is the liveness probe and
+// always succeeds while the server answers.
 func (h *HealthHandler) GetHealth(ctx context.Context, req *dto.HealthRequest) (*dto.HealthResponse, error) {
 	return &dto.HealthResponse{
 		Status:    "ok",
@@ -23,3 +43,107 @@ func (h *HealthHandler) GetHealth(ctx context.Context, req *dto.HealthRequest) (
 		Dirty:     h.Cfg.Dirty,
 	}, nil
 }
+
+// GetReadiness handles readiness probes. It checks that the data directory is
+// writable, the identity table files have a readable header, the data
+// directory git repository opens and the disk has the configured free space.
+// SMTP is reported but never fails the check since it is optional. Returns 503
+// with the components in the details when a check fails.
+//
+// The result is reused for a few seconds. Failures are logged; the
+// response only carries a generic message per component.
+func (h *HealthHandler) GetReadiness(ctx context.Context, req *dto.ReadinessRequest) (*dto.ReadinessResponse, error) {
+	h.mu.Lock()
+	if h.components == nil || time.Since(h.readyAt) >= readinessCacheTTL {
+		h.components = h.checkReadiness(ctx)
+		h.readyAt = time.Now()
+	}
+	components := h.components
+	h.mu.Unlock()
+	for _, c := range components {
+		if c.Status == "error" {
+			return nil, dto.ServiceUnavailable("Server is not ready").WithDetail("components", components)
+		}
+	}
+	return &dto.ReadinessResponse{Status: "ok", Version: h.Cfg.Version, Components: components}, nil
+}
+
+// checkReadiness runs every readiness check.
+func (h *HealthHandler) checkReadiness(ctx context.Context) []dto.ComponentStatus {
+	components := []dto.ComponentStatus{
+		componentStatus(ctx, "data_dir", "not writable", checkDataDir(h.DataDir)),
+		componentStatus(ctx, "table_files", "unreadable", checkTableFiles(filepath.Join(h.DataDir, "db"))),
+		componentStatus(ctx, "git", "repository unavailable", checkGit(ctx, h.DataDir)),
+		{Name: "smtp", Status: "disabled"},
+		componentStatus(ctx, "disk", "insufficient free space", h.checkDisk()),
+	}
+	if !h.Cfg.Current().SMTP.IsZero() {
+		components[3].Status = "ok"
+	}
+	return components
+}
+
+// componentStatus returns the status of component name given the result of
+// its check. The error is logged and replaced with msg, since the endpoint is
+// public and errors carry paths.
+func componentStatus(ctx context.Context, name, msg string, err error) dto.ComponentStatus {
+	if err != nil {
+		slog.WarnContext(ctx, "Readiness check failed", "component", name, "err", err)
+		return dto.ComponentStatus{Name: name, Status: "error", Error: msg}
+	}
+	return dto.ComponentStatus{Name: name, Status: "ok"}
+}
+
+// checkDataDir returns an error if files cannot be created in dir.
+func checkDataDir(dir string) error {
+	if dir == "" {
+		return errors.New("not configured")
+	}
+	f, err := os.CreateTemp(dir, ".ready-*")
+	if err != nil {
+		return err
+	}
+	return errors.Join(f.Close(), os.Remove(f.Name()))
+}
+
+// checkTableFiles returns an error if the header of a table file of the db
+// directory cannot be read. The rows are not loaded.
+func checkTableFiles(dir string) error {
+	tables, err := filepath.Glob(filepath.Join(dir, "*.jsonl"))
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(dir); err != nil {
+		return err
+	}
+	for _, path := range tables {
+		f, err := os.Open(path) //nolint:gosec // G304: path is within the data directory
+		if err != nil {
+			return err
+		}
+		_, err = bufio.NewReader(f).ReadString('\n')
+		_ = f.Close()
+		if err != nil {
+			return fmt.Errorf("%s: %w", filepath.Base(path), err)
+		}
+	}
+	return nil
+}
+
+// checkGit returns an error if git cannot open the data directory repository.
+func checkGit(ctx context.Context, dir string) error {
+	out, err := exec.CommandContext(ctx, "git", "-C", dir, "rev-parse", "--git-dir").CombinedOutput() //nolint:gosec // G204: dir is the configured data directory
+	if err != nil {
+		return fmt.Errorf("%w: %s", err, out)
+	}
+	return nil
+}
+
+// checkDisk returns an error if the disk holding the data directory has less
+// than the configured free space.
+func (h *HealthHandler) checkDisk() error {
+	if h.Svc == nil || h.Svc.FileStore == nil {
+		return nil
+	}
+	return h.Svc.FileStore.CheckDiskSpace()
+}
@@ -1,7 +1,14 @@
 package handlers
 
 import (
+	"errors"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/maruel/mddb/backend/internal/server/dto"
 )
@@ -35,4 +42,93 @@ func TestHealthHandler(t *testing.T) {
 			t.Error("Dirty = false, want true")
 		}
 	})
+	t.Run("GetReadiness", func(t *testing.T) {
+		if _, err := exec.LookPath("git"); err != nil {
+			t.Skip("git not installed")
+		}
+		dataDir := t.TempDir()
+		if err := os.Mkdir(filepath.Join(dataDir, "db"), 0o750); err != nil {
+			t.Fatal(err)
+		}
+		if out, err := exec.Command("git", "init", "-q", dataDir).CombinedOutput(); err != nil {
+			t.Fatalf("git init: %v: %s", err, out)
+		}
+		if err := os.WriteFile(filepath.Join(dataDir, "db", "users.jsonl"), []byte("{\"version\":\"1\"}\n"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		svc, _ := testServices(t)
+		cfg := &Config{Version: "1.0.0"}
+
+		t.Run("Ready", func(t *testing.T) {
+			handler := &HealthHandler{Cfg: cfg, Svc: svc, DataDir: dataDir}
+			resp, err := handler.GetReadiness(t.Context(), &dto.ReadinessRequest{})
+			if err != nil {
+				t.Fatalf("GetReadiness() error = %v", err)
+			}
+			if resp.Status != "ok" || resp.Version != "1.0.0" {
+				t.Errorf("GetReadiness() = %+v", resp)
+			}
+			want := map[string]string{"data_dir": "ok", "table_files": "ok", "git": "ok", "smtp": "disabled", "disk": "ok"}
+			for _, c := range resp.Components {
+				if c.Status != want[c.Name] {
+					t.Errorf("%s: status %q, want %q (%s)", c.Name, c.Status, want[c.Name], c.Error)
+				}
+				delete(want, c.Name)
+			}
+			if len(want) != 0 {
+				t.Errorf("missing components %v", want)
+			}
+		})
+
+		t.Run("MissingDataDir", func(t *testing.T) {
+			handler := &HealthHandler{Cfg: cfg, Svc: svc, DataDir: filepath.Join(dataDir, "missing")}
+			_, err := handler.GetReadiness(t.Context(), &dto.ReadinessRequest{})
+			var apiErr *dto.APIError
+			if !errors.As(err, &apiErr) || apiErr.StatusCode() != http.StatusServiceUnavailable {
+				t.Fatalf("GetReadiness() error = %v, want 503", err)
+			}
+			components, ok := apiErr.Details()["components"].([]dto.ComponentStatus)
+			if !ok {
+				t.Fatalf("details = %v", apiErr.Details())
+			}
+			failed := map[string]bool{}
+			for _, c := range components {
+				if c.Status == "error" {
+					if c.Error == "" {
+						t.Errorf("%s: no error message", c.Name)
+					}
+					failed[c.Name] = true
+				}
+			}
+			if !failed["data_dir"] || !failed["table_files"] || !failed["git"] || failed["disk"] {
+				t.Errorf("failed components = %v", failed)
+			}
+			for _, c := range components {
+				if strings.Contains(c.Error, dataDir) {
+					t.Errorf("%s: error %q leaks the path", c.Name, c.Error)
+				}
+			}
+		})
+
+		t.Run("Cached", func(t *testing.T) {
+			dir := t.TempDir()
+			if err := os.Mkdir(filepath.Join(dir, "db"), 0o750); err != nil {
+				t.Fatal(err)
+			}
+			handler := &HealthHandler{Cfg: cfg, Svc: svc, DataDir: dir}
+			if _, err := handler.GetReadiness(t.Context(), &dto.ReadinessRequest{}); err == nil {
+				t.Fatal("want 503 before the repository exists")
+			}
+			if out, err := exec.Command("git", "init", "-q", dir).CombinedOutput(); err != nil {
+				t.Fatalf("git init: %v: %s", err, out)
+			}
+			if _, err := handler.GetReadiness(t.Context(), &dto.ReadinessRequest{}); err == nil {
+				t.Fatal("want the cached 503")
+			}
+			handler.readyAt = time.Time{}
+			if _, err := handler.GetReadiness(t.Context(), &dto.ReadinessRequest{}); err != nil {
+				t.Fatalf("after expiry: %v", err)
+			}
+		})
+	})
 }
@@ -0,0 +1,64 @@
+// Accepts invitations, keeping invitations and memberships consistent.
+
+package handlers
+
+import (
+	"errors"
+
+	"github.com/maruel/ksid"
+	"github.com/maruel/mddb/backend/internal/server/dto"
+	"github.com/maruel/mddb/backend/internal/storage/identity"
+)
+
+// AcceptOrgInvitation makes user userID a member of the organization of inv
+// with the invited role and deletes inv.
+//
+// The invitation and membership tables are separate so the steps cannot be
+// atomic. The invitation is deleted first so it can only be accepted once.
+// When creating the membership fails, the invitation is restored and the call
+// can be retried: the invitation is either fully accepted or still pending.
+func (s *Services) AcceptOrgInvitation(inv *identity.OrganizationInvitation, userID ksid.ID) (*identity.OrganizationMembership, error) {
+	inv = inv.Clone()
+	if err := s.OrgInvitation.Delete(inv.ID); err != nil {
+		return nil, dto.NotFound("invitation")
+	}
+	m, err := s.OrgMembership.Create(userID, inv.OrganizationID, inv.Role)
+	if err != nil {
+		err = dto.InternalWithError("Failed to create organization membership", err)
+		return nil, errors.Join(err, s.OrgInvitation.Restore(inv))
+	}
+	return m, nil
+}
+
+// AcceptWSInvitation makes user userID a member of the workspace of inv with
+// the invited role and deletes inv. orgID is the organization of the
+// workspace; the user is made a member of it if needed.
+//
+// Like AcceptOrgInvitation, the invitation is deleted first. When a step
+// fails, the memberships created so far are deleted and the invitation is
+// restored.
+func (s *Services) AcceptWSInvitation(inv *identity.WorkspaceInvitation, orgID, userID ksid.ID) (*identity.WorkspaceMembership, error) {
+	inv = inv.Clone()
+	if err := s.WSInvitation.Delete(inv.ID); err != nil {
+		return nil, dto.NotFound("invitation")
+	}
+	var orgMembership *identity.OrganizationMembership
+	rollback := func(err error) error {
+		errs := []error{err}
+		if orgMembership != nil {
+			errs = append(errs, s.OrgMembership.Delete(orgMembership.ID))
+		}
+		return errors.Join(append(errs, s.WSInvitation.Restore(inv))...)
+	}
+
+	if _, err := s.OrgMembership.Get(userID, orgID); err != nil {
+		if orgMembership, err = s.OrgMembership.Create(userID, orgID, identity.OrgRoleMember); err != nil {
+			return nil, rollback(dto.InternalWithError("Failed to create organization membership", err))
+		}
+	}
+	m, err := s.WSMembership.Create(userID, inv.WorkspaceID, inv.Role)
+	if err != nil {
+		return nil, rollback(dto.InternalWithError("Failed to create workspace membership", err))
+	}
+	return m, nil
+}
@@ -0,0 +1,145 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"path/filepath"
+	"testing"
+
+	"github.com/maruel/mddb/backend/internal/server/dto"
+	"github.com/maruel/mddb/backend/internal/storage/identity"
+)
+
+func TestAcceptInvitation(t *testing.T) {
+	ctx := t.Context()
+	tempDir := t.TempDir()
+	path := func(name string) string { return filepath.Join(tempDir, name) }
+	must := func(err error) {
+		t.Helper()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	userService, err := identity.NewUserService(path("users.jsonl"))
+	must(err)
+	orgService, err := identity.NewOrganizationService(path("organizations.jsonl"))
+	must(err)
+	wsService, err := identity.NewWorkspaceService(path("workspaces.jsonl"))
+	must(err)
+	orgMemService, err := identity.NewOrganizationMembershipService(path("org_memberships.jsonl"), userService, orgService)
+	must(err)
+	wsMemService, err := identity.NewWorkspaceMembershipService(path("ws_memberships.jsonl"), wsService, orgService)
+	must(err)
+	orgInvService, err := identity.NewOrganizationInvitationService(path("org_invitations.jsonl"))
+	must(err)
+	wsInvService, err := identity.NewWorkspaceInvitationService(path("ws_invitations.jsonl"))
+	must(err)
+	svc := &Services{
+		User:          userService,
+		Organization:  orgService,
+		Workspace:     wsService,
+		OrgInvitation: orgInvService,
+		WSInvitation:  wsInvService,
+		OrgMembership: orgMemService,
+		WSMembership:  wsMemService,
+	}
+
+	owner, err := userService.Create("owner@example.com", "password", "Owner")
+	must(err)
+	org, err := orgService.Create(ctx, "Org", "billing@example.com")
+	must(err)
+	_, err = orgMemService.Create(owner.ID, org.ID, identity.OrgRoleOwner)
+	must(err)
+	ws, err := wsService.Create(ctx, org.ID, "WS")
+	must(err)
+	_, err = wsMemService.Create(owner.ID, ws.ID, identity.WSRoleAdmin)
+	must(err)
+
+	t.Run("Org", func(t *testing.T) {
+		joe, err := userService.Create("joe@example.com", "password", "Joe")
+		must(err)
+		inv, err := orgInvService.Create(joe.Email, org.ID, identity.OrgRoleAdmin, owner.ID)
+		must(err)
+		m, err := svc.AcceptOrgInvitation(inv, joe.ID)
+		must(err)
+		if m.Role != identity.OrgRoleAdmin {
+			t.Errorf("role = %s", m.Role)
+		}
+		if _, err := orgInvService.GetByToken(inv.Token); err == nil {
+			t.Error("invitation not deleted")
+		}
+		// The invitation can only be accepted once.
+		var apiErr *dto.APIError
+		if _, err := svc.AcceptOrgInvitation(inv, joe.ID); !errors.As(err, &apiErr) || apiErr.StatusCode() != http.StatusNotFound {
+			t.Errorf("second accept: got %v, want 404", err)
+		}
+	})
+
+	t.Run("OrgMembershipFails", func(t *testing.T) {
+		// The owner is already a member so creating the membership fails.
+		inv, err := orgInvService.Create(owner.Email, org.ID, identity.OrgRoleMember, owner.ID)
+		must(err)
+		if _, err := svc.AcceptOrgInvitation(inv, owner.ID); err == nil {
+			t.Fatal("expected error")
+		}
+		got, err := orgInvService.GetByToken(inv.Token)
+		if err != nil {
+			t.Fatalf("invitation not restored: %v", err)
+		}
+		if *got != *inv {
+			t.Errorf("restored %+v, want %+v", got, inv)
+		}
+		if m, _ := orgMemService.Get(owner.ID, org.ID); m == nil || m.Role != identity.OrgRoleOwner {
+			t.Errorf("membership changed: %+v", m)
+		}
+	})
+
+	t.Run("WS", func(t *testing.T) {
+		ann, err := userService.Create("ann@example.com", "password", "Ann")
+		must(err)
+		inv, err := wsInvService.Create(ann.Email, ws.ID, identity.WSRoleEditor, owner.ID)
+		must(err)
+		m, err := svc.AcceptWSInvitation(inv, org.ID, ann.ID)
+		must(err)
+		if m.Role != identity.WSRoleEditor {
+			t.Errorf("role = %s", m.Role)
+		}
+		if om, err := orgMemService.Get(ann.ID, org.ID); err != nil || om.Role != identity.OrgRoleMember {
+			t.Errorf("org membership = %+v, %v", om, err)
+		}
+		if _, err := wsInvService.GetByToken(inv.Token); err == nil {
+			t.Error("invitation not deleted")
+		}
+	})
+
+	t.Run("WSMembershipFails", func(t *testing.T) {
+		// The workspace is full: the failure happens after the org membership
+		// was created, which must be undone.
+		_, err := orgService.Modify(org.ID, func(o *identity.Organization) error {
+			o.Quotas.MaxMembersPerWorkspace = wsMemService.CountWSMemberships(ws.ID)
+			return nil
+		})
+		must(err)
+		bob, err := userService.Create("bob@example.com", "password", "Bob")
+		must(err)
+		inv, err := wsInvService.Create(bob.Email, ws.ID, identity.WSRoleViewer, owner.ID)
+		must(err)
+		if _, err := svc.AcceptWSInvitation(inv, org.ID, bob.ID); err == nil {
+			t.Fatal("expected error")
+		}
+		got, err := wsInvService.GetByToken(inv.Token)
+		if err != nil {
+			t.Fatalf("invitation not restored: %v", err)
+		}
+		if *got != *inv {
+			t.Errorf("restored %+v, want %+v", got, inv)
+		}
+		if _, err := orgMemService.Get(bob.ID, org.ID); err == nil {
+			t.Error("org membership not rolled back")
+		}
+		if _, err := wsMemService.Get(bob.ID, ws.ID); err == nil {
+			t.Error("workspace membership created")
+		}
+	})
+}
@@ -4,6 +4,7 @@ package handlers
 
 import (
 	"context"
+	"errors"
 	"log/slog"
 
 	"github.com/maruel/ksid"
@@ -29,25 +30,7 @@ func (h *InvitationHandler) CreateOrgInvitation(ctx context.Context, orgID ksid.
 		return nil, dto.InternalWithError("Failed to create invitation", err)
 	}
 
-	// Send invitation email if configured
-	if h.Svc.Email != nil {
-		org, err := h.Svc.Organization.Get(orgID)
-		if err != nil {
-			slog.WarnContext(ctx, "Failed to get org for invitation email", "err", err, "org_id", orgID)
-		} else {
-			// Determine locale: use request locale, fall back to inviter's settings, then default
-			locale := email.ParseLocale(req.Locale)
-			if req.Locale == "" && user.Settings.Language != "" {
-				locale = email.ParseLocale(user.Settings.Language)
-			}
-			acceptURL := h.Cfg.BaseURL + "/accept-invitation/org?token=" + invitation.Token
-			if err := h.Svc.Email.SendOrgInvitation(ctx, req.Email, org.Name, user.Name, string(req.Role), acceptURL, locale); err != nil {
-				slog.WarnContext(ctx, "Failed to send org invitation email", "err", err, "email", req.Email)
-			} else {
-				slog.InfoContext(ctx, "Org invitation email sent", "email", req.Email, "org_id", orgID, "locale", locale)
-			}
-		}
-	}
+	h.sendOrgInvitationEmail(ctx, invitation, user, req.Locale)
 
 	// Notify the invitee (if they already have an account).
 	if invitee, err := h.Svc.User.GetByEmail(req.Email); err == nil {
@@ -63,6 +46,45 @@ func (h *InvitationHandler) CreateOrgInvitation(ctx context.Context, orgID ksid.
 	return orgInvitationToResponse(invitation), nil
 }
 
+// ResendOrgInvitation extends the expiry of an organization invitation and
+// sends its email again.
+func (h *InvitationHandler) ResendOrgInvitation(ctx context.Context, orgID ksid.ID, user *identity.User, req *dto.ResendOrgInvitationRequest) (*dto.OrgInvitationResponse, error) {
+	inv, err := h.Svc.OrgInvitation.Get(req.InvitationID)
+	if err != nil || inv.OrganizationID != orgID {
+		return nil, dto.NotFound("invitation")
+	}
+	inv, err = h.Svc.OrgInvitation.ResendInvitation(inv.ID)
+	if err != nil {
+		return nil, dto.InternalWithError("Failed to resend invitation", err)
+	}
+	h.sendOrgInvitationEmail(ctx, inv, user, req.Locale)
+	return orgInvitationToResponse(inv), nil
+}
+
+// sendOrgInvitationEmail sends the invitation email if email is configured.
+// Failures are logged since the invitation exists regardless.
+func (h *InvitationHandler) sendOrgInvitationEmail(ctx context.Context, inv *identity.OrganizationInvitation, inviter *identity.User, reqLocale string) {
+	if h.Svc.Email == nil {
+		return
+	}
+	org, err := h.Svc.Organization.Get(inv.OrganizationID)
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to get org for invitation email", "err", err, "org_id", inv.OrganizationID)
+		return
+	}
+	// Determine locale: use request locale, fall back to inviter's settings, then default
+	locale := email.ParseLocale(reqLocale)
+	if reqLocale == "" && inviter.Settings.Language != "" {
+		locale = email.ParseLocale(inviter.Settings.Language)
+	}
+	acceptURL := h.Cfg.BaseURL + "/accept-invitation/org?token=" + inv.Token
+	if err := h.Svc.Email.SendOrgInvitation(ctx, inv.Email, org.Name, inviter.Name, string(inv.Role), acceptURL, locale); err != nil {
+		slog.WarnContext(ctx, "Failed to send org invitation email", "err", err, "email", inv.Email)
+	} else {
+		slog.InfoContext(ctx, "Org invitation email sent", "email", inv.Email, "org_id", inv.OrganizationID, "locale", locale)
+	}
+}
+
 // ListOrgInvitations returns all pending invitations for an organization.
 func (h *InvitationHandler) ListOrgInvitations(ctx context.Context, orgID ksid.ID, _ *identity.User, _ *dto.ListOrgInvitationsRequest) (*dto.ListOrgInvitationsResponse, error) {
 	var responses []dto.OrgInvitationResponse
@@ -138,14 +160,13 @@ func (h *InvitationHandler) AcceptOrgInvitation(ctx context.Context, req *dto.Ac
 	}
 
 	inv, err := h.Svc.OrgInvitation.GetByToken(req.Token)
+	if errors.Is(err, identity.ErrInvitationExpired) {
+		return nil, dto.Expired("invitation")
+	}
 	if err != nil {
 		return nil, dto.NewAPIError(404, dto.ErrorCodeNotFound, "Invitation not found or expired")
 	}
 
-	if storage.Now().After(inv.ExpiresAt) {
-		return nil, dto.Expired("invitation")
-	}
-
 	// Create user or link to existing
 	user, err := h.Svc.User.GetByEmail(inv.Email)
 	if err != nil {
@@ -159,14 +180,9 @@ func (h *InvitationHandler) AcceptOrgInvitation(ctx context.Context, req *dto.Ac
 		}
 	}
 
-	// Create organization membership
-	if _, err = h.Svc.OrgMembership.Create(user.ID, inv.OrganizationID, inv.Role); err != nil {
-		return nil, dto.InternalWithError("Failed to create organization membership", err)
-	}
-
-	// Delete invitation (best effort - membership already created)
-	if err := h.Svc.OrgInvitation.Delete(inv.ID); err != nil {
-		slog.Warn("Failed to delete invitation after acceptance", "invitationID", inv.ID, "error", err)
+	// Create organization membership and delete the invitation
+	if _, err := h.Svc.AcceptOrgInvitation(inv, user.ID); err != nil {
+		return nil, err
 	}
 
 	// Generate token
@@ -239,21 +255,10 @@ func (h *InvitationHandler) AcceptWSInvitation(ctx context.Context, req *dto.Acc
 		}
 	}
 
-	// Ensure user has org membership (as member if not already)
-	if _, err := h.Svc.OrgMembership.Get(user.ID, ws.OrganizationID); err != nil {
-		if _, err = h.Svc.OrgMembership.Create(user.ID, ws.OrganizationID, identity.OrgRoleMember); err != nil {
-			return nil, dto.InternalWithError("Failed to create organization membership", err)
-		}
-	}
-
-	// Create workspace membership
-	if _, err = h.Svc.WSMembership.Create(user.ID, inv.WorkspaceID, inv.Role); err != nil {
-		return nil, dto.InternalWithError("Failed to create workspace membership", err)
-	}
-
-	// Delete invitation (best effort - membership already created)
-	if err := h.Svc.WSInvitation.Delete(inv.ID); err != nil {
-		slog.Warn("Failed to delete invitation after acceptance", "invitationID", inv.ID, "error", err)
+	// Create the memberships (org as member if not already) and delete the
+	// invitation
+	if _, err := h.Svc.AcceptWSInvitation(inv, ws.OrganizationID, user.ID); err != nil {
+		return nil, err
 	}
 
 	// Generate token
@@ -0,0 +1,79 @@
+// Locks out accounts and client IPs after repeated failed logins.
+
+package handlers
+
+import (
+	"context"
+	"log/slog"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/maruel/mddb/backend/internal/server/dto"
+	"github.com/maruel/mddb/backend/internal/server/reqctx"
+)
+
+// loginAccountKey returns the LoginAttemptService key for an account.
+func loginAccountKey(email string) string {
+	return "account:" + strings.ToLower(strings.TrimSpace(email))
+}
+
+// loginIPKey returns the LoginAttemptService key for a client IP.
+func loginIPKey(ip string) string {
+	return "ip:" + ip
+}
+
+// checkLoginLockout returns a 429 error when any of keys is locked out.
+func (s *Services) checkLoginLockout(keys ...string) *dto.APIError {
+	if s.LoginAttempts == nil {
+		return nil
+	}
+	for _, key := range keys {
+		if until := s.LoginAttempts.LockedUntil(key); !until.IsZero() {
+			return dto.RateLimitExceeded(int(math.Ceil(time.Until(until).Seconds())))
+		}
+	}
+	return nil
+}
+
+// recordLoginFailure counts a failed login for the account (may be empty) and
+// the client ip, and logs every lockout it triggers.
+func (s *Services) recordLoginFailure(ctx context.Context, cfg *Config, account, ip string) {
+	if s.LoginAttempts == nil {
+		return
+	}
+	l := cfg.Current().LoginLockout
+	window := time.Duration(l.WindowSeconds) * time.Second
+	lockout := time.Duration(l.LockoutSeconds) * time.Second
+	record := func(key string, maxFailures int) {
+		if maxFailures <= 0 {
+			return
+		}
+		until, err := s.LoginAttempts.RecordFailure(key, maxFailures, window, lockout)
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to record login failure", "error", err, "key", key)
+			return
+		}
+		if !until.IsZero() {
+			slog.WarnContext(ctx, "audit: login lockout", "key", key, "ip", ip, "cc", reqctx.CountryCode(ctx), "until", until)
+		}
+	}
+	if account != "" {
+		record(loginAccountKey(account), l.MaxAccountFailures)
+	}
+	if ip != "" {
+		record(loginIPKey(ip), l.MaxIPFailures)
+	}
+}
+
+// resetLoginFailures forgets the failed logins of account after a successful
+// login. The IP counter is kept so that an attacker cannot clear it by
+// logging into an account of their own between guesses.
+func (s *Services) resetLoginFailures(ctx context.Context, account string) {
+	if s.LoginAttempts == nil {
+		return
+	}
+	if err := s.LoginAttempts.Reset(loginAccountKey(account)); err != nil {
+		slog.ErrorContext(ctx, "Failed to reset login failures", "error", err)
+	}
+}
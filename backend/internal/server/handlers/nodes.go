@@ -78,7 +78,7 @@ func (h *NodeHandler) ListNodeChildren(ctx context.Context, wsID ksid.ID, _ *ide
 	for _, n := range children {
 		responses = append(responses, *nodeToResponse(n))
 	}
-	return &dto.ListNodeChildrenResponse{Nodes: responses}, nil
+	return &dto.ListNodeChildrenResponse{Nodes: responses, Total: len(responses)}, nil
 }
 
 // GetNodeTitles returns a map of node IDs to their titles.
@@ -105,7 +105,10 @@ func (h *NodeHandler) MoveNode(ctx context.Context, wsID ksid.ID, user *identity
 	}
 	author := GitAuthor(user)
 	if err := ws.MoveNode(ctx, req.ID, req.NewParentID, author); err != nil {
-		return nil, dto.InternalWithError("Failed to move node", err)
+		if apiErr := treeLimitError(err, ws.EffectiveQuotas()); apiErr != nil {
+			return nil, apiErr
+		}
+		return nil, contentError(err, "Failed to move node")
 	}
 	h.Svc.PublishEvent(wsID, dto.EventNodeMoved, req.ID, user.ID)
 	return &dto.MoveNodeResponse{Ok: true}, nil
@@ -131,11 +134,11 @@ func (h *NodeHandler) ListNodeVersions(ctx context.Context, wsID ksid.ID, _ *ide
 	if err != nil {
 		return nil, dto.InternalWithError("Failed to get workspace", err)
 	}
-	history, err := ws.GetHistory(ctx, req.ID, req.Limit)
+	history, more, err := ws.GetHistoryBefore(ctx, req.ID, req.Before, req.Limit)
 	if err != nil {
 		return nil, dto.InternalWithError("Failed to get node history", err)
 	}
-	return &dto.ListNodeVersionsResponse{History: commitsToDTO(history)}, nil
+	return &dto.ListNodeVersionsResponse{History: commitsToDTO(history), HasMore: more}, nil
 }
 
 // GetNodeVersion returns a specific version of a node's content.
@@ -190,10 +193,14 @@ func (h *NodeHandler) DeleteNodeAsset(ctx context.Context, wsID ksid.ID, user *i
 	if err != nil {
 		return nil, dto.InternalWithError("Failed to get workspace", err)
 	}
+	if content.ValidateAssetName(req.AssetName) != nil {
+		return nil, dto.BadRequest("invalid_asset_name")
+	}
 	author := GitAuthor(user)
 	if err := ws.DeleteAsset(ctx, req.NodeID, req.AssetName, author); err != nil {
 		return nil, dto.NotFound("asset")
 	}
+	h.Svc.PublishEvent(wsID, dto.EventNodeUpdated, req.NodeID, user.ID)
 	return &dto.DeleteNodeAssetResponse{Ok: true}, nil
 }
 
@@ -210,7 +217,10 @@ func (h *NodeHandler) CreatePage(ctx context.Context, wsID ksid.ID, user *identi
 	author := GitAuthor(user)
 	node, err := ws.CreatePageUnderParent(ctx, req.ParentID, req.Title, req.Content, author)
 	if err != nil {
-		return nil, dto.InternalWithError("Failed to create page", err)
+		if apiErr := treeLimitError(err, ws.EffectiveQuotas()); apiErr != nil {
+			return nil, apiErr
+		}
+		return nil, contentError(err, "Failed to create page")
 	}
 	h.Svc.PublishEvent(wsID, dto.EventNodeCreated, node.ID, user.ID)
 	return &dto.CreatePageResponse{ID: node.ID}, nil
@@ -223,7 +233,7 @@ func (h *NodeHandler) GetPage(ctx context.Context, wsID ksid.ID, _ *identity.Use
 		return nil, dto.InternalWithError("Failed to get workspace", err)
 	}
 
-	node, err := ws.ReadNode(req.ID)
+	node, etag, err := ws.ReadNodeWithETag(req.ID)
 	if err != nil {
 		return nil, dto.NotFound("page")
 	}
@@ -234,9 +244,32 @@ func (h *NodeHandler) GetPage(ctx context.Context, wsID ksid.ID, _ *identity.Use
 		Content:  node.Content,
 		Created:  node.Created,
 		Modified: node.Modified,
+		ETag:     etag,
 	}, nil
 }
 
+// GetPageHTML renders a page's content to sanitized HTML for previews.
+// Links to other pages point to their HTML preview and assets to signed URLs.
+func (h *NodeHandler) GetPageHTML(ctx context.Context, wsID ksid.ID, _ *identity.User, req *dto.GetPageHTMLRequest) (*dto.GetPageHTMLResponse, error) {
+	ws, err := h.Svc.FileStore.GetWorkspaceStore(ctx, wsID)
+	if err != nil {
+		return nil, dto.InternalWithError("Failed to get workspace", err)
+	}
+
+	out, err := ws.RenderHTMLWithOptions(req.ID, content.RenderHTMLOptions{
+		AssetURL: func(nodeID ksid.ID, name string) string {
+			return h.Cfg.GenerateSignedAssetURL(wsID, nodeID, name)
+		},
+	})
+	if errors.Is(err, content.ErrPageNotFound) {
+		return nil, dto.NotFound("page")
+	}
+	if err != nil {
+		return nil, dto.InternalWithError("Failed to render page", err)
+	}
+	return &dto.GetPageHTMLResponse{ID: req.ID, HTML: out}, nil
+}
+
 // UpdatePage updates a page's title and content.
 func (h *NodeHandler) UpdatePage(ctx context.Context, wsID ksid.ID, user *identity.User, req *dto.UpdatePageRequest) (*dto.UpdatePageResponse, error) {
 	ws, err := h.Svc.FileStore.GetWorkspaceStore(ctx, wsID)
@@ -244,12 +277,13 @@ func (h *NodeHandler) UpdatePage(ctx context.Context, wsID ksid.ID, user *identi
 		return nil, dto.InternalWithError("Failed to get workspace", err)
 	}
 	author := GitAuthor(user)
-	node, err := ws.UpdatePage(ctx, req.ID, req.Title, req.Content, author)
+	opts := content.UpdatePageOptions{ExpectedETag: req.ExpectedETag}
+	node, etag, err := ws.UpdatePageWithOptions(ctx, req.ID, req.Title, req.Content, opts, author)
 	if err != nil {
-		return nil, dto.NotFound("page")
+		return nil, contentError(err, "Failed to update page")
 	}
 	h.Svc.PublishEvent(wsID, dto.EventNodeUpdated, node.ID, user.ID)
-	return &dto.UpdatePageResponse{ID: node.ID}, nil
+	return &dto.UpdatePageResponse{ID: node.ID, ETag: etag}, nil
 }
 
 // UpdatePageFrontmatter updates the icon and cover of a page.
@@ -310,7 +344,10 @@ func (h *NodeHandler) CreateTable(ctx context.Context, wsID ksid.ID, user *ident
 	author := GitAuthor(user)
 	node, err := ws.CreateTableUnderParent(ctx, req.ParentID, req.Title, propertiesToEntity(req.Properties), author)
 	if err != nil {
-		return nil, dto.InternalWithError("Failed to create table", err)
+		if apiErr := treeLimitError(err, eq); apiErr != nil {
+			return nil, apiErr
+		}
+		return nil, contentError(err, "Failed to create table")
 	}
 	h.Svc.PublishEvent(wsID, dto.EventNodeCreated, node.ID, user.ID)
 	return &dto.CreateTableUnderParentResponse{ID: node.ID}, nil
@@ -393,15 +430,11 @@ func (h *NodeHandler) ListRecords(ctx context.Context, wsID ksid.ID, _ *identity
 
 	// Fast path: No view, no filters, no sorts -> use optimized paging
 	if req.ViewID.IsZero() && req.Filters == "" && req.Sorts == "" {
-		records, err := ws.ReadRecordsPage(req.ID, req.Offset, req.Limit)
+		records, total, err := ws.ReadRecordsPage(req.ID, req.Offset, req.Limit)
 		if err != nil {
 			return nil, dto.InternalWithError("Failed to list records", err)
 		}
-		recordList := make([]dto.DataRecordResponse, len(records))
-		for i, record := range records {
-			recordList[i] = *dataRecordToResponse(record)
-		}
-		return &dto.ListRecordsResponse{Records: recordList}, nil
+		return recordsPage(records, req.Offset, req.Limit, total), nil
 	}
 
 	// Slow path: Load all records, filter, sort, then page
@@ -472,21 +505,26 @@ func (h *NodeHandler) ListRecords(ctx context.Context, wsID ksid.ID, _ *identity
 	}
 
 	// Page
-	start := req.Offset
-	if start > len(records) {
-		start = len(records)
-	}
-	end := start + req.Limit
-	if end > len(records) {
-		end = len(records)
-	}
-	records = records[start:end]
+	total := len(records)
+	start := min(req.Offset, total)
+	end := min(start+req.Limit, total)
+	return recordsPage(records[start:end], req.Offset, req.Limit, total), nil
+}
 
+// recordsPage builds a ListRecordsResponse for the page starting at offset
+// out of total matching records.
+func recordsPage(records []*content.DataRecord, offset, limit, total int) *dto.ListRecordsResponse {
 	recordList := make([]dto.DataRecordResponse, len(records))
 	for i, record := range records {
 		recordList[i] = *dataRecordToResponse(record)
 	}
-	return &dto.ListRecordsResponse{Records: recordList}, nil
+	return &dto.ListRecordsResponse{
+		Records: recordList,
+		Total:   total,
+		Offset:  offset,
+		Limit:   limit,
+		HasMore: offset+len(records) < total,
+	}
 }
 
 // CreateRecord creates a new record in a table.
@@ -519,7 +557,7 @@ func (h *NodeHandler) CreateRecord(ctx context.Context, wsID ksid.ID, user *iden
 
 	author := GitAuthor(user)
 	if err := ws.AppendRecord(ctx, req.ID, record, author); err != nil {
-		return nil, dto.InternalWithError("Failed to create record", err)
+		return nil, contentError(err, "Failed to create record")
 	}
 	h.Svc.PublishRecordEvent(wsID, req.ID, id, user.ID)
 	return &dto.CreateRecordResponse{ID: id}, nil
@@ -569,7 +607,7 @@ func (h *NodeHandler) UpdateRecord(ctx context.Context, wsID ksid.ID, user *iden
 
 	author := GitAuthor(user)
 	if err := ws.UpdateRecord(ctx, req.ID, record, author); err != nil {
-		return nil, dto.InternalWithError("Failed to update record", err)
+		return nil, contentError(err, "Failed to update record")
 	}
 	h.Svc.PublishRecordEvent(wsID, req.ID, req.RID, user.ID)
 	return &dto.UpdateRecordResponse{ID: req.RID}, nil
@@ -611,3 +649,16 @@ func (h *NodeHandler) DeleteRecord(ctx context.Context, wsID ksid.ID, user *iden
 	h.Svc.PublishRecordEvent(wsID, req.ID, req.RID, user.ID)
 	return &dto.DeleteRecordResponse{Ok: true}, nil
 }
+
+// treeLimitError maps node depth and breadth quota errors to a quota exceeded
+// API error. Returns nil for any other error.
+func treeLimitError(err error, q storage.ResourceQuotas) *dto.APIError {
+	switch {
+	case errors.Is(err, content.ErrNodeDepthExceeded):
+		return dto.QuotaExceeded("levels of node nesting", q.MaxNodeDepth)
+	case errors.Is(err, content.ErrTooManyChildren):
+		return dto.QuotaExceeded("children per node", q.MaxChildrenPerNode)
+	default:
+		return nil
+	}
+}
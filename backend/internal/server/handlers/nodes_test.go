@@ -1,6 +1,8 @@
 package handlers
 
 import (
+	"errors"
+	"net/http"
 	"path/filepath"
 	"strings"
 	"testing"
@@ -15,6 +17,13 @@ import (
 
 // testServices creates a Services struct with a FileStore for testing.
 func testServices(t *testing.T) (*Services, ksid.ID) {
+	t.Helper()
+	return testServicesWith(t, func(*identity.Workspace) {})
+}
+
+// testServicesWith is testServices with an extra hook to adjust the test
+// workspace, e.g. its quotas, before the file store is created.
+func testServicesWith(t *testing.T, modify func(*identity.Workspace)) (*Services, ksid.ID) {
 	t.Helper()
 	tmpDir := t.TempDir()
 
@@ -48,10 +57,11 @@ func testServices(t *testing.T) (*Services, ksid.ID) {
 	_, _ = wsService.Modify(ws.ID, func(w *identity.Workspace) error {
 		w.Quotas.MaxStorageBytes = 1e12
 		w.Quotas.MaxPages = 1000
+		modify(w)
 		return nil
 	})
 
-	serverQuotas := storage.DefaultResourceQuotas()
+	serverQuotas := storage.DefaultServerQuotas()
 	fs, err := content.NewFileStoreService(tmpDir, gitMgr, wsService, orgService, &serverQuotas)
 	if err != nil {
 		t.Fatalf("failed to create FileStoreService: %v", err)
@@ -134,4 +144,206 @@ func TestNodeHandler(t *testing.T) {
 			}
 		})
 	})
+
+	t.Run("ListRecords", func(t *testing.T) {
+		svc, wsID := testServices(t)
+		ctx := t.Context()
+		author := git.Author{Name: "Test", Email: "test@test.com"}
+		if err := svc.FileStore.InitWorkspace(ctx, wsID); err != nil {
+			t.Fatalf("failed to init workspace: %v", err)
+		}
+		wsStore, err := svc.FileStore.GetWorkspaceStore(ctx, wsID)
+		if err != nil {
+			t.Fatalf("failed to get workspace store: %v", err)
+		}
+		props := []content.Property{{Name: "n", Type: content.PropertyTypeNumber}}
+		table, err := wsStore.CreateTableUnderParent(ctx, 0, "Table", props, author)
+		if err != nil {
+			t.Fatalf("failed to create table: %v", err)
+		}
+		for i := range 5 {
+			rec := &content.DataRecord{ID: ksid.NewID(), Data: map[string]any{"n": float64(i)}}
+			if err := wsStore.AppendRecord(ctx, table.ID, rec, author); err != nil {
+				t.Fatalf("failed to append record: %v", err)
+			}
+		}
+		h := &NodeHandler{Svc: svc, Cfg: &Config{}}
+
+		tests := []struct {
+			name      string
+			offset    int
+			limit     int
+			sorts     string
+			wantLen   int
+			wantTotal int
+			wantMore  bool
+		}{
+			{"first page", 0, 2, "", 2, 5, true},
+			{"last page", 4, 2, "", 1, 5, false},
+			{"past end", 10, 2, "", 0, 5, false},
+			{"sorted first page", 0, 3, `[{"property":"n","direction":"desc"}]`, 3, 5, true},
+			{"sorted exact end", 3, 2, `[{"property":"n","direction":"desc"}]`, 2, 5, false},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				req := &dto.ListRecordsRequest{WsID: wsID, ID: table.ID, Offset: tt.offset, Limit: tt.limit, Sorts: tt.sorts}
+				resp, err := h.ListRecords(ctx, wsID, nil, req)
+				if err != nil {
+					t.Fatalf("ListRecords failed: %v", err)
+				}
+				if len(resp.Records) != tt.wantLen || resp.Total != tt.wantTotal || resp.HasMore != tt.wantMore {
+					t.Errorf("got %d records, total=%d, has_more=%v; want %d, %d, %v",
+						len(resp.Records), resp.Total, resp.HasMore, tt.wantLen, tt.wantTotal, tt.wantMore)
+				}
+				if resp.Offset != tt.offset || resp.Limit != tt.limit {
+					t.Errorf("got offset=%d, limit=%d; want %d, %d", resp.Offset, resp.Limit, tt.offset, tt.limit)
+				}
+			})
+		}
+	})
+
+	t.Run("ListNodeChildren", func(t *testing.T) {
+		svc, wsID := testServices(t)
+		ctx := t.Context()
+		author := git.Author{Name: "Test", Email: "test@test.com"}
+		if err := svc.FileStore.InitWorkspace(ctx, wsID); err != nil {
+			t.Fatalf("failed to init workspace: %v", err)
+		}
+		wsStore, err := svc.FileStore.GetWorkspaceStore(ctx, wsID)
+		if err != nil {
+			t.Fatalf("failed to get workspace store: %v", err)
+		}
+		for range 3 {
+			if _, err := wsStore.CreateNode(ctx, "Page", content.NodeTypeDocument, 0, author); err != nil {
+				t.Fatalf("failed to create node: %v", err)
+			}
+		}
+		h := &NodeHandler{Svc: svc, Cfg: &Config{}}
+		resp, err := h.ListNodeChildren(ctx, wsID, nil, &dto.ListNodeChildrenRequest{WsID: wsID})
+		if err != nil {
+			t.Fatalf("ListNodeChildren failed: %v", err)
+		}
+		if len(resp.Nodes) != 3 || resp.Total != 3 || resp.HasMore {
+			t.Errorf("got %d nodes, total=%d, has_more=%v; want 3, 3, false", len(resp.Nodes), resp.Total, resp.HasMore)
+		}
+	})
+
+	t.Run("GetPageHTML", func(t *testing.T) {
+		svc, wsID := testServices(t)
+		ctx := t.Context()
+		author := git.Author{Name: "Test", Email: "test@test.com"}
+		if err := svc.FileStore.InitWorkspace(ctx, wsID); err != nil {
+			t.Fatalf("failed to init workspace: %v", err)
+		}
+		wsStore, err := svc.FileStore.GetWorkspaceStore(ctx, wsID)
+		if err != nil {
+			t.Fatalf("failed to get workspace store: %v", err)
+		}
+		page, err := wsStore.CreatePageUnderParent(ctx, 0, "Page", "# Hi\n\n![img](img.png)\n\n<script>x</script>", author)
+		if err != nil {
+			t.Fatalf("failed to create page: %v", err)
+		}
+		h := &NodeHandler{Svc: svc, Cfg: &Config{}}
+		resp, err := h.GetPageHTML(ctx, wsID, nil, &dto.GetPageHTMLRequest{WsID: wsID, ID: page.ID})
+		if err != nil {
+			t.Fatalf("GetPageHTML failed: %v", err)
+		}
+		if want := `<img src="/assets/` + wsID.String() + "/" + page.ID.String() + "/img.png?sig="; !strings.Contains(resp.HTML, want) {
+			t.Errorf("HTML = %q, want signed asset URL %q", resp.HTML, want)
+		}
+		if strings.Contains(resp.HTML, "<script") {
+			t.Errorf("HTML = %q, want script escaped", resp.HTML)
+		}
+		if _, err := h.GetPageHTML(ctx, wsID, nil, &dto.GetPageHTMLRequest{WsID: wsID, ID: ksid.NewID()}); err == nil {
+			t.Error("GetPageHTML of a missing page should fail")
+		}
+	})
+
+	t.Run("UpdatePageConflict", func(t *testing.T) {
+		svc, wsID := testServices(t)
+		ctx := t.Context()
+		if err := svc.FileStore.InitWorkspace(ctx, wsID); err != nil {
+			t.Fatalf("failed to init workspace: %v", err)
+		}
+		user := &identity.User{ID: ksid.NewID(), Name: "Test", Email: "test@test.com"}
+		h := &NodeHandler{Svc: svc, Cfg: &Config{}}
+		created, err := h.CreatePage(ctx, wsID, user, &dto.CreatePageRequest{WsID: wsID, Title: "Page"})
+		if err != nil {
+			t.Fatalf("CreatePage failed: %v", err)
+		}
+		page, err := h.GetPage(ctx, wsID, nil, &dto.GetPageRequest{WsID: wsID, ID: created.ID})
+		if err != nil {
+			t.Fatalf("GetPage failed: %v", err)
+		}
+		req := &dto.UpdatePageRequest{WsID: wsID, ID: created.ID, Title: "Page", Content: "first", ExpectedETag: page.ETag}
+		first, err := h.UpdatePage(ctx, wsID, user, req)
+		if err != nil {
+			t.Fatalf("UpdatePage failed: %v", err)
+		}
+		if first.ETag == "" || first.ETag == page.ETag {
+			t.Errorf("ETag = %q after update, want a new tag", first.ETag)
+		}
+		req.Content = "second"
+		_, err = h.UpdatePage(ctx, wsID, user, req)
+		var apiErr *dto.APIError
+		if !errors.As(err, &apiErr) || apiErr.StatusCode() != http.StatusConflict {
+			t.Fatalf("stale UpdatePage: got %v, want 409", err)
+		}
+		if d := apiErr.Details(); d["content"] != "first" || d["etag"] != first.ETag {
+			t.Errorf("conflict details = %v", d)
+		}
+	})
+
+	t.Run("GetPageETag", func(t *testing.T) {
+		svc, wsID := testServices(t)
+		ctx := t.Context()
+		author := git.Author{Name: "Test", Email: "test@test.com"}
+		if err := svc.FileStore.InitWorkspace(ctx, wsID); err != nil {
+			t.Fatalf("failed to init workspace: %v", err)
+		}
+		ws, err := svc.FileStore.GetWorkspaceStore(ctx, wsID)
+		if err != nil {
+			t.Fatalf("failed to get workspace store: %v", err)
+		}
+		node, err := ws.CreateNode(ctx, "Hybrid", content.NodeTypeHybrid, 0, author)
+		if err != nil {
+			t.Fatalf("failed to create node: %v", err)
+		}
+		h := &NodeHandler{Svc: svc, Cfg: &Config{}}
+		etag := func() string {
+			t.Helper()
+			resp, err := h.GetPage(ctx, wsID, nil, &dto.GetPageRequest{WsID: wsID, ID: node.ID})
+			if err != nil {
+				t.Fatalf("GetPage failed: %v", err)
+			}
+			if !strings.HasPrefix(resp.ETag, `"`) || !strings.HasSuffix(resp.ETag, `"`) {
+				t.Fatalf("ETag = %q, want a quoted strong tag", resp.ETag)
+			}
+			return resp.ETag
+		}
+
+		first := etag()
+		if got := etag(); got != first {
+			t.Errorf("ETag changed without edits: %q != %q", got, first)
+		}
+		// Editing only the table part of the hybrid node changes the tag.
+		table, err := ws.ReadTable(node.ID)
+		if err != nil {
+			t.Fatalf("failed to read table: %v", err)
+		}
+		table.Properties = append(table.Properties, content.Property{Name: "Status", Type: content.PropertyTypeText})
+		if err := ws.WriteTable(ctx, table, false, author); err != nil {
+			t.Fatalf("failed to write table: %v", err)
+		}
+		second := etag()
+		if second == first {
+			t.Error("ETag unchanged after editing metadata.json")
+		}
+		if _, err := ws.UpdatePage(ctx, node.ID, "Hybrid", "new content", author); err != nil {
+			t.Fatalf("failed to update page: %v", err)
+		}
+		if etag() == second {
+			t.Error("ETag unchanged after editing index.md")
+		}
+	})
 }
@@ -3,14 +3,20 @@
 package handlers
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"fmt"
 	"log/slog"
+	"net/http"
+	"strconv"
 	"sync"
 	"time"
 
 	"github.com/maruel/ksid"
 	"github.com/maruel/mddb/backend/internal/notion"
 	"github.com/maruel/mddb/backend/internal/server/dto"
+	"github.com/maruel/mddb/backend/internal/storage/git"
 	"github.com/maruel/mddb/backend/internal/storage/identity"
 )
 
@@ -24,6 +30,40 @@ type importState struct {
 	stats     *notion.ExtractStats
 	cancel    context.CancelFunc
 	startTime time.Time
+	events    [][]byte      // JSON-encoded notion.ProgressUpdate, in order
+	changed   chan struct{} // closed and replaced whenever events or status change
+}
+
+// newImportState returns the state of an import that just started.
+func newImportState(cancel context.CancelFunc) *importState {
+	return &importState{
+		status:    "running",
+		cancel:    cancel,
+		startTime: time.Now(),
+		changed:   make(chan struct{}),
+	}
+}
+
+// Write records one line written by notion.JSONProgress as a progress event.
+func (s *importState) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.events = append(s.events, bytes.Clone(bytes.TrimSpace(p)))
+	s.notifyLocked()
+	return len(p), nil
+}
+
+// notifyLocked wakes up the progress streams. s.mu must be held.
+func (s *importState) notifyLocked() {
+	close(s.changed)
+	s.changed = make(chan struct{})
+}
+
+// importDoneEvent is the data of the final "done" event of a progress stream.
+type importDoneEvent struct {
+	Status  string               `json:"status"`
+	Message string               `json:"message,omitempty"`
+	Stats   *notion.ExtractStats `json:"stats,omitempty"`
 }
 
 // NotionImportHandler handles Notion import requests.
@@ -47,8 +87,8 @@ func NewNotionImportHandler(svc *Services, cfg *Config) *NotionImportHandler {
 // StartImport creates a new workspace and starts an async Notion import.
 func (h *NotionImportHandler) StartImport(ctx context.Context, orgID ksid.ID, user *identity.User, req *dto.NotionImportRequest) (*dto.NotionImportResponse, error) {
 	// Check server-wide workspace quota
-	if h.Cfg.Quotas.MaxWorkspaces > 0 && h.Svc.Workspace.Count() >= h.Cfg.Quotas.MaxWorkspaces {
-		return nil, dto.QuotaExceeded("workspaces", h.Cfg.Quotas.MaxWorkspaces)
+	if limit := h.Cfg.Current().Quotas.MaxWorkspaces; limit > 0 && h.Svc.Workspace.Count() >= limit {
+		return nil, dto.QuotaExceeded("workspaces", limit)
 	}
 
 	// Validate token and get workspace name from Notion
@@ -79,18 +119,14 @@ func (h *NotionImportHandler) StartImport(ctx context.Context, orgID ksid.ID, us
 
 	// Create import state with a context that outlives the HTTP request but preserves values.
 	importCtx, cancel := context.WithCancel(context.WithoutCancel(ctx)) //nolint:gosec // cancel stored in state and called when import completes or is cancelled
-	state := &importState{
-		status:    "running",
-		cancel:    cancel,
-		startTime: time.Now(),
-	}
+	state := newImportState(cancel)
 
 	h.mu.Lock()
 	h.states[ws.ID] = state
 	h.mu.Unlock()
 
 	// Start async import goroutine
-	go h.runImport(importCtx, ws.ID, req.NotionToken, state)
+	go h.runImport(importCtx, ws.ID, req.NotionToken, GitAuthor(user), state)
 
 	return &dto.NotionImportResponse{
 		WorkspaceID:   ws.ID,
@@ -135,7 +171,7 @@ func (h *NotionImportHandler) GetStatus(_ context.Context, orgID ksid.ID, _ *ide
 		resp.Pages = state.stats.Pages
 		resp.Databases = state.stats.Databases
 		resp.Records = state.stats.Records
-		resp.Assets = state.stats.Assets
+		resp.Assets = state.stats.AssetsDownloaded
 		resp.Errors = state.stats.Errors
 		resp.DurationMs = state.stats.Duration.Milliseconds()
 	} else if state.status == "running" {
@@ -162,6 +198,7 @@ func (h *NotionImportHandler) CancelImport(_ context.Context, wsID ksid.ID, _ *i
 	}
 	state.status = "cancelled"
 	state.message = "Import cancelled by user"
+	state.notifyLocked()
 	state.mu.Unlock()
 
 	// Cancel the context to stop the import goroutine
@@ -172,14 +209,31 @@ func (h *NotionImportHandler) CancelImport(_ context.Context, wsID ksid.ID, _ *i
 	return &dto.NotionImportCancelResponse{Ok: true}, nil
 }
 
+// commitImport commits the files written by the Notion writer to the
+// workspace git repository.
+func (h *NotionImportHandler) commitImport(ctx context.Context, wsID ksid.ID, stats *notion.ExtractStats, author git.Author) error {
+	// The import may have been cancelled; committing must still happen.
+	ctx = context.WithoutCancel(ctx)
+	store, err := h.Svc.FileStore.GetWorkspaceStore(ctx, wsID)
+	if err != nil {
+		return err
+	}
+	summary := "notion"
+	if stats != nil {
+		summary = fmt.Sprintf("notion, %d pages and %d databases", stats.Pages, stats.Databases)
+	}
+	return store.CommitImport(ctx, summary, author)
+}
+
 // runImport performs the actual Notion import in the background.
-func (h *NotionImportHandler) runImport(ctx context.Context, wsID ksid.ID, notionToken string, state *importState) {
+func (h *NotionImportHandler) runImport(ctx context.Context, wsID ksid.ID, notionToken string, author git.Author, state *importState) {
 	defer func() {
 		if r := recover(); r != nil {
 			slog.Error("Notion import panic", "wsID", wsID, "err", r)
 			state.mu.Lock()
 			state.status = "failed"
 			state.message = "Internal error during import"
+			state.notifyLocked()
 			state.mu.Unlock()
 		}
 	}()
@@ -192,7 +246,7 @@ func (h *NotionImportHandler) runImport(ctx context.Context, wsID ksid.ID, notio
 	writer := notion.NewWriter(rootDir, wsID.String())
 
 	// Create progress reporter that updates state
-	progress := &stateProgressReporter{state: state}
+	progress := &stateProgressReporter{state: state, json: notion.JSONProgress{Out: state}}
 
 	// Create extractor
 	extractor := notion.NewExtractor(client, writer, progress)
@@ -201,12 +255,29 @@ func (h *NotionImportHandler) runImport(ctx context.Context, wsID ksid.ID, notio
 	opts := notion.ExtractOptions{
 		IncludeContent: true,
 		MaxDepth:       0, // unlimited
+		Concurrency:    notion.DefaultConcurrency,
+	}
+	// Downloaded assets are held to the same limits as uploads.
+	var stats *notion.ExtractStats
+	store, err := h.Svc.FileStore.GetWorkspaceStore(ctx, wsID)
+	if err == nil {
+		opts.MaxAssetBytes = store.EffectiveQuotas().MaxAssetSizeBytes
+		opts.CheckStorage = store.CheckStorageQuota
+		stats, err = extractor.Extract(ctx, opts)
 	}
 
-	stats, err := extractor.Extract(ctx, opts)
+	// The writer bypasses the workspace store; commit what it wrote, even on
+	// failure, so that a restart doesn't discard it.
+	if cerr := h.commitImport(ctx, wsID, stats, author); cerr != nil {
+		slog.Error("Failed to commit Notion import", "wsID", wsID, "err", cerr)
+		if err == nil {
+			err = cerr
+		}
+	}
 
 	state.mu.Lock()
 	defer state.mu.Unlock()
+	defer state.notifyLocked()
 
 	// Check if cancelled
 	if state.status == "cancelled" {
@@ -231,36 +302,117 @@ func (h *NotionImportHandler) runImport(ctx context.Context, wsID ksid.ID, notio
 	slog.Info("Notion import completed", "wsID", wsID, "pages", stats.Pages, "databases", stats.Databases)
 }
 
+// StreamImport streams the progress of the Notion import into the workspace
+// as Server-Sent Events: a "progress" event per notion.ProgressUpdate, then a
+// "done" event once the import stops. Progress events are numbered so that a
+// client reconnecting with Last-Event-ID resumes where it left off; a client
+// connecting later replays the whole history. InjectTokenFromQuery must wrap
+// this handler before auth middleware.
+func (h *NotionImportHandler) StreamImport(w http.ResponseWriter, r *http.Request) {
+	wsID, err := ksid.Parse(r.PathValue("wsID"))
+	if err != nil {
+		http.Error(w, "invalid workspace ID", http.StatusBadRequest)
+		return
+	}
+	h.mu.Lock()
+	state := h.states[wsID]
+	h.mu.Unlock()
+	if state == nil {
+		http.Error(w, "import not found", http.StatusNotFound)
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming not supported", http.StatusInternalServerError)
+		return
+	}
+	next := 0
+	if id, err := strconv.Atoi(r.Header.Get("Last-Event-ID")); err == nil && id > 0 {
+		next = id
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	flusher.Flush()
+
+	ticker := time.NewTicker(sseKeepAliveInterval)
+	defer ticker.Stop()
+
+	ctx := r.Context()
+	for {
+		state.mu.Lock()
+		events := state.events[min(next, len(state.events)):]
+		changed := state.changed
+		var done []byte
+		if state.status != "running" {
+			done, _ = json.Marshal(importDoneEvent{Status: state.status, Message: state.message, Stats: state.stats})
+		}
+		state.mu.Unlock()
+
+		for _, data := range events {
+			next++
+			if _, err := fmt.Fprintf(w, "id: %d\nevent: progress\ndata: %s\n\n", next, data); err != nil {
+				return
+			}
+		}
+		if done != nil {
+			_, _ = fmt.Fprintf(w, "event: done\ndata: %s\n\n", done)
+			flusher.Flush()
+			return
+		}
+		flusher.Flush()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-changed:
+		case <-ticker.C:
+			if _, err := w.Write([]byte(": keepalive\n\n")); err != nil {
+				return
+			}
+		}
+	}
+}
+
 // stateProgressReporter implements notion.ProgressReporter to update import state.
+// Every update is also recorded as a progress event for StreamImport.
 type stateProgressReporter struct {
 	state *importState
+	json  notion.JSONProgress
 }
 
 func (p *stateProgressReporter) OnStart(total int) {
 	p.state.mu.Lock()
-	defer p.state.mu.Unlock()
 	p.state.total = total
 	p.state.message = "Starting import..."
+	p.state.mu.Unlock()
+	p.json.OnStart(total)
 }
 
-func (p *stateProgressReporter) OnProgress(current int, item string) {
+func (p *stateProgressReporter) OnProgress(current int, item notion.ProgressItem) {
 	p.state.mu.Lock()
-	defer p.state.mu.Unlock()
 	p.state.progress = current
-	p.state.message = item
+	p.state.message = item.Message
+	p.state.mu.Unlock()
+	p.json.OnProgress(current, item)
 }
 
 func (p *stateProgressReporter) OnWarning(msg string) {
 	// Warnings are logged but don't update user-visible message
 	slog.Warn("Notion import warning", "msg", msg)
+	p.json.OnWarning(msg)
 }
 
 func (p *stateProgressReporter) OnError(err error) {
 	slog.Error("Notion import error", "err", err)
+	p.json.OnError(err)
 }
 
 func (p *stateProgressReporter) OnComplete(stats notion.ExtractStats) {
 	p.state.mu.Lock()
-	defer p.state.mu.Unlock()
 	p.state.stats = &stats
+	p.state.mu.Unlock()
+	p.json.OnComplete(stats)
 }
@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/maruel/ksid"
+	"github.com/maruel/mddb/backend/internal/notion"
+)
+
+func TestNotionImportHandler_StreamImport(t *testing.T) {
+	h := NewNotionImportHandler(&Services{}, &Config{})
+	wsID := ksid.NewID()
+	state := newImportState(func() {})
+	h.states[wsID] = state
+	progress := &stateProgressReporter{state: state, json: notion.JSONProgress{Out: state}}
+
+	stream := func(lastEventID string) string {
+		r := httptest.NewRequest(http.MethodGet, "/api/v1/workspaces/"+wsID.String()+"/notion/import/events", http.NoBody)
+		r.SetPathValue("wsID", wsID.String())
+		if lastEventID != "" {
+			r.Header.Set("Last-Event-ID", lastEventID)
+		}
+		w := newFlushRecorder()
+		h.StreamImport(w, r)
+		if ct := w.Header().Get("Content-Type"); ct != "text/event-stream" {
+			t.Fatalf("want Content-Type text/event-stream, got %s", ct)
+		}
+		return w.Body()
+	}
+
+	// Stream while the import runs; the handler returns after the done event.
+	progress.OnStart(2)
+	body := make(chan string)
+	go func() { body <- stream("") }()
+	progress.OnProgress(1, notion.ProgressItem{Phase: notion.PhasePages, ID: "page-a", Message: "Page A"})
+	progress.OnError(errors.New("boom"))
+	progress.OnProgress(2, notion.ProgressItem{Phase: notion.PhasePages, ID: "page-b", Message: "Page B"})
+	progress.OnComplete(notion.ExtractStats{Pages: 2, Errors: 1})
+	state.mu.Lock()
+	state.status = "completed"
+	state.message = "Import completed successfully"
+	state.notifyLocked()
+	state.mu.Unlock()
+
+	got := <-body
+	want := []string{
+		"id: 1\nevent: progress\ndata: {\"type\":\"start\",\"total\":2}\n\n",
+		"id: 2\nevent: progress\ndata: {\"type\":\"progress\",\"current\":1,\"total\":2,\"phase\":\"pages\",\"item_id\":\"page-a\",\"message\":\"Page A\"}\n\n",
+		"id: 3\nevent: progress\ndata: {\"type\":\"error\",\"message\":\"boom\"}\n\n",
+		"id: 4\nevent: progress\ndata: {\"type\":\"progress\",\"current\":2,\"total\":2,\"phase\":\"pages\",\"item_id\":\"page-b\",\"message\":\"Page B\"}\n\n",
+		"id: 5\nevent: progress\ndata: {\"type\":\"complete\",\"stats\":{\"pages\":2,\"databases\":0,\"records\":0,\"assets\":0,\"assets_downloaded\":0,\"errors\":1,\"duration\":0}}\n\n",
+		"event: done\ndata: {\"status\":\"completed\",\"message\":\"Import completed successfully\",\"stats\":{\"pages\":2,\"databases\":0,\"records\":0,\"assets\":0,\"assets_downloaded\":0,\"errors\":1,\"duration\":0}}\n\n",
+	}
+	if w := strings.Join(want, ""); got != w {
+		t.Fatalf("unexpected stream\ngot:\n%s\nwant:\n%s", got, w)
+	}
+
+	// Reconnecting resumes after the last event seen.
+	if got := stream("4"); got != want[4]+want[5] {
+		t.Errorf("resumed stream:\n%s", got)
+	}
+
+	// Unknown import.
+	r := httptest.NewRequest(http.MethodGet, "/", http.NoBody)
+	r.SetPathValue("wsID", ksid.NewID().String())
+	w := newFlushRecorder()
+	h.StreamImport(w, r)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("unknown import: want 404, got %d", w.Code)
+	}
+}
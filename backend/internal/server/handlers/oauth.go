@@ -217,8 +217,15 @@ func (h *OAuthHandler) Callback(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Forged or replayed callbacks count as failed logins for the client IP.
+	if err := h.svc.checkLoginLockout(loginIPKey(reqctx.ClientIP(r.Context()))); err != nil {
+		writeErrorResponse(w, err)
+		return
+	}
+
 	code := r.URL.Query().Get("code")
 	if code == "" {
+		h.svc.recordLoginFailure(r.Context(), h.cfg, "", reqctx.ClientIP(r.Context()))
 		writeErrorResponse(w, dto.MissingField("code"))
 		return
 	}
@@ -226,6 +233,7 @@ func (h *OAuthHandler) Callback(w http.ResponseWriter, r *http.Request) {
 	token, err := config.Exchange(r.Context(), code)
 	if err != nil {
 		slog.ErrorContext(r.Context(), "OAuth: token exchange failed", "error", err, "provider", provider)
+		h.svc.recordLoginFailure(r.Context(), h.cfg, "", reqctx.ClientIP(r.Context()))
 		writeErrorResponse(w, dto.OAuthError("token_exchange"))
 		return
 	}
@@ -339,6 +347,7 @@ func (h *OAuthHandler) Callback(w http.ResponseWriter, r *http.Request) {
 		// This is a linking callback - verify provider matches
 		if linkingProvider != provider {
 			slog.WarnContext(ctx, "OAuth linking: provider mismatch", "expected", linkingProvider, "got", provider)
+			h.svc.recordLoginFailure(ctx, h.cfg, "", reqctx.ClientIP(ctx))
 			writeErrorResponse(w, dto.BadRequest("Provider mismatch"))
 			return
 		}
@@ -461,10 +470,10 @@ func finishOAuthLogin(svc *Services, cfg *Config, w http.ResponseWriter, r *http
 	}
 
 	// Generate JWT token with session tracking
-	clientIP := reqctx.GetClientIP(r)
+	clientIP := reqctx.ClientIP(r.Context())
 	userAgent := r.Header.Get("User-Agent")
 	countryCode := reqctx.CountryCode(r.Context())
-	jwtToken, err := cfg.GenerateTokenWithSession(svc.Session, user, clientIP, userAgent, countryCode)
+	jwtToken, refreshToken, err := cfg.GenerateTokenWithSession(svc.Session, user, clientIP, userAgent, countryCode)
 	if err != nil {
 		slog.ErrorContext(ctx, "OAuth: failed to generate token", "err", err, "userID", user.ID)
 		writeErrorResponse(w, dto.Internal("token_generation"))
@@ -478,7 +487,7 @@ func finishOAuthLogin(svc *Services, cfg *Config, w http.ResponseWriter, r *http
 	}
 
 	slog.InfoContext(ctx, "OAuth: login successful, redirecting with token", "userID", user.ID, "email", user.Email)
-	http.Redirect(w, r, "/?token="+url.QueryEscape(jwtToken), http.StatusFound)
+	http.Redirect(w, r, "/?token="+url.QueryEscape(jwtToken)+"&refresh_token="+url.QueryEscape(refreshToken), http.StatusFound)
 }
 
 // fetchMicrosoftPhoto fetches the user's profile photo from Microsoft Graph API
@@ -3,6 +3,7 @@
 package handlers
 
 import (
+	"cmp"
 	"context"
 	"log/slog"
 
@@ -26,7 +27,7 @@ func (h *OrganizationHandler) GetOrganization(ctx context.Context, orgID ksid.ID
 	}
 	memberCount := h.Svc.OrgMembership.CountOrgMemberships(orgID)
 	workspaceCount := h.Svc.Workspace.CountByOrg(orgID)
-	return organizationToResponse(org, memberCount, workspaceCount, h.Cfg.Quotas.ResourceQuotas), nil
+	return organizationToResponse(org, memberCount, workspaceCount, h.Cfg.Current().Quotas.ResourceQuotas), nil
 }
 
 // UpdateOrgPreferences updates organization-wide preferences/settings and quotas.
@@ -53,7 +54,7 @@ func (h *OrganizationHandler) UpdateOrgPreferences(ctx context.Context, orgID ks
 
 	memberCount := h.Svc.OrgMembership.CountOrgMemberships(orgID)
 	workspaceCount := h.Svc.Workspace.CountByOrg(orgID)
-	return organizationToResponse(org, memberCount, workspaceCount, h.Cfg.Quotas.ResourceQuotas), nil
+	return organizationToResponse(org, memberCount, workspaceCount, h.Cfg.Current().Quotas.ResourceQuotas), nil
 }
 
 // UpdateOrganization updates organization details (name).
@@ -67,7 +68,7 @@ func (h *OrganizationHandler) UpdateOrganization(ctx context.Context, orgID ksid
 	}
 	memberCount := h.Svc.OrgMembership.CountOrgMemberships(orgID)
 	workspaceCount := h.Svc.Workspace.CountByOrg(orgID)
-	return organizationToResponse(org, memberCount, workspaceCount, h.Cfg.Quotas.ResourceQuotas), nil
+	return organizationToResponse(org, memberCount, workspaceCount, h.Cfg.Current().Quotas.ResourceQuotas), nil
 }
 
 // CreateWorkspace creates a new workspace within an organization.
@@ -77,8 +78,8 @@ func (h *OrganizationHandler) CreateWorkspace(ctx context.Context, orgID ksid.ID
 	}
 
 	// Check server-wide workspace quota
-	if h.Cfg.Quotas.MaxWorkspaces > 0 && h.Svc.Workspace.Count() >= h.Cfg.Quotas.MaxWorkspaces {
-		return nil, dto.QuotaExceeded("workspaces", h.Cfg.Quotas.MaxWorkspaces)
+	if limit := h.Cfg.Current().Quotas.MaxWorkspaces; limit > 0 && h.Svc.Workspace.Count() >= limit {
+		return nil, dto.QuotaExceeded("workspaces", limit)
 	}
 
 	// Check org-level workspace quota
@@ -132,7 +133,10 @@ func (h *OrganizationHandler) GetWorkspace(_ context.Context, wsID ksid.ID, _ *i
 }
 
 // UpdateWorkspace updates workspace details (name, quotas, and/or settings).
-func (h *OrganizationHandler) UpdateWorkspace(_ context.Context, wsID ksid.ID, _ *identity.User, req *dto.UpdateWorkspaceRequest) (*dto.WorkspaceResponse, error) {
+//
+// Changing the link style rewrites the links stored in every page.
+func (h *OrganizationHandler) UpdateWorkspace(ctx context.Context, wsID ksid.ID, user *identity.User, req *dto.UpdateWorkspaceRequest) (*dto.WorkspaceResponse, error) {
+	linkStyleChanged := false
 	ws, err := h.Svc.Workspace.Modify(wsID, func(ws *identity.Workspace) error {
 		if req.Name != "" {
 			ws.Name = req.Name
@@ -141,7 +145,9 @@ func (h *OrganizationHandler) UpdateWorkspace(_ context.Context, wsID ksid.ID, _
 			ws.Quotas = workspaceQuotasToEntity(*req.Quotas)
 		}
 		if req.Settings != nil {
+			prev := cmp.Or(ws.Settings.LinkStyle, identity.LinkStyleRelative)
 			ws.Settings = workspaceSettingsToEntity(*req.Settings)
+			linkStyleChanged = cmp.Or(ws.Settings.LinkStyle, identity.LinkStyleRelative) != prev
 		}
 		return nil
 	})
@@ -153,6 +159,15 @@ func (h *OrganizationHandler) UpdateWorkspace(_ context.Context, wsID ksid.ID, _
 	if req.Quotas != nil {
 		h.Svc.FileStore.InvalidateWorkspaceStore(wsID)
 	}
+	if linkStyleChanged {
+		store, err := h.Svc.FileStore.GetWorkspaceStore(ctx, wsID)
+		if err != nil {
+			return nil, dto.InternalWithError("Failed to get workspace", err)
+		}
+		if _, err := store.RewriteLinks(ctx, GitAuthor(user)); err != nil {
+			return nil, dto.InternalWithError("Failed to rewrite links", err)
+		}
+	}
 
 	org, err := h.Svc.Organization.Get(ws.OrganizationID)
 	if err != nil {
@@ -168,5 +183,5 @@ func (h *OrganizationHandler) UpdateWorkspace(_ context.Context, wsID ksid.ID, _
 // imposed by the server and organization layers (ignoring the workspace layer).
 // This is the ceiling that a workspace's resource quotas cannot exceed.
 func (h *OrganizationHandler) workspaceParentLimits(org *identity.Organization) storage.ResourceQuotas {
-	return storage.EffectiveQuotas(h.Cfg.Quotas.ResourceQuotas, org.Quotas.ResourceQuotas, storage.AllInheritResourceQuotas())
+	return storage.EffectiveQuotas(h.Cfg.Current().Quotas.ResourceQuotas, org.Quotas.ResourceQuotas, storage.AllInheritResourceQuotas())
 }
@@ -18,15 +18,15 @@ type SearchHandler struct {
 
 // Search performs a full-text search across all nodes.
 func (h *SearchHandler) Search(ctx context.Context, wsID ksid.ID, _ *identity.User, req *dto.SearchRequest) (*dto.SearchResponse, error) {
-	results, err := h.Svc.Search.Search(ctx, wsID, content.SearchOptions{
-		Query:       req.Query,
-		Limit:       req.Limit,
-		MatchTitle:  req.MatchTitle,
-		MatchBody:   req.MatchBody,
-		MatchFields: req.MatchFields,
-	})
-	if err != nil {
+	if err := h.Svc.Search.EnsureIndexed(ctx, wsID); err != nil {
 		return nil, dto.InternalWithError("Failed to perform search", err)
 	}
-	return &dto.SearchResponse{Results: searchResultsToDTO(results)}, nil
+	hits := h.Svc.Search.SearchWithOptions(wsID, req.Query, content.SearchOptions{
+		MatchTitle: req.MatchTitle,
+		MatchBody:  req.MatchBody,
+	})
+	total := len(hits)
+	// Only the best Limit hits are returned; there is no next page.
+	hits = hits[:min(total, req.Limit)]
+	return &dto.SearchResponse{Results: searchHitsToDTO(hits), Total: total, HasMore: total > len(hits)}, nil
 }
@@ -15,7 +15,7 @@ import (
 
 // ServerHandler handles server configuration endpoints.
 type ServerHandler struct {
-	Cfg              *storage.ServerConfig
+	Cfg              *storage.Config
 	DataDir          string
 	FileStore        *content.FileStoreService // for cache invalidation on quota changes
 	BandwidthLimiter BandwidthUpdater          // for hot-reload of bandwidth limit
@@ -24,13 +24,14 @@ type ServerHandler struct {
 
 // GetConfig returns the current server configuration with masked password.
 func (h *ServerHandler) GetConfig(ctx context.Context, _ *identity.User, _ *dto.ServerConfigRequest) (*dto.ServerConfigResponse, error) {
-	rq := h.Cfg.Quotas.ResourceQuotas
+	cfg := h.Cfg.Current()
+	rq := cfg.Quotas.ResourceQuotas
 	return &dto.ServerConfigResponse{
 		SMTP: dto.SMTPConfigResponse{
-			Host:     h.Cfg.SMTP.Host,
-			Port:     h.Cfg.SMTP.Port,
-			Username: h.Cfg.SMTP.Username,
-			From:     h.Cfg.SMTP.From,
+			Host:     cfg.SMTP.Host,
+			Port:     cfg.SMTP.Port,
+			Username: cfg.SMTP.Username,
+			From:     cfg.SMTP.From,
 			// Password intentionally omitted (masked)
 		},
 		Quotas: dto.QuotasConfigResponse{
@@ -41,26 +42,32 @@ func (h *ServerHandler) GetConfig(ctx context.Context, _ *identity.User, _ *dto.
 				MaxAssetSizeBytes:     rq.MaxAssetSizeBytes,
 				MaxTablesPerWorkspace: rq.MaxTablesPerWorkspace,
 				MaxColumnsPerTable:    rq.MaxColumnsPerTable,
+				MaxNodeDepth:          rq.MaxNodeDepth,
+				MaxChildrenPerNode:    rq.MaxChildrenPerNode,
 			},
-			MaxRequestBodyBytes:   h.Cfg.Quotas.MaxRequestBodyBytes,
-			MaxSessionsPerUser:    h.Cfg.Quotas.MaxSessionsPerUser,
-			MaxOrganizations:      h.Cfg.Quotas.MaxOrganizations,
-			MaxWorkspaces:         h.Cfg.Quotas.MaxWorkspaces,
-			MaxUsers:              h.Cfg.Quotas.MaxUsers,
-			MaxTotalStorageBytes:  h.Cfg.Quotas.MaxTotalStorageBytes,
-			MaxEgressBandwidthBps: h.Cfg.Quotas.MaxEgressBandwidthBps,
+			MaxRequestBodyBytes:   cfg.Quotas.MaxRequestBodyBytes,
+			MaxSessionsPerUser:    cfg.Quotas.MaxSessionsPerUser,
+			MaxOrganizations:      cfg.Quotas.MaxOrganizations,
+			MaxWorkspaces:         cfg.Quotas.MaxWorkspaces,
+			MaxUsers:              cfg.Quotas.MaxUsers,
+			MaxTotalStorageBytes:  cfg.Quotas.MaxTotalStorageBytes,
+			MaxEgressBandwidthBps: cfg.Quotas.MaxEgressBandwidthBps,
+			MinFreeDiskBytes:      cfg.Quotas.MinFreeDiskBytes,
 		},
 		RateLimits: dto.RateLimitsConfigResponse{
-			AuthRatePerMin:       h.Cfg.RateLimits.AuthRatePerMin,
-			WriteRatePerMin:      h.Cfg.RateLimits.WriteRatePerMin,
-			ReadAuthRatePerMin:   h.Cfg.RateLimits.ReadAuthRatePerMin,
-			ReadUnauthRatePerMin: h.Cfg.RateLimits.ReadUnauthRatePerMin,
+			AuthRatePerMin:       cfg.RateLimits.AuthRatePerMin,
+			WriteRatePerMin:      cfg.RateLimits.WriteRatePerMin,
+			ReadAuthRatePerMin:   cfg.RateLimits.ReadAuthRatePerMin,
+			ReadUnauthRatePerMin: cfg.RateLimits.ReadUnauthRatePerMin,
 		},
 	}, nil
 }
 
 // UpdateConfig updates the server configuration and saves to disk.
 func (h *ServerHandler) UpdateConfig(ctx context.Context, _ *identity.User, req *dto.UpdateServerConfigRequest) (*dto.UpdateServerConfigResponse, error) {
+	// Modify a copy; the current configuration is shared with in-flight requests.
+	cfg := *h.Cfg.Current()
+
 	// Update SMTP if provided
 	if req.SMTP != nil {
 		newSMTP := email.Config{
@@ -72,13 +79,13 @@ func (h *ServerHandler) UpdateConfig(ctx context.Context, _ *identity.User, req
 		}
 		// If password is empty, preserve existing password
 		if newSMTP.Password == "" {
-			newSMTP.Password = h.Cfg.SMTP.Password
+			newSMTP.Password = cfg.SMTP.Password
 		}
 		// Validate the new SMTP config
 		if err := newSMTP.Validate(); err != nil {
 			return nil, dto.InvalidField("smtp", err.Error())
 		}
-		h.Cfg.SMTP = newSMTP
+		cfg.SMTP = newSMTP
 	}
 
 	// Update quotas if provided
@@ -91,6 +98,8 @@ func (h *ServerHandler) UpdateConfig(ctx context.Context, _ *identity.User, req
 				MaxAssetSizeBytes:     req.Quotas.MaxAssetSizeBytes,
 				MaxTablesPerWorkspace: req.Quotas.MaxTablesPerWorkspace,
 				MaxColumnsPerTable:    req.Quotas.MaxColumnsPerTable,
+				MaxNodeDepth:          req.Quotas.MaxNodeDepth,
+				MaxChildrenPerNode:    req.Quotas.MaxChildrenPerNode,
 			},
 			MaxRequestBodyBytes:   req.Quotas.MaxRequestBodyBytes,
 			MaxSessionsPerUser:    req.Quotas.MaxSessionsPerUser,
@@ -99,17 +108,13 @@ func (h *ServerHandler) UpdateConfig(ctx context.Context, _ *identity.User, req
 			MaxUsers:              req.Quotas.MaxUsers,
 			MaxTotalStorageBytes:  req.Quotas.MaxTotalStorageBytes,
 			MaxEgressBandwidthBps: req.Quotas.MaxEgressBandwidthBps,
+			MinFreeDiskBytes:      req.Quotas.MinFreeDiskBytes,
 		}
 		// Validate the new quotas
 		if err := newQuotas.Validate(); err != nil {
 			return nil, dto.InvalidField("quotas", err.Error())
 		}
-		h.Cfg.Quotas = newQuotas
-
-		// Invalidate all cached workspace stores so effective quotas are recomputed.
-		if h.FileStore != nil {
-			h.FileStore.InvalidateAllStores()
-		}
+		cfg.Quotas = newQuotas
 	}
 
 	// Update rate limits if provided
@@ -124,28 +129,36 @@ func (h *ServerHandler) UpdateConfig(ctx context.Context, _ *identity.User, req
 		if err := newRateLimits.Validate(); err != nil {
 			return nil, dto.InvalidField("rate_limits", err.Error())
 		}
-		h.Cfg.RateLimits = newRateLimits
+		cfg.RateLimits = newRateLimits
 	}
 
 	// Save to disk
-	if err := h.Cfg.Save(h.DataDir); err != nil {
+	if err := cfg.Save(h.DataDir); err != nil {
 		return nil, dto.Internal(fmt.Sprintf("failed to save config: %v", err))
 	}
+	h.Cfg.Store(&cfg)
+	return &dto.UpdateServerConfigResponse{Ok: true}, nil
+}
 
-	// Hot-reload bandwidth limiter if quotas were updated
-	if req.Quotas != nil && h.BandwidthLimiter != nil {
-		h.BandwidthLimiter.Update(h.Cfg.Quotas.MaxEgressBandwidthBps)
+// ApplyConfig pushes a changed configuration into the services that copied
+// it at construction. It is registered with storage.Config.OnChange so that
+// both UpdateConfig and edits of server_config.json take effect.
+func (h *ServerHandler) ApplyConfig(prev, cur *storage.ServerConfig) {
+	if cur.Quotas != prev.Quotas {
+		// Cached workspace stores hold the effective quotas.
+		if h.FileStore != nil {
+			h.FileStore.SetServerQuotas(&cur.Quotas)
+		}
+		if h.BandwidthLimiter != nil {
+			h.BandwidthLimiter.Update(cur.Quotas.MaxEgressBandwidthBps)
+		}
 	}
-
-	// Hot-reload rate limiters if rate limits were updated
-	if req.RateLimits != nil && h.RateLimiters != nil {
+	if cur.RateLimits != prev.RateLimits && h.RateLimiters != nil {
 		h.RateLimiters.Update(
-			h.Cfg.RateLimits.AuthRatePerMin,
-			h.Cfg.RateLimits.WriteRatePerMin,
-			h.Cfg.RateLimits.ReadAuthRatePerMin,
-			h.Cfg.RateLimits.ReadUnauthRatePerMin,
+			cur.RateLimits.AuthRatePerMin,
+			cur.RateLimits.WriteRatePerMin,
+			cur.RateLimits.ReadAuthRatePerMin,
+			cur.RateLimits.ReadUnauthRatePerMin,
 		)
 	}
-
-	return &dto.UpdateServerConfigResponse{Ok: true}, nil
 }
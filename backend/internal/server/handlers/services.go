@@ -8,6 +8,7 @@ import (
 	"encoding/hex"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -35,12 +36,14 @@ type Services struct {
 	OrgMembership    *identity.OrganizationMembershipService
 	WSMembership     *identity.WorkspaceMembershipService
 	Session          *identity.SessionService
+	LoginAttempts    *identity.LoginAttemptService      // may be nil
 	EmailVerif       *identity.EmailVerificationService // may be nil
 	Email            *email.Service                     // may be nil
 	RootRepo         *git.RootRepo
 	SyncService      *syncsvc.Service                  // may be nil
 	Notification     *identity.NotificationService     // may be nil
 	PushSubscription *identity.PushSubscriptionService // may be nil
+	Audit            *identity.AuditService            // may be nil
 	Broker           *sse.Broker
 }
 
@@ -55,8 +58,12 @@ type RateLimitsUpdater interface {
 }
 
 // Config holds configuration values needed by handlers.
+//
+// The embedded ServerConfig is the configuration at startup. Use Current for
+// the settings that are reloaded at runtime, like quotas.
 type Config struct {
 	storage.ServerConfig
+	Live      *storage.Config // Reloaded server configuration; nil in tests uses ServerConfig
 	BaseURL   string
 	Version   string
 	GoVersion string
@@ -64,6 +71,14 @@ type Config struct {
 	Dirty     bool
 }
 
+// Current returns the current server configuration.
+func (c *Config) Current() *storage.ServerConfig {
+	if c.Live != nil {
+		return c.Live.Current()
+	}
+	return &c.ServerConfig
+}
+
 // AssetURLExpiry is the default duration for which signed asset URLs are valid.
 const AssetURLExpiry = 1 * time.Hour
 
@@ -116,54 +131,120 @@ func (s *Services) PublishRecordEvent(wsID, nodeID, recordID, actorID ksid.ID) {
 	})
 }
 
-const tokenExpiration = 24 * time.Hour
-
 // GenerateToken generates a JWT token for the given user (without session tracking).
 func (c *Config) GenerateToken(user *identity.User) (string, error) {
 	claims := jwt.MapClaims{
 		"sub":   user.ID,
 		"email": user.Email,
-		"exp":   time.Now().Add(tokenExpiration).Unix(),
+		"exp":   time.Now().Add(c.Auth.AccessTokenTTL()).Unix(),
 		"iat":   time.Now().Unix(),
 	}
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(c.JWTSecret)
+	return c.signToken(claims)
 }
 
-// GenerateTokenWithSession creates a session and generates a JWT token with session ID.
-func (c *Config) GenerateTokenWithSession(sessionSvc *identity.SessionService, user *identity.User, clientIP, userAgent, countryCode string) (string, error) {
-	expiresAt := time.Now().Add(tokenExpiration)
-
+// GenerateTokenWithSession creates a session and generates a JWT token with
+// session ID, along with the refresh token that renews it.
+func (c *Config) GenerateTokenWithSession(sessionSvc *identity.SessionService, user *identity.User, clientIP, userAgent, countryCode string) (token, refreshToken string, err error) {
 	// Pre-generate session ID so we can include it in the JWT
 	sessionID := ksid.NewID()
+	token, err = c.generateSessionToken(user, sessionID)
+	if err != nil {
+		return "", "", err
+	}
+	refreshToken, err = newRefreshToken(sessionID)
+	if err != nil {
+		return "", "", err
+	}
+
+	// Create session with the pre-generated ID and token hashes. The session
+	// lives as long as the refresh token.
+	deviceInfo := userAgent
+	if len(deviceInfo) > 200 {
+		deviceInfo = deviceInfo[:200]
+	}
+	expiresAt := storage.ToTime(time.Now().Add(c.Auth.RefreshTokenTTL()))
+	maxSessions := c.Current().Quotas.MaxSessionsPerUser
+	if _, err := sessionSvc.CreateWithID(sessionID, user.ID, utils.HashToken(token), utils.HashToken(refreshToken), deviceInfo, clientIP, countryCode, expiresAt, maxSessions); err != nil {
+		if errors.Is(err, identity.ErrSessionQuotaExceeded) {
+			return "", "", dto.QuotaExceeded("sessions per user", maxSessions)
+		}
+		return "", "", err
+	}
+	return token, refreshToken, nil
+}
+
+// RefreshSession exchanges refreshToken for a new JWT and refresh token on the
+// same session. The presented refresh token stops working; see
+// identity.SessionService.Rotate.
+func (c *Config) RefreshSession(sessionSvc *identity.SessionService, userSvc *identity.UserService, refreshToken string) (token, newRefresh string, err error) {
+	sessionID, ok := refreshTokenSessionID(refreshToken)
+	if !ok {
+		return "", "", identity.ErrRefreshTokenInvalid
+	}
+	session, err := sessionSvc.Get(sessionID)
+	if err != nil {
+		return "", "", identity.ErrRefreshTokenInvalid
+	}
+	user, err := userSvc.Get(session.UserID)
+	if err != nil {
+		return "", "", identity.ErrRefreshTokenInvalid
+	}
+	if token, err = c.generateSessionToken(user, sessionID); err != nil {
+		return "", "", err
+	}
+	if newRefresh, err = newRefreshToken(sessionID); err != nil {
+		return "", "", err
+	}
+	expiresAt := storage.ToTime(time.Now().Add(c.Auth.RefreshTokenTTL()))
+	if _, err := sessionSvc.Rotate(sessionID, utils.HashToken(refreshToken), utils.HashToken(newRefresh), utils.HashToken(token), expiresAt); err != nil {
+		return "", "", err
+	}
+	return token, newRefresh, nil
+}
 
-	// Build claims with session ID
+// generateSessionToken signs a JWT for user bound to sessionID.
+func (c *Config) generateSessionToken(user *identity.User, sessionID ksid.ID) (string, error) {
+	now := time.Now()
 	claims := jwt.MapClaims{
 		"sub":   user.ID,
 		"email": user.Email,
 		"sid":   sessionID.String(),
-		"exp":   expiresAt.Unix(),
-		"iat":   time.Now().Unix(),
+		"exp":   now.Add(c.Auth.AccessTokenTTL()).Unix(),
+		"iat":   now.Unix(),
 	}
+	return c.signToken(claims)
+}
 
-	// Generate the token
+// signToken signs claims with the current JWT key, naming it in the "kid"
+// header when it has an ID.
+func (c *Config) signToken(claims jwt.MapClaims) (string, error) {
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	tokenString, err := token.SignedString(c.JWTSecret)
+	if c.JWTKeyID != "" {
+		token.Header["kid"] = c.JWTKeyID
+	}
+	return token.SignedString(c.JWTSecret)
+}
+
+// newRefreshToken returns a random refresh token for sessionID, formatted as
+// "<session id>.<secret>" so the session can be found without a scan. Only
+// its hash is stored.
+func newRefreshToken(sessionID ksid.ID) (string, error) {
+	secret, err := utils.GenerateToken(32)
 	if err != nil {
 		return "", err
 	}
+	return sessionID.String() + "." + secret, nil
+}
 
-	// Create session with the pre-generated ID and token hash
-	deviceInfo := userAgent
-	if len(deviceInfo) > 200 {
-		deviceInfo = deviceInfo[:200]
+// refreshTokenSessionID returns the session ID embedded in a refresh token.
+func refreshTokenSessionID(refreshToken string) (ksid.ID, bool) {
+	sid, secret, ok := strings.Cut(refreshToken, ".")
+	if !ok || secret == "" {
+		return 0, false
 	}
-	if _, err := sessionSvc.CreateWithID(sessionID, user.ID, utils.HashToken(tokenString), deviceInfo, clientIP, countryCode, storage.ToTime(expiresAt), c.Quotas.MaxSessionsPerUser); err != nil {
-		if errors.Is(err, identity.ErrSessionQuotaExceeded) {
-			return "", dto.QuotaExceeded("sessions per user", c.Quotas.MaxSessionsPerUser)
-		}
-		return "", err
+	id, err := ksid.Parse(sid)
+	if err != nil || id.IsZero() {
+		return 0, false
 	}
-
-	return tokenString, nil
+	return id, true
 }
@@ -129,3 +129,97 @@ func (f *flushRecorder) Flush() {
 func (f *flushRecorder) Body() string {
 	return f.ResponseRecorder.Body.String()
 }
+
+func TestSSEHandler_PageWriteEvent(t *testing.T) {
+	svc, wsID := testServices(t)
+	svc.Broker = sse.NewBroker()
+	ctx := t.Context()
+	if err := svc.FileStore.InitWorkspace(ctx, wsID); err != nil {
+		t.Fatalf("failed to init workspace: %v", err)
+	}
+	user := &identity.User{ID: ksid.NewID(), Name: "test", Email: "t@t.com"}
+	h := &NodeHandler{Svc: svc, Cfg: &Config{}}
+	created, err := h.CreatePage(ctx, wsID, user, &dto.CreatePageRequest{WsID: wsID, Title: "Page"})
+	if err != nil {
+		t.Fatalf("CreatePage failed: %v", err)
+	}
+
+	sub, cleanup, err := svc.Broker.Subscribe(wsID, user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := h.UpdatePage(ctx, wsID, user, &dto.UpdatePageRequest{WsID: wsID, ID: created.ID, Title: "Page", Content: "edited"}); err != nil {
+		t.Fatalf("UpdatePage failed: %v", err)
+	}
+	select {
+	case msg := <-sub.C:
+		for _, want := range []string{`"type":"node_updated"`, `"node_id":"` + created.ID.String() + `"`, `"actor_id":"` + user.ID.String() + `"`} {
+			if !strings.Contains(string(msg), want) {
+				t.Errorf("event %q is missing %s", msg, want)
+			}
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no event after UpdatePage")
+	}
+
+	// Unsubscribing closes the channel and releases the slot.
+	cleanup()
+	if _, ok := <-sub.C; ok {
+		t.Error("channel still open after cleanup")
+	}
+	if n := svc.Broker.SubscriberCount(wsID); n != 0 {
+		t.Errorf("SubscriberCount = %d after cleanup, want 0", n)
+	}
+}
+
+func TestSSEHandler_AssetDeleteEvent(t *testing.T) {
+	svc, wsID := testServices(t)
+	svc.Broker = sse.NewBroker()
+	ctx := t.Context()
+	if err := svc.FileStore.InitWorkspace(ctx, wsID); err != nil {
+		t.Fatalf("failed to init workspace: %v", err)
+	}
+	user := &identity.User{ID: ksid.NewID(), Name: "test", Email: "t@t.com"}
+	h := &NodeHandler{Svc: svc, Cfg: &Config{}}
+	created, err := h.CreatePage(ctx, wsID, user, &dto.CreatePageRequest{WsID: wsID, Title: "Page"})
+	if err != nil {
+		t.Fatalf("CreatePage failed: %v", err)
+	}
+	ws, err := svc.FileStore.GetWorkspaceStore(ctx, wsID)
+	if err != nil {
+		t.Fatalf("failed to get workspace store: %v", err)
+	}
+	if _, err := ws.SaveAsset(ctx, created.ID, "image.png", []byte("png"), GitAuthor(user)); err != nil {
+		t.Fatalf("failed to save asset: %v", err)
+	}
+
+	sub, cleanup, err := svc.Broker.Subscribe(wsID, user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cleanup()
+	req := &dto.DeleteNodeAssetRequest{WsID: wsID, NodeID: created.ID, AssetName: "image.png"}
+	if _, err := h.DeleteNodeAsset(ctx, wsID, user, req); err != nil {
+		t.Fatalf("DeleteNodeAsset failed: %v", err)
+	}
+	select {
+	case msg := <-sub.C:
+		for _, want := range []string{`"type":"node_updated"`, `"node_id":"` + created.ID.String() + `"`, `"actor_id":"` + user.ID.String() + `"`} {
+			if !strings.Contains(string(msg), want) {
+				t.Errorf("event %q is missing %s", msg, want)
+			}
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no event after DeleteNodeAsset")
+	}
+
+	// A failed deletion publishes nothing.
+	if _, err := h.DeleteNodeAsset(ctx, wsID, user, req); err == nil {
+		t.Fatal("deleting a missing asset succeeded")
+	}
+	select {
+	case msg := <-sub.C:
+		t.Errorf("unexpected event %q", msg)
+	default:
+	}
+}
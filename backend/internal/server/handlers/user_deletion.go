@@ -0,0 +1,182 @@
+// Deletes a user account and the data referencing it across services.
+
+package handlers
+
+import (
+	"cmp"
+	"context"
+	"slices"
+
+	"github.com/maruel/ksid"
+	"github.com/maruel/mddb/backend/internal/server/dto"
+	"github.com/maruel/mddb/backend/internal/storage/identity"
+)
+
+// UserDeletion summarizes what DeleteUser removed or updated.
+type UserDeletion struct {
+	// UserDeleted is false when the account was already gone.
+	UserDeleted       bool
+	OrgMemberships    int
+	WSMemberships     int
+	WSOwnersPromoted  int
+	SessionsRevoked   int
+	Invitations       int
+	Notifications     int
+	ActorsCleared     int
+	PushSubscriptions int
+}
+
+// DeleteUser deletes a user account along with their organization and
+// workspace memberships, sessions, pending invitations addressed to their
+// email, notifications and push subscriptions. Notifications the user
+// triggered for others no longer reference them. Git history keeps the name
+// and email the commits were authored with.
+//
+// When the user is the only admin of a workspace with other members, the
+// longest standing remaining member is promoted to admin. Deleting the last
+// owner of an organization that has other members is refused.
+//
+// The user row is removed last so that a call failing midway can be retried.
+// Calling it again for a deleted user succeeds and reports nothing removed.
+func (s *Services) DeleteUser(ctx context.Context, userID ksid.ID) (*UserDeletion, error) {
+	if userID.IsZero() {
+		return nil, dto.MissingField("user_id")
+	}
+	user, _ := s.User.Get(userID)
+	for m := range s.OrgMembership.IterByUser(userID) {
+		if m.Role == identity.OrgRoleOwner && s.isSoleOrgOwner(m.OrganizationID, userID) {
+			return nil, dto.Forbidden("cannot delete the last owner of an organization with other members")
+		}
+	}
+
+	out := &UserDeletion{}
+	var wsMemberships []*identity.WorkspaceMembership
+	for m := range s.WSMembership.IterByUser(userID) {
+		wsMemberships = append(wsMemberships, m)
+	}
+	for _, m := range wsMemberships {
+		if err := s.WSMembership.Delete(m.ID); err != nil {
+			return out, err
+		}
+		out.WSMemberships++
+		if m.Role != identity.WSRoleAdmin {
+			continue
+		}
+		promoted, err := s.promoteWSSuccessor(m.WorkspaceID)
+		if err != nil {
+			return out, err
+		}
+		if promoted {
+			out.WSOwnersPromoted++
+		}
+	}
+
+	var orgMemberships []ksid.ID
+	for m := range s.OrgMembership.IterByUser(userID) {
+		orgMemberships = append(orgMemberships, m.ID)
+	}
+	for _, id := range orgMemberships {
+		if err := s.OrgMembership.Delete(id); err != nil {
+			return out, err
+		}
+		out.OrgMemberships++
+	}
+
+	n, err := s.Session.RevokeAll(userID, 0)
+	out.SessionsRevoked = n
+	if err != nil {
+		return out, err
+	}
+
+	if user != nil {
+		n, err := s.OrgInvitation.DeleteByEmail(user.Email)
+		out.Invitations += n
+		if err != nil {
+			return out, err
+		}
+		n, err = s.WSInvitation.DeleteByEmail(user.Email)
+		out.Invitations += n
+		if err != nil {
+			return out, err
+		}
+	}
+	if s.EmailVerif != nil {
+		if err := s.EmailVerif.DeleteByUserID(userID); err != nil {
+			return out, err
+		}
+	}
+	if s.Notification != nil {
+		n, err := s.Notification.DeleteByUser(userID)
+		out.Notifications = n
+		if err != nil {
+			return out, err
+		}
+		n, err = s.Notification.ClearActor(userID)
+		out.ActorsCleared = n
+		if err != nil {
+			return out, err
+		}
+	}
+	if s.PushSubscription != nil {
+		var subs []ksid.ID
+		for sub := range s.PushSubscription.ListByUser(userID) {
+			subs = append(subs, sub.ID)
+		}
+		for _, id := range subs {
+			if err := s.PushSubscription.Delete(id); err != nil {
+				return out, err
+			}
+			out.PushSubscriptions++
+		}
+	}
+
+	if user != nil {
+		if err := s.User.Delete(ctx, userID); err != nil {
+			return out, err
+		}
+		out.UserDeleted = true
+	}
+	return out, nil
+}
+
+// isSoleOrgOwner reports whether userID is the only owner of orgID while
+// other members remain.
+func (s *Services) isSoleOrgOwner(orgID, userID ksid.ID) bool {
+	others := false
+	for m := range s.OrgMembership.IterByOrg(orgID) {
+		if m.UserID == userID {
+			continue
+		}
+		if m.Role == identity.OrgRoleOwner {
+			return false
+		}
+		others = true
+	}
+	return others
+}
+
+// promoteWSSuccessor makes the longest standing member of wsID an admin when
+// it has members left but no admin.
+func (s *Services) promoteWSSuccessor(wsID ksid.ID) (bool, error) {
+	var members []*identity.WorkspaceMembership
+	for m := range s.WSMembership.IterByWorkspace(wsID) {
+		if m.Role == identity.WSRoleAdmin {
+			return false, nil
+		}
+		members = append(members, m)
+	}
+	if len(members) == 0 {
+		return false, nil
+	}
+	// IDs are time-sortable.
+	next := slices.MinFunc(members, func(a, b *identity.WorkspaceMembership) int {
+		return cmp.Compare(a.ID, b.ID)
+	})
+	if _, err := s.WSMembership.Modify(next.ID, func(m *identity.WorkspaceMembership) error {
+		m.Role = identity.WSRoleAdmin
+		return nil
+	}); err != nil {
+		return false, err
+	}
+	return true, nil
+}
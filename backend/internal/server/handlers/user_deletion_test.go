@@ -0,0 +1,174 @@
+package handlers
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/maruel/mddb/backend/internal/server/dto"
+	"github.com/maruel/mddb/backend/internal/storage"
+	"github.com/maruel/mddb/backend/internal/storage/identity"
+)
+
+func TestDeleteUser(t *testing.T) {
+	ctx := t.Context()
+	tempDir := t.TempDir()
+	path := func(name string) string { return filepath.Join(tempDir, name) }
+	must := func(err error) {
+		t.Helper()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	userService, err := identity.NewUserService(path("users.jsonl"))
+	must(err)
+	orgService, err := identity.NewOrganizationService(path("organizations.jsonl"))
+	must(err)
+	wsService, err := identity.NewWorkspaceService(path("workspaces.jsonl"))
+	must(err)
+	orgMemService, err := identity.NewOrganizationMembershipService(path("org_memberships.jsonl"), userService, orgService)
+	must(err)
+	wsMemService, err := identity.NewWorkspaceMembershipService(path("ws_memberships.jsonl"), wsService, orgService)
+	must(err)
+	orgInvService, err := identity.NewOrganizationInvitationService(path("org_invitations.jsonl"))
+	must(err)
+	wsInvService, err := identity.NewWorkspaceInvitationService(path("ws_invitations.jsonl"))
+	must(err)
+	sessionService, err := identity.NewSessionService(path("sessions.jsonl"))
+	must(err)
+	notifService, err := identity.NewNotificationService(path("notifications.jsonl"))
+	must(err)
+	svc := &Services{
+		User:          userService,
+		Organization:  orgService,
+		Workspace:     wsService,
+		OrgInvitation: orgInvService,
+		WSInvitation:  wsInvService,
+		OrgMembership: orgMemService,
+		WSMembership:  wsMemService,
+		Session:       sessionService,
+		Notification:  notifService,
+	}
+	cfg := &Config{
+		ServerConfig: storage.ServerConfig{
+			JWTSecret: []byte("test-secret-key-32-bytes-long!!!"),
+			Auth:      storage.AuthConfig{AccessTokenTTLSeconds: 900, RefreshTokenTTLSeconds: 3600},
+		},
+	}
+	authHandler := NewAuthHandler(svc, cfg)
+
+	owner, err := userService.Create("owner@example.com", "password", "Owner")
+	must(err)
+	joe, err := userService.Create("joe@example.com", "password", "Joe")
+	must(err)
+	ann, err := userService.Create("ann@example.com", "password", "Ann")
+	must(err)
+	org, err := orgService.Create(ctx, "Org", "billing@example.com")
+	must(err)
+	_, err = orgMemService.Create(owner.ID, org.ID, identity.OrgRoleOwner)
+	must(err)
+	_, err = orgMemService.Create(joe.ID, org.ID, identity.OrgRoleMember)
+	must(err)
+	_, err = orgMemService.Create(ann.ID, org.ID, identity.OrgRoleMember)
+	must(err)
+	shared, err := wsService.Create(ctx, org.ID, "Shared")
+	must(err)
+	_, err = wsMemService.Create(owner.ID, shared.ID, identity.WSRoleAdmin)
+	must(err)
+	_, err = wsMemService.Create(joe.ID, shared.ID, identity.WSRoleEditor)
+	must(err)
+	own, err := wsService.Create(ctx, org.ID, "Joe's")
+	must(err)
+	_, err = wsMemService.Create(joe.ID, own.ID, identity.WSRoleAdmin)
+	must(err)
+	_, err = wsMemService.Create(ann.ID, own.ID, identity.WSRoleViewer)
+	must(err)
+	other, err := orgService.Create(ctx, "Other", "other@example.com")
+	must(err)
+	_, err = orgInvService.Create(joe.Email, other.ID, identity.OrgRoleMember, owner.ID)
+	must(err)
+	_, err = wsInvService.Create(joe.Email, shared.ID, identity.WSRoleViewer, owner.ID)
+	must(err)
+	_, err = notifService.Create(joe.ID, identity.NotifMemberRemoved, "for joe", "", "", owner.ID)
+	must(err)
+	fromJoe, err := notifService.Create(ann.ID, identity.NotifMemberRemoved, "from joe", "", "", joe.ID)
+	must(err)
+	_, refresh, err := cfg.GenerateTokenWithSession(sessionService, joe, "127.0.0.1", "laptop", "")
+	must(err)
+
+	t.Run("LastOrgOwner", func(t *testing.T) {
+		_, err := svc.DeleteUser(ctx, owner.ID)
+		var apiErr *dto.APIError
+		if !errors.As(err, &apiErr) || apiErr.StatusCode() != 403 {
+			t.Fatalf("DeleteUser(last owner) = %v, want 403", err)
+		}
+		if _, err := userService.Get(owner.ID); err != nil {
+			t.Errorf("owner was deleted: %v", err)
+		}
+	})
+
+	t.Run("Cascade", func(t *testing.T) {
+		got, err := svc.DeleteUser(ctx, joe.ID)
+		if err != nil {
+			t.Fatalf("DeleteUser failed: %v", err)
+		}
+		want := UserDeletion{
+			UserDeleted:      true,
+			OrgMemberships:   1,
+			WSMemberships:    2,
+			WSOwnersPromoted: 1,
+			SessionsRevoked:  1,
+			Invitations:      2,
+			Notifications:    1,
+			ActorsCleared:    1,
+		}
+		if *got != want {
+			t.Errorf("DeleteUser = %+v, want %+v", *got, want)
+		}
+
+		// The user can no longer authenticate.
+		if _, err := userService.Authenticate(joe.Email, "password"); err == nil {
+			t.Error("deleted user can still authenticate")
+		}
+		if _, err := authHandler.Login(ctx, &dto.LoginRequest{Email: joe.Email, Password: "password"}); err == nil {
+			t.Error("deleted user can still log in")
+		}
+		if _, err := authHandler.Refresh(ctx, &dto.RefreshTokenRequest{RefreshToken: refresh}); err == nil {
+			t.Error("deleted user can still refresh a session")
+		}
+
+		// Memberships are gone.
+		for range orgMemService.IterByUser(joe.ID) {
+			t.Error("org membership left behind")
+		}
+		for range wsMemService.IterByUser(joe.ID) {
+			t.Error("workspace membership left behind")
+		}
+
+		// The shared workspace is untouched.
+		if _, err := wsService.Get(shared.ID); err != nil {
+			t.Errorf("shared workspace: %v", err)
+		}
+		if m, err := wsMemService.Get(owner.ID, shared.ID); err != nil || m.Role != identity.WSRoleAdmin {
+			t.Errorf("shared workspace owner: %+v, %v", m, err)
+		}
+		// The workspace Joe administered was handed to its remaining member.
+		if m, err := wsMemService.Get(ann.ID, own.ID); err != nil || m.Role != identity.WSRoleAdmin {
+			t.Errorf("successor: %+v, %v", m, err)
+		}
+		if n, err := notifService.Get(fromJoe.ID); err != nil || !n.ActorID.IsZero() {
+			t.Errorf("notification actor not cleared: %+v, %v", n, err)
+		}
+	})
+
+	t.Run("Idempotent", func(t *testing.T) {
+		got, err := svc.DeleteUser(ctx, joe.ID)
+		if err != nil {
+			t.Fatalf("DeleteUser failed: %v", err)
+		}
+		if *got != (UserDeletion{}) {
+			t.Errorf("second DeleteUser = %+v, want nothing removed", *got)
+		}
+	})
+}
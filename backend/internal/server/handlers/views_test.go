@@ -44,7 +44,7 @@ func setupTestServices(t *testing.T) (*Services, ksid.ID) {
 	gitMgr := git.NewManager(dir, "Test", "test@example.com")
 
 	// Create FileStoreService
-	serverQuotas := storage.DefaultResourceQuotas()
+	serverQuotas := storage.DefaultServerQuotas()
 	fileStore, err := content.NewFileStoreService(dir, gitMgr, wsSvc, orgSvc, &serverQuotas)
 	if err != nil {
 		t.Fatal(err)
@@ -9,6 +9,7 @@ import (
 	"path/filepath"
 	"testing"
 
+	"github.com/maruel/ksid"
 	"github.com/maruel/mddb/backend/internal/server/dto"
 	"github.com/maruel/mddb/backend/internal/server/handlers"
 	"github.com/maruel/mddb/backend/internal/storage"
@@ -29,6 +30,7 @@ type testEnv struct {
 	orgInvService *identity.OrganizationInvitationService
 	wsInvService  *identity.WorkspaceInvitationService
 	fileStore     *content.FileStoreService
+	auditService  *identity.AuditService
 }
 
 func setupTestEnv(t *testing.T) *testEnv {
@@ -71,7 +73,7 @@ func setupTestEnv(t *testing.T) *testEnv {
 
 	gitMgr := git.NewManager(tempDir, "test", "test@example.com")
 
-	serverQuotas := storage.DefaultResourceQuotas()
+	serverQuotas := storage.DefaultServerQuotas()
 	fileStore, err := content.NewFileStoreService(tempDir, gitMgr, wsService, orgService, &serverQuotas)
 	if err != nil {
 		t.Fatalf("NewFileStore: %v", err)
@@ -87,6 +89,11 @@ func setupTestEnv(t *testing.T) *testEnv {
 		t.Fatalf("NewRootRepo: %v", err)
 	}
 
+	auditService, err := identity.NewAuditService(filepath.Join(tempDir, "audit.jsonl"))
+	if err != nil {
+		t.Fatalf("NewAuditService: %v", err)
+	}
+
 	svc := &handlers.Services{
 		FileStore:     fileStore,
 		Search:        content.NewSearchService(fileStore),
@@ -101,6 +108,7 @@ func setupTestEnv(t *testing.T) *testEnv {
 		EmailVerif:    nil, // disabled
 		Email:         nil, // disabled
 		RootRepo:      rootRepo,
+		Audit:         auditService,
 	}
 	serverCfg := &storage.ServerConfig{
 		JWTSecret:  testJWTSecret,
@@ -132,6 +140,7 @@ func setupTestEnv(t *testing.T) *testEnv {
 		orgInvService: orgInvService,
 		wsInvService:  wsInvService,
 		fileStore:     fileStore,
+		auditService:  auditService,
 	}
 }
 
@@ -439,6 +448,40 @@ func TestIntegration(t *testing.T) {
 			t.Errorf("Updated node title: got %q, want %q", getNodeResp2.Title, "Updated Title")
 		}
 
+		// Page reads carry an ETag; a conditional re-fetch gets 304.
+		getPage := func(ifNoneMatch string) (int, string, int) {
+			req, err := http.NewRequest(http.MethodGet, env.server.URL+"/api/v1/workspaces/"+wsID.String()+"/nodes/"+nodeID.String()+"/page", http.NoBody)
+			if err != nil {
+				t.Fatalf("NewRequest: %v", err)
+			}
+			req.Header.Set("Authorization", "Bearer "+token)
+			if ifNoneMatch != "" {
+				req.Header.Set("If-None-Match", ifNoneMatch)
+			}
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("Do request: %v", err)
+			}
+			data, err := io.ReadAll(resp.Body)
+			if closeErr := resp.Body.Close(); closeErr != nil && err == nil {
+				err = closeErr
+			}
+			if err != nil {
+				t.Fatalf("ReadAll/Close: %v", err)
+			}
+			return resp.StatusCode, resp.Header.Get("ETag"), len(data)
+		}
+		status, etag, _ := getPage("")
+		if status != http.StatusOK || etag == "" {
+			t.Fatalf("GET page: got status %d, ETag %q", status, etag)
+		}
+		if status, _, n := getPage(etag); status != http.StatusNotModified || n != 0 {
+			t.Errorf("conditional GET page: got status %d with %d bytes, want 304", status, n)
+		}
+		if status, _, _ := getPage(`"stale"`); status != http.StatusOK {
+			t.Errorf("GET page with stale ETag: got status %d, want 200", status)
+		}
+
 		// Delete node
 		status = env.doJSON(t, http.MethodPost, "/api/v1/workspaces/"+wsID.String()+"/nodes/"+nodeID.String()+"/delete", nil, nil, token)
 		if status != http.StatusOK {
@@ -632,6 +675,81 @@ func TestIntegration(t *testing.T) {
 		}
 	})
 
+	t.Run("AuditLog", func(t *testing.T) {
+		t.Parallel()
+		env := setupTestEnv(t)
+
+		var frankLogin dto.AuthResponse
+		env.doJSON(t, http.MethodPost, "/api/v1/auth/register", dto.RegisterRequest{
+			Email: "frank@example.com", Password: "Pass1234", Name: "Frank",
+		}, &frankLogin, "")
+		frankToken := frankLogin.Token
+		var graceLogin dto.AuthResponse
+		env.doJSON(t, http.MethodPost, "/api/v1/auth/register", dto.RegisterRequest{
+			Email: "grace@example.com", Password: "Pass1234", Name: "Grace",
+		}, &graceLogin, "")
+		graceToken := graceLogin.Token
+
+		var orgResp dto.OrganizationResponse
+		env.doJSON(t, http.MethodPost, "/api/v1/organizations", dto.CreateOrganizationRequest{Name: "Frank's Organization"}, &orgResp, frankToken)
+		orgID := orgResp.ID
+		var wsResp dto.WorkspaceResponse
+		env.doJSON(t, http.MethodPost, "/api/v1/organizations/"+orgID.String()+"/workspaces", dto.CreateWorkspaceRequest{Name: "Audited"}, &wsResp, frankToken)
+		wsID := wsResp.ID
+
+		var pageResp dto.CreatePageResponse
+		if status := env.doJSON(t, http.MethodPost, "/api/v1/workspaces/"+wsID.String()+"/nodes/0/page/create", dto.CreatePageRequest{Title: "Audited Page"}, &pageResp, frankToken); status != http.StatusOK {
+			t.Fatalf("create page: got status %d", status)
+		}
+		deletePath := "/api/v1/workspaces/" + wsID.String() + "/nodes/" + pageResp.ID.String() + "/page/delete"
+		// Grace is not a member of the workspace.
+		if status := env.doJSON(t, http.MethodPost, deletePath, nil, nil, graceToken); status != http.StatusForbidden {
+			t.Fatalf("delete page as non-member: got status %d, want %d", status, http.StatusForbidden)
+		}
+		// Failed authentication is not recorded.
+		if status := env.doJSON(t, http.MethodPost, deletePath, nil, nil, ""); status != http.StatusUnauthorized {
+			t.Fatalf("delete page unauthenticated: got status %d, want %d", status, http.StatusUnauthorized)
+		}
+
+		var auditResp dto.ListAuditLogResponse
+		if status := env.doJSON(t, http.MethodGet, "/api/v1/organizations/"+orgID.String()+"/audit", nil, &auditResp, frankToken); status != http.StatusOK {
+			t.Fatalf("GET audit: got status %d", status)
+		}
+		const createAction = "POST /api/v1/workspaces/{wsID}/nodes/{id}/page/create"
+		const deleteAction = "POST /api/v1/workspaces/{wsID}/nodes/{id}/page/delete"
+		want := []struct {
+			actor  ksid.ID
+			action string
+			target string
+			status int
+		}{
+			{graceLogin.User.ID, deleteAction, pageResp.ID.String(), http.StatusForbidden},
+			{frankLogin.User.ID, createAction, "0", http.StatusOK},
+			{frankLogin.User.ID, "POST /api/v1/organizations/{orgID}/workspaces", orgID.String(), http.StatusOK},
+		}
+		if auditResp.Total != len(want) || len(auditResp.Entries) != len(want) {
+			t.Fatalf("got %d entries (total %d), want %d: %+v", len(auditResp.Entries), auditResp.Total, len(want), auditResp.Entries)
+		}
+		for i, w := range want {
+			e := auditResp.Entries[i]
+			if e.ActorID != w.actor || e.Action != w.action || e.TargetID != w.target || e.Status != w.status {
+				t.Errorf("entry %d: got %+v, want %s %s %s %d", i, e, w.actor, w.action, w.target, w.status)
+			}
+		}
+
+		// Filter by actor.
+		auditResp = dto.ListAuditLogResponse{}
+		env.doJSON(t, http.MethodGet, "/api/v1/organizations/"+orgID.String()+"/audit?actor_id="+graceLogin.User.ID.String(), nil, &auditResp, frankToken)
+		if auditResp.Total != 1 || auditResp.Entries[0].Status != http.StatusForbidden {
+			t.Errorf("filtered by actor: got %+v", auditResp)
+		}
+
+		// Only organization admins can read the audit log.
+		if status := env.doJSON(t, http.MethodGet, "/api/v1/organizations/"+orgID.String()+"/audit", nil, nil, graceToken); status != http.StatusForbidden {
+			t.Errorf("GET audit as non-member: got status %d, want %d", status, http.StatusForbidden)
+		}
+	})
+
 	t.Run("ValidationErrors", func(t *testing.T) {
 		t.Parallel()
 		env := setupTestEnv(t)
@@ -0,0 +1,146 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/maruel/mddb/backend/internal/server/handlers"
+	"github.com/maruel/mddb/backend/internal/server/ratelimit"
+	"github.com/maruel/mddb/backend/internal/storage"
+	"github.com/maruel/mddb/backend/internal/storage/identity"
+)
+
+func TestIPRateLimitMiddleware(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	l := ratelimit.NewIPLimiter(1, 2, 60, 5, []netip.Prefix{netip.MustParsePrefix("10.0.0.1/32")})
+	t.Cleanup(l.Close)
+	h := ipRateLimitMiddleware(l, ok)
+	do := func(method, path, remoteAddr, xff string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, http.NoBody)
+		req.RemoteAddr = remoteAddr
+		if xff != "" {
+			req.Header.Set("X-Forwarded-For", xff)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("burst", func(t *testing.T) {
+		for i := range 5 {
+			if w := do(http.MethodGet, "/api/v1/auth/me", "192.0.2.1:1000", ""); w.Code != http.StatusOK {
+				t.Fatalf("request %d: status %d", i, w.Code)
+			}
+		}
+		w := do(http.MethodGet, "/api/v1/auth/me", "192.0.2.1:1001", "")
+		if w.Code != http.StatusTooManyRequests {
+			t.Fatalf("status = %d, want 429", w.Code)
+		}
+		if got := w.Header().Get("Retry-After"); got == "" || got == "0" {
+			t.Errorf("Retry-After = %q", got)
+		}
+		// Another IP is unaffected.
+		if w := do(http.MethodGet, "/api/v1/auth/me", "192.0.2.2:1000", ""); w.Code != http.StatusOK {
+			t.Errorf("other IP: status %d", w.Code)
+		}
+		// The health check is never limited.
+		if w := do(http.MethodGet, "/api/v1/health", "192.0.2.1:1000", ""); w.Code != http.StatusOK {
+			t.Errorf("health: status %d", w.Code)
+		}
+	})
+
+	t.Run("auth is stricter", func(t *testing.T) {
+		for i := range 2 {
+			if w := do(http.MethodPost, "/api/v1/auth/login", "192.0.2.3:1000", ""); w.Code != http.StatusOK {
+				t.Fatalf("login %d: status %d", i, w.Code)
+			}
+		}
+		if w := do(http.MethodPost, "/api/v1/auth/login", "192.0.2.3:1000", ""); w.Code != http.StatusTooManyRequests {
+			t.Errorf("login: status = %d, want 429", w.Code)
+		}
+		// Other endpoints use a separate bucket.
+		if w := do(http.MethodGet, "/api/v1/auth/me", "192.0.2.3:1000", ""); w.Code != http.StatusOK {
+			t.Errorf("read after login limit: status %d", w.Code)
+		}
+	})
+
+	t.Run("trusted proxy", func(t *testing.T) {
+		for i := range 2 {
+			if w := do(http.MethodPost, "/api/v1/auth/login", "10.0.0.1:1000", "198.51.100.1"); w.Code != http.StatusOK {
+				t.Fatalf("login %d: status %d", i, w.Code)
+			}
+		}
+		if w := do(http.MethodPost, "/api/v1/auth/login", "10.0.0.1:1000", "198.51.100.1"); w.Code != http.StatusTooManyRequests {
+			t.Errorf("proxied client: status = %d, want 429", w.Code)
+		}
+		// Clients behind the same proxy have their own bucket.
+		if w := do(http.MethodPost, "/api/v1/auth/login", "10.0.0.1:1000", "198.51.100.2"); w.Code != http.StatusOK {
+			t.Errorf("other proxied client: status %d", w.Code)
+		}
+		// An untrusted peer can't pick a fresh bucket by forging the header.
+		for range 2 {
+			do(http.MethodPost, "/api/v1/auth/login", "192.0.2.4:1000", "198.51.100.10")
+		}
+		if w := do(http.MethodPost, "/api/v1/auth/login", "192.0.2.4:1000", "198.51.100.11"); w.Code != http.StatusTooManyRequests {
+			t.Errorf("forged header: status = %d, want 429", w.Code)
+		}
+	})
+}
+
+func TestClientIPMiddleware_LoginLockout(t *testing.T) {
+	dir := t.TempDir()
+	userService, err := identity.NewUserService(filepath.Join(dir, "users.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	attempts, err := identity.NewLoginAttemptService(filepath.Join(dir, "login_attempts.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	svc := &handlers.Services{User: userService, LoginAttempts: attempts}
+	cfg := &handlers.Config{ServerConfig: storage.ServerConfig{
+		JWTSecret:    []byte("test-secret-key-32-bytes-long!!!"),
+		LoginLockout: storage.LoginLockout{MaxAccountFailures: 100, MaxIPFailures: 3, WindowSeconds: 60, LockoutSeconds: 60},
+	}}
+	limiters := ratelimit.NewLimiters(ratelimit.ConfigFromStorage(1000, 1000, 1000, 1000))
+	t.Cleanup(limiters.Close)
+	ipLimiter := ratelimit.NewIPLimiter(0, 0, 0, 0, []netip.Prefix{netip.MustParsePrefix("10.0.0.1/32")})
+	t.Cleanup(ipLimiter.Close)
+	h := clientIPMiddleware(ipLimiter, Wrap(handlers.NewAuthHandler(svc, cfg).Login, cfg, limiters))
+	login := func(remoteAddr, xff string) int {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/login", strings.NewReader(`{"email":"nobody@example.com","password":"x"}`))
+		req.RemoteAddr = remoteAddr
+		if xff != "" {
+			req.Header.Set("X-Forwarded-For", xff)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	// A forged X-Forwarded-For from an untrusted peer doesn't give a fresh key.
+	for i := range 3 {
+		if got := login("192.0.2.1:1000", fmt.Sprintf("198.51.100.%d", i)); got != http.StatusUnauthorized {
+			t.Fatalf("attempt %d: status %d, want 401", i, got)
+		}
+	}
+	if got := login("192.0.2.1:1000", "198.51.100.99"); got != http.StatusTooManyRequests {
+		t.Errorf("locked peer with a new forged header: status %d, want 429", got)
+	}
+	// Nor can it lock out another address by forging it.
+	if got := login("192.0.2.2:1000", ""); got != http.StatusUnauthorized {
+		t.Errorf("other peer: status %d, want 401", got)
+	}
+	// A trusted proxy forwarding for the locked client is locked out.
+	if got := login("10.0.0.1:1000", "192.0.2.1"); got != http.StatusTooManyRequests {
+		t.Errorf("proxied locked client: status %d, want 429", got)
+	}
+}
@@ -3,7 +3,11 @@
 package ipgeo
 
 import (
+	"errors"
+	"net"
 	"net/netip"
+	"slices"
+	"strings"
 
 	"github.com/oschwald/maxminddb-golang/v2"
 )
@@ -34,6 +38,16 @@ type countryRecord struct {
 	} `maxminddb:"country"`
 }
 
+// geoRecord holds the country and, in databases that have it, the autonomous
+// system of a network.
+type geoRecord struct {
+	Country struct {
+		ISOCode string `maxminddb:"iso_code"`
+	} `maxminddb:"country"`
+	ASN            uint   `maxminddb:"autonomous_system_number"`
+	ASOrganization string `maxminddb:"autonomous_system_organization"`
+}
+
 // tailscalePrefix is the Tailscale CGNAT range 100.64.0.0/10.
 var tailscalePrefix = netip.MustParsePrefix("100.64.0.0/10")
 
@@ -46,15 +60,92 @@ func (c *Checker) CountryCode(ipStr string) string {
 	if err != nil {
 		return ""
 	}
+	if cc := classify(addr); cc != "" {
+		return cc
+	}
+	var rec countryRecord
+	if err := c.reader.Lookup(addr).Decode(&rec); err != nil {
+		return ""
+	}
+	return rec.Country.ISOCode
+}
+
+// classify returns "local" or "tailscale" for addresses that are not on the
+// public internet, "" otherwise.
+func classify(addr netip.Addr) string {
 	if addr.IsLoopback() || addr.IsPrivate() || addr.IsUnspecified() || addr.IsLinkLocalUnicast() {
 		return "local"
 	}
 	if tailscalePrefix.Contains(addr) {
 		return "tailscale"
 	}
-	var rec countryRecord
+	return ""
+}
+
+// GeoResult is what the database knows about an IP address.
+type GeoResult struct {
+	// Country is the ISO 3166-1 alpha-2 country code, "local" or "tailscale"
+	// as returned by CountryCode, or "" when unknown.
+	Country string
+	// ASN is the autonomous system number, 0 when the database has no ASN data.
+	ASN uint
+	// ASOrganization is the name of the autonomous system.
+	ASOrganization string
+}
+
+// IsLocal returns true for loopback, private and Tailscale addresses.
+func (r *GeoResult) IsLocal() bool {
+	return r.Country == "local" || r.Country == "tailscale"
+}
+
+// Evaluate looks up the country and autonomous system of ip.
+//
+// Addresses not on the public internet are not looked up. An address missing
+// from the database returns an empty result.
+func (c *Checker) Evaluate(ip net.IP) (GeoResult, error) {
+	addr, ok := netip.AddrFromSlice(ip)
+	if !ok {
+		return GeoResult{}, errInvalidIP
+	}
+	addr = addr.Unmap()
+	if cc := classify(addr); cc != "" {
+		return GeoResult{Country: cc}, nil
+	}
+	var rec geoRecord
 	if err := c.reader.Lookup(addr).Decode(&rec); err != nil {
-		return ""
+		return GeoResult{}, err
 	}
-	return rec.Country.ISOCode
+	return GeoResult{Country: rec.Country.ISOCode, ASN: rec.ASN, ASOrganization: rec.ASOrganization}, nil
+}
+
+// GeoPolicy restricts access by country and autonomous system.
+type GeoPolicy struct {
+	// AllowCountries, when not empty, lists the only countries allowed.
+	AllowCountries []string
+	// DenyCountries lists the countries denied.
+	DenyCountries []string
+	// DenyASNs lists the autonomous systems denied, e.g. hosting providers.
+	DenyASNs []uint
+}
+
+// Allowed returns true if the policy lets r through.
+//
+// Local addresses are always allowed. With an allow list, addresses of unknown
+// country are denied.
+func (p *GeoPolicy) Allowed(r GeoResult) bool {
+	if r.IsLocal() {
+		return true
+	}
+	if r.ASN != 0 && slices.Contains(p.DenyASNs, r.ASN) {
+		return false
+	}
+	has := func(list []string) bool {
+		return slices.ContainsFunc(list, func(cc string) bool { return strings.EqualFold(cc, r.Country) })
+	}
+	if r.Country != "" && has(p.DenyCountries) {
+		return false
+	}
+	return len(p.AllowCountries) == 0 || (r.Country != "" && has(p.AllowCountries))
 }
+
+var errInvalidIP = errors.New("invalid IP address")
@@ -1,7 +1,13 @@
 package ipgeo
 
 import (
+	"bytes"
+	"encoding/binary"
+	"net"
 	"net/netip"
+	"os"
+	"path/filepath"
+	"slices"
 	"testing"
 )
 
@@ -58,3 +64,215 @@ func TestCountryCodeLocal(t *testing.T) {
 		}
 	}
 }
+
+func TestEvaluate(t *testing.T) {
+	country := func(cc string) map[string]any {
+		return map[string]any{"country": map[string]any{"iso_code": cc}}
+	}
+	withASN := func(cc string, asn uint32, org string) map[string]any {
+		r := country(cc)
+		r["autonomous_system_number"] = asn
+		r["autonomous_system_organization"] = org
+		return r
+	}
+
+	t.Run("WithASN", func(t *testing.T) {
+		c, err := Open(writeTestMMDB(t, map[string]map[string]any{
+			"1.2.3.0/24": withASN("FR", 64500, "Example Hosting"),
+			"8.8.8.0/24": withASN("US", 15169, "Google"),
+		}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { _ = c.Close() })
+
+		tests := []struct {
+			ip   string
+			want GeoResult
+		}{
+			{"1.2.3.4", GeoResult{Country: "FR", ASN: 64500, ASOrganization: "Example Hosting"}},
+			{"::ffff:8.8.8.8", GeoResult{Country: "US", ASN: 15169, ASOrganization: "Google"}},
+			{"9.9.9.9", GeoResult{}},
+			{"127.0.0.1", GeoResult{Country: "local"}},
+			{"192.168.1.1", GeoResult{Country: "local"}},
+			{"100.64.0.1", GeoResult{Country: "tailscale"}},
+		}
+		for _, tt := range tests {
+			got, err := c.Evaluate(net.ParseIP(tt.ip))
+			if err != nil {
+				t.Errorf("Evaluate(%s) failed: %v", tt.ip, err)
+			} else if got != tt.want {
+				t.Errorf("Evaluate(%s) = %+v, want %+v", tt.ip, got, tt.want)
+			}
+		}
+		if got := c.CountryCode("1.2.3.4"); got != "FR" {
+			t.Errorf("CountryCode = %q, want FR", got)
+		}
+		if _, err := c.Evaluate(nil); err == nil {
+			t.Error("Evaluate(nil) should fail")
+		}
+	})
+
+	t.Run("CountryOnly", func(t *testing.T) {
+		c, err := Open(writeTestMMDB(t, map[string]map[string]any{
+			"1.2.3.0/24": country("DE"),
+		}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { _ = c.Close() })
+		got, err := c.Evaluate(net.ParseIP("1.2.3.4"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := (GeoResult{Country: "DE"}); got != want {
+			t.Errorf("Evaluate = %+v, want %+v", got, want)
+		}
+	})
+}
+
+func TestGeoPolicy(t *testing.T) {
+	fr := GeoResult{Country: "FR", ASN: 64500}
+	us := GeoResult{Country: "US", ASN: 15169}
+	unknown := GeoResult{}
+	local := GeoResult{Country: "local"}
+	tests := []struct {
+		name   string
+		policy GeoPolicy
+		want   map[*GeoResult]bool
+	}{
+		{"Empty", GeoPolicy{}, map[*GeoResult]bool{&fr: true, &us: true, &unknown: true, &local: true}},
+		{"Deny", GeoPolicy{DenyCountries: []string{"us"}}, map[*GeoResult]bool{&fr: true, &us: false, &unknown: true, &local: true}},
+		{"Allow", GeoPolicy{AllowCountries: []string{"FR"}}, map[*GeoResult]bool{&fr: true, &us: false, &unknown: false, &local: true}},
+		{"AllowAndDeny", GeoPolicy{AllowCountries: []string{"FR", "US"}, DenyCountries: []string{"FR"}}, map[*GeoResult]bool{&fr: false, &us: true, &local: true}},
+		{"DenyASN", GeoPolicy{DenyASNs: []uint{64500}}, map[*GeoResult]bool{&fr: false, &us: true, &unknown: true, &local: true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for r, want := range tt.want {
+				if got := tt.policy.Allowed(*r); got != want {
+					t.Errorf("Allowed(%+v) = %v, want %v", *r, got, want)
+				}
+			}
+		})
+	}
+}
+
+// writeTestMMDB writes a minimal IPv4 MaxMind DB mapping each network to its
+// record, and returns the file path. Records hold strings, uint32 and nested
+// maps, enough to mimic the GeoIP2/GeoLite2 layouts.
+func writeTestMMDB(t *testing.T, records map[string]map[string]any) string {
+	t.Helper()
+	type node struct {
+		child [2]*node
+		data  [2]int // offset+1 in the data section, 0 if none
+	}
+	root := &node{}
+	var data bytes.Buffer
+	// Sort for a deterministic file.
+	networks := make([]string, 0, len(records))
+	for n := range records {
+		networks = append(networks, n)
+	}
+	slices.Sort(networks)
+	for _, network := range networks {
+		prefix := netip.MustParsePrefix(network)
+		offset := data.Len()
+		encodeMMDB(&data, records[network])
+		ip := prefix.Addr().As4()
+		cur := root
+		for i := range prefix.Bits() {
+			bit := int(ip[i/8]>>(7-i%8)) & 1
+			if i == prefix.Bits()-1 {
+				cur.data[bit] = offset + 1
+				break
+			}
+			if cur.child[bit] == nil {
+				cur.child[bit] = &node{}
+			}
+			cur = cur.child[bit]
+		}
+	}
+
+	// Number the nodes breadth first.
+	nodes := []*node{root}
+	for i := 0; i < len(nodes); i++ {
+		for _, c := range nodes[i].child {
+			if c != nil {
+				nodes = append(nodes, c)
+			}
+		}
+	}
+	index := make(map[*node]int, len(nodes))
+	for i, n := range nodes {
+		index[n] = i
+	}
+	nodeCount := len(nodes)
+	var out bytes.Buffer
+	for _, n := range nodes {
+		for bit := range 2 {
+			v := nodeCount // empty
+			if c := n.child[bit]; c != nil {
+				v = index[c]
+			} else if n.data[bit] != 0 {
+				v = nodeCount + 16 + n.data[bit] - 1
+			}
+			out.Write([]byte{byte(v >> 16), byte(v >> 8), byte(v)})
+		}
+	}
+	out.Write(make([]byte, 16))
+	out.Write(data.Bytes())
+	out.WriteString("\xab\xcd\xefMaxMind.com")
+	encodeMMDB(&out, map[string]any{
+		"node_count":                  uint32(nodeCount),
+		"record_size":                 uint32(24),
+		"ip_version":                  uint32(4),
+		"database_type":               "mddb-test",
+		"binary_format_major_version": uint32(2),
+	})
+
+	path := filepath.Join(t.TempDir(), "test.mmdb")
+	if err := os.WriteFile(path, out.Bytes(), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// encodeMMDB appends v in the MaxMind DB data format.
+func encodeMMDB(buf *bytes.Buffer, v any) {
+	control := func(typ byte, size int) {
+		switch {
+		case size < 29:
+			buf.WriteByte(typ<<5 | byte(size))
+		case size < 29+256:
+			buf.WriteByte(typ<<5 | 29)
+			buf.WriteByte(byte(size - 29))
+		default:
+			panic("size too large for the test encoder")
+		}
+	}
+	switch v := v.(type) {
+	case string:
+		control(2, len(v))
+		buf.WriteString(v)
+	case uint32:
+		var b [4]byte
+		binary.BigEndian.PutUint32(b[:], v)
+		trimmed := bytes.TrimLeft(b[:], "\x00")
+		control(6, len(trimmed))
+		buf.Write(trimmed)
+	case map[string]any:
+		control(7, len(v))
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		slices.Sort(keys)
+		for _, k := range keys {
+			encodeMMDB(buf, k)
+			encodeMMDB(buf, v[k])
+		}
+	default:
+		panic("unsupported type")
+	}
+}
@@ -141,8 +141,8 @@ func (l *Limiters) Update(authRate, writeRate, readAuthRate, readUnauthRate int)
 // MatchUnauth returns the tier for unauthenticated requests.
 // Returns nil for paths that should not be rate limited.
 func (l *Limiters) MatchUnauth(method, path string) *Tier {
-	// Skip health check
-	if path == "/api/v1/health" {
+	// Skip health checks
+	if path == "/api/v1/health" || path == "/api/v1/health/ready" {
 		return nil
 	}
 
@@ -162,8 +162,8 @@ func (l *Limiters) MatchUnauth(method, path string) *Tier {
 // MatchAuth returns the tier for authenticated requests.
 // Returns nil for paths that should not be rate limited.
 func (l *Limiters) MatchAuth(method, path string) *Tier {
-	// Skip health check
-	if path == "/api/v1/health" {
+	// Skip health checks
+	if path == "/api/v1/health" || path == "/api/v1/health/ready" {
 		return nil
 	}
 
@@ -60,6 +60,7 @@ func TestLimiters_MatchUnauth(t *testing.T) {
 		wantTier string
 	}{
 		{"GET", "/api/v1/health", ""},                         // No rate limit for health check
+		{"GET", "/api/v1/health/ready", ""},                   // No rate limit for readiness check
 		{"POST", "/api/v1/auth/login", "auth"},                // Auth tier
 		{"POST", "/api/v1/auth/register", "auth"},             // Auth tier
 		{"GET", "/api/v1/auth/oauth/google/callback", "auth"}, // Auth tier (OAuth callback)
@@ -95,6 +96,7 @@ func TestLimiters_MatchAuth(t *testing.T) {
 		wantTier string
 	}{
 		{"GET", "/api/v1/health", ""},            // No rate limit for health check
+		{"GET", "/api/v1/health/ready", ""},      // No rate limit for readiness check
 		{"GET", "/api/v1/pages", "read"},         // Read tier
 		{"GET", "/api/v1/users", "read"},         // Read tier
 		{"POST", "/api/v1/pages", "write"},       // Write tier
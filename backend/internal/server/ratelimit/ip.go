@@ -0,0 +1,104 @@
+// Limits requests per client IP before routing.
+
+package ratelimit
+
+import (
+	"net/http"
+	"net/netip"
+	"os"
+	"strings"
+	"time"
+)
+
+// IPLimiter limits every request per client IP, with a stricter bucket for
+// authentication endpoints than for the rest.
+type IPLimiter struct {
+	Auth    Tier
+	Default Tier
+	trusted []netip.Prefix
+}
+
+// NewIPLimiter creates a per IP limiter. Rates are per minute, 0 means
+// unlimited. X-Forwarded-For is only honored when sent by a trusted proxy.
+//
+// Set TEST_FAST_RATE_LIMIT=1 to increase rate limits 10000x (for e2e tests).
+func NewIPLimiter(authRate, authBurst, rate, burst int, trusted []netip.Prefix) *IPLimiter {
+	m := 1 // multiplier
+	if os.Getenv("TEST_FAST_RATE_LIMIT") == "1" {
+		m = 10000
+	}
+	auth := TierConfig{Name: "ip-auth", Rate: authRate * m, Window: time.Minute, Burst: max(authBurst*m, 1), Scope: ScopeIP}
+	def := TierConfig{Name: "ip", Rate: rate * m, Window: time.Minute, Burst: max(burst*m, 1), Scope: ScopeIP}
+	return &IPLimiter{
+		Auth:    Tier{TierConfig: auth, Limiter: NewLimiter(auth.Rate, auth.Window, auth.Burst)},
+		Default: Tier{TierConfig: def, Limiter: NewLimiter(def.Rate, def.Window, def.Burst)},
+		trusted: trusted,
+	}
+}
+
+// Allow consumes a token from the bucket of the client that sent r.
+// The health checks are never limited.
+func (l *IPLimiter) Allow(r *http.Request) Result {
+	if r.URL.Path == "/api/v1/health" || r.URL.Path == "/api/v1/health/ready" {
+		return Result{Allowed: true}
+	}
+	tier := &l.Default
+	if isAuthEndpoint(r.Method, r.URL.Path) {
+		tier = &l.Auth
+	}
+	return tier.Limiter.Allow(BuildKey(tier.Scope, l.ClientIP(r), tier.Name))
+}
+
+// ClientIP returns the IP of the client that sent r.
+//
+// When the peer is a trusted proxy, X-Forwarded-For is walked from the right,
+// skipping entries added by other trusted proxies. Entries left of the first
+// untrusted hop are ignored since the client can forge them.
+func (l *IPLimiter) ClientIP(r *http.Request) string {
+	addr, ok := parseAddr(r.RemoteAddr)
+	if !ok {
+		return r.RemoteAddr
+	}
+	if l.isTrusted(addr) {
+		hops := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+		for i := len(hops) - 1; i >= 0; i-- {
+			hop, ok := parseAddr(strings.TrimSpace(hops[i]))
+			if !ok {
+				break
+			}
+			addr = hop
+			if !l.isTrusted(hop) {
+				break
+			}
+		}
+	}
+	return addr.String()
+}
+
+// Close stops the limiters' cleanup goroutines.
+func (l *IPLimiter) Close() {
+	l.Auth.Limiter.Close()
+	l.Default.Limiter.Close()
+}
+
+func (l *IPLimiter) isTrusted(addr netip.Addr) bool {
+	for _, p := range l.trusted {
+		if p.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseAddr parses an IP with an optional port. IPv4-mapped IPv6 addresses are
+// unmapped so both forms share a bucket.
+func parseAddr(s string) (netip.Addr, bool) {
+	if ap, err := netip.ParseAddrPort(s); err == nil {
+		return ap.Addr().Unmap(), true
+	}
+	a, err := netip.ParseAddr(strings.Trim(s, "[]"))
+	if err != nil {
+		return netip.Addr{}, false
+	}
+	return a.Unmap(), true
+}
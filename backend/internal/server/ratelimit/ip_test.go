@@ -0,0 +1,42 @@
+package ratelimit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+)
+
+func TestIPLimiter_ClientIP(t *testing.T) {
+	l := NewIPLimiter(0, 0, 0, 0, []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8"), netip.MustParsePrefix("::1/128")})
+	defer l.Close()
+	tests := []struct {
+		name       string
+		remoteAddr string
+		xff        []string
+		want       string
+	}{
+		{"direct", "203.0.113.5:1234", nil, "203.0.113.5"},
+		{"untrusted peer ignores header", "203.0.113.5:1234", []string{"198.51.100.1"}, "203.0.113.5"},
+		{"trusted proxy", "10.0.0.2:1234", []string{"198.51.100.1"}, "198.51.100.1"},
+		{"forged entries skipped", "10.0.0.2:1234", []string{"1.2.3.4, 198.51.100.1"}, "198.51.100.1"},
+		{"proxy chain", "10.0.0.2:1234", []string{"198.51.100.1, 10.0.0.3", "10.0.0.4"}, "198.51.100.1"},
+		{"invalid hop", "10.0.0.2:1234", []string{"198.51.100.1, garbage"}, "10.0.0.2"},
+		{"no header", "10.0.0.2:1234", nil, "10.0.0.2"},
+		{"ipv6", "[::1]:8080", []string{"2001:db8::1"}, "2001:db8::1"},
+		{"ipv4 mapped", "[::ffff:203.0.113.5]:1234", nil, "203.0.113.5"},
+		{"no port", "203.0.113.5", nil, "203.0.113.5"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", http.NoBody)
+			r.RemoteAddr = tt.remoteAddr
+			for _, v := range tt.xff {
+				r.Header.Add("X-Forwarded-For", v)
+			}
+			if got := l.ClientIP(r); got != tt.want {
+				t.Errorf("ClientIP() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -5,46 +5,11 @@ package reqctx
 
 import (
 	"context"
-	"net/http"
-	"strings"
 
 	"github.com/maruel/ksid"
 	"github.com/maruel/mddb/backend/internal/storage/identity"
 )
 
-// GetClientIP extracts the client IP from an HTTP request,
-// checking X-Forwarded-For and X-Real-IP headers for proxied requests.
-func GetClientIP(r *http.Request) string {
-	// Check X-Forwarded-For header first (for proxies)
-	// X-Forwarded-For can contain multiple IPs: "client, proxy1, proxy2"
-	// The leftmost IP is the original client.
-	if xff := r.Header.Get("X-Forwarded-For"); xff != "" {
-		if first, _, found := strings.Cut(xff, ","); found {
-			return strings.TrimSpace(first)
-		}
-		return strings.TrimSpace(xff)
-	}
-	// Check X-Real-IP header
-	if xri := r.Header.Get("X-Real-IP"); xri != "" {
-		return xri
-	}
-	// Fall back to RemoteAddr, stripping port if present
-	addr := r.RemoteAddr
-	// Handle IPv6 addresses like [::1]:8080
-	if strings.HasPrefix(addr, "[") {
-		if host, _, found := strings.Cut(addr, "]:"); found {
-			return host[1:] // Strip leading "["
-		}
-		// Malformed but try to be lenient
-		return strings.Trim(addr, "[]")
-	}
-	// IPv4 or hostname with port
-	if host, _, found := strings.Cut(addr, ":"); found {
-		return host
-	}
-	return addr
-}
-
 // Context keys for request metadata.
 type contextKey string
 
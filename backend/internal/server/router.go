@@ -30,6 +30,7 @@ import (
 // Config holds configuration for the router.
 type Config struct {
 	*storage.ServerConfig
+	Live      *storage.Config // Reloaded configuration; created from ServerConfig when nil
 	DataDir   string
 	BaseURL   string
 	Version   string
@@ -73,13 +74,28 @@ func NewRouter(svc *handlers.Services, cfg *Config) http.Handler {
 		cfg.RateLimits.ReadUnauthRatePerMin,
 	)
 	limiters := ratelimit.NewLimiters(rlCfg)
+	// TrustedProxies was checked by ServerConfig.Validate.
+	trusted, _ := cfg.IPRateLimits.TrustedPrefixes()
+	ipLimiter := ratelimit.NewIPLimiter(
+		cfg.IPRateLimits.AuthRatePerMin,
+		cfg.IPRateLimits.AuthBurst,
+		cfg.IPRateLimits.RatePerMin,
+		cfg.IPRateLimits.Burst,
+		trusted,
+	)
 
 	// Create bandwidth limiter
 	bandwidthLim := bandwidth.NewLimiter(cfg.Quotas.MaxEgressBandwidthBps)
 
+	live := cfg.Live
+	if live == nil {
+		live = storage.NewConfig(cfg.ServerConfig)
+	}
+
 	// Create handler config from server config
 	hcfg := &handlers.Config{
 		ServerConfig: *cfg.ServerConfig,
+		Live:         live,
 		BaseURL:      cfg.BaseURL,
 		Version:      cfg.Version,
 		GoVersion:    cfg.GoVersion,
@@ -94,6 +110,7 @@ func NewRouter(svc *handlers.Services, cfg *Config) http.Handler {
 	ah := &handlers.AssetHandler{Svc: svc, Cfg: hcfg}
 	nh := &handlers.NodeHandler{Svc: svc, Cfg: hcfg}
 	sh := &handlers.SearchHandler{Svc: svc}
+	arh := &handlers.ArchiveHandler{Svc: svc}
 
 	// Other handlers
 	uh := &handlers.UserHandler{Svc: svc}
@@ -108,15 +125,17 @@ func NewRouter(svc *handlers.Services, cfg *Config) http.Handler {
 	grh := &handlers.GitRemoteHandler{Svc: svc, GitHubApp: ghAppClient}
 
 	// Health check (public)
-	hh := &handlers.HealthHandler{Cfg: hcfg}
+	hh := &handlers.HealthHandler{Cfg: hcfg, Svc: svc, DataDir: cfg.DataDir}
 	mux.Handle("/api/v1/health", Wrap(hh.GetHealth, hcfg, limiters))
+	mux.Handle("GET /api/v1/health/ready", Wrap(hh.GetReadiness, hcfg, limiters))
 
 	// Admin endpoints (requires IsGlobalAdmin)
 	adminh := &handlers.AdminHandler{Svc: svc, RateLimitCounts: limiters.Counts, ServerStartTime: limiters.StartTime}
 	mux.Handle("GET /api/v1/admin/server", WrapGlobalAdmin(adminh.GetServerDetail, svc, hcfg, limiters))
 
 	// Server config endpoints (requires IsGlobalAdmin)
-	serverh := &handlers.ServerHandler{Cfg: cfg.ServerConfig, DataDir: cfg.DataDir, FileStore: svc.FileStore, BandwidthLimiter: bandwidthLim, RateLimiters: limiters}
+	serverh := &handlers.ServerHandler{Cfg: live, DataDir: cfg.DataDir, FileStore: svc.FileStore, BandwidthLimiter: bandwidthLim, RateLimiters: limiters}
+	live.OnChange(serverh.ApplyConfig)
 	mux.Handle("GET /api/v1/server/config", WrapGlobalAdmin(serverh.GetConfig, svc, hcfg, limiters))
 	mux.Handle("POST /api/v1/server/config", WrapGlobalAdmin(serverh.UpdateConfig, svc, hcfg, limiters))
 
@@ -153,6 +172,7 @@ func NewRouter(svc *handlers.Services, cfg *Config) http.Handler {
 	// Public
 	mux.Handle("POST /api/v1/auth/login", WrapWithSvc(authh.Login, svc, hcfg, limiters))
 	mux.Handle("POST /api/v1/auth/register", WrapWithSvc(authh.Register, svc, hcfg, limiters))
+	mux.Handle("POST /api/v1/auth/refresh", WrapWithSvc(authh.Refresh, svc, hcfg, limiters))
 	mux.Handle("POST /api/v1/auth/invitations/org/accept", WrapWithSvc(ih.AcceptOrgInvitation, svc, hcfg, limiters))
 	mux.Handle("POST /api/v1/auth/invitations/ws/accept", WrapWithSvc(ih.AcceptWSInvitation, svc, hcfg, limiters))
 	mux.HandleFunc("GET /api/v1/auth/email/verify", authh.VerifyEmailRedirect)
@@ -183,7 +203,10 @@ func NewRouter(svc *handlers.Services, cfg *Config) http.Handler {
 	mux.Handle("POST /api/v1/organizations/{orgID}/users/remove", WrapOrgAuth(uh.RemoveOrgMember, svc, hcfg, identity.OrgRoleAdmin, limiters))
 	mux.Handle("GET /api/v1/organizations/{orgID}/invitations", WrapOrgAuth(ih.ListOrgInvitations, svc, hcfg, identity.OrgRoleAdmin, limiters))
 	mux.Handle("POST /api/v1/organizations/{orgID}/invitations", WrapOrgAuth(ih.CreateOrgInvitation, svc, hcfg, identity.OrgRoleAdmin, limiters))
+	mux.Handle("POST /api/v1/organizations/{orgID}/invitations/resend", WrapOrgAuth(ih.ResendOrgInvitation, svc, hcfg, identity.OrgRoleAdmin, limiters))
 	mux.Handle("POST /api/v1/organizations/{orgID}/workspaces", WrapOrgAuth(orgh.CreateWorkspace, svc, hcfg, identity.OrgRoleAdmin, limiters))
+	audith := &handlers.AuditHandler{Svc: svc}
+	mux.Handle("GET /api/v1/organizations/{orgID}/audit", WrapOrgAuth(audith.ListAuditLog, svc, hcfg, identity.OrgRoleAdmin, limiters))
 
 	// Notion import endpoints
 	nih := handlers.NewNotionImportHandler(svc, hcfg)
@@ -209,6 +232,11 @@ func NewRouter(svc *handlers.Services, cfg *Config) http.Handler {
 	mux.Handle("GET /api/v1/github-app/available", Wrap(grh.IsGitHubAppAvailable, hcfg, limiters))
 	// Notion import cancel
 	mux.Handle("POST /api/v1/workspaces/{wsID}/notion/import/cancel", WrapWSAuth(nih.CancelImport, svc, hcfg, identity.WSRoleAdmin, limiters))
+	// Notion import progress stream (EventSource can't set headers; token accepted as query param)
+	nihEvents := WrapAuthRaw(nih.StreamImport, svc, hcfg, identity.WSRoleAdmin, limiters)
+	mux.HandleFunc("GET /api/v1/workspaces/{wsID}/notion/import/events", handlers.InjectTokenFromQuery(nihEvents).ServeHTTP)
+	// Workspace archive
+	mux.Handle("GET /api/v1/workspaces/{wsID}/export", WrapAuthRaw(arh.ExportWorkspace, svc, hcfg, identity.WSRoleAdmin, limiters))
 	// Users and invitations
 	mux.Handle("GET /api/v1/workspaces/{wsID}/members", WrapWSAuth(uh.ListWorkspaceMembers, svc, hcfg, identity.WSRoleViewer, limiters))
 	mux.Handle("POST /api/v1/workspaces/{wsID}/users/resolve", WrapWSAuth(uh.ResolveUsers, svc, hcfg, identity.WSRoleViewer, limiters))
@@ -224,6 +252,7 @@ func NewRouter(svc *handlers.Services, cfg *Config) http.Handler {
 	// Pages (under nodes)
 	mux.Handle("POST /api/v1/workspaces/{wsID}/nodes/{id}/page/create", WrapWSAuth(nh.CreatePage, svc, hcfg, identity.WSRoleEditor, limiters))
 	mux.Handle("GET /api/v1/workspaces/{wsID}/nodes/{id}/page", WrapWSAuth(nh.GetPage, svc, hcfg, identity.WSRoleViewer, limiters))
+	mux.Handle("GET /api/v1/workspaces/{wsID}/nodes/{id}/page/html", WrapWSAuth(nh.GetPageHTML, svc, hcfg, identity.WSRoleViewer, limiters))
 	mux.Handle("POST /api/v1/workspaces/{wsID}/nodes/{id}/page", WrapWSAuth(nh.UpdatePage, svc, hcfg, identity.WSRoleEditor, limiters))
 	mux.Handle("POST /api/v1/workspaces/{wsID}/nodes/{id}/page/delete", WrapWSAuth(nh.DeletePage, svc, hcfg, identity.WSRoleEditor, limiters))
 	mux.Handle("POST /api/v1/workspaces/{wsID}/nodes/{id}/page/frontmatter", WrapWSAuth(nh.UpdatePageFrontmatter, svc, hcfg, identity.WSRoleEditor, limiters))
@@ -251,6 +280,7 @@ func NewRouter(svc *handlers.Services, cfg *Config) http.Handler {
 	// Assets (under nodes)
 	mux.Handle("GET /api/v1/workspaces/{wsID}/nodes/{id}/assets", WrapWSAuth(nh.ListNodeAssets, svc, hcfg, identity.WSRoleViewer, limiters))
 	mux.Handle("POST /api/v1/workspaces/{wsID}/nodes/{id}/assets", WrapAuthRaw(ah.UploadNodeAssetHandler, svc, hcfg, identity.WSRoleEditor, limiters))
+	mux.Handle("GET /api/v1/workspaces/{wsID}/nodes/{id}/assets/{name}", WrapAuthRaw(ah.DownloadNodeAsset, svc, hcfg, identity.WSRoleViewer, limiters))
 	mux.Handle("POST /api/v1/workspaces/{wsID}/nodes/{id}/assets/{name}/delete", WrapWSAuth(nh.DeleteNodeAsset, svc, hcfg, identity.WSRoleEditor, limiters))
 	// Search
 	mux.Handle("POST /api/v1/workspaces/{wsID}/search", WrapWSAuth(sh.Search, svc, hcfg, identity.WSRoleViewer, limiters))
@@ -295,13 +325,17 @@ func NewRouter(svc *handlers.Services, cfg *Config) http.Handler {
 	dist, _ := fs.Sub(frontend.Files, "dist")
 	mux.HandleFunc("/", newStaticHandler(dist))
 
-	// Wrap mux with compression middleware chain.
+	// Wrap mux with compression, per IP rate limiting, CORS and security
+	// headers middleware chain.
 	var inner http.Handler = mux
 	inner = compressMiddleware(inner)
 	inner = decompressMiddleware(inner)
+	inner = ipRateLimitMiddleware(ipLimiter, inner)
+	inner = corsMiddleware(cfg.CORS, inner)
+	inner = securityHeadersMiddleware(cfg.SecurityHeaders, inner)
 
 	f := func(w http.ResponseWriter, r *http.Request) {
-		clientIP := reqctx.GetClientIP(r)
+		clientIP := reqctx.ClientIP(r.Context())
 		var cc string
 		if cfg.IPGeo != nil {
 			cc = cfg.IPGeo.CountryCode(clientIP)
@@ -324,7 +358,7 @@ func NewRouter(svc *handlers.Services, cfg *Config) http.Handler {
 			"cc", cc,
 		)
 	}
-	return http.HandlerFunc(f)
+	return clientIPMiddleware(ipLimiter, http.HandlerFunc(f))
 }
 
 // responseWriter wraps http.ResponseWriter to capture status code, response size, and apply bandwidth limiting.
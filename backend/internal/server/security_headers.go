@@ -0,0 +1,63 @@
+// Security headers middleware.
+//
+// Every response gets nosniff, framing, referrer and Content-Security-Policy
+// headers from ServerConfig.SecurityHeaders, plus Strict-Transport-Security
+// when the request arrived over HTTPS. Asset responses replace the policy with
+// a stricter one in handlers.
+
+package server
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/maruel/mddb/backend/internal/storage"
+)
+
+// defaultCSP allows this origin plus the Google Fonts stylesheets loaded by
+// index.html. Inline styles are needed by the editor; images may come from
+// OAuth avatars on other origins.
+const defaultCSP = "default-src 'self'; script-src 'self'; " +
+	"style-src 'self' 'unsafe-inline' https://fonts.googleapis.com; " +
+	"font-src 'self' data: https://fonts.gstatic.com; " +
+	"img-src 'self' data: blob: https:; media-src 'self' blob:; connect-src 'self'; " +
+	"object-src 'none'; base-uri 'self'; form-action 'self'; frame-ancestors "
+
+// securityHeadersMiddleware returns a handler that adds the headers of cfg to
+// every response.
+func securityHeadersMiddleware(cfg storage.SecurityHeadersConfig, next http.Handler) http.Handler {
+	frameOptions := cfg.FrameOptions
+	if frameOptions == "" {
+		frameOptions = "DENY"
+	}
+	csp := cfg.ContentSecurityPolicy
+	if csp == "" {
+		if frameOptions == "SAMEORIGIN" {
+			csp = defaultCSP + "'self'"
+		} else {
+			csp = defaultCSP + "'none'"
+		}
+	}
+	referrer := cfg.ReferrerPolicy
+	if referrer == "" {
+		referrer = "strict-origin-when-cross-origin"
+	}
+	hsts := ""
+	switch {
+	case cfg.HSTSMaxAgeSeconds == 0:
+		hsts = "max-age=31536000"
+	case cfg.HSTSMaxAgeSeconds > 0:
+		hsts = "max-age=" + strconv.Itoa(cfg.HSTSMaxAgeSeconds)
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h := w.Header()
+		h.Set("X-Content-Type-Options", "nosniff")
+		h.Set("X-Frame-Options", frameOptions)
+		h.Set("Content-Security-Policy", csp)
+		h.Set("Referrer-Policy", referrer)
+		if hsts != "" && (r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https") {
+			h.Set("Strict-Transport-Security", hsts)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
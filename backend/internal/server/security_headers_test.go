@@ -0,0 +1,75 @@
+package server
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/maruel/mddb/backend/internal/storage"
+)
+
+func TestSecurityHeadersMiddleware(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	do := func(cfg storage.SecurityHeadersConfig, https bool) http.Header {
+		req := httptest.NewRequest(http.MethodGet, "/", http.NoBody)
+		if https {
+			req.TLS = &tls.ConnectionState{}
+		}
+		w := httptest.NewRecorder()
+		securityHeadersMiddleware(cfg, ok).ServeHTTP(w, req)
+		return w.Header()
+	}
+
+	t.Run("defaults", func(t *testing.T) {
+		h := do(storage.SecurityHeadersConfig{}, false)
+		want := map[string]string{
+			"X-Content-Type-Options": "nosniff",
+			"X-Frame-Options":        "DENY",
+			"Referrer-Policy":        "strict-origin-when-cross-origin",
+		}
+		for k, v := range want {
+			if got := h.Get(k); got != v {
+				t.Errorf("%s = %q, want %q", k, got, v)
+			}
+		}
+		csp := h.Get("Content-Security-Policy")
+		if !strings.Contains(csp, "default-src 'self'") || !strings.HasSuffix(csp, "frame-ancestors 'none'") {
+			t.Errorf("unexpected Content-Security-Policy %q", csp)
+		}
+		if got := h.Get("Strict-Transport-Security"); got != "" {
+			t.Errorf("Strict-Transport-Security = %q over HTTP, want none", got)
+		}
+	})
+
+	t.Run("https", func(t *testing.T) {
+		if got := do(storage.SecurityHeadersConfig{}, true).Get("Strict-Transport-Security"); got != "max-age=31536000" {
+			t.Errorf("Strict-Transport-Security = %q", got)
+		}
+		if got := do(storage.SecurityHeadersConfig{HSTSMaxAgeSeconds: -1}, true).Get("Strict-Transport-Security"); got != "" {
+			t.Errorf("Strict-Transport-Security = %q when disabled", got)
+		}
+	})
+
+	t.Run("configured", func(t *testing.T) {
+		h := do(storage.SecurityHeadersConfig{FrameOptions: "SAMEORIGIN", ReferrerPolicy: "no-referrer", HSTSMaxAgeSeconds: 60}, true)
+		if got := h.Get("X-Frame-Options"); got != "SAMEORIGIN" {
+			t.Errorf("X-Frame-Options = %q", got)
+		}
+		if got := h.Get("Content-Security-Policy"); !strings.HasSuffix(got, "frame-ancestors 'self'") {
+			t.Errorf("Content-Security-Policy = %q", got)
+		}
+		if got := h.Get("Referrer-Policy"); got != "no-referrer" {
+			t.Errorf("Referrer-Policy = %q", got)
+		}
+		if got := h.Get("Strict-Transport-Security"); got != "max-age=60" {
+			t.Errorf("Strict-Transport-Security = %q", got)
+		}
+		if got := do(storage.SecurityHeadersConfig{ContentSecurityPolicy: "default-src 'none'"}, false).Get("Content-Security-Policy"); got != "default-src 'none'" {
+			t.Errorf("Content-Security-Policy = %q", got)
+		}
+	})
+}
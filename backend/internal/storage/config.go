@@ -9,8 +9,12 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"net/netip"
+	"net/url"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/maruel/mddb/backend/internal/email"
 )
@@ -29,6 +33,15 @@ type ServerConfig struct {
 	// Auto-generated if empty on first load.
 	JWTSecret []byte `json:"jwt_secret"`
 
+	// JWTKeyID identifies JWTSecret in the "kid" header of issued tokens.
+	// Empty issues tokens without a kid.
+	JWTKeyID string `json:"jwt_key_id"`
+
+	// JWTPreviousKeys are retired signing keys still accepted when verifying
+	// tokens. To rotate the secret, move the current one here and set a new
+	// JWTSecret and JWTKeyID; remove the entry once its tokens have expired.
+	JWTPreviousKeys []JWTKey `json:"jwt_previous_keys"`
+
 	// SMTP holds email configuration. Empty host disables email features.
 	SMTP email.Config `json:"smtp"`
 
@@ -40,6 +53,217 @@ type ServerConfig struct {
 
 	// RateLimits defines rate limiting configuration.
 	RateLimits RateLimits `json:"rate_limits"`
+
+	// IPRateLimits defines per client IP limits applied to every request.
+	IPRateLimits IPRateLimits `json:"ip_rate_limits"`
+
+	// CORS defines which other origins may call the API. Empty means same-origin only.
+	CORS CORSConfig `json:"cors"`
+
+	// Auth defines access and refresh token lifetimes.
+	Auth AuthConfig `json:"auth"`
+
+	// SecurityHeaders defines the security headers sent on every response.
+	SecurityHeaders SecurityHeadersConfig `json:"security_headers"`
+
+	// LoginLockout defines when repeated failed logins block further attempts.
+	LoginLockout LoginLockout `json:"login_lockout"`
+}
+
+// LoginLockout defines brute-force protection for logins. Failures are counted
+// per account and per client IP; reaching a threshold within WindowSeconds
+// blocks that account or IP for LockoutSeconds.
+type LoginLockout struct {
+	// MaxAccountFailures is the number of failed logins for one account that
+	// triggers a lockout. 0 disables the per-account lockout.
+	MaxAccountFailures int `json:"max_account_failures"`
+
+	// MaxIPFailures is the number of failed logins from one IP that triggers
+	// a lockout. 0 disables the per-IP lockout.
+	MaxIPFailures int `json:"max_ip_failures"`
+
+	// WindowSeconds is the period over which failures are counted.
+	WindowSeconds int `json:"window_seconds"`
+
+	// LockoutSeconds is how long a locked account or IP is blocked.
+	LockoutSeconds int `json:"lockout_seconds"`
+}
+
+// Validate checks that the lockout values are non-negative and that the
+// durations are set when a threshold is.
+func (l *LoginLockout) Validate() error {
+	if l.MaxAccountFailures < 0 {
+		return errors.New("max_account_failures must be non-negative")
+	}
+	if l.MaxIPFailures < 0 {
+		return errors.New("max_ip_failures must be non-negative")
+	}
+	if l.WindowSeconds < 0 {
+		return errors.New("window_seconds must be non-negative")
+	}
+	if l.LockoutSeconds < 0 {
+		return errors.New("lockout_seconds must be non-negative")
+	}
+	if (l.MaxAccountFailures > 0 || l.MaxIPFailures > 0) && (l.WindowSeconds == 0 || l.LockoutSeconds == 0) {
+		return errors.New("window_seconds and lockout_seconds are required when a threshold is set")
+	}
+	return nil
+}
+
+// DefaultLoginLockout returns the default lockout settings.
+func DefaultLoginLockout() LoginLockout {
+	return LoginLockout{
+		MaxAccountFailures: 10,  // 10 failures per account
+		MaxIPFailures:      100, // 100 failures per IP, to tolerate shared NATs
+		WindowSeconds:      900, // within 15 minutes
+		LockoutSeconds:     900, // block for 15 minutes
+	}
+}
+
+// SecurityHeadersConfig defines the browser security headers added to
+// responses. The zero value uses safe defaults.
+type SecurityHeadersConfig struct {
+	// ContentSecurityPolicy is sent on every response except assets, which
+	// always get a restrictive policy. Empty uses a policy allowing only this
+	// origin plus the web fonts the frontend loads.
+	ContentSecurityPolicy string `json:"content_security_policy"`
+
+	// FrameOptions is the X-Frame-Options value, "DENY" or "SAMEORIGIN".
+	// Empty uses DENY. The default policy's frame-ancestors follows it.
+	FrameOptions string `json:"frame_options"`
+
+	// ReferrerPolicy is the Referrer-Policy value.
+	// Empty uses strict-origin-when-cross-origin.
+	ReferrerPolicy string `json:"referrer_policy"`
+
+	// HSTSMaxAgeSeconds is the Strict-Transport-Security max-age sent on
+	// requests received over HTTPS. 0 uses one year; negative disables it.
+	HSTSMaxAgeSeconds int `json:"hsts_max_age_seconds"`
+}
+
+// Validate checks that the frame options value is known.
+func (c *SecurityHeadersConfig) Validate() error {
+	switch c.FrameOptions {
+	case "", "DENY", "SAMEORIGIN":
+	default:
+		return fmt.Errorf("frame_options: must be DENY or SAMEORIGIN, got %q", c.FrameOptions)
+	}
+	if strings.ContainsAny(c.ContentSecurityPolicy+c.ReferrerPolicy, "\r\n") {
+		return errors.New("header values must not contain newlines")
+	}
+	return nil
+}
+
+// AuthConfig defines the lifetime of issued credentials.
+type AuthConfig struct {
+	// AccessTokenTTLSeconds is how long an issued JWT is valid.
+	// 0 uses 24 hours.
+	AccessTokenTTLSeconds int `json:"access_token_ttl_seconds"`
+
+	// RefreshTokenTTLSeconds is how long a session may be renewed with its
+	// refresh token. Each refresh extends it again. 0 uses 30 days.
+	RefreshTokenTTLSeconds int `json:"refresh_token_ttl_seconds"`
+}
+
+// AccessTokenTTL returns the effective JWT lifetime.
+func (c *AuthConfig) AccessTokenTTL() time.Duration {
+	if c.AccessTokenTTLSeconds == 0 {
+		return 24 * time.Hour
+	}
+	return time.Duration(c.AccessTokenTTLSeconds) * time.Second
+}
+
+// RefreshTokenTTL returns the effective refresh token lifetime.
+func (c *AuthConfig) RefreshTokenTTL() time.Duration {
+	if c.RefreshTokenTTLSeconds == 0 {
+		return 30 * 24 * time.Hour
+	}
+	return time.Duration(c.RefreshTokenTTLSeconds) * time.Second
+}
+
+// Validate checks that the lifetimes are non-negative and that refresh tokens
+// outlive access tokens.
+func (c *AuthConfig) Validate() error {
+	if c.AccessTokenTTLSeconds < 0 {
+		return errors.New("access_token_ttl_seconds must be non-negative")
+	}
+	if c.RefreshTokenTTLSeconds < 0 {
+		return errors.New("refresh_token_ttl_seconds must be non-negative")
+	}
+	if c.RefreshTokenTTL() < c.AccessTokenTTL() {
+		return errors.New("refresh_token_ttl_seconds must not be shorter than access_token_ttl_seconds")
+	}
+	return nil
+}
+
+// JWTKey is a JWT verification key.
+type JWTKey struct {
+	// ID matches the "kid" header of tokens signed with Secret. Empty matches
+	// tokens without a kid, i.e. those issued before key IDs were configured.
+	ID     string `json:"id"`
+	Secret []byte `json:"secret"`
+}
+
+// JWTVerificationKeys returns the secrets that may have signed a token with the
+// given "kid" header. An empty kid matches keys without an ID.
+func (c *ServerConfig) JWTVerificationKeys(kid string) [][]byte {
+	var keys [][]byte
+	if c.JWTKeyID == kid {
+		keys = append(keys, c.JWTSecret)
+	}
+	for _, k := range c.JWTPreviousKeys {
+		if k.ID == kid {
+			keys = append(keys, k.Secret)
+		}
+	}
+	return keys
+}
+
+// CORSConfig defines Cross-Origin Resource Sharing for /api/ routes.
+type CORSConfig struct {
+	// AllowedOrigins lists the origins allowed to call the API, e.g.
+	// "https://app.example.com". A single "*" allows any origin.
+	// Empty disables CORS.
+	AllowedOrigins []string `json:"allowed_origins"`
+
+	// AllowedMethods lists the methods allowed in preflight responses.
+	// Empty uses GET, POST and DELETE.
+	AllowedMethods []string `json:"allowed_methods"`
+
+	// AllowedHeaders lists the request headers allowed in preflight responses.
+	// Empty uses Authorization and Content-Type.
+	AllowedHeaders []string `json:"allowed_headers"`
+
+	// AllowCredentials allows cookies and HTTP auth on cross-origin requests.
+	// It cannot be combined with the "*" origin.
+	AllowCredentials bool `json:"allow_credentials"`
+
+	// MaxAgeSeconds is how long browsers may cache a preflight response.
+	// 0 leaves the browser default.
+	MaxAgeSeconds int `json:"max_age_seconds"`
+}
+
+// Validate checks that the allowed origins are well formed.
+func (c *CORSConfig) Validate() error {
+	for _, o := range c.AllowedOrigins {
+		if o == "*" {
+			if len(c.AllowedOrigins) != 1 {
+				return errors.New("allowed_origins: \"*\" must be the only entry")
+			}
+			if c.AllowCredentials {
+				return errors.New("allow_credentials cannot be used with the \"*\" origin")
+			}
+			continue
+		}
+		u, err := url.Parse(o)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || u.Path != "" || u.RawQuery != "" || u.Fragment != "" || u.User != nil {
+			return fmt.Errorf("allowed_origins: invalid origin %q", o)
+		}
+	}
+	if c.MaxAgeSeconds < 0 {
+		return errors.New("max_age_seconds must be non-negative")
+	}
+	return nil
 }
 
 // RateLimits defines rate limiting configuration (requests per minute).
@@ -88,6 +312,72 @@ func DefaultRateLimits() RateLimits {
 	}
 }
 
+// IPRateLimits defines per client IP token buckets checked before routing, in
+// addition to the per endpoint RateLimits. Authentication endpoints use a
+// stricter bucket than the rest.
+type IPRateLimits struct {
+	// AuthRatePerMin limits authentication requests (login, register, OAuth
+	// callbacks) from one IP. 0 means unlimited.
+	AuthRatePerMin int `json:"auth_rate_per_min"`
+
+	// AuthBurst is the number of authentication requests allowed at once.
+	AuthBurst int `json:"auth_burst"`
+
+	// RatePerMin limits all other requests from one IP. 0 means unlimited.
+	RatePerMin int `json:"rate_per_min"`
+
+	// Burst is the number of other requests allowed at once.
+	Burst int `json:"burst"`
+
+	// TrustedProxies lists the IPs or CIDR ranges of reverse proxies whose
+	// X-Forwarded-For header is used to find the client IP. Empty ignores
+	// X-Forwarded-For.
+	TrustedProxies []string `json:"trusted_proxies"`
+}
+
+// Validate checks that the limits are non-negative and the proxies parse.
+func (r *IPRateLimits) Validate() error {
+	if r.AuthRatePerMin < 0 || r.AuthBurst < 0 {
+		return errors.New("auth_rate_per_min and auth_burst must be non-negative")
+	}
+	if r.RatePerMin < 0 || r.Burst < 0 {
+		return errors.New("rate_per_min and burst must be non-negative")
+	}
+	_, err := r.TrustedPrefixes()
+	return err
+}
+
+// TrustedPrefixes parses TrustedProxies. A bare IP is a single address range.
+func (r *IPRateLimits) TrustedPrefixes() ([]netip.Prefix, error) {
+	out := make([]netip.Prefix, 0, len(r.TrustedProxies))
+	for _, s := range r.TrustedProxies {
+		if strings.Contains(s, "/") {
+			p, err := netip.ParsePrefix(s)
+			if err != nil {
+				return nil, fmt.Errorf("trusted_proxies: invalid range %q", s)
+			}
+			out = append(out, p.Masked())
+			continue
+		}
+		a, err := netip.ParseAddr(s)
+		if err != nil {
+			return nil, fmt.Errorf("trusted_proxies: invalid IP %q", s)
+		}
+		out = append(out, netip.PrefixFrom(a, a.BitLen()))
+	}
+	return out, nil
+}
+
+// DefaultIPRateLimits returns the default per IP limits.
+func DefaultIPRateLimits() IPRateLimits {
+	return IPRateLimits{
+		AuthRatePerMin: 20,   // 20 req/min for auth
+		AuthBurst:      10,   // 10 auth requests at once
+		RatePerMin:     6000, // 6k req/min for everything else
+		Burst:          1000, // 1k requests at once
+	}
+}
+
 // ServerQuotas defines server-wide resource limits.
 // ResourceQuotas fields are shared with org and workspace layers;
 // the effective quota is min(server, org, workspace) per field.
@@ -116,6 +406,11 @@ type ServerQuotas struct {
 	// 0 means unlimited.
 	MaxEgressBandwidthBps int64 `json:"max_egress_bandwidth_bps"`
 
+	// MinFreeDiskBytes is the free space that must remain on the data
+	// directory's filesystem after a write. Writes that would go below it are
+	// refused. 0 disables the check.
+	MinFreeDiskBytes int64 `json:"min_free_disk_bytes"`
+
 	// NotificationRetentionDays is how many days to keep notifications before GC.
 	// 0 means no age-based deletion.
 	NotificationRetentionDays int `json:"notification_retention_days"`
@@ -152,6 +447,9 @@ func (q *ServerQuotas) Validate() error {
 	if q.MaxEgressBandwidthBps < 0 {
 		return errors.New("max_egress_bandwidth_bps must be non-negative")
 	}
+	if q.MinFreeDiskBytes < 0 {
+		return errors.New("min_free_disk_bytes must be non-negative")
+	}
 	if q.NotificationRetentionDays < 0 {
 		return errors.New("notification_retention_days must be non-negative")
 	}
@@ -177,6 +475,7 @@ func DefaultServerQuotas() ServerQuotas {
 		MaxUsers:                  maxUsers,
 		MaxTotalStorageBytes:      100 * 1024 * 1024 * 1024, // 100 GiB
 		MaxEgressBandwidthBps:     0,                        // unlimited
+		MinFreeDiskBytes:          256 * 1024 * 1024,        // 256 MiB
 		NotificationRetentionDays: 90,                       // 90 days
 		MaxNotificationsPerUser:   500,                      // 500 per user
 	}
@@ -190,6 +489,14 @@ func (c *ServerConfig) Validate() error {
 	if len(c.JWTSecret) < 32 {
 		return errors.New("jwt_secret must be at least 32 bytes")
 	}
+	for i, k := range c.JWTPreviousKeys {
+		if len(k.Secret) < 32 {
+			return fmt.Errorf("jwt_previous_keys[%d]: secret must be at least 32 bytes", i)
+		}
+		if k.ID != "" && k.ID == c.JWTKeyID {
+			return fmt.Errorf("jwt_previous_keys[%d]: id %q is the current jwt_key_id", i, k.ID)
+		}
+	}
 	if err := c.SMTP.Validate(); err != nil {
 		return fmt.Errorf("smtp: %w", err)
 	}
@@ -199,16 +506,41 @@ func (c *ServerConfig) Validate() error {
 	if err := c.RateLimits.Validate(); err != nil {
 		return fmt.Errorf("rate_limits: %w", err)
 	}
+	if err := c.IPRateLimits.Validate(); err != nil {
+		return fmt.Errorf("ip_rate_limits: %w", err)
+	}
+	if err := c.CORS.Validate(); err != nil {
+		return fmt.Errorf("cors: %w", err)
+	}
+	if err := c.Auth.Validate(); err != nil {
+		return fmt.Errorf("auth: %w", err)
+	}
+	if err := c.SecurityHeaders.Validate(); err != nil {
+		return fmt.Errorf("security_headers: %w", err)
+	}
+	if err := c.LoginLockout.Validate(); err != nil {
+		return fmt.Errorf("login_lockout: %w", err)
+	}
 	return nil
 }
 
+// serverConfigFile is the name of the server configuration in the data
+// directory.
+const serverConfigFile = "server_config.json"
+
+// defaultServerConfig returns the configuration that the fields missing from
+// server_config.json default to.
+func defaultServerConfig() ServerConfig {
+	return ServerConfig{Quotas: DefaultServerQuotas(), RateLimits: DefaultRateLimits(), IPRateLimits: DefaultIPRateLimits(), LoginLockout: DefaultLoginLockout()}
+}
+
 // LoadServerConfig loads configuration from dataDir/server_config.json.
 // Creates the file with defaults if it doesn't exist.
 // Auto-generates JWTSecret if empty.
 func LoadServerConfig(dataDir string) (*ServerConfig, error) {
-	path := filepath.Join(dataDir, "server_config.json")
+	path := filepath.Join(dataDir, serverConfigFile)
 
-	cfg := ServerConfig{Quotas: DefaultServerQuotas(), RateLimits: DefaultRateLimits()}
+	cfg := defaultServerConfig()
 
 	data, err := os.ReadFile(path) //nolint:gosec // G304: path is constructed from dataDir, not user input
 	if err != nil {
@@ -269,7 +601,7 @@ func (c *ServerConfig) Save(dataDir string) error {
 		return fmt.Errorf("failed to marshal config: %w", err)
 	}
 	data = append(data, '\n')
-	if err := os.WriteFile(filepath.Join(dataDir, "server_config.json"), data, 0o600); err != nil {
+	if err := os.WriteFile(filepath.Join(dataDir, serverConfigFile), data, 0o600); err != nil {
 		return fmt.Errorf("failed to write config.json: %w", err)
 	}
 	return nil
@@ -0,0 +1,112 @@
+package storage
+
+import "testing"
+
+func TestCORSConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     CORSConfig
+		wantErr bool
+	}{
+		{"empty", CORSConfig{}, false},
+		{"explicit", CORSConfig{AllowedOrigins: []string{"https://app.example", "http://localhost:5173"}, AllowCredentials: true}, false},
+		{"wildcard", CORSConfig{AllowedOrigins: []string{"*"}}, false},
+		{"wildcard with credentials", CORSConfig{AllowedOrigins: []string{"*"}, AllowCredentials: true}, true},
+		{"wildcard mixed", CORSConfig{AllowedOrigins: []string{"*", "https://app.example"}}, true},
+		{"path", CORSConfig{AllowedOrigins: []string{"https://app.example/"}}, true},
+		{"no scheme", CORSConfig{AllowedOrigins: []string{"app.example"}}, true},
+		{"negative max age", CORSConfig{MaxAgeSeconds: -1}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.cfg.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestAuthConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     AuthConfig
+		wantErr bool
+	}{
+		{"defaults", AuthConfig{}, false},
+		{"short access", AuthConfig{AccessTokenTTLSeconds: 900}, false},
+		{"explicit", AuthConfig{AccessTokenTTLSeconds: 900, RefreshTokenTTLSeconds: 3600}, false},
+		{"negative access", AuthConfig{AccessTokenTTLSeconds: -1}, true},
+		{"negative refresh", AuthConfig{RefreshTokenTTLSeconds: -1}, true},
+		{"refresh shorter than access", AuthConfig{AccessTokenTTLSeconds: 3600, RefreshTokenTTLSeconds: 60}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.cfg.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSecurityHeadersConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     SecurityHeadersConfig
+		wantErr bool
+	}{
+		{"defaults", SecurityHeadersConfig{}, false},
+		{"sameorigin", SecurityHeadersConfig{FrameOptions: "SAMEORIGIN"}, false},
+		{"unknown frame options", SecurityHeadersConfig{FrameOptions: "ALLOW-FROM https://x.example"}, true},
+		{"newline", SecurityHeadersConfig{ContentSecurityPolicy: "default-src 'self'\r\nX-Evil: 1"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.cfg.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoginLockout_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     LoginLockout
+		wantErr bool
+	}{
+		{"disabled", LoginLockout{}, false},
+		{"defaults", DefaultLoginLockout(), false},
+		{"negative", LoginLockout{MaxIPFailures: -1}, true},
+		{"threshold without window", LoginLockout{MaxAccountFailures: 5, LockoutSeconds: 60}, true},
+		{"threshold without lockout", LoginLockout{MaxIPFailures: 5, WindowSeconds: 60}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.cfg.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestIPRateLimits_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     IPRateLimits
+		wantErr bool
+	}{
+		{"disabled", IPRateLimits{}, false},
+		{"defaults", DefaultIPRateLimits(), false},
+		{"negative", IPRateLimits{AuthBurst: -1}, true},
+		{"proxies", IPRateLimits{TrustedProxies: []string{"10.0.0.0/8", "::1", "192.0.2.7"}}, false},
+		{"invalid proxy", IPRateLimits{TrustedProxies: []string{"proxy.example"}}, true},
+		{"invalid range", IPRateLimits{TrustedProxies: []string{"10.0.0.0/33"}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.cfg.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
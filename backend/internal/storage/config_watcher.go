@@ -0,0 +1,145 @@
+// Reloads server_config.json when it changes on disk.
+
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// Config holds the live server configuration.
+//
+// The configuration is replaced as a whole and never modified in place, so
+// readers always see a consistent snapshot without locking.
+type Config struct {
+	cur      atomic.Pointer[ServerConfig]
+	mu       sync.Mutex // Serializes Store and protects onChange
+	onChange []func(prev, cur *ServerConfig)
+}
+
+// NewConfig returns a live configuration starting at cfg.
+func NewConfig(cfg *ServerConfig) *Config {
+	c := &Config{}
+	c.cur.Store(cfg)
+	return c
+}
+
+// Current returns the current configuration. The caller must not modify it.
+func (c *Config) Current() *ServerConfig {
+	return c.cur.Load()
+}
+
+// Store replaces the configuration and calls the OnChange callbacks.
+func (c *Config) Store(cfg *ServerConfig) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	prev := c.cur.Swap(cfg)
+	for _, fn := range c.onChange {
+		fn(prev, cfg)
+	}
+}
+
+// OnChange registers fn to be called after each Store, with the previous and
+// the new configuration. Use it to push values into services that copied them
+// at construction, like limiters.
+func (c *Config) OnChange(fn func(prev, cur *ServerConfig)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.onChange = append(c.onChange, fn)
+}
+
+// configReloadDelay coalesces the events of a single save; editors often
+// truncate then write, or write to a temporary file then rename it.
+const configReloadDelay = 100 * time.Millisecond
+
+// ConfigWatcher reloads server_config.json into a Config when it changes.
+//
+// An edit that fails to parse or validate is logged and ignored, keeping the
+// last good configuration. Settings that services only read at startup, like
+// the SMTP server of the email service or CORS, still need a restart.
+type ConfigWatcher struct {
+	dataDir string
+	cfg     *Config
+	w       *fsnotify.Watcher
+}
+
+// NewConfigWatcher watches dataDir/server_config.json. The directory is
+// watched rather than the file so that atomic replacements are seen.
+func NewConfigWatcher(dataDir string, cfg *Config) (*ConfigWatcher, error) {
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	if err := w.Add(dataDir); err != nil {
+		_ = w.Close()
+		return nil, err
+	}
+	return &ConfigWatcher{dataDir: dataDir, cfg: cfg, w: w}, nil
+}
+
+// Run reloads the configuration on changes until ctx is canceled.
+func (cw *ConfigWatcher) Run(ctx context.Context) {
+	defer func() { _ = cw.w.Close() }()
+	path := filepath.Join(cw.dataDir, serverConfigFile)
+	timer := time.NewTimer(0)
+	<-timer.C
+	for {
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case event, ok := <-cw.w.Events:
+			if !ok {
+				return
+			}
+			if filepath.Clean(event.Name) == path && event.Has(fsnotify.Write|fsnotify.Create|fsnotify.Rename) {
+				timer.Reset(configReloadDelay)
+			}
+		case <-timer.C:
+			if err := cw.Reload(); err != nil {
+				slog.WarnContext(ctx, "Ignoring invalid server_config.json", "err", err)
+			} else {
+				slog.InfoContext(ctx, "Reloaded server_config.json")
+			}
+		case err, ok := <-cw.w.Errors:
+			if !ok {
+				return
+			}
+			slog.WarnContext(ctx, "Error watching server_config.json", "err", err)
+		}
+	}
+}
+
+// Reload reads server_config.json and stores it if valid.
+//
+// Unlike LoadServerConfig, it never writes the file: a missing JWT secret or
+// VAPID key pair is an error instead of being generated, since replacing them
+// would log out every user and break every push subscription.
+func (cw *ConfigWatcher) Reload() error {
+	data, err := os.ReadFile(filepath.Join(cw.dataDir, serverConfigFile)) //nolint:gosec // G304: path is constructed from dataDir, not user input
+	if err != nil {
+		return err
+	}
+	cfg := defaultServerConfig()
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return fmt.Errorf("failed to parse: %w", err)
+	}
+	if cfg.VAPID.PublicKey == "" || cfg.VAPID.PrivateKey == "" {
+		return errors.New("vapid key pair is required")
+	}
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+	cw.cfg.Store(&cfg)
+	return nil
+}
@@ -0,0 +1,85 @@
+package storage
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestConfigWatcher(t *testing.T) {
+	dir := t.TempDir()
+	initial, err := LoadServerConfig(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	live := NewConfig(initial)
+	changed := make(chan *ServerConfig, 10)
+	live.OnChange(func(_, cur *ServerConfig) { changed <- cur })
+	cw, err := NewConfigWatcher(dir, live)
+	if err != nil {
+		t.Fatal(err)
+	}
+	go cw.Run(t.Context())
+
+	waitChange := func(t *testing.T) *ServerConfig {
+		t.Helper()
+		select {
+		case cur := <-changed:
+			return cur
+		case <-time.After(5 * time.Second):
+			t.Fatal("configuration was not reloaded")
+			return nil
+		}
+	}
+
+	t.Run("Quotas", func(t *testing.T) {
+		edited := *live.Current()
+		edited.Quotas.MaxPages = 42
+		edited.Quotas.MaxUsers = 7
+		if err := edited.Save(dir); err != nil {
+			t.Fatal(err)
+		}
+		cur := waitChange(t)
+		if cur.Quotas.MaxPages != 42 || cur.Quotas.MaxUsers != 7 {
+			t.Fatalf("OnChange got quotas %+v", cur.Quotas)
+		}
+		if got := live.Current(); got != cur {
+			t.Fatal("Current() does not return the reloaded configuration")
+		}
+		if !bytes.Equal(live.Current().JWTSecret, initial.JWTSecret) {
+			t.Fatal("JWT secret changed")
+		}
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		last := live.Current()
+		if err := os.WriteFile(filepath.Join(dir, serverConfigFile), []byte("{not json"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if err := cw.Reload(); err == nil {
+			t.Fatal("expected error")
+		}
+		// Let the watcher see the edit too; it must keep the last good one.
+		time.Sleep(3 * configReloadDelay)
+		if live.Current() != last {
+			t.Fatal("invalid configuration was applied")
+		}
+		select {
+		case cur := <-changed:
+			t.Fatalf("unexpected reload: %+v", cur.Quotas)
+		default:
+		}
+
+		// A valid edit afterward is applied again.
+		edited := *last
+		edited.Quotas.MaxPages = 43
+		if err := edited.Save(dir); err != nil {
+			t.Fatal(err)
+		}
+		if cur := waitChange(t); cur.Quotas.MaxPages != 43 {
+			t.Fatalf("MaxPages = %d", cur.Quotas.MaxPages)
+		}
+	})
+}
@@ -0,0 +1,457 @@
+// Exports and imports a workspace's files as a zip archive.
+
+package content
+
+import (
+	"archive/zip"
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/maruel/ksid"
+	"github.com/maruel/mddb/backend/internal/storage/git"
+)
+
+// ExportZip writes every file of the workspace to w as a zip archive, at its
+// path relative to the workspace directory.
+//
+// The archive holds the raw storage layout (index.md, metadata.json,
+// data.jsonl, assets) so it can be restored elsewhere. The .git directory is
+// skipped. Assets held in the workspace blob store are written as plain files
+// in their node directory, as older assets are stored. Files are streamed one
+// at a time so large assets are never held in memory.
+func (ws *WorkspaceFileStore) ExportZip(ctx context.Context, w io.Writer) error {
+	zw := zip.NewWriter(w)
+	dirs := map[ksid.ID]string{} // Node directory of each ID, for blob store assets.
+	err := filepath.WalkDir(ws.wsDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(ws.wsDir, path)
+		if err != nil {
+			return err
+		}
+		if isAssetStorePath(rel) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			if d.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			if id, err := ksid.Parse(d.Name()); err == nil && isNodeDir(d) {
+				dirs[id] = filepath.ToSlash(rel)
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		return exportZipFile(zw, path, filepath.ToSlash(rel), d)
+	})
+	if err != nil {
+		return err
+	}
+	if err := ws.exportAssetRefs(zw, dirs); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// exportZipFile streams the file at path into the archive as name.
+func exportZipFile(zw *zip.Writer, path, name string, d fs.DirEntry) error {
+	info, err := d.Info()
+	if err != nil {
+		return err
+	}
+	hdr, err := zip.FileInfoHeader(info)
+	if err != nil {
+		return err
+	}
+	hdr.Name = name
+	hdr.Method = zip.Deflate
+	f, err := os.Open(path) //nolint:gosec // G304: path comes from walking the workspace directory
+	if err != nil {
+		return err
+	}
+	defer func() { _ = f.Close() }()
+	dst, err := zw.CreateHeader(hdr)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(dst, f)
+	return err
+}
+
+// ImportStats reports what ImportZip added to a workspace.
+type ImportStats struct {
+	Nodes   int `json:"nodes"`
+	Pages   int `json:"pages"`
+	Tables  int `json:"tables"`
+	Records int `json:"records"`
+	Assets  int `json:"assets"`
+	Skipped int `json:"skipped"` // Entries outside any node directory, or not understood.
+}
+
+// ImportZip recreates the nodes of a zip archive produced by ExportZip, or
+// with the same layout, at the root of the workspace and commits them to git.
+//
+// Every node gets a fresh ID and relative markdown links between imported
+// pages are rewritten to the new IDs. Page, table, tree and storage quotas are
+// checked before anything is written, and entries whose name escapes the
+// archive root are rejected. Files outside node directories, such as
+// AGENTS.md, are skipped.
+func (ws *WorkspaceFileStore) ImportZip(ctx context.Context, r io.Reader, author git.Author) (*ImportStats, error) {
+	// zip needs random access; spool to disk rather than memory.
+	tmp, err := os.CreateTemp("", "mddb-import-*.zip")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp file: %w", err)
+	}
+	defer func() {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+	}()
+	size, err := io.Copy(tmp, r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read archive: %w", err)
+	}
+	zr, err := zip.NewReader(tmp, size)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read archive: %w", err)
+	}
+	plan, err := planZipImport(zr.File)
+	if err != nil {
+		return nil, err
+	}
+	if len(plan.nodes) == 0 {
+		return &plan.stats, nil
+	}
+	if err := ws.checkZipImportQuotas(plan); err != nil {
+		return nil, err
+	}
+
+	// Mint in the original ID order so that siblings keep their order.
+	newIDs := make(map[ksid.ID]ksid.ID, len(plan.nodes))
+	for _, n := range plan.order {
+		newIDs[n.id] = ksid.NewID()
+	}
+	newRel := func(n *zipNode) string {
+		var parts []string
+		for ; n != nil; n = plan.nodes[n.parentID] {
+			parts = append(parts, newIDs[n.id].String())
+		}
+		slices.Reverse(parts)
+		return filepath.Join(parts...)
+	}
+
+	stats := plan.stats
+	err = ws.repo.CommitTx(ctx, author, func() (string, []string, error) {
+		var roots []string
+		for _, n := range plan.order {
+			if n.parentID.IsZero() {
+				roots = append(roots, newRel(n))
+			}
+		}
+		for _, n := range plan.order {
+			if err := ws.importZipNode(ctx, filepath.Join(ws.wsDir, newRel(n)), n, newIDs); err != nil {
+				for _, root := range roots {
+					_ = os.RemoveAll(filepath.Join(ws.wsDir, root))
+				}
+				return "", nil, err
+			}
+		}
+		msg := "import: " + strconv.Itoa(len(plan.nodes)) + " nodes from zip"
+		return git.AppendTrailers(msg,
+			git.Trailer{Key: git.TrailerOp, Value: "import"},
+			git.Trailer{Key: git.TrailerType, Value: "node"},
+			git.Trailer{Key: git.TrailerActor, Value: author.ID},
+		), roots, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := ws.refreshCache(); err != nil {
+		return nil, err
+	}
+	var pages []*Node
+	for _, n := range plan.order {
+		id := newIDs[n.id]
+		if n.hasPage {
+			if node, err := ws.ReadPage(id); err == nil {
+				pages = append(pages, node)
+			}
+		}
+		if n.hasTable {
+			if count, err := ws.CountRecords(id); err == nil {
+				stats.Records += count
+			}
+		}
+	}
+	if len(pages) != 0 {
+		ws.pageWritten(pages...)
+	}
+	return &stats, nil
+}
+
+// zipNode is a node directory found in an archive.
+type zipNode struct {
+	id       ksid.ID // ID in the archive
+	parentID ksid.ID // zero at the root
+	files    []zipNodeFile
+	hasPage  bool
+	hasTable bool
+	children int
+	depth    int // 1 for root nodes
+}
+
+// zipNodeFile is a file of a node directory.
+type zipNodeFile struct {
+	name string // slash-separated path within the node directory
+	f    *zip.File
+}
+
+// zipImportPlan is the validated content of an archive.
+type zipImportPlan struct {
+	nodes map[ksid.ID]*zipNode
+	order []*zipNode // by depth then ID, which puts parents before children
+	size  int64      // uncompressed bytes to write
+	stats ImportStats
+}
+
+// planZipImport groups the archive entries by node directory and validates
+// their names.
+func planZipImport(files []*zip.File) (*zipImportPlan, error) {
+	plan := &zipImportPlan{nodes: map[ksid.ID]*zipNode{}}
+	for _, f := range files {
+		if !filepath.IsLocal(filepath.FromSlash(f.Name)) || strings.Contains(f.Name, "\\") {
+			return nil, fmt.Errorf("%w: %q", errUnsafeArchivePath, f.Name)
+		}
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		if !f.Mode().IsRegular() {
+			plan.stats.Skipped++
+			continue
+		}
+		segs := strings.Split(f.Name, "/")
+		k := 0
+		var parent *zipNode
+		for ; k < len(segs)-1; k++ {
+			id, err := ksid.Parse(segs[k])
+			if err != nil || id.String() != segs[k] || id.IsZero() {
+				break
+			}
+			n := plan.nodes[id]
+			var parentID ksid.ID
+			if parent != nil {
+				parentID = parent.id
+			}
+			if n == nil {
+				n = &zipNode{id: id, parentID: parentID, depth: k + 1}
+				plan.nodes[id] = n
+				if parent != nil {
+					parent.children++
+				}
+			} else if n.parentID != parentID {
+				return nil, fmt.Errorf("%w: node %s appears under two parents", errInvalidArchive, id)
+			}
+			parent = n
+		}
+		rest := segs[k:]
+		// Only node files, assets and table blob directories are understood.
+		if parent == nil || (len(rest) > 1 && !strings.HasSuffix(rest[0], ".blobs")) {
+			plan.stats.Skipped++
+			continue
+		}
+		name := path.Join(rest...)
+		parent.files = append(parent.files, zipNodeFile{name: name, f: f})
+		plan.size += int64(f.UncompressedSize64) //nolint:gosec // G115: sizes beyond int64 fail the storage quota anyway
+		switch {
+		case name == "index.md":
+			parent.hasPage = true
+		case name == "metadata.json":
+			parent.hasTable = true
+		case name == "data.jsonl" || len(rest) > 1:
+			// Table records and blobs.
+		default:
+			plan.stats.Assets++
+		}
+	}
+	for _, n := range plan.nodes {
+		plan.order = append(plan.order, n)
+		if n.hasPage {
+			plan.stats.Pages++
+		}
+		if n.hasTable {
+			plan.stats.Tables++
+		}
+	}
+	slices.SortFunc(plan.order, func(a, b *zipNode) int {
+		if a.depth != b.depth {
+			return a.depth - b.depth
+		}
+		return cmp.Compare(a.id, b.id)
+	})
+	plan.stats.Nodes = len(plan.nodes)
+	return plan, nil
+}
+
+// checkZipImportQuotas returns an error if importing plan at the root would
+// exceed a quota.
+func (ws *WorkspaceFileStore) checkZipImportQuotas(plan *zipImportPlan) error {
+	if err := ws.checkBulkQuotas(plan.stats.Pages, plan.stats.Tables, plan.size); err != nil {
+		return err
+	}
+	rootChildren, err := countChildNodes(ws.wsDir)
+	if err != nil {
+		return err
+	}
+	for _, n := range plan.nodes {
+		if n.depth > ws.quotas.MaxNodeDepth {
+			return ErrNodeDepthExceeded
+		}
+		if n.children > ws.quotas.MaxChildrenPerNode {
+			return ErrTooManyChildren
+		}
+		if n.parentID.IsZero() {
+			rootChildren++
+		}
+	}
+	if rootChildren > ws.quotas.MaxChildrenPerNode {
+		return ErrTooManyChildren
+	}
+	return nil
+}
+
+// importZipNode writes the files of n into dir, rewriting the links of its
+// page to the new node IDs.
+func (ws *WorkspaceFileStore) importZipNode(ctx context.Context, dir string, n *zipNode, newIDs map[ksid.ID]ksid.ID) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil { //nolint:gosec // G301: 0o755 is intentional for user data directories
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+	for _, file := range n.files {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := importZipFile(filepath.Join(dir, filepath.FromSlash(file.name)), file, newIDs); err != nil {
+			return fmt.Errorf("failed to import %s: %w", file.f.Name, err)
+		}
+	}
+	return nil
+}
+
+// importZipFile extracts one archive entry to dst.
+func importZipFile(dst string, file zipNodeFile, newIDs map[ksid.ID]ksid.ID) error {
+	src, err := file.f.Open()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = src.Close() }()
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil { //nolint:gosec // G301: 0o755 is intentional for user data directories
+		return err
+	}
+	switch file.name {
+	case "index.md":
+		data, err := io.ReadAll(src)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(dst, []byte(remapLinks(string(data), newIDs)), 0o644) //nolint:gosec // G306: 0o644 is intentional for user data files
+	case "metadata.json":
+		data, err := io.ReadAll(src)
+		if err != nil {
+			return err
+		}
+		if data, err = remapRelations(data, newIDs); err != nil {
+			return err
+		}
+		return os.WriteFile(dst, data, 0o644) //nolint:gosec // G306: 0o644 is intentional for user data files
+	}
+	f, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644) //nolint:gosec // G302,G304: dst is within a freshly created node directory
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(f, src)
+	return errors.Join(err, f.Close())
+}
+
+// remapRelations rewrites the target tables of the relation properties in
+// the table metadata to their new IDs. Targets outside of ids and unknown
+// fields are kept as is.
+func remapRelations(data []byte, ids map[ksid.ID]ksid.ID) ([]byte, error) {
+	var m map[string]json.RawMessage
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, err
+	}
+	var props []map[string]json.RawMessage
+	if raw, ok := m["properties"]; !ok || json.Unmarshal(raw, &props) != nil {
+		return data, nil
+	}
+	changed := false
+	for _, prop := range props {
+		var rc map[string]json.RawMessage
+		if raw, ok := prop["relation_config"]; !ok || json.Unmarshal(raw, &rc) != nil {
+			continue
+		}
+		var target ksid.ID
+		if err := json.Unmarshal(rc["target_node_id"], &target); err != nil {
+			continue
+		}
+		newID, ok := ids[target]
+		if !ok {
+			continue
+		}
+		rc["target_node_id"], _ = json.Marshal(newID)
+		prop["relation_config"], _ = json.Marshal(rc)
+		changed = true
+	}
+	if !changed {
+		return data, nil
+	}
+	m["properties"], _ = json.Marshal(props)
+	return json.Marshal(m)
+}
+
+// remapLinks rewrites the node IDs in relative page links and wikilinks
+// according to ids. Links to nodes not in ids are left alone.
+func remapLinks(content string, ids map[ksid.ID]ksid.ID) string {
+	content = wikiNodeLinkRe.ReplaceAllStringFunc(content, func(m string) string {
+		sub := wikiNodeLinkRe.FindStringSubmatch(m)
+		id, ok := wikiLinkTargetID(sub[1])
+		if !ok {
+			return m
+		}
+		if newID, ok := ids[id]; ok {
+			return "[[" + newID.String() + m[2+len(sub[1]):]
+		}
+		return m
+	})
+	return relativeLinkRe.ReplaceAllStringFunc(content, func(m string) string {
+		i := strings.Index(m, "](") + 2
+		segs := strings.Split(m[i:len(m)-1], "/")
+		for j, seg := range segs {
+			if id, err := ksid.Parse(seg); err == nil && id.String() == seg {
+				if newID, ok := ids[id]; ok {
+					segs[j] = newID.String()
+				}
+			}
+		}
+		return m[:i] + strings.Join(segs, "/") + ")"
+	})
+}
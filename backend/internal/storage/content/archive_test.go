@@ -0,0 +1,245 @@
+package content
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"io"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/maruel/ksid"
+	"github.com/maruel/mddb/backend/internal/storage/git"
+)
+
+// readZip returns the content of every file in a zip archive by name.
+func readZip(t *testing.T, data []byte) map[string][]byte {
+	t.Helper()
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := map[string][]byte{}
+	for _, f := range zr.File {
+		r, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := io.ReadAll(r)
+		_ = r.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		files[f.Name] = b
+	}
+	return files
+}
+
+func TestExportZip(t *testing.T) {
+	_, ws, _ := initWS(t)
+	ctx := t.Context()
+	author := git.Author{Name: "Test", Email: "test@test.com"}
+
+	// A chain of nested pages.
+	var dirs []string
+	var parentID ksid.ID
+	for i := range 6 {
+		node, err := ws.CreatePageUnderParent(ctx, parentID, "Level", strings.Repeat("deep ", i), author)
+		if err != nil {
+			t.Fatal(err)
+		}
+		dirs = append(dirs, node.ID.String())
+		parentID = node.ID
+	}
+	table, err := ws.CreateTableUnderParent(ctx, 0, "Tasks", []Property{{Name: "Name", Type: PropertyTypeText}}, author)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ws.AppendRecord(ctx, table.ID, &DataRecord{ID: ksid.NewID(), Data: map[string]any{"Name": "x"}}, author); err != nil {
+		t.Fatal(err)
+	}
+	large := bytes.Repeat([]byte("0123456789"), 300_000)
+	if _, err := ws.SaveAsset(ctx, parentID, "large.bin", large, author); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := ws.ExportZip(ctx, &buf); err != nil {
+		t.Fatal(err)
+	}
+	files := readZip(t, buf.Bytes())
+
+	deepest := path.Join(dirs...)
+	for _, name := range []string{
+		path.Join(dirs[0], "index.md"),
+		path.Join(deepest, "index.md"),
+		path.Join(table.ID.String(), "metadata.json"),
+		path.Join(table.ID.String(), "data.jsonl"),
+		"AGENTS.md",
+	} {
+		if _, ok := files[name]; !ok {
+			t.Errorf("%s missing from archive", name)
+		}
+	}
+	if got := files[path.Join(deepest, "large.bin")]; !bytes.Equal(got, large) {
+		t.Errorf("large asset: got %d bytes, want %d", len(got), len(large))
+	}
+	if !bytes.Contains(files[path.Join(deepest, "index.md")], []byte("deep deep deep deep deep")) {
+		t.Errorf("unexpected deepest page:\n%s", files[path.Join(deepest, "index.md")])
+	}
+	for name := range files {
+		if name == ".git" || strings.HasPrefix(name, ".git/") || name == assetsFile || strings.HasPrefix(name, "assets.blobs/") {
+			t.Errorf("archive contains %s", name)
+		}
+	}
+}
+
+func TestImportZip(t *testing.T) {
+	author := git.Author{Name: "Test", Email: "test@test.com"}
+
+	// export builds a small workspace and returns its archive.
+	export := func(t *testing.T) (data []byte, parent, child *Node) {
+		_, src, _ := initWS(t)
+		ctx := t.Context()
+		var err error
+		if parent, err = src.CreatePageUnderParent(ctx, 0, "Parent", "", author); err != nil {
+			t.Fatal(err)
+		}
+		if child, err = src.CreatePageUnderParent(ctx, parent.ID, "Child", "Back to [parent](../index.md) and ![logo](logo.png).", author); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := src.UpdatePage(ctx, parent.ID, "Parent", "See [child]("+child.ID.String()+"/index.md).", author); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := src.SaveAsset(ctx, child.ID, "logo.png", []byte("png"), author); err != nil {
+			t.Fatal(err)
+		}
+		table, err := src.CreateTableUnderParent(ctx, 0, "Tasks", []Property{{Name: "Name", Type: PropertyTypeText}}, author)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// A relation to the table itself, to check that its target is remapped.
+		table.Properties = append(table.Properties, Property{Name: "Parent", Type: PropertyTypeRelation, RelationConfig: &RelationConfig{TargetNodeID: table.ID}})
+		if err := src.WriteTable(ctx, table, false, author); err != nil {
+			t.Fatal(err)
+		}
+		for _, name := range []string{"a", "b"} {
+			if err := src.AppendRecord(ctx, table.ID, &DataRecord{ID: ksid.NewID(), Data: map[string]any{"Name": name}}, author); err != nil {
+				t.Fatal(err)
+			}
+		}
+		var buf bytes.Buffer
+		if err := src.ExportZip(ctx, &buf); err != nil {
+			t.Fatal(err)
+		}
+		return buf.Bytes(), parent, child
+	}
+
+	t.Run("RoundTrip", func(t *testing.T) {
+		data, oldParent, oldChild := export(t)
+		_, ws, _ := initWS(t)
+		ctx := t.Context()
+		before, err := ws.CommitCount(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		stats, err := ws.ImportZip(ctx, bytes.NewReader(data), author)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := ImportStats{Nodes: 3, Pages: 2, Tables: 1, Records: 2, Assets: 1, Skipped: 1}
+		if *stats != want {
+			t.Errorf("stats = %+v, want %+v", *stats, want)
+		}
+		if after, err := ws.CommitCount(ctx); err != nil || after != before+1 {
+			t.Errorf("want one commit, got %d (err %v)", after-before, err)
+		}
+
+		roots, err := ws.ListChildren(0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var parent, tasks *Node
+		for _, n := range roots {
+			if n.ID == oldParent.ID {
+				t.Error("imported node kept its old ID")
+			}
+			switch n.Title {
+			case "Parent":
+				parent = n
+			case "Tasks":
+				tasks = n
+			}
+		}
+		if parent == nil || tasks == nil || len(roots) != 2 {
+			t.Fatalf("unexpected roots: %+v", roots)
+		}
+		if table, err := ws.ReadTable(tasks.ID); err != nil {
+			t.Fatal(err)
+		} else if rc := table.Properties[1].RelationConfig; rc == nil || rc.TargetNodeID != tasks.ID {
+			t.Errorf("relation target not remapped: %+v", rc)
+		}
+		children, err := ws.ListChildren(parent.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(children) != 1 || children[0].Title != "Child" || children[0].ID == oldChild.ID {
+			t.Fatalf("unexpected children: %+v", children)
+		}
+		child := children[0]
+		page, err := ws.ReadPage(parent.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := "See [child](" + child.ID.String() + "/index.md)."; page.Content != want {
+			t.Errorf("links not rewritten: %q, want %q", page.Content, want)
+		}
+		if got, err := ws.ReadAsset(child.ID, "logo.png"); err != nil || string(got) != "png" {
+			t.Errorf("asset: %q, %v", got, err)
+		}
+		backlinks, err := ws.GetBacklinks(child.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(backlinks) != 1 || backlinks[0].NodeID != parent.ID {
+			t.Errorf("unexpected backlinks: %+v", backlinks)
+		}
+	})
+
+	t.Run("PathTraversal", func(t *testing.T) {
+		_, ws, _ := initWS(t)
+		for _, name := range []string{"../evil.md", "a/../../evil.md", "/abs/evil.md"} {
+			var buf bytes.Buffer
+			zw := zip.NewWriter(&buf)
+			if _, err := zw.Create(ksid.NewID().String() + "/index.md"); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := zw.Create(name); err != nil {
+				t.Fatal(err)
+			}
+			if err := zw.Close(); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := ws.ImportZip(t.Context(), &buf, author); !errors.Is(err, errUnsafeArchivePath) {
+				t.Errorf("%s: got %v, want errUnsafeArchivePath", name, err)
+			}
+		}
+		if roots, err := ws.ListChildren(0); err != nil || len(roots) != 0 {
+			t.Errorf("nothing should be imported: %+v, %v", roots, err)
+		}
+	})
+
+	t.Run("Quota", func(t *testing.T) {
+		data, _, _ := export(t)
+		_, ws, _ := initWS(t)
+		ws.quotas.MaxPages = 1
+		if _, err := ws.ImportZip(t.Context(), bytes.NewReader(data), author); !errors.Is(err, ErrQuotaExceeded) {
+			t.Errorf("got %v, want ErrQuotaExceeded", err)
+		}
+		if roots, err := ws.ListChildren(0); err != nil || len(roots) != 0 {
+			t.Errorf("nothing should be imported: %+v, %v", roots, err)
+		}
+	})
+}
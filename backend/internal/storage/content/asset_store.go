@@ -0,0 +1,230 @@
+// Stores asset bytes once per workspace, deduplicated by content.
+
+package content
+
+import (
+	"archive/zip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/maruel/ksid"
+	"github.com/maruel/mddb/backend/internal/jsonldb"
+	"github.com/maruel/mddb/backend/internal/storage"
+)
+
+// assetsFile is the workspace table referencing the assets of every node. The
+// bytes live in assetBlobsDir, content-addressed, so identical uploads share a
+// single file. Unlike table blobs, asset blobs are committed so that git
+// history and remotes keep the assets.
+const (
+	assetsFile    = "assets.jsonl"
+	assetBlobsDir = "assets.blobs"
+)
+
+var errAssetNodeRequired = errors.New("asset node ID is required")
+
+// assetRef is a row of the assets table: the bytes of a node's asset.
+type assetRef struct {
+	ID      ksid.ID      `json:"id"`
+	NodeID  ksid.ID      `json:"node_id"`
+	Name    string       `json:"name"`
+	Blob    jsonldb.Blob `json:"blob"`
+	Created storage.Time `json:"created"`
+}
+
+// Clone returns a copy.
+func (a *assetRef) Clone() *assetRef {
+	c := *a
+	c.Blob = a.Blob.Clone()
+	return &c
+}
+
+// GetID returns the row ID.
+func (a *assetRef) GetID() ksid.ID {
+	return a.ID
+}
+
+// Validate checks required fields.
+func (a *assetRef) Validate() error {
+	if a.ID.IsZero() {
+		return errIDRequired
+	}
+	if a.NodeID.IsZero() {
+		return errAssetNodeRequired
+	}
+	if a.Name == "" {
+		return errNameRequired
+	}
+	return nil
+}
+
+// asset returns the API representation of the reference.
+func (a *assetRef) asset() *Asset {
+	size, _ := a.Blob.Ref.Size()
+	return &Asset{
+		ID:       a.Name,
+		Name:     a.Name,
+		MimeType: mime.TypeByExtension(filepath.Ext(a.Name)),
+		Size:     size,
+		Created:  a.Created,
+	}
+}
+
+// assetTable is the opened assets table with its node index.
+type assetTable struct {
+	table  *jsonldb.Table[*assetRef]
+	byNode *jsonldb.Index[ksid.ID, *assetRef]
+}
+
+// get returns the reference of a node's asset, or nil.
+func (at *assetTable) get(nodeID ksid.ID, name string) *assetRef {
+	for a := range at.byNode.Iter(nodeID) {
+		if a.Name == name {
+			return a
+		}
+	}
+	return nil
+}
+
+// list returns the references of a node's assets.
+func (at *assetTable) list(nodeID ksid.ID) []*assetRef {
+	return slices.Collect(at.byNode.Iter(nodeID))
+}
+
+// assetTable returns the workspace assets table, opening it on first use.
+//
+// It stays open for the lifetime of the store. Unreferenced blobs are not
+// collected on load since their removal must be committed; see GCBlobs.
+func (ws *WorkspaceFileStore) assetTable() (*assetTable, error) {
+	ws.assetsMu.Lock()
+	defer ws.assetsMu.Unlock()
+	if ws.assets == nil {
+		table, err := jsonldb.NewTableWithOptions[*assetRef](filepath.Join(ws.wsDir, assetsFile), jsonldb.TableOptions{SkipLoadGC: true})
+		if err != nil {
+			return nil, fmt.Errorf("failed to open assets table: %w", err)
+		}
+		byNode := jsonldb.NewIndex(table, func(a *assetRef) ksid.ID { return a.NodeID })
+		ws.assets = &assetTable{table: table, byNode: byNode}
+	}
+	return ws.assets, nil
+}
+
+// putAssetRef stores data as the asset name of nodeID, replacing the previous
+// content if any.
+func (ws *WorkspaceFileStore) putAssetRef(nodeID ksid.ID, name string, data []byte) (*assetRef, error) {
+	at, err := ws.assetTable()
+	if err != nil {
+		return nil, err
+	}
+	w, err := at.table.NewBlob()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create asset blob: %w", err)
+	}
+	if _, err := w.Write(data); err != nil {
+		return nil, errors.Join(fmt.Errorf("failed to write asset blob: %w", err), w.Abort())
+	}
+	blob, err := w.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to write asset blob: %w", err)
+	}
+
+	a := at.get(nodeID, name)
+	if a == nil {
+		a = &assetRef{ID: ksid.NewID(), NodeID: nodeID, Name: name, Blob: blob, Created: storage.Now()}
+		if err := at.table.Append(a); err != nil {
+			return nil, fmt.Errorf("failed to save asset: %w", err)
+		}
+		return a, nil
+	}
+	a.Blob = blob
+	a.Created = storage.Now()
+	if _, err := at.table.Update(a); err != nil {
+		return nil, fmt.Errorf("failed to save asset: %w", err)
+	}
+	return a, nil
+}
+
+// deleteAssetRefs deletes the asset references of the nodes without
+// committing. A blob is removed along with its last reference. Returns the
+// paths to commit, if anything was deleted.
+func (ws *WorkspaceFileStore) deleteAssetRefs(nodeIDs ...ksid.ID) ([]string, error) {
+	at, err := ws.assetTable()
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, nodeID := range nodeIDs {
+		for _, a := range at.list(nodeID) {
+			if _, err := at.table.Delete(a.ID); err != nil {
+				return nil, fmt.Errorf("failed to delete asset: %w", err)
+			}
+			files = []string{assetsFile, assetBlobsDir}
+		}
+	}
+	return files, nil
+}
+
+// assetFileInfo describes an asset stored in a blob.
+type assetFileInfo struct {
+	name    string
+	size    int64
+	modTime time.Time
+	blob    jsonldb.BlobRef
+}
+
+func (i *assetFileInfo) Name() string       { return i.name }
+func (i *assetFileInfo) Size() int64        { return i.size }
+func (i *assetFileInfo) Mode() fs.FileMode  { return 0o644 }
+func (i *assetFileInfo) ModTime() time.Time { return i.modTime }
+func (i *assetFileInfo) IsDir() bool        { return false }
+func (i *assetFileInfo) Sys() any           { return nil }
+
+// exportAssetRefs writes the assets of the nodes found in dirs, keyed by ID
+// with the slash-separated directory relative to the workspace, as plain
+// files so the archive has the same layout as the workspace.
+func (ws *WorkspaceFileStore) exportAssetRefs(zw *zip.Writer, dirs map[ksid.ID]string) error {
+	at, err := ws.assetTable()
+	if err != nil {
+		return err
+	}
+	for a := range at.table.Iter(0) {
+		dir, ok := dirs[a.NodeID]
+		if !ok {
+			continue
+		}
+		if err := exportAssetRef(zw, at, a, path.Join(dir, a.Name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// exportAssetRef streams the blob of a into the archive as name.
+func exportAssetRef(zw *zip.Writer, at *assetTable, a *assetRef, name string) error {
+	r, err := at.table.OpenBlob(a.Blob)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = r.Close() }()
+	dst, err := zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: a.Created.AsTime()})
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(dst, r)
+	return err
+}
+
+// isAssetStorePath reports whether rel, relative to the workspace directory,
+// belongs to the assets table or its blobs.
+func isAssetStorePath(rel string) bool {
+	return rel == assetsFile || rel == assetBlobsDir || strings.HasPrefix(rel, assetBlobsDir+string(os.PathSeparator))
+}
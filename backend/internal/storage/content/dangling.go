@@ -0,0 +1,63 @@
+// Reports the links a delete would leave dangling, and refuses such deletes.
+
+package content
+
+import (
+	"cmp"
+	"context"
+	"slices"
+
+	"github.com/maruel/ksid"
+	"github.com/maruel/mddb/backend/internal/storage/git"
+)
+
+// DanglingBacklinks returns the pages that link to node id or one of its
+// descendants and would be left with a dangling link if the node was deleted
+// or trashed. Links from within the subtree are not reported since they go
+// along with it. Each linking page is listed once, in ID order.
+func (ws *WorkspaceFileStore) DanglingBacklinks(id ksid.ID) ([]BacklinkInfo, error) {
+	if id.IsZero() || (!ws.PageExists(id) && !ws.TableExists(id)) {
+		return nil, ErrPageNotFound
+	}
+	subtree := append([]ksid.ID{id}, subtreeIDs(ws.pageDir(id, ws.getParent(id)))...)
+	seen := make(map[ksid.ID]bool, len(subtree))
+	for _, n := range subtree {
+		seen[n] = true
+	}
+	var out []BacklinkInfo
+	for _, n := range subtree {
+		backlinks, err := ws.GetBacklinks(n)
+		if err != nil {
+			return nil, err
+		}
+		for _, b := range backlinks {
+			if !seen[b.NodeID] {
+				seen[b.NodeID] = true
+				out = append(out, b)
+			}
+		}
+	}
+	slices.SortFunc(out, func(a, b BacklinkInfo) int { return cmp.Compare(a.NodeID, b.NodeID) })
+	return out, nil
+}
+
+// DeleteNodeChecked deletes node id, along with its descendants, like
+// DeletePage, unless other pages link to it.
+//
+// It returns the pages whose links are or would be left dangling, as reported
+// by DanglingBacklinks. When there are some and force is not set, nothing is
+// deleted and the error is ErrHasBacklinks. The check is not atomic with the
+// delete: a link added concurrently may still be left dangling.
+func (ws *WorkspaceFileStore) DeleteNodeChecked(ctx context.Context, id ksid.ID, force bool, author git.Author) ([]BacklinkInfo, error) {
+	backlinks, err := ws.DanglingBacklinks(id)
+	if err != nil {
+		return nil, err
+	}
+	if len(backlinks) != 0 && !force {
+		return backlinks, ErrHasBacklinks
+	}
+	if err := ws.DeletePage(ctx, id, author); err != nil {
+		return nil, err
+	}
+	return backlinks, nil
+}
@@ -0,0 +1,112 @@
+package content
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/maruel/ksid"
+	"github.com/maruel/mddb/backend/internal/storage/git"
+)
+
+func TestDeleteNodeChecked(t *testing.T) {
+	author := git.Author{Name: "Test", Email: "test@test.com"}
+	_, ws, _ := initWS(t)
+	ctx := t.Context()
+	link := func(id ksid.ID) string { return "[x](../" + id.String() + "/index.md)\n" }
+
+	// target has a child; a links to target, b to the child, and the child to
+	// target, which is not reported since it goes along with it.
+	target, err := ws.CreatePageUnderParent(ctx, 0, "Target", "", author)
+	if err != nil {
+		t.Fatal(err)
+	}
+	child, err := ws.CreatePageUnderParent(ctx, target.ID, "Child", link(target.ID), author)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, err := ws.CreatePageUnderParent(ctx, 0, "A", link(target.ID)+link(child.ID), author)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := ws.CreatePageUnderParent(ctx, 0, "B", link(child.ID), author)
+	if err != nil {
+		t.Fatal(err)
+	}
+	unlinked, err := ws.CreatePageUnderParent(ctx, 0, "Unlinked", "", author)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids := func(backlinks []BacklinkInfo) []ksid.ID {
+		var out []ksid.ID
+		for _, b := range backlinks {
+			out = append(out, b.NodeID)
+		}
+		return out
+	}
+
+	got, err := ws.DanglingBacklinks(target.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []ksid.ID{a.ID, b.ID}; !slices.Equal(ids(got), want) {
+		t.Errorf("DanglingBacklinks = %v, want %v", ids(got), want)
+	}
+
+	t.Run("Refused", func(t *testing.T) {
+		got, err := ws.DeleteNodeChecked(ctx, target.ID, false, author)
+		if !errors.Is(err, ErrHasBacklinks) {
+			t.Fatalf("got %v, want ErrHasBacklinks", err)
+		}
+		if want := []ksid.ID{a.ID, b.ID}; !slices.Equal(ids(got), want) {
+			t.Errorf("backlinks = %v, want %v", ids(got), want)
+		}
+		if !ws.PageExists(target.ID) || !ws.PageExists(child.ID) {
+			t.Error("node deleted despite backlinks")
+		}
+	})
+
+	t.Run("Unlinked", func(t *testing.T) {
+		got, err := ws.DeleteNodeChecked(ctx, unlinked.ID, false, author)
+		if err != nil || len(got) != 0 {
+			t.Fatalf("DeleteNodeChecked = %v, %v", got, err)
+		}
+		if ws.PageExists(unlinked.ID) {
+			t.Error("node not deleted")
+		}
+	})
+
+	t.Run("Force", func(t *testing.T) {
+		got, err := ws.DeleteNodeChecked(ctx, target.ID, true, author)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := []ksid.ID{a.ID, b.ID}; !slices.Equal(ids(got), want) {
+			t.Errorf("backlinks = %v, want %v", ids(got), want)
+		}
+		if ws.PageExists(target.ID) || ws.PageExists(child.ID) {
+			t.Error("node not deleted")
+		}
+		// The reported pages are exactly those now holding dangling links.
+		invalid, err := ws.ValidateLinks()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var sources []ksid.ID
+		for _, l := range invalid {
+			if !slices.Contains(sources, l.SourceID) {
+				sources = append(sources, l.SourceID)
+			}
+		}
+		slices.Sort(sources)
+		if !slices.Equal(sources, ids(got)) {
+			t.Errorf("pages with dangling links = %v, reported %v", sources, ids(got))
+		}
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		if _, err := ws.DeleteNodeChecked(ctx, ksid.NewID(), true, author); !errors.Is(err, ErrPageNotFound) {
+			t.Errorf("got %v, want ErrPageNotFound", err)
+		}
+	})
+}
@@ -0,0 +1,34 @@
+// Guards writes against filling the host disk.
+
+package content
+
+import "errors"
+
+// checkDiskSpace returns ErrDiskFull if writing additionalBytes would leave
+// less than ServerQuotas.MinFreeDiskBytes free on the filesystem holding the
+// data directory. The check is skipped when the threshold is 0 or free space
+// cannot be queried on this platform.
+func (svc *FileStoreService) checkDiskSpace(additionalBytes int64) error {
+	minFree := svc.serverQuotas.Load().MinFreeDiskBytes
+	if minFree <= 0 {
+		return nil
+	}
+	free, err := svc.freeDiskSpace(svc.rootDir)
+	if err != nil {
+		if errors.Is(err, errors.ErrUnsupported) {
+			return nil
+		}
+		return err
+	}
+	if exceedsBytes(minFree, additionalBytes, free) {
+		return ErrDiskFull
+	}
+	return nil
+}
+
+// CheckDiskSpace returns ErrDiskFull if the filesystem holding the data
+// directory has less than ServerQuotas.MinFreeDiskBytes free, e.g. for a
+// readiness probe.
+func (svc *FileStoreService) CheckDiskSpace() error {
+	return svc.checkDiskSpace(0)
+}
@@ -0,0 +1,13 @@
+// Free disk space fallback for platforms without statfs(2).
+
+//go:build !linux && !darwin && !freebsd
+
+package content
+
+import "errors"
+
+// freeDiskSpace is not implemented on this platform; the disk space check is
+// skipped.
+func freeDiskSpace(string) (int64, error) {
+	return 0, errors.ErrUnsupported
+}
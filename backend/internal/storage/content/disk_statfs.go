@@ -0,0 +1,17 @@
+// Queries free disk space with statfs(2).
+
+//go:build linux || darwin || freebsd
+
+package content
+
+import "syscall"
+
+// freeDiskSpace returns the bytes available to unprivileged users on the
+// filesystem holding dir.
+func freeDiskSpace(dir string) (int64, error) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(dir, &st); err != nil {
+		return 0, err
+	}
+	return int64(st.Bavail) * int64(st.Bsize), nil //nolint:gosec,unconvert // G115: block counts fit in int64; field types vary by OS
+}
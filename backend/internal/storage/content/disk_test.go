@@ -0,0 +1,67 @@
+package content
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/maruel/mddb/backend/internal/storage/git"
+)
+
+func TestCheckDiskSpace(t *testing.T) {
+	fs, ws, _ := initWS(t)
+	ctx := t.Context()
+	author := git.Author{Name: "Test", Email: "test@test.com"}
+	node, err := ws.CreatePageUnderParent(ctx, 0, "Page", "", author)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var free int64
+	fs.freeDiskSpace = func(string) (int64, error) { return free, nil }
+	fs.serverQuotas.Load().MinFreeDiskBytes = 1000
+	body := strings.Repeat("x", 100)
+
+	// Enough room: 100 bytes written leaves exactly the threshold.
+	free = 1100
+	if err := fs.checkDiskSpace(100); err != nil {
+		t.Errorf("at threshold: %v", err)
+	}
+	free = 1000 + int64(len(body)) + 4096
+	if _, err := ws.UpdatePage(ctx, node.ID, "Page", body, author); err != nil {
+		t.Fatalf("UpdatePage with room: %v", err)
+	}
+
+	// Below the threshold: writes are refused.
+	free = 1099
+	if err := fs.checkDiskSpace(100); !errors.Is(err, ErrDiskFull) {
+		t.Errorf("one byte short: got %v, want ErrDiskFull", err)
+	}
+	free = 500
+	if _, err := ws.CreatePageUnderParent(ctx, 0, "Other", body, author); !errors.Is(err, ErrDiskFull) {
+		t.Errorf("CreatePageUnderParent: got %v, want ErrDiskFull", err)
+	}
+	if _, err := ws.SaveAsset(ctx, node.ID, "a.txt", []byte(body), author); !errors.Is(err, ErrDiskFull) {
+		t.Errorf("SaveAsset: got %v, want ErrDiskFull", err)
+	}
+	page, err := ws.ReadPage(node.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if page.Content != body {
+		t.Errorf("page changed after refused writes: %q", page.Content)
+	}
+
+	// Disabled.
+	fs.serverQuotas.Load().MinFreeDiskBytes = 0
+	if err := fs.checkDiskSpace(100); err != nil {
+		t.Errorf("disabled: %v", err)
+	}
+
+	// Unsupported platform.
+	fs.serverQuotas.Load().MinFreeDiskBytes = 1000
+	fs.freeDiskSpace = func(string) (int64, error) { return 0, errors.ErrUnsupported }
+	if err := fs.checkDiskSpace(100); err != nil {
+		t.Errorf("unsupported: %v", err)
+	}
+}
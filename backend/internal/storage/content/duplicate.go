@@ -0,0 +1,307 @@
+// Duplicates a node, optionally with its subtree, under new IDs.
+
+package content
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/maruel/ksid"
+	"github.com/maruel/mddb/backend/internal/jsonldb"
+	"github.com/maruel/mddb/backend/internal/storage"
+	"github.com/maruel/mddb/backend/internal/storage/git"
+)
+
+// dupNode is a node to duplicate.
+type dupNode struct {
+	id       ksid.ID
+	dir      string   // Source node directory
+	parent   *dupNode // nil for the duplicated node itself
+	height   int      // 1 for leaves
+	hasPage  bool
+	hasTable bool
+	size     int64 // Bytes of the node's own files
+	newID    ksid.ID
+	newDir   string
+}
+
+// DuplicateNode copies node id next to it, under a new ID and with " (copy)"
+// appended to its title, and commits the copy to git.
+//
+// The page, table schema, records and assets of the node are copied. When deep
+// is set, its descendants are duplicated too, each under a new ID, and relative
+// links between the copied pages are rewritten to point at the copies. Page,
+// table, tree and storage quotas are checked before anything is written.
+func (ws *WorkspaceFileStore) DuplicateNode(ctx context.Context, id ksid.ID, deep bool, author git.Author) (*Node, error) {
+	if id.IsZero() || (!ws.PageExists(id) && !ws.TableExists(id)) {
+		return nil, ErrPageNotFound
+	}
+	parentID := ws.getParent(id)
+	var nodes []*dupNode
+	err := ws.repo.CommitTx(ctx, author, func() (string, []string, error) {
+		var err error
+		if nodes, err = planDuplicate(ws.pageDir(id, parentID), id, deep); err != nil {
+			return "", nil, err
+		}
+		if err := ws.checkDuplicateQuotas(parentID, nodes); err != nil {
+			return "", nil, err
+		}
+		newIDs := make(map[ksid.ID]ksid.ID, len(nodes))
+		for _, n := range nodes {
+			n.newID = ksid.NewID()
+			newIDs[n.id] = n.newID
+			if n.parent == nil {
+				n.newDir = ws.pageDir(n.newID, parentID)
+			} else {
+				n.newDir = filepath.Join(n.parent.newDir, n.newID.String())
+			}
+		}
+		files, err := ws.duplicateNodes(ctx, nodes, newIDs)
+		if err != nil {
+			return "", nil, err
+		}
+		msg := "duplicate: node " + id.String() + " as " + nodes[0].newID.String()
+		if len(nodes) > 1 {
+			msg += " with " + strconv.Itoa(len(nodes)-1) + " descendants"
+		}
+		files = append(files, ws.relativeDir(nodes[0].newID, parentID))
+		return commitMsg(author, msg, "create", "node", nodes[0].newID), files, nil
+	})
+	if err != nil {
+		ws.undoDuplicate(nodes)
+		return nil, err
+	}
+
+	if err := ws.refreshCache(); err != nil {
+		return nil, err
+	}
+	var pages []*Node
+	for _, n := range nodes {
+		if n.hasPage {
+			if p, err := ws.ReadPage(n.newID); err == nil {
+				pages = append(pages, p)
+			}
+		}
+	}
+	if len(pages) != 0 {
+		ws.pageWritten(pages...)
+	}
+	return ws.ReadNode(nodes[0].newID)
+}
+
+// planDuplicate lists the node in dir and, when deep is set, its descendants,
+// parents before children.
+func planDuplicate(dir string, id ksid.ID, deep bool) ([]*dupNode, error) {
+	nodes := []*dupNode{{id: id, dir: dir}}
+	for i := 0; i < len(nodes); i++ {
+		n := nodes[i]
+		entries, err := os.ReadDir(n.dir)
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range entries {
+			name := entry.Name()
+			switch {
+			case isNodeDir(entry):
+				if deep {
+					childID, _ := ksid.Parse(name)
+					nodes = append(nodes, &dupNode{id: childID, dir: filepath.Join(n.dir, name), parent: n})
+				}
+				continue
+			case name == "index.md":
+				n.hasPage = true
+			case name == "metadata.json":
+				n.hasTable = true
+			}
+			p := filepath.Join(n.dir, name)
+			if entry.IsDir() {
+				size, err := dirUsage(p)
+				if err != nil {
+					return nil, err
+				}
+				n.size += size
+			} else if info, err := entry.Info(); err == nil {
+				n.size += info.Size()
+			}
+		}
+	}
+	// Heights, from the leaves up.
+	for i := len(nodes) - 1; i >= 0; i-- {
+		n := nodes[i]
+		n.height = max(n.height, 1)
+		if n.parent != nil {
+			n.parent.height = max(n.parent.height, n.height+1)
+		}
+	}
+	return nodes, nil
+}
+
+// checkDuplicateQuotas returns an error if adding nodes under parentID would
+// exceed a quota.
+func (ws *WorkspaceFileStore) checkDuplicateQuotas(parentID ksid.ID, nodes []*dupNode) error {
+	if err := ws.checkTreeLimits(parentID, nodes[0].height); err != nil {
+		return err
+	}
+	pages, tables := 0, 0
+	var size int64
+	for _, n := range nodes {
+		if n.hasPage {
+			pages++
+		}
+		if n.hasTable {
+			tables++
+		}
+		size += n.size
+	}
+	return ws.checkBulkQuotas(pages, tables, size)
+}
+
+// undoDuplicate removes the copies of nodes written so far and their asset
+// references, after the duplication failed before or while committing.
+func (ws *WorkspaceFileStore) undoDuplicate(nodes []*dupNode) {
+	if len(nodes) == 0 || nodes[0].newDir == "" {
+		return
+	}
+	_ = os.RemoveAll(nodes[0].newDir)
+	ids := make([]ksid.ID, len(nodes))
+	for i, n := range nodes {
+		ids[i] = n.newID
+	}
+	if _, err := ws.deleteAssetRefs(ids...); err != nil {
+		slog.Warn("Failed to remove the asset references of a failed duplicate", "wsID", ws.wsID, "error", err)
+	}
+}
+
+// duplicateNodes writes the copies of nodes and their assets without
+// committing. Returns the asset store paths to commit, if any asset was
+// copied.
+func (ws *WorkspaceFileStore) duplicateNodes(ctx context.Context, nodes []*dupNode, newIDs map[ksid.ID]ksid.ID) ([]string, error) {
+	now := storage.Now()
+	for _, n := range nodes {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if err := os.MkdirAll(n.newDir, 0o755); err != nil { //nolint:gosec // G301: 0o755 is intentional for user data directories
+			return nil, fmt.Errorf("failed to create directory: %w", err)
+		}
+		entries, err := os.ReadDir(n.dir)
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range entries {
+			if isNodeDir(entry) {
+				continue
+			}
+			src, dst := filepath.Join(n.dir, entry.Name()), filepath.Join(n.newDir, entry.Name())
+			switch entry.Name() {
+			case "index.md":
+				err = duplicatePage(src, dst, n.parent == nil, now, newIDs)
+			case "metadata.json":
+				err = duplicateMetadata(src, dst, n.parent == nil, now)
+			default:
+				err = copyTree(src, dst)
+			}
+			if err != nil {
+				return nil, fmt.Errorf("failed to copy %s: %w", src, err)
+			}
+		}
+	}
+
+	at, err := ws.assetTable()
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, n := range nodes {
+		for _, a := range at.list(n.id) {
+			// Asset blobs are content-addressed: the copy references the same blob.
+			c := &assetRef{ID: ksid.NewID(), NodeID: n.newID, Name: a.Name, Blob: a.Blob.Clone(), Created: now}
+			if err := at.table.Append(c); err != nil {
+				return nil, fmt.Errorf("failed to copy asset: %w", err)
+			}
+			files = []string{assetsFile}
+		}
+	}
+	return files, nil
+}
+
+// duplicatePage copies the page at src to dst, rewriting its links to the
+// copied nodes. The copy of the duplicated node itself gets a " (copy)" title.
+func duplicatePage(src, dst string, isRoot bool, now storage.Time, newIDs map[ksid.ID]ksid.ID) error {
+	data, err := os.ReadFile(src) //nolint:gosec // G304: src is within the workspace directory
+	if err != nil {
+		return err
+	}
+	p := ParseMarkdown(data)
+	if isRoot {
+		p.title += " (copy)"
+	}
+	p.content = remapLinks(p.content, newIDs)
+	p.created = now
+	p.modified = now
+	return jsonldb.WriteFileDurable(dst, formatMarkdownFile(p))
+}
+
+// duplicateMetadata copies the table metadata at src to dst. The copy of the
+// duplicated node itself gets a " (copy)" title.
+func duplicateMetadata(src, dst string, isRoot bool, now storage.Time) error {
+	data, err := os.ReadFile(src) //nolint:gosec // G304: src is within the workspace directory
+	if err != nil {
+		return err
+	}
+	var m map[string]json.RawMessage
+	if err := json.Unmarshal(data, &m); err != nil {
+		return err
+	}
+	if isRoot {
+		var title string
+		if err := json.Unmarshal(m["title"], &title); err != nil {
+			return fmt.Errorf("invalid title: %w", err)
+		}
+		m["title"], _ = json.Marshal(title + " (copy)")
+	}
+	m["created"], _ = json.Marshal(now)
+	m["modified"], _ = json.Marshal(now)
+	if data, err = json.Marshal(m); err != nil {
+		return err
+	}
+	return jsonldb.WriteFileDurable(dst, data)
+}
+
+// copyTree copies the file or directory at src to dst.
+func copyTree(src, dst string) error {
+	return filepath.WalkDir(src, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		if d.IsDir() {
+			return os.MkdirAll(target, 0o755) //nolint:gosec // G301: 0o755 is intentional for user data directories
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		in, err := os.Open(path) //nolint:gosec // G304: path comes from walking the workspace directory
+		if err != nil {
+			return err
+		}
+		defer func() { _ = in.Close() }()
+		out, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644) //nolint:gosec // G302,G304: target is within a freshly created node directory
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(out, in)
+		return errors.Join(err, out.Close())
+	})
+}
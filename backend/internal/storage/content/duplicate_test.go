@@ -0,0 +1,213 @@
+package content
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/maruel/ksid"
+	"github.com/maruel/mddb/backend/internal/storage"
+	"github.com/maruel/mddb/backend/internal/storage/git"
+)
+
+func TestDuplicateNode(t *testing.T) {
+	author := git.Author{Name: "Test", Email: "test@test.com"}
+
+	t.Run("ShallowHybrid", func(t *testing.T) {
+		_, ws, _ := initWS(t)
+		ctx := t.Context()
+		props := []Property{{Name: "name", Type: PropertyTypeText}}
+		src, err := ws.CreateTableUnderParent(ctx, 0, "Hybrid", props, author)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := ws.WritePage(ctx, src.ID, 0, "Hybrid", "body", author); err != nil {
+			t.Fatal(err)
+		}
+		rec := &DataRecord{ID: ksid.NewID(), Data: map[string]any{"name": "row"}, Created: storage.Now(), Modified: storage.Now()}
+		if err := ws.AppendRecord(ctx, src.ID, rec, author); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := ws.SaveAsset(ctx, src.ID, "img.png", []byte("png"), author); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := ws.CreatePageUnderParent(ctx, src.ID, "Child", "", author); err != nil {
+			t.Fatal(err)
+		}
+
+		dup, err := ws.DuplicateNode(ctx, src.ID, false, author)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if dup.ID == src.ID || dup.ParentID != 0 || dup.Title != "Hybrid (copy)" {
+			t.Errorf("DuplicateNode = %+v", dup)
+		}
+		if dup.Children != nil {
+			t.Error("shallow copy has children")
+		}
+		page, err := ws.ReadPage(dup.ID)
+		if err != nil || page.Content != "body" || page.Title != "Hybrid (copy)" {
+			t.Errorf("ReadPage = %+v, %v", page, err)
+		}
+		table, err := ws.ReadTable(dup.ID)
+		if err != nil || table.Title != "Hybrid (copy)" || len(table.Properties) != 1 {
+			t.Errorf("ReadTable = %+v, %v", table, err)
+		}
+		records, _, err := ws.ReadRecordsPage(dup.ID, 0, 10)
+		if err != nil || len(records) != 1 || records[0].Data["name"] != "row" {
+			t.Errorf("records = %+v, %v", records, err)
+		}
+		if data, err := ws.ReadAsset(dup.ID, "img.png"); err != nil || string(data) != "png" {
+			t.Errorf("ReadAsset = %q, %v", data, err)
+		}
+		// The copy is independent of the original.
+		if err := ws.DeleteAsset(ctx, src.ID, "img.png", author); err != nil {
+			t.Fatal(err)
+		}
+		if data, err := ws.ReadAsset(dup.ID, "img.png"); err != nil || string(data) != "png" {
+			t.Errorf("ReadAsset after deleting the original = %q, %v", data, err)
+		}
+		if orig, err := ws.ReadPage(src.ID); err != nil || orig.Title != "Hybrid" {
+			t.Errorf("original = %+v, %v", orig, err)
+		}
+	})
+
+	t.Run("Deep", func(t *testing.T) {
+		_, ws, _ := initWS(t)
+		ctx := t.Context()
+		outside, err := ws.CreatePageUnderParent(ctx, 0, "Outside", "", author)
+		if err != nil {
+			t.Fatal(err)
+		}
+		root, err := ws.CreatePageUnderParent(ctx, 0, "Root", "", author)
+		if err != nil {
+			t.Fatal(err)
+		}
+		mid, err := ws.CreatePageUnderParent(ctx, root.ID, "Mid", "", author)
+		if err != nil {
+			t.Fatal(err)
+		}
+		leaf, err := ws.CreatePageUnderParent(ctx, mid.ID, "Leaf", "", author)
+		if err != nil {
+			t.Fatal(err)
+		}
+		link := func(id ksid.ID) string { return "[x](../" + id.String() + "/index.md)" }
+		if _, err := ws.UpdatePage(ctx, root.ID, "Root", link(leaf.ID)+" "+link(outside.ID), author); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := ws.UpdatePage(ctx, leaf.ID, "Leaf", link(root.ID), author); err != nil {
+			t.Fatal(err)
+		}
+
+		dup, err := ws.DuplicateNode(ctx, root.ID, true, author)
+		if err != nil {
+			t.Fatal(err)
+		}
+		mids, err := ws.ListChildren(dup.ID)
+		if err != nil || len(mids) != 1 || mids[0].Title != "Mid" || mids[0].ID == mid.ID {
+			t.Fatalf("children of copy = %+v, %v", mids, err)
+		}
+		leaves, err := ws.ListChildren(mids[0].ID)
+		if err != nil || len(leaves) != 1 || leaves[0].Title != "Leaf" || leaves[0].ID == leaf.ID {
+			t.Fatalf("grandchildren of copy = %+v, %v", leaves, err)
+		}
+		newLeaf := leaves[0].ID
+
+		rootCopy, err := ws.ReadPage(dup.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := link(newLeaf) + " " + link(outside.ID); rootCopy.Content != want {
+			t.Errorf("root copy content = %q, want %q", rootCopy.Content, want)
+		}
+		leafCopy, err := ws.ReadPage(newLeaf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := link(dup.ID); leafCopy.Content != want {
+			t.Errorf("leaf copy content = %q, want %q", leafCopy.Content, want)
+		}
+		// Backlinks are indexed for the copies.
+		backlinks, err := ws.GetBacklinks(newLeaf)
+		if err != nil || len(backlinks) != 1 || backlinks[0].NodeID != dup.ID {
+			t.Errorf("GetBacklinks = %+v, %v", backlinks, err)
+		}
+		if orig, err := ws.ReadPage(leaf.ID); err != nil || orig.Content != link(root.ID) {
+			t.Errorf("original leaf = %+v, %v", orig, err)
+		}
+	})
+
+	t.Run("Undo", func(t *testing.T) {
+		_, ws, _ := initWS(t)
+		ctx := t.Context()
+		src, err := ws.CreatePageUnderParent(ctx, 0, "Source", "", author)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := ws.SaveAsset(ctx, src.ID, "img.png", []byte("png"), author); err != nil {
+			t.Fatal(err)
+		}
+		// Write a copy the way DuplicateNode does, then undo it as if the commit
+		// had failed.
+		nodes, err := planDuplicate(ws.pageDir(src.ID, 0), src.ID, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		nodes[0].newID = ksid.NewID()
+		nodes[0].newDir = ws.pageDir(nodes[0].newID, 0)
+		if _, err := ws.duplicateNodes(ctx, nodes, map[ksid.ID]ksid.ID{src.ID: nodes[0].newID}); err != nil {
+			t.Fatal(err)
+		}
+		at, err := ws.assetTable()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(at.list(nodes[0].newID)) != 1 {
+			t.Fatal("asset reference not copied")
+		}
+		ws.undoDuplicate(nodes)
+		if refs := at.list(nodes[0].newID); len(refs) != 0 {
+			t.Errorf("asset references left: %+v", refs)
+		}
+		if _, err := os.Stat(nodes[0].newDir); !os.IsNotExist(err) {
+			t.Errorf("copy left: %v", err)
+		}
+		if data, err := ws.ReadAsset(src.ID, "img.png"); err != nil || string(data) != "png" {
+			t.Errorf("original asset = %q, %v", data, err)
+		}
+	})
+
+	t.Run("Quota", func(t *testing.T) {
+		_, ws, _ := initWS(t)
+		ctx := t.Context()
+		root, err := ws.CreatePageUnderParent(ctx, 0, "Root", "", author)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := ws.CreatePageUnderParent(ctx, root.ID, "Child", "", author); err != nil {
+			t.Fatal(err)
+		}
+		ws.quotas.MaxPages = 3
+		if _, err := ws.DuplicateNode(ctx, root.ID, true, author); !errors.Is(err, ErrQuotaExceeded) {
+			t.Errorf("deep copy over quota: got %v, want ErrQuotaExceeded", err)
+		}
+		if _, err := ws.DuplicateNode(ctx, root.ID, false, author); err != nil {
+			t.Errorf("shallow copy within quota: %v", err)
+		}
+		if _, err := ws.DuplicateNode(ctx, ksid.NewID(), false, author); !errors.Is(err, ErrPageNotFound) {
+			t.Errorf("missing node: got %v, want ErrPageNotFound", err)
+		}
+		top, err := ws.ListChildren(0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var titles []string
+		for _, n := range top {
+			titles = append(titles, n.Title)
+		}
+		if got := strings.Join(titles, ","); got != "Root,Root (copy)" {
+			t.Errorf("top-level nodes = %s", got)
+		}
+	})
+}
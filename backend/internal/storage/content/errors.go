@@ -2,20 +2,82 @@
 
 package content
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+)
 
 var (
-	errWSIDRequired  = errors.New("workspace ID is required")
-	errOrgIDRequired = errors.New("organization ID is required")
-	errPageNotFound  = errors.New("page not found")
-	errTableNotFound = errors.New("table not found")
-	errAssetNotFound = errors.New("asset not found")
-	errIDRequired    = errors.New("ID is required")
-	errNameRequired  = errors.New("name is required")
-	errQuotaExceeded = errors.New("quota exceeded")
+	errWSIDRequired   = errors.New("workspace ID is required")
+	errOrgIDRequired  = errors.New("organization ID is required")
+	errIDRequired     = errors.New("ID is required")
+	errNameRequired   = errors.New("name is required")
+	errFindRequired   = errors.New("find string is required")
+	errTitleMultiline = fmt.Errorf("%w: must be a single line", ErrInvalidTitle)
+	errTitleControl   = fmt.Errorf("%w: must not contain control characters", ErrInvalidTitle)
+
+	errReservedFrontMatter = errors.New("front matter field is reserved")
+
+	errInvalidArchive    = errors.New("invalid archive")
+	errUnsafeArchivePath = fmt.Errorf("%w: entry escapes the archive root", errInvalidArchive)
+)
+
+// Errors returned by the content stores. Callers should test for them with
+// errors.Is since they may be wrapped.
+var (
+	// ErrPageNotFound is returned when a node has no page (document) part.
+	ErrPageNotFound = errors.New("page not found")
+	// ErrTableNotFound is returned when a node has no table part.
+	ErrTableNotFound = errors.New("table not found")
+	// ErrAssetNotFound is returned when a node has no asset with the given name.
+	ErrAssetNotFound = errors.New("asset not found")
+	// ErrInvalidAssetName is returned when an asset name is not a plain file
+	// name, e.g. because it contains a path separator or is "..".
+	ErrInvalidAssetName = errors.New("invalid asset name")
+	// ErrInvalidTitle is returned when a node title cannot be stored, e.g.
+	// because it spans several lines.
+	ErrInvalidTitle = errors.New("invalid title")
+	// ErrNoRemote is returned when pushing a workspace without a git remote.
+	ErrNoRemote = errors.New("no git remote configured")
+	// ErrConflict is returned when a node changed since the version the caller
+	// based its update on. It is wrapped by *ConflictError.
+	ErrConflict = errors.New("conflicting update")
+	// ErrHasBacklinks is returned by DeleteNodeChecked when other pages link
+	// to the node to delete.
+	ErrHasBacklinks = errors.New("node is linked to by other pages")
+	// ErrCycleDetected is returned when moving a node under one of its descendants.
+	ErrCycleDetected = errors.New("move would create a cycle")
+	// ErrInvalidRecord is returned when a record does not match the property
+	// schema of its table.
+	ErrInvalidRecord = errors.New("invalid record")
+	// ErrDiskFull is returned when a write would leave less free disk space
+	// than ServerQuotas.MinFreeDiskBytes.
+	ErrDiskFull = errors.New("insufficient disk space")
+	// ErrQuotaExceeded is returned when a workspace, organization or server
+	// quota would be exceeded. All the more specific quota errors below wrap it.
+	ErrQuotaExceeded = errors.New("quota exceeded")
 	// ErrTableQuotaExceeded is returned when the table limit for a workspace is reached.
-	ErrTableQuotaExceeded = errors.New("maximum number of tables per workspace exceeded")
-	errCycleDetected      = errors.New("move would create a cycle")
+	ErrTableQuotaExceeded = fmt.Errorf("%w: maximum number of tables per workspace", ErrQuotaExceeded)
+	// ErrNodeDepthExceeded is returned when a node would be nested deeper than MaxNodeDepth.
+	ErrNodeDepthExceeded = fmt.Errorf("%w: maximum node depth", ErrQuotaExceeded)
+	// ErrTooManyChildren is returned when a node would have more than MaxChildrenPerNode children.
+	ErrTooManyChildren = fmt.Errorf("%w: maximum number of children per node", ErrQuotaExceeded)
 	// ErrServerStorageQuotaExceeded is returned when the server-wide storage limit is reached.
-	ErrServerStorageQuotaExceeded = errors.New("server storage quota exceeded")
+	ErrServerStorageQuotaExceeded = fmt.Errorf("%w: server storage", ErrQuotaExceeded)
 )
+
+// ConflictError is returned by UpdatePage when the page changed since the
+// expected version. It carries the current page so the caller can merge.
+type ConflictError struct {
+	Current *Node  // Current page
+	ETag    string // Current NodeETag
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("%s: page %s was modified", ErrConflict, e.Current.ID)
+}
+
+// Unwrap returns ErrConflict.
+func (e *ConflictError) Unwrap() error {
+	return ErrConflict
+}
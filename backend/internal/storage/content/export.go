@@ -0,0 +1,191 @@
+// Exports a workspace as a static HTML site in a zip archive.
+
+package content
+
+import (
+	"archive/zip"
+	"context"
+	"html"
+	"html/template"
+	"io"
+	"math"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/maruel/ksid"
+)
+
+var exportPageTmpl = template.Must(template.New("page").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+</head>
+<body>
+<nav>{{.Nav}}</nav>
+<main>
+<h1>{{.Title}}</h1>
+{{.Body}}
+</main>
+</body>
+</html>
+`))
+
+// exportPage is the data for exportPageTmpl.
+type exportPage struct {
+	Title string
+	Nav   template.HTML
+	Body  template.HTML
+}
+
+// ExportStaticSite writes the workspace to w as a zip archive of static HTML
+// pages rendered with RenderMarkdown. See ExportStaticSiteWith.
+func (ws *WorkspaceFileStore) ExportStaticSite(ctx context.Context, w io.Writer) error {
+	return ws.ExportStaticSiteWith(ctx, w, RenderMarkdown)
+}
+
+// ExportStaticSiteWith writes the workspace to w as a zip archive of static
+// HTML pages rendered with render.
+//
+// The archive mirrors the on-disk layout: each page is written to
+// <dir>/index.html next to a copy of its assets, and index.html at the root
+// lists the node tree. Links between pages are rewritten to relative
+// index.html paths; links to nodes without a page are reduced to their text.
+func (ws *WorkspaceFileStore) ExportStaticSiteWith(ctx context.Context, w io.Writer, render MarkdownRenderer) error {
+	tree, err := ws.ReadTree(0, math.MaxInt)
+	if err != nil {
+		return err
+	}
+	// dirs holds the archive directory of every node that has a page.
+	dirs := map[ksid.ID]string{}
+	var nodes []*Node
+	var walk func([]*Node)
+	walk = func(children []*Node) {
+		for _, n := range children {
+			nodes = append(nodes, n)
+			if n.Type != NodeTypeTable && ws.PageExists(n.ID) {
+				dirs[n.ID] = filepath.ToSlash(ws.relativeDir(n.ID, ws.getParent(n.ID)))
+			}
+			walk(n.Children)
+		}
+	}
+	walk(tree)
+
+	zw := zip.NewWriter(w)
+	if err := writeExportPage(zw, "index.html", exportPage{Title: "Index", Nav: exportNav(tree, dirs, "")}); err != nil {
+		return err
+	}
+	for _, n := range nodes {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		dir, ok := dirs[n.ID]
+		if !ok {
+			continue
+		}
+		page, err := ws.ReadPage(n.ID)
+		if err != nil {
+			return err
+		}
+		title := page.Title
+		if title == "" {
+			title = "Untitled"
+		}
+		data := exportPage{
+			Title: title,
+			Nav:   exportNav(tree, dirs, strings.Repeat("../", strings.Count(dir, "/")+1)),
+			Body:  template.HTML(render(exportLinks(page.Content, dir, dirs))), //nolint:gosec // G203: the renderer escapes its input
+		}
+		if err := writeExportPage(zw, dir+"/index.html", data); err != nil {
+			return err
+		}
+		if err := ws.exportAssets(zw, n.ID, dir); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+// exportAssets copies the assets of node id into dir in the archive.
+func (ws *WorkspaceFileStore) exportAssets(zw *zip.Writer, id ksid.ID, dir string) error {
+	assets, err := ws.IterAssets(id)
+	if err != nil {
+		return err
+	}
+	for a := range assets {
+		f, _, err := ws.OpenAsset(id, a.Name)
+		if err != nil {
+			return err
+		}
+		dst, err := zw.Create(dir + "/" + a.Name)
+		if err == nil {
+			_, err = io.Copy(dst, f)
+		}
+		_ = f.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeExportPage(zw *zip.Writer, name string, data exportPage) error {
+	f, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+	return exportPageTmpl.Execute(f, data)
+}
+
+// exportLinks rewrites the relative ../id/index.md links and the wikilinks in
+// content to the target's index.html relative to dir.
+func exportLinks(content, dir string, dirs map[ksid.ID]string) string {
+	return relativeLinkRe.ReplaceAllStringFunc(toRelativeLinks(content, siblingHref), func(m string) string {
+		sub := relativeLinkRe.FindStringSubmatch(m)
+		id, ok := linkTargetID(sub[2])
+		if !ok {
+			return m
+		}
+		target, ok := dirs[id]
+		if !ok {
+			return sub[1]
+		}
+		rel, err := filepath.Rel(filepath.FromSlash(dir), filepath.FromSlash(target))
+		if err != nil {
+			return sub[1]
+		}
+		return "[" + sub[1] + "](" + path.Join(filepath.ToSlash(rel), "index.html") + ")"
+	})
+}
+
+// exportNav renders the node tree as nested lists. prefix is the path from the
+// current page back to the archive root.
+func exportNav(nodes []*Node, dirs map[ksid.ID]string, prefix string) template.HTML {
+	var b strings.Builder
+	var walk func([]*Node)
+	walk = func(nodes []*Node) {
+		b.WriteString("<ul>")
+		for _, n := range nodes {
+			title := html.EscapeString(n.Title)
+			if title == "" {
+				title = "Untitled"
+			}
+			b.WriteString("<li>")
+			if dir, ok := dirs[n.ID]; ok {
+				b.WriteString(`<a href="` + html.EscapeString(prefix+dir) + `/index.html">` + title + "</a>")
+			} else {
+				b.WriteString(title)
+			}
+			if len(n.Children) != 0 {
+				walk(n.Children)
+			}
+			b.WriteString("</li>")
+		}
+		b.WriteString("</ul>")
+	}
+	if len(nodes) != 0 {
+		walk(nodes)
+	}
+	return template.HTML(b.String()) //nolint:gosec // G203: titles are escaped above
+}
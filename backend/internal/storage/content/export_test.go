@@ -0,0 +1,122 @@
+package content
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"path"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/maruel/mddb/backend/internal/storage/git"
+)
+
+func TestExportStaticSite(t *testing.T) {
+	_, ws, _ := initWS(t)
+	ctx := t.Context()
+	author := git.Author{Name: "Test", Email: "test@test.com"}
+
+	other, err := ws.CreatePageUnderParent(ctx, 0, "Other", "Back to nothing.", author)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parent, err := ws.CreatePageUnderParent(ctx, 0, "Parent", "", author)
+	if err != nil {
+		t.Fatal(err)
+	}
+	child, err := ws.CreatePageUnderParent(ctx, parent.ID, "Child <b>", "See [other](../../"+other.ID.String()+"/index.md) and ![logo](logo.png).", author)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ws.UpdatePage(ctx, parent.ID, "Parent", "# Parent\n\nGo to [child]("+child.ID.String()+"/index.md).", author); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ws.SaveAsset(ctx, child.ID, "logo.png", []byte("png"), author); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := ws.ExportStaticSite(ctx, &buf); err != nil {
+		t.Fatal(err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{}
+	for _, f := range zr.File {
+		r, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := io.ReadAll(r)
+		_ = r.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		files[f.Name] = string(data)
+	}
+
+	pages := map[string]string{
+		"index.html":                                                 "",
+		other.ID.String() + "/index.html":                            "Other",
+		parent.ID.String() + "/index.html":                           "Parent",
+		parent.ID.String() + "/" + child.ID.String() + "/index.html": "Child &lt;b&gt;",
+	}
+	for name, title := range pages {
+		page, ok := files[name]
+		if !ok {
+			t.Fatalf("missing %s; got %v", name, files)
+		}
+		if title != "" && !strings.Contains(page, "<title>"+title+"</title>") {
+			t.Errorf("%s: missing title %q", name, title)
+		}
+	}
+	if files[parent.ID.String()+"/"+child.ID.String()+"/logo.png"] != "png" {
+		t.Error("asset not copied")
+	}
+
+	// Every relative link and image must resolve to a file in the archive.
+	hrefRe := regexp.MustCompile(`(?:href|src)="([^"]+)"`)
+	links := 0
+	for name, content := range files {
+		if !strings.HasSuffix(name, ".html") {
+			continue
+		}
+		if strings.Contains(content, "index.md") {
+			t.Errorf("%s: unrewritten link", name)
+		}
+		for _, m := range hrefRe.FindAllStringSubmatch(content, -1) {
+			target := path.Join(path.Dir(name), m[1])
+			if _, ok := files[target]; !ok {
+				t.Errorf("%s: link %q resolves to missing %q", name, m[1], target)
+			}
+			links++
+		}
+	}
+	if links == 0 {
+		t.Error("no links found")
+	}
+}
+
+func TestRenderMarkdown(t *testing.T) {
+	tests := []struct {
+		name, in, want string
+	}{
+		{"paragraph", "a <b> & c\nd", "<p>a &lt;b&gt; &amp; c\nd</p>\n"},
+		{"heading", "## Title *x*", "<h2>Title <em>x</em></h2>\n"},
+		{"list", "- a\n- **b**\n\n1. c", "<ul>\n<li>a</li>\n<li><strong>b</strong></li>\n</ul>\n<ol>\n<li>c</li>\n</ol>\n"},
+		{"code", "```go\n<x>\n```\nuse `a<b`", "<pre><code>&lt;x&gt;\n</code></pre>\n<p>use <code>a&lt;b</code></p>\n"},
+		{"quote", "> q\n> r", "<blockquote><p>q\nr</p></blockquote>\n"},
+		{"link", "[a](https://x.example/?a=1&b=2) ![i](i.png)", `<p><a href="https://x.example/?a=1&amp;b=2">a</a> <img src="i.png" alt="i"></p>` + "\n"},
+		{"unsafe link", "[a](javascript:alert(1))", `<p><a href="#">a</a>)</p>` + "\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := RenderMarkdown(tt.in); got != tt.want {
+				t.Errorf("RenderMarkdown(%q)\ngot  %q\nwant %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
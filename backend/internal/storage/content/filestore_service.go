@@ -11,6 +11,7 @@ import (
 	"os"
 	"path/filepath"
 	"sync"
+	"sync/atomic"
 
 	"github.com/maruel/ksid"
 	"github.com/maruel/mddb/backend/internal/storage"
@@ -26,9 +27,15 @@ type FileStoreService struct {
 	git          *git.Manager
 	wsSvc        *identity.WorkspaceService
 	orgSvc       *identity.OrganizationService
-	serverQuotas *storage.ResourceQuotas
+	serverQuotas atomic.Pointer[storage.ServerQuotas]
 	mu           sync.RWMutex
 	stores       map[ksid.ID]*WorkspaceFileStore // wsID -> WorkspaceFileStore
+	recovered    map[ksid.ID]bool                // wsID -> crash recovery done in this process
+	observers    []PageObserver
+
+	// freeDiskSpace returns the bytes available to unprivileged users on the
+	// filesystem holding dir. Replaced in tests.
+	freeDiskSpace func(dir string) (int64, error)
 }
 
 // page is an internal type for reading/writing page markdown files.
@@ -38,16 +45,19 @@ type page struct {
 	created  storage.Time
 	modified storage.Time
 	tags     []string
-	icon     string // emoji character or MDI icon name
-	cover    string // asset filename used as cover image
+	icon     string         // emoji character or MDI icon name
+	cover    string         // asset filename used as cover image
+	extra    map[string]any // unknown front matter fields, kept as is
 }
 
 // NewFileStoreService creates a versioned file store service.
 // gitMgr is required - all operations are versioned.
 // wsSvc provides quota limits for workspaces.
 // orgSvc provides quota limits for organizations.
-// serverQuotas provides server-level resource quotas for effective quota computation.
-func NewFileStoreService(rootDir string, gitMgr *git.Manager, wsSvc *identity.WorkspaceService, orgSvc *identity.OrganizationService, serverQuotas *storage.ResourceQuotas) (*FileStoreService, error) {
+// serverQuotas provides server-level resource quotas for effective quota
+// computation and the free disk space threshold. Use SetServerQuotas to
+// replace them.
+func NewFileStoreService(rootDir string, gitMgr *git.Manager, wsSvc *identity.WorkspaceService, orgSvc *identity.OrganizationService, serverQuotas *storage.ServerQuotas) (*FileStoreService, error) {
 	if gitMgr == nil {
 		return nil, errors.New("git manager is required")
 	}
@@ -64,14 +74,17 @@ func NewFileStoreService(rootDir string, gitMgr *git.Manager, wsSvc *identity.Wo
 		return nil, fmt.Errorf("failed to create root directory: %w", err)
 	}
 
-	return &FileStoreService{
-		rootDir:      rootDir,
-		git:          gitMgr,
-		wsSvc:        wsSvc,
-		orgSvc:       orgSvc,
-		serverQuotas: serverQuotas,
-		stores:       make(map[ksid.ID]*WorkspaceFileStore),
-	}, nil
+	svc := &FileStoreService{
+		rootDir:       rootDir,
+		git:           gitMgr,
+		wsSvc:         wsSvc,
+		orgSvc:        orgSvc,
+		stores:        make(map[ksid.ID]*WorkspaceFileStore),
+		recovered:     make(map[ksid.ID]bool),
+		freeDiskSpace: freeDiskSpace,
+	}
+	svc.serverQuotas.Store(serverQuotas)
+	return svc, nil
 }
 
 // GetWorkspaceStore returns a WorkspaceFileStore for the given workspace.
@@ -115,25 +128,86 @@ func (svc *FileStoreService) GetWorkspaceStore(ctx context.Context, wsID ksid.ID
 	}
 
 	// Compute effective quotas from server, org, and workspace layers.
-	effective := storage.EffectiveQuotas(*svc.serverQuotas, org.Quotas.ResourceQuotas, ws.Quotas)
+	effective := storage.EffectiveQuotas(svc.serverQuotas.Load().ResourceQuotas, org.Quotas.ResourceQuotas, ws.Quotas)
 
 	wsDir := filepath.Join(svc.rootDir, wsID.String())
-	store := newWorkspaceFileStore(wsDir, repo, &effective)
+	store := newWorkspaceFileStore(wsID, wsDir, repo, &effective, svc.checkDiskSpace, svc.pageObservers, svc.wsSvc)
 	svc.stores[wsID] = store
+	// Pages may have been written while the store was not cached, e.g. by an
+	// import or a crash recovery. svc.mu is held so call the observers
+	// directly.
+	for _, o := range svc.observers {
+		o.OnPagesReloaded(wsID)
+	}
 
-	invalid, err := store.ValidateLinks()
-	if err != nil {
-		slog.Error("link validation failed", "wsID", wsID, "error", err)
-	} else {
-		for _, l := range invalid {
-			slog.Warn("invalid internal link: target node not found",
-				"wsID", wsID, "source", l.SourceID, "target", l.Target)
+	// Recover from a crash the first time the workspace is opened in this
+	// process. Later re-opens, e.g. after InvalidateAllStores, must not lose
+	// writes made since then nor walk the whole tree again.
+	if !svc.recovered[wsID] {
+		svc.recovered[wsID] = true
+		// A crash between writing files and committing them leaves changes
+		// that git does not know about; drop them so the workspace matches its
+		// history.
+		if discarded, err := repo.DiscardChanges(ctx); err != nil {
+			slog.Error("Failed to discard uncommitted changes", "wsID", wsID, "error", err)
+		} else if discarded {
+			slog.Warn("Discarded uncommitted changes left by an interrupted write", "wsID", wsID)
+		}
+		if _, err := store.RepairWorkspace(ctx); err != nil {
+			slog.Error("Failed to repair orphaned node directories", "wsID", wsID, "error", err)
 		}
 	}
 
 	return store, nil
 }
 
+// LogInvalidLinks validates the links of every workspace and logs the ones
+// pointing to missing nodes or assets. It reads every page, so it is meant to
+// run in the background, not when a workspace is opened.
+func (svc *FileStoreService) LogInvalidLinks(ctx context.Context) error {
+	var errs []error
+	for ws := range svc.wsSvc.Iter(0) {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		store, err := svc.GetWorkspaceStore(ctx, ws.ID)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		invalid, err := store.ValidateLinks()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to validate links of workspace %s: %w", ws.ID, err))
+			continue
+		}
+		for _, l := range invalid {
+			if l.IsAsset {
+				slog.WarnContext(ctx, "Invalid internal link: asset not found",
+					"wsID", ws.ID, "source", l.SourceID, "asset", l.Target)
+				continue
+			}
+			slog.WarnContext(ctx, "Invalid internal link: target node not found",
+				"wsID", ws.ID, "source", l.SourceID, "target", l.Target)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// AddPageObserver registers an observer notified of page changes in every
+// workspace, including stores already cached.
+func (svc *FileStoreService) AddPageObserver(o PageObserver) {
+	svc.mu.Lock()
+	defer svc.mu.Unlock()
+	svc.observers = append(svc.observers, o)
+}
+
+// pageObservers returns the registered page observers.
+func (svc *FileStoreService) pageObservers() []PageObserver {
+	svc.mu.RLock()
+	defer svc.mu.RUnlock()
+	return svc.observers
+}
+
 // InvalidateWorkspaceStore removes a cached workspace store so that
 // the next GetWorkspaceStore call recomputes effective quotas.
 func (svc *FileStoreService) InvalidateWorkspaceStore(wsID ksid.ID) {
@@ -142,6 +216,13 @@ func (svc *FileStoreService) InvalidateWorkspaceStore(wsID ksid.ID) {
 	delete(svc.stores, wsID)
 }
 
+// SetServerQuotas replaces the server-level quotas. Cached workspace stores
+// are dropped so that effective quotas are recomputed.
+func (svc *FileStoreService) SetServerQuotas(q *storage.ServerQuotas) {
+	svc.serverQuotas.Store(q)
+	svc.InvalidateAllStores()
+}
+
 // InvalidateAllStores removes all cached workspace stores.
 // Use after server-level quota changes that affect all workspaces.
 func (svc *FileStoreService) InvalidateAllStores() {
@@ -203,8 +284,8 @@ func (svc *FileStoreService) CheckOrgStorageQuota(wsID ksid.ID, additionalBytes
 		return err
 	}
 
-	if orgUsage+additionalBytes > org.Quotas.MaxTotalStorageBytes {
-		return errQuotaExceeded
+	if exceedsBytes(orgUsage, additionalBytes, org.Quotas.MaxTotalStorageBytes) {
+		return ErrQuotaExceeded
 	}
 	return nil
 }
@@ -218,19 +299,8 @@ func (svc *FileStoreService) GetOrganizationUsage(orgID ksid.ID) (int64, error)
 	var totalUsage int64
 
 	for ws := range svc.wsSvc.IterByOrg(orgID) {
-		wsDir := filepath.Join(svc.rootDir, ws.ID.String())
-		err := filepath.Walk(wsDir, func(path string, info os.FileInfo, err error) error {
-			if err != nil {
-				return nil //nolint:nilerr // Intentionally continue walking on error
-			}
-			if info.IsDir() && info.Name() == ".git" {
-				return filepath.SkipDir
-			}
-			if !info.IsDir() {
-				totalUsage += info.Size()
-			}
-			return nil
-		})
+		usage, err := dirUsage(filepath.Join(svc.rootDir, ws.ID.String()))
+		totalUsage += usage
 		if err != nil && !os.IsNotExist(err) {
 			slog.Error("failed to calculate workspace usage", "wsID", ws.ID, "error", err)
 		}
@@ -241,19 +311,7 @@ func (svc *FileStoreService) GetOrganizationUsage(orgID ksid.ID) (int64, error)
 
 // GetServerUsage returns the total storage usage across all workspaces on the server.
 func (svc *FileStoreService) GetServerUsage() (int64, error) {
-	var totalUsage int64
-	err := filepath.Walk(svc.rootDir, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return nil //nolint:nilerr // Intentionally continue walking on error
-		}
-		if info.IsDir() && info.Name() == ".git" {
-			return filepath.SkipDir
-		}
-		if !info.IsDir() {
-			totalUsage += info.Size()
-		}
-		return nil
-	})
+	totalUsage, err := dirUsage(svc.rootDir)
 	if err != nil && !os.IsNotExist(err) {
 		return 0, err
 	}
@@ -275,7 +333,7 @@ func (svc *FileStoreService) CheckServerStorageQuota(additionalBytes, maxBytes i
 	if err != nil {
 		return err
 	}
-	if usage+additionalBytes > maxBytes {
+	if exceedsBytes(usage, additionalBytes, maxBytes) {
 		return ErrServerStorageQuotaExceeded
 	}
 	return nil
@@ -0,0 +1,122 @@
+package content
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/maruel/ksid"
+	"github.com/maruel/mddb/backend/internal/storage/git"
+)
+
+func TestInterruptedWriteRecovery(t *testing.T) {
+	fs, ws, wsID := initWS(t)
+	ctx := t.Context()
+	author := git.Author{Name: "Test", Email: "test@test.com"}
+	table, err := ws.CreateTableUnderParent(ctx, 0, "Tasks", []Property{{Name: "Name", Type: PropertyTypeText}}, author)
+	if err != nil {
+		t.Fatal(err)
+	}
+	committed := &DataRecord{ID: ksid.NewID(), Data: map[string]any{"Name": "committed"}}
+	if err := ws.AppendRecord(ctx, table.ID, committed, author); err != nil {
+		t.Fatal(err)
+	}
+	before, err := ws.CommitCount(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Write the files as AppendRecord and WriteTable do, but "crash" before
+	// their git commit.
+	lost := &DataRecord{ID: ksid.NewID(), Data: map[string]any{"Name": "lost"}}
+	if err := ws.appendRecord(table.ID, 0, lost); err != nil {
+		t.Fatal(err)
+	}
+	renamed := *table
+	renamed.Title = "Renamed"
+	if err := ws.writeTable(&renamed, false); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := ws.CountRecords(table.ID); err != nil || n != 2 {
+		t.Fatalf("want the uncommitted record on disk, got %d (err %v)", n, err)
+	}
+
+	// Reopening the workspace, as after a restart, restores the last commit.
+	fs.mu.Lock()
+	delete(fs.stores, wsID)
+	delete(fs.recovered, wsID)
+	fs.mu.Unlock()
+	ws, err = fs.GetWorkspaceStore(ctx, wsID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	records, total, err := ws.ReadRecordsPage(table.ID, 0, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 1 || records[0].ID != committed.ID {
+		t.Errorf("unexpected records after recovery: %+v", records)
+	}
+	read, err := ws.ReadTable(table.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if read.Title != "Tasks" {
+		t.Errorf("title = %q, want the committed one", read.Title)
+	}
+	if after, err := ws.CommitCount(ctx); err != nil || after != before {
+		t.Errorf("commits: %d -> %d (err %v)", before, after, err)
+	}
+
+	// The store keeps working after recovery.
+	if err := ws.AppendRecord(ctx, table.ID, lost, author); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := ws.CountRecords(table.ID); err != nil || n != 2 {
+		t.Errorf("want 2 records, got %d (err %v)", n, err)
+	}
+}
+
+func TestReopenKeepsUncommittedChanges(t *testing.T) {
+	fs, ws, wsID := initWS(t)
+	ctx := t.Context()
+	author := git.Author{Name: "Test", Email: "test@test.com"}
+	node, err := ws.CreatePageUnderParent(ctx, 0, "Page", "before", author)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A writer outside CommitTx, like an importer, changes the page and the
+	// stores are dropped, e.g. after a server quota change.
+	path := ws.pageIndexFile(node.ID, 0)
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	edited := []byte(strings.Replace(string(raw), "before", "after", 1))
+	if err := os.WriteFile(path, edited, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	fs.InvalidateAllStores()
+	if ws, err = fs.GetWorkspaceStore(ctx, wsID); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := os.ReadFile(path); err != nil || string(got) != string(edited) {
+		t.Errorf("re-open discarded the edit: %q (err %v)", got, err)
+	}
+
+	// Once committed, the edit survives a restart.
+	if err := ws.CommitImport(ctx, "test", author); err != nil {
+		t.Fatal(err)
+	}
+	fs.mu.Lock()
+	delete(fs.stores, wsID)
+	delete(fs.recovered, wsID)
+	fs.mu.Unlock()
+	if _, err := fs.GetWorkspaceStore(ctx, wsID); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := os.ReadFile(path); err != nil || string(got) != string(edited) {
+		t.Errorf("restart discarded the committed edit: %q (err %v)", got, err)
+	}
+}
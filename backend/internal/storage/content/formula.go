@@ -0,0 +1,381 @@
+// Parses and evaluates formula property expressions.
+
+package content
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// FormulaErrorValue is the value of a formula property when its expression
+// cannot be parsed or evaluated for a record, e.g. on a division by zero.
+const FormulaErrorValue = "#ERROR"
+
+var (
+	errFormulaSyntax = errors.New("formula syntax error")
+	errFormulaEval   = errors.New("formula evaluation error")
+)
+
+// Formula expressions are evaluated against the other properties of the same
+// record. The grammar is:
+//
+//	expr    = term { ("+" | "-") term }
+//	term    = unary { ("*" | "/") unary }
+//	unary   = "-" unary | primary
+//	primary = number | string | ref | "(" expr ")"
+//	ref     = name | "[" name with spaces "]" | "prop(" string ")"
+//
+// prop("Name") is the Notion syntax, so that simple imported formulas keep
+// working. Strings are double-quoted with Go escapes. "+" concatenates when
+// either operand is text. An operand referring to an empty property makes the
+// result of arithmetic empty (nil) and counts as "" in a concatenation.
+
+// formulaNode is a node of a parsed formula expression.
+type formulaNode interface {
+	eval(data map[string]any) (any, error)
+}
+
+type formulaLiteral struct{ v any }
+
+func (n formulaLiteral) eval(map[string]any) (any, error) { return n.v, nil }
+
+type formulaRef struct{ name string }
+
+func (n formulaRef) eval(data map[string]any) (any, error) {
+	switch v := data[n.name].(type) {
+	case nil:
+		return nil, nil
+	case string:
+		if v == "" {
+			return nil, nil
+		}
+		return v, nil
+	case bool:
+		if v {
+			return float64(1), nil
+		}
+		return float64(0), nil
+	case int64:
+		return float64(v), nil
+	case int:
+		return float64(v), nil
+	case float64:
+		return v, nil
+	default:
+		return nil, fmt.Errorf("%w: %q is not a number or text", errFormulaEval, n.name)
+	}
+}
+
+type formulaNeg struct{ x formulaNode }
+
+func (n formulaNeg) eval(data map[string]any) (any, error) {
+	v, err := n.x.eval(data)
+	if err != nil || v == nil {
+		return nil, err
+	}
+	f, ok := v.(float64)
+	if !ok {
+		return nil, fmt.Errorf("%w: cannot negate text", errFormulaEval)
+	}
+	return -f, nil
+}
+
+type formulaBinary struct {
+	op   byte
+	l, r formulaNode
+}
+
+func (n formulaBinary) eval(data map[string]any) (any, error) {
+	l, err := n.l.eval(data)
+	if err != nil {
+		return nil, err
+	}
+	r, err := n.r.eval(data)
+	if err != nil {
+		return nil, err
+	}
+	_, ls := l.(string)
+	_, rs := r.(string)
+	if n.op == '+' && (ls || rs) {
+		return formulaText(l) + formulaText(r), nil
+	}
+	if l == nil || r == nil {
+		return nil, nil
+	}
+	if ls || rs {
+		return nil, fmt.Errorf("%w: %q needs numbers", errFormulaEval, n.op)
+	}
+	a, b := l.(float64), r.(float64)
+	var v float64
+	switch n.op {
+	case '+':
+		v = a + b
+	case '-':
+		v = a - b
+	case '*':
+		v = a * b
+	case '/':
+		if b == 0 {
+			return nil, fmt.Errorf("%w: division by zero", errFormulaEval)
+		}
+		v = a / b
+	}
+	if math.IsInf(v, 0) || math.IsNaN(v) {
+		return nil, fmt.Errorf("%w: result out of range", errFormulaEval)
+	}
+	return v, nil
+}
+
+// formulaText formats an operand of a concatenation.
+func formulaText(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	default:
+		return coerceToText(v).(string)
+	}
+}
+
+// parseFormula parses expr. Every property referenced must be in props and
+// must not itself be a formula.
+func parseFormula(expr string, props []Property) (formulaNode, error) {
+	p := &formulaParser{src: expr, props: props}
+	n, err := p.expr()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpace()
+	if p.pos != len(p.src) {
+		return nil, p.errorf("unexpected %q", p.src[p.pos:])
+	}
+	return n, nil
+}
+
+// formulaParser is a recursive descent parser over an expression.
+type formulaParser struct {
+	src   string
+	pos   int
+	props []Property
+}
+
+func (p *formulaParser) errorf(format string, args ...any) error {
+	return fmt.Errorf("%w at offset %d: %s", errFormulaSyntax, p.pos, fmt.Sprintf(format, args...))
+}
+
+func (p *formulaParser) skipSpace() {
+	for p.pos < len(p.src) && (p.src[p.pos] == ' ' || p.src[p.pos] == '\t') {
+		p.pos++
+	}
+}
+
+// accept consumes the next non-space byte if it is one of ops.
+func (p *formulaParser) accept(ops string) (byte, bool) {
+	p.skipSpace()
+	if p.pos < len(p.src) && strings.IndexByte(ops, p.src[p.pos]) >= 0 {
+		p.pos++
+		return p.src[p.pos-1], true
+	}
+	return 0, false
+}
+
+func (p *formulaParser) expr() (formulaNode, error) {
+	n, err := p.term()
+	for err == nil {
+		op, ok := p.accept("+-")
+		if !ok {
+			break
+		}
+		var r formulaNode
+		if r, err = p.term(); err == nil {
+			n = formulaBinary{op: op, l: n, r: r}
+		}
+	}
+	return n, err
+}
+
+func (p *formulaParser) term() (formulaNode, error) {
+	n, err := p.unary()
+	for err == nil {
+		op, ok := p.accept("*/")
+		if !ok {
+			break
+		}
+		var r formulaNode
+		if r, err = p.unary(); err == nil {
+			n = formulaBinary{op: op, l: n, r: r}
+		}
+	}
+	return n, err
+}
+
+func (p *formulaParser) unary() (formulaNode, error) {
+	if _, ok := p.accept("-"); ok {
+		x, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return formulaNeg{x: x}, nil
+	}
+	return p.primary()
+}
+
+func (p *formulaParser) primary() (formulaNode, error) {
+	p.skipSpace()
+	if p.pos >= len(p.src) {
+		return nil, p.errorf("unexpected end of expression")
+	}
+	start := p.pos
+	switch c := p.src[p.pos]; {
+	case c == '(':
+		p.pos++
+		n, err := p.expr()
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := p.accept(")"); !ok {
+			return nil, p.errorf("missing )")
+		}
+		return n, nil
+	case c == '"':
+		p.pos++
+		for p.pos < len(p.src) && p.src[p.pos] != '"' {
+			if p.src[p.pos] == '\\' {
+				p.pos++
+			}
+			p.pos++
+		}
+		if p.pos >= len(p.src) {
+			return nil, p.errorf("unterminated string")
+		}
+		p.pos++
+		s, err := strconv.Unquote(p.src[start:p.pos])
+		if err != nil {
+			return nil, p.errorf("invalid string %s", p.src[start:p.pos])
+		}
+		return formulaLiteral{v: s}, nil
+	case c == '[':
+		end := strings.IndexByte(p.src[p.pos:], ']')
+		if end < 0 {
+			return nil, p.errorf("missing ]")
+		}
+		p.pos += end + 1
+		return p.ref(p.src[start+1 : p.pos-1])
+	case c == '.' || (c >= '0' && c <= '9'):
+		for p.pos < len(p.src) && (p.src[p.pos] == '.' || (p.src[p.pos] >= '0' && p.src[p.pos] <= '9')) {
+			p.pos++
+		}
+		f, err := strconv.ParseFloat(p.src[start:p.pos], 64)
+		if err != nil {
+			return nil, p.errorf("invalid number %q", p.src[start:p.pos])
+		}
+		return formulaLiteral{v: f}, nil
+	default:
+		for _, r := range p.src[p.pos:] {
+			if r != '_' && !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+				break
+			}
+			p.pos += len(string(r))
+		}
+		if p.pos == start {
+			return nil, p.errorf("unexpected %q", c)
+		}
+		if name := p.src[start:p.pos]; name != "prop" || !strings.HasPrefix(p.src[p.pos:], "(") {
+			return p.ref(name)
+		}
+		p.pos++
+		p.skipSpace()
+		arg, err := p.primary()
+		if err != nil {
+			return nil, err
+		}
+		lit, ok := arg.(formulaLiteral)
+		if _, isString := lit.v.(string); !ok || !isString {
+			return nil, p.errorf("prop() takes a property name")
+		}
+		if _, ok := p.accept(")"); !ok {
+			return nil, p.errorf("missing )")
+		}
+		return p.ref(lit.v.(string))
+	}
+}
+
+// ref resolves a property reference.
+func (p *formulaParser) ref(name string) (formulaNode, error) {
+	for i := range p.props {
+		if p.props[i].Name != name {
+			continue
+		}
+		if p.props[i].Type == PropertyTypeFormula {
+			return nil, p.errorf("%q is a formula", name)
+		}
+		return formulaRef{name: name}, nil
+	}
+	return nil, p.errorf("unknown property %q", name)
+}
+
+// tableFormulas holds the parsed formula properties of a table.
+type tableFormulas struct {
+	names []string
+	nodes []formulaNode // nil when the expression does not parse
+}
+
+// compileFormulas parses the formula properties of a table. Returns nil if
+// there are none.
+//
+// A formula whose expression does not parse, e.g. one imported from Notion
+// using functions, keeps the value stored in the record if there is one.
+func compileFormulas(props []Property) *tableFormulas {
+	var f *tableFormulas
+	for _, prop := range props {
+		if prop.Type != PropertyTypeFormula {
+			continue
+		}
+		if f == nil {
+			f = &tableFormulas{}
+		}
+		var n formulaNode
+		if prop.FormulaConfig != nil {
+			n, _ = parseFormula(prop.FormulaConfig.Expression, props)
+		}
+		f.names = append(f.names, prop.Name)
+		f.nodes = append(f.nodes, n)
+	}
+	return f
+}
+
+// apply sets the computed value of every formula property in data.
+func (f *tableFormulas) apply(data map[string]any) {
+	for i, name := range f.names {
+		if f.nodes[i] == nil {
+			if data[name] == nil {
+				data[name] = FormulaErrorValue
+			}
+			continue
+		}
+		v, err := f.nodes[i].eval(data)
+		if err != nil {
+			v = FormulaErrorValue
+		}
+		data[name] = v
+	}
+}
+
+// computed reports whether the value of the named property is computed on
+// read, in which case it must not be stored.
+func (f *tableFormulas) computed(name string) bool {
+	if f == nil {
+		return false
+	}
+	for i, n := range f.names {
+		if n == name {
+			return f.nodes[i] != nil
+		}
+	}
+	return false
+}
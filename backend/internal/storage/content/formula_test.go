@@ -0,0 +1,120 @@
+package content
+
+import (
+	"errors"
+	"testing"
+)
+
+func Test_parseFormula(t *testing.T) {
+	props := []Property{
+		{Name: "price", Type: PropertyTypeNumber},
+		{Name: "qty", Type: PropertyTypeNumber},
+		{Name: "name", Type: PropertyTypeText},
+		{Name: "Unit Cost", Type: PropertyTypeNumber},
+		{Name: "done", Type: PropertyTypeCheckbox},
+		{Name: "empty", Type: PropertyTypeNumber},
+		{Name: "total", Type: PropertyTypeFormula},
+	}
+	data := map[string]any{
+		"price":     float64(2.5),
+		"qty":       int64(4),
+		"name":      "Widget",
+		"Unit Cost": int64(3),
+		"done":      int64(1),
+		"empty":     nil,
+	}
+
+	t.Run("Eval", func(t *testing.T) {
+		tests := []struct {
+			expr string
+			want any
+		}{
+			{"price * qty", float64(10)},
+			{"1 + 2 * 3", float64(7)},
+			{"(1 + 2) * 3", float64(9)},
+			{"10 - 4 - 3", float64(3)},
+			{"12 / 4 / 3", float64(1)},
+			{"-price + -(-1)", float64(-1.5)},
+			{"[Unit Cost] * qty", float64(12)},
+			{`prop("Unit Cost") * prop( "qty" )`, float64(12)},
+			{"done + done", float64(2)},
+			{`name + " x" + qty`, "Widget x4"},
+			{`"total: " + price * qty`, "total: 10"},
+			{`"say \"hi\""`, `say "hi"`},
+			{"empty * 2", nil},
+			{`"[" + empty + "]"`, "[]"},
+			{".5 + 1.25", float64(1.75)},
+		}
+		for _, tt := range tests {
+			t.Run(tt.expr, func(t *testing.T) {
+				n, err := parseFormula(tt.expr, props)
+				if err != nil {
+					t.Fatal(err)
+				}
+				got, err := n.eval(data)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got != tt.want {
+					t.Errorf("got %v (%T), want %v (%T)", got, got, tt.want, tt.want)
+				}
+			})
+		}
+	})
+
+	t.Run("SyntaxError", func(t *testing.T) {
+		for _, expr := range []string{
+			"",
+			"price *",
+			"(price",
+			"price qty",
+			`"open`,
+			"[Unit Cost",
+			"missing + 1",
+			"total * 2",
+			"prop(price)",
+			"1.2.3",
+			"price % 2",
+		} {
+			if _, err := parseFormula(expr, props); !errors.Is(err, errFormulaSyntax) {
+				t.Errorf("%q: got %v, want errFormulaSyntax", expr, err)
+			}
+		}
+	})
+
+	t.Run("EvalError", func(t *testing.T) {
+		for _, expr := range []string{"price / 0", "name * 2", "-name", `name - "x"`} {
+			n, err := parseFormula(expr, props)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := n.eval(data); !errors.Is(err, errFormulaEval) {
+				t.Errorf("%q: got %v, want errFormulaEval", expr, err)
+			}
+		}
+	})
+}
+
+func Test_compileFormulas(t *testing.T) {
+	if compileFormulas([]Property{{Name: "a", Type: PropertyTypeNumber}}) != nil {
+		t.Fatal("want nil without formula properties")
+	}
+	f := compileFormulas([]Property{
+		{Name: "a", Type: PropertyTypeNumber},
+		{Name: "double", Type: PropertyTypeFormula, FormulaConfig: &FormulaConfig{Expression: "a * 2"}},
+		{Name: "ratio", Type: PropertyTypeFormula, FormulaConfig: &FormulaConfig{Expression: "1 / a"}},
+		{Name: "notion", Type: PropertyTypeFormula, FormulaConfig: &FormulaConfig{Expression: `if(prop("a") > 1, "big", "small")`}},
+		{Name: "unset", Type: PropertyTypeFormula},
+	})
+	if !f.computed("double") || f.computed("notion") || f.computed("a") {
+		t.Error("unexpected computed()")
+	}
+	data := map[string]any{"a": int64(0), "double": "stale", "notion": "small"}
+	f.apply(data)
+	want := map[string]any{"a": int64(0), "double": float64(0), "ratio": FormulaErrorValue, "notion": "small", "unset": FormulaErrorValue}
+	for k, v := range want {
+		if data[k] != v {
+			t.Errorf("%s = %v, want %v", k, data[k], v)
+		}
+	}
+}
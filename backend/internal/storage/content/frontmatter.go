@@ -0,0 +1,165 @@
+// Reads and writes the YAML front matter of page markdown files.
+
+package content
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/maruel/mddb/backend/internal/storage"
+	"gopkg.in/yaml.v3"
+)
+
+// frontMatter is the YAML front matter of an index.md file.
+type frontMatter struct {
+	Title    string    `yaml:"title"`
+	Created  time.Time `yaml:"created,omitempty"`
+	Modified time.Time `yaml:"modified,omitempty"`
+	Tags     []string  `yaml:"tags,omitempty,flow"`
+	Icon     string    `yaml:"icon,omitempty"`
+	Cover    string    `yaml:"cover,omitempty"`
+	// Extra holds the fields mddb does not know about so that they survive
+	// edits.
+	Extra map[string]any `yaml:",inline"`
+}
+
+// checkFrontMatterExtra returns an error if extra sets a field of frontMatter,
+// which would be written twice.
+func checkFrontMatterExtra(extra map[string]any) error {
+	for key := range extra {
+		switch key {
+		case "title", "created", "modified", "tags", "icon", "cover":
+			return fmt.Errorf("%w: %q", errReservedFrontMatter, key)
+		}
+	}
+	return nil
+}
+
+// ParseMarkdown parses a markdown file with optional YAML front matter.
+//
+// Front matter that is not valid YAML, like titles containing ": " written by
+// older versions, is parsed line by line instead.
+func ParseMarkdown(data []byte) *page {
+	content := string(data)
+	var fm frontMatter
+	if raw, body, ok := splitFrontMatter(content); ok {
+		content = body
+		if err := yaml.Unmarshal([]byte(raw), &fm); err != nil {
+			fm = parseLegacyFrontMatter(raw)
+		}
+	}
+
+	p := &page{
+		title:   fm.Title,
+		content: content,
+		tags:    fm.Tags,
+		icon:    fm.Icon,
+		cover:   fm.Cover,
+		extra:   fm.Extra,
+	}
+	if !fm.Created.IsZero() {
+		p.created = storage.ToTime(fm.Created)
+	} else {
+		p.created = storage.Now()
+	}
+	if !fm.Modified.IsZero() {
+		p.modified = storage.ToTime(fm.Modified)
+	} else {
+		p.modified = storage.Now()
+	}
+	return p
+}
+
+// splitFrontMatter splits content into the front matter between the leading
+// --- lines and the body that follows.
+func splitFrontMatter(content string) (raw, body string, ok bool) {
+	if !strings.HasPrefix(content, "---") {
+		return "", content, false
+	}
+	parts := strings.SplitN(content, "\n---", 2)
+	if len(parts) != 2 {
+		return "", content, false
+	}
+	// Keep the newline ending the last line, which is part of a trailing
+	// block scalar.
+	return strings.TrimPrefix(parts[0][3:], "\n") + "\n", strings.TrimLeft(parts[1], "\n"), true
+}
+
+// parseLegacyFrontMatter parses front matter one "key: value" line at a time,
+// as written before values were YAML encoded. Values may be Go-quoted, as done
+// by the Notion importer.
+func parseLegacyFrontMatter(raw string) frontMatter {
+	var fm frontMatter
+	for _, line := range strings.Split(raw, "\n") {
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		switch key {
+		case "title":
+			if strings.HasPrefix(value, `"`) {
+				if t, err := strconv.Unquote(value); err == nil {
+					value = t
+				}
+			}
+			fm.Title = value
+		case "created":
+			fm.Created, _ = time.Parse(time.RFC3339, value)
+		case "modified":
+			fm.Modified, _ = time.Parse(time.RFC3339, value)
+		case "tags":
+			value = strings.TrimSuffix(strings.TrimPrefix(value, "["), "]")
+			for _, tag := range strings.Split(value, ",") {
+				if tag = strings.TrimSpace(tag); tag != "" {
+					fm.Tags = append(fm.Tags, tag)
+				}
+			}
+		case "icon":
+			fm.Icon = value
+		case "cover":
+			fm.Cover = value
+		}
+	}
+	return fm
+}
+
+// formatMarkdownFile returns the content of an index.md file for p.
+func formatMarkdownFile(p *page) []byte {
+	fm := frontMatter{
+		Title:    p.title,
+		Created:  p.created.AsTime().Truncate(time.Second),
+		Modified: p.modified.AsTime().Truncate(time.Second),
+		Tags:     p.tags,
+		Icon:     p.icon,
+		Cover:    p.cover,
+		Extra:    p.extra,
+	}
+	var buf bytes.Buffer
+	buf.WriteString("---\n")
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&fm); err != nil {
+		// Only fails on values that cannot be marshaled, which parsed YAML
+		// never contains; write the known fields.
+		buf.Reset()
+		buf.WriteString("---\n")
+		fm.Extra = nil
+		_ = yaml.NewEncoder(&buf).Encode(&fm)
+	}
+	buf.WriteString("---\n\n")
+	buf.WriteString(p.content)
+	return buf.Bytes()
+}
+
+// formatTitle returns title as a YAML scalar for the title front matter line.
+func formatTitle(title string) string {
+	out, err := yaml.Marshal(title)
+	if err != nil {
+		return strconv.Quote(title)
+	}
+	return strings.TrimSuffix(string(out), "\n")
+}
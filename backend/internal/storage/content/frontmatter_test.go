@@ -0,0 +1,139 @@
+package content
+
+import (
+	"os"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/maruel/ksid"
+	"github.com/maruel/mddb/backend/internal/storage/git"
+)
+
+func TestParseMarkdown(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		title   string
+		tags    []string
+		icon    string
+		extra   map[string]any
+		content string
+	}{
+		{
+			name:    "Ordered",
+			data:    "---\ntitle: Hello\ncreated: 2024-01-02T03:04:05Z\nmodified: 2024-01-02T03:04:05Z\ntags: [a, b c]\nicon: 📄\n---\n\nbody",
+			title:   "Hello",
+			tags:    []string{"a", "b c"},
+			icon:    "📄",
+			content: "body",
+		},
+		{
+			name:    "AnyOrder",
+			data:    "---\nicon: x\ntags:\n  - one\ntitle: 'It''s: here'\n---\nbody",
+			title:   "It's: here",
+			tags:    []string{"one"},
+			icon:    "x",
+			content: "body",
+		},
+		{
+			name:    "NotionQuoted",
+			data:    "---\ntitle: \"Plan: Q3 \\\"draft\\\"\"\n---\n\nbody",
+			title:   `Plan: Q3 "draft"`,
+			content: "body",
+		},
+		{
+			name:    "NotAString",
+			data:    "---\ntitle: 2024\n---\n\nbody",
+			title:   "2024",
+			content: "body",
+		},
+		{
+			name:    "MultiLineExtra",
+			data:    "---\ntitle: T\nsummary: |\n  line one\n  --- not the end\nstatus: draft\n---\n\nbody\n---\nmore",
+			title:   "T",
+			extra:   map[string]any{"summary": "line one\n--- not the end\n", "status": "draft"},
+			content: "body\n---\nmore",
+		},
+		{
+			name:    "LegacyUnquoted",
+			data:    "---\ntitle: Key: value\ncreated: 2024-01-02T03:04:05Z\ntags: [x, y]\n---\n\nbody",
+			title:   "Key: value",
+			tags:    []string{"x", "y"},
+			content: "body",
+		},
+		{
+			name:    "None",
+			data:    "just body",
+			content: "just body",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := ParseMarkdown([]byte(tt.data))
+			if p.title != tt.title || p.content != tt.content || p.icon != tt.icon || !slices.Equal(p.tags, tt.tags) {
+				t.Errorf("got title %q tags %q icon %q content %q", p.title, p.tags, p.icon, p.content)
+			}
+			if len(p.extra) != 0 || len(tt.extra) != 0 {
+				if !reflect.DeepEqual(p.extra, tt.extra) {
+					t.Errorf("extra = %#v, want %#v", p.extra, tt.extra)
+				}
+			}
+			// What is parsed is written back the same.
+			again := ParseMarkdown(formatMarkdownFile(p))
+			if again.title != p.title || again.content != p.content || !slices.Equal(again.tags, p.tags) || !reflect.DeepEqual(again.extra, p.extra) {
+				t.Errorf("round trip: %+v, want %+v", again, p)
+			}
+		})
+	}
+}
+
+func TestFrontMatterExtra(t *testing.T) {
+	_, ws, _ := initWS(t)
+	ctx := t.Context()
+	author := git.Author{Name: "Test", Email: "test@test.com"}
+	id := ksid.NewID()
+	if _, err := ws.WritePage(ctx, id, 0, "Page", "first", author); err != nil {
+		t.Fatal(err)
+	}
+
+	// A field added by another tool.
+	file := ws.pageIndexFile(id, 0)
+	data, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data = []byte(strings.Replace(string(data), "---\n", "---\nreviewers:\n  - ann\n  - bob\n", 1))
+	if err := os.WriteFile(file, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if node, err := ws.ReadPage(id); err != nil || node.Title != "Page" || node.Content != "first" {
+		t.Fatalf("unexpected page: %+v, %v", node, err)
+	}
+
+	if _, err := ws.UpdatePage(ctx, id, "Page: v2", "second", author); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ws.UpdatePageFrontmatter(ctx, id, "📄", "", author); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ws.RenameNode(ctx, id, "Page: v3", author); err != nil {
+		t.Fatal(err)
+	}
+	node, err := ws.ReadPage(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if node.Title != "Page: v3" || node.Content != "second" || node.Icon != "📄" {
+		t.Errorf("unexpected page: %+v", node)
+	}
+	data, err = os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]any{"reviewers": []any{"ann", "bob"}}
+	if p := ParseMarkdown(data); !reflect.DeepEqual(p.extra, want) {
+		t.Errorf("extra = %#v, want %#v\n%s", p.extra, want, data)
+	}
+}
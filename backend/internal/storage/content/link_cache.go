@@ -1,53 +1,82 @@
-// In-memory bidirectional link index for backlink queries.
+// Bidirectional link index for backlink queries, persisted across restarts.
 
 package content
 
 import (
-	"iter"
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"path/filepath"
 	"slices"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/maruel/ksid"
 )
 
 // linkCache maintains a bidirectional index of internal page links.
 //
-// It is lazily built on first access by scanning all pages, then kept
-// up-to-date incrementally as pages are created, updated, or deleted.
+// It is lazily loaded on first access, then kept up-to-date incrementally as
+// pages are created, updated, or deleted, so that the next process does not
+// have to scan every page. The whole index is saved to file when it is built;
+// each later change is appended to a journal next to it, and the index is
+// saved again once the journal outgrows it.
 //
 // The forward map tracks source→targets so we can diff on update.
 // The backward map tracks target→sources for O(1) backlink lookups.
 type linkCache struct {
-	mu       sync.RWMutex
-	built    bool
-	forward  map[ksid.ID][]ksid.ID // source → target IDs
-	backward map[ksid.ID][]ksid.ID // target → source IDs
+	file      string     // Where the index is saved; empty to keep it in memory only.
+	saveMu    sync.Mutex // Serializes save and compact so the journal follows the index
+	mu        sync.RWMutex
+	built     bool
+	forward   map[ksid.ID][]ksid.ID // source → target IDs
+	backward  map[ksid.ID][]ksid.ID // target → source IDs
+	pending   []linkJournalEntry    // Changes not appended to the journal yet
+	journaled int                   // Entries in the journal; guarded by saveMu
 }
 
-// buildLocked populates both maps by scanning all pages. Caller must hold mu for writing.
-func (c *linkCache) buildLocked(iterPages func() (iter.Seq[*Node], error)) error {
-	pages, err := iterPages()
-	if err != nil {
-		return err
-	}
-	c.forward = make(map[ksid.ID][]ksid.ID)
+// linkIndex is the content of linkCache.file.
+type linkIndex struct {
+	// Saved is when the index was written. Pages modified since then are
+	// indexed again on load.
+	Saved time.Time             `json:"saved"`
+	Links map[ksid.ID][]ksid.ID `json:"links"`
+}
+
+// linkJournalEntry is a line of the journal: the links of a page as of when
+// it was appended. An entry without links removes the page.
+type linkJournalEntry struct {
+	ID    ksid.ID   `json:"id"`
+	Links []ksid.ID `json:"links,omitempty"`
+	Saved time.Time `json:"saved"`
+}
+
+// linkJournalMin is the number of entries the journal can always hold before
+// the index is saved again, on top of one per page in the index.
+const linkJournalMin = 256
+
+// journalFile returns where changes to the saved index are appended.
+func (c *linkCache) journalFile() string {
+	return strings.TrimSuffix(c.file, ".json") + ".jsonl"
+}
+
+// setLocked replaces both maps from a forward map. Caller must hold mu for writing.
+func (c *linkCache) setLocked(forward map[ksid.ID][]ksid.ID) {
+	c.forward = forward
 	c.backward = make(map[ksid.ID][]ksid.ID)
-	for page := range pages {
-		targets := ExtractLinkedNodeIDs(page.Content)
-		if len(targets) == 0 {
-			continue
-		}
-		c.forward[page.ID] = targets
+	for src, targets := range forward {
 		for _, t := range targets {
-			c.backward[t] = append(c.backward[t], page.ID)
+			c.backward[t] = append(c.backward[t], src)
 		}
 	}
 	c.built = true
-	return nil
 }
 
-// ensureBuilt lazily initializes the cache on first access.
-func (c *linkCache) ensureBuilt(iterPages func() (iter.Seq[*Node], error)) error {
+// ensureBuilt lazily initializes the cache on first access. load returns the
+// forward map, source → targets.
+func (c *linkCache) ensureBuilt(load func() (map[ksid.ID][]ksid.ID, error)) error {
 	c.mu.RLock()
 	if c.built {
 		c.mu.RUnlock()
@@ -56,15 +85,38 @@ func (c *linkCache) ensureBuilt(iterPages func() (iter.Seq[*Node], error)) error
 	c.mu.RUnlock()
 
 	c.mu.Lock()
-	defer c.mu.Unlock()
 	if c.built {
+		c.mu.Unlock()
 		return nil
 	}
-	return c.buildLocked(iterPages)
+	forward, err := load()
+	if err != nil {
+		c.mu.Unlock()
+		return err
+	}
+	c.setLocked(forward)
+	c.mu.Unlock()
+	c.compact()
+	return nil
+}
+
+// rebuild replaces the cache content with the forward map returned by scan
+// and saves it.
+func (c *linkCache) rebuild(scan func() (map[ksid.ID][]ksid.ID, error)) error {
+	c.mu.Lock()
+	forward, err := scan()
+	if err != nil {
+		c.mu.Unlock()
+		return err
+	}
+	c.setLocked(forward)
+	c.mu.Unlock()
+	c.compact()
+	return nil
 }
 
 // update recomputes entries for sourceID based on its current content.
-// Call after a page is created or updated.
+// Call after a page is created or updated, then call save.
 func (c *linkCache) update(sourceID ksid.ID, content string) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
@@ -90,9 +142,10 @@ func (c *linkCache) update(sourceID ksid.ID, content string) {
 	for _, t := range newTargets {
 		c.backward[t] = append(c.backward[t], sourceID)
 	}
+	c.pending = append(c.pending, linkJournalEntry{ID: sourceID, Links: newTargets})
 }
 
-// remove deletes all entries for a deleted page.
+// remove deletes all entries for a deleted page. Call save afterward.
 func (c *linkCache) remove(sourceID ksid.ID) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
@@ -103,6 +156,92 @@ func (c *linkCache) remove(sourceID ksid.ID) {
 		c.removeBackwardLocked(t, sourceID)
 	}
 	delete(c.forward, sourceID)
+	c.pending = append(c.pending, linkJournalEntry{ID: sourceID})
+}
+
+// save appends the changes made since the last call to the journal, or saves
+// the whole index when the journal grew larger than it. Failures are logged:
+// the index is rebuilt from the pages when it cannot be loaded.
+func (c *linkCache) save() {
+	if c.file == "" {
+		return
+	}
+	c.saveMu.Lock()
+	c.mu.Lock()
+	pending := c.pending
+	c.pending = nil
+	size := len(c.forward)
+	c.mu.Unlock()
+	if len(pending) == 0 {
+		c.saveMu.Unlock()
+		return
+	}
+	if c.journaled+len(pending) > size+linkJournalMin {
+		c.saveMu.Unlock()
+		c.compact()
+		return
+	}
+	defer c.saveMu.Unlock()
+	var buf bytes.Buffer
+	saved := time.Now()
+	for i := range pending {
+		pending[i].Saved = saved
+		data, err := json.Marshal(&pending[i])
+		if err != nil {
+			slog.Warn("Failed to save the link index", "file", c.file, "error", err)
+			return
+		}
+		buf.Write(data)
+		buf.WriteByte('\n')
+	}
+	f, err := os.OpenFile(c.journalFile(), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err == nil {
+		_, err = f.Write(buf.Bytes())
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+	}
+	if err != nil {
+		slog.Warn("Failed to save the link index", "file", c.journalFile(), "error", err)
+		return
+	}
+	c.journaled += len(pending)
+}
+
+// compact writes the whole index to c.file and empties the journal.
+func (c *linkCache) compact() {
+	if c.file == "" {
+		return
+	}
+	c.saveMu.Lock()
+	defer c.saveMu.Unlock()
+	saved := time.Now()
+	c.mu.Lock()
+	if !c.built {
+		c.mu.Unlock()
+		return
+	}
+	// The index written below includes the pending changes.
+	c.pending = nil
+	data, err := json.Marshal(linkIndex{Saved: saved, Links: c.forward})
+	c.mu.Unlock()
+	if err == nil {
+		// Write then rename so a concurrent load never sees a torn file.
+		tmp := c.file + ".tmp"
+		if err = os.WriteFile(tmp, data, 0o600); err == nil {
+			err = os.Rename(tmp, c.file)
+		}
+	}
+	if err == nil {
+		if err = os.Remove(c.journalFile()); os.IsNotExist(err) {
+			err = nil
+		}
+	}
+	if err != nil {
+		slog.Warn("Failed to save the link index", "file", c.file, "error", err)
+		return
+	}
+	c.journaled = 0
 }
 
 // backlinks returns source IDs that link to targetID.
@@ -137,3 +276,97 @@ func (c *linkCache) removeBackwardLocked(targetID, sourceID ksid.ID) {
 		}
 	}
 }
+
+// linkIndexSlack is how long before the index was saved a page must have been
+// modified to be trusted, to cope with coarse file system timestamps.
+const linkIndexSlack = time.Second
+
+// loadLinks returns the forward link map of the workspace.
+//
+// It starts from the saved index when there is one, applies its journal and
+// only reads the pages modified since their links were saved, so that changes
+// committed right before a crash or made behind the store's back are picked
+// up. Otherwise every page is read.
+func (ws *WorkspaceFileStore) loadLinks() (map[ksid.ID][]ksid.ID, error) {
+	var idx linkIndex
+	data, err := os.ReadFile(ws.links.file)
+	if err != nil || json.Unmarshal(data, &idx) != nil || idx.Links == nil {
+		return ws.scanLinks()
+	}
+	// When the links of a page were last saved.
+	savedAt := map[ksid.ID]time.Time{}
+	if journal, err := os.ReadFile(ws.links.journalFile()); err == nil {
+		for line := range bytes.Lines(journal) {
+			var e linkJournalEntry
+			if json.Unmarshal(line, &e) != nil {
+				// A line torn by a crash; the page is read again below.
+				continue
+			}
+			if len(e.Links) != 0 {
+				idx.Links[e.ID] = e.Links
+			} else {
+				delete(idx.Links, e.ID)
+			}
+			savedAt[e.ID] = e.Saved
+		}
+	}
+	modified := map[ksid.ID]time.Time{}
+	var walk func(dir string)
+	walk = func(dir string) {
+		ids, _ := nodeDirs(dir)
+		for _, id := range ids {
+			d := filepath.Join(dir, id.String())
+			if fi, err := os.Stat(filepath.Join(d, "index.md")); err == nil {
+				modified[id] = fi.ModTime()
+			}
+			walk(d)
+		}
+	}
+	walk(ws.wsDir)
+	for src := range idx.Links {
+		if _, ok := modified[src]; !ok {
+			delete(idx.Links, src)
+		}
+	}
+	for id, t := range modified {
+		saved, ok := savedAt[id]
+		if !ok {
+			saved = idx.Saved
+		}
+		if t.Before(saved.Add(-linkIndexSlack)) {
+			continue
+		}
+		page, err := ws.ReadPage(id)
+		if err != nil {
+			continue
+		}
+		if targets := ExtractLinkedNodeIDs(page.Content); len(targets) != 0 {
+			idx.Links[id] = targets
+		} else {
+			delete(idx.Links, id)
+		}
+	}
+	return idx.Links, nil
+}
+
+// scanLinks reads every page of the workspace and returns its forward link
+// map.
+func (ws *WorkspaceFileStore) scanLinks() (map[ksid.ID][]ksid.ID, error) {
+	pages, err := ws.IterPages()
+	if err != nil {
+		return nil, err
+	}
+	forward := make(map[ksid.ID][]ksid.ID)
+	for page := range pages {
+		if targets := ExtractLinkedNodeIDs(page.Content); len(targets) != 0 {
+			forward[page.ID] = targets
+		}
+	}
+	return forward, nil
+}
+
+// RebuildBacklinks discards the backlink index and rebuilds it by reading
+// every page.
+func (ws *WorkspaceFileStore) RebuildBacklinks() error {
+	return ws.links.rebuild(ws.scanLinks)
+}
@@ -0,0 +1,219 @@
+package content
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/maruel/ksid"
+	"github.com/maruel/mddb/backend/internal/storage/git"
+)
+
+// sortedLinks returns the forward links of the index with sorted targets.
+func sortedLinks(ws *WorkspaceFileStore) map[ksid.ID][]ksid.ID {
+	links := ws.links.forwardAll()
+	for _, targets := range links {
+		slices.Sort(targets)
+	}
+	return links
+}
+
+func TestLinkIndex(t *testing.T) {
+	author := git.Author{Name: "Test", Email: "test@test.com"}
+	link := func(ids ...ksid.ID) string {
+		s := ""
+		for _, id := range ids {
+			s += fmt.Sprintf("[x](../%s/index.md) ", id)
+		}
+		return s
+	}
+
+	t.Run("IncrementalMatchesRebuild", func(t *testing.T) {
+		_, ws, _ := initWS(t)
+		ctx := t.Context()
+		a, err := ws.CreatePageUnderParent(ctx, 0, "A", "", author)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := ws.CreatePageUnderParent(ctx, 0, "B", link(a.ID), author)
+		if err != nil {
+			t.Fatal(err)
+		}
+		c, err := ws.CreatePageUnderParent(ctx, a.ID, "C", link(a.ID, b.ID), author)
+		if err != nil {
+			t.Fatal(err)
+		}
+		d, err := ws.CreatePageUnderParent(ctx, 0, "D", link(c.ID), author)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := ws.UpdatePage(ctx, b.ID, "B", link(c.ID, d.ID), author); err != nil {
+			t.Fatal(err)
+		}
+		if err := ws.MoveNode(ctx, c.ID, b.ID, author); err != nil {
+			t.Fatal(err)
+		}
+		if err := ws.TrashNode(ctx, d.ID, author); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := ws.UpdatePage(ctx, a.ID, "A", link(b.ID), author); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := ws.RestoreNode(ctx, d.ID, author); err != nil {
+			t.Fatal(err)
+		}
+		if err := ws.DeletePage(ctx, a.ID, author); err != nil {
+			t.Fatal(err)
+		}
+
+		incremental := sortedLinks(ws)
+		if err := ws.RebuildBacklinks(); err != nil {
+			t.Fatal(err)
+		}
+		if rebuilt := sortedLinks(ws); !maps.EqualFunc(incremental, rebuilt, slices.Equal) {
+			t.Errorf("incremental index differs from a rebuild:\n%v\n%v", incremental, rebuilt)
+		}
+		if got, err := ws.GetBacklinks(d.ID); err != nil || len(got) != 1 || got[0].NodeID != b.ID {
+			t.Errorf("unexpected backlinks of D: %+v, %v", got, err)
+		}
+	})
+
+	t.Run("Persisted", func(t *testing.T) {
+		fs, ws, wsID := initWS(t)
+		ctx := t.Context()
+		a, err := ws.CreatePageUnderParent(ctx, 0, "A", "", author)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := ws.CreatePageUnderParent(ctx, 0, "B", link(a.ID), author)
+		if err != nil {
+			t.Fatal(err)
+		}
+		c, err := ws.CreatePageUnderParent(ctx, 0, "C", "", author)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// The index is built and saved on first use.
+		if _, err := ws.GetBacklinks(a.ID); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := os.Stat(ws.links.file); err != nil {
+			t.Fatalf("index not saved: %v", err)
+		}
+		if _, err := ws.UpdatePage(ctx, c.ID, "C", link(a.ID), author); err != nil {
+			t.Fatal(err)
+		}
+
+		// Replace the index with one saved before C was updated and drop the
+		// journal, as if the process crashed right after committing C. It also
+		// claims that A links to C, which is only known from the file since A is
+		// older than it.
+		if err := os.Remove(ws.links.journalFile()); err != nil && !os.IsNotExist(err) {
+			t.Fatal(err)
+		}
+		old := time.Now().Add(-time.Hour)
+		for _, id := range []ksid.ID{a.ID, b.ID} {
+			if err := os.Chtimes(ws.pageIndexFile(id, 0), old, old); err != nil {
+				t.Fatal(err)
+			}
+		}
+		saved := time.Now().Add(-time.Minute).Format(time.RFC3339Nano)
+		data := fmt.Sprintf(`{"saved":%q,"links":{%q:[%q],%q:[%q]}}`, saved, a.ID, c.ID, b.ID, a.ID)
+		if err := os.WriteFile(ws.links.file, []byte(data), 0o600); err != nil {
+			t.Fatal(err)
+		}
+
+		fs.mu.Lock()
+		delete(fs.stores, wsID)
+		fs.mu.Unlock()
+		ws, err = fs.GetWorkspaceStore(ctx, wsID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := ws.GetBacklinks(a.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var ids []ksid.ID
+		for _, bl := range got {
+			ids = append(ids, bl.NodeID)
+		}
+		slices.Sort(ids)
+		if want := []ksid.ID{b.ID, c.ID}; !slices.Equal(ids, want) {
+			t.Errorf("backlinks = %v, want %v", ids, want)
+		}
+		if got, err := ws.GetBacklinks(c.ID); err != nil || len(got) != 1 {
+			t.Errorf("want the backlink from the saved index: %+v, %v", got, err)
+		}
+		if err := ws.RebuildBacklinks(); err != nil {
+			t.Fatal(err)
+		}
+		if got, err := ws.GetBacklinks(c.ID); err != nil || len(got) != 0 {
+			t.Errorf("want no backlinks after a rebuild: %+v, %v", got, err)
+		}
+	})
+
+	t.Run("Journal", func(t *testing.T) {
+		fs, ws, wsID := initWS(t)
+		ctx := t.Context()
+		a, err := ws.CreatePageUnderParent(ctx, 0, "A", "", author)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := ws.CreatePageUnderParent(ctx, 0, "B", link(a.ID), author)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := ws.RebuildBacklinks(); err != nil {
+			t.Fatal(err)
+		}
+		before, err := os.ReadFile(ws.links.file)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// Changes are appended to the journal, the index is left alone.
+		if _, err := ws.UpdatePage(ctx, b.ID, "B", "no links", author); err != nil {
+			t.Fatal(err)
+		}
+		if after, err := os.ReadFile(ws.links.file); err != nil || string(after) != string(before) {
+			t.Errorf("index rewritten: %s, %v", after, err)
+		}
+		if fi, err := os.Stat(ws.links.journalFile()); err != nil || fi.Size() == 0 {
+			t.Fatalf("journal not written: %v", err)
+		}
+
+		// The journal is applied on load even when the page is not read again,
+		// and folded into the index.
+		old := time.Now().Add(-time.Hour)
+		if err := os.Chtimes(ws.pageIndexFile(b.ID, 0), old, old); err != nil {
+			t.Fatal(err)
+		}
+		fs.mu.Lock()
+		delete(fs.stores, wsID)
+		fs.mu.Unlock()
+		if ws, err = fs.GetWorkspaceStore(ctx, wsID); err != nil {
+			t.Fatal(err)
+		}
+		if got, err := ws.GetBacklinks(a.ID); err != nil || len(got) != 0 {
+			t.Errorf("want no backlinks of A: %+v, %v", got, err)
+		}
+		if _, err := os.Stat(ws.links.journalFile()); !os.IsNotExist(err) {
+			t.Errorf("journal not compacted: %v", err)
+		}
+
+		// A long journal is folded into the index.
+		for i := range linkJournalMin + 1 {
+			if _, err := ws.UpdatePage(ctx, b.ID, "B", link(a.ID)+strconv.Itoa(i), author); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if ws.links.journaled > linkJournalMin+2 {
+			t.Errorf("journal has %d entries", ws.links.journaled)
+		}
+	})
+}
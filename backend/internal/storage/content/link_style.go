@@ -0,0 +1,159 @@
+// Converts links between pages to the link style configured for the workspace.
+
+package content
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+
+	"github.com/maruel/ksid"
+	"github.com/maruel/mddb/backend/internal/storage/git"
+	"github.com/maruel/mddb/backend/internal/storage/identity"
+)
+
+// wikiNodeLinkRe matches wikilinks to a node like [[nodeID]] or
+// [[nodeID|text]]. Group 1: node ID, Group 2: text.
+var wikiNodeLinkRe = regexp.MustCompile(`\[\[([0-9A-V]{1,13})(?:\|([^\[\]]*))?\]\]`)
+
+// nodeLinkRe matches links to a node in either style. Groups 1 and 2 are
+// those of relativeLinkRe, groups 3 and 4 those of wikiNodeLinkRe.
+var nodeLinkRe = regexp.MustCompile(relativeLinkRe.String() + `|` + wikiNodeLinkRe.String())
+
+// wikiLinkTargetID returns the node ID a wikilink target like nodeID refers
+// to. Only the canonical encoding is accepted so that [[Some Page]] style
+// wikilinks are not mistaken for node links.
+func wikiLinkTargetID(target string) (ksid.ID, bool) {
+	id, err := ksid.Parse(target)
+	if err != nil || id.IsZero() || id.String() != target {
+		return 0, false
+	}
+	return id, true
+}
+
+// toWikiLinks rewrites the relative path links to nodes in content as
+// wikilinks. The link text is kept unless it is the node ID itself.
+func toWikiLinks(content string) string {
+	return relativeLinkRe.ReplaceAllStringFunc(content, func(m string) string {
+		sub := relativeLinkRe.FindStringSubmatch(m)
+		id, ok := linkTargetID(sub[2])
+		if !ok {
+			return m
+		}
+		if sub[1] == "" || sub[1] == id.String() {
+			return "[[" + id.String() + "]]"
+		}
+		return "[[" + id.String() + "|" + sub[1] + "]]"
+	})
+}
+
+// toRelativeLinks rewrites the wikilinks to nodes in content as relative path
+// links. href returns the path to the index.md of a node. Wikilinks without
+// text use the node ID as text.
+func toRelativeLinks(content string, href func(ksid.ID) string) string {
+	return wikiNodeLinkRe.ReplaceAllStringFunc(content, func(m string) string {
+		sub := wikiNodeLinkRe.FindStringSubmatch(m)
+		id, ok := wikiLinkTargetID(sub[1])
+		if !ok {
+			return m
+		}
+		text := sub[2]
+		if text == "" {
+			text = id.String()
+		}
+		return "[" + text + "](" + href(id) + ")"
+	})
+}
+
+// siblingHref returns the ../nodeID/index.md href used when the location of
+// the target node is unknown.
+func siblingHref(id ksid.ID) string {
+	return "../" + id.String() + "/index.md"
+}
+
+// linkStyle returns the link style configured for the workspace.
+func (ws *WorkspaceFileStore) linkStyle() identity.LinkStyle {
+	if ws.wsSvc != nil {
+		if w, err := ws.wsSvc.Get(ws.wsID); err == nil && w.Settings.LinkStyle != "" {
+			return w.Settings.LinkStyle
+		}
+	}
+	return identity.LinkStyleRelative
+}
+
+// normalizeLinks returns the content of page id with its links to other nodes
+// in the configured link style.
+//
+// Wikilinks become links relative to the page's directory; links to nodes
+// that do not exist use ../nodeID/index.md. Relative links are kept as is so
+// the paths written by the user are preserved.
+func (ws *WorkspaceFileStore) normalizeLinks(id, parentID ksid.ID, content string) string {
+	if ws.linkStyle() == identity.LinkStyleWikilink {
+		return toWikiLinks(content)
+	}
+	srcDir := ws.relativeDir(id, parentID)
+	return toRelativeLinks(content, func(target ksid.ID) string {
+		ws.mu.RLock()
+		parent, ok := ws.cache[target]
+		ws.mu.RUnlock()
+		if !ok {
+			return siblingHref(target)
+		}
+		rel, err := filepath.Rel(srcDir, ws.relativeDir(target, parent))
+		if err != nil {
+			return siblingHref(target)
+		}
+		return path.Join(filepath.ToSlash(rel), "index.md")
+	})
+}
+
+// RewriteLinks rewrites the links between pages of every page in the
+// configured link style, in a single git commit. It is meant to be called
+// after the workspace link style changed. Returns the number of pages
+// rewritten.
+func (ws *WorkspaceFileStore) RewriteLinks(ctx context.Context, author git.Author) (int, error) {
+	if err := ws.refreshCache(); err != nil {
+		return 0, fmt.Errorf("refresh cache: %w", err)
+	}
+	var nodes []*Node
+	err := ws.repo.CommitTx(ctx, author, func() (string, []string, error) {
+		pages, err := ws.IterPages()
+		if err != nil {
+			return "", nil, err
+		}
+		var files []string
+		for node := range pages {
+			// ReadPage already normalized the content; compare with the file.
+			file := ws.pageIndexFile(node.ID, node.ParentID)
+			data, err := os.ReadFile(file) //nolint:gosec // G304: file is constructed from cached node IDs
+			if err != nil {
+				return "", nil, fmt.Errorf("failed to read page %s: %w", node.ID, err)
+			}
+			p := ParseMarkdown(data)
+			if p.content == node.Content {
+				continue
+			}
+			p.content = node.Content
+			if err := ws.writePageFile(node.ID, node.ParentID, p); err != nil {
+				return "", nil, err
+			}
+			nodes = append(nodes, node)
+			files = append(files, ws.gitPath(node.ParentID, node.ID, "index.md"))
+		}
+		summary := "links: rewrite " + strconv.Itoa(len(files)) + " pages as " + string(ws.linkStyle())
+		return git.AppendTrailers(summary,
+			git.Trailer{Key: git.TrailerOp, Value: "update"},
+			git.Trailer{Key: git.TrailerType, Value: string(NodeTypeDocument)},
+			git.Trailer{Key: git.TrailerActor, Value: author.ID},
+		), files, nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	ws.pageWritten(nodes...)
+	return len(nodes), nil
+}
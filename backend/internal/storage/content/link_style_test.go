@@ -0,0 +1,147 @@
+package content
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/maruel/ksid"
+	"github.com/maruel/mddb/backend/internal/storage/git"
+	"github.com/maruel/mddb/backend/internal/storage/identity"
+)
+
+// setLinkStyle configures the link style of the workspace of ws.
+func setLinkStyle(t *testing.T, ws *WorkspaceFileStore, style identity.LinkStyle) {
+	t.Helper()
+	if _, err := ws.wsSvc.Modify(ws.wsID, func(w *identity.Workspace) error {
+		w.Settings.LinkStyle = style
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// readIndexFile returns the content of the index.md file of page id, without
+// the normalization ReadPage does.
+func readIndexFile(t *testing.T, ws *WorkspaceFileStore, id ksid.ID) string {
+	t.Helper()
+	data, err := os.ReadFile(ws.pageIndexFile(id, ws.getParent(id)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ParseMarkdown(data).content
+}
+
+func TestLinkStyle(t *testing.T) {
+	author := git.Author{Name: "Test", Email: "test@test.com"}
+	for _, style := range []identity.LinkStyle{identity.LinkStyleRelative, identity.LinkStyleWikilink} {
+		t.Run(string(style), func(t *testing.T) {
+			_, ws, _ := initWS(t)
+			ctx := t.Context()
+			setLinkStyle(t, ws, style)
+			parent, err := ws.CreatePageUnderParent(ctx, 0, "Parent", "", author)
+			if err != nil {
+				t.Fatal(err)
+			}
+			target, err := ws.CreatePageUnderParent(ctx, parent.ID, "Target", "", author)
+			if err != nil {
+				t.Fatal(err)
+			}
+			missing := ksid.NewID()
+			wantRelative := "[T](../" + parent.ID.String() + "/" + target.ID.String() + "/index.md) [" + missing.String() + "](../" + missing.String() + "/index.md)"
+			wantWiki := "[[" + target.ID.String() + "|T]] [[" + missing.String() + "]]"
+			want := wantRelative
+			if style == identity.LinkStyleWikilink {
+				want = wantWiki
+			}
+
+			// Links written in either style are stored in the configured one.
+			for i, content := range []string{wantWiki, wantRelative} {
+				src, err := ws.CreatePageUnderParent(ctx, 0, "Source", content, author)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if src.Content != want {
+					t.Errorf("%d: created %q, want %q", i, src.Content, want)
+				}
+				if got := readIndexFile(t, ws, src.ID); got != want {
+					t.Errorf("%d: stored %q, want %q", i, got, want)
+				}
+				page, err := ws.ReadPage(src.ID)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if page.Content != want {
+					t.Errorf("%d: read %q, want %q", i, page.Content, want)
+				}
+
+				backlinks, err := ws.GetBacklinks(target.ID)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(backlinks) != 1 || backlinks[0].NodeID != src.ID {
+					t.Errorf("%d: GetBacklinks = %v, want %s", i, backlinks, src.ID)
+				}
+				invalid, err := ws.ValidateLinks()
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(invalid) != 1 || invalid[0].SourceID != src.ID || invalid[0].Target != missing.String() {
+					t.Errorf("%d: ValidateLinks = %v, want the link to %s", i, invalid, missing)
+				}
+				if err := ws.DeletePage(ctx, src.ID, author); err != nil {
+					t.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func TestRewriteLinks(t *testing.T) {
+	author := git.Author{Name: "Test", Email: "test@test.com"}
+	_, ws, _ := initWS(t)
+	ctx := t.Context()
+	parent, err := ws.CreatePageUnderParent(ctx, 0, "Parent", "", author)
+	if err != nil {
+		t.Fatal(err)
+	}
+	target, err := ws.CreatePageUnderParent(ctx, parent.ID, "Target", "", author)
+	if err != nil {
+		t.Fatal(err)
+	}
+	src, err := ws.CreatePageUnderParent(ctx, 0, "Source", "See [the target](../"+parent.ID.String()+"/"+target.ID.String()+"/index.md).", author)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	setLinkStyle(t, ws, identity.LinkStyleWikilink)
+	n, err := ws.RewriteLinks(ctx, author)
+	if err != nil || n != 1 {
+		t.Fatalf("RewriteLinks = %d, %v, want 1 page", n, err)
+	}
+	if got, want := readIndexFile(t, ws, src.ID), "See [["+target.ID.String()+"|the target]]."; got != want {
+		t.Errorf("stored %q, want %q", got, want)
+	}
+	if n, err := ws.RewriteLinks(ctx, author); err != nil || n != 0 {
+		t.Errorf("second RewriteLinks = %d, %v, want nothing to do", n, err)
+	}
+
+	setLinkStyle(t, ws, identity.LinkStyleRelative)
+	if n, err := ws.RewriteLinks(ctx, author); err != nil || n != 1 {
+		t.Fatalf("RewriteLinks = %d, %v, want 1 page", n, err)
+	}
+	got := readIndexFile(t, ws, src.ID)
+	if want := "See [the target](../" + parent.ID.String() + "/" + target.ID.String() + "/index.md)."; got != want {
+		t.Errorf("stored %q, want %q", got, want)
+	}
+	// The rewritten link resolves on disk from the page's directory.
+	href := got[strings.Index(got, "(")+1 : strings.Index(got, ")")]
+	if _, err := os.Stat(filepath.Join(ws.pageDir(src.ID, 0), href)); err != nil {
+		t.Errorf("link %s does not resolve: %v", href, err)
+	}
+	backlinks, err := ws.GetBacklinks(target.ID)
+	if err != nil || len(backlinks) != 1 || backlinks[0].NodeID != src.ID {
+		t.Errorf("GetBacklinks = %v, %v, want %s", backlinks, err, src.ID)
+	}
+}
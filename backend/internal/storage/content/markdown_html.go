@@ -0,0 +1,156 @@
+// Minimal Markdown to HTML renderer used by static site exports.
+
+package content
+
+import (
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// MarkdownRenderer converts Markdown source to an HTML fragment.
+type MarkdownRenderer func(src string) string
+
+var (
+	mdImageRe  = regexp.MustCompile(`!\[([^\]]*)\]\(([^)\s]+)\)`)
+	mdLinkRe   = regexp.MustCompile(`\[([^\]]+)\]\(([^)\s]+)\)`)
+	mdStrongRe = regexp.MustCompile(`\*\*([^*]+)\*\*`)
+	mdEmRe     = regexp.MustCompile(`\*([^*]+)\*`)
+	mdOListRe  = regexp.MustCompile(`^\d+[.)] `)
+)
+
+// RenderMarkdown is the default MarkdownRenderer. It handles the common subset
+// of Markdown: ATX headings, paragraphs, fenced code blocks, block quotes,
+// flat lists, horizontal rules, and inline code, emphasis, links and images.
+// All text is HTML-escaped and links with a scheme other than http, https or
+// mailto are neutralized.
+func RenderMarkdown(src string) string {
+	var b strings.Builder
+	var para, quote []string
+	list := ""
+	flush := func() {
+		if len(para) != 0 {
+			b.WriteString("<p>" + renderInline(strings.Join(para, "\n")) + "</p>\n")
+			para = nil
+		}
+		if len(quote) != 0 {
+			b.WriteString("<blockquote><p>" + renderInline(strings.Join(quote, "\n")) + "</p></blockquote>\n")
+			quote = nil
+		}
+		if list != "" {
+			b.WriteString("</" + list + ">\n")
+			list = ""
+		}
+	}
+	lines := strings.Split(strings.ReplaceAll(src, "\r\n", "\n"), "\n")
+	for i := 0; i < len(lines); i++ {
+		line := strings.TrimSpace(lines[i])
+		switch {
+		case strings.HasPrefix(line, "```"):
+			flush()
+			b.WriteString("<pre><code>")
+			for i++; i < len(lines) && !strings.HasPrefix(strings.TrimSpace(lines[i]), "```"); i++ {
+				b.WriteString(html.EscapeString(lines[i]) + "\n")
+			}
+			b.WriteString("</code></pre>\n")
+		case line == "":
+			flush()
+		case line == "---" || line == "***" || line == "___":
+			flush()
+			b.WriteString("<hr>\n")
+		case headingLevel(line) > 0:
+			flush()
+			n := headingLevel(line)
+			tag := "h" + strconv.Itoa(n)
+			b.WriteString("<" + tag + ">" + renderInline(strings.TrimSpace(line[n:])) + "</" + tag + ">\n")
+		case line == ">" || strings.HasPrefix(line, "> "):
+			if quote == nil {
+				flush()
+			}
+			quote = append(quote, strings.TrimSpace(line[1:]))
+		case strings.HasPrefix(line, "- ") || strings.HasPrefix(line, "* ") || strings.HasPrefix(line, "+ "):
+			if list != "ul" {
+				flush()
+				b.WriteString("<ul>\n")
+				list = "ul"
+			}
+			b.WriteString("<li>" + renderInline(line[2:]) + "</li>\n")
+		case mdOListRe.MatchString(line):
+			if list != "ol" {
+				flush()
+				b.WriteString("<ol>\n")
+				list = "ol"
+			}
+			b.WriteString("<li>" + renderInline(line[len(mdOListRe.FindString(line)):]) + "</li>\n")
+		default:
+			if para == nil {
+				flush()
+			}
+			para = append(para, line)
+		}
+	}
+	flush()
+	return b.String()
+}
+
+// headingLevel returns the level of an ATX heading line, or 0.
+func headingLevel(line string) int {
+	n := 0
+	for n < len(line) && n < 7 && line[n] == '#' {
+		n++
+	}
+	if n == 0 || n > 6 || n == len(line) || line[n] != ' ' {
+		return 0
+	}
+	return n
+}
+
+// renderInline renders the inline Markdown in s, keeping code spans verbatim.
+func renderInline(s string) string {
+	var b strings.Builder
+	for {
+		start := strings.IndexByte(s, '`')
+		if start < 0 {
+			break
+		}
+		end := strings.IndexByte(s[start+1:], '`')
+		if end < 0 {
+			break
+		}
+		b.WriteString(renderSpans(s[:start]))
+		b.WriteString("<code>" + html.EscapeString(s[start+1:start+1+end]) + "</code>")
+		s = s[start+end+2:]
+	}
+	b.WriteString(renderSpans(s))
+	return b.String()
+}
+
+// renderSpans escapes s and renders images, links and emphasis.
+func renderSpans(s string) string {
+	s = html.EscapeString(s)
+	s = mdImageRe.ReplaceAllStringFunc(s, func(m string) string {
+		sub := mdImageRe.FindStringSubmatch(m)
+		return `<img src="` + safeURL(sub[2]) + `" alt="` + sub[1] + `">`
+	})
+	s = mdLinkRe.ReplaceAllStringFunc(s, func(m string) string {
+		sub := mdLinkRe.FindStringSubmatch(m)
+		return `<a href="` + safeURL(sub[2]) + `">` + sub[1] + `</a>`
+	})
+	s = mdStrongRe.ReplaceAllString(s, "<strong>$1</strong>")
+	return mdEmRe.ReplaceAllString(s, "<em>$1</em>")
+}
+
+// safeURL returns the already escaped href, or "#" when it uses a scheme that
+// could run script.
+func safeURL(href string) string {
+	i := strings.IndexAny(href, ":/?#")
+	if i < 0 || href[i] != ':' {
+		return href
+	}
+	switch strings.ToLower(href[:i]) {
+	case "http", "https", "mailto":
+		return href
+	}
+	return "#"
+}
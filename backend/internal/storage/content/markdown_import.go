@@ -0,0 +1,446 @@
+// Imports a directory of plain markdown files, like an Obsidian vault or a Hugo
+// content directory.
+
+package content
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"maps"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/maruel/ksid"
+	"github.com/maruel/mddb/backend/internal/storage"
+	"github.com/maruel/mddb/backend/internal/storage/git"
+	"github.com/maruel/mddb/backend/internal/storage/identity"
+)
+
+// wikiLinkRe matches wiki-style links like [[Page]], [[Page#Heading|label]]
+// and embeds like ![[image.png]]. Groups: 1 "!" for embeds, 2 target, 3 label.
+var wikiLinkRe = regexp.MustCompile(`(!?)\[\[([^\[\]|#]*)(?:#[^\[\]|]*)?(?:\|([^\[\]]*))?\]\]`)
+
+// ImportMarkdownTree creates a page for every markdown file under srcDir, at
+// the root of the workspace, and commits them to git.
+//
+// Folders containing markdown files become pages too, with the content of
+// their index.md or _index.md file if any, so the hierarchy is preserved. The
+// title, tags and creation time are read from the YAML front matter; the title
+// defaults to the file or folder name. Files referenced by images and links,
+// relative to the page or by name like Obsidian does, are copied as assets of
+// the page. Wiki-style [[links]] and relative .md links to imported files are
+// rewritten to internal links. Hidden files and folders are ignored.
+//
+// Page, tree and storage quotas are checked before anything is written.
+func (ws *WorkspaceFileStore) ImportMarkdownTree(ctx context.Context, srcDir string, author git.Author) (*ImportStats, error) {
+	plan, err := planMarkdownImport(srcDir)
+	if err != nil {
+		return nil, err
+	}
+	if len(plan.nodes) == 0 {
+		return &plan.stats, nil
+	}
+	err = ws.repo.CommitTx(ctx, author, func() (string, []string, error) {
+		// Checked under the transaction lock so that concurrent writes can't
+		// both fit the quota.
+		if err := ws.checkMarkdownImportQuotas(plan); err != nil {
+			return "", nil, err
+		}
+		var roots []string
+		var ids []ksid.ID
+		for _, n := range plan.nodes {
+			if n.parent == nil {
+				roots = append(roots, n.id.String())
+			}
+			ids = append(ids, n.id)
+		}
+		if err := ws.writeMarkdownImport(ctx, plan); err != nil {
+			for _, root := range roots {
+				_ = os.RemoveAll(filepath.Join(ws.wsDir, root))
+			}
+			_, _ = ws.deleteAssetRefs(ids...)
+			return "", nil, err
+		}
+		files := roots
+		if plan.stats.Assets != 0 {
+			files = append(files, assetsFile, assetBlobsDir)
+		}
+		msg := "import: " + strconv.Itoa(len(plan.nodes)) + " nodes from markdown"
+		return git.AppendTrailers(msg,
+			git.Trailer{Key: git.TrailerOp, Value: "import"},
+			git.Trailer{Key: git.TrailerType, Value: "node"},
+			git.Trailer{Key: git.TrailerActor, Value: author.ID},
+		), files, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := ws.refreshCache(); err != nil {
+		return nil, err
+	}
+	var pages []*Node
+	for _, n := range plan.nodes {
+		if node, err := ws.ReadPage(n.id); err == nil {
+			pages = append(pages, node)
+		}
+	}
+	if len(pages) != 0 {
+		ws.pageWritten(pages...)
+	}
+	return &plan.stats, nil
+}
+
+// mdImportNode is a page to create from a markdown file or a folder.
+type mdImportNode struct {
+	id       ksid.ID
+	parent   *mdImportNode // nil at the root
+	file     string        // Source markdown file; empty for a folder without an index
+	title    string
+	depth    int // 1 for root nodes
+	children int
+	page     *page
+	assets   map[string]string // Asset name to source file
+	dir      string            // Destination directory, set when writing
+}
+
+// mdImportPlan is the content of a markdown directory to import.
+type mdImportPlan struct {
+	nodes  []*mdImportNode          // parents before children, siblings by name
+	byPath map[string]*mdImportNode // by source markdown file
+	byName map[string]*mdImportNode // by lower-cased name without .md, first found
+	files  map[string]bool          // other files, set once used as an asset
+	byBase map[string]string        // other files by name, first found
+	size   int64                    // bytes to write
+	stats  ImportStats
+}
+
+// planMarkdownImport lists the pages to create from srcDir and rewrites their
+// links.
+func planMarkdownImport(srcDir string) (*mdImportPlan, error) {
+	plan := &mdImportPlan{
+		byPath: map[string]*mdImportNode{},
+		byName: map[string]*mdImportNode{},
+		files:  map[string]bool{},
+		byBase: map[string]string{},
+	}
+	if err := plan.walk(filepath.Clean(srcDir), nil); err != nil {
+		return nil, err
+	}
+	now := storage.Now()
+	for _, n := range plan.nodes {
+		if n.file == "" {
+			n.page = &page{title: n.title, created: now, modified: now}
+		} else {
+			data, err := os.ReadFile(n.file) //nolint:gosec // G304: n.file was found walking srcDir
+			if err != nil {
+				return nil, err
+			}
+			n.page = ParseMarkdown(data)
+			if n.page.title == "" {
+				n.page.title = n.title
+			}
+			n.page.content = plan.rewriteLinks(n, n.page.content)
+		}
+		plan.size += int64(len(formatMarkdownFile(n.page)))
+		for _, src := range n.assets {
+			info, err := os.Stat(src)
+			if err != nil {
+				return nil, err
+			}
+			plan.size += info.Size()
+		}
+		plan.stats.Assets += len(n.assets)
+	}
+	plan.stats.Nodes = len(plan.nodes)
+	plan.stats.Pages = len(plan.nodes)
+	for _, used := range plan.files {
+		if !used {
+			plan.stats.Skipped++
+		}
+	}
+	return plan, nil
+}
+
+// walk adds the markdown files and the folders containing some under dir as
+// children of parent.
+func (plan *mdImportPlan) walk(dir string, parent *mdImportNode) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	depth := 1
+	if parent != nil {
+		depth = parent.depth + 1
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		p := filepath.Join(dir, name)
+		if strings.HasPrefix(name, ".") || (parent != nil && p == parent.file) {
+			continue
+		}
+		switch {
+		case entry.IsDir():
+			if ok, err := containsMarkdown(p); err != nil || !ok {
+				if err != nil {
+					return err
+				}
+				if err := plan.walkFiles(p); err != nil {
+					return err
+				}
+				continue
+			}
+			n := &mdImportNode{parent: parent, title: name, depth: depth}
+			for _, index := range []string{"index.md", "_index.md"} {
+				if info, err := os.Lstat(filepath.Join(p, index)); err == nil && info.Mode().IsRegular() {
+					n.file = filepath.Join(p, index)
+					break
+				}
+			}
+			plan.add(n, name)
+			if err := plan.walk(p, n); err != nil {
+				return err
+			}
+		case !entry.Type().IsRegular():
+		case isMarkdownFile(name):
+			title := strings.TrimSuffix(name, filepath.Ext(name))
+			plan.add(&mdImportNode{parent: parent, file: p, title: title, depth: depth}, title)
+		default:
+			plan.addFile(p)
+		}
+	}
+	return nil
+}
+
+// walkFiles adds the files under dir, which contains no markdown file, as
+// candidate assets.
+func (plan *mdImportPlan) walkFiles(dir string) error {
+	return filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if strings.HasPrefix(d.Name(), ".") {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.Type().IsRegular() {
+			plan.addFile(p)
+		}
+		return nil
+	})
+}
+
+// add appends n to the plan with a fresh ID. IDs are minted in walk order so
+// that siblings keep their name order.
+func (plan *mdImportPlan) add(n *mdImportNode, name string) {
+	n.id = ksid.NewID()
+	plan.nodes = append(plan.nodes, n)
+	if n.file != "" {
+		plan.byPath[n.file] = n
+	}
+	if key := strings.ToLower(name); plan.byName[key] == nil {
+		plan.byName[key] = n
+	}
+	if n.parent != nil {
+		n.parent.children++
+	}
+}
+
+// addFile records the non-markdown file p as a candidate asset.
+func (plan *mdImportPlan) addFile(p string) {
+	plan.files[p] = false
+	if name := filepath.Base(p); plan.byBase[name] == "" {
+		plan.byBase[name] = p
+	}
+}
+
+// rewriteLinks returns content with wiki-style and relative links to imported
+// pages rewritten to internal links, and references to files rewritten to
+// assets of n.
+func (plan *mdImportPlan) rewriteLinks(n *mdImportNode, content string) string {
+	dir := filepath.Dir(n.file)
+	content = wikiLinkRe.ReplaceAllStringFunc(content, func(m string) string {
+		sub := wikiLinkRe.FindStringSubmatch(m)
+		target := strings.TrimSpace(sub[2])
+		if target == "" {
+			return m
+		}
+		label := strings.TrimSpace(sub[3])
+		if label == "" {
+			label = target
+		}
+		if sub[1] != "" {
+			if name, ok := plan.asset(n, dir, target); ok {
+				return "![" + label + "](" + url.PathEscape(name) + ")"
+			}
+		}
+		if to, ok := plan.page(dir, target); ok {
+			return "[" + label + "](../" + to.id.String() + "/index.md)"
+		}
+		return m
+	})
+	return assetLinkRe.ReplaceAllStringFunc(content, func(m string) string {
+		sub := assetLinkRe.FindStringSubmatchIndex(m)
+		href := m[sub[2]:sub[3]]
+		if strings.HasPrefix(href, "/") || strings.HasPrefix(href, "#") || strings.Contains(href, ":") {
+			return m
+		}
+		target, _, _ := strings.Cut(href, "#")
+		target, _, _ = strings.Cut(target, "?")
+		target, err := url.PathUnescape(target)
+		if err != nil || target == "" {
+			return m
+		}
+		if isMarkdownFile(target) {
+			if to, ok := plan.page(dir, target); ok {
+				return m[:sub[2]] + "../" + to.id.String() + "/index.md" + m[sub[3]:]
+			}
+		} else if name, ok := plan.asset(n, dir, target); ok {
+			return m[:sub[2]] + url.PathEscape(name) + m[sub[3]:]
+		}
+		return m
+	})
+}
+
+// page returns the imported page that target, relative to dir, refers to,
+// falling back to the file or folder name.
+func (plan *mdImportPlan) page(dir, target string) (*mdImportNode, bool) {
+	p := filepath.Join(dir, filepath.FromSlash(target))
+	if !isMarkdownFile(p) {
+		p += ".md"
+	}
+	if n := plan.byPath[p]; n != nil {
+		return n, true
+	}
+	name := filepath.Base(filepath.FromSlash(target))
+	if isMarkdownFile(name) {
+		name = strings.TrimSuffix(name, filepath.Ext(name))
+	}
+	n := plan.byName[strings.ToLower(name)]
+	return n, n != nil
+}
+
+// asset returns the name of the asset of n for the file that target, relative
+// to dir, refers to, falling back to the file name. The file is added to the
+// assets of n, renamed if another file of the same name already is.
+func (plan *mdImportPlan) asset(n *mdImportNode, dir, target string) (string, bool) {
+	src := filepath.Join(dir, filepath.FromSlash(target))
+	if _, ok := plan.files[src]; !ok {
+		if src = plan.byBase[filepath.Base(filepath.FromSlash(target))]; src == "" {
+			return "", false
+		}
+	}
+	plan.files[src] = true
+	for name, s := range n.assets {
+		if s == src {
+			return name, true
+		}
+	}
+	if n.assets == nil {
+		n.assets = map[string]string{}
+	}
+	name := filepath.Base(src)
+	ext := filepath.Ext(name)
+	for i := 2; n.assets[name] != ""; i++ {
+		name = strings.TrimSuffix(filepath.Base(src), ext) + "-" + strconv.Itoa(i) + ext
+	}
+	n.assets[name] = src
+	return name, true
+}
+
+// checkMarkdownImportQuotas returns an error if importing plan at the root
+// would exceed a quota.
+func (ws *WorkspaceFileStore) checkMarkdownImportQuotas(plan *mdImportPlan) error {
+	if err := ws.checkBulkQuotas(plan.stats.Pages, 0, plan.size); err != nil {
+		return err
+	}
+	rootChildren, err := countChildNodes(ws.wsDir)
+	if err != nil {
+		return err
+	}
+	for _, n := range plan.nodes {
+		if n.depth > ws.quotas.MaxNodeDepth {
+			return ErrNodeDepthExceeded
+		}
+		if n.children > ws.quotas.MaxChildrenPerNode {
+			return ErrTooManyChildren
+		}
+		if n.parent == nil {
+			rootChildren++
+		}
+	}
+	if rootChildren > ws.quotas.MaxChildrenPerNode {
+		return ErrTooManyChildren
+	}
+	return nil
+}
+
+// writeMarkdownImport writes the pages and assets of plan without committing.
+func (ws *WorkspaceFileStore) writeMarkdownImport(ctx context.Context, plan *mdImportPlan) error {
+	wikilinks := ws.linkStyle() == identity.LinkStyleWikilink
+	for _, n := range plan.nodes {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if wikilinks {
+			n.page.content = toWikiLinks(n.page.content)
+		}
+		if n.parent == nil {
+			n.dir = filepath.Join(ws.wsDir, n.id.String())
+		} else {
+			n.dir = filepath.Join(n.parent.dir, n.id.String())
+		}
+		if err := os.MkdirAll(n.dir, 0o755); err != nil { //nolint:gosec // G301: 0o755 is intentional for user data directories
+			return fmt.Errorf("failed to create directory: %w", err)
+		}
+		if err := os.WriteFile(filepath.Join(n.dir, "index.md"), formatMarkdownFile(n.page), 0o644); err != nil { //nolint:gosec // G306: 0o644 is intentional for user data files
+			return fmt.Errorf("failed to write page: %w", err)
+		}
+		for _, name := range slices.Sorted(maps.Keys(n.assets)) {
+			data, err := os.ReadFile(n.assets[name]) //nolint:gosec // G304: the asset was found walking srcDir
+			if err != nil {
+				return err
+			}
+			if _, err := ws.putAssetRef(n.id, name, data); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// containsMarkdown reports whether a markdown file is under dir, ignoring
+// hidden files and folders.
+func containsMarkdown(dir string) (bool, error) {
+	found := false
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if p != dir && strings.HasPrefix(d.Name(), ".") {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.Type().IsRegular() && isMarkdownFile(d.Name()) {
+			found = true
+			return filepath.SkipAll
+		}
+		return nil
+	})
+	return found, err
+}
+
+// isMarkdownFile reports whether name has a markdown extension.
+func isMarkdownFile(name string) bool {
+	return strings.EqualFold(filepath.Ext(name), ".md")
+}
@@ -0,0 +1,120 @@
+package content
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/maruel/ksid"
+	"github.com/maruel/mddb/backend/internal/storage/git"
+)
+
+func TestImportMarkdownTree(t *testing.T) {
+	author := git.Author{Name: "Test", Email: "test@test.com"}
+	src := t.TempDir()
+	for name, data := range map[string]string{
+		"Home.md":              "---\ntitle: Welcome\ntags: [a, b]\ncreated: 2020-01-02T03:04:05Z\n---\n\nSee [[Note]], [the guide](guides/Setup.md#step-1) and [web](https://example.com/x.md).\n\n![logo](img/logo.png)\n",
+		"img/logo.png":         "logo",
+		"guides/_index.md":     "---\ntitle: Guides\n---\nAll guides.\n",
+		"guides/Setup.md":      "Back to [[Home|home page]]. ![[diagram.png]] [[Missing]]\n",
+		"guides/diagram.png":   "diagram",
+		"guides/deep/Note.md":  "Leaf\n",
+		"empty/unused.txt":     "unused",
+		".obsidian/config.md":  "hidden",
+		"guides/.draft/Old.md": "hidden",
+	} {
+		p := filepath.Join(src, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0o750); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(data), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	t.Run("Import", func(t *testing.T) {
+		_, ws, _ := initWS(t)
+		stats, err := ws.ImportMarkdownTree(t.Context(), src, author)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := (ImportStats{Nodes: 5, Pages: 5, Assets: 2, Skipped: 1}); *stats != want {
+			t.Errorf("stats = %+v, want %+v", *stats, want)
+		}
+
+		roots, err := ws.ListChildren(0)
+		if err != nil || len(roots) != 2 || roots[0].Title != "Welcome" || roots[1].Title != "Guides" {
+			t.Fatalf("roots = %+v, %v", roots, err)
+		}
+		home, guides := roots[0], roots[1]
+		children, err := ws.ListChildren(guides.ID)
+		if err != nil || len(children) != 2 || children[0].Title != "Setup" || children[1].Title != "deep" {
+			t.Fatalf("children of Guides = %+v, %v", children, err)
+		}
+		setup, deep := children[0], children[1]
+		leaves, err := ws.ListChildren(deep.ID)
+		if err != nil || len(leaves) != 1 || leaves[0].Title != "Note" {
+			t.Fatalf("children of deep = %+v, %v", leaves, err)
+		}
+		note := leaves[0]
+
+		got, err := ws.ReadPage(home.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := "See [Note](../" + note.ID.String() + "/index.md), [the guide](../" + setup.ID.String() + "/index.md) and [web](https://example.com/x.md).\n\n![logo](logo.png)\n"
+		if got.Content != want {
+			t.Errorf("Home content = %q, want %q", got.Content, want)
+		}
+		if !slices.Equal(got.Tags, []string{"a", "b"}) || got.Created.AsTime().Year() != 2020 {
+			t.Errorf("Home tags = %v, created = %v", got.Tags, got.Created)
+		}
+		if data, err := ws.ReadAsset(home.ID, "logo.png"); err != nil || string(data) != "logo" {
+			t.Errorf("ReadAsset(logo.png) = %q, %v", data, err)
+		}
+
+		if got, err := ws.ReadPage(guides.ID); err != nil || got.Content != "All guides.\n" {
+			t.Errorf("Guides = %+v, %v", got, err)
+		}
+		got, err = ws.ReadPage(setup.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want = "Back to [home page](../" + home.ID.String() + "/index.md). ![diagram.png](diagram.png) [[Missing]]\n"
+		if got.Content != want {
+			t.Errorf("Setup content = %q, want %q", got.Content, want)
+		}
+		if data, err := ws.ReadAsset(setup.ID, "diagram.png"); err != nil || string(data) != "diagram" {
+			t.Errorf("ReadAsset(diagram.png) = %q, %v", data, err)
+		}
+
+		backlinks, err := ws.GetBacklinks(note.ID)
+		if err != nil || len(backlinks) != 1 || backlinks[0].NodeID != home.ID {
+			t.Errorf("GetBacklinks(Note) = %+v, %v", backlinks, err)
+		}
+	})
+
+	t.Run("Quota", func(t *testing.T) {
+		_, ws, _ := initWS(t)
+		ws.quotas.MaxPages = 4
+		if _, err := ws.ImportMarkdownTree(t.Context(), src, author); !errors.Is(err, ErrQuotaExceeded) {
+			t.Errorf("got %v, want ErrQuotaExceeded", err)
+		}
+		if roots, err := ws.ListChildren(0); err != nil || len(roots) != 0 {
+			t.Errorf("roots = %+v, %v", roots, err)
+		}
+	})
+
+	t.Run("NoMarkdown", func(t *testing.T) {
+		_, ws, _ := initWS(t)
+		stats, err := ws.ImportMarkdownTree(t.Context(), filepath.Join(src, "img"), author)
+		if err != nil || *stats != (ImportStats{Skipped: 1}) {
+			t.Errorf("stats = %+v, %v", stats, err)
+		}
+		if _, err := ws.ImportMarkdownTree(t.Context(), filepath.Join(src, ksid.NewID().String()), author); err == nil {
+			t.Error("expected error for missing directory")
+		}
+	})
+}
@@ -6,6 +6,7 @@ import (
 	"cmp"
 	"slices"
 	"strings"
+	"time"
 )
 
 // QueryRecords applies filters and sorts from a view to records.
@@ -157,26 +158,20 @@ func compareValues(a, b any) int {
 		return 1
 	}
 
+	// Numbers compare by value whatever their Go type: records decoded from
+	// JSON hold float64 while coerced values are int64.
+	if fa, ok := toNumber(a); ok {
+		if fb, ok := toNumber(b); ok {
+			return cmp.Compare(fa, fb)
+		}
+	}
+
 	// Try type-specific comparisons
 	switch va := a.(type) {
 	case string:
 		if vb, ok := b.(string); ok {
 			return cmp.Compare(va, vb)
 		}
-	case float64:
-		if vb, ok := b.(float64); ok {
-			return cmp.Compare(va, vb)
-		}
-		if vb, ok := b.(int); ok {
-			return cmp.Compare(va, float64(vb))
-		}
-	case int:
-		if vb, ok := b.(int); ok {
-			return cmp.Compare(va, vb)
-		}
-		if vb, ok := b.(float64); ok {
-			return cmp.Compare(float64(va), vb)
-		}
 	case bool:
 		if vb, ok := b.(bool); ok {
 			if va == vb {
@@ -193,6 +188,41 @@ func compareValues(a, b any) int {
 	return cmp.Compare(toString(a), toString(b))
 }
 
+// sortKey converts a property value so that compareValues orders it by the
+// property type: numbers (including numeric text) as float64 and ISO8601 dates
+// as their Unix time. Other values are returned unchanged.
+func sortKey(v any, pt PropertyType) any {
+	switch pt {
+	case PropertyTypeNumber:
+		return coerceToReal(v)
+	case PropertyTypeDate:
+		if s, ok := v.(string); ok {
+			for _, layout := range recordDateLayouts {
+				if t, err := time.Parse(layout, s); err == nil {
+					return float64(t.UnixNano())
+				}
+			}
+		}
+		return v
+	default:
+		return v
+	}
+}
+
+// toNumber returns the value of a numeric value as float64.
+func toNumber(v any) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case int64:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	default:
+		return 0, false
+	}
+}
+
 // containsString checks if value contains the filter string (case-insensitive).
 func containsString(value, filterValue any) bool {
 	vs := strings.ToLower(toString(value))
@@ -0,0 +1,106 @@
+// Reconstructs the history of a table record from the git history of data.jsonl.
+
+package content
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/maruel/ksid"
+	"github.com/maruel/mddb/backend/internal/storage/git"
+)
+
+// recordHistoryPageSize is the number of commits fetched at a time while
+// walking the history of data.jsonl.
+const recordHistoryPageSize = 100
+
+// RecordVersion is a record as it was after a commit.
+type RecordVersion struct {
+	Commit *git.Commit `json:"commit"`
+	Record *DataRecord `json:"record"`
+}
+
+// GetRecordHistory returns up to n versions of a record, newest first.
+//
+// It walks the commits that touched the table's data.jsonl and looks the
+// record up by ID in each, since records move within the file. Commits where
+// the record is absent are skipped, and a version is attributed to the commit
+// that introduced it rather than to later commits that only changed other
+// records. Like GetHistory, the table is looked up at its current path.
+func (ws *WorkspaceFileStore) GetRecordHistory(ctx context.Context, tableID, recordID ksid.ID, n int) ([]RecordVersion, error) {
+	if tableID.IsZero() || !ws.TableExists(tableID) {
+		return nil, ErrTableNotFound
+	}
+	if n <= 0 {
+		n = recordHistoryPageSize
+	}
+	path := ws.gitPath(ws.getParent(tableID), tableID, "data.jsonl")
+	var versions []RecordVersion
+	var last []byte // Line of the oldest version so far; nil when absent at the previous commit.
+	before := ""
+	for {
+		commits, more, err := ws.repo.GetHistoryBefore(ctx, path, before, recordHistoryPageSize)
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range commits {
+			line, err := ws.recordLineAtCommit(ctx, c.Hash, path, recordID)
+			if err != nil {
+				return nil, err
+			}
+			switch {
+			case line == nil:
+				if len(versions) == n {
+					// The oldest version's commit is settled.
+					return versions, nil
+				}
+			case last != nil && bytes.Equal(line, last):
+				// Unchanged since this older commit.
+				versions[len(versions)-1].Commit = c
+			default:
+				if len(versions) == n {
+					return versions, nil
+				}
+				rec := &DataRecord{}
+				if err := json.Unmarshal(line, rec); err != nil {
+					return nil, fmt.Errorf("failed to parse record at commit %s: %w", c.Hash, err)
+				}
+				versions = append(versions, RecordVersion{Commit: c, Record: rec})
+			}
+			last = line
+		}
+		if !more || len(commits) == 0 {
+			return versions, nil
+		}
+		before = commits[len(commits)-1].Hash
+	}
+}
+
+// recordLineAtCommit returns the JSON line of a record in the data.jsonl file
+// at path as of commit hash, or nil if the record or the file is absent.
+func (ws *WorkspaceFileStore) recordLineAtCommit(ctx context.Context, hash, path string, recordID ksid.ID) ([]byte, error) {
+	data, err := ws.repo.GetFileAtCommit(ctx, hash, path)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		// The commit deleted the file.
+		return nil, nil
+	}
+	lines := bytes.Split(data, []byte("\n"))
+	// The first line is the schema header.
+	for _, line := range lines[1:] {
+		var row struct {
+			ID ksid.ID `json:"id"`
+		}
+		if len(line) == 0 || json.Unmarshal(line, &row) != nil {
+			continue
+		}
+		if row.ID == recordID {
+			return line, nil
+		}
+	}
+	return nil, nil
+}
@@ -0,0 +1,111 @@
+package content
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/maruel/ksid"
+	"github.com/maruel/mddb/backend/internal/storage"
+	"github.com/maruel/mddb/backend/internal/storage/git"
+)
+
+func TestGetRecordHistory(t *testing.T) {
+	author := git.Author{Name: "Test", Email: "test@test.com"}
+	_, ws, _ := initWS(t)
+	ctx := t.Context()
+
+	props := []Property{{Name: "name", Type: PropertyTypeText}}
+	table, err := ws.CreateTableUnderParent(ctx, 0, "Table", props, author)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec := &DataRecord{ID: ksid.NewID(), Data: map[string]any{"name": "v1"}, Created: storage.Now(), Modified: storage.Now()}
+	if err := ws.AppendRecord(ctx, table.ID, rec, author); err != nil {
+		t.Fatal(err)
+	}
+	// Records appended and updated afterward shift the line of rec and must
+	// not show up as versions of it.
+	other := &DataRecord{ID: ksid.NewID(), Data: map[string]any{"name": "other"}, Created: storage.Now(), Modified: storage.Now()}
+	if err := ws.AppendRecord(ctx, table.ID, other, author); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"v2", "v3"} {
+		rec.Data = map[string]any{"name": name}
+		rec.Modified = storage.Now()
+		if err := ws.UpdateRecord(ctx, table.ID, rec, author); err != nil {
+			t.Fatal(err)
+		}
+		other.Data = map[string]any{"name": "other " + name}
+		if err := ws.UpdateRecord(ctx, table.ID, other, author); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := ws.DeleteRecord(ctx, table.ID, rec.ID, author); err != nil {
+		t.Fatal(err)
+	}
+
+	commits, err := ws.repo.GetHistory(ctx, ws.gitPath(0, table.ID, "data.jsonl"), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Newest first: delete, other v3, v3, other v2, v2, append other, v1.
+	if len(commits) < 7 {
+		t.Fatalf("got %d commits", len(commits))
+	}
+
+	t.Run("All", func(t *testing.T) {
+		versions, err := ws.GetRecordHistory(ctx, table.ID, rec.ID, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := []struct {
+			name string
+			hash string
+		}{
+			{"v3", commits[2].Hash},
+			{"v2", commits[4].Hash},
+			{"v1", commits[6].Hash},
+		}
+		if len(versions) != len(want) {
+			t.Fatalf("got %d versions, want %d", len(versions), len(want))
+		}
+		for i, w := range want {
+			v := versions[i]
+			if v.Record.ID != rec.ID || v.Record.Data["name"] != w.name {
+				t.Errorf("version %d: got %v %v, want %s", i, v.Record.ID, v.Record.Data, w.name)
+			}
+			if v.Commit.Hash != w.hash {
+				t.Errorf("version %d: got commit %q %s, want %s", i, v.Commit.Message, v.Commit.Hash, w.hash)
+			}
+		}
+	})
+
+	t.Run("Limit", func(t *testing.T) {
+		versions, err := ws.GetRecordHistory(ctx, table.ID, rec.ID, 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(versions) != 2 || versions[0].Record.Data["name"] != "v3" || versions[1].Record.Data["name"] != "v2" {
+			t.Fatalf("got %+v", versions)
+		}
+		if versions[1].Commit.Hash != commits[4].Hash {
+			t.Errorf("got commit %s, want %s", versions[1].Commit.Hash, commits[4].Hash)
+		}
+	})
+
+	t.Run("NeverExisted", func(t *testing.T) {
+		versions, err := ws.GetRecordHistory(ctx, table.ID, ksid.NewID(), 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(versions) != 0 {
+			t.Fatalf("got %d versions", len(versions))
+		}
+	})
+
+	t.Run("TableNotFound", func(t *testing.T) {
+		if _, err := ws.GetRecordHistory(ctx, ksid.NewID(), rec.ID, 0); !errors.Is(err, ErrTableNotFound) {
+			t.Fatalf("got %v, want ErrTableNotFound", err)
+		}
+	})
+}
@@ -0,0 +1,97 @@
+// Validates record data against a table's property schema.
+
+package content
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"slices"
+	"time"
+)
+
+// recordDateLayouts are the accepted formats of a date property value: a
+// calendar date as sent by the date picker, or a full ISO8601 timestamp.
+var recordDateLayouts = []string{time.DateOnly, time.RFC3339Nano, "2006-01-02T15:04:05", "2006-01-02T15:04"}
+
+// validateRecordData checks that every value in data matches the type of its
+// property and that required properties are set.
+//
+// Values are expected to have gone through CoerceRecordData first, so a
+// checkbox is accepted either as a bool or as the integer 0 or 1. A nil value
+// or an empty string means "not set" and is accepted for any type unless the
+// property is required.
+//
+// Keys that are not in the schema are logged and kept rather than rejected:
+// deleting a property leaves its values in existing records and updating such
+// a record must keep working.
+func validateRecordData(data map[string]any, properties []Property) error {
+	var errs []error
+	for _, prop := range properties {
+		v, ok := data[prop.Name]
+		if !ok || v == nil || v == "" {
+			if prop.Required {
+				errs = append(errs, fmt.Errorf("property %q is required", prop.Name))
+			}
+			continue
+		}
+		if err := validatePropertyValue(prop.Type, v); err != nil {
+			errs = append(errs, fmt.Errorf("property %q: %w", prop.Name, err))
+		}
+	}
+	for k := range data {
+		if !slices.ContainsFunc(properties, func(p Property) bool { return p.Name == k }) {
+			slog.Warn("Record has a value for an unknown property", "property", k)
+		}
+	}
+	if len(errs) != 0 {
+		return fmt.Errorf("%w: %w", ErrInvalidRecord, errors.Join(errs...))
+	}
+	return nil
+}
+
+// validatePropertyValue checks a non-empty value against a property type.
+// Types with no scalar representation (multi_select, relation, rollup, …) are
+// not checked.
+func validatePropertyValue(pt PropertyType, v any) error {
+	switch pt {
+	case PropertyTypeText, PropertyTypeMarkdown, PropertyTypeSelect, PropertyTypeUser, PropertyTypeURL, PropertyTypeEmail, PropertyTypePhone:
+		if _, ok := v.(string); !ok {
+			return fmt.Errorf("want text, got %T", v)
+		}
+	case PropertyTypeNumber:
+		switch n := v.(type) {
+		case int64, int:
+		case float64:
+			if math.IsNaN(n) || math.IsInf(n, 0) {
+				return fmt.Errorf("want a finite number, got %v", n)
+			}
+		default:
+			return fmt.Errorf("want a number, got %T", v)
+		}
+	case PropertyTypeCheckbox:
+		switch b := v.(type) {
+		case bool:
+		case int64:
+			if b != 0 && b != 1 {
+				return fmt.Errorf("want a checkbox, got %d", b)
+			}
+		default:
+			return fmt.Errorf("want a checkbox, got %T", v)
+		}
+	case PropertyTypeDate:
+		s, ok := v.(string)
+		if !ok {
+			return fmt.Errorf("want a date, got %T", v)
+		}
+		if !slices.ContainsFunc(recordDateLayouts, func(layout string) bool {
+			_, err := time.Parse(layout, s)
+			return err == nil
+		}) {
+			return fmt.Errorf("want an ISO8601 date, got %q", s)
+		}
+	default:
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package content
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"testing"
+)
+
+func Test_validateRecordData(t *testing.T) {
+	props := []Property{
+		{Name: "Name", Type: PropertyTypeText, Required: true},
+		{Name: "Count", Type: PropertyTypeNumber},
+		{Name: "Done", Type: PropertyTypeCheckbox},
+		{Name: "Due", Type: PropertyTypeDate},
+		{Name: "Tags", Type: PropertyTypeMultiSelect},
+	}
+	tests := []struct {
+		name    string
+		data    map[string]any
+		wantErr bool
+	}{
+		{"valid", map[string]any{"Name": "a", "Count": float64(1.5), "Done": true, "Due": "2024-03-01", "Tags": []any{"x"}}, false},
+		{"coerced", map[string]any{"Name": "a", "Count": int64(2), "Done": int64(1), "Due": "2024-03-01T10:00:00Z"}, false},
+		{"nulls", map[string]any{"Name": "a", "Count": nil, "Done": nil, "Due": nil}, false},
+		{"empty date", map[string]any{"Name": "a", "Due": ""}, false},
+		{"unknown key kept", map[string]any{"Name": "a", "Removed": 42}, false},
+		{"missing required", map[string]any{"Count": int64(1)}, true},
+		{"null required", map[string]any{"Name": nil}, true},
+		{"empty required", map[string]any{"Name": ""}, true},
+		{"text not string", map[string]any{"Name": int64(3)}, true},
+		{"number as text", map[string]any{"Name": "a", "Count": "many"}, true},
+		{"number NaN", map[string]any{"Name": "a", "Count": math.NaN()}, true},
+		{"checkbox as text", map[string]any{"Name": "a", "Done": "yes"}, true},
+		{"checkbox out of range", map[string]any{"Name": "a", "Done": int64(2)}, true},
+		{"date malformed", map[string]any{"Name": "a", "Due": "next tuesday"}, true},
+		{"date not string", map[string]any{"Name": "a", "Due": float64(20240301)}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateRecordData(tt.data, props)
+			if tt.wantErr != (err != nil) {
+				t.Fatalf("got %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrInvalidRecord) {
+				t.Errorf("%v does not wrap ErrInvalidRecord", err)
+			}
+		})
+	}
+
+	t.Run("AllErrors", func(t *testing.T) {
+		err := validateRecordData(map[string]any{"Count": "x", "Done": "y"}, props)
+		for _, want := range []string{`"Name" is required`, `"Count"`, `"Done"`} {
+			if err == nil || !strings.Contains(err.Error(), want) {
+				t.Errorf("%v does not mention %s", err, want)
+			}
+		}
+	})
+}
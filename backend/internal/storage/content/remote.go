@@ -0,0 +1,46 @@
+// Pushes a workspace to the git remote stored in its configuration.
+
+package content
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/maruel/mddb/backend/internal/storage/git"
+)
+
+// remoteName is the name of the git remote mirroring identity.GitRemote.
+const remoteName = "origin"
+
+// PushToRemote pushes the workspace to the git remote in its configuration.
+//
+// The token is injected in https URLs for token authentication; ssh URLs
+// authenticate through the ssh agent. GitHub App remotes need an installation
+// token and are pushed by the sync service instead. Returns ErrNoRemote when
+// no remote is configured, and git.ErrAuthFailed or git.ErrNonFastForward
+// wrapped when the remote refused the push.
+func (ws *WorkspaceFileStore) PushToRemote(ctx context.Context) error {
+	w, err := ws.wsSvc.Get(ws.wsID)
+	if err != nil {
+		return fmt.Errorf("failed to get workspace: %w", err)
+	}
+	remote := &w.GitRemote
+	if remote.IsZero() {
+		return ErrNoRemote
+	}
+	if remote.AuthType == "github_app" {
+		return errors.New("GitHub App remotes are pushed by the sync service")
+	}
+	url := remote.URL
+	if remote.AuthType == "token" {
+		url = git.InjectTokenInURL(url, remote.Token, remote.Type)
+	}
+	if err := ws.repo.SetRemote(ctx, remoteName, url); err != nil {
+		return fmt.Errorf("failed to set git remote: %w", err)
+	}
+	if err := ws.repo.Push(ctx, remoteName, remote.Branch); err != nil {
+		return fmt.Errorf("failed to push to git remote: %w", err)
+	}
+	return nil
+}
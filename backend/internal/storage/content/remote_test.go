@@ -0,0 +1,53 @@
+package content
+
+import (
+	"errors"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/maruel/ksid"
+	"github.com/maruel/mddb/backend/internal/storage/git"
+	"github.com/maruel/mddb/backend/internal/storage/identity"
+)
+
+func TestPushToRemote(t *testing.T) {
+	fs, ws, wsID := initWS(t)
+	ctx := t.Context()
+	author := git.Author{Name: "Test", Email: "test@test.com"}
+	if _, err := ws.WritePage(ctx, ksid.NewID(), 0, "Page", "body", author); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ws.PushToRemote(ctx); !errors.Is(err, ErrNoRemote) {
+		t.Fatalf("PushToRemote() without remote = %v, want ErrNoRemote", err)
+	}
+
+	remoteDir := t.TempDir()
+	// #nosec G204
+	if out, err := exec.Command("git", "-C", remoteDir, "init", "--bare").CombinedOutput(); err != nil {
+		t.Fatalf("failed to init bare repo: %v\n%s", err, out)
+	}
+	if _, err := fs.wsSvc.Modify(wsID, func(w *identity.Workspace) error {
+		w.GitRemote = identity.GitRemote{URL: remoteDir, Type: "custom", Branch: "backup"}
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := ws.PushToRemote(ctx); err != nil {
+		t.Fatalf("PushToRemote() failed: %v", err)
+	}
+	// The remote branch is the workspace's HEAD.
+	revParse := func(dir, rev string) string {
+		t.Helper()
+		// #nosec G204
+		out, err := exec.Command("git", "-C", dir, "rev-parse", rev).CombinedOutput()
+		if err != nil {
+			t.Fatalf("rev-parse %s in %s: %v\n%s", rev, dir, err, out)
+		}
+		return strings.TrimSpace(string(out))
+	}
+	if got, want := revParse(remoteDir, "backup"), revParse(ws.wsDir, "HEAD"); got != want {
+		t.Errorf("remote backup = %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,65 @@
+// Renders pages to sanitized HTML for previews.
+
+package content
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/maruel/ksid"
+)
+
+// RenderHTMLOptions configures the URLs of the links rewritten by
+// RenderHTMLWithOptions.
+type RenderHTMLOptions struct {
+	// NodeURL returns the URL of the node linked to by a ../ID/index.md link.
+	// Defaults to the node's HTML preview API endpoint.
+	NodeURL func(id ksid.ID) string
+	// AssetURL returns the URL of the asset name of node id. Defaults to the
+	// node asset API endpoint.
+	AssetURL func(id ksid.ID, name string) string
+}
+
+// RenderHTML renders the markdown body of page id to sanitized HTML, rewriting
+// links to other pages and to the page's assets to their API endpoints.
+func (ws *WorkspaceFileStore) RenderHTML(id ksid.ID) (string, error) {
+	return ws.RenderHTMLWithOptions(id, RenderHTMLOptions{})
+}
+
+// RenderHTMLWithOptions is RenderHTML with configurable link URLs.
+//
+// The HTML is produced by RenderMarkdown, which escapes all raw HTML and
+// neutralizes links with a scheme that could run script.
+func (ws *WorkspaceFileStore) RenderHTMLWithOptions(id ksid.ID, opts RenderHTMLOptions) (string, error) {
+	node, err := ws.ReadPage(id)
+	if err != nil {
+		return "", err
+	}
+	if opts.NodeURL == nil {
+		opts.NodeURL = func(target ksid.ID) string {
+			return fmt.Sprintf("/api/v1/workspaces/%s/nodes/%s/page/html", ws.wsID, target)
+		}
+	}
+	if opts.AssetURL == nil {
+		opts.AssetURL = func(nodeID ksid.ID, name string) string {
+			return fmt.Sprintf("/api/v1/workspaces/%s/nodes/%s/assets/%s", ws.wsID, nodeID, url.PathEscape(name))
+		}
+	}
+	src := relativeLinkRe.ReplaceAllStringFunc(toRelativeLinks(node.Content, siblingHref), func(m string) string {
+		sub := relativeLinkRe.FindStringSubmatch(m)
+		target, ok := linkTargetID(sub[2])
+		if !ok {
+			return m
+		}
+		return "[" + sub[1] + "](" + opts.NodeURL(target) + ")"
+	})
+	src = assetLinkRe.ReplaceAllStringFunc(src, func(m string) string {
+		sub := assetLinkRe.FindStringSubmatchIndex(m)
+		name, ok := localAssetName(m[sub[2]:sub[3]])
+		if !ok {
+			return m
+		}
+		return m[:sub[2]] + opts.AssetURL(id, name) + m[sub[3]:]
+	})
+	return RenderMarkdown(src), nil
+}
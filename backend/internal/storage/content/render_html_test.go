@@ -0,0 +1,77 @@
+package content
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/maruel/ksid"
+	"github.com/maruel/mddb/backend/internal/storage/git"
+)
+
+func TestRenderHTML(t *testing.T) {
+	author := git.Author{Name: "Test", Email: "test@test.com"}
+	_, ws, wsID := initWS(t)
+	ctx := t.Context()
+
+	target, err := ws.CreatePageUnderParent(ctx, 0, "Target", "target", author)
+	if err != nil {
+		t.Fatal(err)
+	}
+	content := "# Heading\n\n" +
+		"See [target](../" + target.ID.String() + "/index.md).\n\n" +
+		"![diagram](./my%20diagram.png)\n\n" +
+		"[notes](notes)\n\n" +
+		"<script>alert(1)</script>\n\n" +
+		`<img src=x onerror="alert(1)">` + "\n\n" +
+		"[click](javascript:alert(1))\n"
+	page, err := ws.CreatePageUnderParent(ctx, 0, "Page", content, author)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("Default", func(t *testing.T) {
+		got, err := ws.RenderHTML(page.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, want := range []string{
+			"<h1>Heading</h1>",
+			`<a href="/api/v1/workspaces/` + wsID.String() + `/nodes/` + target.ID.String() + `/page/html">target</a>`,
+			`<img src="/api/v1/workspaces/` + wsID.String() + `/nodes/` + page.ID.String() + `/assets/my%20diagram.png" alt="diagram">`,
+			`<a href="notes">notes</a>`,
+		} {
+			if !strings.Contains(got, want) {
+				t.Errorf("missing %q in:\n%s", want, got)
+			}
+		}
+		for _, unsafe := range []string{"<script", "<img src=x", "javascript:", "index.md"} {
+			if strings.Contains(got, unsafe) {
+				t.Errorf("unexpected %q in:\n%s", unsafe, got)
+			}
+		}
+	})
+
+	t.Run("Options", func(t *testing.T) {
+		got, err := ws.RenderHTMLWithOptions(page.ID, RenderHTMLOptions{
+			NodeURL:  func(id ksid.ID) string { return "/p/" + id.String() },
+			AssetURL: func(id ksid.ID, name string) string { return "/a/" + id.String() + "?n=" + name[:2] + "&sig=x" },
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, want := range []string{
+			`<a href="/p/` + target.ID.String() + `">target</a>`,
+			`<img src="/a/` + page.ID.String() + `?n=my&amp;sig=x" alt="diagram">`,
+		} {
+			if !strings.Contains(got, want) {
+				t.Errorf("missing %q in:\n%s", want, got)
+			}
+		}
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		if _, err := ws.RenderHTML(ksid.NewID()); err == nil {
+			t.Error("expected error for missing page")
+		}
+	})
+}
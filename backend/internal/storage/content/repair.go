@@ -0,0 +1,87 @@
+// Detects and repairs node directories left behind by interrupted operations.
+
+package content
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+
+	"github.com/maruel/ksid"
+	"github.com/maruel/mddb/backend/internal/storage/git"
+)
+
+// RepairWorkspace finds the orphaned node directories of the workspace: the
+// directories named after an ID that contain neither index.md nor
+// metadata.json, typically left over by an interrupted delete. Each is logged;
+// the empty ones are removed, after their own orphaned subdirectories. The
+// others are kept since they may hold the files of nested pages.
+//
+// The parent cache is rebuilt afterwards so removed directories no longer
+// appear as nodes. Returns the IDs of the orphaned directories found, removed
+// or not. Nothing is committed since git does not track empty directories.
+func (ws *WorkspaceFileStore) RepairWorkspace(ctx context.Context) ([]ksid.ID, error) {
+	var orphans []ksid.ID
+	// Hold the repository lock so that directories of pages being created
+	// are not mistaken for orphans. fn returns no file so nothing is committed.
+	err := ws.repo.CommitTx(ctx, git.Author{}, func() (string, []string, error) {
+		var err error
+		orphans, err = ws.repairDir(ctx, ws.wsDir, orphans)
+		return "", nil, err
+	})
+	if err2 := ws.refreshCache(); err == nil && err2 != nil {
+		err = fmt.Errorf("refresh cache: %w", err2)
+	}
+	return orphans, err
+}
+
+// repairDir handles the node directories below dir, depth first, appending
+// the orphaned ones to orphans.
+func (ws *WorkspaceFileStore) repairDir(ctx context.Context, dir string, orphans []ksid.ID) ([]ksid.ID, error) {
+	ids, err := nodeDirs(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return orphans, nil
+		}
+		return orphans, err
+	}
+	for _, id := range ids {
+		if err := ctx.Err(); err != nil {
+			return orphans, err
+		}
+		path := filepath.Join(dir, id.String())
+		if orphans, err = ws.repairDir(ctx, path, orphans); err != nil {
+			return orphans, err
+		}
+		if hasNodeFile(path) {
+			continue
+		}
+		orphans = append(orphans, id)
+		entries, err := os.ReadDir(path)
+		if err != nil {
+			return orphans, err
+		}
+		if len(entries) != 0 {
+			slog.Warn("Kept orphaned node directory that is not empty", "wsID", ws.wsID, "id", id, "entries", len(entries))
+			continue
+		}
+		if err := os.Remove(path); err != nil {
+			return orphans, err
+		}
+		slog.Warn("Removed empty orphaned node directory", "wsID", ws.wsID, "id", id)
+	}
+	return orphans, nil
+}
+
+// hasNodeFile reports whether the node directory dir contains index.md or
+// metadata.json.
+func hasNodeFile(dir string) bool {
+	for _, name := range []string{"index.md", "metadata.json"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+			return true
+		}
+	}
+	return false
+}
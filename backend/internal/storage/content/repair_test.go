@@ -0,0 +1,97 @@
+package content
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/maruel/ksid"
+	"github.com/maruel/mddb/backend/internal/storage/git"
+)
+
+func TestRepairWorkspace(t *testing.T) {
+	author := git.Author{Name: "Test", Email: "test@test.com"}
+	_, ws, _ := initWS(t)
+	ctx := t.Context()
+
+	page, err := ws.CreatePageUnderParent(ctx, 0, "Page", "content", author)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pageDir := ws.pageDir(page.ID, 0)
+	// An empty orphan, an orphan holding only an empty orphan, and orphans
+	// holding a file, at the root and below the page.
+	empty, outer, inner, kept, keptChild := ksid.NewID(), ksid.NewID(), ksid.NewID(), ksid.NewID(), ksid.NewID()
+	for _, dir := range []string{
+		filepath.Join(pageDir, empty.String()),
+		filepath.Join(pageDir, outer.String(), inner.String()),
+		filepath.Join(ws.wsDir, kept.String()),
+		filepath.Join(pageDir, keptChild.String()),
+	} {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, dir := range []string{filepath.Join(ws.wsDir, kept.String()), filepath.Join(pageDir, keptChild.String())} {
+		if err := os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("x"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := ws.refreshCache(); err != nil {
+		t.Fatal(err)
+	}
+	// Orphans are never listed, even before the repair.
+	nodes, err := ws.ListChildren(0)
+	if err != nil || len(nodes) != 1 || nodes[0].HasChildren {
+		t.Fatalf("before repair: ListChildren = %v, %v", nodes, err)
+	}
+
+	orphans, err := ws.RepairWorkspace(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	slices.Sort(orphans)
+	want := []ksid.ID{empty, outer, inner, kept, keptChild}
+	slices.Sort(want)
+	if !slices.Equal(orphans, want) {
+		t.Errorf("orphans = %v, want %v", orphans, want)
+	}
+
+	nodes, err = ws.ListChildren(0)
+	if err != nil || len(nodes) != 1 || nodes[0].ID != page.ID {
+		t.Fatalf("ListChildren(0) = %v, %v", nodes, err)
+	}
+	if nodes[0].HasChildren {
+		t.Error("page still has children")
+	}
+	if children, err := ws.ListChildren(page.ID); err != nil || len(children) != 0 {
+		t.Errorf("ListChildren(page) = %v, %v", children, err)
+	}
+	for _, id := range []ksid.ID{empty, outer, inner} {
+		if _, err := os.Stat(filepath.Join(pageDir, id.String())); !os.IsNotExist(err) {
+			t.Errorf("%s not removed: %v", id, err)
+		}
+	}
+	for _, dir := range []string{filepath.Join(ws.wsDir, kept.String()), filepath.Join(pageDir, keptChild.String())} {
+		if _, err := os.Stat(filepath.Join(dir, "notes.txt")); err != nil {
+			t.Errorf("non-empty orphan removed: %v", err)
+		}
+	}
+	ws.mu.RLock()
+	for _, id := range []ksid.ID{empty, outer, inner} {
+		if _, ok := ws.cache[id]; ok {
+			t.Errorf("%s still in the parent cache", id)
+		}
+	}
+	ws.mu.RUnlock()
+
+	// Repairing again finds only the orphans that were kept.
+	orphans, err = ws.RepairWorkspace(ctx)
+	slices.Sort(orphans)
+	want = []ksid.ID{kept, keptChild}
+	slices.Sort(want)
+	if err != nil || !slices.Equal(orphans, want) {
+		t.Errorf("second repair = %v, %v", orphans, err)
+	}
+}
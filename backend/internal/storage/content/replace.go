@@ -0,0 +1,210 @@
+// Implements workspace-wide find and replace across page contents.
+
+package content
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"unicode/utf8"
+
+	"github.com/maruel/ksid"
+	"github.com/maruel/mddb/backend/internal/storage/git"
+)
+
+// replaceContextBytes is how much text around a match ReplaceMatch.Context shows on each side.
+const replaceContextBytes = 30
+
+// linkTargetRe matches the "(target)" part of a markdown link or image.
+var linkTargetRe = regexp.MustCompile(`\]\([^)\n]*\)`)
+
+// ReplaceOptions configures ReplaceAll.
+type ReplaceOptions struct {
+	// Regex treats find as an RE2 regular expression; replace may then refer
+	// to submatches with $1 or ${name}. Otherwise both are literal.
+	Regex bool
+	// IgnoreCase matches find case-insensitively.
+	IgnoreCase bool
+	// IncludeTitles also replaces in page titles.
+	IncludeTitles bool
+	// SkipLinkTargets leaves matches inside markdown link targets "](...)"
+	// untouched so links are not broken.
+	SkipLinkTargets bool
+	// DryRun reports the matches without changing anything.
+	DryRun bool
+}
+
+// ReplaceMatch is one occurrence found by ReplaceAll.
+type ReplaceMatch struct {
+	NodeID  ksid.ID
+	InTitle bool   // The match is in the title rather than the body.
+	Offset  int    // Byte offset of the match in the original title or body.
+	Match   string // Matched text.
+	Context string // Matched text with some surrounding text.
+}
+
+// ReplaceResult summarizes a ReplaceAll call.
+type ReplaceResult struct {
+	Pages       int // Number of pages with at least one match.
+	Occurrences int // Total number of matches.
+	Matches     []ReplaceMatch
+}
+
+// ReplaceAll replaces every occurrence of find in all page bodies, and titles
+// when opts.IncludeTitles is set.
+//
+// All changed pages are committed in a single commit. With opts.DryRun, the
+// matches are returned and nothing is written.
+func (ws *WorkspaceFileStore) ReplaceAll(ctx context.Context, find, replace string, opts ReplaceOptions, author git.Author) (*ReplaceResult, error) {
+	if find == "" {
+		return nil, errFindRequired
+	}
+	expr := find
+	if !opts.Regex {
+		expr = regexp.QuoteMeta(find)
+	}
+	if opts.IgnoreCase {
+		expr = "(?i)" + expr
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern: %w", err)
+	}
+	r := &replacer{re: re, replace: replace, opts: opts}
+
+	if opts.DryRun {
+		res := &ReplaceResult{}
+		pages, err := ws.IterPages()
+		if err != nil {
+			return nil, err
+		}
+		for node := range pages {
+			r.page(res, node)
+		}
+		return res, nil
+	}
+
+	res := &ReplaceResult{}
+	var changed []*Node
+	err = ws.repo.CommitTx(ctx, author, func() (string, []string, error) {
+		pages, err := ws.IterPages()
+		if err != nil {
+			return "", nil, err
+		}
+		var files []string
+		for node := range pages {
+			title, body, ok := r.page(res, node)
+			if !ok {
+				continue
+			}
+			updated, err := ws.updatePage(node.ID, title, body)
+			if err != nil {
+				return "", nil, err
+			}
+			changed = append(changed, updated)
+			files = append(files, ws.gitPath(ws.getParent(node.ID), node.ID, "index.md"))
+		}
+		summary := "replace: " + strconv.Itoa(res.Occurrences) + " occurrences in " + strconv.Itoa(res.Pages) + " pages"
+		msg := git.AppendTrailers(summary,
+			git.Trailer{Key: git.TrailerOp, Value: "replace"},
+			git.Trailer{Key: git.TrailerType, Value: string(NodeTypeDocument)},
+			git.Trailer{Key: git.TrailerActor, Value: author.ID},
+		)
+		return msg, files, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(changed) != 0 {
+		ws.pageWritten(changed...)
+	}
+	return res, nil
+}
+
+// replacer applies one ReplaceAll request to individual pages.
+type replacer struct {
+	re      *regexp.Regexp
+	replace string
+	opts    ReplaceOptions
+}
+
+// page records the matches in node into res and returns the new title and
+// body. ok is false when nothing matched.
+func (r *replacer) page(res *ReplaceResult, node *Node) (title, body string, ok bool) {
+	before := res.Occurrences
+	title = node.Title
+	if r.opts.IncludeTitles {
+		title = r.apply(res, node.ID, true, node.Title)
+	}
+	body = r.apply(res, node.ID, false, node.Content)
+	if res.Occurrences == before {
+		return "", "", false
+	}
+	res.Pages++
+	return title, body, true
+}
+
+// apply records the matches in s and returns s with them replaced.
+func (r *replacer) apply(res *ReplaceResult, id ksid.ID, inTitle bool, s string) string {
+	matches := r.re.FindAllStringSubmatchIndex(s, -1)
+	if len(matches) == 0 {
+		return s
+	}
+	var skip [][]int
+	if r.opts.SkipLinkTargets && !inTitle {
+		skip = linkTargetRe.FindAllStringIndex(s, -1)
+	}
+	var out []byte
+	last := 0
+	for _, m := range matches {
+		if m[0] == m[1] || inLinkTarget(skip, m[0], m[1]) {
+			continue
+		}
+		res.Occurrences++
+		res.Matches = append(res.Matches, ReplaceMatch{
+			NodeID:  id,
+			InTitle: inTitle,
+			Offset:  m[0],
+			Match:   s[m[0]:m[1]],
+			Context: snippet(s, m[0], m[1]),
+		})
+		out = append(out, s[last:m[0]]...)
+		if r.opts.Regex {
+			out = r.re.ExpandString(out, r.replace, s, m)
+		} else {
+			out = append(out, r.replace...)
+		}
+		last = m[1]
+	}
+	if out == nil {
+		return s
+	}
+	return string(append(out, s[last:]...))
+}
+
+// inLinkTarget reports whether [start, end) overlaps the inside of one of the
+// "](...)" spans.
+func inLinkTarget(spans [][]int, start, end int) bool {
+	for _, sp := range spans {
+		// Exclude the leading "](" and the trailing ")".
+		if start < sp[1]-1 && end > sp[0]+2 {
+			return true
+		}
+	}
+	return false
+}
+
+// snippet returns s[start:end] with up to replaceContextBytes of context on
+// each side, cut at rune boundaries.
+func snippet(s string, start, end int) string {
+	from := max(0, start-replaceContextBytes)
+	for from > 0 && !utf8.RuneStart(s[from]) {
+		from--
+	}
+	to := min(len(s), end+replaceContextBytes)
+	for to < len(s) && !utf8.RuneStart(s[to]) {
+		to++
+	}
+	return s[from:to]
+}
@@ -0,0 +1,153 @@
+package content
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/maruel/mddb/backend/internal/storage/git"
+)
+
+func TestReplaceAll(t *testing.T) {
+	author := git.Author{Name: "Test", Email: "test@test.com"}
+
+	// setup creates two pages mentioning "Acme" and one that does not.
+	setup := func(t *testing.T) (*WorkspaceFileStore, []*Node) {
+		_, ws, _ := initWS(t)
+		ctx := t.Context()
+		bodies := []struct{ title, body string }{
+			{"Acme overview", "Acme builds rockets. acme is lowercase here. See [Acme](https://acme.example/Acme)."},
+			{"Roadmap", "Acme 2025 and Acme 2026."},
+			{"Unrelated", "Nothing to see."},
+		}
+		var nodes []*Node
+		for _, b := range bodies {
+			node, err := ws.CreatePageUnderParent(ctx, 0, b.title, b.body, author)
+			if err != nil {
+				t.Fatal(err)
+			}
+			nodes = append(nodes, node)
+		}
+		return ws, nodes
+	}
+
+	t.Run("Literal", func(t *testing.T) {
+		ws, nodes := setup(t)
+		ctx := t.Context()
+		before, err := ws.repo.CommitCount(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		res, err := ws.ReplaceAll(ctx, "Acme", "Globex", ReplaceOptions{}, author)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// Case-sensitive body-only: 3 in the first page (link text and
+		// target included), 2 in the second.
+		if res.Pages != 2 || res.Occurrences != 5 {
+			t.Errorf("got %d pages, %d occurrences; want 2, 5", res.Pages, res.Occurrences)
+		}
+		page, err := ws.ReadPage(nodes[0].ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := "Globex builds rockets. acme is lowercase here. See [Globex](https://acme.example/Globex)."; page.Content != want {
+			t.Errorf("content = %q, want %q", page.Content, want)
+		}
+		if page.Title != "Acme overview" {
+			t.Errorf("title = %q, want unchanged", page.Title)
+		}
+		after, err := ws.repo.CommitCount(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if after != before+1 {
+			t.Errorf("got %d new commits, want 1", after-before)
+		}
+	})
+
+	t.Run("Options", func(t *testing.T) {
+		ws, nodes := setup(t)
+		opts := ReplaceOptions{IgnoreCase: true, IncludeTitles: true, SkipLinkTargets: true}
+		res, err := ws.ReplaceAll(t.Context(), "acme", "Globex", opts, author)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// Title 1, body 3 (link target skipped), second page 2.
+		if res.Pages != 2 || res.Occurrences != 6 {
+			t.Errorf("got %d pages, %d occurrences; want 2, 6", res.Pages, res.Occurrences)
+		}
+		page, err := ws.ReadPage(nodes[0].ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := "Globex builds rockets. Globex is lowercase here. See [Globex](https://acme.example/Acme)."; page.Content != want {
+			t.Errorf("content = %q, want %q", page.Content, want)
+		}
+		if page.Title != "Globex overview" {
+			t.Errorf("title = %q, want %q", page.Title, "Globex overview")
+		}
+	})
+
+	t.Run("Regex", func(t *testing.T) {
+		ws, nodes := setup(t)
+		res, err := ws.ReplaceAll(t.Context(), `Acme (\d{4})`, "Acme FY$1", ReplaceOptions{Regex: true}, author)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.Pages != 1 || res.Occurrences != 2 {
+			t.Errorf("got %d pages, %d occurrences; want 1, 2", res.Pages, res.Occurrences)
+		}
+		page, err := ws.ReadPage(nodes[1].ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := "Acme FY2025 and Acme FY2026."; page.Content != want {
+			t.Errorf("content = %q, want %q", page.Content, want)
+		}
+		if _, err := ws.ReplaceAll(t.Context(), "(", "", ReplaceOptions{Regex: true}, author); err == nil {
+			t.Error("expected error for invalid regex")
+		}
+	})
+
+	t.Run("DryRun", func(t *testing.T) {
+		ws, nodes := setup(t)
+		ctx := t.Context()
+		before, err := ws.repo.CommitCount(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res, err := ws.ReplaceAll(ctx, "Acme 2025", "x", ReplaceOptions{DryRun: true}, author)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.Pages != 1 || res.Occurrences != 1 || len(res.Matches) != 1 {
+			t.Fatalf("got %d pages, %d occurrences, %d matches; want 1, 1, 1", res.Pages, res.Occurrences, len(res.Matches))
+		}
+		m := res.Matches[0]
+		if m.NodeID != nodes[1].ID || m.InTitle || m.Offset != 0 || m.Match != "Acme 2025" || m.Context != "Acme 2025 and Acme 2026." {
+			t.Errorf("unexpected match %+v", m)
+		}
+		page, err := ws.ReadPage(nodes[1].ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if page.Content != "Acme 2025 and Acme 2026." {
+			t.Errorf("dry run modified content: %q", page.Content)
+		}
+		after, err := ws.repo.CommitCount(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if after != before {
+			t.Errorf("dry run created %d commits", after-before)
+		}
+	})
+
+	t.Run("EmptyFind", func(t *testing.T) {
+		ws, _ := setup(t)
+		if _, err := ws.ReplaceAll(t.Context(), "", "x", ReplaceOptions{}, author); !errors.Is(err, errFindRequired) {
+			t.Errorf("got %v, want errFindRequired", err)
+		}
+	})
+}
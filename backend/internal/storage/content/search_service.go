@@ -3,30 +3,373 @@
 package content
 
 import (
+	"cmp"
 	"context"
-	"errors"
+	"math"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/maruel/ksid"
+	"github.com/maruel/mddb/backend/internal/storage"
 )
 
-// SearchService handles full-text search across nodes.
-// TODO: This needs refactoring to work with workspace stores (iterate workspaces in org).
+const (
+	// searchTitleWeight is how much more a term occurrence in the title counts
+	// than one in the body.
+	searchTitleWeight = 5
+	// searchSnippetBefore and searchSnippetAfter bound the context kept around
+	// the first match in a snippet, in bytes.
+	searchSnippetBefore = 60
+	searchSnippetAfter  = 100
+	// maxSearchIndexes bounds how many workspace indexes are kept in memory.
+	// The least recently used one is dropped beyond it and rebuilt on its next
+	// search.
+	maxSearchIndexes = 32
+)
+
+// SearchOptions restricts a search.
+//
+// When neither MatchTitle nor MatchBody is set, both are searched.
+type SearchOptions struct {
+	Limit      int  // <= 0 returns all hits
+	MatchTitle bool // Search in titles
+	MatchBody  bool // Search in body content
+}
+
+// SearchService maintains an in-memory inverted index of page titles and
+// bodies per workspace.
+//
+// A workspace is indexed by [SearchService.Reindex] and then kept up to date
+// incrementally as pages are written or deleted, through a [PageObserver]
+// registered on the FileStoreService. At most maxSearchIndexes workspaces are
+// indexed at once.
 type SearchService struct {
 	fileStore *FileStoreService
+	mu        sync.RWMutex
+	indexes   map[ksid.ID]*searchIndex // wsID -> index
 }
 
-// NewSearchService creates a new search service.
+// NewSearchService creates a new search service and registers it to receive
+// page changes from fileStore.
 func NewSearchService(fileStore *FileStoreService) *SearchService {
-	return &SearchService{
+	s := &SearchService{
 		fileStore: fileStore,
+		indexes:   make(map[ksid.ID]*searchIndex),
+	}
+	fileStore.AddPageObserver(s)
+	return s
+}
+
+// Reindex rebuilds the index of a workspace from the pages on disk.
+func (s *SearchService) Reindex(ctx context.Context, wsID ksid.ID) error {
+	ws, err := s.fileStore.GetWorkspaceStore(ctx, wsID)
+	if err != nil {
+		return err
+	}
+	pages, err := ws.IterPages()
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	idx := s.indexes[wsID]
+	if idx == nil {
+		idx = &searchIndex{}
+		idx.touch()
+		s.indexes[wsID] = idx
+		s.evictLocked()
+	}
+	s.mu.Unlock()
+
+	// Holding the index lock while reading pages makes concurrent observer
+	// notifications wait, so they are applied on top of the rebuilt index.
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	idx.resetLocked()
+	for node := range pages {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		idx.addLocked(node)
+	}
+	idx.built = true
+	return nil
+}
+
+// EnsureIndexed indexes the workspace unless it already is.
+func (s *SearchService) EnsureIndexed(ctx context.Context, wsID ksid.ID) error {
+	if idx := s.index(wsID); idx != nil {
+		idx.mu.RLock()
+		built := idx.built
+		idx.mu.RUnlock()
+		if built {
+			return nil
+		}
+	}
+	return s.Reindex(ctx, wsID)
+}
+
+// Search returns the pages of the workspace whose title or body contains
+// every term of query, best match first.
+//
+// limit <= 0 returns all hits. Returns nil if the workspace was not indexed.
+func (s *SearchService) Search(wsID ksid.ID, query string, limit int) []SearchHit {
+	return s.SearchWithOptions(wsID, query, SearchOptions{Limit: limit})
+}
+
+// SearchWithOptions is like Search but only matches the fields selected by
+// opts.
+//
+// Terms are matched case-insensitively against whole words. A page matches
+// when each term is found in at least one of the selected fields.
+func (s *SearchService) SearchWithOptions(wsID ksid.ID, query string, opts SearchOptions) []SearchHit {
+	idx := s.index(wsID)
+	if idx == nil {
+		return nil
+	}
+	idx.touch()
+	if !opts.MatchTitle && !opts.MatchBody {
+		opts.MatchTitle, opts.MatchBody = true, true
+	}
+	terms := searchTokens(query)
+	slices.Sort(terms)
+	terms = slices.Compact(terms)
+	if len(terms) == 0 {
+		return nil
+	}
+
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	// Intersect starting from the rarest term.
+	slices.SortFunc(terms, func(a, b string) int { return cmp.Compare(len(idx.postings[a]), len(idx.postings[b])) })
+	var hits []SearchHit
+	for id, weight := range idx.postings[terms[0]] {
+		score := weight.score(opts) * idx.idfLocked(terms[0])
+		for _, t := range terms[1:] {
+			w := idx.postings[t][id].score(opts)
+			if w == 0 {
+				score = 0
+				break
+			}
+			score += w * idx.idfLocked(t)
+		}
+		if score == 0 {
+			continue
+		}
+		doc := idx.docs[id]
+		hits = append(hits, SearchHit{
+			NodeID:   id,
+			Title:    doc.title,
+			Snippet:  searchSnippet(doc.body, terms),
+			Score:    score,
+			Modified: doc.modified,
+		})
+	}
+	slices.SortFunc(hits, func(a, b SearchHit) int {
+		if c := cmp.Compare(b.Score, a.Score); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.NodeID, b.NodeID)
+	})
+	if opts.Limit > 0 && len(hits) > opts.Limit {
+		hits = hits[:opts.Limit]
+	}
+	return hits
+}
+
+// OnPageWrite updates the page in the workspace index, if the workspace is
+// indexed. Implements PageObserver.
+func (s *SearchService) OnPageWrite(wsID ksid.ID, node *Node) {
+	if idx := s.index(wsID); idx != nil {
+		idx.mu.Lock()
+		defer idx.mu.Unlock()
+		idx.removeLocked(node.ID)
+		idx.addLocked(node)
+	}
+}
+
+// OnPageDelete removes the page from the workspace index, if the workspace is
+// indexed. Implements PageObserver.
+func (s *SearchService) OnPageDelete(wsID, id ksid.ID) {
+	if idx := s.index(wsID); idx != nil {
+		idx.mu.Lock()
+		defer idx.mu.Unlock()
+		idx.removeLocked(id)
+	}
+}
+
+// OnPagesReloaded drops the workspace index, since pages may have changed
+// without notifications. It is rebuilt on the next EnsureIndexed. Implements
+// PageObserver.
+func (s *SearchService) OnPagesReloaded(wsID ksid.ID) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.indexes, wsID)
+}
+
+// evictLocked drops the least recently used indexes beyond maxSearchIndexes.
+// Caller must hold mu for writing.
+func (s *SearchService) evictLocked() {
+	for len(s.indexes) > maxSearchIndexes {
+		var oldest ksid.ID
+		var oldestUsed int64
+		for wsID, idx := range s.indexes {
+			if used := idx.lastUsed.Load(); oldest.IsZero() || used < oldestUsed {
+				oldest, oldestUsed = wsID, used
+			}
+		}
+		delete(s.indexes, oldest)
+	}
+}
+
+// index returns the index of a workspace, or nil.
+func (s *SearchService) index(wsID ksid.ID) *searchIndex {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.indexes[wsID]
+}
+
+// searchIndex is the inverted index of one workspace.
+type searchIndex struct {
+	lastUsed atomic.Int64 // UnixNano of the last search or rebuild
+	mu       sync.RWMutex
+	built    bool
+	docs     map[ksid.ID]*searchDoc
+	postings map[string]map[ksid.ID]searchWeight // term -> page -> occurrences
+}
+
+// searchWeight counts the occurrences of a term in a page, per field.
+type searchWeight struct {
+	title float64
+	body  float64
+}
+
+// score returns the weighted occurrences in the fields selected by opts.
+func (w searchWeight) score(opts SearchOptions) float64 {
+	var score float64
+	if opts.MatchTitle {
+		score += w.title * searchTitleWeight
+	}
+	if opts.MatchBody {
+		score += w.body
+	}
+	return score
+}
+
+// searchDoc is what the index retains of a page.
+type searchDoc struct {
+	title    string
+	body     string
+	modified storage.Time
+	terms    []string // distinct terms, to remove the postings
+}
+
+// touch records that the index was used, for eviction.
+func (idx *searchIndex) touch() {
+	idx.lastUsed.Store(time.Now().UnixNano())
+}
+
+// resetLocked empties the index. Caller must hold mu for writing.
+func (idx *searchIndex) resetLocked() {
+	idx.built = false
+	idx.docs = make(map[ksid.ID]*searchDoc)
+	idx.postings = make(map[string]map[ksid.ID]searchWeight)
+}
+
+// addLocked indexes a page. Caller must hold mu for writing.
+func (idx *searchIndex) addLocked(node *Node) {
+	if idx.docs == nil {
+		idx.resetLocked()
 	}
+	weights := map[string]searchWeight{}
+	for _, t := range searchTokens(node.Title) {
+		w := weights[t]
+		w.title++
+		weights[t] = w
+	}
+	for _, t := range searchTokens(node.Content) {
+		w := weights[t]
+		w.body++
+		weights[t] = w
+	}
+	doc := &searchDoc{title: node.Title, body: node.Content, modified: node.Modified}
+	for t, w := range weights {
+		p := idx.postings[t]
+		if p == nil {
+			p = make(map[ksid.ID]searchWeight)
+			idx.postings[t] = p
+		}
+		p[node.ID] = w
+		doc.terms = append(doc.terms, t)
+	}
+	idx.docs[node.ID] = doc
+}
+
+// removeLocked drops a page from the index. Caller must hold mu for writing.
+func (idx *searchIndex) removeLocked(id ksid.ID) {
+	doc := idx.docs[id]
+	if doc == nil {
+		return
+	}
+	for _, t := range doc.terms {
+		delete(idx.postings[t], id)
+		if len(idx.postings[t]) == 0 {
+			delete(idx.postings, t)
+		}
+	}
+	delete(idx.docs, id)
+}
+
+// idfLocked returns the inverse document frequency of a term, so that rare
+// terms weigh more. Caller must hold mu.
+func (idx *searchIndex) idfLocked(term string) float64 {
+	return math.Log(1 + float64(len(idx.docs))/float64(len(idx.postings[term])))
 }
 
-// Search performs a full-text search across all nodes.
-func (s *SearchService) Search(ctx context.Context, orgID ksid.ID, opts SearchOptions) ([]SearchResult, error) {
-	// TODO: Implement full-text search working with workspace stores
-	_ = ctx
-	_ = orgID
-	_ = opts
-	return nil, errors.New("search not implemented")
+// searchTokens splits s into lowercase words.
+func searchTokens(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
+
+// searchSnippet returns the part of body around the first occurrence of any
+// of terms, with whitespace collapsed. Falls back to the start of body.
+func searchSnippet(body string, terms []string) string {
+	text := strings.Join(strings.Fields(body), " ")
+	if lower := strings.ToLower(text); len(lower) != len(text) {
+		// Lowercasing changed byte offsets; show the lowercase text instead.
+		text = lower
+	}
+	lower := strings.ToLower(text)
+	pos := -1
+	for _, t := range terms {
+		if i := strings.Index(lower, t); i >= 0 && (pos < 0 || i < pos) {
+			pos = i
+		}
+	}
+	start, end := 0, searchSnippetBefore+searchSnippetAfter
+	if pos >= 0 {
+		start, end = pos-searchSnippetBefore, pos+searchSnippetAfter
+	}
+	start = max(start, 0)
+	end = min(end, len(text))
+	for start > 0 && !utf8.RuneStart(text[start]) {
+		start--
+	}
+	for end < len(text) && !utf8.RuneStart(text[end]) {
+		end++
+	}
+	snippet := text[start:end]
+	if start > 0 {
+		snippet = "…" + snippet
+	}
+	if end < len(text) {
+		snippet += "…"
+	}
+	return snippet
 }
@@ -0,0 +1,191 @@
+package content
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/maruel/ksid"
+	"github.com/maruel/mddb/backend/internal/storage/git"
+)
+
+func TestSearchService(t *testing.T) {
+	author := git.Author{Name: "Test", Email: "test@test.com"}
+
+	// setup indexes a workspace with three pages and returns them by title.
+	setup := func(t *testing.T) (*SearchService, *WorkspaceFileStore, ksid.ID, map[string]*Node) {
+		fs, ws, wsID := initWS(t)
+		s := NewSearchService(fs)
+		ctx := t.Context()
+		nodes := map[string]*Node{}
+		for _, p := range []struct{ title, body string }{
+			{"Rocket Engines", "Liquid fuel engines burn kerosene and oxygen."},
+			{"Fuel Depot", "The depot stores fuel for the launch site."},
+			{"Cafeteria", "Lunch is served at noon. No rockets allowed."},
+		} {
+			node, err := ws.CreatePageUnderParent(ctx, 0, p.title, p.body, author)
+			if err != nil {
+				t.Fatal(err)
+			}
+			nodes[p.title] = node
+		}
+		if err := s.Reindex(ctx, wsID); err != nil {
+			t.Fatal(err)
+		}
+		return s, ws, wsID, nodes
+	}
+	titles := func(hits []SearchHit) string {
+		var out []string
+		for _, h := range hits {
+			out = append(out, h.Title)
+		}
+		return strings.Join(out, ",")
+	}
+
+	t.Run("MultiTerm", func(t *testing.T) {
+		s, _, wsID, _ := setup(t)
+		// "fuel" is in two pages but only one also has "kerosene".
+		if got := titles(s.Search(wsID, "fuel kerosene", 10)); got != "Rocket Engines" {
+			t.Errorf("fuel kerosene: got %q", got)
+		}
+		// A title match ranks first.
+		if got := titles(s.Search(wsID, "fuel", 10)); got != "Fuel Depot,Rocket Engines" {
+			t.Errorf("fuel: got %q", got)
+		}
+		if got := titles(s.Search(wsID, "fuel", 1)); got != "Fuel Depot" {
+			t.Errorf("limit: got %q", got)
+		}
+		if hits := s.Search(wsID, "fuel submarine", 10); len(hits) != 0 {
+			t.Errorf("want no hit when a term is missing, got %q", titles(hits))
+		}
+	})
+
+	t.Run("CaseInsensitive", func(t *testing.T) {
+		s, _, wsID, nodes := setup(t)
+		hits := s.Search(wsID, "KEROSENE Oxygen", 10)
+		if len(hits) != 1 || hits[0].NodeID != nodes["Rocket Engines"].ID {
+			t.Fatalf("got %+v", hits)
+		}
+		if !strings.Contains(hits[0].Snippet, "kerosene") {
+			t.Errorf("snippet %q does not contain the match", hits[0].Snippet)
+		}
+	})
+
+	t.Run("Incremental", func(t *testing.T) {
+		s, ws, wsID, nodes := setup(t)
+		ctx := t.Context()
+
+		if _, err := ws.UpdatePage(ctx, nodes["Cafeteria"].ID, "Cafeteria", "Soup and kerosene-free salad.", author); err != nil {
+			t.Fatal(err)
+		}
+		if got := titles(s.Search(wsID, "kerosene", 10)); got != "Rocket Engines,Cafeteria" {
+			t.Errorf("after update: got %q", got)
+		}
+		if hits := s.Search(wsID, "lunch", 10); len(hits) != 0 {
+			t.Errorf("old body still indexed: %q", titles(hits))
+		}
+
+		child, err := ws.CreatePageUnderParent(ctx, nodes["Fuel Depot"].ID, "Depot Safety", "Keep fuel away from sparks.", author)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := titles(s.Search(wsID, "sparks", 10)); got != "Depot Safety" {
+			t.Errorf("after create: got %q", got)
+		}
+
+		// Deleting a page also drops its descendants.
+		if err := ws.DeletePage(ctx, nodes["Fuel Depot"].ID, author); err != nil {
+			t.Fatal(err)
+		}
+		for _, h := range s.Search(wsID, "fuel", 10) {
+			if h.NodeID == nodes["Fuel Depot"].ID || h.NodeID == child.ID {
+				t.Errorf("deleted page %q still returned", h.Title)
+			}
+		}
+		if got := titles(s.Search(wsID, "fuel", 10)); got != "Rocket Engines" {
+			t.Errorf("after delete: got %q", got)
+		}
+	})
+
+	t.Run("NotIndexed", func(t *testing.T) {
+		fs, ws, wsID := initWS(t)
+		s := NewSearchService(fs)
+		if _, err := ws.CreatePageUnderParent(t.Context(), 0, "Hello", "world", author); err != nil {
+			t.Fatal(err)
+		}
+		if hits := s.Search(wsID, "hello", 10); hits != nil {
+			t.Errorf("want nil before indexing, got %+v", hits)
+		}
+		if err := s.EnsureIndexed(t.Context(), wsID); err != nil {
+			t.Fatal(err)
+		}
+		if got := titles(s.Search(wsID, "hello", 10)); got != "Hello" {
+			t.Errorf("got %q", got)
+		}
+	})
+
+	t.Run("Fields", func(t *testing.T) {
+		s, _, wsID, _ := setup(t)
+		if got := titles(s.SearchWithOptions(wsID, "fuel", SearchOptions{MatchTitle: true})); got != "Fuel Depot" {
+			t.Errorf("title: got %q", got)
+		}
+		if hits := s.SearchWithOptions(wsID, "fuel", SearchOptions{MatchBody: true}); len(hits) != 2 || hits[0].Score != hits[1].Score {
+			t.Errorf("body: the title must not weigh in: %+v", hits)
+		}
+		if hits := s.SearchWithOptions(wsID, "cafeteria", SearchOptions{MatchBody: true}); len(hits) != 0 {
+			t.Errorf("title-only term matched the body: %q", titles(hits))
+		}
+	})
+
+	t.Run("Reloaded", func(t *testing.T) {
+		s, ws, wsID, _ := setup(t)
+		ctx := t.Context()
+		// Write a page behind the store's back, as an import does.
+		id := ksid.NewID()
+		if err := os.MkdirAll(filepath.Join(ws.wsDir, id.String()), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(ws.wsDir, id.String(), "index.md"), []byte("---\ntitle: Imported\n---\n\nplutonium\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := ws.CommitImport(ctx, "test", author); err != nil {
+			t.Fatal(err)
+		}
+		if err := s.EnsureIndexed(ctx, wsID); err != nil {
+			t.Fatal(err)
+		}
+		if got := titles(s.Search(wsID, "plutonium", 10)); got != "Imported" {
+			t.Errorf("got %q", got)
+		}
+	})
+
+	t.Run("Evict", func(t *testing.T) {
+		s, _, wsID, _ := setup(t)
+		for range maxSearchIndexes {
+			s.mu.Lock()
+			idx := &searchIndex{}
+			idx.touch()
+			s.indexes[ksid.NewID()] = idx
+			s.evictLocked()
+			s.mu.Unlock()
+		}
+		if len(s.indexes) != maxSearchIndexes {
+			t.Errorf("got %d indexes", len(s.indexes))
+		}
+		if s.index(wsID) != nil {
+			t.Error("least recently used index was kept")
+		}
+	})
+}
+
+func TestSearchSnippet(t *testing.T) {
+	body := strings.Repeat("filler ", 30) + "the Needle is here " + strings.Repeat("tail ", 40)
+	got := searchSnippet(body, []string{"needle"})
+	if !strings.HasPrefix(got, "…") || !strings.HasSuffix(got, "…") || !strings.Contains(got, "the Needle is here") {
+		t.Errorf("got %q", got)
+	}
+	if got := searchSnippet("short\n\nbody", []string{"missing"}); got != "short body" {
+		t.Errorf("got %q", got)
+	}
+}
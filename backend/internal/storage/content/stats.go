@@ -0,0 +1,102 @@
+// Aggregates workspace metrics in a single walk of the workspace directory.
+
+package content
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+
+	"github.com/maruel/ksid"
+	"github.com/maruel/mddb/backend/internal/storage"
+)
+
+// WorkspaceStats summarizes the content of a workspace.
+type WorkspaceStats struct {
+	Pages        int          // Nodes with an index.md; hybrid nodes count as a page and a table
+	Tables       int          // Nodes with a metadata.json
+	Records      int          // Rows across all tables
+	Assets       int          // Assets across all pages
+	StorageBytes int64        // Size of all files, excluding .git
+	Modified     storage.Time // Latest modification of any file, excluding .git
+}
+
+// Stats returns the metrics of the workspace.
+//
+// It walks the workspace directory once, reading only the data.jsonl files to
+// count their rows. Unreadable entries are skipped, like in dirUsage.
+func (ws *WorkspaceFileStore) Stats() (WorkspaceStats, error) {
+	at, err := ws.assetTable()
+	if err != nil {
+		return WorkspaceStats{}, err
+	}
+	s := WorkspaceStats{Assets: at.table.Len()}
+	ws.statsWalk(ws.wsDir, 0, true, at, &s)
+	return s, nil
+}
+
+// statsWalk adds the files under dir to s. nodeID is the node whose directory
+// dir is, or zero for the workspace root and other directories. tree is set
+// for the workspace root and node directories, the only ones that contain
+// node directories; blob directories have names that look like IDs.
+func (ws *WorkspaceFileStore) statsWalk(dir string, nodeID ksid.ID, tree bool, at *assetTable, s *WorkspaceStats) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		p := filepath.Join(dir, name)
+		if entry.IsDir() {
+			switch {
+			case excludedFromUsage(name):
+			case tree && isNodeDir(entry):
+				id, _ := ksid.Parse(name)
+				ws.statsWalk(p, id, true, at, s)
+			default:
+				ws.statsWalk(p, 0, false, at, s)
+			}
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		s.StorageBytes += info.Size()
+		if t := storage.ToTime(info.ModTime()); t.After(s.Modified) {
+			s.Modified = t
+		}
+		if nodeID.IsZero() {
+			continue
+		}
+		switch name {
+		case "index.md":
+			s.Pages++
+		case "metadata.json":
+			s.Tables++
+		case "data.jsonl":
+			s.Records += countRows(p)
+		default:
+			// Plain asset files predate the assets table, which takes precedence.
+			if at.get(nodeID, name) == nil {
+				s.Assets++
+			}
+		}
+	}
+}
+
+// countRows returns the number of rows of the JSONL file at path, not counting
+// its schema header.
+func countRows(path string) int {
+	data, err := os.ReadFile(path) //nolint:gosec // G304: path is within the workspace directory
+	if err != nil {
+		return 0
+	}
+	n := 0
+	for line := range bytes.SplitSeq(data, []byte{'\n'}) {
+		if len(bytes.TrimSpace(line)) != 0 {
+			n++
+		}
+	}
+	return max(n-1, 0)
+}
@@ -0,0 +1,122 @@
+package content
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/maruel/ksid"
+	"github.com/maruel/mddb/backend/internal/storage"
+	"github.com/maruel/mddb/backend/internal/storage/git"
+)
+
+func TestWorkspaceStats(t *testing.T) {
+	author := git.Author{Name: "Test", Email: "test@test.com"}
+	_, ws, _ := initWS(t)
+	ctx := t.Context()
+
+	empty, err := ws.Stats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if empty.Pages != 0 || empty.Tables != 0 || empty.Records != 0 || empty.Assets != 0 {
+		t.Errorf("empty workspace: got %+v", empty)
+	}
+
+	// Populate: a page with a child page, a table under the page, a hybrid
+	// node, records and assets, including a plain asset file.
+	page, err := ws.CreatePageUnderParent(ctx, 0, "Page", "body", author)
+	if err != nil {
+		t.Fatal(err)
+	}
+	child, err := ws.CreatePageUnderParent(ctx, page.ID, "Child", "child", author)
+	if err != nil {
+		t.Fatal(err)
+	}
+	props := []Property{{Name: "name", Type: PropertyTypeText}}
+	table, err := ws.CreateTableUnderParent(ctx, page.ID, "Table", props, author)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hybrid, err := ws.CreateTableUnderParent(ctx, 0, "Hybrid", props, author)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ws.WritePage(ctx, hybrid.ID, 0, "Hybrid", "hybrid body", author); err != nil {
+		t.Fatal(err)
+	}
+	for i, id := range []ksid.ID{table.ID, table.ID, table.ID, hybrid.ID} {
+		rec := &DataRecord{ID: ksid.NewID(), Data: map[string]any{"name": fmt.Sprint(i)}, Created: storage.Now(), Modified: storage.Now()}
+		if err := ws.AppendRecord(ctx, id, rec, author); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, id := range []ksid.ID{page.ID, page.ID, child.ID} {
+		if _, err := ws.SaveAsset(ctx, id, ksid.NewID().String()+".png", []byte("png"), author); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(ws.pageDir(child.ID, page.ID), "legacy.txt"), []byte("legacy"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := ws.Stats()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Compare against the individual iterators.
+	var want WorkspaceStats
+	var nodes []ksid.ID
+	pages, err := ws.IterPages()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for p := range pages {
+		want.Pages++
+		nodes = append(nodes, p.ID)
+	}
+	tables, err := ws.IterTables()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for tbl := range tables {
+		want.Tables++
+		if tbl.ID != hybrid.ID {
+			nodes = append(nodes, tbl.ID)
+		}
+		records, err := ws.IterRecords(tbl.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for range records {
+			want.Records++
+		}
+	}
+	for _, id := range nodes {
+		assets, err := ws.IterAssets(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for range assets {
+			want.Assets++
+		}
+	}
+	if want.StorageBytes, err = dirUsage(ws.wsDir); err != nil {
+		t.Fatal(err)
+	}
+	if want.Pages != 3 || want.Tables != 2 || want.Records != 4 || want.Assets != 4 {
+		t.Fatalf("unexpected iterator counts: %+v", want)
+	}
+	want.Modified = got.Modified
+	if got != want {
+		t.Errorf("Stats() = %+v, want %+v", got, want)
+	}
+	if got.Modified.Before(empty.Modified) || got.Modified.IsZero() {
+		t.Errorf("Modified = %v, want after %v", got.Modified, empty.Modified)
+	}
+	if pageCount, usage, err := ws.GetWorkspaceUsage(); err != nil || pageCount != got.Pages || usage != got.StorageBytes {
+		t.Errorf("GetWorkspaceUsage() = %d, %d, %v", pageCount, usage, err)
+	}
+}
@@ -60,13 +60,17 @@ func BenchmarkTableOperations(b *testing.B) {
 		b.Fatal(err)
 	}
 
-	serverQuotas := storage.ResourceQuotas{
-		MaxPages:              1_000_000,
-		MaxStorageBytes:       1_000_000_000_000, // 1TB
-		MaxRecordsPerTable:    1_000_000,
-		MaxAssetSizeBytes:     1024 * 1024 * 1024, // 1GB
-		MaxTablesPerWorkspace: 10_000,
-		MaxColumnsPerTable:    1_000,
+	serverQuotas := storage.ServerQuotas{
+		ResourceQuotas: storage.ResourceQuotas{
+			MaxPages:              1_000_000,
+			MaxStorageBytes:       1_000_000_000_000, // 1TB
+			MaxRecordsPerTable:    1_000_000,
+			MaxAssetSizeBytes:     1024 * 1024 * 1024, // 1GB
+			MaxTablesPerWorkspace: 10_000,
+			MaxColumnsPerTable:    1_000,
+			MaxNodeDepth:          1_000,
+			MaxChildrenPerNode:    1_000,
+		},
 	}
 	fs, err := NewFileStoreService(tmpDir, gitMgr, wsService, orgService, &serverQuotas)
 	if err != nil {
@@ -179,7 +183,7 @@ func BenchmarkTableOperations(b *testing.B) {
 		b.ResetTimer()
 		for range b.N {
 			// Read 50 records from middle
-			records, err := wsStore.ReadRecordsPage(readDBID, 5000, 50)
+			records, _, err := wsStore.ReadRecordsPage(readDBID, 5000, 50)
 			if err != nil {
 				b.Fatal(err)
 			}
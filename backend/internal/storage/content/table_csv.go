@@ -0,0 +1,259 @@
+// Imports and exports table records as CSV for editing in spreadsheets.
+
+package content
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/maruel/ksid"
+	"github.com/maruel/mddb/backend/internal/jsonldb"
+	"github.com/maruel/mddb/backend/internal/storage"
+	"github.com/maruel/mddb/backend/internal/storage/git"
+)
+
+// ExportTableCSV writes the records of table id to w as CSV.
+//
+// The header row has one column per property, in schema order, and each
+// record is a row in ID order. Numbers and text are written as is, checkboxes
+// as true or false and list values like multi_select as a JSON array. Unset
+// values are empty cells. Text that a spreadsheet would evaluate as a formula,
+// starting with =, +, -, @, a tab or a carriage return, is prefixed with a
+// single quote; see csvFormulaPrefix.
+func (ws *WorkspaceFileStore) ExportTableCSV(id ksid.ID, w io.Writer) error {
+	table, err := ws.ReadTable(id)
+	if err != nil {
+		return err
+	}
+	records, err := ws.IterRecords(id)
+	if err != nil {
+		return err
+	}
+	cw := csv.NewWriter(w)
+	row := make([]string, len(table.Properties))
+	for i, prop := range table.Properties {
+		row[i] = prop.Name
+	}
+	if err := cw.Write(row); err != nil {
+		return err
+	}
+	for r := range records {
+		for i, prop := range table.Properties {
+			if row[i], err = csvCell(prop.Type, r.Data[prop.Name]); err != nil {
+				return fmt.Errorf("record %s: property %q: %w", r.ID, prop.Name, err)
+			}
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// csvCell returns the CSV representation of a record value.
+func csvCell(pt PropertyType, v any) (string, error) {
+	switch v := v.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return csvEscape(v), nil
+	case float64:
+		if pt == PropertyTypeCheckbox {
+			return strconv.FormatBool(v != 0), nil
+		}
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case int64:
+		if pt == PropertyTypeCheckbox {
+			return strconv.FormatBool(v != 0), nil
+		}
+		return strconv.FormatInt(v, 10), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	default:
+		b, err := json.Marshal(v)
+		return string(b), err
+	}
+}
+
+// csvFormulaPrefix starts the text cells that spreadsheets evaluate as
+// formulas, which may run commands or leak data when an exported file is
+// opened. Such cells are written with a leading single quote, which
+// spreadsheets display as text.
+const csvFormulaPrefix = "=+-@\t\r"
+
+// csvEscape prefixes s with a single quote when it would be evaluated as a
+// formula. Text that already starts with quotes followed by a formula
+// character gets one more, so that csvUnescape restores every value.
+func csvEscape(s string) string {
+	if t := strings.TrimLeft(s, "'"); t != "" && strings.ContainsRune(csvFormulaPrefix, rune(t[0])) {
+		return "'" + s
+	}
+	return s
+}
+
+// csvUnescape removes the quote added by csvEscape.
+func csvUnescape(s string) string {
+	if strings.HasPrefix(s, "'") && csvEscape(s[1:]) != s[1:] {
+		return s[1:]
+	}
+	return s
+}
+
+// ImportTableCSV appends the rows of the CSV in r as new records of table id,
+// in a single git commit. Returns the number of records appended.
+//
+// The first row is the header naming the property of each column. Columns
+// that are not properties are ignored, as are formula columns since their
+// values are computed; properties without a column are left unset. Rows may
+// have fewer cells than the header, the missing ones being empty. Cells are
+// parsed like ExportTableCSV writes them, removing the quote before a formula
+// character, and coerced to their property type.
+//
+// Every row is validated against the schema and the record quota is checked
+// before anything is written: either all rows are imported or none.
+func (ws *WorkspaceFileStore) ImportTableCSV(ctx context.Context, id ksid.ID, r io.Reader, author git.Author) (int, error) {
+	table, err := ws.ReadTable(id)
+	if err != nil {
+		return 0, err
+	}
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	header, err := cr.Read()
+	if errors.Is(err, io.EOF) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("invalid CSV: %w", err)
+	}
+	// columns[i] is the property of column i, or nil when ignored.
+	columns := make([]*Property, len(header))
+	for i, name := range header {
+		if slices.Contains(header[:i], name) {
+			return 0, fmt.Errorf("invalid CSV: duplicate column %q", name)
+		}
+		if j := slices.IndexFunc(table.Properties, func(p Property) bool { return p.Name == name }); j != -1 && table.Properties[j].Type != PropertyTypeFormula {
+			columns[i] = &table.Properties[j]
+		}
+	}
+
+	var records []*DataRecord
+	now := storage.Now()
+	for {
+		if err := ctx.Err(); err != nil {
+			return 0, err
+		}
+		row, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return 0, fmt.Errorf("invalid CSV: %w", err)
+		}
+		line, _ := cr.FieldPos(0)
+		if len(row) > len(header) {
+			return 0, fmt.Errorf("invalid CSV: line %d: %d cells for %d columns", line, len(row), len(header))
+		}
+		data := map[string]any{}
+		for i, cell := range row {
+			if columns[i] != nil && cell != "" {
+				data[columns[i].Name] = csvValue(columns[i].Type, cell)
+			}
+		}
+		record, err := prepareTableRecord(table, &DataRecord{ID: ksid.NewID(), Data: CoerceRecordData(data, table.Properties), Created: now, Modified: now})
+		if err != nil {
+			return 0, fmt.Errorf("line %d: %w", line, err)
+		}
+		records = append(records, record)
+	}
+	if len(records) == 0 {
+		return 0, nil
+	}
+
+	parentID := ws.getParent(id)
+	err = ws.repo.CommitTx(ctx, author, func() (string, []string, error) {
+		if err := ws.appendRecords(id, parentID, records); err != nil {
+			return "", nil, err
+		}
+		files := []string{ws.gitPath(parentID, id, "data.jsonl")}
+		return commitMsg(author, "import: "+strconv.Itoa(len(records))+" records from CSV", "import", "record", id), files, nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return len(records), nil
+}
+
+// csvValue parses a CSV cell of a property. The result still needs to be
+// coerced with CoerceRecordData.
+func csvValue(pt PropertyType, cell string) any {
+	cell = csvUnescape(cell)
+	switch pt {
+	case PropertyTypeCheckbox:
+		if b, err := strconv.ParseBool(cell); err == nil {
+			return b
+		}
+	case PropertyTypeMultiSelect, PropertyTypeRelation:
+		var l []any
+		if err := json.Unmarshal([]byte(cell), &l); err == nil {
+			return l
+		}
+		for s := range strings.SplitSeq(cell, ",") {
+			if s = strings.TrimSpace(s); s != "" {
+				l = append(l, s)
+			}
+		}
+		return l
+	default:
+	}
+	return cell
+}
+
+// appendRecords appends prepared records to a table without committing, after
+// checking the quotas for all of them. The records file is restored if an
+// append fails.
+func (ws *WorkspaceFileStore) appendRecords(tableID, tableParentID ksid.ID, records []*DataRecord) error {
+	recordsFile := ws.tableRecordsFile(tableID, tableParentID)
+	var size int64
+	for _, r := range records {
+		data, err := json.Marshal(r)
+		if err != nil {
+			return fmt.Errorf("failed to marshal record: %w", err)
+		}
+		size += int64(len(data)) + 1
+	}
+	if err := ws.CheckStorageQuota(size); err != nil {
+		return err
+	}
+	prev, err := os.ReadFile(recordsFile) //nolint:gosec // G304: recordsFile is constructed from validated id
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read table: %w", err)
+	}
+	existed := err == nil
+	table, err := jsonldb.NewTable[*DataRecord](recordsFile)
+	if err != nil {
+		return fmt.Errorf("failed to open table: %w", err)
+	}
+	if n := table.Len() + len(records); n > ws.quotas.MaxRecordsPerTable {
+		return fmt.Errorf("%w: max %d records per table", ErrQuotaExceeded, ws.quotas.MaxRecordsPerTable)
+	}
+	for _, r := range records {
+		if err := table.Append(r); err != nil {
+			if existed {
+				err = errors.Join(err, os.WriteFile(recordsFile, prev, 0o644)) //nolint:gosec // G306: 0o644 is intentional for user data files
+			} else {
+				err = errors.Join(err, os.Remove(recordsFile))
+			}
+			return fmt.Errorf("failed to append record: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,187 @@
+package content
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/maruel/ksid"
+	"github.com/maruel/mddb/backend/internal/storage"
+	"github.com/maruel/mddb/backend/internal/storage/git"
+)
+
+func TestTableCSV(t *testing.T) {
+	author := git.Author{Name: "Test", Email: "test@test.com"}
+	_, ws, _ := initWS(t)
+	ctx := t.Context()
+	props := []Property{
+		{Name: "Name", Type: PropertyTypeText, Required: true},
+		{Name: "Count", Type: PropertyTypeNumber},
+		{Name: "Done", Type: PropertyTypeCheckbox},
+		{Name: "Due", Type: PropertyTypeDate},
+		{Name: "Tags", Type: PropertyTypeMultiSelect},
+		{Name: "Total", Type: PropertyTypeFormula, FormulaConfig: &FormulaConfig{Expression: "Count * 2"}},
+	}
+	newTable := func(t *testing.T) ksid.ID {
+		t.Helper()
+		table, err := ws.CreateTableUnderParent(ctx, 0, "Tasks", props, author)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return table.ID
+	}
+	readData := func(t *testing.T, id ksid.ID) []map[string]any {
+		t.Helper()
+		records, err := ws.IterRecords(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var out []map[string]any
+		for r := range records {
+			out = append(out, r.Data)
+		}
+		return out
+	}
+
+	t.Run("RoundTrip", func(t *testing.T) {
+		src := newTable(t)
+		now := storage.Now()
+		for _, data := range []map[string]any{
+			{"Name": "Quoted, \"with\" comma\nand newline", "Count": int64(3), "Done": true, "Due": "2026-01-02", "Tags": []any{"a", "b"}},
+			{"Name": "Fraction", "Count": 1.5},
+			{"Name": "Unset"},
+			{"Name": "=HYPERLINK(\"http://x\")", "Count": int64(-2)},
+			{"Name": "'@quoted"},
+		} {
+			r := &DataRecord{ID: ksid.NewID(), Data: CoerceRecordData(data, props), Created: now, Modified: now}
+			if err := ws.AppendRecord(ctx, src, r, author); err != nil {
+				t.Fatal(err)
+			}
+		}
+		var buf bytes.Buffer
+		if err := ws.ExportTableCSV(src, &buf); err != nil {
+			t.Fatal(err)
+		}
+		exported := buf.String()
+		if !strings.HasPrefix(exported, "Name,Count,Done,Due,Tags,Total\n") {
+			t.Errorf("header: %q", exported)
+		}
+		// Formulas are neutralized, numbers are not.
+		if !strings.Contains(exported, "\n\"'=HYPERLINK(\"\"http://x\"\")\",-2,") || !strings.Contains(exported, "\n''@quoted,") {
+			t.Errorf("formulas not neutralized: %q", exported)
+		}
+
+		dst := newTable(t)
+		n, err := ws.ImportTableCSV(ctx, dst, strings.NewReader(exported), author)
+		if err != nil || n != 5 {
+			t.Fatalf("ImportTableCSV = %d, %v", n, err)
+		}
+		if got, want := readData(t, dst), readData(t, src); !reflect.DeepEqual(got, want) {
+			t.Errorf("imported %v\nwant %v", got, want)
+		}
+		buf.Reset()
+		if err := ws.ExportTableCSV(dst, &buf); err != nil {
+			t.Fatal(err)
+		}
+		if buf.String() != exported {
+			t.Errorf("re-exported %q\nwant %q", buf.String(), exported)
+		}
+	})
+
+	t.Run("Columns", func(t *testing.T) {
+		// Columns in another order, an unknown column, a missing column and a
+		// short row.
+		id := newTable(t)
+		in := "Extra,Done,Name,Tags\nx,1,First,\"a, b\"\ny,false,Second\n"
+		if n, err := ws.ImportTableCSV(ctx, id, strings.NewReader(in), author); err != nil || n != 2 {
+			t.Fatalf("ImportTableCSV = %d, %v", n, err)
+		}
+		want := []map[string]any{
+			{"Name": "First", "Done": 1.0, "Tags": []any{"a", "b"}, "Total": nil},
+			{"Name": "Second", "Done": 0.0, "Total": nil},
+		}
+		if got := readData(t, id); !reflect.DeepEqual(got, want) {
+			t.Errorf("imported %v\nwant %v", got, want)
+		}
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		id := newTable(t)
+		for _, tc := range []struct {
+			name, in string
+			want     error
+		}{
+			{"Malformed", "Name,Count\n\"unterminated,1\n", nil},
+			{"BareQuote", "Name,Count\na\"b,1\n", nil},
+			{"TooManyCells", "Name\na,b\n", nil},
+			{"DuplicateColumn", "Name,Name\na,b\n", nil},
+			{"InvalidNumber", "Name,Count\nOK,1\nBad,abc\n", ErrInvalidRecord},
+			{"MissingRequired", "Count\n1\n", ErrInvalidRecord},
+		} {
+			t.Run(tc.name, func(t *testing.T) {
+				n, err := ws.ImportTableCSV(ctx, id, strings.NewReader(tc.in), author)
+				if err == nil || n != 0 {
+					t.Fatalf("ImportTableCSV = %d, %v, want error", n, err)
+				}
+				if tc.want != nil && !errors.Is(err, tc.want) {
+					t.Errorf("got %v, want %v", err, tc.want)
+				}
+				if got := readData(t, id); len(got) != 0 {
+					t.Errorf("records written: %v", got)
+				}
+			})
+		}
+	})
+
+	t.Run("Quota", func(t *testing.T) {
+		id := newTable(t)
+		saved := ws.quotas.MaxRecordsPerTable
+		ws.quotas.MaxRecordsPerTable = 2
+		t.Cleanup(func() { ws.quotas.MaxRecordsPerTable = saved })
+		if _, err := ws.ImportTableCSV(ctx, id, strings.NewReader("Name\na\nb\nc\n"), author); !errors.Is(err, ErrQuotaExceeded) {
+			t.Fatalf("got %v, want ErrQuotaExceeded", err)
+		}
+		if got := readData(t, id); len(got) != 0 {
+			t.Errorf("records written: %v", got)
+		}
+		if n, err := ws.ImportTableCSV(ctx, id, strings.NewReader("Name\na\nb\n"), author); err != nil || n != 2 {
+			t.Errorf("ImportTableCSV = %d, %v", n, err)
+		}
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		if err := ws.ExportTableCSV(ksid.NewID(), &bytes.Buffer{}); !errors.Is(err, ErrTableNotFound) {
+			t.Errorf("ExportTableCSV: got %v", err)
+		}
+		if _, err := ws.ImportTableCSV(ctx, ksid.NewID(), strings.NewReader("Name\na\n"), author); !errors.Is(err, ErrTableNotFound) {
+			t.Errorf("ImportTableCSV: got %v", err)
+		}
+	})
+}
+
+func TestCSVEscape(t *testing.T) {
+	for _, tc := range []struct{ in, want string }{
+		{"", ""},
+		{"plain", "plain"},
+		{"a=b", "a=b"},
+		{"=1+1", "'=1+1"},
+		{"+1", "'+1"},
+		{"-1", "'-1"},
+		{"@SUM(A1)", "'@SUM(A1)"},
+		{"\tx", "'\tx"},
+		{"\rx", "'\rx"},
+		{"'", "'"},
+		{"'quoted", "'quoted"},
+		{"'=1", "''=1"},
+		{"''-1", "'''-1"},
+	} {
+		if got := csvEscape(tc.in); got != tc.want {
+			t.Errorf("csvEscape(%q) = %q, want %q", tc.in, got, tc.want)
+		}
+		if got := csvUnescape(tc.want); got != tc.in {
+			t.Errorf("csvUnescape(%q) = %q, want %q", tc.want, got, tc.in)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+// Lists pages by the tags in their front matter.
+
+package content
+
+import (
+	"slices"
+)
+
+// ListByTag returns the nodes whose front matter lists tag, in tree order.
+// Tags are compared exactly.
+func (ws *WorkspaceFileStore) ListByTag(tag string) ([]*Node, error) {
+	pages, err := ws.IterPages()
+	if err != nil {
+		return nil, err
+	}
+	var out []*Node
+	for page := range pages {
+		if !slices.Contains(page.Tags, tag) {
+			continue
+		}
+		// Read the node again to report hybrid nodes with their table
+		// properties.
+		node, err := ws.ReadNode(page.ID)
+		if err != nil {
+			continue // node may have been deleted since it was listed
+		}
+		out = append(out, node)
+	}
+	return out, nil
+}
+
+// AllTags returns every tag used in the workspace with the number of pages
+// using it.
+func (ws *WorkspaceFileStore) AllTags() (map[string]int, error) {
+	pages, err := ws.IterPages()
+	if err != nil {
+		return nil, err
+	}
+	tags := map[string]int{}
+	for page := range pages {
+		// A tag listed twice on a page counts once.
+		seen := map[string]bool{}
+		for _, tag := range page.Tags {
+			if !seen[tag] {
+				seen[tag] = true
+				tags[tag]++
+			}
+		}
+	}
+	return tags, nil
+}
@@ -0,0 +1,155 @@
+package content
+
+import (
+	"errors"
+	"os"
+	"slices"
+	"testing"
+
+	"github.com/maruel/ksid"
+	"github.com/maruel/mddb/backend/internal/storage"
+	"github.com/maruel/mddb/backend/internal/storage/git"
+)
+
+func TestTags(t *testing.T) {
+	_, ws, _ := initWS(t)
+	ctx := t.Context()
+	author := git.Author{Name: "Test", Email: "test@test.com"}
+
+	// tagged creates a page and writes tags in its front matter.
+	tagged := func(title string, parentID ksid.ID, tags ...string) *Node {
+		t.Helper()
+		node, err := ws.CreatePageUnderParent(ctx, parentID, title, "body", author)
+		if err != nil {
+			t.Fatal(err)
+		}
+		now := storage.Now()
+		if err := ws.writePageFile(node.ID, parentID, &page{title: title, content: "body", created: now, modified: now, tags: tags}); err != nil {
+			t.Fatal(err)
+		}
+		return node
+	}
+	multi := tagged("Multi", 0, "go", "db", "go")
+	spaced := tagged("Spaced", multi.ID, "to do", "go")
+	untagged := tagged("Untagged", 0)
+
+	t.Run("ParseRoundTrip", func(t *testing.T) {
+		node, err := ws.ReadPage(spaced.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := []string{"to do", "go"}; !slices.Equal(node.Tags, want) {
+			t.Errorf("tags = %q, want %q", node.Tags, want)
+		}
+		if node.Content != "body" {
+			t.Errorf("content = %q", node.Content)
+		}
+		if node, err := ws.ReadNode(untagged.ID); err != nil || node.Tags != nil {
+			t.Errorf("untagged: %q, %v", node.Tags, err)
+		}
+		if got := parseLegacyFrontMatter("tags: a ,b c,, ").Tags; !slices.Equal(got, []string{"a", "b c"}) {
+			t.Errorf("legacy tags without brackets = %q", got)
+		}
+	})
+
+	t.Run("ListByTag", func(t *testing.T) {
+		for _, tc := range []struct {
+			tag  string
+			want []ksid.ID
+		}{
+			{"go", []ksid.ID{multi.ID, spaced.ID}},
+			{"to do", []ksid.ID{spaced.ID}},
+			{"to", nil},
+			{"", nil},
+		} {
+			nodes, err := ws.ListByTag(tc.tag)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var ids []ksid.ID
+			for _, n := range nodes {
+				ids = append(ids, n.ID)
+			}
+			if !slices.Equal(ids, tc.want) {
+				t.Errorf("ListByTag(%q) = %v, want %v", tc.tag, ids, tc.want)
+			}
+		}
+	})
+
+	t.Run("AllTags", func(t *testing.T) {
+		tags, err := ws.AllTags()
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := map[string]int{"go": 2, "db": 1, "to do": 1}
+		if len(tags) != len(want) {
+			t.Errorf("AllTags() = %v, want %v", tags, want)
+		}
+		for tag, n := range want {
+			if tags[tag] != n {
+				t.Errorf("AllTags()[%q] = %d, want %d", tag, tags[tag], n)
+			}
+		}
+	})
+
+	t.Run("PreservedOnUpdate", func(t *testing.T) {
+		if _, err := ws.UpdatePage(ctx, multi.ID, "Multi", "new body", author); err != nil {
+			t.Fatal(err)
+		}
+		node, err := ws.ReadPage(multi.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := []string{"go", "db", "go"}; !slices.Equal(node.Tags, want) {
+			t.Errorf("tags = %q, want %q", node.Tags, want)
+		}
+	})
+}
+
+func TestCreatePageWithMeta(t *testing.T) {
+	_, ws, _ := initWS(t)
+	ctx := t.Context()
+	author := git.Author{Name: "Test", Email: "test@test.com"}
+
+	before, err := ws.repo.CommitCount(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	meta := PageMeta{Tags: []string{"new", "go"}, Extra: map[string]any{"status": "draft"}}
+	node, err := ws.CreatePageWithMeta(ctx, 0, "Tagged", "body", meta, author)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(node.Tags, meta.Tags) {
+		t.Errorf("returned tags = %q, want %q", node.Tags, meta.Tags)
+	}
+	if after, err := ws.repo.CommitCount(ctx); err != nil || after != before+1 {
+		t.Errorf("commits: %d -> %d (err %v)", before, after, err)
+	}
+
+	nodes, err := ws.ListByTag("new")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(nodes) != 1 || nodes[0].ID != node.ID {
+		t.Fatalf("ListByTag(new) = %v", nodes)
+	}
+	read, err := ws.ReadPage(node.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(read.Tags, meta.Tags) || read.Content != "body" {
+		t.Errorf("ReadPage() tags %q content %q", read.Tags, read.Content)
+	}
+	data, err := os.ReadFile(ws.pageIndexFile(node.ID, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := ParseMarkdown(data).extra["status"]; got != "draft" {
+		t.Errorf("extra status = %v\n%s", got, data)
+	}
+
+	if _, err := ws.CreatePageWithMeta(ctx, 0, "Bad", "", PageMeta{Extra: map[string]any{"tags": "x"}}, author); !errors.Is(err, errReservedFrontMatter) {
+		t.Errorf("got %v, want errReservedFrontMatter", err)
+	}
+}
@@ -0,0 +1,191 @@
+// Moves nodes to and from the workspace trash.
+
+package content
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/maruel/ksid"
+	"github.com/maruel/mddb/backend/internal/storage"
+	"github.com/maruel/mddb/backend/internal/storage/git"
+)
+
+// trashDir is the directory of the workspace holding trashed nodes. Its name
+// is not an ID so the node tree walks never enter it.
+const trashDir = ".trash"
+
+// TrashEntry describes a node in the trash.
+type TrashEntry struct {
+	ID       ksid.ID      `json:"id"`
+	ParentID ksid.ID      `json:"parent_id,omitempty"` // Parent the node was trashed from; 0 for the root.
+	Title    string       `json:"title"`
+	Type     NodeType     `json:"type"`
+	Trashed  storage.Time `json:"trashed"`
+}
+
+// trashInfo is stored as .trash/<id>.json next to the trashed node directory.
+type trashInfo struct {
+	ParentID ksid.ID      `json:"parent_id,omitempty"`
+	Trashed  storage.Time `json:"trashed"`
+}
+
+// TrashNode moves a node, along with its descendants, to the workspace trash
+// and commits to git. RestoreNode brings it back; DeletePage remains the way
+// to delete a node permanently.
+func (ws *WorkspaceFileStore) TrashNode(ctx context.Context, id ksid.ID, author git.Author) error {
+	if id.IsZero() || (!ws.PageExists(id) && !ws.TableExists(id)) {
+		return ErrPageNotFound
+	}
+	parentID := ws.getParent(id)
+	oldRelDir := ws.relativeDir(id, parentID)
+
+	var trashed []ksid.ID
+	err := ws.repo.CommitTx(ctx, author, func() (string, []string, error) {
+		trashed = append([]ksid.ID{id}, subtreeIDs(filepath.Join(ws.wsDir, oldRelDir))...)
+		files, err := MoveToTrash(ws.wsDir, oldRelDir, id, parentID)
+		if err != nil {
+			return "", nil, err
+		}
+		for _, t := range trashed {
+			ws.deleteFromCache(t)
+		}
+		return commitMsg(author, "trash: node "+id.String(), "trash", "node", id), files, nil
+	})
+	if err == nil {
+		ws.pagesDeleted(trashed...)
+	}
+	return err
+}
+
+// MoveToTrash moves the node directory relDir of workspace directory wsDir
+// to the trash, recording parentID as where to restore it. It doesn't commit;
+// it returns the paths to commit, relative to wsDir.
+//
+// It is for writers working on the workspace directory without a store, like
+// the Notion importer; use WorkspaceFileStore.TrashNode otherwise.
+func MoveToTrash(wsDir, relDir string, id, parentID ksid.ID) ([]string, error) {
+	newRelDir := filepath.Join(trashDir, id.String())
+	infoRel := newRelDir + ".json"
+	info, err := json.Marshal(trashInfo{ParentID: parentID, Trashed: storage.Now()})
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Join(wsDir, trashDir), 0o755); err != nil { //nolint:gosec // G301: 0o755 is intentional for user data directories
+		return nil, fmt.Errorf("failed to create trash: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(wsDir, infoRel), info, 0o644); err != nil { //nolint:gosec // G306: 0o644 is intentional for user data files
+		return nil, fmt.Errorf("failed to write trash info: %w", err)
+	}
+	if err := os.Rename(filepath.Join(wsDir, relDir), filepath.Join(wsDir, newRelDir)); err != nil {
+		_ = os.Remove(filepath.Join(wsDir, infoRel))
+		return nil, fmt.Errorf("failed to trash node: %w", err)
+	}
+	return []string{relDir, newRelDir, infoRel}, nil
+}
+
+// ListTrash returns the nodes in the trash, most recently trashed first.
+// Descendants trashed along with a node are not listed separately.
+func (ws *WorkspaceFileStore) ListTrash() ([]*TrashEntry, error) {
+	dir := filepath.Join(ws.wsDir, trashDir)
+	ids, err := nodeDirs(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var out []*TrashEntry
+	for _, id := range ids {
+		info, err := ws.readTrashInfo(id)
+		if err != nil {
+			return nil, err
+		}
+		e := &TrashEntry{ID: id, ParentID: info.ParentID, Trashed: info.Trashed}
+		if node, err := ws.ReadNodeFromPath(filepath.Join(dir, id.String()), id, info.ParentID); err == nil {
+			e.Title = node.Title
+			e.Type = node.Type
+		}
+		out = append(out, e)
+	}
+	slices.SortStableFunc(out, func(a, b *TrashEntry) int { return cmp.Compare(b.Trashed, a.Trashed) })
+	return out, nil
+}
+
+// RestoreNode moves a node out of the trash back under the parent it was
+// trashed from, and commits to git. If that parent no longer exists, the node
+// is restored at the root.
+func (ws *WorkspaceFileStore) RestoreNode(ctx context.Context, id ksid.ID, author git.Author) (*Node, error) {
+	info, err := ws.readTrashInfo(id)
+	if err != nil {
+		return nil, err
+	}
+	oldRelDir := filepath.Join(trashDir, id.String())
+	infoRel := oldRelDir + ".json"
+	oldDir := filepath.Join(ws.wsDir, oldRelDir)
+	parentID := info.ParentID
+	if !parentID.IsZero() && !ws.PageExists(parentID) && !ws.TableExists(parentID) {
+		parentID = 0
+	}
+	height, err := subtreeHeight(oldDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to measure subtree: %w", err)
+	}
+	if err := ws.checkTreeLimits(parentID, height); err != nil {
+		return nil, err
+	}
+	newRelDir := ws.relativeDir(id, parentID)
+
+	err = ws.repo.CommitTx(ctx, author, func() (string, []string, error) {
+		newDir := filepath.Join(ws.wsDir, newRelDir)
+		if err := os.Rename(oldDir, newDir); err != nil {
+			return "", nil, fmt.Errorf("failed to restore node: %w", err)
+		}
+		if err := os.Remove(filepath.Join(ws.wsDir, infoRel)); err != nil {
+			_ = os.Rename(newDir, oldDir)
+			return "", nil, fmt.Errorf("failed to remove trash info: %w", err)
+		}
+		return commitMsg(author, "restore: node "+id.String()+" to parent "+parentID.String(), "restore", "node", id), []string{oldRelDir, infoRel, newRelDir}, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := ws.refreshCache(); err != nil {
+		return nil, err
+	}
+	dir := ws.pageDir(id, parentID)
+	var pages []*Node
+	for _, pid := range append([]ksid.ID{id}, subtreeIDs(dir)...) {
+		if page, err := ws.ReadPage(pid); err == nil {
+			pages = append(pages, page)
+		}
+	}
+	if len(pages) != 0 {
+		ws.pageWritten(pages...)
+	}
+	return ws.ReadNodeFromPath(dir, id, parentID)
+}
+
+// readTrashInfo reads where and when a trashed node was trashed from.
+func (ws *WorkspaceFileStore) readTrashInfo(id ksid.ID) (*trashInfo, error) {
+	if id.IsZero() {
+		return nil, ErrPageNotFound
+	}
+	data, err := os.ReadFile(filepath.Join(ws.wsDir, trashDir, id.String()+".json")) //nolint:gosec // G304: path is constructed from validated id
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrPageNotFound
+		}
+		return nil, fmt.Errorf("failed to read trash info: %w", err)
+	}
+	info := &trashInfo{}
+	if err := json.Unmarshal(data, info); err != nil {
+		return nil, fmt.Errorf("failed to parse trash info: %w", err)
+	}
+	return info, nil
+}
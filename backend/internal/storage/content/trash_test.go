@@ -0,0 +1,132 @@
+package content
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/maruel/ksid"
+	"github.com/maruel/mddb/backend/internal/storage/git"
+)
+
+func TestTrash(t *testing.T) {
+	author := git.Author{Name: "Test", Email: "test@test.com"}
+
+	// setup creates parent > node > child and returns them.
+	setup := func(t *testing.T) (ws *WorkspaceFileStore, parent, node, child *Node) {
+		_, ws, _ = initWS(t)
+		ctx := t.Context()
+		var err error
+		if parent, err = ws.CreatePageUnderParent(ctx, 0, "Parent", "", author); err != nil {
+			t.Fatal(err)
+		}
+		if node, err = ws.CreatePageUnderParent(ctx, parent.ID, "Node", "node body", author); err != nil {
+			t.Fatal(err)
+		}
+		if child, err = ws.CreatePageUnderParent(ctx, node.ID, "Child", "child body", author); err != nil {
+			t.Fatal(err)
+		}
+		return ws, parent, node, child
+	}
+	pageIDs := func(t *testing.T, ws *WorkspaceFileStore) map[ksid.ID]bool {
+		it, err := ws.IterPages()
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids := map[ksid.ID]bool{}
+		for n := range it {
+			ids[n.ID] = true
+		}
+		return ids
+	}
+
+	t.Run("TrashAndRestore", func(t *testing.T) {
+		ws, parent, node, child := setup(t)
+		ctx := t.Context()
+		if err := ws.TrashNode(ctx, node.ID, author); err != nil {
+			t.Fatal(err)
+		}
+
+		children, err := ws.ListChildren(parent.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(children) != 0 {
+			t.Errorf("trashed node still listed: %+v", children)
+		}
+		if roots, err := ws.ListChildren(0); err != nil || len(roots) != 1 {
+			t.Errorf("unexpected roots: %+v, %v", roots, err)
+		}
+		if ids := pageIDs(t, ws); ids[node.ID] || ids[child.ID] || !ids[parent.ID] {
+			t.Errorf("unexpected pages: %v", ids)
+		}
+		if _, err := ws.ReadPage(child.ID); !errors.Is(err, ErrPageNotFound) {
+			t.Errorf("ReadPage on a trashed node: %v", err)
+		}
+		trash, err := ws.ListTrash()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(trash) != 1 || trash[0].ID != node.ID || trash[0].ParentID != parent.ID || trash[0].Title != "Node" || trash[0].Type != NodeTypeDocument {
+			t.Fatalf("unexpected trash: %+v", trash)
+		}
+
+		restored, err := ws.RestoreNode(ctx, node.ID, author)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if restored.ParentID != parent.ID {
+			t.Errorf("restored under %s, want %s", restored.ParentID, parent.ID)
+		}
+		page, err := ws.ReadPage(child.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if page.ParentID != node.ID || page.Content != "child body" {
+			t.Errorf("unexpected child after restore: %+v", page)
+		}
+		if trash, err := ws.ListTrash(); err != nil || len(trash) != 0 {
+			t.Errorf("trash not emptied: %+v, %v", trash, err)
+		}
+		if _, err := ws.RestoreNode(ctx, node.ID, author); !errors.Is(err, ErrPageNotFound) {
+			t.Errorf("second restore: %v", err)
+		}
+	})
+
+	t.Run("RestoreToRoot", func(t *testing.T) {
+		ws, parent, node, child := setup(t)
+		ctx := t.Context()
+		if err := ws.TrashNode(ctx, node.ID, author); err != nil {
+			t.Fatal(err)
+		}
+		if err := ws.DeletePage(ctx, parent.ID, author); err != nil {
+			t.Fatal(err)
+		}
+		restored, err := ws.RestoreNode(ctx, node.ID, author)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !restored.ParentID.IsZero() {
+			t.Errorf("restored under %s, want root", restored.ParentID)
+		}
+		roots, err := ws.ListChildren(0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(roots) != 1 || roots[0].ID != node.ID || !roots[0].HasChildren {
+			t.Errorf("unexpected roots: %+v", roots)
+		}
+		if ids := pageIDs(t, ws); !ids[node.ID] || !ids[child.ID] {
+			t.Errorf("unexpected pages: %v", ids)
+		}
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		ws, _, _, _ := setup(t)
+		if err := ws.TrashNode(t.Context(), ksid.NewID(), author); !errors.Is(err, ErrPageNotFound) {
+			t.Errorf("trash missing node: %v", err)
+		}
+		if _, err := ws.RestoreNode(t.Context(), ksid.NewID(), author); !errors.Is(err, ErrPageNotFound) {
+			t.Errorf("restore missing node: %v", err)
+		}
+	})
+}
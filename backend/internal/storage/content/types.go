@@ -15,6 +15,7 @@ type Node struct {
 	Content     string       `json:"content,omitempty" jsonschema:"description=Markdown content (Page part)"`
 	Properties  []Property   `json:"properties,omitempty" jsonschema:"description=Schema definition (Table part)"`
 	Views       []View       `json:"views,omitempty" jsonschema:"description=Saved view configurations (Table part)"`
+	LaxRecords  bool         `json:"lax_records,omitempty" jsonschema:"description=Skip validating records against the property schema (Table part)"`
 	Created     storage.Time `json:"created" jsonschema:"description=Node creation timestamp"`
 	Modified    storage.Time `json:"modified" jsonschema:"description=Last modification timestamp"`
 	Tags        []string     `json:"tags,omitempty" jsonschema:"description=Node tags for categorization"`
@@ -104,28 +105,16 @@ type Asset struct {
 	MimeType string       `json:"mime_type" jsonschema:"description=MIME type of the asset"`
 	Size     int64        `json:"size" jsonschema:"description=File size in bytes"`
 	Created  storage.Time `json:"created" jsonschema:"description=Upload timestamp"`
-	Path     string       `json:"path" jsonschema:"description=Storage path on disk"`
+	Path     string       `json:"path,omitempty" jsonschema:"description=Storage path on disk for assets stored as plain files"`
 }
 
-// SearchResult represents a single search result.
-type SearchResult struct {
-	Type     string            `json:"type" jsonschema:"description=Result type (page or record)"`
-	NodeID   ksid.ID           `json:"node_id" jsonschema:"description=Node containing the result"`
-	RecordID ksid.ID           `json:"record_id,omitempty" jsonschema:"description=Record ID if result is a table record"`
-	Title    string            `json:"title" jsonschema:"description=Title of the matched item"`
-	Snippet  string            `json:"snippet" jsonschema:"description=Text snippet with match context"`
-	Score    float64           `json:"score" jsonschema:"description=Relevance score"`
-	Matches  map[string]string `json:"matches" jsonschema:"description=Matched fields and their values"`
-	Modified storage.Time      `json:"modified" jsonschema:"description=Last modification timestamp"`
-}
-
-// SearchOptions defines parameters for a search.
-type SearchOptions struct {
-	Query       string `json:"query" jsonschema:"description=Search query string"`
-	Limit       int    `json:"limit,omitempty" jsonschema:"description=Maximum number of results"`
-	MatchTitle  bool   `json:"match_title,omitempty" jsonschema:"description=Search in titles"`
-	MatchBody   bool   `json:"match_body,omitempty" jsonschema:"description=Search in body content"`
-	MatchFields bool   `json:"match_fields,omitempty" jsonschema:"description=Search in table fields"`
+// SearchHit is a page matching a search query.
+type SearchHit struct {
+	NodeID   ksid.ID      `json:"node_id" jsonschema:"description=Matching page"`
+	Title    string       `json:"title" jsonschema:"description=Title of the page"`
+	Snippet  string       `json:"snippet" jsonschema:"description=Body text around the first match"`
+	Score    float64      `json:"score" jsonschema:"description=Relevance score, higher is better"`
+	Modified storage.Time `json:"modified" jsonschema:"description=Last modification timestamp"`
 }
 
 // PropertyType represents the type of a table property.
@@ -241,7 +230,8 @@ const (
 
 // FormulaConfig defines a computed property expression.
 type FormulaConfig struct {
-	// Expression is the formula expression (stored for reference, not evaluated).
+	// Expression is evaluated against the other properties of each record when
+	// records are read; see formula.go for the grammar.
 	Expression string `json:"expression" jsonschema:"description=Formula expression"`
 }
 
@@ -5,54 +5,110 @@ package content
 
 import (
 	"bytes"
+	"cmp"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"iter"
 	"log/slog"
+	"maps"
 	"mime"
+	"net/url"
 	"os"
+	"path"
 	"path/filepath"
 	"regexp"
 	"slices"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
+	"unicode"
 
 	"github.com/maruel/ksid"
 	"github.com/maruel/mddb/backend/internal/jsonldb"
 	"github.com/maruel/mddb/backend/internal/storage"
 	"github.com/maruel/mddb/backend/internal/storage/git"
+	"github.com/maruel/mddb/backend/internal/storage/identity"
 )
 
 // relativeLinkRe matches markdown links ending in /index.md (relative file paths on disk).
 // Group 1: link text, Group 2: relative href ending in /index.md.
 var relativeLinkRe = regexp.MustCompile(`\[([^\]]*)\]\(([^)]+/index\.md)\)`)
 
+// assetLinkRe matches markdown images and links. Group 1: href.
+var assetLinkRe = regexp.MustCompile(`!?\[[^\]]*\]\(([^)\s]+)\)`)
+
 // WorkspaceFileStore is a versioned file storage system for a single workspace.
 // All mutations are committed to git.
 // Storage model: Each page (document or table) is an ID-based directory within the workspace.
 //   - Pages: ID directory containing index.md with YAML front matter.
 //   - Tables: ID directory containing metadata.json + data.jsonl.
-//   - Assets: files within each page's directory namespace.
+//   - Assets: rows of the workspace assets.jsonl table, their bytes in its
+//     blob directory. Older assets are plain files in the page directory.
 type WorkspaceFileStore struct {
-	wsDir  string                  // Pre-computed: rootDir/wsID
-	repo   git.Repository          // Cached git repository
-	quotas *storage.ResourceQuotas // Effective quotas (min of server/org/ws)
-	mu     sync.RWMutex            // Protects cache
-	cache  map[ksid.ID]ksid.ID     // nodeID -> parentID
-	links  linkCache               // In-memory backlink index
+	wsID      ksid.ID
+	wsDir     string                  // Pre-computed: rootDir/wsID
+	repo      git.Repository          // Cached git repository
+	quotas    *storage.ResourceQuotas // Effective quotas (min of server/org/ws)
+	checkDisk func(int64) error       // Host free disk space check
+	mu        sync.RWMutex            // Protects cache, dirs and dirsGen
+	cache     map[ksid.ID]ksid.ID     // nodeID -> parentID
+	dirs      map[ksid.ID]string      // nodeID -> relative directory, memoized from cache
+	dirsGen   uint64                  // Incremented when dirs is reset
+	links     linkCache               // Backlink index
+	assetsMu  sync.Mutex              // Protects assets
+	assets    *assetTable             // Opened on first use
+	observers func() []PageObserver   // Notified after page changes; may be nil
+	wsSvc     *identity.WorkspaceService
+}
+
+// PageObserver receives notifications about committed page changes.
+//
+// Observers are called synchronously after the git commit succeeded, without
+// any store lock held.
+type PageObserver interface {
+	// OnPageWrite is called after a page was created or its title or content
+	// changed.
+	OnPageWrite(wsID ksid.ID, node *Node)
+	// OnPageDelete is called after a page was deleted.
+	OnPageDelete(wsID, id ksid.ID)
+	// OnPagesReloaded is called when pages may have changed on disk without
+	// individual notifications, e.g. when the store is re-opened or after an
+	// import.
+	OnPagesReloaded(wsID ksid.ID)
 }
 
 // newWorkspaceFileStore creates a new workspace store.
 // This is called internally by FileStoreService.GetWorkspaceStore.
-func newWorkspaceFileStore(wsDir string, repo git.Repository, quotas *storage.ResourceQuotas) *WorkspaceFileStore {
+func newWorkspaceFileStore(wsID ksid.ID, wsDir string, repo git.Repository, quotas *storage.ResourceQuotas, checkDisk func(int64) error, observers func() []PageObserver, wsSvc *identity.WorkspaceService) *WorkspaceFileStore {
 	return &WorkspaceFileStore{
-		wsDir:  wsDir,
-		repo:   repo,
-		quotas: quotas,
-		cache:  make(map[ksid.ID]ksid.ID),
+		wsID:      wsID,
+		wsDir:     wsDir,
+		repo:      repo,
+		quotas:    quotas,
+		checkDisk: checkDisk,
+		cache:     make(map[ksid.ID]ksid.ID),
+		dirs:      make(map[ksid.ID]string),
+		links:     linkCache{file: linkIndexFile(wsDir)},
+		observers: observers,
+		wsSvc:     wsSvc,
+	}
+}
+
+// linkIndexFile returns where the backlink index of the workspace is saved.
+// It lives in the git directory so that it is neither committed nor exported.
+// Returns "" if wsDir has no git directory.
+func linkIndexFile(wsDir string) string {
+	gitDir := filepath.Join(wsDir, ".git")
+	if fi, err := os.Stat(gitDir); err != nil || !fi.IsDir() {
+		return ""
 	}
+	return filepath.Join(gitDir, "mddb-links.json")
 }
 
 // EffectiveQuotas returns the effective resource quotas for this workspace.
@@ -65,6 +121,7 @@ func (ws *WorkspaceFileStore) refreshCache() error {
 	ws.mu.Lock()
 	defer ws.mu.Unlock()
 	ws.cache = make(map[ksid.ID]ksid.ID)
+	ws.resetDirsLocked()
 	return ws.walkDirForCache(ws.wsDir, 0)
 }
 
@@ -121,7 +178,17 @@ func (ws *WorkspaceFileStore) getParent(id ksid.ID) ksid.ID {
 func (ws *WorkspaceFileStore) setParent(id, parentID ksid.ID) {
 	ws.mu.Lock()
 	defer ws.mu.Unlock()
-	ws.cache[id] = parentID
+	p, ok := ws.cache[id]
+	switch {
+	case !ok:
+		// A new node doesn't change the directory of the others. A directory
+		// computed while it was unknown must not be memoized though.
+		ws.cache[id] = parentID
+		ws.dirsGen++
+	case p != parentID:
+		ws.cache[id] = parentID
+		ws.resetDirsLocked()
+	}
 }
 
 // deleteFromCache removes a node from the cache.
@@ -129,6 +196,88 @@ func (ws *WorkspaceFileStore) deleteFromCache(id ksid.ID) {
 	ws.mu.Lock()
 	defer ws.mu.Unlock()
 	delete(ws.cache, id)
+	delete(ws.dirs, id)
+	ws.dirsGen++
+}
+
+// resetDirsLocked forgets the memoized directories after the parent of an
+// existing node changed; a move changes the directory of the whole subtree.
+// Caller must hold ws.mu for writing.
+func (ws *WorkspaceFileStore) resetDirsLocked() {
+	if len(ws.dirs) != 0 {
+		ws.dirs = make(map[ksid.ID]string)
+	}
+	ws.dirsGen++
+}
+
+// nodeDir returns the directory of node id relative to the workspace, "" for
+// the root. It walks the parent chain once per node and memoizes the result
+// until the parent cache changes, so deep nodes do not cost a walk on every
+// path computation.
+func (ws *WorkspaceFileStore) nodeDir(id ksid.ID) string {
+	if id.IsZero() {
+		return ""
+	}
+	ws.mu.RLock()
+	dir, ok := ws.dirs[id]
+	gen := ws.dirsGen
+	ws.mu.RUnlock()
+	if ok {
+		return dir
+	}
+	dir = filepath.Join(ws.nodeDir(ws.getParent(id)), id.String())
+	ws.mu.Lock()
+	// Only memoize known nodes, and not if the cache changed meanwhile.
+	if _, known := ws.cache[id]; known && gen == ws.dirsGen {
+		ws.dirs[id] = dir
+	}
+	ws.mu.Unlock()
+	return dir
+}
+
+// pageWritten updates the backlink index and notifies observers after page
+// writes were committed.
+func (ws *WorkspaceFileStore) pageWritten(nodes ...*Node) {
+	for _, node := range nodes {
+		ws.links.update(node.ID, node.Content)
+	}
+	ws.links.save()
+	if ws.observers == nil {
+		return
+	}
+	for _, o := range ws.observers() {
+		for _, node := range nodes {
+			o.OnPageWrite(ws.wsID, node)
+		}
+	}
+}
+
+// pagesDeleted updates the backlink index and notifies observers after the
+// deletion of pages was committed.
+func (ws *WorkspaceFileStore) pagesDeleted(ids ...ksid.ID) {
+	for _, id := range ids {
+		ws.links.remove(id)
+	}
+	ws.links.save()
+	if ws.observers == nil {
+		return
+	}
+	for _, o := range ws.observers() {
+		for _, id := range ids {
+			o.OnPageDelete(ws.wsID, id)
+		}
+	}
+}
+
+// pagesReloaded notifies observers that pages may have changed without going
+// through the store.
+func (ws *WorkspaceFileStore) pagesReloaded() {
+	if ws.observers == nil {
+		return
+	}
+	for _, o := range ws.observers() {
+		o.OnPagesReloaded(ws.wsID)
+	}
 }
 
 // checkPageQuota returns an error if creating a new page would exceed quota.
@@ -139,38 +288,199 @@ func (ws *WorkspaceFileStore) checkPageQuota() error {
 		return err
 	}
 	if count >= ws.quotas.MaxPages {
-		return errQuotaExceeded
+		return ErrQuotaExceeded
 	}
 	return nil
 }
 
-// checkStorageQuota returns an error if adding the given bytes would exceed workspace storage quota.
+// CheckStorageQuota returns ErrQuotaExceeded if adding the given bytes would exceed workspace storage quota,
+// or ErrDiskFull if the host disk is nearly full.
 // MaxStorageBytes=0 means disabled (0 bytes allowed). Server always provides a positive floor.
-func (ws *WorkspaceFileStore) checkStorageQuota(additionalBytes int64) error {
+func (ws *WorkspaceFileStore) CheckStorageQuota(additionalBytes int64) error {
 	_, usage, err := ws.GetWorkspaceUsage()
 	if err != nil {
 		return err
 	}
-	if usage+additionalBytes > ws.quotas.MaxStorageBytes {
-		return errQuotaExceeded
+	if exceedsBytes(usage, additionalBytes, ws.quotas.MaxStorageBytes) {
+		return ErrQuotaExceeded
+	}
+	return ws.checkDisk(additionalBytes)
+}
+
+// checkBulkQuotas returns an error if adding pages, tables and size bytes at
+// once would exceed the page, table or storage quota. Tree limits depend on
+// where the nodes go and are checked by the callers.
+func (ws *WorkspaceFileStore) checkBulkQuotas(pages, tables int, size int64) error {
+	count, _, err := ws.GetWorkspaceUsage()
+	if err != nil {
+		return err
+	}
+	if count+pages > ws.quotas.MaxPages {
+		return ErrQuotaExceeded
+	}
+	if tables > 0 {
+		it, err := ws.IterTables()
+		if err != nil {
+			return err
+		}
+		for range it {
+			tables++
+		}
+		if tables > ws.quotas.MaxTablesPerWorkspace {
+			return ErrTableQuotaExceeded
+		}
+	}
+	return ws.CheckStorageQuota(size)
+}
+
+// exceedsBytes reports whether adding additional bytes to usage goes over
+// limit. Reaching the limit exactly is allowed. All storage quotas, workspace,
+// organization and server, are checked with it.
+func exceedsBytes(usage, additional, limit int64) bool {
+	return usage+additional > limit
+}
+
+// excludedFromUsage reports whether the directory name is left out of storage
+// accounting. Only git internals are: blob directories hold the content of
+// records and assets and are counted like any other file.
+func excludedFromUsage(name string) bool {
+	return name == ".git"
+}
+
+// dirUsage returns the total size in bytes of the files under dir, excluding
+// .git directories. Unreadable entries are skipped.
+func dirUsage(dir string) (int64, error) {
+	var total int64
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil //nolint:nilerr // skip transient errors (e.g. git maintenance.lock race)
+		}
+		if info.IsDir() && excludedFromUsage(info.Name()) {
+			return filepath.SkipDir
+		}
+		if !info.IsDir() {
+			total += info.Size()
+		}
+		return nil
+	})
+	return total, err
+}
+
+// checkTreeLimits returns an error if attaching a subtree of the given height under
+// parentID would exceed MaxNodeDepth or give parentID more than MaxChildrenPerNode
+// children. Use height 1 for a single new node. Top-level nodes have depth 1.
+func (ws *WorkspaceFileStore) checkTreeLimits(parentID ksid.ID, height int) error {
+	depth := 0
+	if !parentID.IsZero() {
+		depth = strings.Count(ws.nodeDir(parentID), string(filepath.Separator)) + 1
+	}
+	if depth+height > ws.quotas.MaxNodeDepth {
+		return ErrNodeDepthExceeded
+	}
+	dir := ws.wsDir
+	if !parentID.IsZero() {
+		dir = ws.pageDir(parentID, ws.getParent(parentID))
+	}
+	children, err := countChildNodes(dir)
+	if err != nil {
+		return err
+	}
+	if children >= ws.quotas.MaxChildrenPerNode {
+		return ErrTooManyChildren
 	}
 	return nil
 }
 
+// countChildNodes returns the number of node directories directly inside dir.
+func countChildNodes(dir string) (int, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, nil
+		}
+		return 0, err
+	}
+	n := 0
+	for _, entry := range entries {
+		if isNodeDir(entry) {
+			n++
+		}
+	}
+	return n, nil
+}
+
+// isNodeDir reports whether entry is a node directory.
+//
+// The name must be the canonical encoding of an ID so that lowercase
+// directories like "pages" that happen to decode are not counted.
+func isNodeDir(entry os.DirEntry) bool {
+	if !entry.IsDir() {
+		return false
+	}
+	id, err := ksid.Parse(entry.Name())
+	return err == nil && id.String() == entry.Name()
+}
+
+// nodeDirs returns the IDs of the node directories in dir sorted by ID, which
+// is creation order. os.ReadDir sorts by name, which differs from ID order
+// since encoded IDs have no leading zeros.
+func nodeDirs(dir string) ([]ksid.ID, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var ids []ksid.ID
+	for _, entry := range entries {
+		if isNodeDir(entry) {
+			id, _ := ksid.Parse(entry.Name())
+			ids = append(ids, id)
+		}
+	}
+	slices.Sort(ids)
+	return ids, nil
+}
+
+// subtreeIDs returns the IDs of all nodes below dir, recursively.
+func subtreeIDs(dir string) []ksid.ID {
+	ids, err := nodeDirs(dir)
+	if err != nil {
+		return nil
+	}
+	out := ids
+	for _, id := range ids {
+		out = append(out, subtreeIDs(filepath.Join(dir, id.String()))...)
+	}
+	return out
+}
+
+// subtreeHeight returns the number of levels in the node tree rooted at dir,
+// counting the node itself as 1.
+func subtreeHeight(dir string) (int, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return 0, err
+	}
+	deepest := 0
+	for _, entry := range entries {
+		if !isNodeDir(entry) {
+			continue
+		}
+		h, err := subtreeHeight(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return 0, err
+		}
+		deepest = max(deepest, h)
+	}
+	return deepest + 1, nil
+}
+
 // Path helpers
 
 // relativeDir returns the relative directory path for a node.
 // Top-level nodes (parentID=0) are stored directly in workspace dir.
 // Nested nodes include their parent chain in the path.
 func (ws *WorkspaceFileStore) relativeDir(id, parentID ksid.ID) string {
-	// Build path from parent chain, stopping at workspace level (parentID=0).
-	var parts []string
-	for p := parentID; !p.IsZero(); p = ws.getParent(p) {
-		parts = append(parts, p.String())
-	}
-	slices.Reverse(parts)
-	parts = append(parts, id.String())
-	return filepath.Join(parts...)
+	return filepath.Join(ws.nodeDir(parentID), id.String())
 }
 
 // pageDir returns the absolute directory path for a node.
@@ -202,6 +512,17 @@ func (ws *WorkspaceFileStore) gitPath(parentID, id ksid.ID, fileName string) str
 	return filepath.Join(rel, fileName)
 }
 
+// commitMsg appends the mddb trailers to a human-readable commit summary so
+// tooling can parse the operation without relying on the summary format.
+func commitMsg(author git.Author, summary, op, kind string, nodeID ksid.ID) string {
+	return git.AppendTrailers(summary,
+		git.Trailer{Key: git.TrailerOp, Value: op},
+		git.Trailer{Key: git.TrailerNode, Value: nodeID.String()},
+		git.Trailer{Key: git.TrailerType, Value: kind},
+		git.Trailer{Key: git.TrailerActor, Value: author.ID},
+	)
+}
+
 // PageExists checks if a page exists.
 func (ws *WorkspaceFileStore) PageExists(id ksid.ID) bool {
 	if id.IsZero() {
@@ -221,7 +542,7 @@ func (ws *WorkspaceFileStore) ReadPage(id ksid.ID) (*Node, error) {
 	data, err := os.ReadFile(filePath) //nolint:gosec // G304: filePath is constructed from validated id
 	if err != nil {
 		if os.IsNotExist(err) {
-			return nil, errPageNotFound
+			return nil, ErrPageNotFound
 		}
 		return nil, fmt.Errorf("failed to read page: %w", err)
 	}
@@ -232,9 +553,10 @@ func (ws *WorkspaceFileStore) ReadPage(id ksid.ID) (*Node, error) {
 		ParentID: parentID,
 		Title:    p.title,
 		Type:     NodeTypeDocument,
-		Content:  p.content,
+		Content:  ws.normalizeLinks(id, parentID, p.content),
 		Created:  p.created,
 		Modified: p.modified,
+		Tags:     p.tags,
 		Icon:     p.icon,
 		Cover:    p.cover,
 	}, nil
@@ -242,6 +564,9 @@ func (ws *WorkspaceFileStore) ReadPage(id ksid.ID) (*Node, error) {
 
 // WritePage writes a page and commits to git.
 func (ws *WorkspaceFileStore) WritePage(ctx context.Context, id, parentID ksid.ID, title, content string, author git.Author) (*Node, error) {
+	if err := checkTitle(title); err != nil {
+		return nil, err
+	}
 	var node *Node
 	err := ws.repo.CommitTx(ctx, author, func() (string, []string, error) {
 		var err error
@@ -250,10 +575,10 @@ func (ws *WorkspaceFileStore) WritePage(ctx context.Context, id, parentID ksid.I
 			return "", nil, err
 		}
 		files := []string{ws.gitPath(parentID, id, "index.md")}
-		return "update: page " + id.String(), files, nil
+		return commitMsg(author, "update: page "+id.String(), "update", string(NodeTypeDocument), id), files, nil
 	})
 	if err == nil {
-		ws.links.update(id, node.Content)
+		ws.pageWritten(node)
 	}
 	return node, err
 }
@@ -262,6 +587,7 @@ func (ws *WorkspaceFileStore) WritePage(ctx context.Context, id, parentID ksid.I
 // Returns the Node (with disk content) and an error.
 func (ws *WorkspaceFileStore) writePage(id, parentID ksid.ID, title, content string) (*Node, error) {
 	now := storage.Now()
+	content = ws.normalizeLinks(id, parentID, content)
 	p := &page{
 		title:    title,
 		content:  content,
@@ -302,21 +628,66 @@ func (ws *WorkspaceFileStore) writePageFile(id, parentID ksid.ID, p *page) error
 
 // UpdatePage updates a page and commits to git.
 func (ws *WorkspaceFileStore) UpdatePage(ctx context.Context, id ksid.ID, title, content string, author git.Author) (*Node, error) {
+	node, _, err := ws.UpdatePageWithOptions(ctx, id, title, content, UpdatePageOptions{}, author)
+	return node, err
+}
+
+// UpdatePageOptions configures UpdatePageWithOptions.
+type UpdatePageOptions struct {
+	// ExpectedETag, when not empty, makes the update only happen if the
+	// page's current NodeETag matches it; otherwise a *ConflictError carrying
+	// the current page is returned. Empty overwrites unconditionally.
+	ExpectedETag string
+}
+
+// UpdatePageWithOptions updates a page as configured by opts and commits to
+// git. It returns the updated page along with its new NodeETag, computed
+// under the same lock as the write.
+func (ws *WorkspaceFileStore) UpdatePageWithOptions(ctx context.Context, id ksid.ID, title, content string, opts UpdatePageOptions, author git.Author) (*Node, string, error) {
+	if err := checkTitle(title); err != nil {
+		return nil, "", err
+	}
 	var node *Node
+	var etag string
 	err := ws.repo.CommitTx(ctx, author, func() (string, []string, error) {
+		if opts.ExpectedETag != "" {
+			if err := ws.checkPageETag(id, opts.ExpectedETag); err != nil {
+				return "", nil, err
+			}
+		}
 		var err error
-		node, err = ws.updatePage(id, title, content)
-		if err != nil {
+		if node, err = ws.updatePage(id, title, content); err != nil {
+			return "", nil, err
+		}
+		if etag, err = ws.NodeETag(id); err != nil {
 			return "", nil, err
 		}
 		parentID := ws.getParent(id)
 		files := []string{ws.gitPath(parentID, id, "index.md")}
-		return "update: page " + id.String(), files, nil
+		return commitMsg(author, "update: page "+id.String(), "update", string(NodeTypeDocument), id), files, nil
 	})
-	if err == nil {
-		ws.links.update(id, node.Content)
+	if err != nil {
+		return nil, "", err
 	}
-	return node, err
+	ws.pageWritten(node)
+	return node, etag, nil
+}
+
+// checkPageETag returns a *ConflictError carrying the current page when the
+// entity tag of node id is not expectedETag.
+func (ws *WorkspaceFileStore) checkPageETag(id ksid.ID, expectedETag string) error {
+	etag, err := ws.NodeETag(id)
+	if err != nil {
+		return err
+	}
+	if etag == expectedETag {
+		return nil
+	}
+	current, err := ws.ReadPage(id)
+	if err != nil {
+		return err
+	}
+	return &ConflictError{Current: current, ETag: etag}
 }
 
 // updatePage updates a page without committing.
@@ -327,14 +698,14 @@ func (ws *WorkspaceFileStore) updatePage(id ksid.ID, title, content string) (*No
 	data, err := os.ReadFile(filePath) //nolint:gosec // G304: filePath is constructed from validated id
 	if err != nil {
 		if os.IsNotExist(err) {
-			return nil, errPageNotFound
+			return nil, ErrPageNotFound
 		}
 		return nil, fmt.Errorf("failed to read page: %w", err)
 	}
 
 	p := ParseMarkdown(data)
 	p.title = title
-	p.content = content
+	p.content = ws.normalizeLinks(id, parentID, content)
 	p.modified = storage.Now()
 
 	if err := ws.writePageFile(id, parentID, p); err != nil {
@@ -346,7 +717,7 @@ func (ws *WorkspaceFileStore) updatePage(id ksid.ID, title, content string) (*No
 		ParentID: parentID,
 		Title:    title,
 		Type:     NodeTypeDocument,
-		Content:  content,
+		Content:  p.content,
 		Created:  p.created,
 		Modified: p.modified,
 	}, nil
@@ -363,7 +734,7 @@ func (ws *WorkspaceFileStore) UpdatePageFrontmatter(ctx context.Context, id ksid
 		data, err := os.ReadFile(filePath) //nolint:gosec // G304: filePath is constructed from validated id
 		if err != nil {
 			if os.IsNotExist(err) {
-				return "", nil, errPageNotFound
+				return "", nil, ErrPageNotFound
 			}
 			return "", nil, fmt.Errorf("failed to read page: %w", err)
 		}
@@ -388,24 +759,143 @@ func (ws *WorkspaceFileStore) UpdatePageFrontmatter(ctx context.Context, id ksid
 			Cover:    p.cover,
 		}
 		files := []string{ws.gitPath(parentID, id, "index.md")}
-		return "update: frontmatter " + id.String(), files, nil
+		return commitMsg(author, "update: frontmatter "+id.String(), "update", string(NodeTypeDocument), id), files, nil
+	})
+	return node, err
+}
+
+// RenameNode changes the title of a node and commits to git.
+//
+// For pages only the title and modified lines of the front matter are
+// rewritten; the body, created time and other fields are kept byte for byte.
+// For tables and hybrid nodes the metadata.json title is updated too, in the
+// same commit.
+func (ws *WorkspaceFileStore) RenameNode(ctx context.Context, id ksid.ID, title string, author git.Author) (*Node, error) {
+	if err := checkTitle(title); err != nil {
+		return nil, err
+	}
+	var node *Node
+	err := ws.repo.CommitTx(ctx, author, func() (string, []string, error) {
+		parentID := ws.getParent(id)
+		now := storage.Now()
+		var files []string
+		// restore undoes the writes done so far if a later one fails.
+		var restore []func()
+		fail := func(err error) (string, []string, error) {
+			for _, r := range restore {
+				r()
+			}
+			return "", nil, err
+		}
+
+		kind := NodeTypeDocument
+		pagePath := ws.pageIndexFile(id, parentID)
+		pageData, err := os.ReadFile(pagePath) //nolint:gosec // G304: pagePath is constructed from validated id
+		switch {
+		case err == nil:
+			p := ParseMarkdown(pageData)
+			node = &Node{ID: id, ParentID: parentID, Title: title, Type: NodeTypeDocument, Content: p.content, Created: p.created, Modified: now, Icon: p.icon, Cover: p.cover}
+			if err := os.WriteFile(pagePath, renameFrontMatter(pageData, title, now), 0o644); err != nil { //nolint:gosec // G306: 0o644 is intentional for user data files
+				return fail(fmt.Errorf("failed to write page: %w", err))
+			}
+			restore = append(restore, func() { _ = os.WriteFile(pagePath, pageData, 0o644) }) //nolint:gosec // G306: 0o644 is intentional for user data files
+			files = append(files, ws.gitPath(parentID, id, "index.md"))
+		case !os.IsNotExist(err):
+			return fail(fmt.Errorf("failed to read page: %w", err))
+		}
+
+		metadataPath := ws.tableMetadataFile(id, parentID)
+		metadataData, err := os.ReadFile(metadataPath) //nolint:gosec // G304: metadataPath is constructed from validated id
+		switch {
+		case err == nil:
+			var metadata map[string]any
+			if err := json.Unmarshal(metadataData, &metadata); err != nil {
+				return fail(fmt.Errorf("failed to parse table metadata: %w", err))
+			}
+			metadata["title"] = title
+			metadata["modified"] = now
+			data, err := json.Marshal(metadata)
+			if err != nil {
+				return fail(fmt.Errorf("failed to marshal metadata: %w", err))
+			}
+			if err := jsonldb.WriteFileDurable(metadataPath, data); err != nil {
+				return fail(fmt.Errorf("failed to write metadata: %w", err))
+			}
+			files = append(files, ws.gitPath(parentID, id, "metadata.json"))
+			if node == nil {
+				kind = NodeTypeTable
+				node = &Node{ID: id, ParentID: parentID, Title: title, Type: NodeTypeTable, Modified: now}
+			} else {
+				kind = NodeTypeHybrid
+				node.Type = NodeTypeHybrid
+			}
+		case !os.IsNotExist(err):
+			return fail(fmt.Errorf("failed to read table metadata: %w", err))
+		}
+
+		if node == nil {
+			return "", nil, ErrPageNotFound
+		}
+		return commitMsg(author, "rename: "+string(kind)+" "+id.String()+" - "+title, "rename", string(kind), id), files, nil
 	})
+	if err == nil && node.Type != NodeTypeTable {
+		ws.pageWritten(node)
+	}
 	return node, err
 }
 
-// DeletePage deletes a page and commits to git.
+// renameFrontMatter returns data with the front matter title and modified
+// lines replaced. Everything else, including the body, is kept as is. A front
+// matter is added if data has none.
+func renameFrontMatter(data []byte, title string, modified storage.Time) []byte {
+	ts := modified.AsTime().Format(time.RFC3339)
+	rest, ok := bytes.CutPrefix(data, []byte("---\n"))
+	end := bytes.Index(rest, []byte("\n---"))
+	if !ok || end < 0 {
+		return append([]byte("---\ntitle: "+formatTitle(title)+"\ncreated: "+ts+"\nmodified: "+ts+"\n---\n\n"), data...)
+	}
+	var buf bytes.Buffer
+	buf.WriteString("---\n")
+	hasTitle, hasModified := false, false
+	for line := range strings.SplitSeq(string(rest[:end]), "\n") {
+		switch {
+		case strings.HasPrefix(line, "title:"):
+			line, hasTitle = "title: "+formatTitle(title), true
+		case strings.HasPrefix(line, "modified:"):
+			line, hasModified = "modified: "+ts, true
+		}
+		buf.WriteString(line + "\n")
+	}
+	if !hasTitle {
+		buf.WriteString("title: " + formatTitle(title) + "\n")
+	}
+	if !hasModified {
+		buf.WriteString("modified: " + ts + "\n")
+	}
+	// Keep the closing delimiter and the body verbatim.
+	buf.Write(rest[end+1:])
+	return buf.Bytes()
+}
+
+// DeletePage deletes a page, along with its descendants, and commits to git.
 func (ws *WorkspaceFileStore) DeletePage(ctx context.Context, id ksid.ID, author git.Author) error {
 	parentID := ws.getParent(id)
 	gitPathFile := ws.gitPath(parentID, id, "index.md")
 
+	var deleted []ksid.ID
 	err := ws.repo.CommitTx(ctx, author, func() (string, []string, error) {
+		deleted = append([]ksid.ID{id}, subtreeIDs(ws.pageDir(id, parentID))...)
 		if err := ws.deletePage(id); err != nil {
 			return "", nil, err
 		}
-		return "delete: page " + id.String(), []string{gitPathFile}, nil
+		assetFiles, err := ws.deleteAssetRefs(deleted...)
+		if err != nil {
+			return "", nil, err
+		}
+		return commitMsg(author, "delete: page "+id.String(), "delete", string(NodeTypeDocument), id), append([]string{gitPathFile}, assetFiles...), nil
 	})
 	if err == nil {
-		ws.links.remove(id)
+		ws.pagesDeleted(deleted...)
 	}
 	return err
 }
@@ -413,14 +903,14 @@ func (ws *WorkspaceFileStore) DeletePage(ctx context.Context, id ksid.ID, author
 // deletePage deletes a page without committing.
 func (ws *WorkspaceFileStore) deletePage(id ksid.ID) error {
 	if id.IsZero() {
-		return errPageNotFound // No node with ID 0 exists
+		return ErrPageNotFound // No node with ID 0 exists
 	}
 
 	parentID := ws.getParent(id)
 	dir := ws.pageDir(id, parentID)
 	if err := os.RemoveAll(dir); err != nil {
 		if os.IsNotExist(err) {
-			return errPageNotFound
+			return ErrPageNotFound
 		}
 		return fmt.Errorf("failed to delete page: %w", err)
 	}
@@ -430,6 +920,7 @@ func (ws *WorkspaceFileStore) deletePage(id ksid.ID) error {
 
 // IterPages returns an iterator over all pages in the workspace.
 // Recursively traverses the directory tree to include child pages under parents.
+// Siblings are yielded in ID order, each followed by its descendants.
 func (ws *WorkspaceFileStore) IterPages() (iter.Seq[*Node], error) {
 	return func(yield func(*Node) bool) {
 		ws.iterPagesRecursive(ws.wsDir, 0, yield)
@@ -438,19 +929,12 @@ func (ws *WorkspaceFileStore) IterPages() (iter.Seq[*Node], error) {
 
 // iterPagesRecursive recursively yields pages from a directory and its subdirectories.
 func (ws *WorkspaceFileStore) iterPagesRecursive(dir string, parentID ksid.ID, yield func(*Node) bool) {
-	entries, err := os.ReadDir(dir)
+	ids, err := nodeDirs(dir)
 	if err != nil {
 		return
 	}
 
-	for _, entry := range entries {
-		if !entry.IsDir() {
-			continue
-		}
-		id, err := ksid.Parse(entry.Name())
-		if err != nil {
-			continue
-		}
+	for _, id := range ids {
 		indexFile := ws.pageIndexFile(id, parentID)
 		if _, err := os.Stat(indexFile); err == nil {
 			if node, err := ws.ReadPage(id); err == nil {
@@ -460,17 +944,24 @@ func (ws *WorkspaceFileStore) iterPagesRecursive(dir string, parentID ksid.ID, y
 			}
 		}
 		// Recursively yield children
-		ws.iterPagesRecursive(filepath.Join(dir, entry.Name()), id, yield)
+		ws.iterPagesRecursive(filepath.Join(dir, id.String()), id, yield)
 	}
 }
 
 // ReadNode reads a node (page or table or hybrid) by ID.
 func (ws *WorkspaceFileStore) ReadNode(id ksid.ID) (*Node, error) {
+	node, _, err := ws.ReadNodeWithETag(id)
+	return node, err
+}
+
+// ReadNodeWithETag reads a node like ReadNode along with its NodeETag,
+// computed from the same read of its files.
+func (ws *WorkspaceFileStore) ReadNodeWithETag(id ksid.ID) (*Node, string, error) {
 	parentID := ws.getParent(id)
 	nodeDir := ws.pageDir(id, parentID)
-	node, err := ws.ReadNodeFromPath(nodeDir, id, parentID)
+	node, etag, err := ws.readNodeFromPath(nodeDir, id, parentID)
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 
 	// Detect children for UI to show expand arrow
@@ -484,11 +975,75 @@ func (ws *WorkspaceFileStore) ReadNode(id ksid.ID) (*Node, error) {
 		}
 	}
 
-	return node, nil
+	return node, etag, nil
+}
+
+// NodeETag returns a strong HTTP entity tag of a node's stored content. It
+// covers both index.md and metadata.json so it changes when either part of a
+// hybrid node does.
+func (ws *WorkspaceFileStore) NodeETag(id ksid.ID) (string, error) {
+	dir := ws.pageDir(id, ws.getParent(id))
+	var files [2][]byte
+	found := false
+	for i, name := range nodeFiles {
+		data, err := os.ReadFile(filepath.Join(dir, name)) //nolint:gosec // G304: dir is constructed from validated id
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return "", fmt.Errorf("failed to read node: %w", err)
+		}
+		found = true
+		files[i] = data
+	}
+	if !found {
+		return "", ErrPageNotFound
+	}
+	return nodeETag(files[0], files[1]), nil
+}
+
+// nodeFiles are the files holding a node, in the order they are hashed by
+// nodeETag.
+var nodeFiles = [2]string{"index.md", "metadata.json"}
+
+// nodeETag returns the entity tag of a node from the content of its index.md
+// and metadata.json; nil for a file the node doesn't have.
+func nodeETag(index, metadata []byte) string {
+	h := sha256.New()
+	for i, data := range [2][]byte{index, metadata} {
+		if data == nil {
+			continue
+		}
+		sum := sha256.Sum256(data)
+		_, _ = h.Write([]byte(nodeFiles[i]))
+		_, _ = h.Write(sum[:])
+	}
+	return formatETag(h.Sum(nil))
+}
+
+// AssetETag returns a strong HTTP entity tag of an asset from the info
+// returned by OpenAsset, without reading it: the blob hash for assets stored
+// in blobs, the size and modification time for plain files.
+func AssetETag(info os.FileInfo) string {
+	if a, ok := info.(*assetFileInfo); ok && !a.blob.IsZero() {
+		sum := sha256.Sum256([]byte(a.blob))
+		return formatETag(sum[:])
+	}
+	return `"` + strconv.FormatInt(info.Size(), 16) + "-" + strconv.FormatInt(info.ModTime().UnixNano(), 16) + `"`
+}
+
+func formatETag(sum []byte) string {
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
 }
 
 // ReadNodeFromPath reads a node from a specific path.
 func (ws *WorkspaceFileStore) ReadNodeFromPath(path string, id, parentID ksid.ID) (*Node, error) {
+	node, _, err := ws.readNodeFromPath(path, id, parentID)
+	return node, err
+}
+
+// readNodeFromPath reads a node from a specific path along with its NodeETag.
+func (ws *WorkspaceFileStore) readNodeFromPath(path string, id, parentID ksid.ID) (*Node, string, error) {
 	indexFile := filepath.Join(path, "index.md")
 	metadataFile := filepath.Join(path, "metadata.json")
 
@@ -499,7 +1054,7 @@ func (ws *WorkspaceFileStore) ReadNodeFromPath(path string, id, parentID ksid.ID
 	hasMetadata := metadataErr == nil
 
 	if !hasIndex && !hasMetadata {
-		return nil, errPageNotFound
+		return nil, "", ErrPageNotFound
 	}
 
 	var nodeType NodeType
@@ -521,9 +1076,10 @@ func (ws *WorkspaceFileStore) ReadNodeFromPath(path string, id, parentID ksid.ID
 	if hasIndex {
 		p := ParseMarkdown(indexData)
 		node.Title = p.title
-		node.Content = p.content
+		node.Content = ws.normalizeLinks(id, parentID, p.content)
 		node.Created = p.created
 		node.Modified = p.modified
+		node.Tags = p.tags
 		node.Icon = p.icon
 		node.Cover = p.cover
 	}
@@ -531,7 +1087,7 @@ func (ws *WorkspaceFileStore) ReadNodeFromPath(path string, id, parentID ksid.ID
 	if hasMetadata {
 		var metadata map[string]any
 		if err := json.Unmarshal(metadataData, &metadata); err != nil {
-			return nil, fmt.Errorf("failed to parse table metadata: %w", err)
+			return nil, "", fmt.Errorf("failed to parse table metadata: %w", err)
 		}
 		if title, ok := metadata["title"].(string); ok {
 			node.Title = title
@@ -549,32 +1105,19 @@ func (ws *WorkspaceFileStore) ReadNodeFromPath(path string, id, parentID ksid.ID
 		}
 	}
 
-	return node, nil
+	if !hasIndex {
+		indexData = nil
+	}
+	if !hasMetadata {
+		metadataData = nil
+	}
+	return node, nodeETag(indexData, metadataData), nil
 }
 
 // GetWorkspaceUsage returns the page count and storage usage for the workspace.
 func (ws *WorkspaceFileStore) GetWorkspaceUsage() (pageCount int, storageUsage int64, err error) {
-	pages, err := ws.IterPages()
-	if err != nil {
-		return 0, 0, err
-	}
-	for range pages {
-		pageCount++
-	}
-
-	err = filepath.Walk(ws.wsDir, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return nil //nolint:nilerr // skip transient errors (e.g. git maintenance.lock race)
-		}
-		if info.IsDir() && info.Name() == ".git" {
-			return filepath.SkipDir
-		}
-		if !info.IsDir() {
-			storageUsage += info.Size()
-		}
-		return nil
-	})
-	return
+	s, err := ws.Stats()
+	return s.Pages, s.StorageBytes, err
 }
 
 // CommitCount returns the number of git commits in the workspace repository.
@@ -598,7 +1141,7 @@ func (ws *WorkspaceFileStore) ReadTable(id ksid.ID) (*Node, error) {
 	data, err := os.ReadFile(metadataFile) //nolint:gosec // G304: metadataFile is constructed from validated id
 	if err != nil {
 		if os.IsNotExist(err) {
-			return nil, errTableNotFound
+			return nil, ErrTableNotFound
 		}
 		return nil, fmt.Errorf("failed to read table metadata: %w", err)
 	}
@@ -642,6 +1185,10 @@ func (ws *WorkspaceFileStore) ReadTable(id ksid.ID) (*Node, error) {
 		}
 	}
 
+	if lax, ok := metadata["lax_records"].(bool); ok {
+		node.LaxRecords = lax
+	}
+
 	return node, nil
 }
 
@@ -653,7 +1200,7 @@ func (ws *WorkspaceFileStore) WriteTable(ctx context.Context, node *Node, isNew
 			return "", nil, err
 		}
 		files := []string{ws.gitPath(parentID, node.ID, "metadata.json")}
-		return "update: table " + node.ID.String(), files, nil
+		return commitMsg(author, "update: table "+node.ID.String(), "update", string(NodeTypeTable), node.ID), files, nil
 	})
 }
 
@@ -669,6 +1216,10 @@ func (ws *WorkspaceFileStore) writeTable(node *Node, isNew bool) error {
 		"properties": node.Properties,
 		"views":      node.Views,
 	}
+	if node.LaxRecords {
+		// Only tables that opt out of record validation carry the flag.
+		metadata["lax_records"] = true
+	}
 
 	if isNew {
 		metadata["created"] = storage.Now()
@@ -691,7 +1242,7 @@ func (ws *WorkspaceFileStore) writeTable(node *Node, isNew bool) error {
 
 	// Check storage quota for new or updated metadata
 	if isNew {
-		if err := ws.checkStorageQuota(int64(len(data))); err != nil {
+		if err := ws.CheckStorageQuota(int64(len(data))); err != nil {
 			return err
 		}
 	} else {
@@ -700,7 +1251,7 @@ func (ws *WorkspaceFileStore) writeTable(node *Node, isNew bool) error {
 		if err == nil {
 			additionalBytes := int64(len(data)) - int64(len(oldData))
 			if additionalBytes > 0 {
-				if err := ws.checkStorageQuota(additionalBytes); err != nil {
+				if err := ws.CheckStorageQuota(additionalBytes); err != nil {
 					return err
 				}
 			}
@@ -711,7 +1262,7 @@ func (ws *WorkspaceFileStore) writeTable(node *Node, isNew bool) error {
 		return fmt.Errorf("failed to create directory: %w", err)
 	}
 
-	if err := os.WriteFile(metadataFile, data, 0o644); err != nil { //nolint:gosec // G306: 0o644 is intentional for user data files
+	if err := jsonldb.WriteFileDurable(metadataFile, data); err != nil {
 		return fmt.Errorf("failed to write metadata: %w", err)
 	}
 	return nil
@@ -725,7 +1276,7 @@ func (ws *WorkspaceFileStore) DeleteTable(ctx context.Context, id ksid.ID, autho
 			return "", nil, err
 		}
 		files := []string{ws.gitPath(parentID, id, "metadata.json")}
-		return "delete: table " + id.String(), files, nil
+		return commitMsg(author, "delete: table "+id.String(), "delete", string(NodeTypeTable), id), files, nil
 	})
 }
 
@@ -735,7 +1286,7 @@ func (ws *WorkspaceFileStore) deleteTable(id ksid.ID) error {
 	metadataFile := ws.tableMetadataFile(id, parentID)
 	if err := os.Remove(metadataFile); err != nil {
 		if os.IsNotExist(err) {
-			return errTableNotFound
+			return ErrTableNotFound
 		}
 		return fmt.Errorf("failed to delete table metadata: %w", err)
 	}
@@ -744,6 +1295,7 @@ func (ws *WorkspaceFileStore) deleteTable(id ksid.ID) error {
 
 // IterTables returns an iterator over all tables for the workspace as Nodes.
 // Recursively traverses the directory tree to include child tables under parents.
+// Siblings are yielded in ID order, each followed by its descendants.
 func (ws *WorkspaceFileStore) IterTables() (iter.Seq[*Node], error) {
 	return func(yield func(*Node) bool) {
 		ws.iterTablesRecursive(ws.wsDir, 0, yield)
@@ -752,19 +1304,12 @@ func (ws *WorkspaceFileStore) IterTables() (iter.Seq[*Node], error) {
 
 // iterTablesRecursive recursively yields tables from a directory and its subdirectories.
 func (ws *WorkspaceFileStore) iterTablesRecursive(dir string, parentID ksid.ID, yield func(*Node) bool) {
-	entries, err := os.ReadDir(dir)
+	ids, err := nodeDirs(dir)
 	if err != nil {
 		return
 	}
 
-	for _, entry := range entries {
-		if !entry.IsDir() {
-			continue
-		}
-		id, err := ksid.Parse(entry.Name())
-		if err != nil {
-			continue
-		}
+	for _, id := range ids {
 		metadataFile := ws.tableMetadataFile(id, parentID)
 		if _, err := os.Stat(metadataFile); err == nil {
 			if node, err := ws.ReadTable(id); err == nil {
@@ -774,7 +1319,7 @@ func (ws *WorkspaceFileStore) iterTablesRecursive(dir string, parentID ksid.ID,
 			}
 		}
 		// Recursively yield children
-		ws.iterTablesRecursive(filepath.Join(dir, entry.Name()), id, yield)
+		ws.iterTablesRecursive(filepath.Join(dir, id.String()), id, yield)
 	}
 }
 
@@ -804,12 +1349,16 @@ func (ws *WorkspaceFileStore) AppendRecord(ctx context.Context, tableID ksid.ID,
 			return "", nil, err
 		}
 		files := []string{ws.gitPath(parentID, tableID, "data.jsonl")}
-		return "create: record " + record.ID.String(), files, nil
+		return commitMsg(author, "create: record "+record.ID.String(), "create", "record", tableID), files, nil
 	})
 }
 
 // appendRecord appends a record to a table without committing.
 func (ws *WorkspaceFileStore) appendRecord(tableID, tableParentID ksid.ID, record *DataRecord) error {
+	record, err := ws.prepareRecord(tableID, record)
+	if err != nil {
+		return err
+	}
 	recordsFile := ws.tableRecordsFile(tableID, tableParentID)
 
 	// Check max records per table
@@ -821,7 +1370,7 @@ func (ws *WorkspaceFileStore) appendRecord(tableID, tableParentID ksid.ID, recor
 
 	if table != nil {
 		if table.Len() >= ws.quotas.MaxRecordsPerTable {
-			return fmt.Errorf("record quota exceeded: max %d", ws.quotas.MaxRecordsPerTable)
+			return fmt.Errorf("%w: max %d records per table", ErrQuotaExceeded, ws.quotas.MaxRecordsPerTable)
 		}
 	} else {
 		// New table
@@ -836,7 +1385,7 @@ func (ws *WorkspaceFileStore) appendRecord(tableID, tableParentID ksid.ID, recor
 	if err != nil {
 		return fmt.Errorf("failed to marshal record: %w", err)
 	}
-	if err := ws.checkStorageQuota(int64(len(data))); err != nil {
+	if err := ws.CheckStorageQuota(int64(len(data))); err != nil {
 		return err
 	}
 
@@ -846,7 +1395,55 @@ func (ws *WorkspaceFileStore) appendRecord(tableID, tableParentID ksid.ID, recor
 	return nil
 }
 
-// IterRecords iterates over all records in a table.
+// prepareRecord returns the record to write: a copy without the computed
+// formula values, checked against the property schema of its table unless
+// the table has LaxRecords set.
+func (ws *WorkspaceFileStore) prepareRecord(tableID ksid.ID, record *DataRecord) (*DataRecord, error) {
+	table, err := ws.ReadTable(tableID)
+	if err != nil {
+		return nil, err
+	}
+	return prepareTableRecord(table, record)
+}
+
+// prepareTableRecord is prepareRecord for a table already read.
+func prepareTableRecord(table *Node, record *DataRecord) (*DataRecord, error) {
+	formulas := compileFormulas(table.Properties)
+	if formulas != nil {
+		record = record.Clone()
+		maps.DeleteFunc(record.Data, func(k string, _ any) bool { return formulas.computed(k) })
+	}
+	if !table.LaxRecords {
+		if err := validateRecordData(record.Data, table.Properties); err != nil {
+			return nil, err
+		}
+	}
+	return record, nil
+}
+
+// tableFormulas returns the parsed formula properties of a table, or nil if
+// it has none.
+func (ws *WorkspaceFileStore) tableFormulas(tableID ksid.ID) (*tableFormulas, error) {
+	table, err := ws.ReadTable(tableID)
+	if err != nil {
+		return nil, err
+	}
+	return compileFormulas(table.Properties), nil
+}
+
+// withFormulas sets the computed formula values of a record read from disk.
+func withFormulas(f *tableFormulas, r *DataRecord) *DataRecord {
+	if f != nil {
+		if r.Data == nil {
+			r.Data = map[string]any{}
+		}
+		f.apply(r.Data)
+	}
+	return r
+}
+
+// IterRecords iterates over all records in a table in ID order, which jsonldb
+// maintains on load and append. Formula properties are computed on the fly.
 func (ws *WorkspaceFileStore) IterRecords(id ksid.ID) (iter.Seq[*DataRecord], error) {
 	parentID := ws.getParent(id)
 	filePath := ws.tableRecordsFile(id, parentID)
@@ -855,12 +1452,25 @@ func (ws *WorkspaceFileStore) IterRecords(id ksid.ID) (iter.Seq[*DataRecord], er
 		return func(yield func(*DataRecord) bool) {}, nil
 	}
 
+	formulas, err := ws.tableFormulas(id)
+	if err != nil {
+		return nil, err
+	}
 	table, err := jsonldb.NewTable[*DataRecord](filePath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read records: %w", err)
 	}
 
-	return table.Iter(0), nil
+	if formulas == nil {
+		return table.Iter(0), nil
+	}
+	return func(yield func(*DataRecord) bool) {
+		for r := range table.Iter(0) {
+			if !yield(withFormulas(formulas, r)) {
+				return
+			}
+		}
+	}, nil
 }
 
 // CountRecords returns the number of records in a table.
@@ -881,37 +1491,96 @@ func (ws *WorkspaceFileStore) CountRecords(id ksid.ID) (int, error) {
 }
 
 // ReadRecordsPage reads a page of records for a table using jsonldb abstraction.
-func (ws *WorkspaceFileStore) ReadRecordsPage(id ksid.ID, offset, limit int) ([]*DataRecord, error) {
+// It also returns the total number of records in the table. Formula
+// properties are computed on the fly.
+func (ws *WorkspaceFileStore) ReadRecordsPage(id ksid.ID, offset, limit int) ([]*DataRecord, int, error) {
 	parentID := ws.getParent(id)
 	filePath := ws.tableRecordsFile(id, parentID)
 
 	if _, err := os.Stat(filePath); os.IsNotExist(err) {
-		return []*DataRecord{}, nil
+		return []*DataRecord{}, 0, nil
 	}
 
+	formulas, err := ws.tableFormulas(id)
+	if err != nil {
+		return nil, 0, err
+	}
 	table, err := jsonldb.NewTable[*DataRecord](filePath)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read records: %w", err)
+		return nil, 0, fmt.Errorf("failed to read records: %w", err)
 	}
 
+	total := table.Len()
 	offset = max(0, offset)
-	if offset >= table.Len() {
-		return []*DataRecord{}, nil
+	if offset >= total {
+		return []*DataRecord{}, total, nil
 	}
-	end := min(offset+limit, table.Len())
+	end := min(offset+limit, total)
 
 	var records []*DataRecord
 	idx := 0
 	for r := range table.Iter(0) {
 		if idx >= offset {
-			records = append(records, r)
+			records = append(records, withFormulas(formulas, r))
 		}
 		idx++
 		if idx >= end {
 			break
 		}
 	}
-	return records, nil
+	return records, total, nil
+}
+
+// ReadRecordsPageSorted reads a page of the records of a table whose
+// properties equal every value in filter, sorted by the sortBy property. It
+// also returns the total number of matching records.
+//
+// A nil filter value matches records where the property is empty. Values are
+// compared according to the type of sortBy in the table schema, so numbers
+// sort numerically and dates chronologically. Records with equal values, or
+// all records if sortBy is empty, stay in ID order.
+func (ws *WorkspaceFileStore) ReadRecordsPageSorted(id ksid.ID, sortBy string, desc bool, filter map[string]any, offset, limit int) ([]*DataRecord, int, error) {
+	table, err := ws.ReadTable(id)
+	if err != nil {
+		return nil, 0, err
+	}
+	it, err := ws.IterRecords(id)
+	if err != nil {
+		return nil, 0, err
+	}
+	filters := make([]Filter, 0, len(filter))
+	for k, v := range filter {
+		if v == nil {
+			filters = append(filters, Filter{Property: k, Operator: FilterOpIsEmpty})
+		} else {
+			filters = append(filters, Filter{Property: k, Operator: FilterOpEquals, Value: v})
+		}
+	}
+	var matches []*DataRecord
+	for r := range it {
+		if matchesFilters(r, filters) {
+			matches = append(matches, r)
+		}
+	}
+	if sortBy != "" {
+		var pt PropertyType
+		for _, prop := range table.Properties {
+			if prop.Name == sortBy {
+				pt = prop.Type
+			}
+		}
+		slices.SortStableFunc(matches, func(a, b *DataRecord) int {
+			c := compareValues(sortKey(a.Data[sortBy], pt), sortKey(b.Data[sortBy], pt))
+			if desc {
+				return -c
+			}
+			return c
+		})
+	}
+	total := len(matches)
+	offset = min(max(0, offset), total)
+	end := min(offset+max(0, limit), total)
+	return matches[offset:end], total, nil
 }
 
 // UpdateRecord updates a record in a table and commits to git.
@@ -922,12 +1591,16 @@ func (ws *WorkspaceFileStore) UpdateRecord(ctx context.Context, tableID ksid.ID,
 			return "", nil, err
 		}
 		files := []string{ws.gitPath(parentID, tableID, "data.jsonl")}
-		return "update: record " + record.ID.String(), files, nil
+		return commitMsg(author, "update: record "+record.ID.String(), "update", "record", tableID), files, nil
 	})
 }
 
 // updateRecord updates a record in a table without committing.
 func (ws *WorkspaceFileStore) updateRecord(tableID, tableParentID ksid.ID, record *DataRecord) error {
+	record, err := ws.prepareRecord(tableID, record)
+	if err != nil {
+		return err
+	}
 	recordsFile := ws.tableRecordsFile(tableID, tableParentID)
 
 	table, err := jsonldb.NewTable[*DataRecord](recordsFile)
@@ -950,7 +1623,7 @@ func (ws *WorkspaceFileStore) DeleteRecord(ctx context.Context, tableID, recordI
 			return "", nil, err
 		}
 		files := []string{ws.gitPath(parentID, tableID, "data.jsonl")}
-		return "delete: record " + recordID.String(), files, nil
+		return commitMsg(author, "delete: record "+recordID.String(), "delete", "record", tableID), files, nil
 	})
 }
 
@@ -970,137 +1643,305 @@ func (ws *WorkspaceFileStore) deleteRecord(tableID, tableParentID, recordID ksid
 	return nil
 }
 
+// GCBlobs reclaims unreferenced blob files across all tables in the workspace,
+// including the assets table. Blobs written within the grace period of
+// jsonldb.Table.GCBlobs are kept, as they may belong to a write in progress.
+//
+// Table blob directories are not tracked by git; the removal of asset blobs is
+// committed so that discarding uncommitted changes doesn't restore them.
+// Returns the total number of blobs removed and bytes reclaimed.
+func (ws *WorkspaceFileStore) GCBlobs(ctx context.Context) (removed int, bytes int64, err error) {
+	tables, err := ws.IterTables()
+	if err != nil {
+		return 0, 0, err
+	}
+	var errs []error
+	for node := range tables {
+		recordsFile := ws.tableRecordsFile(node.ID, node.ParentID)
+		if _, err := os.Stat(recordsFile); os.IsNotExist(err) {
+			continue
+		}
+		// Loading would remove the orphans regardless of their age.
+		table, err := jsonldb.NewTableWithOptions[*DataRecord](recordsFile, jsonldb.TableOptions{SkipLoadGC: true})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to open table %s: %w", node.ID, err))
+			continue
+		}
+		n, b, err := table.GCBlobs()
+		removed += n
+		bytes += b
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to gc blobs of table %s: %w", node.ID, err))
+		}
+	}
+	if at, err := ws.assetTable(); err != nil {
+		errs = append(errs, err)
+	} else {
+		err := ws.repo.CommitTx(ctx, git.Author{}, func() (string, []string, error) {
+			n, b, err := at.table.GCBlobs()
+			removed += n
+			bytes += b
+			if n == 0 {
+				return "", nil, err
+			}
+			// Commit what was removed even if some removals failed.
+			if err != nil {
+				errs = append(errs, fmt.Errorf("failed to gc asset blobs: %w", err))
+			}
+			msg := "gc: " + strconv.Itoa(n) + " asset blobs"
+			return git.AppendTrailers(msg,
+				git.Trailer{Key: git.TrailerOp, Value: "gc"},
+				git.Trailer{Key: git.TrailerType, Value: "asset"},
+			), []string{assetBlobsDir}, nil
+		})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to gc asset blobs: %w", err))
+		}
+	}
+	return removed, bytes, errors.Join(errs...)
+}
+
+// CommitImport commits every change written to the workspace directory
+// without going through the store, e.g. by the Notion importer, and reloads
+// the node cache.
+//
+// Changes left uncommitted are discarded when the server restarts; see
+// FileStoreService.GetWorkspaceStore.
+func (ws *WorkspaceFileStore) CommitImport(ctx context.Context, summary string, author git.Author) error {
+	err := ws.repo.CommitTx(ctx, author, func() (string, []string, error) {
+		return git.AppendTrailers("import: "+summary,
+			git.Trailer{Key: git.TrailerOp, Value: "import"},
+			git.Trailer{Key: git.TrailerType, Value: "node"},
+			git.Trailer{Key: git.TrailerActor, Value: author.ID},
+		), []string{"."}, nil
+	})
+	if err != nil {
+		return err
+	}
+	if err := ws.refreshCache(); err != nil {
+		return err
+	}
+	ws.pagesReloaded()
+	return nil
+}
+
+// ValidateAssetName returns ErrInvalidAssetName unless name is a plain file
+// name, so it can't address a file outside of its node directory, and isn't
+// one of the node's own files.
+func ValidateAssetName(name string) error {
+	if name == "" || name == "." || name == ".." || strings.ContainsAny(name, `/\`+"\x00") || filepath.Base(name) != name || isNodeFile(name) {
+		return ErrInvalidAssetName
+	}
+	return nil
+}
+
+// isNodeFile reports whether name is a file the store keeps in a node
+// directory, or in the workspace directory for the root node, rather than an
+// asset. The comparison ignores case for case-insensitive file systems.
+func isNodeFile(name string) bool {
+	for _, f := range []string{"index.md", "metadata.json", "data.jsonl", assetsFile, ".git"} {
+		if strings.EqualFold(name, f) {
+			return true
+		}
+	}
+	return strings.HasSuffix(strings.ToLower(name), ".blobs")
+}
+
+// legacyAssetFile returns the path of the plain file holding asset name of a
+// node, which predates the blob store, or "" if there is none.
+func (ws *WorkspaceFileStore) legacyAssetFile(nodeID, parentID ksid.ID, name string) string {
+	if isNodeFile(name) {
+		return ""
+	}
+	p := filepath.Join(ws.pageDir(nodeID, parentID), name)
+	if fi, err := os.Lstat(p); err != nil || !fi.Mode().IsRegular() {
+		return ""
+	}
+	return p
+}
+
 // SaveAsset saves an asset and commits to git.
+//
+// The bytes are stored once in the workspace blob store; saving the same
+// content to several pages only adds references.
 func (ws *WorkspaceFileStore) SaveAsset(ctx context.Context, nodeID ksid.ID, assetName string, data []byte, author git.Author) (*Asset, error) {
+	if err := ValidateAssetName(assetName); err != nil {
+		return nil, err
+	}
 	parentID := ws.getParent(nodeID)
 	var asset *Asset
 	err := ws.repo.CommitTx(ctx, author, func() (string, []string, error) {
+		files := []string{assetsFile, assetBlobsDir}
+		// A plain file of the same name predates the blob store; it is
+		// replaced.
+		if ws.legacyAssetFile(nodeID, parentID, assetName) != "" {
+			files = append(files, ws.gitPath(parentID, nodeID, assetName))
+		}
 		var err error
 		asset, err = ws.saveAsset(nodeID, parentID, assetName, data)
 		if err != nil {
 			return "", nil, err
 		}
-		files := []string{ws.gitPath(parentID, nodeID, assetName)}
-		return "create: asset " + assetName, files, nil
+		return commitMsg(author, "create: asset "+assetName, "create", "asset", nodeID), files, nil
 	})
 	return asset, err
 }
 
 // saveAsset saves an asset without committing.
 func (ws *WorkspaceFileStore) saveAsset(nodeID, parentID ksid.ID, assetName string, data []byte) (*Asset, error) {
-	if err := ws.checkStorageQuota(int64(len(data))); err != nil {
+	if err := ws.CheckStorageQuota(int64(len(data))); err != nil {
 		return nil, err
 	}
-
-	dir := ws.pageDir(nodeID, parentID)
-	if err := os.MkdirAll(dir, 0o755); err != nil { //nolint:gosec // G301: 0o755 is intentional for user data directories
-		return nil, fmt.Errorf("failed to create directory: %w", err)
+	a, err := ws.putAssetRef(nodeID, assetName, data)
+	if err != nil {
+		return nil, err
 	}
-
-	filePath := filepath.Join(dir, assetName)
-	if err := os.WriteFile(filePath, data, 0o644); err != nil { //nolint:gosec // G306: 0o644 is intentional for user data files
-		return nil, fmt.Errorf("failed to write asset: %w", err)
+	if p := ws.legacyAssetFile(nodeID, parentID, assetName); p != "" {
+		if err := os.Remove(p); err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("failed to remove previous asset: %w", err)
+		}
 	}
+	return a.asset(), nil
+}
 
-	info, err := os.Stat(filePath)
+// ReadAsset reads an asset.
+func (ws *WorkspaceFileStore) ReadAsset(nodeID ksid.ID, assetName string) ([]byte, error) {
+	f, _, err := ws.OpenAsset(nodeID, assetName)
 	if err != nil {
-		return nil, fmt.Errorf("failed to stat asset: %w", err)
+		return nil, err
 	}
-
-	return &Asset{
-		ID:       assetName,
-		Name:     assetName,
-		MimeType: mime.TypeByExtension(filepath.Ext(assetName)),
-		Size:     info.Size(),
-		Created:  storage.ToTime(info.ModTime()),
-		Path:     filePath,
-	}, nil
+	defer func() { _ = f.Close() }()
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read asset: %w", err)
+	}
+	return data, nil
 }
 
-// ReadAsset reads an asset.
-func (ws *WorkspaceFileStore) ReadAsset(nodeID ksid.ID, assetName string) ([]byte, error) {
+// OpenAsset opens an asset for streaming.
+// The caller must close the returned reader.
+func (ws *WorkspaceFileStore) OpenAsset(nodeID ksid.ID, assetName string) (io.ReadSeekCloser, os.FileInfo, error) {
+	if err := ValidateAssetName(assetName); err != nil {
+		return nil, nil, err
+	}
+	at, err := ws.assetTable()
+	if err != nil {
+		return nil, nil, err
+	}
+	if a := at.get(nodeID, assetName); a != nil {
+		r, err := at.table.OpenBlob(a.Blob)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to open asset: %w", err)
+		}
+		size, _ := a.Blob.Ref.Size()
+		return r, &assetFileInfo{name: a.Name, size: size, modTime: a.Created.AsTime(), blob: a.Blob.Ref}, nil
+	}
+
 	parentID := ws.getParent(nodeID)
 	filePath := filepath.Join(ws.pageDir(nodeID, parentID), assetName)
 
-	data, err := os.ReadFile(filePath) //nolint:gosec // G304: filePath is constructed from validated ids
+	f, err := os.Open(filePath) //nolint:gosec // G304: filePath is constructed from validated ids
 	if err != nil {
 		if os.IsNotExist(err) {
-			return nil, errAssetNotFound
+			return nil, nil, ErrAssetNotFound
 		}
-		return nil, fmt.Errorf("failed to read asset: %w", err)
+		return nil, nil, fmt.Errorf("failed to open asset: %w", err)
 	}
-	return data, nil
+	info, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return nil, nil, fmt.Errorf("failed to stat asset: %w", err)
+	}
+	if info.IsDir() {
+		_ = f.Close()
+		return nil, nil, ErrAssetNotFound
+	}
+	return f, info, nil
 }
 
 // DeleteAsset deletes an asset and commits to git.
+//
+// The bytes are removed once no page references them anymore.
 func (ws *WorkspaceFileStore) DeleteAsset(ctx context.Context, nodeID ksid.ID, assetName string, author git.Author) error {
 	parentID := ws.getParent(nodeID)
 	return ws.repo.CommitTx(ctx, author, func() (string, []string, error) {
-		if err := ws.deleteAsset(nodeID, parentID, assetName); err != nil {
+		files, err := ws.deleteAsset(nodeID, parentID, assetName)
+		if err != nil {
 			return "", nil, err
 		}
-		files := []string{ws.gitPath(parentID, nodeID, assetName)}
-		return "delete: asset " + assetName, files, nil
+		return commitMsg(author, "delete: asset "+assetName, "delete", "asset", nodeID), files, nil
 	})
 }
 
-// deleteAsset deletes an asset without committing.
-func (ws *WorkspaceFileStore) deleteAsset(nodeID, parentID ksid.ID, assetName string) error {
-	filePath := filepath.Join(ws.pageDir(nodeID, parentID), assetName)
+// deleteAsset deletes an asset without committing. It returns the paths to
+// commit, relative to the workspace.
+func (ws *WorkspaceFileStore) deleteAsset(nodeID, parentID ksid.ID, assetName string) ([]string, error) {
+	if err := ValidateAssetName(assetName); err != nil {
+		return nil, err
+	}
+	at, err := ws.assetTable()
+	if err != nil {
+		return nil, err
+	}
+	if a := at.get(nodeID, assetName); a != nil {
+		if _, err := at.table.Delete(a.ID); err != nil {
+			return nil, fmt.Errorf("failed to delete asset: %w", err)
+		}
+		return []string{assetsFile, assetBlobsDir}, nil
+	}
 
+	filePath := ws.legacyAssetFile(nodeID, parentID, assetName)
+	if filePath == "" {
+		return nil, ErrAssetNotFound
+	}
 	if err := os.Remove(filePath); err != nil {
 		if os.IsNotExist(err) {
-			return errAssetNotFound
+			return nil, ErrAssetNotFound
 		}
-		return fmt.Errorf("failed to delete asset: %w", err)
+		return nil, fmt.Errorf("failed to delete asset: %w", err)
 	}
-	return nil
+	return []string{ws.gitPath(parentID, nodeID, assetName)}, nil
 }
 
-// IterAssets returns an iterator over all assets for a page.
+// IterAssets returns an iterator over all assets for a page, sorted by name.
 func (ws *WorkspaceFileStore) IterAssets(nodeID ksid.ID) (iter.Seq[*Asset], error) {
+	at, err := ws.assetTable()
+	if err != nil {
+		return nil, err
+	}
+	var assets []*Asset
+	for _, a := range at.list(nodeID) {
+		assets = append(assets, a.asset())
+	}
+
 	parentID := ws.getParent(nodeID)
 	dir := ws.pageDir(nodeID, parentID)
-
-	// Check if directory exists
-	if _, err := os.Stat(dir); err != nil {
-		if os.IsNotExist(err) {
-			// Page directory doesn't exist, so no assets
-			return func(yield func(*Asset) bool) {}, nil
-		}
+	entries, err := os.ReadDir(dir)
+	if err != nil && !os.IsNotExist(err) {
 		return nil, fmt.Errorf("failed to list assets: %w", err)
 	}
-
-	return func(yield func(*Asset) bool) {
-		entries, err := os.ReadDir(dir)
-		if err != nil {
-			return
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() || isNodeFile(entry.Name()) {
+			continue
 		}
-
-		for _, entry := range entries {
-			if entry.IsDir() || entry.Name() == "index.md" || entry.Name() == "metadata.json" || entry.Name() == "data.jsonl" || strings.HasSuffix(entry.Name(), ".blobs") {
-				continue
-			}
-
-			info, err := entry.Info()
-			if err != nil {
-				continue
-			}
-
-			asset := &Asset{
-				ID:       entry.Name(),
-				Name:     entry.Name(),
-				MimeType: mime.TypeByExtension(filepath.Ext(entry.Name())),
-				Size:     info.Size(),
-				Created:  storage.ToTime(info.ModTime()),
-				Path:     filepath.Join(dir, entry.Name()),
-			}
-
-			if !yield(asset) {
-				return
-			}
+		if slices.ContainsFunc(assets, func(a *Asset) bool { return a.Name == entry.Name() }) {
+			continue
 		}
-	}, nil
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		assets = append(assets, &Asset{
+			ID:       entry.Name(),
+			Name:     entry.Name(),
+			MimeType: mime.TypeByExtension(filepath.Ext(entry.Name())),
+			Size:     info.Size(),
+			Created:  storage.ToTime(info.ModTime()),
+			Path:     filepath.Join(dir, entry.Name()),
+		})
+	}
+	slices.SortFunc(assets, func(a, b *Asset) int { return strings.Compare(a.Name, b.Name) })
+	return slices.Values(assets), nil
 }
 
 // History operations
@@ -1108,13 +1949,21 @@ func (ws *WorkspaceFileStore) IterAssets(nodeID ksid.ID) (iter.Seq[*Asset], erro
 // GetHistory returns the commit history for a node, limited to n commits.
 // n is capped at 1000. If n <= 0, defaults to 1000.
 func (ws *WorkspaceFileStore) GetHistory(ctx context.Context, id ksid.ID, n int) ([]*git.Commit, error) {
+	commits, _, err := ws.GetHistoryBefore(ctx, id, "", n)
+	return commits, err
+}
+
+// GetHistoryBefore returns up to n commits for a node that are older than the
+// commit hash before, and whether more history remains. An empty before starts
+// from HEAD.
+func (ws *WorkspaceFileStore) GetHistoryBefore(ctx context.Context, id ksid.ID, before string, n int) ([]*git.Commit, bool, error) {
 	if id.IsZero() {
-		return nil, errPageNotFound // No node with ID 0 exists
+		return nil, false, ErrPageNotFound // No node with ID 0 exists
 	}
 	// Use the full relative path including parent chain, otherwise nested nodes won't find their history.
 	parentID := ws.getParent(id)
 	path := ws.relativeDir(id, parentID)
-	return ws.repo.GetHistory(ctx, path, n)
+	return ws.repo.GetHistoryBefore(ctx, path, before, n)
 }
 
 // GetPageContentAtCommit returns the content of a node's index.md file at a specific commit,
@@ -1130,13 +1979,58 @@ func (ws *WorkspaceFileStore) GetPageContentAtCommit(ctx context.Context, hash s
 	return p.content, nil
 }
 
+// RevertNode replaces the page of a node with its version at commit hash and
+// commits to git. The node keeps its ID and parent. Like GetHistory, it looks
+// the page up at the node's current path. Returns git.ErrInvalidHash if hash
+// is not a full commit hash, and ErrPageNotFound if the node does not exist
+// now or had no page there at that commit.
+func (ws *WorkspaceFileStore) RevertNode(ctx context.Context, id ksid.ID, hash string, author git.Author) (*Node, error) {
+	if !git.IsCommitHash(hash) {
+		return nil, git.ErrInvalidHash
+	}
+	if id.IsZero() || (!ws.PageExists(id) && !ws.TableExists(id)) {
+		return nil, ErrPageNotFound
+	}
+	current, err := ws.ReadNode(id)
+	if err != nil {
+		return nil, err
+	}
+	parentID := ws.getParent(id)
+	data, err := ws.repo.GetFileAtCommit(ctx, hash, ws.gitPath(parentID, id, "index.md"))
+	if err != nil {
+		return nil, fmt.Errorf("node %s at commit %s: %w", id, hash, ErrPageNotFound)
+	}
+	p := ParseMarkdown(data)
+	p.modified = storage.Now()
+
+	err = ws.repo.CommitTx(ctx, author, func() (string, []string, error) {
+		if err := ws.writePageFile(id, parentID, p); err != nil {
+			return "", nil, err
+		}
+		files := []string{ws.gitPath(parentID, id, "index.md")}
+		return commitMsg(author, "revert: "+string(current.Type)+" "+id.String()+" to "+hash, "revert", string(current.Type), id), files, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	node, err := ws.ReadNode(id)
+	if err != nil {
+		return nil, err
+	}
+	ws.pageWritten(node)
+	return node, nil
+}
+
 // CreateNode creates a new node (can be document, table, or hybrid) and commits to git.
 // If parentID is zero, creates a top-level node in the workspace.
 // Otherwise, creates a child under the specified parent node.
 func (ws *WorkspaceFileStore) CreateNode(ctx context.Context, title string, nodeType NodeType, parentID ksid.ID, author git.Author) (*Node, error) {
+	if err := checkTitle(title); err != nil {
+		return nil, err
+	}
 	// Verify parent exists if specified.
 	if !parentID.IsZero() && !ws.PageExists(parentID) && !ws.TableExists(parentID) {
-		return nil, fmt.Errorf("parent node not found: %w", errPageNotFound)
+		return nil, fmt.Errorf("parent node not found: %w", ErrPageNotFound)
 	}
 
 	var node *Node
@@ -1151,8 +2045,11 @@ func (ws *WorkspaceFileStore) CreateNode(ctx context.Context, title string, node
 		if !parentID.IsZero() {
 			msg += " (parent: " + parentID.String() + ")"
 		}
-		return msg, files, nil
+		return commitMsg(author, msg, "create", string(nodeType), node.ID), files, nil
 	})
+	if err == nil && (nodeType == NodeTypeDocument || nodeType == NodeTypeHybrid) {
+		ws.pageWritten(node)
+	}
 	return node, err
 }
 
@@ -1163,6 +2060,9 @@ func (ws *WorkspaceFileStore) createNode(title string, nodeType NodeType, parent
 	if err := ws.checkPageQuota(); err != nil {
 		return nil, nil, err
 	}
+	if err := ws.checkTreeLimits(parentID, 1); err != nil {
+		return nil, nil, err
+	}
 
 	id := ksid.NewID()
 	now := storage.Now()
@@ -1209,7 +2109,7 @@ func (ws *WorkspaceFileStore) createNode(title string, nodeType NodeType, parent
 	}
 
 	// Check storage quota before writing any files
-	if err := ws.checkStorageQuota(totalSize); err != nil {
+	if err := ws.CheckStorageQuota(totalSize); err != nil {
 		return nil, nil, err
 	}
 
@@ -1234,7 +2134,7 @@ func (ws *WorkspaceFileStore) createNode(title string, nodeType NodeType, parent
 		}
 
 		metadataFile := ws.tableMetadataFile(id, parentID)
-		if err := os.WriteFile(metadataFile, metadataData, 0o644); err != nil { //nolint:gosec // G306: 0o644 is intentional for user data files
+		if err := jsonldb.WriteFileDurable(metadataFile, metadataData); err != nil {
 			return nil, nil, fmt.Errorf("failed to write metadata: %w", err)
 		}
 		files = append(files, ws.gitPath(parentID, id, "metadata.json"))
@@ -1250,18 +2150,18 @@ func (ws *WorkspaceFileStore) createNode(title string, nodeType NodeType, parent
 // and that the move would not create a cycle in the tree.
 func (ws *WorkspaceFileStore) MoveNode(ctx context.Context, id, newParentID ksid.ID, author git.Author) error {
 	if id.IsZero() {
-		return errPageNotFound
+		return ErrPageNotFound
 	}
 
 	// Verify node exists.
 	oldParentID := ws.getParent(id)
 	if !ws.PageExists(id) && !ws.TableExists(id) {
-		return errPageNotFound
+		return ErrPageNotFound
 	}
 
 	// Verify new parent exists (unless root).
 	if !newParentID.IsZero() && !ws.PageExists(newParentID) && !ws.TableExists(newParentID) {
-		return fmt.Errorf("new parent not found: %w", errPageNotFound)
+		return fmt.Errorf("new parent not found: %w", ErrPageNotFound)
 	}
 
 	// No-op if already under the requested parent.
@@ -1272,26 +2172,289 @@ func (ws *WorkspaceFileStore) MoveNode(ctx context.Context, id, newParentID ksid
 	// Cycle check: walk from newParentID up to root; if we hit id, it's a cycle.
 	for p := newParentID; !p.IsZero(); p = ws.getParent(p) {
 		if p == id {
-			return errCycleDetected
+			return ErrCycleDetected
 		}
 	}
 
 	oldDir := ws.pageDir(id, oldParentID)
+	height, err := subtreeHeight(oldDir)
+	if err != nil {
+		return fmt.Errorf("failed to measure subtree: %w", err)
+	}
+	if err := ws.checkTreeLimits(newParentID, height); err != nil {
+		return err
+	}
 	oldRelDir := ws.relativeDir(id, oldParentID)
 
 	// Compute new directory before moving (uses current cache state for newParentID chain).
 	newRelDir := ws.relativeDir(id, newParentID)
 	newDir := filepath.Join(ws.wsDir, newRelDir)
 
-	return ws.repo.CommitTx(ctx, author, func() (string, []string, error) {
+	var rewrites []linkRewrite
+	err = ws.repo.CommitTx(ctx, author, func() (string, []string, error) {
+		var err error
+		if rewrites, err = ws.movedLinkRewrites(map[string]string{oldRelDir: newRelDir}); err != nil {
+			return "", nil, err
+		}
 		if err := os.Rename(oldDir, newDir); err != nil {
 			return "", nil, fmt.Errorf("failed to move node: %w", err)
 		}
+		if err := ws.writeLinkRewrites(rewrites); err != nil {
+			if rerr := os.Rename(newDir, oldDir); rerr != nil {
+				err = errors.Join(err, fmt.Errorf("failed to undo move: %w", rerr))
+			}
+			return "", nil, err
+		}
+		files := []string{oldRelDir, newRelDir}
+		for _, rw := range rewrites {
+			files = append(files, rw.file)
+		}
 		ws.setParent(id, newParentID)
+		return commitMsg(author, "move: node "+id.String()+" to parent "+newParentID.String(), "move", "node", id), files, nil
+	})
+	if err != nil {
+		return err
+	}
+	ws.linksRewritten(rewrites)
+	return nil
+}
 
-		files := []string{oldRelDir, newRelDir}
-		return "move: node " + id.String() + " to parent " + newParentID.String(), files, nil
+// linkRewrite is the new content of a page file whose links were rewritten.
+type linkRewrite struct {
+	id   ksid.ID
+	file string // Relative to the workspace directory.
+	data []byte
+	old  []byte // Previous content, restored if the move fails.
+}
+
+// movedLinkRewrites returns the pages outside of the moved subtrees whose
+// relative links into them must be rewritten. dirs maps the old relative
+// directory of each moved node to its new one; a moved node may be nested in
+// another one. Must be called before the subtrees are moved.
+//
+// Only links whose path resolves to the old location are rewritten; links that
+// only name the target's directory, like ../id/index.md from an unrelated
+// depth, already resolve by ID and are left alone.
+func (ws *WorkspaceFileStore) movedLinkRewrites(dirs map[string]string) ([]linkRewrite, error) {
+	// Old and new directory of every node in the subtrees, slash separated.
+	moved := map[ksid.ID][2]string{}
+	for oldRelDir, newRelDir := range dirs {
+		var walk func(rel string)
+		walk = func(rel string) {
+			id, _ := ksid.Parse(filepath.Base(rel))
+			moved[id] = [2]string{filepath.ToSlash(rel), filepath.ToSlash(filepath.Join(newRelDir, strings.TrimPrefix(rel, oldRelDir)))}
+			ids, _ := nodeDirs(filepath.Join(ws.wsDir, rel))
+			for _, child := range ids {
+				// A nested moved node goes to its own new directory.
+				if childRel := filepath.Join(rel, child.String()); dirs[childRel] == "" {
+					walk(childRel)
+				}
+			}
+		}
+		walk(oldRelDir)
+	}
+
+	if err := ws.links.ensureBuilt(ws.loadLinks); err != nil {
+		return nil, fmt.Errorf("build link cache: %w", err)
+	}
+	sources := map[ksid.ID]bool{}
+	for target := range moved {
+		for _, src := range ws.links.backlinks(target) {
+			if _, inside := moved[src]; !inside {
+				sources[src] = true
+			}
+		}
+	}
+
+	var out []linkRewrite
+	for src := range sources {
+		srcDir := filepath.ToSlash(ws.relativeDir(src, ws.getParent(src)))
+		file := filepath.Join(filepath.FromSlash(srcDir), "index.md")
+		data, err := os.ReadFile(filepath.Join(ws.wsDir, file)) //nolint:gosec // G304: path is built from cached node IDs
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, fmt.Errorf("failed to read page %s: %w", src, err)
+		}
+		changed := false
+		content := relativeLinkRe.ReplaceAllStringFunc(string(data), func(m string) string {
+			sub := relativeLinkRe.FindStringSubmatch(m)
+			id, ok := linkTargetID(sub[2])
+			dirs, isMoved := moved[id]
+			if !ok || !isMoved || path.Join(srcDir, sub[2]) != path.Join(dirs[0], "index.md") {
+				return m
+			}
+			rel, err := filepath.Rel(filepath.FromSlash(srcDir), filepath.FromSlash(dirs[1]))
+			if err != nil {
+				return m
+			}
+			changed = true
+			return "[" + sub[1] + "](" + path.Join(filepath.ToSlash(rel), "index.md") + ")"
+		})
+		if changed {
+			out = append(out, linkRewrite{id: src, file: file, data: []byte(content), old: data})
+		}
+	}
+	slices.SortFunc(out, func(a, b linkRewrite) int { return cmp.Compare(a.id, b.id) })
+	return out, nil
+}
+
+// writeLinkRewrites writes the rewritten pages. If one fails, the pages
+// already written get their previous content back.
+func (ws *WorkspaceFileStore) writeLinkRewrites(rewrites []linkRewrite) error {
+	for i, rw := range rewrites {
+		if err := jsonldb.WriteFileDurable(filepath.Join(ws.wsDir, rw.file), rw.data); err != nil {
+			for _, done := range rewrites[:i] {
+				if rerr := jsonldb.WriteFileDurable(filepath.Join(ws.wsDir, done.file), done.old); rerr != nil {
+					err = errors.Join(err, fmt.Errorf("failed to restore page %s: %w", done.id, rerr))
+				}
+			}
+			return fmt.Errorf("failed to rewrite links: %w", err)
+		}
+	}
+	return nil
+}
+
+// linksRewritten notifies about the pages whose links were rewritten once the
+// move was committed.
+func (ws *WorkspaceFileStore) linksRewritten(rewrites []linkRewrite) {
+	var pages []*Node
+	for _, rw := range rewrites {
+		if page, err := ws.ReadPage(rw.id); err == nil {
+			pages = append(pages, page)
+		}
+	}
+	if len(pages) != 0 {
+		ws.pageWritten(pages...)
+	}
+}
+
+// MoveNodes reparents several nodes under newParentID (or root if zero) in a
+// single git commit.
+//
+// All moves are validated before anything is touched: every node must exist,
+// the new parent must exist and no move may create a cycle. If a directory
+// relocation fails midway, the ones already done are undone so the batch is
+// all or nothing. Nodes already under newParentID are left alone. Nodes may be
+// nested within each other; each ends up directly under newParentID. Relative
+// links into the moved subtrees are rewritten like MoveNode does.
+func (ws *WorkspaceFileStore) MoveNodes(ctx context.Context, ids []ksid.ID, newParentID ksid.ID, author git.Author) error {
+	if !newParentID.IsZero() && !ws.PageExists(newParentID) && !ws.TableExists(newParentID) {
+		return fmt.Errorf("new parent not found: %w", ErrPageNotFound)
+	}
+	batch := make(map[ksid.ID]bool, len(ids))
+	for _, id := range ids {
+		if id.IsZero() || (!ws.PageExists(id) && !ws.TableExists(id)) {
+			return fmt.Errorf("node %s: %w", id, ErrPageNotFound)
+		}
+		batch[id] = true
+	}
+	// Cycle check: walk from newParentID up to root; if we hit any moved node,
+	// it would end up under itself.
+	depth := 0
+	for p := newParentID; !p.IsZero(); p = ws.getParent(p) {
+		if batch[p] {
+			return ErrCycleDetected
+		}
+		depth++
+	}
+
+	type move struct {
+		id, oldParentID ksid.ID
+		depth           int
+		oldRel, newRel  string
+	}
+	var moves []move
+	for id := range batch {
+		oldParentID := ws.getParent(id)
+		if oldParentID == newParentID {
+			continue
+		}
+		height, err := subtreeHeight(ws.pageDir(id, oldParentID))
+		if err != nil {
+			return fmt.Errorf("failed to measure subtree: %w", err)
+		}
+		if depth+height > ws.quotas.MaxNodeDepth {
+			return ErrNodeDepthExceeded
+		}
+		m := move{id: id, oldParentID: oldParentID, oldRel: ws.relativeDir(id, oldParentID), newRel: ws.relativeDir(id, newParentID)}
+		for p := oldParentID; !p.IsZero(); p = ws.getParent(p) {
+			m.depth++
+		}
+		moves = append(moves, m)
+	}
+	if len(moves) == 0 {
+		return nil
+	}
+	dir := ws.wsDir
+	if !newParentID.IsZero() {
+		dir = ws.pageDir(newParentID, ws.getParent(newParentID))
+	}
+	children, err := countChildNodes(dir)
+	if err != nil {
+		return err
+	}
+	if children+len(moves) > ws.quotas.MaxChildrenPerNode {
+		return ErrTooManyChildren
+	}
+	// Move the deepest nodes first so that the old directory of a node nested
+	// in another moved node is still where it was computed to be.
+	slices.SortFunc(moves, func(a, b move) int {
+		if a.depth != b.depth {
+			return b.depth - a.depth
+		}
+		return cmp.Compare(a.id, b.id)
+	})
+
+	var rewrites []linkRewrite
+	err = ws.repo.CommitTx(ctx, author, func() (string, []string, error) {
+		dirs := make(map[string]string, len(moves))
+		for _, m := range moves {
+			dirs[m.oldRel] = m.newRel
+		}
+		var err error
+		if rewrites, err = ws.movedLinkRewrites(dirs); err != nil {
+			return "", nil, err
+		}
+		// undo moves back the first n nodes, in reverse order.
+		undo := func(n int, err error) error {
+			for j := n - 1; j >= 0; j-- {
+				if rerr := os.Rename(filepath.Join(ws.wsDir, moves[j].newRel), filepath.Join(ws.wsDir, moves[j].oldRel)); rerr != nil {
+					err = errors.Join(err, fmt.Errorf("failed to undo move of %s: %w", moves[j].id, rerr))
+				}
+				ws.setParent(moves[j].id, moves[j].oldParentID)
+			}
+			return err
+		}
+		for i, m := range moves {
+			if err := os.Rename(filepath.Join(ws.wsDir, m.oldRel), filepath.Join(ws.wsDir, m.newRel)); err != nil {
+				return "", nil, undo(i, fmt.Errorf("failed to move node %s: %w", m.id, err))
+			}
+			ws.setParent(m.id, newParentID)
+		}
+		if err := ws.writeLinkRewrites(rewrites); err != nil {
+			return "", nil, undo(len(moves), err)
+		}
+		files := make([]string, 0, 2*len(moves)+len(rewrites))
+		for _, m := range moves {
+			files = append(files, m.oldRel, m.newRel)
+		}
+		for _, rw := range rewrites {
+			files = append(files, rw.file)
+		}
+		msg := "move: " + strconv.Itoa(len(moves)) + " nodes to parent " + newParentID.String()
+		return git.AppendTrailers(msg,
+			git.Trailer{Key: git.TrailerOp, Value: "move"},
+			git.Trailer{Key: git.TrailerType, Value: "node"},
+			git.Trailer{Key: git.TrailerActor, Value: author.ID},
+		), files, nil
 	})
+	if err != nil {
+		return err
+	}
+	ws.linksRewritten(rewrites)
+	return nil
 }
 
 // Repo returns the git Repository for the workspace. This is exported for handlers
@@ -1323,29 +2486,55 @@ func (ws *WorkspaceFileStore) HasTable(id ksid.ID) bool {
 // Otherwise, creates a child under the specified parent node.
 // Returns the new node with the page content.
 func (ws *WorkspaceFileStore) CreatePageUnderParent(ctx context.Context, parentID ksid.ID, title, content string, author git.Author) (*Node, error) {
+	return ws.CreatePageWithMeta(ctx, parentID, title, content, PageMeta{}, author)
+}
+
+// PageMeta is the front matter of a new page besides its title and dates.
+type PageMeta struct {
+	Tags []string
+	// Extra holds front matter fields mddb does not know about, kept as is.
+	// Keys of the known fields, like "title" or "tags", are rejected.
+	Extra map[string]any
+}
+
+// CreatePageWithMeta is CreatePageUnderParent writing meta in the front matter
+// of the page, in the same commit.
+func (ws *WorkspaceFileStore) CreatePageWithMeta(ctx context.Context, parentID ksid.ID, title, content string, meta PageMeta, author git.Author) (*Node, error) {
+	if err := checkTitle(title); err != nil {
+		return nil, err
+	}
+	if err := checkFrontMatterExtra(meta.Extra); err != nil {
+		return nil, err
+	}
 	// Verify parent exists if specified.
 	if !parentID.IsZero() && !ws.PageExists(parentID) && !ws.TableExists(parentID) {
-		return nil, fmt.Errorf("parent node not found: %w", errPageNotFound)
+		return nil, fmt.Errorf("parent node not found: %w", ErrPageNotFound)
 	}
 
 	if err := ws.checkPageQuota(); err != nil {
 		return nil, err
 	}
+	if err := ws.checkTreeLimits(parentID, 1); err != nil {
+		return nil, err
+	}
 
 	var node *Node
 	err := ws.repo.CommitTx(ctx, author, func() (string, []string, error) {
 		id := ksid.NewID()
 		now := storage.Now()
+		content := ws.normalizeLinks(id, parentID, content)
 
 		p := &page{
 			title:    title,
 			content:  content,
 			created:  now,
 			modified: now,
+			tags:     meta.Tags,
+			extra:    meta.Extra,
 		}
 		pageData := formatMarkdownFile(p)
 
-		if err := ws.checkStorageQuota(int64(len(pageData))); err != nil {
+		if err := ws.CheckStorageQuota(int64(len(pageData))); err != nil {
 			return "", nil, err
 		}
 
@@ -1369,14 +2558,15 @@ func (ws *WorkspaceFileStore) CreatePageUnderParent(ctx context.Context, parentI
 			Type:     NodeTypeDocument,
 			Created:  now,
 			Modified: now,
+			Tags:     meta.Tags,
 		}
 
 		files := []string{ws.gitPath(parentID, id, "index.md")}
 		msg := "create: page " + id.String() + " - " + title + " (parent: " + parentID.String() + ")"
-		return msg, files, nil
+		return commitMsg(author, msg, "create", string(NodeTypeDocument), id), files, nil
 	})
 	if err == nil {
-		ws.links.update(node.ID, node.Content)
+		ws.pageWritten(node)
 	}
 	return node, err
 }
@@ -1385,7 +2575,7 @@ func (ws *WorkspaceFileStore) CreatePageUnderParent(ctx context.Context, parentI
 // The node directory is kept if table content exists.
 func (ws *WorkspaceFileStore) DeletePageFromNode(ctx context.Context, id ksid.ID, author git.Author) error {
 	if id.IsZero() {
-		return errPageNotFound // No node with ID 0 exists
+		return ErrPageNotFound // No node with ID 0 exists
 	}
 
 	parentID := ws.getParent(id)
@@ -1393,7 +2583,7 @@ func (ws *WorkspaceFileStore) DeletePageFromNode(ctx context.Context, id ksid.ID
 		filePath := ws.pageIndexFile(id, parentID)
 		if err := os.Remove(filePath); err != nil {
 			if os.IsNotExist(err) {
-				return "", nil, errPageNotFound
+				return "", nil, ErrPageNotFound
 			}
 			return "", nil, fmt.Errorf("failed to delete page: %w", err)
 		}
@@ -1410,10 +2600,10 @@ func (ws *WorkspaceFileStore) DeletePageFromNode(ctx context.Context, id ksid.ID
 		}
 
 		files := []string{ws.gitPath(parentID, id, "index.md")}
-		return "delete: page " + id.String(), files, nil
+		return commitMsg(author, "delete: page "+id.String(), "delete", string(NodeTypeDocument), id), files, nil
 	})
 	if err == nil {
-		ws.links.remove(id)
+		ws.pagesDeleted(id)
 	}
 	return err
 }
@@ -1425,12 +2615,15 @@ func (ws *WorkspaceFileStore) DeletePageFromNode(ctx context.Context, id ksid.ID
 func (ws *WorkspaceFileStore) CreateTableUnderParent(ctx context.Context, parentID ksid.ID, title string, properties []Property, author git.Author) (*Node, error) {
 	// Verify parent exists if parentID is specified (non-root)
 	if !parentID.IsZero() && !ws.PageExists(parentID) && !ws.TableExists(parentID) {
-		return nil, fmt.Errorf("parent node not found: %w", errPageNotFound)
+		return nil, fmt.Errorf("parent node not found: %w", ErrPageNotFound)
 	}
 
 	if err := ws.checkPageQuota(); err != nil {
 		return nil, err
 	}
+	if err := ws.checkTreeLimits(parentID, 1); err != nil {
+		return nil, err
+	}
 
 	var node *Node
 	err := ws.repo.CommitTx(ctx, author, func() (string, []string, error) {
@@ -1449,7 +2642,7 @@ func (ws *WorkspaceFileStore) CreateTableUnderParent(ctx context.Context, parent
 			return "", nil, fmt.Errorf("failed to marshal metadata: %w", err)
 		}
 
-		if err := ws.checkStorageQuota(int64(len(metadataData))); err != nil {
+		if err := ws.CheckStorageQuota(int64(len(metadataData))); err != nil {
 			return "", nil, err
 		}
 
@@ -1459,7 +2652,7 @@ func (ws *WorkspaceFileStore) CreateTableUnderParent(ctx context.Context, parent
 		}
 
 		metadataFile := ws.tableMetadataFile(id, parentID)
-		if err := os.WriteFile(metadataFile, metadataData, 0o644); err != nil { //nolint:gosec // G306: 0o644 is intentional
+		if err := jsonldb.WriteFileDurable(metadataFile, metadataData); err != nil {
 			return "", nil, fmt.Errorf("failed to write metadata: %w", err)
 		}
 
@@ -1480,7 +2673,7 @@ func (ws *WorkspaceFileStore) CreateTableUnderParent(ctx context.Context, parent
 		if !parentID.IsZero() {
 			msg += " (parent: " + parentID.String() + ")"
 		}
-		return msg, files, nil
+		return commitMsg(author, msg, "create", string(NodeTypeTable), id), files, nil
 	})
 	return node, err
 }
@@ -1489,7 +2682,7 @@ func (ws *WorkspaceFileStore) CreateTableUnderParent(ctx context.Context, parent
 // The node directory is kept if page content exists.
 func (ws *WorkspaceFileStore) DeleteTableFromNode(ctx context.Context, id ksid.ID, author git.Author) error {
 	if id.IsZero() {
-		return errTableNotFound // No node with ID 0 exists
+		return ErrTableNotFound // No node with ID 0 exists
 	}
 
 	parentID := ws.getParent(id)
@@ -1521,7 +2714,7 @@ func (ws *WorkspaceFileStore) DeleteTableFromNode(ctx context.Context, id ksid.I
 			}
 		}
 
-		return "delete: table " + id.String(), files, nil
+		return commitMsg(author, "delete: table "+id.String(), "delete", string(NodeTypeTable), id), files, nil
 	})
 }
 
@@ -1529,6 +2722,8 @@ func (ws *WorkspaceFileStore) DeleteTableFromNode(ctx context.Context, id ksid.I
 // In single-root model:
 //   - If parentID is zero (root), returns children from workspace dir.
 //   - Otherwise, returns children from parent's subdirectory.
+//
+// Children are sorted by ID.
 func (ws *WorkspaceFileStore) ListChildren(parentID ksid.ID) ([]*Node, error) {
 	var dir string
 	if parentID.IsZero() {
@@ -1540,7 +2735,7 @@ func (ws *WorkspaceFileStore) ListChildren(parentID ksid.ID) ([]*Node, error) {
 		dir = ws.pageDir(parentID, parentParentID)
 	}
 
-	entries, err := os.ReadDir(dir)
+	ids, err := nodeDirs(dir)
 	if err != nil {
 		if os.IsNotExist(err) {
 			return nil, nil
@@ -1549,29 +2744,20 @@ func (ws *WorkspaceFileStore) ListChildren(parentID ksid.ID) ([]*Node, error) {
 	}
 
 	var nodes []*Node
-	for _, entry := range entries {
-		if !entry.IsDir() {
-			continue
-		}
-		id, err := ksid.Parse(entry.Name())
-		if err != nil {
-			continue
-		}
-
-		nodePath := filepath.Join(dir, entry.Name())
+	for _, id := range ids {
+		nodePath := filepath.Join(dir, id.String())
 		node, err := ws.ReadNodeFromPath(nodePath, id, parentID)
 		if err != nil {
 			continue
 		}
 
-		// Check if this child has its own children
-		childEntries, _ := os.ReadDir(nodePath)
-		for _, childEntry := range childEntries {
-			if childEntry.IsDir() {
-				if _, decErr := ksid.Parse(childEntry.Name()); decErr == nil {
-					node.HasChildren = true
-					break
-				}
+		// Check if this child has its own children, ignoring orphaned
+		// directories; see RepairWorkspace.
+		childIDs, _ := nodeDirs(nodePath)
+		for _, childID := range childIDs {
+			if hasNodeFile(filepath.Join(nodePath, childID.String())) {
+				node.HasChildren = true
+				break
 			}
 		}
 
@@ -1581,115 +2767,117 @@ func (ws *WorkspaceFileStore) ListChildren(parentID ksid.ID) ([]*Node, error) {
 	return nodes, nil
 }
 
-// Helper functions
-
-// ParseMarkdown parses a markdown file with optional YAML front matter.
-func ParseMarkdown(data []byte) *page {
-	content := string(data)
-	var title, icon, cover string
-	var created, modified storage.Time
-
-	if strings.HasPrefix(content, "---") {
-		parts := strings.SplitN(content, "\n---", 2)
-		if len(parts) == 2 {
-			frontMatter := parts[0][4:]
-			content = strings.TrimLeft(parts[1], "\n")
-			for _, line := range strings.Split(frontMatter, "\n") {
-				switch {
-				case strings.HasPrefix(line, "title:"):
-					title = strings.TrimSpace(strings.TrimPrefix(line, "title:"))
-				case strings.HasPrefix(line, "created:"):
-					dateStr := strings.TrimSpace(strings.TrimPrefix(line, "created:"))
-					if t, err := time.Parse(time.RFC3339, dateStr); err == nil {
-						created = storage.ToTime(t)
-					}
-				case strings.HasPrefix(line, "modified:"):
-					dateStr := strings.TrimSpace(strings.TrimPrefix(line, "modified:"))
-					if t, err := time.Parse(time.RFC3339, dateStr); err == nil {
-						modified = storage.ToTime(t)
-					}
-				case strings.HasPrefix(line, "icon:"):
-					icon = strings.TrimSpace(strings.TrimPrefix(line, "icon:"))
-				case strings.HasPrefix(line, "cover:"):
-					cover = strings.TrimSpace(strings.TrimPrefix(line, "cover:"))
-				}
-			}
+// ReadTree returns the children of rootID with their descendants nested in
+// Children, down to maxDepth levels below them. maxDepth=0 returns only the
+// immediate children. Nodes whose children were not loaded keep HasChildren
+// set so the caller can fetch them lazily.
+func (ws *WorkspaceFileStore) ReadTree(rootID ksid.ID, maxDepth int) ([]*Node, error) {
+	nodes, err := ws.ListChildren(rootID)
+	if err != nil || maxDepth <= 0 {
+		return nodes, err
+	}
+	for _, node := range nodes {
+		if !node.HasChildren {
+			continue
+		}
+		if node.Children, err = ws.ReadTree(node.ID, maxDepth-1); err != nil {
+			return nil, err
 		}
 	}
+	return nodes, nil
+}
 
-	if created.IsZero() {
-		created = storage.Now()
-	}
-	if modified.IsZero() {
-		modified = storage.Now()
-	}
+// Helper functions
 
-	return &page{
-		title:    title,
-		content:  content,
-		created:  created,
-		modified: modified,
-		icon:     icon,
-		cover:    cover,
+// checkTitle returns an error if title cannot be stored on the single title
+// line of the front matter.
+func checkTitle(title string) error {
+	if strings.ContainsAny(title, "\r\n") {
+		return errTitleMultiline
 	}
-}
-
-func formatMarkdownFile(p *page) []byte {
-	var buf bytes.Buffer
-	buf.WriteString("---")
-	buf.WriteString("\ntitle: " + p.title + "\n")
-	buf.WriteString("created: " + p.created.AsTime().Format(time.RFC3339) + "\n")
-	buf.WriteString("modified: " + p.modified.AsTime().Format(time.RFC3339) + "\n")
-	if len(p.tags) > 0 {
-		buf.WriteString("tags: [" + strings.Join(p.tags, ", ") + "]\n")
-	}
-	if p.icon != "" {
-		buf.WriteString("icon: " + p.icon + "\n")
-	}
-	if p.cover != "" {
-		buf.WriteString("cover: " + p.cover + "\n")
-	}
-	buf.WriteString("---")
-	buf.WriteString("\n\n")
-	buf.WriteString(p.content)
-	return buf.Bytes()
+	if strings.ContainsFunc(title, unicode.IsControl) {
+		return errTitleControl
+	}
+	return nil
 }
 
 // --- Page Links Extraction and Backlinks Index ---
 
-// ExtractLinkedNodeIDs extracts all node IDs from relative path links in markdown content.
-// Matches links like [text](../nodeID/index.md) and extracts the nodeID from the directory name.
+// ExtractLinkedNodeIDs extracts all node IDs from links in markdown content.
+// Matches relative path links like [text](../nodeID/index.md), extracting the
+// nodeID from the directory name, and wikilinks like [[nodeID|text]].
 func ExtractLinkedNodeIDs(content string) []ksid.ID {
-	matches := relativeLinkRe.FindAllStringSubmatch(content, -1)
+	matches := nodeLinkRe.FindAllStringSubmatch(content, -1)
 	seen := make(map[string]bool)
 	var ids []ksid.ID
 
 	for _, match := range matches {
-		if len(match) < 3 {
-			continue
-		}
-		href := match[2]
-		if strings.HasPrefix(href, "/") || strings.HasPrefix(href, "http") {
+		var id ksid.ID
+		var ok bool
+		if match[2] != "" {
+			id, ok = linkTargetID(match[2])
+		} else {
+			id, ok = wikiLinkTargetID(match[3])
+		}
+		if !ok || seen[id.String()] {
 			continue
 		}
-		nodeIDStr := filepath.Base(filepath.Dir(href))
-		if seen[nodeIDStr] {
+		seen[id.String()] = true
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// linkTargetID returns the node ID a relative href like ../nodeID/index.md
+// points to.
+func linkTargetID(href string) (ksid.ID, bool) {
+	if strings.HasPrefix(href, "/") || strings.HasPrefix(href, "http") {
+		return 0, false
+	}
+	id, err := ksid.Parse(filepath.Base(filepath.Dir(href)))
+	if err != nil || id.IsZero() {
+		return 0, false
+	}
+	return id, true
+}
+
+// extractAssetNames returns the names of the assets of the page itself
+// referenced by images and links in markdown content, like ![x](image.png).
+// Links to other pages, absolute paths and URLs are ignored.
+func extractAssetNames(content string) []string {
+	var names []string
+	for _, match := range assetLinkRe.FindAllStringSubmatch(content, -1) {
+		name, ok := localAssetName(match[1])
+		if !ok || slices.Contains(names, name) {
 			continue
 		}
-		seen[nodeIDStr] = true
-		id, err := ksid.Parse(nodeIDStr)
-		if err == nil && !id.IsZero() {
-			ids = append(ids, id)
-		}
+		names = append(names, name)
 	}
-	return ids
+	return names
+}
+
+// localAssetName returns the name of the asset of the page itself an href
+// refers to, like image.png or ./my%20file.pdf#page=2. Only names with a file
+// extension are assets: [x](page) is a link, not a missing file.
+func localAssetName(href string) (string, bool) {
+	href, _, _ = strings.Cut(href, "#")
+	href, _, _ = strings.Cut(href, "?")
+	href = strings.TrimPrefix(href, "./")
+	if href == "" || strings.ContainsAny(href, "/:\\") || strings.HasSuffix(href, ".md") || len(filepath.Ext(href)) < 2 {
+		return "", false
+	}
+	name, err := url.PathUnescape(href)
+	if err != nil {
+		return "", false
+	}
+	return name, true
 }
 
 // GetBacklinks returns all nodes that link to the given node.
 // Uses an in-memory cache that is lazily built on first call and
 // incrementally updated on page mutations.
 func (ws *WorkspaceFileStore) GetBacklinks(targetID ksid.ID) ([]BacklinkInfo, error) {
-	if err := ws.links.ensureBuilt(ws.IterPages); err != nil {
+	if err := ws.links.ensureBuilt(ws.loadLinks); err != nil {
 		return nil, err
 	}
 	sourceIDs := ws.links.backlinks(targetID)
@@ -1704,19 +2892,22 @@ func (ws *WorkspaceFileStore) GetBacklinks(targetID ksid.ID) ([]BacklinkInfo, er
 	return backlinks, nil
 }
 
-// InvalidLink describes an internal link whose target node does not exist.
+// InvalidLink describes an internal link whose target node or asset does not
+// exist.
 type InvalidLink struct {
 	SourceID ksid.ID
-	Target   string
+	Target   string // Node ID, or asset name when IsAsset is set
+	IsAsset  bool
 }
 
 // ValidateLinks checks every internal link in the workspace and returns those
-// pointing to non-existent nodes.
+// pointing to non-existent nodes, followed by the images and links pointing to
+// assets missing from their page.
 func (ws *WorkspaceFileStore) ValidateLinks() ([]InvalidLink, error) {
 	if err := ws.refreshCache(); err != nil {
 		return nil, fmt.Errorf("refresh cache: %w", err)
 	}
-	if err := ws.links.ensureBuilt(ws.IterPages); err != nil {
+	if err := ws.links.ensureBuilt(ws.loadLinks); err != nil {
 		return nil, fmt.Errorf("build link cache: %w", err)
 	}
 
@@ -1736,9 +2927,31 @@ func (ws *WorkspaceFileStore) ValidateLinks() ([]InvalidLink, error) {
 			}
 		}
 	}
+
+	pages, err := ws.IterPages()
+	if err != nil {
+		return nil, err
+	}
+	for page := range pages {
+		for _, name := range extractAssetNames(page.Content) {
+			if !ws.assetExists(page.ID, name) {
+				invalid = append(invalid, InvalidLink{SourceID: page.ID, Target: name, IsAsset: true})
+			}
+		}
+	}
 	return invalid, nil
 }
 
+// assetExists reports whether the page has an asset named name, either in the
+// asset store or as a plain file in its directory.
+func (ws *WorkspaceFileStore) assetExists(nodeID ksid.ID, name string) bool {
+	if at, err := ws.assetTable(); err == nil && at.get(nodeID, name) != nil {
+		return true
+	}
+	fi, err := os.Stat(filepath.Join(ws.pageDir(nodeID, ws.getParent(nodeID)), name))
+	return err == nil && fi.Mode().IsRegular()
+}
+
 // GetNodeTitles returns a map of node IDs to their titles for the given IDs.
 func (ws *WorkspaceFileStore) GetNodeTitles(ids []ksid.ID) (map[ksid.ID]string, error) {
 	titles := make(map[ksid.ID]string)
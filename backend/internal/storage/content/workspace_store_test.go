@@ -4,12 +4,18 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
+	"io"
+	"io/fs"
+	"maps"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/maruel/ksid"
+	"github.com/maruel/mddb/backend/internal/jsonldb"
 	"github.com/maruel/mddb/backend/internal/storage"
 	"github.com/maruel/mddb/backend/internal/storage/git"
 	"github.com/maruel/mddb/backend/internal/storage/identity"
@@ -64,13 +70,17 @@ func testFileStore(t *testing.T) (*FileStoreService, ksid.ID) {
 		t.Fatalf("failed to set unlimited workspace quotas: %v", err)
 	}
 
-	serverQuotas := storage.ResourceQuotas{
-		MaxPages:              1_000_000,
-		MaxStorageBytes:       1_000_000_000_000, // 1TB
-		MaxRecordsPerTable:    1_000_000,
-		MaxAssetSizeBytes:     1024 * 1024 * 1024, // 1GB
-		MaxTablesPerWorkspace: 10_000,
-		MaxColumnsPerTable:    1_000,
+	serverQuotas := storage.ServerQuotas{
+		ResourceQuotas: storage.ResourceQuotas{
+			MaxPages:              1_000_000,
+			MaxStorageBytes:       1_000_000_000_000, // 1TB
+			MaxRecordsPerTable:    1_000_000,
+			MaxAssetSizeBytes:     1024 * 1024 * 1024, // 1GB
+			MaxTablesPerWorkspace: 10_000,
+			MaxColumnsPerTable:    1_000,
+			MaxNodeDepth:          1_000,
+			MaxChildrenPerNode:    1_000,
+		},
 	}
 	fs, err := NewFileStoreService(tmpDir, gitMgr, wsService, orgService, &serverQuotas)
 	if err != nil {
@@ -108,6 +118,19 @@ func TestFileStoreService(t *testing.T) {
 		if _, err := os.Stat(agentsPath); err != nil {
 			t.Fatalf("AGENTS.md not found: %v", err)
 		}
+
+		// The initial commit has no author so it uses the manager's default.
+		repo, err := fs.git.Repo(ctx, wsID.String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		history, err := repo.GetHistory(ctx, "AGENTS.md", 1)
+		if err != nil || len(history) != 1 {
+			t.Fatalf("GetHistory() = %v, %v", history, err)
+		}
+		if c := history[0]; c.Author != "test" || c.AuthorEmail != "test@test.com" || c.Committer != "test" || c.CommitterEmail != "test@test.com" {
+			t.Errorf("initial commit by %s <%s>, committed by %s <%s>", c.Author, c.AuthorEmail, c.Committer, c.CommitterEmail)
+		}
 	})
 }
 
@@ -163,6 +186,45 @@ func TestWorkspaceFileStore(t *testing.T) {
 			}
 		})
 
+		t.Run("UpdatePageConflict", func(t *testing.T) {
+			base, err := ws.NodeETag(nodeID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// Two editors start from the same version; the first one wins.
+			opts := UpdatePageOptions{ExpectedETag: base}
+			_, etag, err := ws.UpdatePageWithOptions(ctx, nodeID, "Updated Title", "first", opts, author)
+			if err != nil {
+				t.Fatalf("first update failed: %v", err)
+			}
+			if cur, _ := ws.NodeETag(nodeID); etag != cur || etag == base {
+				t.Errorf("returned ETag = %q, want the new %q", etag, cur)
+			}
+			_, _, err = ws.UpdatePageWithOptions(ctx, nodeID, "Updated Title", "second", opts, author)
+			var conflict *ConflictError
+			if !errors.Is(err, ErrConflict) || !errors.As(err, &conflict) {
+				t.Fatalf("second update: got %v, want ErrConflict", err)
+			}
+			if conflict.Current.Content != "first" {
+				t.Errorf("conflict content = %q, want %q", conflict.Current.Content, "first")
+			}
+			if cur, _ := ws.NodeETag(nodeID); conflict.ETag != cur {
+				t.Errorf("conflict ETag = %q, want %q", conflict.ETag, cur)
+			}
+			if p, _ := ws.ReadPage(nodeID); p.Content != "first" {
+				t.Errorf("content = %q after conflict, want %q", p.Content, "first")
+			}
+			// Retrying with the current version succeeds, as does an update
+			// without an expected version.
+			opts.ExpectedETag = conflict.ETag
+			if _, _, err := ws.UpdatePageWithOptions(ctx, nodeID, "Updated Title", "merged", opts, author); err != nil {
+				t.Fatalf("update with current ETag failed: %v", err)
+			}
+			if _, err := ws.UpdatePage(ctx, nodeID, "Updated Title", "# Updated Content", author); err != nil {
+				t.Fatalf("unconditional update failed: %v", err)
+			}
+		})
+
 		t.Run("DeletePage", func(t *testing.T) {
 			if err := ws.DeletePage(ctx, nodeID, author); err != nil {
 				t.Fatalf("failed to delete page: %v", err)
@@ -374,6 +436,93 @@ func TestWorkspaceFileStore(t *testing.T) {
 			})
 		})
 
+		t.Run("GCBlobs", func(t *testing.T) {
+			keep := &DataRecord{ID: ksid.NewID(), Data: map[string]any{"name": "keep"}, Created: storage.Now(), Modified: storage.Now()}
+			if err := ws.AppendRecord(ctx, tableID, keep, author); err != nil {
+				t.Fatal(err)
+			}
+			// Leave an unreferenced blob behind, as an aborted upload would.
+			table, err := jsonldb.NewTable[*DataRecord](ws.tableRecordsFile(tableID, 0))
+			if err != nil {
+				t.Fatal(err)
+			}
+			w, err := table.NewBlob()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := w.Write([]byte("orphan")); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := w.Close(); err != nil {
+				t.Fatal(err)
+			}
+			blobDir := filepath.Join(ws.pageDir(tableID, 0), "data.blobs")
+			if n := countFiles(t, blobDir); n != 1 {
+				t.Fatalf("expected 1 blob file before GC, got %d", n)
+			}
+			// And an unreferenced asset blob that was committed.
+			at, err := ws.assetTable()
+			if err != nil {
+				t.Fatal(err)
+			}
+			w, err = at.table.NewBlob()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := w.Write([]byte("asset orphan")); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := w.Close(); err != nil {
+				t.Fatal(err)
+			}
+			if err := ws.repo.CommitTx(ctx, author, func() (string, []string, error) {
+				return "orphan", []string{assetBlobsDir}, nil
+			}); err != nil {
+				t.Fatal(err)
+			}
+			assetBlobs := filepath.Join(ws.wsDir, assetBlobsDir)
+			if n := countFiles(t, assetBlobs); n != 1 {
+				t.Fatalf("expected 1 asset blob file before GC, got %d", n)
+			}
+
+			// Blobs within the grace period are kept.
+			if removed, size, err := ws.GCBlobs(ctx); err != nil || removed != 0 || size != 0 {
+				t.Fatalf("GCBlobs() = %d, %d, %v; want nothing removed", removed, size, err)
+			}
+			old := time.Now().Add(-2 * time.Hour)
+			for _, dir := range []string{blobDir, assetBlobs} {
+				err := filepath.WalkDir(dir, func(path string, _ fs.DirEntry, err error) error {
+					if err != nil {
+						return err
+					}
+					return os.Chtimes(path, old, old)
+				})
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+			removed, size, err := ws.GCBlobs(ctx)
+			if err != nil {
+				t.Fatalf("GCBlobs() failed: %v", err)
+			}
+			if want := int64(len("orphan") + len("asset orphan")); removed != 2 || size != want {
+				t.Errorf("GCBlobs() = %d, %d; want 2, %d", removed, size, want)
+			}
+			if n := countFiles(t, blobDir); n != 0 {
+				t.Errorf("expected orphan blob to be removed, %d files left", n)
+			}
+			// The asset blob removal was committed.
+			if _, err := ws.repo.DiscardChanges(ctx); err != nil {
+				t.Fatal(err)
+			}
+			if n := countFiles(t, assetBlobs); n != 0 {
+				t.Errorf("expected asset orphan blob to stay removed, %d files left", n)
+			}
+			if n, err := ws.CountRecords(tableID); err != nil || n != 1 {
+				t.Errorf("CountRecords() = %d, %v; want 1", n, err)
+			}
+		})
+
 		t.Run("DeleteTable", func(t *testing.T) {
 			if err := ws.DeleteTable(ctx, tableID, author); err != nil {
 				t.Fatalf("failed to delete table: %v", err)
@@ -499,6 +648,169 @@ func TestWorkspaceFileStore(t *testing.T) {
 				t.Error("record not found after update with nil data")
 			}
 		})
+
+		t.Run("RecordValidation", func(t *testing.T) {
+			testTable := &Node{
+				ID:    ksid.NewID(),
+				Title: "Typed",
+				Type:  NodeTypeTable,
+				Properties: []Property{
+					{Name: "name", Type: PropertyTypeText, Required: true},
+					{Name: "count", Type: PropertyTypeNumber},
+				},
+			}
+			if err := ws.WriteTable(ctx, testTable, true, author); err != nil {
+				t.Fatal(err)
+			}
+			record := &DataRecord{ID: ksid.NewID(), Data: map[string]any{"name": "a", "count": int64(1)}}
+			if err := ws.AppendRecord(ctx, testTable.ID, record, author); err != nil {
+				t.Fatal(err)
+			}
+			bad := &DataRecord{ID: ksid.NewID(), Data: map[string]any{"name": "b", "count": "many"}}
+			if err := ws.AppendRecord(ctx, testTable.ID, bad, author); !errors.Is(err, ErrInvalidRecord) {
+				t.Errorf("append: got %v, want ErrInvalidRecord", err)
+			}
+			update := &DataRecord{ID: record.ID, Data: map[string]any{"count": int64(2)}}
+			if err := ws.UpdateRecord(ctx, testTable.ID, update, author); !errors.Is(err, ErrInvalidRecord) {
+				t.Errorf("update without required property: got %v, want ErrInvalidRecord", err)
+			}
+			if n, err := ws.CountRecords(testTable.ID); err != nil || n != 1 {
+				t.Errorf("want 1 record, got %d (err %v)", n, err)
+			}
+
+			// Lax tables accept anything and keep the flag across reads.
+			testTable.LaxRecords = true
+			if err := ws.WriteTable(ctx, testTable, false, author); err != nil {
+				t.Fatal(err)
+			}
+			read, err := ws.ReadTable(testTable.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !read.LaxRecords {
+				t.Error("LaxRecords not persisted")
+			}
+			if err := ws.AppendRecord(ctx, testTable.ID, bad, author); err != nil {
+				t.Errorf("lax append: %v", err)
+			}
+		})
+
+		t.Run("Formulas", func(t *testing.T) {
+			testTable := &Node{
+				ID:    ksid.NewID(),
+				Title: "Orders",
+				Type:  NodeTypeTable,
+				Properties: []Property{
+					{Name: "price", Type: PropertyTypeNumber},
+					{Name: "qty", Type: PropertyTypeNumber},
+					{Name: "total", Type: PropertyTypeFormula, FormulaConfig: &FormulaConfig{Expression: "price * qty"}},
+					{Name: "each", Type: PropertyTypeFormula, FormulaConfig: &FormulaConfig{Expression: "total / qty"}},
+				},
+			}
+			if err := ws.WriteTable(ctx, testTable, true, author); err != nil {
+				t.Fatal(err)
+			}
+			record := &DataRecord{ID: ksid.NewID(), Data: map[string]any{"price": float64(2.5), "qty": int64(0), "total": "client value"}}
+			if err := ws.AppendRecord(ctx, testTable.ID, record, author); err != nil {
+				t.Fatal(err)
+			}
+			if _, ok := record.Data["total"]; !ok {
+				t.Error("caller's record was modified")
+			}
+			data, err := os.ReadFile(ws.tableRecordsFile(testTable.ID, 0))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if bytes.Contains(data, []byte("client value")) {
+				t.Errorf("formula value persisted:\n%s", data)
+			}
+
+			records, _, err := ws.ReadRecordsPage(testTable.ID, 0, 10)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// "each" refers to another formula, which is an error.
+			if len(records) != 1 || records[0].Data["total"] != float64(0) || records[0].Data["each"] != FormulaErrorValue {
+				t.Fatalf("unexpected records: %+v", records)
+			}
+			record.Data["qty"] = int64(3)
+			if err := ws.UpdateRecord(ctx, testTable.ID, record, author); err != nil {
+				t.Fatal(err)
+			}
+			it, err := ws.IterRecords(testTable.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for r := range it {
+				if r.Data["total"] != float64(7.5) {
+					t.Errorf("total = %v, want 7.5", r.Data["total"])
+				}
+			}
+		})
+
+		t.Run("SortedPage", func(t *testing.T) {
+			testTable := &Node{
+				ID:    ksid.NewID(),
+				Title: "Sortable",
+				Type:  NodeTypeTable,
+				Properties: []Property{
+					{Name: "name", Type: PropertyTypeText},
+					{Name: "rank", Type: PropertyTypeNumber},
+					{Name: "due", Type: PropertyTypeDate},
+					{Name: "team", Type: PropertyTypeSelect},
+				},
+			}
+			if err := ws.WriteTable(ctx, testTable, true, author); err != nil {
+				t.Fatal(err)
+			}
+			for _, d := range []map[string]any{
+				{"name": "b", "rank": int64(10), "due": "2024-03-01", "team": "red"},
+				{"name": "a", "rank": int64(9), "due": "2024-01-15T10:00:00Z", "team": "blue"},
+				{"name": "d", "rank": int64(100), "due": "2023-12-31", "team": "red"},
+				{"name": "c", "rank": int64(2), "due": "2024-02-01", "team": "red"},
+			} {
+				if err := ws.AppendRecord(ctx, testTable.ID, &DataRecord{ID: ksid.NewID(), Data: d}, author); err != nil {
+					t.Fatal(err)
+				}
+			}
+			names := func(records []*DataRecord) string {
+				var out []string
+				for _, r := range records {
+					out = append(out, r.Data["name"].(string))
+				}
+				return strings.Join(out, ",")
+			}
+			tests := []struct {
+				name      string
+				sortBy    string
+				desc      bool
+				filter    map[string]any
+				offset    int
+				limit     int
+				want      string
+				wantTotal int
+			}{
+				{"Number", "rank", false, nil, 0, 10, "c,a,b,d", 4},
+				{"Text", "name", false, nil, 0, 10, "a,b,c,d", 4},
+				{"Date", "due", false, nil, 0, 10, "d,a,c,b", 4},
+				{"Descending", "rank", true, nil, 0, 10, "d,b,a,c", 4},
+				{"FilterNone", "rank", false, map[string]any{"team": "green"}, 0, 10, "", 0},
+				{"FilterPage", "rank", false, map[string]any{"team": "red"}, 1, 1, "b", 3},
+				{"FilterNumber", "", false, map[string]any{"rank": int64(9)}, 0, 10, "a", 1},
+				{"PastEnd", "rank", false, nil, 10, 10, "", 4},
+			}
+			for _, tt := range tests {
+				t.Run(tt.name, func(t *testing.T) {
+					records, total, err := ws.ReadRecordsPageSorted(testTable.ID, tt.sortBy, tt.desc, tt.filter, tt.offset, tt.limit)
+					if err != nil {
+						t.Fatal(err)
+					}
+					if got := names(records); got != tt.want || total != tt.wantTotal {
+						t.Errorf("got %q (total %d), want %q (total %d)", got, total, tt.want, tt.wantTotal)
+					}
+				})
+			}
+		})
 	})
 
 	t.Run("AssetOperations", func(t *testing.T) {
@@ -533,6 +845,107 @@ func TestWorkspaceFileStore(t *testing.T) {
 			}
 		})
 
+		t.Run("OpenAsset", func(t *testing.T) {
+			f, info, err := ws.OpenAsset(nodeID, assetName)
+			if err != nil {
+				t.Fatalf("failed to open asset: %v", err)
+			}
+			defer func() { _ = f.Close() }()
+			if info.Size() != int64(len(assetData)) {
+				t.Errorf("expected size %d, got %d", len(assetData), info.Size())
+			}
+			if _, err := f.Seek(5, io.SeekStart); err != nil {
+				t.Fatalf("failed to seek: %v", err)
+			}
+			data, err := io.ReadAll(f)
+			if err != nil {
+				t.Fatalf("failed to read: %v", err)
+			}
+			if !bytes.Equal(data, assetData[5:]) {
+				t.Errorf("expected data %q, got %q", string(assetData[5:]), string(data))
+			}
+			if _, _, err := ws.OpenAsset(nodeID, "missing.txt"); !errors.Is(err, ErrAssetNotFound) {
+				t.Errorf("expected ErrAssetNotFound, got %v", err)
+			}
+		})
+
+		t.Run("PathTraversal", func(t *testing.T) {
+			other := ksid.NewID()
+			if _, err := ws.WritePage(ctx, other, 0, "Other", "secret", author); err != nil {
+				t.Fatal(err)
+			}
+			for _, name := range []string{"", ".", "..", "../" + other.String() + "/index.md", "..\\x", "a/b", "/etc/passwd", "x\x00"} {
+				if _, _, err := ws.OpenAsset(nodeID, name); !errors.Is(err, ErrInvalidAssetName) {
+					t.Errorf("OpenAsset(%q) = %v, want ErrInvalidAssetName", name, err)
+				}
+				if _, err := ws.SaveAsset(ctx, nodeID, name, []byte("x"), author); !errors.Is(err, ErrInvalidAssetName) {
+					t.Errorf("SaveAsset(%q) = %v, want ErrInvalidAssetName", name, err)
+				}
+				if err := ws.DeleteAsset(ctx, nodeID, name, author); !errors.Is(err, ErrInvalidAssetName) {
+					t.Errorf("DeleteAsset(%q) = %v, want ErrInvalidAssetName", name, err)
+				}
+			}
+			if data, err := ws.ReadPage(other); err != nil || data.Content != "secret" {
+				t.Errorf("other page changed: %v, %v", data, err)
+			}
+		})
+
+		t.Run("ReservedName", func(t *testing.T) {
+			table, err := ws.CreateTableUnderParent(ctx, 0, "Table", []Property{{Name: "Name", Type: PropertyTypeText}}, author)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, id := range []ksid.ID{0, nodeID, table.ID} {
+				for _, name := range []string{"index.md", "INDEX.MD", "metadata.json", "data.jsonl", "data.blobs", "assets.jsonl", "assets.blobs", ".git"} {
+					if _, err := ws.SaveAsset(ctx, id, name, []byte("x"), author); !errors.Is(err, ErrInvalidAssetName) {
+						t.Errorf("SaveAsset(%s, %q) = %v, want ErrInvalidAssetName", id, name, err)
+					}
+					if err := ws.DeleteAsset(ctx, id, name, author); !errors.Is(err, ErrInvalidAssetName) {
+						t.Errorf("DeleteAsset(%s, %q) = %v, want ErrInvalidAssetName", id, name, err)
+					}
+				}
+			}
+			if page, err := ws.ReadPage(nodeID); err != nil || page.Content != "content" {
+				t.Errorf("page changed: %v, %v", page, err)
+			}
+			if _, err := ws.ReadTable(table.ID); err != nil {
+				t.Errorf("table changed: %v", err)
+			}
+			assets, err := ws.IterAssets(nodeID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for a := range assets {
+				if a.Name != assetName {
+					t.Errorf("unexpected asset %q", a.Name)
+				}
+			}
+		})
+
+		t.Run("AssetETag", func(t *testing.T) {
+			etag := func(name string) string {
+				f, info, err := ws.OpenAsset(nodeID, name)
+				if err != nil {
+					t.Fatal(err)
+				}
+				_ = f.Close()
+				return AssetETag(info)
+			}
+			for _, c := range []struct{ name, data string }{{"a.txt", "same"}, {"b.txt", "same"}, {"c.txt", "different"}} {
+				if _, err := ws.SaveAsset(ctx, nodeID, c.name, []byte(c.data), author); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if a, b, c := etag("a.txt"), etag("b.txt"), etag("c.txt"); a != b || a == c {
+				t.Errorf("ETags do not follow the content: %q, %q, %q", a, b, c)
+			}
+			for _, name := range []string{"a.txt", "b.txt", "c.txt"} {
+				if err := ws.DeleteAsset(ctx, nodeID, name, author); err != nil {
+					t.Fatal(err)
+				}
+			}
+		})
+
 		t.Run("IterAssets", func(t *testing.T) {
 			it, err := ws.IterAssets(nodeID)
 			if err != nil {
@@ -558,6 +971,120 @@ func TestWorkspaceFileStore(t *testing.T) {
 				t.Error("expected error reading deleted asset")
 			}
 		})
+
+		t.Run("Deduplicated", func(t *testing.T) {
+			_, ws, _ := initWS(t)
+			var pages []ksid.ID
+			for range 5 {
+				node, err := ws.CreatePageUnderParent(ctx, 0, "Page", "", author)
+				if err != nil {
+					t.Fatal(err)
+				}
+				pages = append(pages, node.ID)
+			}
+			data := bytes.Repeat([]byte("same image "), 10_000)
+			if _, err := ws.SaveAsset(ctx, pages[0], "logo.png", data, author); err != nil {
+				t.Fatal(err)
+			}
+			before, err := dirUsage(ws.wsDir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, id := range pages[1:] {
+				if _, err := ws.SaveAsset(ctx, id, "logo.png", data, author); err != nil {
+					t.Fatal(err)
+				}
+			}
+			after, err := dirUsage(ws.wsDir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if grown := after - before; grown >= int64(len(data)) {
+				t.Errorf("disk usage grew by %d bytes for %d copies of %d bytes", grown, len(pages)-1, len(data))
+			}
+			blobDir := filepath.Join(ws.wsDir, "assets.blobs")
+			if n := countFiles(t, blobDir); n != 1 {
+				t.Errorf("expected 1 blob file, got %d", n)
+			}
+			for _, id := range pages {
+				if got, err := ws.ReadAsset(id, "logo.png"); err != nil || !bytes.Equal(got, data) {
+					t.Errorf("ReadAsset(%s) = %d bytes, %v", id, len(got), err)
+				}
+			}
+
+			// The blob is removed with its last reference, whether the asset or
+			// its page is deleted.
+			if err := ws.DeletePage(ctx, pages[0], author); err != nil {
+				t.Fatal(err)
+			}
+			for _, id := range pages[1:4] {
+				if err := ws.DeleteAsset(ctx, id, "logo.png", author); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if n := countFiles(t, blobDir); n != 1 {
+				t.Errorf("expected the blob to be kept while referenced, got %d files", n)
+			}
+			if err := ws.DeleteAsset(ctx, pages[4], "logo.png", author); err != nil {
+				t.Fatal(err)
+			}
+			if n := countFiles(t, blobDir); n != 0 {
+				t.Errorf("expected the blob to be removed, %d files left", n)
+			}
+			if _, err := ws.ReadAsset(pages[4], "logo.png"); !errors.Is(err, ErrAssetNotFound) {
+				t.Errorf("expected ErrAssetNotFound, got %v", err)
+			}
+		})
+
+		t.Run("PlainFile", func(t *testing.T) {
+			_, ws, _ := initWS(t)
+			node, err := ws.CreatePageUnderParent(ctx, 0, "Page", "", author)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// Assets saved before the blob store are plain files in the page
+			// directory.
+			if err := ws.repo.CommitTx(ctx, author, func() (string, []string, error) {
+				err := os.WriteFile(filepath.Join(ws.pageDir(node.ID, 0), "old.png"), []byte("old"), 0o600)
+				return "old asset", []string{ws.gitPath(0, node.ID, "old.png")}, err
+			}); err != nil {
+				t.Fatal(err)
+			}
+			if got, err := ws.ReadAsset(node.ID, "old.png"); err != nil || string(got) != "old" {
+				t.Errorf("ReadAsset = %q, %v", got, err)
+			}
+			if _, err := ws.SaveAsset(ctx, node.ID, "new.png", []byte("new"), author); err != nil {
+				t.Fatal(err)
+			}
+			it, err := ws.IterAssets(node.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var names []string
+			for a := range it {
+				names = append(names, a.Name)
+			}
+			if want := []string{"new.png", "old.png"}; !slices.Equal(names, want) {
+				t.Errorf("IterAssets = %v, want %v", names, want)
+			}
+
+			// Saving over a plain file moves it to the blob store.
+			if _, err := ws.SaveAsset(ctx, node.ID, "old.png", []byte("replaced"), author); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := os.Stat(filepath.Join(ws.pageDir(node.ID, 0), "old.png")); !os.IsNotExist(err) {
+				t.Errorf("plain file not removed: %v", err)
+			}
+			if got, err := ws.ReadAsset(node.ID, "old.png"); err != nil || string(got) != "replaced" {
+				t.Errorf("ReadAsset = %q, %v", got, err)
+			}
+			if err := ws.DeleteAsset(ctx, node.ID, "old.png", author); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := ws.ReadAsset(node.ID, "old.png"); !errors.Is(err, ErrAssetNotFound) {
+				t.Errorf("expected ErrAssetNotFound, got %v", err)
+			}
+		})
 	})
 
 	t.Run("UpdatePageFrontmatter", func(t *testing.T) {
@@ -630,20 +1157,199 @@ func TestWorkspaceFileStore(t *testing.T) {
 		})
 	})
 
-	t.Run("PageVersionHistory", func(t *testing.T) {
+	t.Run("RenameNode", func(t *testing.T) {
 		_, ws, _ := initWS(t)
 		ctx := t.Context()
-		nodeID := ksid.NewID()
+		// Leading blank lines and trailing whitespace must survive the rename.
+		body := "\n\n# Heading\n\nSome text.  \n---\ntitle: not front matter\n"
 
-		if _, err := ws.WritePage(ctx, nodeID, 0, "Initial", "initial content", author); err != nil {
-			t.Fatalf("failed to write initial page: %v", err)
-		}
-		if _, err := ws.UpdatePage(ctx, nodeID, "Updated", "updated content", author); err != nil {
-			t.Fatalf("failed to update page: %v", err)
-		}
+		t.Run("Page", func(t *testing.T) {
+			node, err := ws.CreatePageUnderParent(ctx, 0, "Old", "", author)
+			if err != nil {
+				t.Fatal(err)
+			}
+			path := ws.pageIndexFile(node.ID, 0)
+			// Write the body directly so that it is not normalized.
+			before, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			before = append(before, body...)
+			if err := os.WriteFile(path, before, 0o644); err != nil {
+				t.Fatal(err)
+			}
+			orig, err := ws.ReadPage(node.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
 
-		history, err := ws.GetHistory(ctx, nodeID, 10)
-		if err != nil {
+			renamed, err := ws.RenameNode(ctx, node.ID, "New", author)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if renamed.Title != "New" || renamed.Created != orig.Created || renamed.Content != orig.Content {
+				t.Errorf("unexpected node: %+v", renamed)
+			}
+			after, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.HasSuffix(after, []byte("---\n\n"+body)) {
+				t.Errorf("body changed:\n%q", after)
+			}
+			page, err := ws.ReadPage(node.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if page.Title != "New" || page.Created != orig.Created {
+				t.Errorf("unexpected page: %+v", page)
+			}
+			history, err := ws.GetHistory(ctx, node.ID, 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(history) != 1 || !strings.HasPrefix(history[0].Message, "rename: ") {
+				t.Errorf("unexpected history: %+v", history)
+			}
+		})
+
+		t.Run("Hybrid", func(t *testing.T) {
+			node, err := ws.CreateNode(ctx, "Old", NodeTypeHybrid, 0, author)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := ws.UpdatePage(ctx, node.ID, "Old", body, author); err != nil {
+				t.Fatal(err)
+			}
+			before, err := ws.ReadPage(node.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			count, err := ws.CommitCount(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if _, err := ws.RenameNode(ctx, node.ID, "New", author); err != nil {
+				t.Fatal(err)
+			}
+			page, err := ws.ReadPage(node.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if page.Title != "New" || page.Content != before.Content {
+				t.Errorf("unexpected page: %+v", page)
+			}
+			table, err := ws.ReadTable(node.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if table.Title != "New" {
+				t.Errorf("table title = %q", table.Title)
+			}
+			if got, err := ws.CommitCount(ctx); err != nil || got != count+1 {
+				t.Errorf("want one commit, got %d new (err %v)", got-count, err)
+			}
+		})
+
+		t.Run("Errors", func(t *testing.T) {
+			if _, err := ws.RenameNode(ctx, ksid.NewID(), "New", author); !errors.Is(err, ErrPageNotFound) {
+				t.Errorf("missing node: got %v", err)
+			}
+			node, err := ws.CreatePageUnderParent(ctx, 0, "Old", "", author)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := ws.RenameNode(ctx, node.ID, "two\nlines", author); !errors.Is(err, errTitleMultiline) {
+				t.Errorf("multiline title: got %v", err)
+			}
+		})
+	})
+
+	t.Run("Titles", func(t *testing.T) {
+		_, ws, _ := initWS(t)
+		ctx := t.Context()
+		content := "before\n---\nafter"
+
+		t.Run("RoundTrip", func(t *testing.T) {
+			for _, title := range []string{
+				"Key: value",
+				"  padded  ",
+				"---",
+				`"quoted" title`,
+				`back\slash`,
+			} {
+				node, err := ws.CreatePageUnderParent(ctx, 0, title, content, author)
+				if err != nil {
+					t.Fatalf("%q: %v", title, err)
+				}
+				for _, step := range []string{"create", "update", "rename"} {
+					switch step {
+					case "update":
+						_, err = ws.UpdatePage(ctx, node.ID, title, content, author)
+					case "rename":
+						_, err = ws.RenameNode(ctx, node.ID, title, author)
+					}
+					if err != nil {
+						t.Fatalf("%q: %s: %v", title, step, err)
+					}
+					got, err := ws.ReadPage(node.ID)
+					if err != nil {
+						t.Fatalf("%q: %s: %v", title, step, err)
+					}
+					if got.Title != title || got.Content != content {
+						t.Errorf("%s: got title %q content %q, want %q %q", step, got.Title, got.Content, title, content)
+					}
+				}
+			}
+		})
+
+		t.Run("NotionQuoted", func(t *testing.T) {
+			p := ParseMarkdown([]byte("---\ntitle: \"Plan: Q3 \\\"draft\\\"\"\n---\n\nbody"))
+			if want := `Plan: Q3 "draft"`; p.title != want || p.content != "body" {
+				t.Errorf("got %q %q, want %q", p.title, p.content, want)
+			}
+		})
+
+		t.Run("Rejected", func(t *testing.T) {
+			page, err := ws.CreatePageUnderParent(ctx, 0, "Page", "", author)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, title := range []string{"two\nlines", "end\n---", "cr\r", "tab\there", "nul\x00"} {
+				if _, err := ws.CreatePageUnderParent(ctx, 0, title, content, author); !errors.Is(err, ErrInvalidTitle) {
+					t.Errorf("CreatePageUnderParent(%q): got %v", title, err)
+				}
+				if _, err := ws.CreateNode(ctx, title, NodeTypeDocument, 0, author); !errors.Is(err, ErrInvalidTitle) {
+					t.Errorf("CreateNode(%q): got %v", title, err)
+				}
+				if _, err := ws.WritePage(ctx, ksid.NewID(), 0, title, content, author); !errors.Is(err, ErrInvalidTitle) {
+					t.Errorf("WritePage(%q): got %v", title, err)
+				}
+				if _, err := ws.UpdatePage(ctx, page.ID, title, content, author); !errors.Is(err, ErrInvalidTitle) {
+					t.Errorf("UpdatePage(%q): got %v", title, err)
+				}
+			}
+			if got, err := ws.ReadPage(page.ID); err != nil || got.Title != "Page" {
+				t.Errorf("page was modified: %+v, %v", got, err)
+			}
+		})
+	})
+
+	t.Run("PageVersionHistory", func(t *testing.T) {
+		_, ws, _ := initWS(t)
+		ctx := t.Context()
+		nodeID := ksid.NewID()
+
+		if _, err := ws.WritePage(ctx, nodeID, 0, "Initial", "initial content", author); err != nil {
+			t.Fatalf("failed to write initial page: %v", err)
+		}
+		if _, err := ws.UpdatePage(ctx, nodeID, "Updated", "updated content", author); err != nil {
+			t.Fatalf("failed to update page: %v", err)
+		}
+
+		history, err := ws.GetHistory(ctx, nodeID, 10)
+		if err != nil {
 			t.Fatalf("failed to get history: %v", err)
 		}
 		if len(history) < 2 {
@@ -651,6 +1357,101 @@ func TestWorkspaceFileStore(t *testing.T) {
 		}
 	})
 
+	t.Run("RevertNode", func(t *testing.T) {
+		_, ws, _ := initWS(t)
+		ctx := t.Context()
+		parent, err := ws.CreatePageUnderParent(ctx, 0, "Parent", "", author)
+		if err != nil {
+			t.Fatal(err)
+		}
+		node, err := ws.CreatePageUnderParent(ctx, parent.ID, "v0", "content v0", author)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, v := range []string{"v1", "v2", "v3"} {
+			if _, err := ws.UpdatePage(ctx, node.ID, v, "content "+v, author); err != nil {
+				t.Fatal(err)
+			}
+		}
+		history, err := ws.GetHistory(ctx, node.ID, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		first := history[len(history)-1].Hash
+
+		reverted, err := ws.RevertNode(ctx, node.ID, first, author)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if reverted.ID != node.ID || reverted.ParentID != parent.ID || reverted.Title != "v0" || reverted.Content != "content v0" {
+			t.Errorf("unexpected reverted node: %+v", reverted)
+		}
+		page, err := ws.ReadPage(node.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if page.Title != "v0" || page.Content != "content v0" {
+			t.Errorf("unexpected page: %+v", page)
+		}
+		after, err := ws.GetHistory(ctx, node.ID, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(after) != len(history)+1 || !strings.HasPrefix(after[0].Message, "revert:") || !strings.Contains(after[0].Message, first) {
+			t.Errorf("want a revert commit on top of the history, got %+v", after[0])
+		}
+
+		t.Run("Hybrid", func(t *testing.T) {
+			hybrid, err := ws.CreateNode(ctx, "Hybrid", NodeTypeHybrid, 0, author)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := ws.UpdatePage(ctx, hybrid.ID, "Hybrid", "edited", author); err != nil {
+				t.Fatal(err)
+			}
+			history, err := ws.GetHistory(ctx, hybrid.ID, 10)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := ws.RevertNode(ctx, hybrid.ID, history[len(history)-1].Hash, author); err != nil {
+				t.Fatal(err)
+			}
+			after, err := ws.GetHistory(ctx, hybrid.ID, 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(after[0].Body, git.TrailerType+": "+string(NodeTypeHybrid)) {
+				t.Errorf("want the node type in the revert commit, got %q", after[0].Body)
+			}
+		})
+
+		t.Run("Errors", func(t *testing.T) {
+			// The parent's creation predates the node.
+			parentHistory, err := ws.GetHistory(ctx, parent.ID, 10)
+			if err != nil {
+				t.Fatal(err)
+			}
+			before := parentHistory[len(parentHistory)-1].Hash
+			if _, err := ws.RevertNode(ctx, node.ID, before, author); !errors.Is(err, ErrPageNotFound) {
+				t.Errorf("node missing at commit: got %v", err)
+			}
+			if _, err := ws.RevertNode(ctx, node.ID, "0123456789abcdef0123456789abcdef01234567", author); !errors.Is(err, ErrPageNotFound) {
+				t.Errorf("unknown commit: got %v", err)
+			}
+			if _, err := ws.RevertNode(ctx, ksid.NewID(), first, author); !errors.Is(err, ErrPageNotFound) {
+				t.Errorf("unknown node: got %v", err)
+			}
+			for _, hash := range []string{"HEAD~1", "--output=x", first[:7], strings.ToUpper(first)} {
+				if _, err := ws.RevertNode(ctx, node.ID, hash, author); !errors.Is(err, git.ErrInvalidHash) {
+					t.Errorf("hash %q: got %v, want git.ErrInvalidHash", hash, err)
+				}
+			}
+			if page, err := ws.ReadPage(node.ID); err != nil || page.Content != "content v0" {
+				t.Errorf("page was modified: %+v, %v", page, err)
+			}
+		})
+	})
+
 	t.Run("NestedPageVersionHistory", func(t *testing.T) {
 		_, ws, _ := initWS(t)
 		ctx := t.Context()
@@ -690,6 +1491,40 @@ func TestWorkspaceFileStore(t *testing.T) {
 		}
 	})
 
+	t.Run("CommitTrailers", func(t *testing.T) {
+		_, ws, _ := initWS(t)
+		ctx := t.Context()
+		actor := git.Author{Name: "Test", Email: "test@test.com", ID: "USER1"}
+
+		node, err := ws.CreateNode(ctx, "Trailers", NodeTypeDocument, 0, actor)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := ws.UpdatePage(ctx, node.ID, "Trailers", "body", actor); err != nil {
+			t.Fatal(err)
+		}
+
+		history, err := ws.GetHistory(ctx, node.ID, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(history) != 2 {
+			t.Fatalf("got %d commits, want 2", len(history))
+		}
+		want := []map[string]string{
+			{"mddb-op": "update", "mddb-node": node.ID.String(), "mddb-type": "document", "mddb-actor": "USER1"},
+			{"mddb-op": "create", "mddb-node": node.ID.String(), "mddb-type": "document", "mddb-actor": "USER1"},
+		}
+		for i, c := range history {
+			if got := git.ParseTrailers(c.Body); !maps.Equal(got, want[i]) {
+				t.Errorf("commit %d trailers = %v, want %v", i, got, want[i])
+			}
+			if !strings.HasPrefix(c.Message, want[i]["mddb-op"]+": ") {
+				t.Errorf("commit %d subject = %q, want human summary", i, c.Message)
+			}
+		}
+	})
+
 	t.Run("NodeTree", func(t *testing.T) {
 		_, ws, _ := initWS(t)
 		ctx := t.Context()
@@ -737,6 +1572,73 @@ func TestWorkspaceFileStore(t *testing.T) {
 		}
 	})
 
+	t.Run("ReadTree", func(t *testing.T) {
+		_, ws, _ := initWS(t)
+		ctx := t.Context()
+
+		// A chain of 4 levels plus a leaf sibling at the top.
+		var chain []ksid.ID
+		var parentID ksid.ID
+		for i := range 4 {
+			node, err := ws.CreateNode(ctx, fmt.Sprintf("Level %d", i), NodeTypeDocument, parentID, author)
+			if err != nil {
+				t.Fatal(err)
+			}
+			chain = append(chain, node.ID)
+			parentID = node.ID
+		}
+		leaf, err := ws.CreateNode(ctx, "Leaf", NodeTypeDocument, 0, author)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for maxDepth := range 5 {
+			t.Run(fmt.Sprintf("depth%d", maxDepth), func(t *testing.T) {
+				nodes, err := ws.ReadTree(0, maxDepth)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(nodes) != 2 {
+					t.Fatalf("got %d top-level nodes, want 2", len(nodes))
+				}
+				var node *Node
+				for _, n := range nodes {
+					switch n.ID {
+					case leaf.ID:
+						if n.HasChildren || n.Children != nil {
+							t.Errorf("leaf: HasChildren=%v, %d children", n.HasChildren, len(n.Children))
+						}
+					case chain[0]:
+						node = n
+					}
+				}
+				if node == nil {
+					t.Fatal("chain root missing")
+				}
+				// Walk down: levels 0..maxDepth-1 are expanded, the rest truncated.
+				for level := range chain {
+					if node.ID != chain[level] {
+						t.Fatalf("level %d: got %s, want %s", level, node.ID, chain[level])
+					}
+					last := level == len(chain)-1
+					if node.HasChildren == last {
+						t.Errorf("level %d: HasChildren=%v, want %v", level, node.HasChildren, !last)
+					}
+					if level == maxDepth || last {
+						if node.Children != nil {
+							t.Errorf("level %d: got %d children, want truncation", level, len(node.Children))
+						}
+						break
+					}
+					if len(node.Children) != 1 {
+						t.Fatalf("level %d: got %d children, want 1", level, len(node.Children))
+					}
+					node = node.Children[0]
+				}
+			})
+		}
+	})
+
 	t.Run("ListChildren_ReturnsOnlyTopLevelNodes", func(t *testing.T) {
 		_, ws, _ := initWS(t)
 		ctx := t.Context()
@@ -792,6 +1694,73 @@ func TestWorkspaceFileStore(t *testing.T) {
 		}
 	})
 
+	t.Run("Iteration_IsSortedByID", func(t *testing.T) {
+		_, ws, _ := initWS(t)
+		ctx := t.Context()
+
+		for i := range 3 {
+			if _, err := ws.CreatePageUnderParent(ctx, 0, fmt.Sprintf("Page %d", i), "", author); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := ws.CreateTableUnderParent(ctx, 0, fmt.Sprintf("Table %d", i), nil, author); err != nil {
+				t.Fatal(err)
+			}
+		}
+		// Encoded IDs have no leading zeros so "10" (32) sorts before "V" (31)
+		// by name; iteration must still follow ID order.
+		for _, id := range []ksid.ID{32, 31} {
+			dir := filepath.Join(ws.wsDir, id.String())
+			if err := os.Mkdir(dir, 0o755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(filepath.Join(dir, "index.md"), []byte("---\ntitle: Old\n---\n"), 0o644); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		collect := func() []ksid.ID {
+			var ids []ksid.ID
+			pages, err := ws.IterPages()
+			if err != nil {
+				t.Fatal(err)
+			}
+			for n := range pages {
+				ids = append(ids, n.ID)
+			}
+			tables, err := ws.IterTables()
+			if err != nil {
+				t.Fatal(err)
+			}
+			for n := range tables {
+				ids = append(ids, n.ID)
+			}
+			children, err := ws.ListChildren(0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, n := range children {
+				ids = append(ids, n.ID)
+			}
+			return ids
+		}
+		first := collect()
+		if second := collect(); !slices.Equal(first, second) {
+			t.Errorf("iteration order changed: %v then %v", first, second)
+		}
+		// 5 pages, 3 tables, 8 children.
+		if len(first) != 16 {
+			t.Fatalf("got %d nodes, want 16", len(first))
+		}
+		for _, part := range [][]ksid.ID{first[:5], first[5:8], first[8:]} {
+			if !slices.IsSorted(part) {
+				t.Errorf("not sorted by ID: %v", part)
+			}
+		}
+		if first[0] != 31 || first[1] != 32 {
+			t.Errorf("want IDs 31, 32 first, got %v", first[:2])
+		}
+	})
+
 	t.Run("TopLevelPages", func(t *testing.T) {
 		fs, ws, wsID := initWS(t)
 		ctx := t.Context()
@@ -1055,7 +2024,7 @@ func TestWorkspaceFileStore(t *testing.T) {
 			}
 
 			err = ws.MoveNode(ctx, parent.ID, child.ID, author)
-			if !errors.Is(err, errCycleDetected) {
+			if !errors.Is(err, ErrCycleDetected) {
 				t.Errorf("expected cycle error, got %v", err)
 			}
 		})
@@ -1078,7 +2047,7 @@ func TestWorkspaceFileStore(t *testing.T) {
 			}
 
 			err = ws.MoveNode(ctx, a.ID, c.ID, author)
-			if !errors.Is(err, errCycleDetected) {
+			if !errors.Is(err, ErrCycleDetected) {
 				t.Errorf("expected cycle error for descendant, got %v", err)
 			}
 		})
@@ -1141,85 +2110,318 @@ func TestWorkspaceFileStore(t *testing.T) {
 			if node.Title != "Grandchild" {
 				t.Errorf("expected title 'Grandchild', got %q", node.Title)
 			}
-
-			gc, err := ws.ListChildren(child.ID)
+
+			gc, err := ws.ListChildren(child.ID)
+			if err != nil {
+				t.Fatalf("list grandchild: %v", err)
+			}
+			if len(gc) != 1 || gc[0].ID != grandchild.ID {
+				t.Errorf("expected grandchild under child, got %v", gc)
+			}
+		})
+
+		t.Run("MoveNonExistent", func(t *testing.T) {
+			_, ws, _ := initWS(t)
+			ctx := t.Context()
+
+			err := ws.MoveNode(ctx, ksid.NewID(), 0, author)
+			if err == nil {
+				t.Error("expected error moving non-existent node")
+			}
+		})
+
+		t.Run("MoveToNonExistentParent", func(t *testing.T) {
+			_, ws, _ := initWS(t)
+			ctx := t.Context()
+
+			node, err := ws.CreateNode(ctx, "Node", NodeTypeDocument, 0, author)
+			if err != nil {
+				t.Fatalf("create node: %v", err)
+			}
+
+			err = ws.MoveNode(ctx, node.ID, ksid.NewID(), author)
+			if err == nil {
+				t.Error("expected error moving to non-existent parent")
+			}
+		})
+
+		t.Run("PreservesLinks", func(t *testing.T) {
+			_, ws, _ := initWS(t)
+			ctx := t.Context()
+
+			pageA, err := ws.CreatePageUnderParent(ctx, 0, "A", "", author)
+			if err != nil {
+				t.Fatalf("create A: %v", err)
+			}
+			pageB, err := ws.CreatePageUnderParent(ctx, 0, "B", "", author)
+			if err != nil {
+				t.Fatalf("create B: %v", err)
+			}
+			pageC, err := ws.CreatePageUnderParent(ctx, pageA.ID, "C", "", author)
+			if err != nil {
+				t.Fatalf("create C: %v", err)
+			}
+
+			cLink := fmt.Sprintf("See [B](../%s/index.md)", pageB.ID)
+			if _, err := ws.UpdatePage(ctx, pageC.ID, "C", cLink, author); err != nil {
+				t.Fatalf("update C: %v", err)
+			}
+			bLink := fmt.Sprintf("See [C](../%s/index.md)", pageC.ID)
+			if _, err := ws.UpdatePage(ctx, pageB.ID, "B", bLink, author); err != nil {
+				t.Fatalf("update B: %v", err)
+			}
+
+			if err := ws.MoveNode(ctx, pageC.ID, 0, author); err != nil {
+				t.Fatalf("move C: %v", err)
+			}
+
+			nodeC, err := ws.ReadPage(pageC.ID)
+			if err != nil {
+				t.Fatalf("read C: %v", err)
+			}
+			if nodeC.Content != cLink {
+				t.Errorf("C link should be preserved, want %q, got %q", cLink, nodeC.Content)
+			}
+
+			nodeB, err := ws.ReadPage(pageB.ID)
+			if err != nil {
+				t.Fatalf("read B: %v", err)
+			}
+			if nodeB.Content != bLink {
+				t.Errorf("B link should be preserved, want %q, got %q", bLink, nodeB.Content)
+			}
+		})
+
+		t.Run("RewritesInboundLinks", func(t *testing.T) {
+			_, ws, _ := initWS(t)
+			ctx := t.Context()
+
+			// Root pages S, P and N; K under N and Q under P.
+			s, err := ws.CreatePageUnderParent(ctx, 0, "S", "", author)
+			if err != nil {
+				t.Fatal(err)
+			}
+			p, err := ws.CreatePageUnderParent(ctx, 0, "P", "", author)
+			if err != nil {
+				t.Fatal(err)
+			}
+			n, err := ws.CreatePageUnderParent(ctx, 0, "N", "", author)
+			if err != nil {
+				t.Fatal(err)
+			}
+			k, err := ws.CreatePageUnderParent(ctx, n.ID, "K", "", author)
+			if err != nil {
+				t.Fatal(err)
+			}
+			q, err := ws.CreatePageUnderParent(ctx, p.ID, "Q", "", author)
+			if err != nil {
+				t.Fatal(err)
+			}
+			sLinks := fmt.Sprintf("[N](../%s/index.md) [K](../%s/%s/index.md)", n.ID, n.ID, k.ID)
+			if _, err := ws.UpdatePage(ctx, s.ID, "S", sLinks, author); err != nil {
+				t.Fatal(err)
+			}
+			qLinks := fmt.Sprintf("[N](../../%s/index.md)", n.ID)
+			if _, err := ws.UpdatePage(ctx, q.ID, "Q", qLinks, author); err != nil {
+				t.Fatal(err)
+			}
+			kLinks := fmt.Sprintf("[S](../../%s/index.md)", s.ID)
+			if _, err := ws.UpdatePage(ctx, k.ID, "K", kLinks, author); err != nil {
+				t.Fatal(err)
+			}
+			before, err := ws.CommitCount(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if err := ws.MoveNode(ctx, n.ID, p.ID, author); err != nil {
+				t.Fatal(err)
+			}
+
+			if after, err := ws.CommitCount(ctx); err != nil || after != before+1 {
+				t.Errorf("want one commit, got %d (err %v)", after-before, err)
+			}
+			for _, tc := range []struct {
+				id   ksid.ID
+				want string
+			}{
+				{s.ID, fmt.Sprintf("[N](../%s/%s/index.md) [K](../%s/%s/%s/index.md)", p.ID, n.ID, p.ID, n.ID, k.ID)},
+				{q.ID, fmt.Sprintf("[N](../%s/index.md)", n.ID)},
+				{k.ID, kLinks},
+			} {
+				page, err := ws.ReadPage(tc.id)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if page.Content != tc.want {
+					t.Errorf("%s: got %q, want %q", page.Title, page.Content, tc.want)
+				}
+				// Every rewritten link resolves on disk from the page's directory.
+				dir := ws.pageDir(tc.id, ws.getParent(tc.id))
+				for _, m := range relativeLinkRe.FindAllStringSubmatch(page.Content, -1) {
+					if _, err := os.Stat(filepath.Join(dir, m[2])); tc.id != k.ID && err != nil {
+						t.Errorf("%s: link %s does not resolve: %v", page.Title, m[2], err)
+					}
+				}
+			}
+			invalid, err := ws.ValidateLinks()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(invalid) != 0 {
+				t.Errorf("unexpected invalid links: %+v", invalid)
+			}
+			if backlinks, err := ws.GetBacklinks(n.ID); err != nil || len(backlinks) != 2 {
+				t.Errorf("unexpected backlinks: %+v, %v", backlinks, err)
+			}
+		})
+	})
+
+	t.Run("MoveNodes", func(t *testing.T) {
+		// setup creates root pages A, B and P, with child C under A.
+		setup := func(t *testing.T) (ws *WorkspaceFileStore, a, b, c, p *Node) {
+			_, ws, _ = initWS(t)
+			ctx := t.Context()
+			var err error
+			if a, err = ws.CreateNode(ctx, "A", NodeTypeDocument, 0, author); err != nil {
+				t.Fatal(err)
+			}
+			if b, err = ws.CreateNode(ctx, "B", NodeTypeDocument, 0, author); err != nil {
+				t.Fatal(err)
+			}
+			if c, err = ws.CreateNode(ctx, "C", NodeTypeDocument, a.ID, author); err != nil {
+				t.Fatal(err)
+			}
+			if p, err = ws.CreateNode(ctx, "P", NodeTypeDocument, 0, author); err != nil {
+				t.Fatal(err)
+			}
+			return ws, a, b, c, p
+		}
+		childIDs := func(t *testing.T, ws *WorkspaceFileStore, parentID ksid.ID) []ksid.ID {
+			children, err := ws.ListChildren(parentID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var ids []ksid.ID
+			for _, n := range children {
+				ids = append(ids, n.ID)
+			}
+			return ids
+		}
+
+		t.Run("SiblingsUnderNewParent", func(t *testing.T) {
+			ws, a, b, c, p := setup(t)
+			ctx := t.Context()
+			before, err := ws.CommitCount(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// C is nested in A; both end up directly under P.
+			if err := ws.MoveNodes(ctx, []ksid.ID{a.ID, b.ID, c.ID}, p.ID, author); err != nil {
+				t.Fatal(err)
+			}
+			if got, want := childIDs(t, ws, p.ID), []ksid.ID{a.ID, b.ID, c.ID}; !slices.Equal(got, want) {
+				t.Errorf("children of P = %v, want %v", got, want)
+			}
+			if got := childIDs(t, ws, a.ID); len(got) != 0 {
+				t.Errorf("children of A = %v, want none", got)
+			}
+			for _, n := range []*Node{a, b, c} {
+				if _, err := os.Stat(filepath.Join(ws.wsDir, p.ID.String(), n.ID.String(), "index.md")); err != nil {
+					t.Errorf("%s not moved: %v", n.Title, err)
+				}
+			}
+			after, err := ws.CommitCount(ctx)
 			if err != nil {
-				t.Fatalf("list grandchild: %v", err)
+				t.Fatal(err)
 			}
-			if len(gc) != 1 || gc[0].ID != grandchild.ID {
-				t.Errorf("expected grandchild under child, got %v", gc)
+			if after != before+1 {
+				t.Errorf("want 1 commit, got %d", after-before)
 			}
 		})
 
-		t.Run("MoveNonExistent", func(t *testing.T) {
-			_, ws, _ := initWS(t)
+		t.Run("ValidationFailure", func(t *testing.T) {
+			ws, a, b, c, p := setup(t)
 			ctx := t.Context()
-
-			err := ws.MoveNode(ctx, ksid.NewID(), 0, author)
-			if err == nil {
-				t.Error("expected error moving non-existent node")
+			// Moving A under its own child is a cycle; B must not move either.
+			if err := ws.MoveNodes(ctx, []ksid.ID{b.ID, a.ID}, c.ID, author); !errors.Is(err, ErrCycleDetected) {
+				t.Errorf("got %v, want ErrCycleDetected", err)
 			}
-		})
-
-		t.Run("MoveToNonExistentParent", func(t *testing.T) {
-			_, ws, _ := initWS(t)
-			ctx := t.Context()
-
-			node, err := ws.CreateNode(ctx, "Node", NodeTypeDocument, 0, author)
-			if err != nil {
-				t.Fatalf("create node: %v", err)
+			if err := ws.MoveNodes(ctx, []ksid.ID{b.ID, ksid.NewID()}, p.ID, author); !errors.Is(err, ErrPageNotFound) {
+				t.Errorf("got %v, want ErrPageNotFound", err)
 			}
-
-			err = ws.MoveNode(ctx, node.ID, ksid.NewID(), author)
-			if err == nil {
-				t.Error("expected error moving to non-existent parent")
+			if err := ws.MoveNodes(ctx, []ksid.ID{b.ID}, ksid.NewID(), author); !errors.Is(err, ErrPageNotFound) {
+				t.Errorf("got %v, want ErrPageNotFound", err)
+			}
+			if got, want := childIDs(t, ws, 0), []ksid.ID{a.ID, b.ID, p.ID}; !slices.Equal(got, want) {
+				t.Errorf("root children = %v, want %v", got, want)
 			}
 		})
 
-		t.Run("PreservesLinks", func(t *testing.T) {
-			_, ws, _ := initWS(t)
+		t.Run("RollbackOnRenameFailure", func(t *testing.T) {
+			ws, a, b, _, p := setup(t)
 			ctx := t.Context()
-
-			pageA, err := ws.CreatePageUnderParent(ctx, 0, "A", "", author)
-			if err != nil {
-				t.Fatalf("create A: %v", err)
+			// A non-empty directory in B's way makes its rename fail after A
+			// was already moved.
+			blocker := filepath.Join(ws.wsDir, p.ID.String(), b.ID.String())
+			if err := os.MkdirAll(blocker, 0o755); err != nil {
+				t.Fatal(err)
 			}
-			pageB, err := ws.CreatePageUnderParent(ctx, 0, "B", "", author)
-			if err != nil {
-				t.Fatalf("create B: %v", err)
+			if err := os.WriteFile(filepath.Join(blocker, "x"), nil, 0o644); err != nil {
+				t.Fatal(err)
 			}
-			pageC, err := ws.CreatePageUnderParent(ctx, pageA.ID, "C", "", author)
+			before, err := ws.CommitCount(ctx)
 			if err != nil {
-				t.Fatalf("create C: %v", err)
+				t.Fatal(err)
 			}
-
-			cLink := fmt.Sprintf("See [B](../%s/index.md)", pageB.ID)
-			if _, err := ws.UpdatePage(ctx, pageC.ID, "C", cLink, author); err != nil {
-				t.Fatalf("update C: %v", err)
+			if err := ws.MoveNodes(ctx, []ksid.ID{a.ID, b.ID}, p.ID, author); err == nil {
+				t.Fatal("expected an error")
 			}
-			bLink := fmt.Sprintf("See [C](../%s/index.md)", pageC.ID)
-			if _, err := ws.UpdatePage(ctx, pageB.ID, "B", bLink, author); err != nil {
-				t.Fatalf("update B: %v", err)
+			for _, n := range []*Node{a, b} {
+				if _, err := os.Stat(filepath.Join(ws.wsDir, n.ID.String(), "index.md")); err != nil {
+					t.Errorf("%s not restored: %v", n.Title, err)
+				}
+				if got := ws.getParent(n.ID); got != 0 {
+					t.Errorf("%s parent = %s, want root", n.Title, got)
+				}
 			}
-
-			if err := ws.MoveNode(ctx, pageC.ID, 0, author); err != nil {
-				t.Fatalf("move C: %v", err)
+			if _, err := os.Stat(filepath.Join(ws.wsDir, p.ID.String(), a.ID.String())); !os.IsNotExist(err) {
+				t.Errorf("A left under P: %v", err)
 			}
-
-			nodeC, err := ws.ReadPage(pageC.ID)
+			if after, err := ws.CommitCount(ctx); err != nil || after != before {
+				t.Errorf("commits: %d -> %d (err %v)", before, after, err)
+			}
+		})
+		t.Run("RewritesInboundLinks", func(t *testing.T) {
+			ws, a, b, c, p := setup(t)
+			ctx := t.Context()
+			bLinks := fmt.Sprintf("[A](../%s/index.md) [C](../%s/%s/index.md)", a.ID, a.ID, c.ID)
+			if _, err := ws.UpdatePage(ctx, b.ID, "B", bLinks, author); err != nil {
+				t.Fatal(err)
+			}
+			before, err := ws.CommitCount(ctx)
 			if err != nil {
-				t.Fatalf("read C: %v", err)
+				t.Fatal(err)
 			}
-			if nodeC.Content != cLink {
-				t.Errorf("C link should be preserved, want %q, got %q", cLink, nodeC.Content)
+			// C is nested in A and both end up directly under P.
+			if err := ws.MoveNodes(ctx, []ksid.ID{a.ID, c.ID}, p.ID, author); err != nil {
+				t.Fatal(err)
 			}
-
-			nodeB, err := ws.ReadPage(pageB.ID)
+			if after, err := ws.CommitCount(ctx); err != nil || after != before+1 {
+				t.Errorf("want one commit, got %d (err %v)", after-before, err)
+			}
+			page, err := ws.ReadPage(b.ID)
 			if err != nil {
-				t.Fatalf("read B: %v", err)
+				t.Fatal(err)
 			}
-			if nodeB.Content != bLink {
-				t.Errorf("B link should be preserved, want %q, got %q", bLink, nodeB.Content)
+			want := fmt.Sprintf("[A](../%s/%s/index.md) [C](../%s/%s/index.md)", p.ID, a.ID, p.ID, c.ID)
+			if page.Content != want {
+				t.Errorf("got %q, want %q", page.Content, want)
+			}
+			for _, m := range relativeLinkRe.FindAllStringSubmatch(page.Content, -1) {
+				if _, err := os.Stat(filepath.Join(ws.wsDir, b.ID.String(), m[2])); err != nil {
+					t.Errorf("link %s does not resolve: %v", m[2], err)
+				}
 			}
 		})
 	})
@@ -1260,6 +2462,26 @@ func TestWorkspaceFileStore(t *testing.T) {
 			}
 		})
 
+		t.Run("SetServerQuotas", func(t *testing.T) {
+			fs, ws, wsID := initWS(t)
+			ctx := t.Context()
+			if _, err := ws.CreatePageUnderParent(ctx, 0, "Page 1", "content", author); err != nil {
+				t.Fatal(err)
+			}
+
+			q := *fs.serverQuotas.Load()
+			q.MaxPages = 1
+			fs.SetServerQuotas(&q)
+
+			ws, err := fs.GetWorkspaceStore(ctx, wsID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := ws.CreatePageUnderParent(ctx, 0, "Page 2", "content", author); !errors.Is(err, ErrQuotaExceeded) {
+				t.Fatalf("got %v, want ErrQuotaExceeded", err)
+			}
+		})
+
 		t.Run("StorageQuota", func(t *testing.T) {
 			fs, _, wsID := initWS(t)
 			ctx := t.Context()
@@ -1295,11 +2517,85 @@ func TestWorkspaceFileStore(t *testing.T) {
 			_, err = ws.WritePage(ctx, id, 0, "Large", string(largeContent), author)
 			if err == nil {
 				t.Logf("WritePage succeeded - quota enforcement might not be strict")
-			} else if !errors.Is(err, errQuotaExceeded) {
+			} else if !errors.Is(err, ErrQuotaExceeded) {
 				t.Logf("Got error: %v (not quota exceeded)", err)
 			}
 		})
 
+		t.Run("StorageQuotaBoundary", func(t *testing.T) {
+			fs, ws, wsID := initWS(t)
+			if _, err := ws.CreatePageUnderParent(t.Context(), 0, "Page", "some content", author); err != nil {
+				t.Fatal(err)
+			}
+			w, err := fs.wsSvc.Get(wsID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, wsUsage, err := ws.GetWorkspaceUsage()
+			if err != nil {
+				t.Fatal(err)
+			}
+			orgUsage, err := fs.GetOrganizationUsage(w.OrganizationID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			const room = 100
+			ws.quotas.MaxStorageBytes = wsUsage + room
+			if _, err := fs.orgSvc.Modify(w.OrganizationID, func(o *identity.Organization) error {
+				o.Quotas.MaxTotalStorageBytes = orgUsage + room
+				return nil
+			}); err != nil {
+				t.Fatal(err)
+			}
+			// Measured last since the organization update may write under the root.
+			serverUsage, err := fs.GetServerUsage()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			checks := []struct {
+				name  string
+				check func(int64) error
+			}{
+				{"workspace", ws.CheckStorageQuota},
+				{"organization", func(n int64) error { return fs.CheckOrgStorageQuota(wsID, n) }},
+				{"server", func(n int64) error { return fs.CheckServerStorageQuota(n, serverUsage+room) }},
+			}
+			for _, c := range checks {
+				if err := c.check(room - 1); err != nil {
+					t.Errorf("%s: one byte under: %v", c.name, err)
+				}
+				if err := c.check(room); err != nil {
+					t.Errorf("%s: exactly at limit: %v", c.name, err)
+				}
+				if err := c.check(room + 1); !errors.Is(err, ErrQuotaExceeded) {
+					t.Errorf("%s: one byte over: got %v, want ErrQuotaExceeded", c.name, err)
+				}
+			}
+		})
+
+		t.Run("StorageQuotaMiB", func(t *testing.T) {
+			_, ws, _ := initWS(t)
+			ctx := t.Context()
+			page, err := ws.CreatePageUnderParent(ctx, 0, "Page", "", author)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, usage, err := ws.GetWorkspaceUsage()
+			if err != nil {
+				t.Fatal(err)
+			}
+			// Quotas are in bytes; a 1 MiB allowance admits exactly 1 MiB.
+			const mib = 1024 * 1024
+			ws.quotas.MaxStorageBytes = usage + mib
+			if _, err := ws.SaveAsset(ctx, page.ID, "over.bin", make([]byte, mib+1), author); !errors.Is(err, ErrQuotaExceeded) {
+				t.Errorf("1 MiB + 1 byte: got %v, want ErrQuotaExceeded", err)
+			}
+			if _, err := ws.SaveAsset(ctx, page.ID, "fit.bin", make([]byte, mib), author); err != nil {
+				t.Errorf("exactly 1 MiB: %v", err)
+			}
+		})
+
 		t.Run("RecordQuota", func(t *testing.T) {
 			fs, _, wsID := initWS(t)
 			ctx := t.Context()
@@ -1347,8 +2643,8 @@ func TestWorkspaceFileStore(t *testing.T) {
 				Created:  storage.Now(),
 				Modified: storage.Now(),
 			}
-			if err := ws.AppendRecord(ctx, tableID, rec, author); err == nil {
-				t.Error("expected record quota exceeded error")
+			if err := ws.AppendRecord(ctx, tableID, rec, author); !errors.Is(err, ErrQuotaExceeded) {
+				t.Errorf("got %v, want ErrQuotaExceeded", err)
 			}
 		})
 
@@ -1412,6 +2708,113 @@ func TestWorkspaceFileStore(t *testing.T) {
 				t.Errorf("same-size update should succeed: %v", err)
 			}
 		})
+
+		// treeWS returns a workspace store limited to the given depth and breadth.
+		treeWS := func(t *testing.T, depth, children int) *WorkspaceFileStore {
+			fs, _, wsID := initWS(t)
+			if _, err := fs.wsSvc.Modify(wsID, func(w *identity.Workspace) error {
+				w.Quotas.MaxNodeDepth = depth
+				w.Quotas.MaxChildrenPerNode = children
+				return nil
+			}); err != nil {
+				t.Fatalf("failed to set quota: %v", err)
+			}
+			fs.InvalidateWorkspaceStore(wsID)
+			ws, err := fs.GetWorkspaceStore(t.Context(), wsID)
+			if err != nil {
+				t.Fatalf("failed to get workspace store: %v", err)
+			}
+			return ws
+		}
+
+		t.Run("NodeDepth", func(t *testing.T) {
+			ws := treeWS(t, 3, 100)
+			ctx := t.Context()
+
+			// Depth 1, 2 and 3 are allowed.
+			var parentID ksid.ID
+			for i := range 3 {
+				node, err := ws.CreatePageUnderParent(ctx, parentID, "Level", "", author)
+				if err != nil {
+					t.Fatalf("level %d: %v", i+1, err)
+				}
+				parentID = node.ID
+			}
+			if _, err := ws.CreatePageUnderParent(ctx, parentID, "Too deep", "", author); !errors.Is(err, ErrNodeDepthExceeded) {
+				t.Errorf("CreatePageUnderParent at depth 4: got %v, want ErrNodeDepthExceeded", err)
+			}
+			if _, err := ws.CreateNode(ctx, "Too deep", NodeTypeDocument, parentID, author); !errors.Is(err, ErrNodeDepthExceeded) {
+				t.Errorf("CreateNode at depth 4: got %v, want ErrNodeDepthExceeded", err)
+			}
+			if _, err := ws.CreateTableUnderParent(ctx, parentID, "Too deep", nil, author); !errors.Is(err, ErrNodeDepthExceeded) {
+				t.Errorf("CreateTableUnderParent at depth 4: got %v, want ErrNodeDepthExceeded", err)
+			}
+		})
+
+		t.Run("ChildrenPerNode", func(t *testing.T) {
+			ws := treeWS(t, 10, 2)
+			ctx := t.Context()
+
+			// Root and a nested parent each accept exactly 2 children.
+			var parents []ksid.ID
+			for range 2 {
+				node, err := ws.CreateNode(ctx, "Top", NodeTypeDocument, 0, author)
+				if err != nil {
+					t.Fatal(err)
+				}
+				parents = append(parents, node.ID)
+			}
+			if _, err := ws.CreateNode(ctx, "Third top", NodeTypeDocument, 0, author); !errors.Is(err, ErrTooManyChildren) {
+				t.Errorf("third root child: got %v, want ErrTooManyChildren", err)
+			}
+			for range 2 {
+				if _, err := ws.CreatePageUnderParent(ctx, parents[0], "Child", "", author); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if _, err := ws.CreatePageUnderParent(ctx, parents[0], "Third child", "", author); !errors.Is(err, ErrTooManyChildren) {
+				t.Errorf("third nested child: got %v, want ErrTooManyChildren", err)
+			}
+		})
+
+		t.Run("MoveNode", func(t *testing.T) {
+			ws := treeWS(t, 3, 2)
+			ctx := t.Context()
+
+			// a (depth 1) -> b (depth 2); c (depth 1) -> d (depth 2) -> e (depth 3).
+			a, err := ws.CreateNode(ctx, "a", NodeTypeDocument, 0, author)
+			if err != nil {
+				t.Fatal(err)
+			}
+			b, err := ws.CreatePageUnderParent(ctx, a.ID, "b", "", author)
+			if err != nil {
+				t.Fatal(err)
+			}
+			c, err := ws.CreateNode(ctx, "c", NodeTypeDocument, 0, author)
+			if err != nil {
+				t.Fatal(err)
+			}
+			d, err := ws.CreatePageUnderParent(ctx, c.ID, "d", "", author)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := ws.CreatePageUnderParent(ctx, d.ID, "e", "", author); err != nil {
+				t.Fatal(err)
+			}
+
+			// Moving d (height 2) under b (depth 2) would put e at depth 4.
+			if err := ws.MoveNode(ctx, d.ID, b.ID, author); !errors.Is(err, ErrNodeDepthExceeded) {
+				t.Errorf("MoveNode too deep: got %v, want ErrNodeDepthExceeded", err)
+			}
+			// Moving d under a puts e at depth 3, which is allowed.
+			if err := ws.MoveNode(ctx, d.ID, a.ID, author); err != nil {
+				t.Fatalf("MoveNode within depth: %v", err)
+			}
+			// a now has 2 children; root has 2 children.
+			if err := ws.MoveNode(ctx, b.ID, 0, author); !errors.Is(err, ErrTooManyChildren) {
+				t.Errorf("MoveNode to full root: got %v, want ErrTooManyChildren", err)
+			}
+		})
 	})
 
 	t.Run("Markdown", func(t *testing.T) {
@@ -1648,6 +3051,38 @@ func TestWorkspaceFileStore(t *testing.T) {
 				t.Errorf("expected target=%s, got %s", ghost, invalid[0].Target)
 			}
 		})
+
+		t.Run("MissingAsset", func(t *testing.T) {
+			_, ws, _ := initWS(t)
+			ctx := t.Context()
+
+			content := "![kept](kept.png) ![gone](./gone%20away.png) [doc](kept.png#p2)\n" +
+				"![ext](https://example.com/x.png) ![abs](/assets/y.png) [no extension](notes)"
+			page, err := ws.CreatePageUnderParent(ctx, 0, "Assets", content, author)
+			if err != nil {
+				t.Fatalf("create Assets: %v", err)
+			}
+			for _, name := range []string{"kept.png", "gone away.png"} {
+				if _, err := ws.SaveAsset(ctx, page.ID, name, []byte(name), author); err != nil {
+					t.Fatalf("SaveAsset %s: %v", name, err)
+				}
+			}
+			if invalid, err := ws.ValidateLinks(); err != nil || len(invalid) != 0 {
+				t.Fatalf("ValidateLinks = %+v, %v; want none", invalid, err)
+			}
+
+			if err := ws.DeleteAsset(ctx, page.ID, "gone away.png", author); err != nil {
+				t.Fatalf("DeleteAsset: %v", err)
+			}
+			invalid, err := ws.ValidateLinks()
+			if err != nil {
+				t.Fatalf("ValidateLinks: %v", err)
+			}
+			want := []InvalidLink{{SourceID: page.ID, Target: "gone away.png", IsAsset: true}}
+			if !slices.Equal(invalid, want) {
+				t.Errorf("got %+v, want %+v", invalid, want)
+			}
+		})
 	})
 
 	t.Run("NodeTitles", func(t *testing.T) {
@@ -1717,6 +3152,9 @@ func TestWorkspaceFileStore(t *testing.T) {
 			if err != nil {
 				t.Fatalf("GetWorkspaceUsage failed with .git present: %v", err)
 			}
+			if walked, err := dirUsage(ws.wsDir); err != nil || walked != storageBytes {
+				t.Errorf("dirUsage() = %d, %v; GetWorkspaceUsage() = %d", walked, err, storageBytes)
+			}
 
 			// Storage must not include the .git contents.
 			// Remove .git and compare.
@@ -1732,6 +3170,32 @@ func TestWorkspaceFileStore(t *testing.T) {
 			}
 		})
 
+		t.Run("CountsAssetBlobs", func(t *testing.T) {
+			_, ws, _ := initWS(t)
+			ctx := t.Context()
+
+			page, err := ws.CreatePageUnderParent(ctx, 0, "Page", "", author)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, before, err := ws.GetWorkspaceUsage()
+			if err != nil {
+				t.Fatal(err)
+			}
+			data := make([]byte, 4096)
+			if _, err := ws.SaveAsset(ctx, page.ID, "blob.bin", data, author); err != nil {
+				t.Fatal(err)
+			}
+			// The asset content lives in assets.blobs, which is real storage.
+			_, after, err := ws.GetWorkspaceUsage()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if after-before < int64(len(data)) {
+				t.Errorf("storage grew by %d, want at least %d", after-before, len(data))
+			}
+		})
+
 		t.Run("HybridNodeCountedOnce", func(t *testing.T) {
 			_, ws, _ := initWS(t)
 			ctx := t.Context()
@@ -1823,6 +3287,21 @@ func TestExtractLinkedNodeIDs(t *testing.T) {
 			content:  "[bad](../not-valid-id/index.md)",
 			expected: nil,
 		},
+		{
+			name:     "wikilink",
+			content:  "See [[" + nodeA.String() + "]] and [[" + nodeB.String() + "|page B]]",
+			expected: []ksid.ID{nodeA, nodeB},
+		},
+		{
+			name:     "both styles in order",
+			content:  "[[" + nodeC.String() + "]] [b](../" + nodeB.String() + "/index.md) [[" + nodeB.String() + "]]",
+			expected: []ksid.ID{nodeC, nodeB},
+		},
+		{
+			name:     "wikilink to a page name ignored",
+			content:  "[[Some Page]] and [[" + strings.ToLower(nodeA.String()) + "]]",
+			expected: nil,
+		},
 	}
 
 	for _, tt := range tests {
@@ -1842,3 +3321,89 @@ func TestExtractLinkedNodeIDs(t *testing.T) {
 		})
 	}
 }
+
+// countFiles returns the number of regular files under dir, excluding temp files.
+func countFiles(t *testing.T, dir string) int {
+	t.Helper()
+	n := 0
+	err := filepath.WalkDir(dir, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() && !strings.HasSuffix(d.Name(), ".tmp") {
+			n++
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+func TestNodeDir(t *testing.T) {
+	author := git.Author{Name: "Test", Email: "test@test.com"}
+	_, ws, _ := initWS(t)
+	ctx := t.Context()
+	a, err := ws.CreateNode(ctx, "a", NodeTypeDocument, 0, author)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := ws.CreatePageUnderParent(ctx, a.ID, "b", "", author)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := ws.CreatePageUnderParent(ctx, b.ID, "c", "", author)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := ws.nodeDir(c.ID), filepath.Join(a.ID.String(), b.ID.String(), c.ID.String()); got != want {
+		t.Fatalf("nodeDir = %q, want %q", got, want)
+	}
+
+	// Moving an ancestor changes the directory of the whole subtree.
+	if err := ws.MoveNode(ctx, b.ID, 0, author); err != nil {
+		t.Fatal(err)
+	}
+	want := filepath.Join(b.ID.String(), c.ID.String())
+	if got := ws.nodeDir(c.ID); got != want {
+		t.Errorf("nodeDir after move = %q, want %q", got, want)
+	}
+	if _, err := os.Stat(filepath.Join(ws.wsDir, want, "index.md")); err != nil {
+		t.Error(err)
+	}
+	if got := ws.nodeDir(0); got != "" {
+		t.Errorf("nodeDir(0) = %q", got)
+	}
+
+	// Creating and deleting nodes keeps the directories memoized for the others.
+	d, err := ws.CreatePageUnderParent(ctx, c.ID, "d", "", author)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ws.DeletePage(ctx, d.ID, author); err != nil {
+		t.Fatal(err)
+	}
+	ws.mu.RLock()
+	memo, ok := ws.dirs[c.ID]
+	ws.mu.RUnlock()
+	if !ok || memo != want {
+		t.Errorf("memoized directory of c = %q, %v; want %q", memo, ok, want)
+	}
+}
+
+func BenchmarkRelativeDir(b *testing.B) {
+	// Only the parent cache is used, no files.
+	ws := newWorkspaceFileStore(ksid.NewID(), b.TempDir(), nil, &storage.ResourceQuotas{}, nil, nil, nil)
+	var parentID ksid.ID
+	for range 100 {
+		id := ksid.NewID()
+		ws.setParent(id, parentID)
+		parentID = id
+	}
+	leaf := ksid.NewID()
+	ws.setParent(leaf, parentID)
+	for b.Loop() {
+		_ = ws.relativeDir(leaf, parentID)
+	}
+}
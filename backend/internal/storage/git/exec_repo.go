@@ -17,17 +17,15 @@ import (
 
 // ExecRepo implements Repository using os/exec git commands.
 type ExecRepo struct {
-	dir          string
-	defaultName  string
-	defaultEmail string
-	mu           sync.Mutex
+	dir      string
+	defaults *identity
+	mu       sync.Mutex
 }
 
-func newExecRepo(ctx context.Context, dir, defaultName, defaultEmail string) (*ExecRepo, error) {
+func newExecRepo(ctx context.Context, dir string, defaults *identity) (*ExecRepo, error) {
 	r := &ExecRepo{
-		dir:          dir,
-		defaultName:  defaultName,
-		defaultEmail: defaultEmail,
+		dir:      dir,
+		defaults: defaults,
 	}
 	if err := r.init(ctx); err != nil {
 		return nil, err
@@ -44,10 +42,11 @@ func (r *ExecRepo) init(ctx context.Context) error {
 		if err := r.gitRun(ctx, "init"); err != nil {
 			return fmt.Errorf("failed to initialize git repo: %w", err)
 		}
-		if err := r.gitRun(ctx, "config", "user.email", r.defaultEmail); err != nil {
+		def := r.defaults.resolve(Author{})
+		if err := r.gitRun(ctx, "config", "user.email", def.Email); err != nil {
 			return fmt.Errorf("failed to configure git user.email: %w", err)
 		}
-		if err := r.gitRun(ctx, "config", "user.name", r.defaultName); err != nil {
+		if err := r.gitRun(ctx, "config", "user.name", def.Name); err != nil {
 			return fmt.Errorf("failed to configure git user.name: %w", err)
 		}
 	}
@@ -98,23 +97,22 @@ func (r *ExecRepo) commit(ctx context.Context, author Author, message string, fi
 	}
 
 	// Use defaults if not provided
-	name := author.Name
-	email := author.Email
-	if name == "" {
-		name = r.defaultName
-	}
-	if email == "" {
-		email = r.defaultEmail
-	}
-
-	authorStr := fmt.Sprintf("%s <%s>", name, email)
-	if err := r.gitRun(ctx, "commit", "-m", message, "--author", authorStr); err != nil {
+	author = r.defaults.resolve(author)
+	authorStr := fmt.Sprintf("%s <%s>", author.Name, author.Email)
+	// Sync the objects and refs so a commit that returned survives a crash.
+	if err := r.gitRun(ctx, "-c", "core.fsync=committed", "commit", "-m", message, "--author", authorStr); err != nil {
 		return fmt.Errorf("failed to commit: %w", err)
 	}
 
 	return nil
 }
 
+// defaultAuthorString returns the default identity as a --author value.
+func (r *ExecRepo) defaultAuthorString() string {
+	def := r.defaults.resolve(Author{})
+	return fmt.Sprintf("%s <%s>", def.Name, def.Email)
+}
+
 // CommitCount returns the total number of commits in the repository.
 func (r *ExecRepo) CommitCount(ctx context.Context) (int, error) {
 	out, err := r.gitOutput(ctx, "rev-list", "--count", "HEAD")
@@ -133,16 +131,33 @@ func (r *ExecRepo) CommitCount(ctx context.Context) (int, error) {
 // GetHistory returns commit history for a specific path, limited to n commits.
 // n is capped at 1000. If n <= 0, defaults to 1000.
 func (r *ExecRepo) GetHistory(ctx context.Context, path string, n int) ([]*Commit, error) {
+	commits, _, err := r.GetHistoryBefore(ctx, path, "", n)
+	return commits, err
+}
+
+// GetHistoryBefore returns up to n commits for path that are strictly older than before.
+// If before is empty, starts from HEAD.
+// n is capped at 1000. If n <= 0, defaults to 1000.
+func (r *ExecRepo) GetHistoryBefore(ctx context.Context, path, before string, n int) ([]*Commit, bool, error) {
 	if n <= 0 || n > 1000 {
 		n = 1000
 	}
+	if before != "" && !IsCommitHash(before) {
+		return nil, false, ErrInvalidHash
+	}
 
 	// Use record separator (%x1e) between commits since body can contain newlines
 	format := "%H%x00%an%x00%ae%x00%ai%x00%cn%x00%ce%x00%ci%x00%s%x00%b%x1e"
-	args := []string{"log", "--pretty=format:" + format, fmt.Sprintf("-n%d", n), "--", path}
+	// Request one extra commit to know whether more history exists.
+	args := []string{"log", "--pretty=format:" + format, fmt.Sprintf("-n%d", n+1)}
+	if before != "" {
+		// All parents of before, so that before itself is excluded.
+		args = append(args, before+"^@")
+	}
+	args = append(args, "--", path)
 	out, err := r.gitCombinedOutput(ctx, args...)
 	if err != nil {
-		return nil, nil //nolint:nilerr // git log returns error for paths with no history, which is not an error condition
+		return nil, false, nil //nolint:nilerr // git log returns error for paths with no history, which is not an error condition
 	}
 
 	var commits []*Commit
@@ -173,7 +188,10 @@ func (r *ExecRepo) GetHistory(ctx context.Context, path string, n int) ([]*Commi
 		})
 	}
 
-	return commits, nil
+	if len(commits) > n {
+		return commits[:n], true, nil
+	}
+	return commits, false, nil
 }
 
 // GetFileAtCommit retrieves the content of a file at a specific commit.
@@ -231,7 +249,11 @@ func (r *ExecRepo) Push(ctx context.Context, remoteName, branch string) error {
 	refspec := "HEAD:refs/heads/" + branch
 	out, err := r.gitCombinedOutput(ctx, "push", remoteName, refspec)
 	if err != nil {
-		return fmt.Errorf("%w: %s", err, strings.TrimSpace(string(out)))
+		msg := strings.TrimSpace(string(out))
+		if cause := pushErrorCause(msg); cause != nil {
+			return fmt.Errorf("%w: %s", cause, msg)
+		}
+		return fmt.Errorf("%w: %s", err, msg)
 	}
 	return nil
 }
@@ -300,13 +322,52 @@ func (r *ExecRepo) AbortMerge(ctx context.Context) error {
 	return r.gitRun(ctx, "merge", "--abort")
 }
 
+// DiscardChanges restores the tracked files to the last commit.
+func (r *ExecRepo) DiscardChanges(ctx context.Context) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := r.gitRun(ctx, "rev-parse", "--verify", "--quiet", "HEAD"); err != nil {
+		return false, nil //nolint:nilerr // no commits yet, nothing to restore
+	}
+	out, err := r.gitOutput(ctx, "status", "--porcelain", "--untracked-files=no")
+	if err != nil {
+		return false, fmt.Errorf("failed to get status: %w", err)
+	}
+	if len(bytes.TrimSpace(out)) == 0 {
+		return false, nil
+	}
+	if out, err := r.gitCombinedOutput(ctx, "reset", "--quiet", "--hard", "HEAD"); err != nil {
+		return false, fmt.Errorf("failed to reset: %w\nOutput: %s", err, string(out))
+	}
+	return true, nil
+}
+
+// Maintenance runs git gc.
+func (r *ExecRepo) Maintenance(ctx context.Context) error {
+	if !r.mu.TryLock() {
+		return ErrBusy
+	}
+	defer r.mu.Unlock()
+	if out, err := r.gitCombinedOutput(ctx, "gc", "--quiet"); err != nil {
+		return fmt.Errorf("failed to gc: %w\nOutput: %s", err, string(out))
+	}
+	return nil
+}
+
 // gitCmd creates an exec.Cmd for git with standard environment settings.
 func (r *ExecRepo) gitCmd(ctx context.Context, args ...string) *exec.Cmd {
 	cmd := exec.CommandContext(ctx, "git", args...) //nolint:gosec // G204: args are internal, not user input
 	cmd.Dir = r.dir
+	// The committer is always the default identity, independent of the repo
+	// config which only records the identity at init.
+	def := r.defaults.resolve(Author{})
 	cmd.Env = append(os.Environ(),
 		"GIT_CONFIG_GLOBAL=/dev/null",
 		"GIT_CONFIG_SYSTEM=/dev/null",
+		// Fail instead of waiting for credentials nobody will type.
+		"GIT_TERMINAL_PROMPT=0",
+		"GIT_COMMITTER_NAME="+def.Name,
+		"GIT_COMMITTER_EMAIL="+def.Email,
 	)
 	return cmd
 }
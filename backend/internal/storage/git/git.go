@@ -4,8 +4,10 @@ package git
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io/fs"
+	"os"
 	"path/filepath"
 	"strings"
 	"sync"
@@ -28,6 +30,51 @@ func InjectTokenInURL(remoteURL, token, remoteType string) string {
 	}
 }
 
+// ErrBusy is returned by Maintenance when a commit is in progress.
+var ErrBusy = errors.New("repository busy")
+
+// ErrAuthFailed is returned by Push when the remote rejected the credentials.
+var ErrAuthFailed = errors.New("git remote authentication failed")
+
+// ErrNonFastForward is returned by Push when the remote branch has commits
+// that are not in the local branch.
+var ErrNonFastForward = errors.New("git remote has diverged (non-fast-forward)")
+
+// pushErrorCause returns ErrAuthFailed or ErrNonFastForward when msg, the
+// output of a failed push, shows one of these causes, or nil.
+func pushErrorCause(msg string) error {
+	lower := strings.ToLower(msg)
+	switch {
+	case strings.Contains(lower, "non-fast-forward"), strings.Contains(lower, "[rejected]"):
+		return ErrNonFastForward
+	case strings.Contains(lower, "authentication failed"),
+		strings.Contains(lower, "authentication required"),
+		strings.Contains(lower, "authorization failed"),
+		strings.Contains(lower, "could not read username"),
+		strings.Contains(lower, "terminal prompts disabled"),
+		strings.Contains(lower, "permission denied"),
+		strings.Contains(lower, "unable to authenticate"):
+		return ErrAuthFailed
+	}
+	return nil
+}
+
+// ErrInvalidHash is returned when a commit hash is not a full hexadecimal object name.
+var ErrInvalidHash = errors.New("invalid commit hash")
+
+// IsCommitHash reports whether s is a full SHA-1 or SHA-256 hexadecimal object name.
+func IsCommitHash(s string) bool {
+	if len(s) != 40 && len(s) != 64 {
+		return false
+	}
+	for _, c := range s {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
+
 // Repository is the interface for git operations on a single repository.
 type Repository interface {
 	// FS returns a read-only filesystem view of the repository's working directory.
@@ -42,12 +89,20 @@ type Repository interface {
 	// GetHistory returns commit history for a specific path, limited to n commits.
 	// n is capped at 1000. If n <= 0, defaults to 1000.
 	GetHistory(ctx context.Context, path string, n int) ([]*Commit, error)
+	// GetHistoryBefore returns up to n commits for path that are strictly older than
+	// the commit before. If before is empty, starts from HEAD. more reports whether
+	// older commits exist beyond the returned page.
+	// n is capped at 1000. If n <= 0, defaults to 1000.
+	GetHistoryBefore(ctx context.Context, path, before string, n int) (commits []*Commit, more bool, err error)
 	// GetFileAtCommit retrieves the content of a file at a specific commit.
 	GetFileAtCommit(ctx context.Context, hash, filePath string) ([]byte, error)
 	// SetRemote adds or updates a remote in the repository.
 	// If url is empty, the remote is removed.
 	SetRemote(ctx context.Context, name, url string) error
-	// Push pushes changes to a remote repository.
+	// Push pushes changes to a remote repository. Credentials are taken from
+	// userinfo in the remote URL, ssh remotes authenticate with the ssh agent.
+	// Returns an error wrapping ErrAuthFailed or ErrNonFastForward for these
+	// failures.
 	Push(ctx context.Context, remoteName, branch string) error
 	// Fetch fetches from a remote repository.
 	Fetch(ctx context.Context, remoteName, branch string) error
@@ -58,6 +113,15 @@ type Repository interface {
 	HasUnmergedFiles(ctx context.Context) (bool, error)
 	// AbortMerge aborts an in-progress merge.
 	AbortMerge(ctx context.Context) error
+	// DiscardChanges restores the tracked files to the last commit, dropping
+	// staged and unstaged changes, and reports whether there were any.
+	// Untracked files are left alone. Does nothing in a repository without
+	// commits.
+	DiscardChanges(ctx context.Context) (bool, error)
+	// Maintenance packs loose objects and prunes unreachable ones older than
+	// two weeks, like git gc. It is safe to run concurrently with reads and
+	// returns ErrBusy without doing anything if a commit is in progress.
+	Maintenance(ctx context.Context) error
 }
 
 // Backend selects which git implementation to use.
@@ -72,11 +136,10 @@ const (
 
 // Manager creates and caches git repositories.
 type Manager struct {
-	rootDir      string
-	defaultName  string
-	defaultEmail string
-	backend      Backend
-	repos        sync.Map // path -> Repository
+	rootDir  string
+	defaults *identity
+	backend  Backend
+	repos    sync.Map // path -> Repository
 }
 
 // NewManager creates a new git repository manager using the exec backend.
@@ -86,20 +149,25 @@ func NewManager(rootDir, defaultName, defaultEmail string) *Manager {
 
 // NewManagerWithBackend creates a new git repository manager with the given backend.
 func NewManagerWithBackend(rootDir, defaultName, defaultEmail string, backend Backend) *Manager {
-	if defaultName == "" {
-		defaultName = "mddb"
-	}
-	if defaultEmail == "" {
-		defaultEmail = "mddb@localhost"
-	}
 	return &Manager{
-		rootDir:      rootDir,
-		defaultName:  defaultName,
-		defaultEmail: defaultEmail,
-		backend:      backend,
+		rootDir:  rootDir,
+		defaults: newIdentity(defaultName, defaultEmail),
+		backend:  backend,
 	}
 }
 
+// SetDefaultAuthor sets the identity used for commits made without an author
+// name or email, and as the committer of every commit. Empty fields reset to
+// "mddb" and "mddb@localhost". It applies to repositories already opened.
+func (m *Manager) SetDefaultAuthor(author Author) {
+	m.defaults.set(author.Name, author.Email)
+}
+
+// DefaultAuthor returns the identity used for commits made without an author.
+func (m *Manager) DefaultAuthor() Author {
+	return m.defaults.resolve(Author{})
+}
+
 // Repo returns or creates a repository for the given subdirectory.
 // The subdir is relative to the manager's root directory.
 func (m *Manager) Repo(ctx context.Context, subdir string) (Repository, error) {
@@ -112,9 +180,9 @@ func (m *Manager) Repo(ctx context.Context, subdir string) (Repository, error) {
 	var err error
 	switch m.backend {
 	case BackendGoGit:
-		r, err = newGoGitRepo(ctx, dir, m.defaultName, m.defaultEmail)
+		r, err = newGoGitRepo(ctx, dir, m.defaults)
 	default:
-		r, err = newExecRepo(ctx, dir, m.defaultName, m.defaultEmail)
+		r, err = newExecRepo(ctx, dir, m.defaults)
 	}
 	if err != nil {
 		return nil, err
@@ -124,10 +192,82 @@ func (m *Manager) Repo(ctx context.Context, subdir string) (Repository, error) {
 	return actual.(Repository), nil
 }
 
+// MaintainAll runs Maintenance on every repository directly under the root
+// directory, including ones not opened yet. Busy repositories are skipped and
+// errors are collected so that one failing repository doesn't stop the others.
+func (m *Manager) MaintainAll(ctx context.Context) error {
+	entries, err := os.ReadDir(m.rootDir)
+	if err != nil {
+		return fmt.Errorf("failed to read root directory: %w", err)
+	}
+	var errs []error
+	for _, e := range entries {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if !e.IsDir() || strings.HasPrefix(e.Name(), ".") {
+			continue
+		}
+		// .git is a file in workspaces absorbed as submodules of the root repo.
+		if _, err := os.Stat(filepath.Join(m.rootDir, e.Name(), ".git")); err != nil {
+			continue
+		}
+		r, err := m.Repo(ctx, e.Name())
+		if err == nil {
+			err = r.Maintenance(ctx)
+		}
+		if err != nil && !errors.Is(err, ErrBusy) {
+			errs = append(errs, fmt.Errorf("%s: %w", e.Name(), err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
 // Author identifies who made a change for git commits.
 type Author struct {
 	Name  string
 	Email string
+	ID    string // Stable user ID, recorded as the mddb-actor trailer when set.
+}
+
+// identity is the fallback commit identity. It is shared by a Manager and the
+// repositories it opened so that changing it applies to all of them.
+type identity struct {
+	mu    sync.RWMutex
+	name  string
+	email string
+}
+
+func newIdentity(name, email string) *identity {
+	id := &identity{}
+	id.set(name, email)
+	return id
+}
+
+func (id *identity) set(name, email string) {
+	if name == "" {
+		name = "mddb"
+	}
+	if email == "" {
+		email = "mddb@localhost"
+	}
+	id.mu.Lock()
+	id.name = name
+	id.email = email
+	id.mu.Unlock()
+}
+
+// resolve returns author with an empty Name or Email replaced by the default.
+func (id *identity) resolve(author Author) Author {
+	id.mu.RLock()
+	defer id.mu.RUnlock()
+	if author.Name == "" {
+		author.Name = id.name
+	}
+	if author.Email == "" {
+		author.Email = id.email
+	}
+	return author
 }
 
 // Commit represents a commit in git history.
@@ -3,9 +3,13 @@ package git
 import (
 	"bytes"
 	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"testing"
 )
@@ -113,6 +117,122 @@ func testRepoAll(t *testing.T, backend Backend) {
 		}
 	})
 
+	t.Run("DefaultAuthor", func(t *testing.T) {
+		t.Parallel()
+		tmpDir := t.TempDir()
+		ctx := t.Context()
+
+		mgr := NewManagerWithBackend(tmpDir, "", "", backend)
+		repo, err := mgr.Repo(ctx, "")
+		if err != nil {
+			t.Fatalf("Repo() failed: %v", err)
+		}
+		commit := func(content string, author Author) *Commit {
+			t.Helper()
+			err := repo.CommitTx(ctx, author, func() (string, []string, error) {
+				return "write", []string{"f.txt"}, os.WriteFile(filepath.Join(tmpDir, "f.txt"), []byte(content), 0o600)
+			})
+			if err != nil {
+				t.Fatalf("CommitTx() failed: %v", err)
+			}
+			history, err := repo.GetHistory(ctx, "f.txt", 1)
+			if err != nil || len(history) != 1 {
+				t.Fatalf("GetHistory() = %v, %v", history, err)
+			}
+			return history[0]
+		}
+		check := func(c *Commit, author, authorEmail, committer, committerEmail string) {
+			t.Helper()
+			if c.Author != author || c.AuthorEmail != authorEmail || c.Committer != committer || c.CommitterEmail != committerEmail {
+				t.Errorf("got %s <%s> committed by %s <%s>, want %s <%s> committed by %s <%s>",
+					c.Author, c.AuthorEmail, c.Committer, c.CommitterEmail, author, authorEmail, committer, committerEmail)
+			}
+		}
+
+		check(commit("1", Author{}), "mddb", "mddb@localhost", "mddb", "mddb@localhost")
+
+		// Applies to the already opened repo.
+		mgr.SetDefaultAuthor(Author{Name: "Server", Email: "server@example.com"})
+		if got := mgr.DefaultAuthor(); got.Name != "Server" || got.Email != "server@example.com" {
+			t.Errorf("DefaultAuthor() = %+v", got)
+		}
+		check(commit("2", Author{}), "Server", "server@example.com", "Server", "server@example.com")
+		check(commit("3", Author{Email: "anon@example.com"}), "Server", "anon@example.com", "Server", "server@example.com")
+		check(commit("4", Author{Name: "Alice", Email: "alice@example.com"}), "Alice", "alice@example.com", "Server", "server@example.com")
+
+		mgr.SetDefaultAuthor(Author{})
+		if got := mgr.DefaultAuthor(); got.Name != "mddb" || got.Email != "mddb@localhost" {
+			t.Errorf("DefaultAuthor() after reset = %+v", got)
+		}
+	})
+
+	t.Run("Maintenance", func(t *testing.T) {
+		t.Parallel()
+		tmpDir := t.TempDir()
+		ctx := t.Context()
+
+		mgr := NewManagerWithBackend(tmpDir, "", "", backend)
+		repo, err := mgr.Repo(ctx, "ws")
+		if err != nil {
+			t.Fatalf("Repo() failed: %v", err)
+		}
+		const n = 30
+		for i := range n {
+			err := repo.CommitTx(ctx, Author{}, func() (string, []string, error) {
+				return fmt.Sprintf("commit %d", i), []string{"f.txt"}, os.WriteFile(filepath.Join(tmpDir, "ws", "f.txt"), []byte(strconv.Itoa(i)), 0o600)
+			})
+			if err != nil {
+				t.Fatalf("CommitTx() failed: %v", err)
+			}
+		}
+		if got := countLooseObjects(t, filepath.Join(tmpDir, "ws")); got < 3*n {
+			t.Fatalf("expected at least %d loose objects, got %d", 3*n, got)
+		}
+
+		// A repo with a commit in progress is skipped.
+		err = repo.CommitTx(ctx, Author{}, func() (string, []string, error) {
+			return "", nil, repo.Maintenance(ctx)
+		})
+		if !errors.Is(err, ErrBusy) {
+			t.Fatalf("Maintenance() during commit = %v, want ErrBusy", err)
+		}
+
+		if err := mgr.MaintainAll(ctx); err != nil {
+			t.Fatalf("MaintainAll() failed: %v", err)
+		}
+		if got := countLooseObjects(t, filepath.Join(tmpDir, "ws")); got != 0 {
+			t.Errorf("expected no loose objects after maintenance, got %d", got)
+		}
+
+		history, err := repo.GetHistory(ctx, "f.txt", 0)
+		if err != nil {
+			t.Fatalf("GetHistory() failed: %v", err)
+		}
+		if len(history) != n {
+			t.Fatalf("expected %d commits, got %d", n, len(history))
+		}
+		for i, c := range history {
+			want := strconv.Itoa(n - 1 - i)
+			if c.Message != "commit "+want {
+				t.Errorf("commit %d message = %q", i, c.Message)
+			}
+			data, err := repo.GetFileAtCommit(ctx, c.Hash, "f.txt")
+			if err != nil || string(data) != want {
+				t.Errorf("f.txt at %s = %q, %v; want %q", c.Hash, data, err, want)
+			}
+		}
+		// Still usable.
+		err = repo.CommitTx(ctx, Author{}, func() (string, []string, error) {
+			return "after", []string{"f.txt"}, os.WriteFile(filepath.Join(tmpDir, "ws", "f.txt"), []byte("after"), 0o600)
+		})
+		if err != nil {
+			t.Fatalf("CommitTx() after maintenance failed: %v", err)
+		}
+		if count, err := repo.CommitCount(ctx); err != nil || count != n+1 {
+			t.Errorf("CommitCount() = %d, %v; want %d", count, err, n+1)
+		}
+	})
+
 	t.Run("GetHistory", func(t *testing.T) {
 		t.Parallel()
 		tmpDir := t.TempDir()
@@ -162,6 +282,83 @@ func testRepoAll(t *testing.T, backend Backend) {
 		}
 	})
 
+	t.Run("GetHistoryBefore", func(t *testing.T) {
+		t.Parallel()
+		tmpDir := t.TempDir()
+		ctx := t.Context()
+		mgr := NewManagerWithBackend(tmpDir, "Test User", "test@example.com", backend)
+		repo, err := mgr.Repo(ctx, "")
+		if err != nil {
+			t.Fatalf("Repo() failed: %v", err)
+		}
+
+		testFile := "test.txt"
+		const total = 7
+		for i := range total {
+			if err := os.WriteFile(filepath.Join(tmpDir, testFile), []byte("v"+strconv.Itoa(i)), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			if err := repo.CommitTx(ctx, Author{}, func() (string, []string, error) {
+				return "Commit " + strconv.Itoa(i), []string{testFile}, nil
+			}); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		// Page through the history 3 commits at a time.
+		var got []string
+		before := ""
+		for pages := 0; ; pages++ {
+			if pages > total {
+				t.Fatal("pagination did not terminate")
+			}
+			page, more, err := repo.GetHistoryBefore(ctx, testFile, before, 3)
+			if err != nil {
+				t.Fatalf("GetHistoryBefore() failed: %v", err)
+			}
+			for _, c := range page {
+				got = append(got, c.Message)
+			}
+			if !more {
+				break
+			}
+			if len(page) != 3 {
+				t.Fatalf("expected full page when more is set, got %d", len(page))
+			}
+			before = page[len(page)-1].Hash
+		}
+		if len(got) != total {
+			t.Fatalf("expected %d commits, got %d: %v", total, len(got), got)
+		}
+		for i, msg := range got {
+			if want := "Commit " + strconv.Itoa(total-1-i); msg != want {
+				t.Errorf("commit %d: got %q, want %q", i, msg, want)
+			}
+		}
+
+		// Exact page boundary must not report more.
+		all, more, err := repo.GetHistoryBefore(ctx, testFile, "", total)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(all) != total || more {
+			t.Errorf("expected %d commits and no more, got %d, more=%v", total, len(all), more)
+		}
+
+		// Before the oldest commit there is nothing.
+		rest, more, err := repo.GetHistoryBefore(ctx, testFile, all[total-1].Hash, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(rest) != 0 || more {
+			t.Errorf("expected empty page, got %d, more=%v", len(rest), more)
+		}
+
+		if _, _, err := repo.GetHistoryBefore(ctx, testFile, "HEAD~1", 10); !errors.Is(err, ErrInvalidHash) {
+			t.Errorf("expected ErrInvalidHash, got %v", err)
+		}
+	})
+
 	t.Run("GetFileAtCommit", func(t *testing.T) {
 		t.Parallel()
 		tmpDir := t.TempDir()
@@ -310,6 +507,65 @@ func testRepoAll(t *testing.T, backend Backend) {
 		}
 	})
 
+	t.Run("PushErrors", func(t *testing.T) {
+		t.Parallel()
+		ctx := t.Context()
+		remoteDir := t.TempDir()
+		// #nosec G204
+		if err := exec.Command("git", "-C", remoteDir, "init", "--bare").Run(); err != nil {
+			t.Fatalf("failed to init bare repo: %v", err)
+		}
+		// clone creates a repo with one commit of its own, pushing to remoteDir.
+		clone := func(content string) Repository {
+			t.Helper()
+			tmpDir := t.TempDir()
+			repo, err := NewManagerWithBackend(tmpDir, "", "", backend).Repo(ctx, "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := repo.CommitTx(ctx, Author{}, func() (string, []string, error) {
+				return content, []string{"f.txt"}, os.WriteFile(filepath.Join(tmpDir, "f.txt"), []byte(content), 0o600)
+			}); err != nil {
+				t.Fatal(err)
+			}
+			if err := repo.SetRemote(ctx, "origin", remoteDir); err != nil {
+				t.Fatal(err)
+			}
+			return repo
+		}
+		first := clone("first")
+		if err := first.Push(ctx, "origin", "master"); err != nil {
+			t.Fatalf("Push() failed: %v", err)
+		}
+		if err := first.Push(ctx, "origin", "master"); err != nil {
+			t.Errorf("Push() when up to date failed: %v", err)
+		}
+		if err := clone("second").Push(ctx, "origin", "master"); !errors.Is(err, ErrNonFastForward) {
+			t.Errorf("Push() of a diverged branch = %v, want ErrNonFastForward", err)
+		}
+
+		// The credentials in the URL are sent and the rejection is reported.
+		var gotUser, gotPassword string
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if u, p, ok := r.BasicAuth(); ok {
+				gotUser, gotPassword = u, p
+			}
+			w.Header().Set("WWW-Authenticate", `Basic realm="test"`)
+			w.WriteHeader(http.StatusUnauthorized)
+		}))
+		defer srv.Close()
+		authed := clone("third")
+		if err := authed.SetRemote(ctx, "origin", strings.Replace(srv.URL, "http://", "http://x-access-token:bad@", 1)+"/repo.git"); err != nil {
+			t.Fatal(err)
+		}
+		if err := authed.Push(ctx, "origin", "master"); !errors.Is(err, ErrAuthFailed) {
+			t.Errorf("Push() with bad token = %v, want ErrAuthFailed", err)
+		}
+		if gotUser != "x-access-token" || gotPassword != "bad" {
+			t.Errorf("sent credentials %q:%q", gotUser, gotPassword)
+		}
+	})
+
 	t.Run("SeparateRepos", func(t *testing.T) {
 		t.Parallel()
 		tmpDir := t.TempDir()
@@ -748,6 +1004,52 @@ func testRepoAll(t *testing.T, backend Backend) {
 		}
 	})
 
+	t.Run("DiscardChanges", func(t *testing.T) {
+		t.Parallel()
+		tmpDir := t.TempDir()
+		ctx := t.Context()
+		mgr := NewManagerWithBackend(tmpDir, "User", "user@example.com", backend)
+		repo, err := mgr.Repo(ctx, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if discarded, err := repo.DiscardChanges(ctx); err != nil || discarded {
+			t.Fatalf("empty repo: %v, %v", discarded, err)
+		}
+
+		tracked := filepath.Join(tmpDir, "tracked.txt")
+		if err := os.WriteFile(tracked, []byte("v1"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		err = repo.CommitTx(ctx, Author{}, func() (string, []string, error) {
+			return "create", []string{"tracked.txt"}, nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if discarded, err := repo.DiscardChanges(ctx); err != nil || discarded {
+			t.Fatalf("clean repo: %v, %v", discarded, err)
+		}
+
+		untracked := filepath.Join(tmpDir, "untracked.txt")
+		if err := os.WriteFile(untracked, []byte("keep"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(tracked, []byte("v2"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		discarded, err := repo.DiscardChanges(ctx)
+		if err != nil || !discarded {
+			t.Fatalf("dirty repo: %v, %v", discarded, err)
+		}
+		if got, err := os.ReadFile(tracked); err != nil || string(got) != "v1" {
+			t.Errorf("tracked file: %q, %v", got, err)
+		}
+		if _, err := os.Stat(untracked); err != nil {
+			t.Errorf("untracked file removed: %v", err)
+		}
+	})
+
 	t.Run("FS", func(t *testing.T) {
 		t.Parallel()
 		tmpDir := t.TempDir()
@@ -850,6 +1152,81 @@ func testRepoAll(t *testing.T, backend Backend) {
 	})
 }
 
+func TestTrailers(t *testing.T) {
+	t.Run("AppendTrailers", func(t *testing.T) {
+		got := AppendTrailers("create: page X",
+			Trailer{Key: TrailerOp, Value: "create"},
+			Trailer{Key: TrailerActor, Value: ""},
+			Trailer{Key: TrailerNode, Value: "multi\nline"},
+		)
+		want := "create: page X\n\nmddb-op: create\nmddb-node: multi line"
+		if got != want {
+			t.Errorf("AppendTrailers() = %q, want %q", got, want)
+		}
+		if got := AppendTrailers("summary"); got != "summary" {
+			t.Errorf("AppendTrailers() without trailers = %q, want %q", got, "summary")
+		}
+	})
+
+	t.Run("ParseTrailers", func(t *testing.T) {
+		tests := []struct {
+			name string
+			body string
+			want map[string]string
+		}{
+			{"empty", "", nil},
+			{"trailers only", "mddb-op: move\nmddb-node: A", map[string]string{"mddb-op": "move", "mddb-node": "A"}},
+			{"after paragraph", "Some text.\n\nmddb-op: delete", map[string]string{"mddb-op": "delete"}},
+			{"free form", "Not a trailer line", nil},
+			{"mixed last paragraph", "mddb-op: create\nsome prose", nil},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				got := ParseTrailers(tt.body)
+				if len(got) != len(tt.want) {
+					t.Fatalf("ParseTrailers(%q) = %v, want %v", tt.body, got, tt.want)
+				}
+				for k, v := range tt.want {
+					if got[k] != v {
+						t.Errorf("ParseTrailers(%q)[%q] = %q, want %q", tt.body, k, got[k], v)
+					}
+				}
+			})
+		}
+	})
+
+	for _, b := range backends {
+		t.Run(b.name, func(t *testing.T) {
+			dir := t.TempDir()
+			repo, err := NewManagerWithBackend(dir, "Test", "test@example.com", b.backend).Repo(t.Context(), "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			msg := AppendTrailers("create: page A", Trailer{Key: TrailerOp, Value: "create"}, Trailer{Key: TrailerNode, Value: "A"})
+			err = repo.CommitTx(t.Context(), Author{}, func() (string, []string, error) {
+				return msg, []string{"a.md"}, os.WriteFile(filepath.Join(dir, "a.md"), []byte("a"), 0o600)
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			commits, err := repo.GetHistory(t.Context(), "a.md", 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(commits) != 1 {
+				t.Fatalf("got %d commits, want 1", len(commits))
+			}
+			if commits[0].Message != "create: page A" {
+				t.Errorf("Message = %q, want %q", commits[0].Message, "create: page A")
+			}
+			got := ParseTrailers(commits[0].Body)
+			if got[TrailerOp] != "create" || got[TrailerNode] != "A" {
+				t.Errorf("ParseTrailers(%q) = %v", commits[0].Body, got)
+			}
+		})
+	}
+}
+
 func checkConfig(t *testing.T, dir, key, expected string) {
 	t.Helper()
 	// #nosec G204
@@ -863,3 +1240,24 @@ func checkConfig(t *testing.T, dir, key, expected string) {
 		t.Errorf("expected %s=%s, got %s", key, expected, string(out))
 	}
 }
+
+// countLooseObjects returns the number of loose objects in the repository at dir.
+func countLooseObjects(t *testing.T, dir string) int {
+	t.Helper()
+	entries, err := os.ReadDir(filepath.Join(dir, ".git", "objects"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := 0
+	for _, e := range entries {
+		if len(e.Name()) != 2 {
+			continue
+		}
+		files, err := os.ReadDir(filepath.Join(dir, ".git", "objects", e.Name()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		n += len(files)
+	}
+	return n
+}
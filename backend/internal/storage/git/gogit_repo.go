@@ -4,6 +4,7 @@ package git
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"io/fs"
@@ -17,18 +18,18 @@ import (
 	"github.com/go-git/go-git/v5/config"
 	"github.com/go-git/go-git/v5/plumbing"
 	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/transport"
 )
 
 // GoGitRepo implements Repository using go-git (pure Go).
 type GoGitRepo struct {
-	dir          string
-	defaultName  string
-	defaultEmail string
-	repo         *gogit.Repository
-	mu           sync.Mutex
+	dir      string
+	defaults *identity
+	repo     *gogit.Repository
+	mu       sync.Mutex
 }
 
-func newGoGitRepo(_ context.Context, dir, defaultName, defaultEmail string) (*GoGitRepo, error) {
+func newGoGitRepo(_ context.Context, dir string, defaults *identity) (*GoGitRepo, error) {
 	if err := os.MkdirAll(dir, 0o755); err != nil { //nolint:gosec // G301: 0o755 is intentional for data directories
 		return nil, fmt.Errorf("failed to create repo directory: %w", err)
 	}
@@ -45,18 +46,18 @@ func newGoGitRepo(_ context.Context, dir, defaultName, defaultEmail string) (*Go
 		if err != nil {
 			return nil, fmt.Errorf("failed to read git config: %w", err)
 		}
-		cfg.User.Name = defaultName
-		cfg.User.Email = defaultEmail
+		def := defaults.resolve(Author{})
+		cfg.User.Name = def.Name
+		cfg.User.Email = def.Email
 		if err := repo.SetConfig(cfg); err != nil {
 			return nil, fmt.Errorf("failed to write git config: %w", err)
 		}
 	}
 
 	return &GoGitRepo{
-		dir:          dir,
-		defaultName:  defaultName,
-		defaultEmail: defaultEmail,
-		repo:         repo,
+		dir:      dir,
+		defaults: defaults,
+		repo:     repo,
 	}, nil
 }
 
@@ -109,25 +110,18 @@ func (r *GoGitRepo) CommitTx(ctx context.Context, author Author, fn func() (msg
 		return nil
 	}
 
-	name := author.Name
-	email := author.Email
-	if name == "" {
-		name = r.defaultName
-	}
-	if email == "" {
-		email = r.defaultEmail
-	}
-
+	author = r.defaults.resolve(author)
+	committer := r.defaults.resolve(Author{})
 	now := time.Now()
 	_, err = w.Commit(msg, &gogit.CommitOptions{
 		Author: &object.Signature{
-			Name:  name,
-			Email: email,
+			Name:  author.Name,
+			Email: author.Email,
 			When:  now,
 		},
 		Committer: &object.Signature{
-			Name:  r.defaultName,
-			Email: r.defaultEmail,
+			Name:  committer.Name,
+			Email: committer.Email,
 			When:  now,
 		},
 	})
@@ -156,28 +150,47 @@ func (r *GoGitRepo) CommitCount(_ context.Context) (int, error) {
 }
 
 // GetHistory returns commit history for a specific path, limited to n commits.
-func (r *GoGitRepo) GetHistory(_ context.Context, path string, n int) ([]*Commit, error) {
+func (r *GoGitRepo) GetHistory(ctx context.Context, path string, n int) ([]*Commit, error) {
+	commits, _, err := r.GetHistoryBefore(ctx, path, "", n)
+	return commits, err
+}
+
+// GetHistoryBefore returns up to n commits for path that are strictly older than before.
+// If before is empty, starts from HEAD.
+func (r *GoGitRepo) GetHistoryBefore(_ context.Context, path, before string, n int) ([]*Commit, bool, error) {
 	if n <= 0 || n > 1000 {
 		n = 1000
 	}
+	if before != "" && !IsCommitHash(before) {
+		return nil, false, ErrInvalidHash
+	}
 
 	opts := &gogit.LogOptions{}
 	if path != "" && path != "." {
 		opts.FileName = &path
 	}
+	if before != "" {
+		opts.From = plumbing.NewHash(before)
+	}
 
 	iter, err := r.repo.Log(opts)
 	if err != nil {
-		return nil, nil // no commits yet is not an error
+		return nil, false, nil // no commits yet is not an error
 	}
 	defer iter.Close()
 
 	var commits []*Commit
-	for range n {
+	for {
 		c, err := iter.Next()
 		if err != nil {
 			break
 		}
+		if before != "" && c.Hash == opts.From {
+			continue
+		}
+		if len(commits) == n {
+			return commits, true, nil
+		}
 		// Split message into subject and body.
 		subject, body, _ := strings.Cut(c.Message, "\n")
 		commits = append(commits, &Commit{
@@ -192,7 +205,7 @@ func (r *GoGitRepo) GetHistory(_ context.Context, path string, n int) ([]*Commit
 			CommitDate:     c.Committer.When,
 		})
 	}
-	return commits, nil
+	return commits, false, nil
 }
 
 // GetFileAtCommit retrieves the content of a file at a specific commit.
@@ -271,10 +284,20 @@ func (r *GoGitRepo) Push(ctx context.Context, remoteName, branch string) error {
 	}
 
 	refSpec := config.RefSpec(fmt.Sprintf("refs/heads/%s:refs/heads/%s", branch, branch))
-	return remote.PushContext(ctx, &gogit.PushOptions{
+	err = remote.PushContext(ctx, &gogit.PushOptions{
 		RemoteName: remoteName,
 		RefSpecs:   []config.RefSpec{refSpec},
 	})
+	switch {
+	case err == nil, errors.Is(err, gogit.NoErrAlreadyUpToDate):
+		return nil
+	case errors.Is(err, transport.ErrAuthenticationRequired), errors.Is(err, transport.ErrAuthorizationFailed):
+		return fmt.Errorf("%w: %w", ErrAuthFailed, err)
+	}
+	if cause := pushErrorCause(err.Error()); cause != nil {
+		return fmt.Errorf("%w: %w", cause, err)
+	}
+	return err
 }
 
 // Fetch fetches from a remote repository.
@@ -346,6 +369,90 @@ func (r *GoGitRepo) AbortMerge(_ context.Context) error {
 	return nil
 }
 
+// DiscardChanges restores the tracked files to the last commit.
+func (r *GoGitRepo) DiscardChanges(_ context.Context) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	head, err := r.repo.Head()
+	if err != nil {
+		return false, nil //nolint:nilerr // no commits yet, nothing to restore
+	}
+	w, err := r.repo.Worktree()
+	if err != nil {
+		return false, fmt.Errorf("failed to get worktree: %w", err)
+	}
+	status, err := w.Status()
+	if err != nil {
+		return false, fmt.Errorf("failed to get worktree status: %w", err)
+	}
+	var dirty []string
+	for path, st := range status {
+		if st.Worktree != gogit.Untracked {
+			dirty = append(dirty, path)
+		}
+	}
+	if len(dirty) == 0 {
+		return false, nil
+	}
+	commit, err := r.repo.CommitObject(head.Hash())
+	if err != nil {
+		return false, fmt.Errorf("failed to get HEAD commit: %w", err)
+	}
+	tree, err := commit.Tree()
+	if err != nil {
+		return false, fmt.Errorf("failed to get HEAD tree: %w", err)
+	}
+	// A hard reset in go-git also deletes untracked files, so restore the
+	// dirty files one by one and only reset the index.
+	for _, path := range dirty {
+		dst := filepath.Join(r.dir, filepath.FromSlash(path))
+		f, err := tree.File(path)
+		if errors.Is(err, object.ErrFileNotFound) {
+			if err := os.Remove(dst); err != nil && !os.IsNotExist(err) {
+				return false, err
+			}
+			continue
+		}
+		if err != nil {
+			return false, fmt.Errorf("failed to read %s at HEAD: %w", path, err)
+		}
+		content, err := f.Contents()
+		if err != nil {
+			return false, fmt.Errorf("failed to read %s at HEAD: %w", path, err)
+		}
+		if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil { //nolint:gosec // G301: 0o755 is intentional for data directories
+			return false, err
+		}
+		if err := os.WriteFile(dst, []byte(content), 0o644); err != nil { //nolint:gosec // G306: 0o644 is intentional for user data files
+			return false, err
+		}
+	}
+	if err := w.Reset(&gogit.ResetOptions{Commit: head.Hash(), Mode: gogit.MixedReset}); err != nil {
+		return false, fmt.Errorf("failed to reset: %w", err)
+	}
+	return true, nil
+}
+
+// Maintenance prunes unreachable loose objects older than two weeks and
+// packs the reachable ones into a single pack, removing them as loose objects.
+func (r *GoGitRepo) Maintenance(_ context.Context) error {
+	if !r.mu.TryLock() {
+		return ErrBusy
+	}
+	defer r.mu.Unlock()
+	err := r.repo.Prune(gogit.PruneOptions{
+		OnlyObjectsOlderThan: time.Now().Add(-14 * 24 * time.Hour),
+		Handler:              r.repo.DeleteObject,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to prune: %w", err)
+	}
+	if err := r.repo.RepackObjects(&gogit.RepackConfig{}); err != nil {
+		return fmt.Errorf("failed to repack: %w", err)
+	}
+	return nil
+}
+
 // goGitCommitFS implements fs.FS for a specific commit using go-git.
 type goGitCommitFS struct {
 	repo *gogit.Repository
@@ -28,13 +28,7 @@ type RootRepo struct {
 // server_config.json, and registers any pre-existing workspace git repos
 // as submodules (migration).
 func NewRootRepo(ctx context.Context, dataDir, defaultName, defaultEmail string) (*RootRepo, error) {
-	if defaultName == "" {
-		defaultName = "mddb"
-	}
-	if defaultEmail == "" {
-		defaultEmail = "mddb@localhost"
-	}
-	repo, err := newExecRepo(ctx, dataDir, defaultName, defaultEmail)
+	repo, err := newExecRepo(ctx, dataDir, newIdentity(defaultName, defaultEmail))
 	if err != nil {
 		return nil, fmt.Errorf("root repo init: %w", err)
 	}
@@ -78,16 +72,8 @@ func (rr *RootRepo) CommitDBChanges(ctx context.Context, author Author, msg stri
 	// codes portably, so we just proceed to commit.
 	_ = out
 
-	name := author.Name
-	email := author.Email
-	if name == "" {
-		name = rr.repo.defaultName
-	}
-	if email == "" {
-		email = rr.repo.defaultEmail
-	}
-
-	authorStr := fmt.Sprintf("%s <%s>", name, email)
+	author = rr.repo.defaults.resolve(author)
+	authorStr := fmt.Sprintf("%s <%s>", author.Name, author.Email)
 	if err := rr.repo.gitRun(ctx, "commit", "-m", msg, "--author", authorStr); err != nil {
 		return fmt.Errorf("failed to commit db changes: %w", err)
 	}
@@ -107,7 +93,7 @@ func (rr *RootRepo) AddWorkspaceSubmodule(ctx context.Context, wsID string) erro
 	// deinit works correctly on removal (some git versions leave a real
 	// .git dir after submodule add --force on an existing repo).
 	_ = rr.repo.gitRun(ctx, "submodule", "absorbgitdirs")
-	authorStr := fmt.Sprintf("%s <%s>", rr.repo.defaultName, rr.repo.defaultEmail)
+	authorStr := rr.repo.defaultAuthorString()
 	if err := rr.repo.gitRun(ctx, "commit", "-m", "add workspace "+wsID, "--author", authorStr); err != nil {
 		return fmt.Errorf("failed to commit submodule add: %w", err)
 	}
@@ -139,7 +125,7 @@ func (rr *RootRepo) RemoveWorkspaceSubmodule(ctx context.Context, wsID string) e
 		return fmt.Errorf("failed to git rm submodule %s: %w\nOutput: %s", wsID, err, string(out))
 	}
 
-	authorStr := fmt.Sprintf("%s <%s>", rr.repo.defaultName, rr.repo.defaultEmail)
+	authorStr := rr.repo.defaultAuthorString()
 	if err := rr.repo.gitRun(ctx, "commit", "-m", "remove workspace "+wsID, "--author", authorStr); err != nil {
 		return fmt.Errorf("failed to commit submodule removal: %w", err)
 	}
@@ -171,7 +157,7 @@ func (rr *RootRepo) initialCommit(ctx context.Context, staticFiles []string) err
 		return fmt.Errorf("failed to stage initial files: %w\nOutput: %s", err, string(out))
 	}
 
-	authorStr := fmt.Sprintf("%s <%s>", rr.repo.defaultName, rr.repo.defaultEmail)
+	authorStr := rr.repo.defaultAuthorString()
 	if out, err := rr.repo.gitCombinedOutput(ctx, "commit", "-m", "initial commit", "--author", authorStr, "--allow-empty"); err != nil {
 		return fmt.Errorf("failed to create initial commit: %w\nOutput: %s", err, string(out))
 	}
@@ -0,0 +1,66 @@
+// Formats and parses the machine-readable trailers appended to commit messages.
+
+package git
+
+import (
+	"strings"
+)
+
+// Trailer keys recorded on content commits.
+const (
+	TrailerOp    = "mddb-op"
+	TrailerNode  = "mddb-node"
+	TrailerType  = "mddb-type"
+	TrailerActor = "mddb-actor"
+)
+
+// Trailer is a "key: value" line in the final paragraph of a commit message.
+type Trailer struct {
+	Key   string
+	Value string
+}
+
+// AppendTrailers returns msg followed by a blank line and one line per trailer.
+//
+// Trailers with an empty value are skipped. Newlines in values are replaced
+// with spaces so each trailer stays on a single line.
+func AppendTrailers(msg string, trailers ...Trailer) string {
+	var b strings.Builder
+	b.WriteString(msg)
+	sep := "\n\n"
+	for _, t := range trailers {
+		if t.Value == "" {
+			continue
+		}
+		b.WriteString(sep)
+		sep = "\n"
+		b.WriteString(t.Key)
+		b.WriteString(": ")
+		b.WriteString(strings.Join(strings.Fields(t.Value), " "))
+	}
+	return b.String()
+}
+
+// ParseTrailers returns the trailers found in the last paragraph of a commit
+// body, keyed by trailer key.
+//
+// It returns nil if the last paragraph contains a line that is not a trailer,
+// so that free-form bodies are not mistaken for trailers.
+func ParseTrailers(body string) map[string]string {
+	body = strings.TrimSpace(body)
+	if i := strings.LastIndex(body, "\n\n"); i >= 0 {
+		body = body[i+2:]
+	}
+	if body == "" {
+		return nil
+	}
+	out := map[string]string{}
+	for line := range strings.SplitSeq(body, "\n") {
+		key, value, ok := strings.Cut(line, ": ")
+		if !ok || key == "" || strings.ContainsAny(key, " \t") {
+			return nil
+		}
+		out[key] = strings.TrimSpace(value)
+	}
+	return out
+}
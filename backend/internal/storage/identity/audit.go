@@ -0,0 +1,140 @@
+// Records an append-only audit trail of mutating API requests.
+
+package identity
+
+import (
+	"cmp"
+	"errors"
+	"slices"
+	"time"
+
+	"github.com/maruel/ksid"
+	"github.com/maruel/mddb/backend/internal/jsonldb"
+	"github.com/maruel/mddb/backend/internal/storage"
+)
+
+// AuditEntry records one mutating API request, whether it succeeded or not.
+type AuditEntry struct {
+	ID             ksid.ID      `json:"id" jsonschema:"description=Unique entry identifier"`
+	ActorID        ksid.ID      `json:"actor_id,omitempty" jsonschema:"description=Authenticated user"`
+	OrganizationID ksid.ID      `json:"org_id,omitempty" jsonschema:"description=Organization the request was scoped to, if any"`
+	WorkspaceID    ksid.ID      `json:"ws_id,omitempty" jsonschema:"description=Workspace the request was scoped to, if any"`
+	Action         string       `json:"action" jsonschema:"description=Route pattern, e.g. POST /api/v1/workspaces/{wsID}/nodes/{id}/delete"`
+	TargetID       string       `json:"target_id,omitempty" jsonschema:"description=Value of the last path parameter of the route"`
+	Status         int          `json:"status" jsonschema:"description=HTTP status code of the response"`
+	Created        storage.Time `json:"created" jsonschema:"description=Request timestamp"`
+}
+
+// Clone returns a copy of the entry.
+func (e *AuditEntry) Clone() *AuditEntry {
+	c := *e
+	return &c
+}
+
+// GetID returns the entry's ID.
+func (e *AuditEntry) GetID() ksid.ID {
+	return e.ID
+}
+
+// Expiry returns when the entry was created, so that CleanupExpired can drop
+// the entries older than the retention period.
+func (e *AuditEntry) Expiry() time.Time {
+	return e.Created.AsTime()
+}
+
+// Validate checks that the entry is valid.
+func (e *AuditEntry) Validate() error {
+	if e.ID.IsZero() {
+		return errAuditIDRequired
+	}
+	if e.Action == "" {
+		return errAuditActionRequired
+	}
+	return nil
+}
+
+// AuditFilter selects audit entries. Zero fields match everything.
+type AuditFilter struct {
+	ActorID ksid.ID
+	Since   storage.Time // Inclusive
+	Until   storage.Time // Exclusive
+}
+
+func (f *AuditFilter) match(e *AuditEntry) bool {
+	if !f.ActorID.IsZero() && e.ActorID != f.ActorID {
+		return false
+	}
+	if !f.Since.IsZero() && e.Created.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && !e.Created.Before(f.Until) {
+		return false
+	}
+	return true
+}
+
+// AuditService stores audit entries. Entries are only ever appended, and
+// removed by CleanupExpired once older than the retention period.
+type AuditService struct {
+	table *jsonldb.Table[*AuditEntry]
+	byOrg *jsonldb.Index[ksid.ID, *AuditEntry]
+}
+
+// NewAuditService creates a new audit service.
+func NewAuditService(tablePath string) (*AuditService, error) {
+	table, err := jsonldb.NewTable[*AuditEntry](tablePath)
+	if err != nil {
+		return nil, err
+	}
+	byOrg := jsonldb.NewIndex(table, func(e *AuditEntry) ksid.ID { return e.OrganizationID })
+	return &AuditService{table: table, byOrg: byOrg}, nil
+}
+
+// Record appends an entry, assigning its ID and timestamp when unset.
+func (s *AuditService) Record(e *AuditEntry) error {
+	if e.ID.IsZero() {
+		e.ID = ksid.NewID()
+	}
+	if e.Created.IsZero() {
+		e.Created = storage.Now()
+	}
+	return s.table.Append(e)
+}
+
+// CleanupExpired removes the entries created more than olderThan ago.
+func (s *AuditService) CleanupExpired(olderThan time.Duration) (int, error) {
+	return s.table.PurgeExpired(time.Now().Add(-olderThan))
+}
+
+// ListByOrg returns the entries of an organization matching f, newest first,
+// along with the total number of matching entries before pagination.
+func (s *AuditService) ListByOrg(orgID ksid.ID, f AuditFilter, limit, offset int) ([]*AuditEntry, int) {
+	var all []*AuditEntry
+	for e := range s.byOrg.Iter(orgID) {
+		if f.match(e) {
+			all = append(all, e)
+		}
+	}
+	// Sort newest-first by ID (IDs are time-sortable).
+	slices.SortFunc(all, func(a, b *AuditEntry) int {
+		return cmp.Compare(b.ID, a.ID)
+	})
+	total := len(all)
+	if offset >= len(all) {
+		return nil, total
+	}
+	all = all[offset:]
+	if limit > 0 && limit < len(all) {
+		all = all[:limit]
+	}
+	result := make([]*AuditEntry, len(all))
+	for i, e := range all {
+		result[i] = e.Clone()
+	}
+	return result, total
+}
+
+var (
+	errAuditIDRequired     = errors.New("audit entry id is required")
+	errAuditActionRequired = errors.New("audit entry action is required")
+)
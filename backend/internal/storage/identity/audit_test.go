@@ -0,0 +1,98 @@
+package identity
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/maruel/ksid"
+	"github.com/maruel/mddb/backend/internal/storage"
+)
+
+func TestAuditService(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	svc, err := NewAuditService(path)
+	if err != nil {
+		t.Fatalf("NewAuditService failed: %v", err)
+	}
+	orgID := ksid.NewID()
+	alice := ksid.NewID()
+	bob := ksid.NewID()
+	entries := []*AuditEntry{
+		{ActorID: alice, OrganizationID: orgID, Action: "POST /a", Status: 200, Created: 1000},
+		{ActorID: bob, OrganizationID: orgID, Action: "POST /b", Status: 403, Created: 2000},
+		{ActorID: alice, OrganizationID: orgID, Action: "POST /c", Status: 200, Created: 3000},
+		{ActorID: alice, OrganizationID: ksid.NewID(), Action: "POST /d", Status: 200, Created: 4000},
+		{Action: "POST /e", Status: 401},
+	}
+	for _, e := range entries {
+		if err := svc.Record(e); err != nil {
+			t.Fatalf("Record failed: %v", err)
+		}
+		if e.ID.IsZero() || e.Created.IsZero() {
+			t.Fatalf("Record did not set ID and Created: %+v", e)
+		}
+	}
+	if err := svc.Record(&AuditEntry{}); err == nil {
+		t.Error("Record without action should fail")
+	}
+
+	actions := func(got []*AuditEntry) []string {
+		var out []string
+		for _, e := range got {
+			out = append(out, e.Action)
+		}
+		return out
+	}
+	tests := []struct {
+		name   string
+		filter AuditFilter
+		limit  int
+		offset int
+		want   []string
+		total  int
+	}{
+		{"All", AuditFilter{}, 0, 0, []string{"POST /c", "POST /b", "POST /a"}, 3},
+		{"Actor", AuditFilter{ActorID: alice}, 0, 0, []string{"POST /c", "POST /a"}, 2},
+		{"Range", AuditFilter{Since: 2000, Until: 3000}, 0, 0, []string{"POST /b"}, 1},
+		{"Page", AuditFilter{}, 1, 1, []string{"POST /b"}, 3},
+		{"PastEnd", AuditFilter{}, 10, 5, nil, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, total := svc.ListByOrg(orgID, tt.filter, tt.limit, tt.offset)
+			if a := actions(got); !slices.Equal(a, tt.want) || total != tt.total {
+				t.Errorf("ListByOrg = %q (total %d), want %q (total %d)", a, total, tt.want, tt.total)
+			}
+		})
+	}
+
+	t.Run("Reload", func(t *testing.T) {
+		svc2, err := NewAuditService(path)
+		if err != nil {
+			t.Fatalf("NewAuditService failed: %v", err)
+		}
+		if _, total := svc2.ListByOrg(orgID, AuditFilter{Since: storage.Time(1)}, 0, 0); total != 3 {
+			t.Errorf("got %d entries after reload, want 3", total)
+		}
+	})
+
+	t.Run("CleanupExpired", func(t *testing.T) {
+		recent := &AuditEntry{OrganizationID: orgID, Action: "POST /f", Status: 200}
+		if err := svc.Record(recent); err != nil {
+			t.Fatal(err)
+		}
+		n, err := svc.CleanupExpired(time.Hour)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// All the entries but the two recorded with the current time.
+		if n != len(entries)-1 {
+			t.Errorf("removed %d entries, want %d", n, len(entries)-1)
+		}
+		if got, total := svc.ListByOrg(orgID, AuditFilter{}, 0, 0); total != 1 || got[0].ID != recent.ID {
+			t.Errorf("got %q after cleanup, want the recent entry", actions(got))
+		}
+	})
+}
@@ -1,10 +1,13 @@
 package identity
 
 import (
+	"errors"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/maruel/ksid"
+	"github.com/maruel/mddb/backend/internal/storage"
 )
 
 func TestOrganizationInvitation(t *testing.T) {
@@ -244,6 +247,75 @@ func TestOrganizationInvitationService(t *testing.T) {
 			}
 		})
 	})
+
+	// expire moves the expiry of an invitation to ago in the past.
+	expire := func(t *testing.T, id ksid.ID, ago time.Duration) {
+		t.Helper()
+		if _, err := service.table.Modify(id, func(i *OrganizationInvitation) error {
+			i.ExpiresAt = storage.ToTime(time.Now().Add(-ago))
+			return nil
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	t.Run("Expiry", func(t *testing.T) {
+		if d := time.Until(inv2.ExpiresAt.AsTime()); d < 6*24*time.Hour || d > 7*24*time.Hour {
+			t.Errorf("new invitation expires in %v, want 7 days", d)
+		}
+		expire(t, inv2.ID, time.Minute)
+		if _, err := service.GetByToken(inv2.Token); !errors.Is(err, ErrInvitationExpired) {
+			t.Errorf("GetByToken of an expired invitation = %v, want ErrInvitationExpired", err)
+		}
+	})
+
+	t.Run("ResendInvitation", func(t *testing.T) {
+		resent, err := service.ResendInvitation(inv2.ID)
+		if err != nil {
+			t.Fatalf("ResendInvitation failed: %v", err)
+		}
+		if d := time.Until(resent.ExpiresAt.AsTime()); d < 6*24*time.Hour {
+			t.Errorf("resent invitation expires in %v, want 7 days", d)
+		}
+		if resent.Token != inv2.Token {
+			t.Error("token changed")
+		}
+		if found, err := service.GetByToken(inv2.Token); err != nil || found.ID != inv2.ID {
+			t.Errorf("GetByToken after resend = %v, %v", found, err)
+		}
+		if _, err := service.ResendInvitation(ksid.ID(99999)); err == nil {
+			t.Error("Expected error for ResendInvitation with non-existent ID")
+		}
+	})
+
+	t.Run("CleanupExpired", func(t *testing.T) {
+		recent, err := service.Create("recent@example.com", orgID, role, inviterID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		old, err := service.Create("old@example.com", orgID, role, inviterID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expire(t, recent.ID, 24*time.Hour)
+		expire(t, old.ID, 31*24*time.Hour)
+		n, err := service.CleanupExpired(30 * 24 * time.Hour)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n != 1 {
+			t.Errorf("CleanupExpired removed %d, want 1", n)
+		}
+		if _, err := service.Get(old.ID); err == nil {
+			t.Error("invitation expired 31 days ago was kept")
+		}
+		if _, err := service.Get(recent.ID); err != nil {
+			t.Errorf("invitation expired yesterday was removed: %v", err)
+		}
+		if _, err := service.Get(inv2.ID); err != nil {
+			t.Errorf("pending invitation was removed: %v", err)
+		}
+	})
 }
 
 func TestWorkspaceInvitation(t *testing.T) {
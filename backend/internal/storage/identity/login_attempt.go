@@ -0,0 +1,142 @@
+// Tracks failed login attempts to lock out brute-force attacks.
+
+package identity
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/maruel/ksid"
+	"github.com/maruel/mddb/backend/internal/jsonldb"
+	"github.com/maruel/mddb/backend/internal/storage"
+)
+
+// LoginAttempt counts recent failed logins for one key, e.g. an account or a
+// client IP.
+type LoginAttempt struct {
+	ID          ksid.ID      `json:"id" jsonschema:"description=Unique row identifier"`
+	Key         string       `json:"key" jsonschema:"description=What is being tracked, e.g. account:<email> or ip:<address>"`
+	Failures    int          `json:"failures" jsonschema:"description=Failed attempts since WindowStart"`
+	WindowStart storage.Time `json:"window_start" jsonschema:"description=Time of the first failure counted"`
+	LockedUntil storage.Time `json:"locked_until,omitempty" jsonschema:"description=End of the lockout, if locked"`
+	ExpiresAt   storage.Time `json:"expires_at" jsonschema:"description=When the row no longer matters and can be purged"`
+}
+
+// Clone returns a deep copy of the attempt.
+func (a *LoginAttempt) Clone() *LoginAttempt {
+	c := *a
+	return &c
+}
+
+// GetID returns the attempt's ID.
+func (a *LoginAttempt) GetID() ksid.ID {
+	return a.ID
+}
+
+// Expiry returns when the row can be purged, implementing jsonldb.Expirer.
+func (a *LoginAttempt) Expiry() time.Time {
+	return a.ExpiresAt.AsTime()
+}
+
+// Validate checks that the attempt is valid.
+func (a *LoginAttempt) Validate() error {
+	if a.ID.IsZero() {
+		return errLoginAttemptIDRequired
+	}
+	if a.Key == "" {
+		return errLoginAttemptKeyRequired
+	}
+	return nil
+}
+
+// LoginAttemptService records failed logins and locks out keys that fail too
+// often within a window.
+type LoginAttemptService struct {
+	mu    sync.Mutex
+	table *jsonldb.Table[*LoginAttempt]
+	byKey *jsonldb.UniqueIndex[string, *LoginAttempt]
+	now   func() time.Time
+}
+
+// NewLoginAttemptService creates a new login attempt service.
+func NewLoginAttemptService(tablePath string) (*LoginAttemptService, error) {
+	table, err := jsonldb.NewTable[*LoginAttempt](tablePath)
+	if err != nil {
+		return nil, err
+	}
+	byKey := jsonldb.NewUniqueIndex(table, func(a *LoginAttempt) string { return a.Key })
+	return &LoginAttemptService{table: table, byKey: byKey, now: time.Now}, nil
+}
+
+// LockedUntil returns the end of the lockout of key, or the zero time if it is
+// not locked.
+func (s *LoginAttemptService) LockedUntil(key string) time.Time {
+	a := s.byKey.Get(key)
+	if a == nil || a.LockedUntil.IsZero() {
+		return time.Time{}
+	}
+	if until := a.LockedUntil.AsTime(); until.After(s.now()) {
+		return until
+	}
+	return time.Time{}
+}
+
+// RecordFailure counts a failed attempt for key. Failures older than window
+// are forgotten. When maxFailures is reached the key is locked for lockout and
+// the end of the lockout is returned; otherwise the zero time is returned.
+func (s *LoginAttemptService) RecordFailure(key string, maxFailures int, window, lockout time.Duration) (time.Time, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	a := s.byKey.Get(key)
+	if a == nil {
+		a = &LoginAttempt{ID: ksid.NewID(), Key: key, WindowStart: storage.ToTime(now)}
+		if err := s.table.Append(a); err != nil {
+			return time.Time{}, err
+		}
+	}
+	var lockedUntil time.Time
+	_, err := s.table.Modify(a.ID, func(a *LoginAttempt) error {
+		if a.WindowStart.AsTime().Add(window).Before(now) || (!a.LockedUntil.IsZero() && !a.LockedUntil.AsTime().After(now)) {
+			// The window elapsed or a previous lockout ended: start over.
+			a.Failures = 0
+			a.WindowStart = storage.ToTime(now)
+			a.LockedUntil = 0
+		}
+		a.Failures++
+		a.ExpiresAt = storage.ToTime(a.WindowStart.AsTime().Add(window))
+		if a.Failures >= maxFailures {
+			lockedUntil = now.Add(lockout)
+			a.LockedUntil = storage.ToTime(lockedUntil)
+			a.ExpiresAt = a.LockedUntil
+		}
+		return nil
+	})
+	if err != nil {
+		return time.Time{}, err
+	}
+	return lockedUntil, nil
+}
+
+// Reset forgets the failures recorded for key.
+func (s *LoginAttemptService) Reset(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	a := s.byKey.Get(key)
+	if a == nil {
+		return nil
+	}
+	_, err := s.table.Delete(a.ID)
+	return err
+}
+
+// CleanupExpired removes rows whose window and lockout have both ended.
+func (s *LoginAttemptService) CleanupExpired() (int, error) {
+	return s.table.PurgeExpired(s.now())
+}
+
+var (
+	errLoginAttemptIDRequired  = errors.New("login attempt id is required")
+	errLoginAttemptKeyRequired = errors.New("login attempt key is required")
+)
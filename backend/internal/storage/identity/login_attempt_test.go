@@ -0,0 +1,95 @@
+package identity
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLoginAttemptService(t *testing.T) {
+	const maxFailures = 3
+	window := 10 * time.Minute
+	lockout := 5 * time.Minute
+	setup := func(t *testing.T) (*LoginAttemptService, *time.Time) {
+		service, err := NewLoginAttemptService(filepath.Join(t.TempDir(), "login_attempts.jsonl"))
+		if err != nil {
+			t.Fatalf("NewLoginAttemptService failed: %v", err)
+		}
+		now := time.Now()
+		service.now = func() time.Time { return now }
+		return service, &now
+	}
+	fail := func(t *testing.T, service *LoginAttemptService, key string) time.Time {
+		t.Helper()
+		until, err := service.RecordFailure(key, maxFailures, window, lockout)
+		if err != nil {
+			t.Fatalf("RecordFailure failed: %v", err)
+		}
+		return until
+	}
+
+	t.Run("LockoutAfterN", func(t *testing.T) {
+		service, now := setup(t)
+		for i := range maxFailures - 1 {
+			if until := fail(t, service, "ip:1.2.3.4"); !until.IsZero() {
+				t.Fatalf("locked after %d failures", i+1)
+			}
+		}
+		if !service.LockedUntil("ip:1.2.3.4").IsZero() {
+			t.Fatal("locked before the threshold")
+		}
+		until := fail(t, service, "ip:1.2.3.4")
+		if want := now.Add(lockout); !until.Equal(want) {
+			t.Fatalf("lockout until %v, want %v", until, want)
+		}
+		if service.LockedUntil("ip:1.2.3.4").IsZero() {
+			t.Error("not locked after the threshold")
+		}
+		if !service.LockedUntil("ip:5.6.7.8").IsZero() {
+			t.Error("other keys must not be locked")
+		}
+
+		// The lockout ends on its own and counting starts over.
+		*now = now.Add(lockout + time.Second)
+		if !service.LockedUntil("ip:1.2.3.4").IsZero() {
+			t.Error("still locked after the lockout elapsed")
+		}
+		if until := fail(t, service, "ip:1.2.3.4"); !until.IsZero() {
+			t.Error("locked again on the first failure after the lockout")
+		}
+	})
+
+	t.Run("WindowElapse", func(t *testing.T) {
+		service, now := setup(t)
+		for range maxFailures - 1 {
+			fail(t, service, "account:joe@example.com")
+		}
+		*now = now.Add(window + time.Second)
+		if until := fail(t, service, "account:joe@example.com"); !until.IsZero() {
+			t.Error("failures from an elapsed window were counted")
+		}
+		if n, err := service.CleanupExpired(); err != nil || n != 0 {
+			t.Errorf("CleanupExpired() = %d, %v; want 0 while the new window is open", n, err)
+		}
+		*now = now.Add(window + time.Second)
+		if n, err := service.CleanupExpired(); err != nil || n != 1 {
+			t.Errorf("CleanupExpired() = %d, %v; want 1", n, err)
+		}
+	})
+
+	t.Run("Reset", func(t *testing.T) {
+		service, _ := setup(t)
+		for range maxFailures - 1 {
+			fail(t, service, "account:joe@example.com")
+		}
+		if err := service.Reset("account:joe@example.com"); err != nil {
+			t.Fatal(err)
+		}
+		if until := fail(t, service, "account:joe@example.com"); !until.IsZero() {
+			t.Error("failures before the reset were counted")
+		}
+		if err := service.Reset("account:nobody@example.com"); err != nil {
+			t.Errorf("Reset of an unknown key: %v", err)
+		}
+	})
+}
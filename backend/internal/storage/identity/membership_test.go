@@ -1,7 +1,9 @@
 package identity
 
 import (
+	"errors"
 	"path/filepath"
+	"sync"
 	"testing"
 
 	"github.com/maruel/ksid"
@@ -546,4 +548,99 @@ func TestWorkspaceMembershipService(t *testing.T) {
 			t.Errorf("Expected 1 membership remaining, got %d", count)
 		}
 	})
+
+	t.Run("TransferOwnership", func(t *testing.T) {
+		owner, err := userService.Create("owner@example.com", "password", "Owner")
+		if err != nil {
+			t.Fatal(err)
+		}
+		member, err := userService.Create("member@example.com", "password", "Member")
+		if err != nil {
+			t.Fatal(err)
+		}
+		other, err := userService.Create("other@example.com", "password", "Other")
+		if err != nil {
+			t.Fatal(err)
+		}
+		outsider, err := userService.Create("outsider@example.com", "password", "Outsider")
+		if err != nil {
+			t.Fatal(err)
+		}
+		ws, err := wsService.Create(t.Context(), org2.ID, "Transfer")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := service.Create(owner.ID, ws.ID, WSRoleAdmin); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := service.Create(member.ID, ws.ID, WSRoleViewer); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := service.Create(other.ID, ws.ID, WSRoleEditor); err != nil {
+			t.Fatal(err)
+		}
+		admins := func() []ksid.ID {
+			var ids []ksid.ID
+			for m := range service.IterByWorkspace(ws.ID) {
+				if m.Role == WSRoleAdmin {
+					ids = append(ids, m.UserID)
+				}
+			}
+			return ids
+		}
+
+		t.Run("non-member", func(t *testing.T) {
+			if err := service.TransferOwnership(t.Context(), ws.ID, owner.ID, outsider.ID); !errors.Is(err, ErrNotWorkspaceMember) {
+				t.Errorf("TransferOwnership to non-member = %v, want ErrNotWorkspaceMember", err)
+			}
+			if got := admins(); len(got) != 1 || got[0] != owner.ID {
+				t.Errorf("admins = %v, want [%v]", got, owner.ID)
+			}
+		})
+
+		t.Run("not owner", func(t *testing.T) {
+			if err := service.TransferOwnership(t.Context(), ws.ID, other.ID, member.ID); !errors.Is(err, ErrNotWorkspaceOwner) {
+				t.Errorf("TransferOwnership from editor = %v, want ErrNotWorkspaceOwner", err)
+			}
+			if err := service.TransferOwnership(t.Context(), ws.ID, owner.ID, owner.ID); !errors.Is(err, ErrAlreadyWorkspaceOwner) {
+				t.Errorf("TransferOwnership to self = %v, want ErrAlreadyWorkspaceOwner", err)
+			}
+		})
+
+		t.Run("concurrent", func(t *testing.T) {
+			targets := []ksid.ID{member.ID, other.ID}
+			errs := make([]error, len(targets))
+			var wg sync.WaitGroup
+			for i, target := range targets {
+				wg.Go(func() {
+					errs[i] = service.TransferOwnership(t.Context(), ws.ID, owner.ID, target)
+				})
+			}
+			wg.Wait()
+
+			succeeded := 0
+			for i, err := range errs {
+				switch {
+				case err == nil:
+					succeeded++
+				case !errors.Is(err, ErrNotWorkspaceOwner):
+					t.Errorf("transfer to %v: %v", targets[i], err)
+				}
+			}
+			if succeeded != 1 {
+				t.Errorf("%d transfers succeeded, want 1", succeeded)
+			}
+			got := admins()
+			if len(got) != 1 || got[0] == owner.ID {
+				t.Fatalf("admins = %v, want exactly one new owner", got)
+			}
+			m, err := service.Get(owner.ID, ws.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if m.Role != WSRoleEditor {
+				t.Errorf("previous owner role = %q, want %q", m.Role, WSRoleEditor)
+			}
+		})
+	})
 }
@@ -262,6 +262,43 @@ func (s *NotificationService) Delete(id, userID ksid.ID) error {
 	return err
 }
 
+// DeleteByUser deletes all notifications of a user. Returns count deleted.
+func (s *NotificationService) DeleteByUser(userID ksid.ID) (int, error) {
+	var toDelete []ksid.ID
+	for n := range s.byUserID.Iter(userID) {
+		toDelete = append(toDelete, n.ID)
+	}
+	for i, id := range toDelete {
+		if _, err := s.table.Delete(id); err != nil {
+			return i, err
+		}
+	}
+	return len(toDelete), nil
+}
+
+// ClearActor removes actorID from the notifications it triggered for other
+// users, so they no longer point to a deleted account. Returns count updated.
+func (s *NotificationService) ClearActor(actorID ksid.ID) (int, error) {
+	if actorID.IsZero() {
+		return 0, nil
+	}
+	var ids []ksid.ID
+	for n := range s.table.Iter(0) {
+		if n.ActorID == actorID {
+			ids = append(ids, n.ID)
+		}
+	}
+	for i, id := range ids {
+		if _, err := s.table.Modify(id, func(n *Notification) error {
+			n.ActorID = 0
+			return nil
+		}); err != nil {
+			return i, err
+		}
+	}
+	return len(ids), nil
+}
+
 // DeleteOlderThan deletes notifications created before cutoff. Returns count deleted.
 func (s *NotificationService) DeleteOlderThan(cutoff storage.Time) (int, error) {
 	var toDelete []ksid.ID
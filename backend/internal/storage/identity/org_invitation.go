@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"iter"
+	"strings"
 	"time"
 
 	"github.com/maruel/ksid"
@@ -62,6 +63,14 @@ func (i *OrganizationInvitation) IsExpired() bool {
 	return storage.Now().After(i.ExpiresAt)
 }
 
+// Expiry returns when the invitation expires, implementing jsonldb.Expirer.
+func (i *OrganizationInvitation) Expiry() time.Time {
+	return i.ExpiresAt.AsTime()
+}
+
+// orgInvitationTTL is how long an organization invitation can be accepted.
+const orgInvitationTTL = 7 * 24 * time.Hour
+
 // OrganizationInvitationService handles organization invitations.
 type OrganizationInvitationService struct {
 	table   *jsonldb.Table[*OrganizationInvitation]
@@ -102,7 +111,7 @@ func (s *OrganizationInvitationService) Create(email string, orgID ksid.ID, role
 		Role:           role,
 		Token:          token,
 		InvitedBy:      invitedBy,
-		ExpiresAt:      storage.ToTime(time.Now().Add(7 * 24 * time.Hour)), // 7 days
+		ExpiresAt:      storage.ToTime(time.Now().Add(orgInvitationTTL)),
 		Created:        storage.Now(),
 	}
 	if err := s.table.Append(invitation); err != nil {
@@ -112,14 +121,56 @@ func (s *OrganizationInvitationService) Create(email string, orgID ksid.ID, role
 }
 
 // GetByToken retrieves an invitation by its token. O(1) via index.
+//
+// Returns ErrInvitationExpired if the invitation can no longer be accepted.
 func (s *OrganizationInvitationService) GetByToken(token string) (*OrganizationInvitation, error) {
 	inv := s.byToken.Get(token)
 	if inv == nil {
 		return nil, errOrgInvitationNotFound
 	}
+	if inv.IsExpired() {
+		return nil, ErrInvitationExpired
+	}
 	return inv, nil
 }
 
+// Get retrieves an invitation by ID, expired or not.
+func (s *OrganizationInvitationService) Get(id ksid.ID) (*OrganizationInvitation, error) {
+	if id.IsZero() {
+		return nil, errOrgInvitationIDEmpty
+	}
+	inv := s.table.Get(id)
+	if inv == nil {
+		return nil, errOrgInvitationNotFound
+	}
+	return inv.Clone(), nil
+}
+
+// ResendInvitation extends the expiry of an invitation to 7 days from now,
+// for the invitation email to be sent again. The token is unchanged so links
+// already sent keep working.
+func (s *OrganizationInvitationService) ResendInvitation(id ksid.ID) (*OrganizationInvitation, error) {
+	if id.IsZero() {
+		return nil, errOrgInvitationIDEmpty
+	}
+	if s.table.Get(id) == nil {
+		return nil, errOrgInvitationNotFound
+	}
+	inv, err := s.table.Modify(id, func(inv *OrganizationInvitation) error {
+		inv.ExpiresAt = storage.ToTime(time.Now().Add(orgInvitationTTL))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return inv.Clone(), nil
+}
+
+// CleanupExpired removes invitations that have been expired for more than the given duration.
+func (s *OrganizationInvitationService) CleanupExpired(olderThan time.Duration) (int, error) {
+	return s.table.PurgeExpired(time.Now().Add(-olderThan))
+}
+
 // Delete deletes an invitation.
 func (s *OrganizationInvitationService) Delete(id ksid.ID) error {
 	if id.IsZero() {
@@ -134,14 +185,45 @@ func (s *OrganizationInvitationService) Delete(id ksid.ID) error {
 	return nil
 }
 
+// Restore adds back an invitation removed by Delete, with the same ID and
+// token. It is used to undo an acceptance that failed midway.
+func (s *OrganizationInvitationService) Restore(inv *OrganizationInvitation) error {
+	if inv.ID.IsZero() {
+		return errOrgInvitationIDEmpty
+	}
+	return s.table.Append(inv.Clone())
+}
+
 // IterByOrg iterates over all invitations for an organization. O(1) via index.
 func (s *OrganizationInvitationService) IterByOrg(orgID ksid.ID) iter.Seq[*OrganizationInvitation] {
 	return s.byOrgID.Iter(orgID)
 }
 
+// DeleteByEmail removes all invitations addressed to email. Returns the count
+// deleted.
+func (s *OrganizationInvitationService) DeleteByEmail(email string) (int, error) {
+	if email == "" {
+		return 0, errEmailEmpty
+	}
+	var toDelete []ksid.ID
+	for inv := range s.table.Iter(0) {
+		if strings.EqualFold(inv.Email, email) {
+			toDelete = append(toDelete, inv.ID)
+		}
+	}
+	for i, id := range toDelete {
+		if _, err := s.table.Delete(id); err != nil {
+			return i, err
+		}
+	}
+	return len(toDelete), nil
+}
+
 //
 
 var (
 	errOrgInvitationNotFound = errors.New("organization invitation not found")
 	errOrgInvitationIDEmpty  = errors.New("organization invitation id cannot be empty")
+	// ErrInvitationExpired is returned when accepting an invitation past its expiry.
+	ErrInvitationExpired = errors.New("invitation expired")
 )
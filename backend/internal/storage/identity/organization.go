@@ -4,6 +4,7 @@ package identity
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"iter"
@@ -30,6 +31,21 @@ func (o *Organization) Clone() *Organization {
 	return &c
 }
 
+// UnmarshalJSON decodes an Organization. Resource quota fields missing from rows
+// written by older versions default to -1 (inherit) instead of 0 (disabled).
+func (o *Organization) UnmarshalJSON(data []byte) error {
+	type organization Organization
+	v := organization{
+		Quotas:   OrganizationQuotas{ResourceQuotas: storage.AllInheritResourceQuotas()},
+		Settings: OrganizationSettings{DefaultWorkspaceQuotas: DefaultWorkspaceQuotas()},
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	*o = Organization(v)
+	return nil
+}
+
 // GetID returns the Organization's ID.
 func (o *Organization) GetID() ksid.ID {
 	return o.ID
@@ -89,6 +105,8 @@ func DefaultOrganizationQuotas() OrganizationQuotas {
 			MaxAssetSizeBytes:     -1,
 			MaxTablesPerWorkspace: -1,
 			MaxColumnsPerTable:    -1,
+			MaxNodeDepth:          -1,
+			MaxChildrenPerNode:    -1,
 		},
 		MaxWorkspacesPerOrg:    3,
 		MaxMembersPerOrg:       10,
@@ -1,6 +1,7 @@
 package identity
 
 import (
+	"encoding/json"
 	"os"
 	"path/filepath"
 	"testing"
@@ -96,6 +97,32 @@ func TestOrganization(t *testing.T) {
 			t.Errorf("GetID() = %v, want %v", org.GetID(), ksid.ID(42))
 		}
 	})
+
+	t.Run("UnmarshalJSON missing quota fields inherit", func(t *testing.T) {
+		// Row written before max_node_depth and max_children_per_node existed.
+		data := `{"name":"Old","quotas":{"max_pages":50,"max_workspaces_per_org":3},"settings":{"default_workspace_quotas":{"max_pages":-1}}}`
+		var org Organization
+		if err := json.Unmarshal([]byte(data), &org); err != nil {
+			t.Fatal(err)
+		}
+		if org.Quotas.MaxPages != 50 || org.Quotas.MaxWorkspacesPerOrg != 3 {
+			t.Errorf("explicit fields not decoded: %+v", org.Quotas)
+		}
+		if org.Quotas.MaxNodeDepth != -1 || org.Quotas.MaxChildrenPerNode != -1 {
+			t.Errorf("missing quotas = %d, %d; want -1 (inherit)", org.Quotas.MaxNodeDepth, org.Quotas.MaxChildrenPerNode)
+		}
+		if d := org.Settings.DefaultWorkspaceQuotas; d.MaxNodeDepth != -1 || d.MaxChildrenPerNode != -1 {
+			t.Errorf("missing default workspace quotas = %d, %d; want -1", d.MaxNodeDepth, d.MaxChildrenPerNode)
+		}
+
+		var ws Workspace
+		if err := json.Unmarshal([]byte(`{"name":"W","quotas":{"max_pages":5}}`), &ws); err != nil {
+			t.Fatal(err)
+		}
+		if ws.Quotas.MaxPages != 5 || ws.Quotas.MaxNodeDepth != -1 || ws.Quotas.MaxChildrenPerNode != -1 {
+			t.Errorf("workspace quotas = %+v", ws.Quotas)
+		}
+	})
 }
 
 func TestGitRemote(t *testing.T) {
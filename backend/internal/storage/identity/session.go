@@ -5,6 +5,7 @@ package identity
 import (
 	"errors"
 	"iter"
+	"slices"
 	"time"
 
 	"github.com/maruel/ksid"
@@ -14,21 +15,24 @@ import (
 
 // Session represents an active user session.
 type Session struct {
-	ID          ksid.ID      `json:"id" jsonschema:"description=Unique session identifier"`
-	UserID      ksid.ID      `json:"user_id" jsonschema:"description=User who owns this session"`
-	TokenHash   string       `json:"token_hash" jsonschema:"description=SHA-256 hash of the JWT token"`
-	DeviceInfo  string       `json:"device_info" jsonschema:"description=Parsed User-Agent (browser/OS)"`
-	IPAddress   string       `json:"ip_address" jsonschema:"description=Client IP address at login"`
-	CountryCode string       `json:"country_code,omitempty" jsonschema:"description=ISO 3166-1 alpha-2 country code at login"`
-	Created     storage.Time `json:"created" jsonschema:"description=Session creation timestamp"`
-	LastUsed    storage.Time `json:"last_used" jsonschema:"description=Last activity timestamp"`
-	ExpiresAt   storage.Time `json:"expires_at" jsonschema:"description=Session expiration timestamp"`
-	RevokedAt   storage.Time `json:"revoked_at,omitempty" jsonschema:"description=Revocation timestamp if revoked"`
+	ID                ksid.ID      `json:"id" jsonschema:"description=Unique session identifier"`
+	UserID            ksid.ID      `json:"user_id" jsonschema:"description=User who owns this session"`
+	TokenHash         string       `json:"token_hash" jsonschema:"description=SHA-256 hash of the JWT token"`
+	RefreshHash       string       `json:"refresh_hash,omitempty" jsonschema:"description=SHA-256 hash of the current refresh token"`
+	UsedRefreshHashes []string     `json:"used_refresh_hashes,omitempty" jsonschema:"description=SHA-256 hashes of the refresh tokens rotated out, oldest first; only the most recent are kept"`
+	DeviceInfo        string       `json:"device_info" jsonschema:"description=Parsed User-Agent (browser/OS)"`
+	IPAddress         string       `json:"ip_address" jsonschema:"description=Client IP address at login"`
+	CountryCode       string       `json:"country_code,omitempty" jsonschema:"description=ISO 3166-1 alpha-2 country code at login"`
+	Created           storage.Time `json:"created" jsonschema:"description=Session creation timestamp"`
+	LastUsed          storage.Time `json:"last_used" jsonschema:"description=Last activity timestamp"`
+	ExpiresAt         storage.Time `json:"expires_at" jsonschema:"description=Session expiration timestamp"`
+	RevokedAt         storage.Time `json:"revoked_at,omitempty" jsonschema:"description=Revocation timestamp if revoked"`
 }
 
 // Clone returns a deep copy of the session.
 func (s *Session) Clone() *Session {
 	c := *s
+	c.UsedRefreshHashes = slices.Clone(s.UsedRefreshHashes)
 	return &c
 }
 
@@ -37,6 +41,11 @@ func (s *Session) GetID() ksid.ID {
 	return s.ID
 }
 
+// Expiry returns when the session expires, implementing jsonldb.Expirer.
+func (s *Session) Expiry() time.Time {
+	return s.ExpiresAt.AsTime()
+}
+
 // Validate checks that the session is valid.
 func (s *Session) Validate() error {
 	if s.ID.IsZero() {
@@ -68,15 +77,17 @@ func NewSessionService(tablePath string) (*SessionService, error) {
 }
 
 // Create creates a new session with an auto-generated ID.
+// refreshHash may be empty for sessions that cannot be refreshed.
 // maxSessions limits the number of active sessions per user. Use 0 to disable the limit.
-func (s *SessionService) Create(userID ksid.ID, tokenHash, deviceInfo, ipAddress, countryCode string, expiresAt storage.Time, maxSessions int) (*Session, error) {
-	return s.CreateWithID(ksid.NewID(), userID, tokenHash, deviceInfo, ipAddress, countryCode, expiresAt, maxSessions)
+func (s *SessionService) Create(userID ksid.ID, tokenHash, refreshHash, deviceInfo, ipAddress, countryCode string, expiresAt storage.Time, maxSessions int) (*Session, error) {
+	return s.CreateWithID(ksid.NewID(), userID, tokenHash, refreshHash, deviceInfo, ipAddress, countryCode, expiresAt, maxSessions)
 }
 
 // CreateWithID creates a new session with a pre-specified ID.
 // This is useful when the session ID needs to be included in the JWT before creating the session.
+// refreshHash may be empty for sessions that cannot be refreshed.
 // maxSessions limits the number of active sessions per user. Use 0 to disable the limit.
-func (s *SessionService) CreateWithID(id, userID ksid.ID, tokenHash, deviceInfo, ipAddress, countryCode string, expiresAt storage.Time, maxSessions int) (*Session, error) {
+func (s *SessionService) CreateWithID(id, userID ksid.ID, tokenHash, refreshHash, deviceInfo, ipAddress, countryCode string, expiresAt storage.Time, maxSessions int) (*Session, error) {
 	if id.IsZero() {
 		return nil, errSessionIDRequired
 	}
@@ -103,6 +114,7 @@ func (s *SessionService) CreateWithID(id, userID ksid.ID, tokenHash, deviceInfo,
 		ID:          id,
 		UserID:      userID,
 		TokenHash:   tokenHash,
+		RefreshHash: refreshHash,
 		DeviceInfo:  deviceInfo,
 		IPAddress:   ipAddress,
 		CountryCode: countryCode,
@@ -175,11 +187,34 @@ func (s *SessionService) Revoke(id ksid.ID) error {
 	return err
 }
 
-// RevokeAllForUser revokes all sessions for a user. Returns the count of revoked sessions.
+// RevokeAllForUser revokes all active sessions for a user. Returns the count of revoked sessions.
 func (s *SessionService) RevokeAllForUser(userID ksid.ID) (int, error) {
+	return s.RevokeAll(userID, ksid.ID(0))
+}
+
+// ListByUser returns the active (non-revoked, non-expired) sessions of a user,
+// most recently used first.
+func (s *SessionService) ListByUser(userID ksid.ID) ([]*Session, error) {
+	if userID.IsZero() {
+		return nil, errSessionUserIDRequired
+	}
+	sessions := slices.Collect(s.GetActiveByUserID(userID))
+	slices.SortFunc(sessions, func(a, b *Session) int {
+		return b.LastUsed.AsTime().Compare(a.LastUsed.AsTime())
+	})
+	return sessions, nil
+}
+
+// RevokeAll revokes the active sessions of a user except exceptID, typically
+// the session making the request. Use a zero exceptID to revoke them all.
+// Returns the count of revoked sessions.
+func (s *SessionService) RevokeAll(userID, exceptID ksid.ID) (int, error) {
+	if userID.IsZero() {
+		return 0, errSessionUserIDRequired
+	}
 	var ids []ksid.ID
-	for session := range s.byUserID.Iter(userID) {
-		if session.RevokedAt.IsZero() {
+	for session := range s.GetActiveByUserID(userID) {
+		if session.ID != exceptID {
 			ids = append(ids, session.ID)
 		}
 	}
@@ -203,6 +238,58 @@ func (s *SessionService) UpdateLastUsed(id ksid.ID) error {
 	return err
 }
 
+// maxUsedRefreshHashes is the number of rotated-out refresh token hashes kept
+// per session to detect reuse.
+const maxUsedRefreshHashes = 32
+
+// Rotate exchanges the refresh token hashed as refreshHash for a new one.
+//
+// On success the session records newRefreshHash and newTokenHash and its
+// expiry moves to expiresAt. Presenting one of the last maxUsedRefreshHashes
+// refresh tokens rotated out of the session means it was copied: the session
+// is revoked and ErrRefreshTokenReused is returned, so neither party can
+// continue the chain. Any other mismatch, including older rotated-out tokens,
+// and revoked or expired sessions, return ErrRefreshTokenInvalid.
+func (s *SessionService) Rotate(id ksid.ID, refreshHash, newRefreshHash, newTokenHash string, expiresAt storage.Time) (*Session, error) {
+	if refreshHash == "" || newRefreshHash == "" || newTokenHash == "" {
+		return nil, ErrRefreshTokenInvalid
+	}
+	if s.table.Get(id) == nil {
+		return nil, ErrRefreshTokenInvalid
+	}
+	reused := false
+	session, err := s.table.Modify(id, func(session *Session) error {
+		now := storage.Now()
+		if !session.RevokedAt.IsZero() || session.ExpiresAt.Before(now) {
+			return ErrRefreshTokenInvalid
+		}
+		switch {
+		case refreshHash == session.RefreshHash:
+			session.UsedRefreshHashes = append(session.UsedRefreshHashes, session.RefreshHash)
+			if n := len(session.UsedRefreshHashes) - maxUsedRefreshHashes; n > 0 {
+				session.UsedRefreshHashes = slices.Delete(session.UsedRefreshHashes, 0, n)
+			}
+			session.RefreshHash = newRefreshHash
+			session.TokenHash = newTokenHash
+			session.LastUsed = now
+			session.ExpiresAt = expiresAt
+		case slices.Contains(session.UsedRefreshHashes, refreshHash):
+			reused = true
+			session.RevokedAt = now
+		default:
+			return ErrRefreshTokenInvalid
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if reused {
+		return nil, ErrRefreshTokenReused
+	}
+	return session.Clone(), nil
+}
+
 // IsValid checks if a session is valid (not revoked and not expired).
 func (s *SessionService) IsValid(id ksid.ID) (bool, error) {
 	session := s.table.Get(id)
@@ -220,23 +307,7 @@ func (s *SessionService) IsValid(id ksid.ID) (bool, error) {
 
 // CleanupExpired removes sessions that have been expired for more than the given duration.
 func (s *SessionService) CleanupExpired(olderThan time.Duration) (int, error) {
-	cutoff := storage.ToTime(time.Now().Add(-olderThan))
-	var toDelete []ksid.ID
-
-	for session := range s.table.Iter(0) {
-		if session.ExpiresAt.Before(cutoff) {
-			toDelete = append(toDelete, session.ID)
-		}
-	}
-
-	count := 0
-	for _, id := range toDelete {
-		if _, err := s.table.Delete(id); err != nil {
-			return count, err
-		}
-		count++
-	}
-	return count, nil
+	return s.table.PurgeExpired(time.Now().Add(-olderThan))
 }
 
 var (
@@ -246,4 +317,9 @@ var (
 	errSessionNotFound          = errors.New("session not found")
 	// ErrSessionQuotaExceeded is returned when a user has too many active sessions.
 	ErrSessionQuotaExceeded = errors.New("maximum number of active sessions exceeded")
+	// ErrRefreshTokenInvalid is returned when a refresh token is unknown, expired or revoked.
+	ErrRefreshTokenInvalid = errors.New("invalid refresh token")
+	// ErrRefreshTokenReused is returned when a rotated-out refresh token is
+	// presented again. The session has been revoked.
+	ErrRefreshTokenReused = errors.New("refresh token reused; session revoked")
 )
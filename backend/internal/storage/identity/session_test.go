@@ -0,0 +1,211 @@
+package identity
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/maruel/ksid"
+	"github.com/maruel/mddb/backend/internal/storage"
+)
+
+func TestSessionService_Rotate(t *testing.T) {
+	newService := func(t *testing.T) *SessionService {
+		service, err := NewSessionService(filepath.Join(t.TempDir(), "sessions.jsonl"))
+		if err != nil {
+			t.Fatalf("NewSessionService failed: %v", err)
+		}
+		return service
+	}
+	future := storage.ToTime(time.Now().Add(time.Hour))
+
+	t.Run("Rotation", func(t *testing.T) {
+		service := newService(t)
+		session, err := service.Create(ksid.NewID(), "jwt1", "r1", "", "", "", future, 0)
+		if err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+		later := storage.ToTime(time.Now().Add(2 * time.Hour))
+		rotated, err := service.Rotate(session.ID, "r1", "r2", "jwt2", later)
+		if err != nil {
+			t.Fatalf("Rotate failed: %v", err)
+		}
+		if rotated.RefreshHash != "r2" || !slices.Equal(rotated.UsedRefreshHashes, []string{"r1"}) || rotated.TokenHash != "jwt2" {
+			t.Errorf("unexpected hashes after rotation: %+v", rotated)
+		}
+		if rotated.ExpiresAt != later {
+			t.Errorf("ExpiresAt = %v, want %v", rotated.ExpiresAt, later)
+		}
+		if _, err := service.Rotate(session.ID, "r2", "r3", "jwt3", later); err != nil {
+			t.Fatalf("second Rotate failed: %v", err)
+		}
+		if _, err := service.Rotate(session.ID, "bogus", "r4", "jwt4", later); !errors.Is(err, ErrRefreshTokenInvalid) {
+			t.Errorf("unknown token: got %v, want ErrRefreshTokenInvalid", err)
+		}
+		if ok, _ := service.IsValid(session.ID); !ok {
+			t.Error("an unknown refresh token must not revoke the session")
+		}
+	})
+
+	t.Run("Reuse", func(t *testing.T) {
+		service := newService(t)
+		session, err := service.Create(ksid.NewID(), "jwt1", "r1", "", "", "", future, 0)
+		if err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+		if _, err := service.Rotate(session.ID, "r1", "r2", "jwt2", future); err != nil {
+			t.Fatalf("Rotate failed: %v", err)
+		}
+		if _, err := service.Rotate(session.ID, "r2", "r3", "jwt3", future); err != nil {
+			t.Fatalf("second Rotate failed: %v", err)
+		}
+		// Not only the previous token: any earlier one revokes the chain.
+		if _, err := service.Rotate(session.ID, "r1", "r4", "jwt4", future); !errors.Is(err, ErrRefreshTokenReused) {
+			t.Fatalf("replayed token: got %v, want ErrRefreshTokenReused", err)
+		}
+		if ok, _ := service.IsValid(session.ID); ok {
+			t.Error("session should be revoked after reuse")
+		}
+		// The current token of the chain is dead too.
+		if _, err := service.Rotate(session.ID, "r3", "r4", "jwt4", future); !errors.Is(err, ErrRefreshTokenInvalid) {
+			t.Errorf("current token after reuse: got %v, want ErrRefreshTokenInvalid", err)
+		}
+	})
+
+	t.Run("Window", func(t *testing.T) {
+		service := newService(t)
+		session, err := service.Create(ksid.NewID(), "jwt0", "r0", "", "", "", future, 0)
+		if err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+		n := maxUsedRefreshHashes + 5
+		var rotated *Session
+		for i := range n {
+			if rotated, err = service.Rotate(session.ID, fmt.Sprintf("r%d", i), fmt.Sprintf("r%d", i+1), "jwt", future); err != nil {
+				t.Fatalf("Rotate %d failed: %v", i, err)
+			}
+		}
+		if len(rotated.UsedRefreshHashes) != maxUsedRefreshHashes || rotated.UsedRefreshHashes[0] != fmt.Sprintf("r%d", n-maxUsedRefreshHashes) {
+			t.Fatalf("UsedRefreshHashes = %v, want the last %d", rotated.UsedRefreshHashes, maxUsedRefreshHashes)
+		}
+		// A token that fell out of the window is still rejected, without
+		// revoking the session.
+		if _, err := service.Rotate(session.ID, "r0", "x", "jwt", future); !errors.Is(err, ErrRefreshTokenInvalid) {
+			t.Errorf("token outside the window: got %v, want ErrRefreshTokenInvalid", err)
+		}
+		if ok, _ := service.IsValid(session.ID); !ok {
+			t.Error("session should still be valid")
+		}
+		if _, err := service.Rotate(session.ID, fmt.Sprintf("r%d", n-1), "x", "jwt", future); !errors.Is(err, ErrRefreshTokenReused) {
+			t.Errorf("token inside the window: got %v, want ErrRefreshTokenReused", err)
+		}
+	})
+
+	t.Run("Expired", func(t *testing.T) {
+		service := newService(t)
+		past := storage.ToTime(time.Now().Add(-time.Minute))
+		session, err := service.Create(ksid.NewID(), "jwt1", "r1", "", "", "", past, 0)
+		if err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+		if _, err := service.Rotate(session.ID, "r1", "r2", "jwt2", future); !errors.Is(err, ErrRefreshTokenInvalid) {
+			t.Errorf("got %v, want ErrRefreshTokenInvalid", err)
+		}
+	})
+
+	t.Run("Revoked", func(t *testing.T) {
+		service := newService(t)
+		session, err := service.Create(ksid.NewID(), "jwt1", "r1", "", "", "", future, 0)
+		if err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+		if err := service.Revoke(session.ID); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := service.Rotate(session.ID, "r1", "r2", "jwt2", future); !errors.Is(err, ErrRefreshTokenInvalid) {
+			t.Errorf("got %v, want ErrRefreshTokenInvalid", err)
+		}
+	})
+
+	t.Run("NotRefreshable", func(t *testing.T) {
+		service := newService(t)
+		session, err := service.Create(ksid.NewID(), "jwt1", "", "", "", "", future, 0)
+		if err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+		if _, err := service.Rotate(session.ID, "", "r2", "jwt2", future); !errors.Is(err, ErrRefreshTokenInvalid) {
+			t.Errorf("got %v, want ErrRefreshTokenInvalid", err)
+		}
+		if _, err := service.Rotate(ksid.NewID(), "r1", "r2", "jwt2", future); !errors.Is(err, ErrRefreshTokenInvalid) {
+			t.Errorf("unknown session: got %v, want ErrRefreshTokenInvalid", err)
+		}
+	})
+}
+
+func TestSessionService_ListAndRevokeAll(t *testing.T) {
+	service, err := NewSessionService(filepath.Join(t.TempDir(), "sessions.jsonl"))
+	if err != nil {
+		t.Fatalf("NewSessionService failed: %v", err)
+	}
+	userID := ksid.NewID()
+	future := storage.ToTime(time.Now().Add(time.Hour))
+	create := func(tokenHash, device string, expiresAt storage.Time) *Session {
+		t.Helper()
+		session, err := service.Create(userID, tokenHash, "", device, "10.0.0.1", "", expiresAt, 0)
+		if err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+		return session
+	}
+	current := create("jwt1", "Firefox on Linux", future)
+	other := create("jwt2", "Safari on iOS", future)
+	create("jwt3", "Chrome on Windows", storage.ToTime(time.Now().Add(-time.Minute)))
+	if _, err := service.Create(ksid.NewID(), "jwt4", "", "", "", "", future, 0); err != nil {
+		t.Fatal(err)
+	}
+	// Timestamps have a one second resolution; age the other session so the
+	// order does not depend on timing.
+	if _, err := service.table.Modify(other.ID, func(s *Session) error {
+		s.LastUsed = storage.ToTime(time.Now().Add(-time.Minute))
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := service.UpdateLastUsed(current.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	sessions, err := service.ListByUser(userID)
+	if err != nil {
+		t.Fatalf("ListByUser failed: %v", err)
+	}
+	if len(sessions) != 2 || sessions[0].ID != current.ID || sessions[1].ID != other.ID {
+		t.Fatalf("ListByUser = %+v, want the current then the other session", sessions)
+	}
+	if sessions[1].DeviceInfo != "Safari on iOS" || sessions[1].IPAddress != "10.0.0.1" {
+		t.Errorf("device not listed: %+v", sessions[1])
+	}
+
+	n, err := service.RevokeAll(userID, current.ID)
+	if err != nil {
+		t.Fatalf("RevokeAll failed: %v", err)
+	}
+	if n != 1 {
+		t.Errorf("RevokeAll revoked %d sessions, want 1", n)
+	}
+	if ok, _ := service.IsValid(other.ID); ok {
+		t.Error("revoked session is still valid")
+	}
+	if ok, _ := service.IsValid(current.ID); !ok {
+		t.Error("current session was revoked")
+	}
+	if sessions, _ := service.ListByUser(userID); len(sessions) != 1 || sessions[0].ID != current.ID {
+		t.Errorf("ListByUser after RevokeAll = %+v", sessions)
+	}
+	if _, err := service.ListByUser(0); err == nil {
+		t.Error("ListByUser(0) should fail")
+	}
+}
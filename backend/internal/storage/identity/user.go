@@ -10,6 +10,7 @@
 package identity
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"iter"
@@ -230,6 +231,21 @@ func (s *UserService) Modify(id ksid.ID, fn func(user *User) error) (*User, erro
 	return &user, nil
 }
 
+// Delete removes a user account. Data owned by the user in other services is
+// left in place; see handlers.Services.DeleteUser for the full cleanup.
+func (s *UserService) Delete(_ context.Context, id ksid.ID) error {
+	if id.IsZero() {
+		return errUserIDEmpty
+	}
+	if s.table.Get(id) == nil {
+		return errUserNotFound
+	}
+	if _, err := s.table.Delete(id); err != nil {
+		return err
+	}
+	return nil
+}
+
 // Iter iterates over users with ID greater than startID. Pass 0 to iterate from the beginning.
 func (s *UserService) Iter(startID ksid.ID) iter.Seq[*User] {
 	return func(yield func(*User) bool) {
@@ -556,6 +556,28 @@ func TestUserService(t *testing.T) {
 		})
 	})
 
+	t.Run("Delete", func(t *testing.T) {
+		doomed, err := service.Create("doomed@example.com", "password", "Doomed")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := service.Delete(t.Context(), doomed.ID); err != nil {
+			t.Fatalf("Delete failed: %v", err)
+		}
+		if _, err := service.Get(doomed.ID); err == nil {
+			t.Error("Expected deleted user to be gone")
+		}
+		if _, err := service.GetByEmail(doomed.Email); err == nil {
+			t.Error("Expected deleted user to be gone from the email index")
+		}
+		if err := service.Delete(t.Context(), doomed.ID); err == nil {
+			t.Error("Expected error deleting a non-existent user")
+		}
+		if err := service.Delete(t.Context(), 0); err == nil {
+			t.Error("Expected error for Delete with zero ID")
+		}
+	})
+
 	t.Run("InvalidJSONL", func(t *testing.T) {
 		t.Run("malformed JSON", func(t *testing.T) {
 			tempDir := t.TempDir()
@@ -4,6 +4,7 @@ package identity
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"iter"
 
@@ -34,6 +35,18 @@ func (w *Workspace) Clone() *Workspace {
 	return &c
 }
 
+// UnmarshalJSON decodes a Workspace. Quota fields missing from rows written by
+// older versions default to -1 (inherit) instead of 0 (disabled).
+func (w *Workspace) UnmarshalJSON(data []byte) error {
+	type workspace Workspace
+	v := workspace{Quotas: DefaultWorkspaceQuotas()}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	*w = Workspace(v)
+	return nil
+}
+
 // GetID returns the Workspace's ID.
 func (w *Workspace) GetID() ksid.ID {
 	return w.ID
@@ -54,14 +67,38 @@ func (w *Workspace) Validate() error {
 	if err := w.Quotas.Validate(); err != nil {
 		return errInvalidWorkspaceQuota
 	}
+	if !w.Settings.LinkStyle.IsValid() {
+		return errInvalidLinkStyle
+	}
 	return nil
 }
 
 // WorkspaceSettings represents workspace-wide settings.
 type WorkspaceSettings struct {
-	AllowedDomains []string `json:"allowed_domains,omitempty" jsonschema:"description=Additional email domain restrictions (inherits org)"`
-	PublicAccess   bool     `json:"public_access" jsonschema:"description=Whether content is publicly accessible"`
-	GitAutoPush    bool     `json:"git_auto_push" jsonschema:"description=Automatically push changes to remote"`
+	AllowedDomains []string  `json:"allowed_domains,omitempty" jsonschema:"description=Additional email domain restrictions (inherits org)"`
+	PublicAccess   bool      `json:"public_access" jsonschema:"description=Whether content is publicly accessible"`
+	GitAutoPush    bool      `json:"git_auto_push" jsonschema:"description=Automatically push changes to remote"`
+	LinkStyle      LinkStyle `json:"link_style,omitempty" jsonschema:"description=How links between pages are stored in page files (relative or wikilink)"`
+}
+
+// LinkStyle defines how links between pages are stored in page files.
+type LinkStyle string
+
+const (
+	// LinkStyleRelative stores links as relative paths like
+	// [text](../nodeID/index.md). It is the default.
+	LinkStyleRelative LinkStyle = "relative"
+	// LinkStyleWikilink stores links as wikilinks like [[nodeID|text]].
+	LinkStyleWikilink LinkStyle = "wikilink"
+)
+
+// IsValid returns true if the link style is empty or a known style.
+func (s LinkStyle) IsValid() bool {
+	switch s {
+	case "", LinkStyleRelative, LinkStyleWikilink:
+		return true
+	}
+	return false
 }
 
 // WorkspaceQuotas is a type alias for storage.ResourceQuotas.
@@ -78,6 +115,8 @@ func DefaultWorkspaceQuotas() WorkspaceQuotas {
 		MaxAssetSizeBytes:     -1,
 		MaxTablesPerWorkspace: -1,
 		MaxColumnsPerTable:    -1,
+		MaxNodeDepth:          -1,
+		MaxChildrenPerNode:    -1,
 	}
 }
 
@@ -183,4 +222,5 @@ var (
 	errWorkspaceNameRequired = errors.New("workspace name is required")
 	errWorkspaceNotFound     = errors.New("workspace not found")
 	errInvalidWorkspaceQuota = errors.New("invalid workspace quota")
+	errInvalidLinkStyle      = errors.New("invalid link style")
 )
@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"iter"
+	"strings"
 	"time"
 
 	"github.com/maruel/ksid"
@@ -134,6 +135,15 @@ func (s *WorkspaceInvitationService) Delete(id ksid.ID) error {
 	return nil
 }
 
+// Restore adds back an invitation removed by Delete, with the same ID and
+// token. It is used to undo an acceptance that failed midway.
+func (s *WorkspaceInvitationService) Restore(inv *WorkspaceInvitation) error {
+	if inv.ID.IsZero() {
+		return errWSInvitationIDEmpty
+	}
+	return s.table.Append(inv.Clone())
+}
+
 // IterByWorkspace iterates over all invitations for a workspace. O(1) via index.
 func (s *WorkspaceInvitationService) IterByWorkspace(wsID ksid.ID) iter.Seq[*WorkspaceInvitation] {
 	return s.byWSID.Iter(wsID)
@@ -153,6 +163,26 @@ func (s *WorkspaceInvitationService) DeleteAllByWorkspace(wsID ksid.ID) error {
 	return nil
 }
 
+// DeleteByEmail removes all invitations addressed to email. Returns the count
+// deleted.
+func (s *WorkspaceInvitationService) DeleteByEmail(email string) (int, error) {
+	if email == "" {
+		return 0, errEmailEmpty
+	}
+	var toDelete []ksid.ID
+	for inv := range s.table.Iter(0) {
+		if strings.EqualFold(inv.Email, email) {
+			toDelete = append(toDelete, inv.ID)
+		}
+	}
+	for i, id := range toDelete {
+		if _, err := s.table.Delete(id); err != nil {
+			return i, err
+		}
+	}
+	return len(toDelete), nil
+}
+
 //
 
 var (
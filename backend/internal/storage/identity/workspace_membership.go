@@ -3,6 +3,7 @@
 package identity
 
 import (
+	"context"
 	"errors"
 	"iter"
 
@@ -212,6 +213,44 @@ func (s *WorkspaceMembershipService) Modify(id ksid.ID, fn func(m *WorkspaceMemb
 	return s.table.Modify(id, fn)
 }
 
+// TransferOwnership hands the admin role of a workspace from one member to
+// another. The current admin becomes an editor and the target becomes admin;
+// both rows are updated in a single write so the number of admins never
+// changes. The target must already be a member of the workspace.
+func (s *WorkspaceMembershipService) TransferOwnership(_ context.Context, wsID, fromUserID, toUserID ksid.ID) error {
+	if wsID.IsZero() {
+		return errWSIDEmpty
+	}
+	if fromUserID.IsZero() || toUserID.IsZero() {
+		return errUserIDEmpty
+	}
+	if fromUserID == toUserID {
+		return ErrAlreadyWorkspaceOwner
+	}
+	from := s.findByUserAndWorkspace(fromUserID, wsID)
+	if from == nil {
+		return errWSMembershipNotFound
+	}
+	to := s.findByUserAndWorkspace(toUserID, wsID)
+	if to == nil {
+		return ErrNotWorkspaceMember
+	}
+	// Roles are checked again under the table lock, a concurrent transfer may
+	// have completed since the lookup above.
+	_, err := s.table.ModifyMany([]ksid.ID{from.ID, to.ID}, func(rows []*WorkspaceMembership) error {
+		if rows[0].Role != WSRoleAdmin {
+			return ErrNotWorkspaceOwner
+		}
+		if rows[1].Role == WSRoleAdmin {
+			return ErrAlreadyWorkspaceOwner
+		}
+		rows[0].Role = WSRoleEditor
+		rows[1].Role = WSRoleAdmin
+		return nil
+	})
+	return err
+}
+
 // Delete removes a membership.
 func (s *WorkspaceMembershipService) Delete(id ksid.ID) error {
 	if id.IsZero() {
@@ -270,6 +309,15 @@ var (
 	errWSMembershipNotFound = errors.New("workspace membership not found")
 	errInvalidWSRole        = errors.New("invalid workspace role")
 	errWSIDEmpty            = errors.New("workspace id cannot be empty")
+	// ErrNotWorkspaceMember is returned when transferring ownership to a user
+	// who is not a member of the workspace.
+	ErrNotWorkspaceMember = errors.New("target user is not a workspace member")
+	// ErrNotWorkspaceOwner is returned when the user giving up ownership is not
+	// a workspace admin.
+	ErrNotWorkspaceOwner = errors.New("user is not the workspace owner")
+	// ErrAlreadyWorkspaceOwner is returned when the target of a transfer is
+	// already a workspace admin.
+	ErrAlreadyWorkspaceOwner = errors.New("target user is already a workspace owner")
 )
 
 // userWSKey is a composite key for user+workspace lookups.
@@ -27,6 +27,12 @@ type ResourceQuotas struct {
 
 	// MaxColumnsPerTable limits properties/columns per table.
 	MaxColumnsPerTable int `json:"max_columns_per_table" jsonschema:"description=Maximum columns per table (-1=inherit, 0=disabled, positive=limit)"`
+
+	// MaxNodeDepth limits how deeply nodes can be nested; top-level nodes have depth 1.
+	MaxNodeDepth int `json:"max_node_depth" jsonschema:"description=Maximum node nesting depth (-1=inherit, 0=disabled, positive=limit)"`
+
+	// MaxChildrenPerNode limits the direct children of a single node, including the workspace root.
+	MaxChildrenPerNode int `json:"max_children_per_node" jsonschema:"description=Maximum direct children per node (-1=inherit, 0=disabled, positive=limit)"`
 }
 
 // Validate checks that all quota values are valid for org/workspace layers.
@@ -51,6 +57,12 @@ func (q *ResourceQuotas) Validate() error {
 	if q.MaxColumnsPerTable < -1 {
 		return errors.New("max_columns_per_table must be -1 (inherit), 0 (disabled), or positive")
 	}
+	if q.MaxNodeDepth < -1 {
+		return errors.New("max_node_depth must be -1 (inherit), 0 (disabled), or positive")
+	}
+	if q.MaxChildrenPerNode < -1 {
+		return errors.New("max_children_per_node must be -1 (inherit), 0 (disabled), or positive")
+	}
 	return nil
 }
 
@@ -75,6 +87,12 @@ func (q *ResourceQuotas) ValidatePositive() error {
 	if q.MaxColumnsPerTable <= 0 {
 		return errors.New("max_columns_per_table must be positive")
 	}
+	if q.MaxNodeDepth <= 0 {
+		return errors.New("max_node_depth must be positive")
+	}
+	if q.MaxChildrenPerNode <= 0 {
+		return errors.New("max_children_per_node must be positive")
+	}
 	return nil
 }
 
@@ -88,6 +106,8 @@ func AllInheritResourceQuotas() ResourceQuotas {
 		MaxAssetSizeBytes:     -1,
 		MaxTablesPerWorkspace: -1,
 		MaxColumnsPerTable:    -1,
+		MaxNodeDepth:          -1,
+		MaxChildrenPerNode:    -1,
 	}
 }
 
@@ -100,6 +120,8 @@ func DefaultResourceQuotas() ResourceQuotas {
 		MaxAssetSizeBytes:     50 * 1024 * 1024, // 50 MiB
 		MaxTablesPerWorkspace: 100,
 		MaxColumnsPerTable:    50,
+		MaxNodeDepth:          64,
+		MaxChildrenPerNode:    1000,
 	}
 }
 
@@ -116,6 +138,8 @@ func EffectiveQuotas(server, org, ws ResourceQuotas) ResourceQuotas {
 		MaxAssetSizeBytes:     minEffectiveInt64(server.MaxAssetSizeBytes, org.MaxAssetSizeBytes, ws.MaxAssetSizeBytes),
 		MaxTablesPerWorkspace: minEffective(server.MaxTablesPerWorkspace, org.MaxTablesPerWorkspace, ws.MaxTablesPerWorkspace),
 		MaxColumnsPerTable:    minEffective(server.MaxColumnsPerTable, org.MaxColumnsPerTable, ws.MaxColumnsPerTable),
+		MaxNodeDepth:          minEffective(server.MaxNodeDepth, org.MaxNodeDepth, ws.MaxNodeDepth),
+		MaxChildrenPerNode:    minEffective(server.MaxChildrenPerNode, org.MaxChildrenPerNode, ws.MaxChildrenPerNode),
 	}
 }
 
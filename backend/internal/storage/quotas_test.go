@@ -136,8 +136,8 @@ func TestEffectiveParentLimits(t *testing.T) {
 		},
 		{
 			name: "org restricts pages – lower value wins",
-			org:  ResourceQuotas{MaxPages: 100, MaxStorageBytes: -1, MaxRecordsPerTable: -1, MaxAssetSizeBytes: -1, MaxTablesPerWorkspace: -1, MaxColumnsPerTable: -1},
-			want: ResourceQuotas{MaxPages: 100, MaxStorageBytes: server.MaxStorageBytes, MaxRecordsPerTable: server.MaxRecordsPerTable, MaxAssetSizeBytes: server.MaxAssetSizeBytes, MaxTablesPerWorkspace: server.MaxTablesPerWorkspace, MaxColumnsPerTable: server.MaxColumnsPerTable},
+			org:  ResourceQuotas{MaxPages: 100, MaxStorageBytes: -1, MaxRecordsPerTable: -1, MaxAssetSizeBytes: -1, MaxTablesPerWorkspace: -1, MaxColumnsPerTable: -1, MaxNodeDepth: -1, MaxChildrenPerNode: -1},
+			want: ResourceQuotas{MaxPages: 100, MaxStorageBytes: server.MaxStorageBytes, MaxRecordsPerTable: server.MaxRecordsPerTable, MaxAssetSizeBytes: server.MaxAssetSizeBytes, MaxTablesPerWorkspace: server.MaxTablesPerWorkspace, MaxColumnsPerTable: server.MaxColumnsPerTable, MaxNodeDepth: server.MaxNodeDepth, MaxChildrenPerNode: server.MaxChildrenPerNode},
 		},
 	}
 	for _, tt := range tests {
@@ -155,6 +155,7 @@ func TestResourceQuotas_Validate(t *testing.T) {
 		q := ResourceQuotas{
 			MaxPages: -1, MaxStorageBytes: -1, MaxRecordsPerTable: -1,
 			MaxAssetSizeBytes: -1, MaxTablesPerWorkspace: -1, MaxColumnsPerTable: -1,
+			MaxNodeDepth: -1, MaxChildrenPerNode: -1,
 		}
 		if err := q.Validate(); err != nil {
 			t.Errorf("expected no error, got %v", err)
@@ -10,7 +10,7 @@ import { GoogleIcon, MicrosoftIcon, GitHubIcon } from './OAuthIcons';
 import { useI18n } from '../i18n';
 
 interface AuthProps {
-  onLogin: (token: string, user: UserResponse) => void;
+  onLogin: (token: string, user: UserResponse, refreshToken?: string) => void;
 }
 
 // Map providers to their icon components
@@ -71,7 +71,7 @@ export default function Auth(props: AuthProps) {
         : await api.auth.login({ email: email(), password: password() });
 
       if (data.token && data.user) {
-        props.onLogin(data.token, data.user);
+        props.onLogin(data.token, data.user, data.refresh_token);
       } else {
         setError('Invalid response from server');
       }
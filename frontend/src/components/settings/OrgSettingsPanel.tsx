@@ -51,6 +51,8 @@ export default function OrgSettingsPanel(props: OrgSettingsPanelProps) {
     max_asset_size_bytes: -1,
     max_tables_per_workspace: -1,
     max_columns_per_table: -1,
+    max_node_depth: -1,
+    max_children_per_node: -1,
   });
 
   // Server-imposed upper bounds for resource quotas (shown as ceiling hints)
@@ -94,6 +96,8 @@ export default function OrgSettingsPanel(props: OrgSettingsPanelProps) {
       max_asset_size_bytes: orgData.quotas.max_asset_size_bytes,
       max_tables_per_workspace: orgData.quotas.max_tables_per_workspace,
       max_columns_per_table: orgData.quotas.max_columns_per_table,
+      max_node_depth: orgData.quotas.max_node_depth,
+      max_children_per_node: orgData.quotas.max_children_per_node,
     });
     setServerLimits(orgData.server_resource_limits);
   };
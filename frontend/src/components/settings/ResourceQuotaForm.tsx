@@ -1,4 +1,4 @@
-// Shared resource quota form rendering the 6 ResourceQuotas fields for server, org, and workspace panels.
+// Shared resource quota form rendering the 8 ResourceQuotas fields for server, org, and workspace panels.
 
 import { Show, For } from 'solid-js';
 import { useI18n } from '../../i18n';
@@ -41,6 +41,8 @@ export default function ResourceQuotaForm(props: ResourceQuotaFormProps) {
     { key: 'max_asset_size_bytes', label: t('settings.maxAssetSizeBytes') },
     { key: 'max_tables_per_workspace', label: t('settings.maxTablesPerWorkspace') },
     { key: 'max_columns_per_table', label: t('settings.maxColumnsPerTable') },
+    { key: 'max_node_depth', label: t('settings.maxNodeDepth') },
+    { key: 'max_children_per_node', label: t('settings.maxChildrenPerNode') },
   ];
 
   return (
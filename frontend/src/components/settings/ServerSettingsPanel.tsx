@@ -33,6 +33,8 @@ export default function ServerSettingsPanel() {
     max_asset_size_bytes: 0,
     max_tables_per_workspace: 0,
     max_columns_per_table: 0,
+    max_node_depth: 0,
+    max_children_per_node: 0,
   });
 
   // Server-specific quota fields (not part of ResourceQuotas)
@@ -43,6 +45,7 @@ export default function ServerSettingsPanel() {
   const [maxUsers, setMaxUsers] = createSignal(0);
   const [maxTotalStorageBytes, setMaxTotalStorageBytes] = createSignal(0);
   const [maxEgressBandwidthBps, setMaxEgressBandwidthBps] = createSignal(0);
+  const [minFreeDiskBytes, setMinFreeDiskBytes] = createSignal(0);
 
   // Rate limit fields
   const [authRatePerMin, setAuthRatePerMin] = createSignal(0);
@@ -74,6 +77,8 @@ export default function ServerSettingsPanel() {
         max_asset_size_bytes: data.quotas.max_asset_size_bytes,
         max_tables_per_workspace: data.quotas.max_tables_per_workspace,
         max_columns_per_table: data.quotas.max_columns_per_table,
+        max_node_depth: data.quotas.max_node_depth,
+        max_children_per_node: data.quotas.max_children_per_node,
       });
 
       setMaxRequestBodyBytes(data.quotas.max_request_body_bytes);
@@ -83,6 +88,7 @@ export default function ServerSettingsPanel() {
       setMaxUsers(data.quotas.max_users);
       setMaxTotalStorageBytes(data.quotas.max_total_storage_bytes);
       setMaxEgressBandwidthBps(data.quotas.max_egress_bandwidth_bps);
+      setMinFreeDiskBytes(data.quotas.min_free_disk_bytes);
 
       setAuthRatePerMin(data.rate_limits.auth_rate_per_min);
       setWriteRatePerMin(data.rate_limits.write_rate_per_min);
@@ -142,6 +148,7 @@ export default function ServerSettingsPanel() {
           max_users: maxUsers(),
           max_total_storage_bytes: maxTotalStorageBytes(),
           max_egress_bandwidth_bps: maxEgressBandwidthBps(),
+          min_free_disk_bytes: minFreeDiskBytes(),
         },
         rate_limits: {
           auth_rate_per_min: authRatePerMin(),
@@ -331,6 +338,16 @@ export default function ServerSettingsPanel() {
                   min="0"
                 />
               </div>
+
+              <div class={styles.formItem}>
+                <label>{t('server.minFreeDiskBytes')}</label>
+                <input
+                  type="number"
+                  value={minFreeDiskBytes()}
+                  onInput={(e) => setMinFreeDiskBytes(parseInt(e.target.value) || 0)}
+                  min="0"
+                />
+              </div>
             </div>
 
             <h3>{t('server.rateLimits')}</h3>
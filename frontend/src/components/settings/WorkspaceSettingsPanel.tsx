@@ -76,6 +76,8 @@ export default function WorkspaceSettingsPanel(props: WorkspaceSettingsPanelProp
     max_asset_size_bytes: -1,
     max_tables_per_workspace: -1,
     max_columns_per_table: -1,
+    max_node_depth: -1,
+    max_children_per_node: -1,
   });
 
   // Server+org upper bounds for workspace quotas (shown as ceiling hints)
@@ -123,6 +125,8 @@ export default function WorkspaceSettingsPanel(props: WorkspaceSettingsPanelProp
           max_asset_size_bytes: wsData.quotas.max_asset_size_bytes,
           max_tables_per_workspace: wsData.quotas.max_tables_per_workspace,
           max_columns_per_table: wsData.quotas.max_columns_per_table,
+          max_node_depth: wsData.quotas.max_node_depth,
+          max_children_per_node: wsData.quotas.max_children_per_node,
         });
         setParentLimits(wsData.parent_resource_limits);
       }
@@ -22,7 +22,7 @@ interface AuthContextValue {
   api: Accessor<Api>;
   wsApi: Accessor<ReturnType<Api['ws']> | null>;
   orgApi: Accessor<ReturnType<Api['org']> | null>;
-  login: (token: string, user: UserResponse) => void;
+  login: (token: string, user: UserResponse, refreshToken?: string) => void;
   logout: () => Promise<void>;
   setUser: (user: UserResponse | null) => void;
   refreshUser: () => Promise<void>;
@@ -30,11 +30,46 @@ interface AuthContextValue {
 
 const AuthContext = createContext<AuthContextValue>();
 
+const TOKEN_KEY = 'mddb_token';
+const REFRESH_TOKEN_KEY = 'mddb_refresh_token';
+
 export const AuthProvider: ParentComponent = (props) => {
   const [user, setUser] = createSignal<UserResponse | null>(null);
-  const [token, setToken] = createSignal<string | null>(localStorage.getItem('mddb_token'));
+  const [token, setToken] = createSignal<string | null>(localStorage.getItem(TOKEN_KEY));
   const [ready, setReady] = createSignal(false);
 
+  const clearTokens = () => {
+    localStorage.removeItem(TOKEN_KEY);
+    localStorage.removeItem(REFRESH_TOKEN_KEY);
+    setToken(null);
+  };
+
+  // Exchanges the stored refresh token for a new token pair. Concurrent 401s
+  // share one request since each refresh token can only be used once.
+  let refreshing: Promise<string | null> | null = null;
+  const exchangeRefreshToken = async () => {
+    const refreshToken = localStorage.getItem(REFRESH_TOKEN_KEY);
+    if (!refreshToken) return null;
+    try {
+      const data = await createApi(() => null).auth.refresh({ refresh_token: refreshToken });
+      localStorage.setItem(TOKEN_KEY, data.token);
+      localStorage.setItem(REFRESH_TOKEN_KEY, data.refresh_token);
+      setToken(data.token);
+      return data.token;
+    } catch {
+      localStorage.removeItem(REFRESH_TOKEN_KEY);
+      return null;
+    }
+  };
+  const refreshSession = () => {
+    if (!refreshing) {
+      refreshing = exchangeRefreshToken().finally(() => {
+        refreshing = null;
+      });
+    }
+    return refreshing;
+  };
+
   const logout = async () => {
     const currentToken = token();
     if (currentToken) {
@@ -48,14 +83,13 @@ export const AuthProvider: ParentComponent = (props) => {
         // Ignore errors - proceed with local logout even if server call fails
       }
     }
-    localStorage.removeItem('mddb_token');
-    setToken(null);
+    clearTokens();
     setUser(null);
     // Note: Navigation after logout is handled by calling components
   };
 
   // Create API client with auth
-  const api = createMemo(() => createApi(() => token(), logout));
+  const api = createMemo(() => createApi(() => token(), logout, refreshSession));
 
   // Get workspace-scoped API client
   const wsApi = createMemo(() => {
@@ -69,8 +103,11 @@ export const AuthProvider: ParentComponent = (props) => {
     return orgID ? api().org(orgID) : null;
   });
 
-  const login = (newToken: string, userData: UserResponse) => {
-    localStorage.setItem('mddb_token', newToken);
+  const login = (newToken: string, userData: UserResponse, refreshToken?: string) => {
+    localStorage.setItem(TOKEN_KEY, newToken);
+    if (refreshToken) {
+      localStorage.setItem(REFRESH_TOKEN_KEY, refreshToken);
+    }
     setToken(newToken);
     setUser(userData);
   };
@@ -82,8 +119,7 @@ export const AuthProvider: ParentComponent = (props) => {
     } catch (err) {
       console.error('Failed to refresh user', err);
       if (err instanceof APIError && err.status === 401) {
-        localStorage.removeItem('mddb_token');
-        setToken(null);
+        clearTokens();
         setUser(null);
       }
     }
@@ -94,7 +130,11 @@ export const AuthProvider: ParentComponent = (props) => {
     const urlParams = new URLSearchParams(window.location.search);
     const urlToken = urlParams.get('token');
     if (urlToken) {
-      localStorage.setItem('mddb_token', urlToken);
+      localStorage.setItem(TOKEN_KEY, urlToken);
+      const urlRefreshToken = urlParams.get('refresh_token');
+      if (urlRefreshToken) {
+        localStorage.setItem(REFRESH_TOKEN_KEY, urlRefreshToken);
+      }
       setToken(urlToken);
       window.history.replaceState({}, document.title, window.location.pathname);
     }
@@ -102,7 +142,7 @@ export const AuthProvider: ParentComponent = (props) => {
     // Determine which token to use - URL token takes priority over localStorage
     // Note: We read the token directly from the sources, not from the signal,
     // because the signal update might not be visible in this synchronous context
-    const effectiveToken = urlToken || localStorage.getItem('mddb_token');
+    const effectiveToken = urlToken || localStorage.getItem(TOKEN_KEY);
     if (effectiveToken) {
       try {
         const data = await api().auth.getMe();
@@ -110,8 +150,7 @@ export const AuthProvider: ParentComponent = (props) => {
       } catch (err) {
         console.error('Failed to load user', err);
         if (err instanceof APIError && err.status === 401) {
-          localStorage.removeItem('mddb_token');
-          setToken(null);
+          clearTokens();
         }
       }
     }
@@ -134,8 +173,7 @@ export const AuthProvider: ParentComponent = (props) => {
             } catch (err) {
               console.error('Failed to load user', err);
               if (err instanceof APIError && err.status === 401) {
-                localStorage.removeItem('mddb_token');
-                setToken(null);
+                clearTokens();
               }
             }
           })();
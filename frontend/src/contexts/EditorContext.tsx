@@ -307,7 +307,7 @@ export const EditorProvider: ParentComponent = (props) => {
 
     try {
       setLoadingHistory(true);
-      const data = await ws.nodes.history.listNodeVersions(nodeId, { Limit: 100 });
+      const data = await ws.nodes.history.listNodeVersions(nodeId, { Limit: 100, Before: '' });
       setHistory((data.history?.filter(Boolean) as Commit[]) || []);
       setShowHistory(true);
     } catch (err) {
@@ -279,6 +279,8 @@ export const dict: Dictionary = {
     maxTotalStorageBytes: 'Max. Speicher gesamt (Bytes)',
     maxTablesPerWorkspace: 'Max. Tabellen pro Arbeitsbereich',
     maxColumnsPerTable: 'Max. Spalten pro Tabelle',
+    maxNodeDepth: 'Max. Knotentiefe',
+    maxChildrenPerNode: 'Max. Unterknoten pro Knoten',
     // Workspace quotas
     workspaceQuotas: 'Arbeitsbereich-Kontingente',
     maxPages: 'Max. Seiten',
@@ -530,6 +532,8 @@ export const dict: Dictionary = {
     maxSessionsPerUser: 'Max. Sitzungen pro Benutzer',
     maxTablesPerWorkspace: 'Max. Tabellen pro Arbeitsbereich',
     maxColumnsPerTable: 'Max. Spalten pro Tabelle',
+    maxNodeDepth: 'Max. Knotentiefe',
+    maxChildrenPerNode: 'Max. Unterknoten pro Knoten',
     maxRecordsPerTable: 'Max. Datensätze pro Tabelle',
     maxPages: 'Max. Seiten',
     maxStorageBytes: 'Max. Speicher (Bytes)',
@@ -539,6 +543,7 @@ export const dict: Dictionary = {
     maxTotalStorageBytes: 'Max. Speicher gesamt (Bytes)',
     maxAssetSizeBytes: 'Max. Asset-Größe (Bytes)',
     maxEgressBandwidthBps: 'Max. Ausgangs-Bandbreite (Bytes/Sek)',
+    minFreeDiskBytes: 'Min. freier Speicherplatz (Bytes)',
     rateLimits: 'Ratenlimits',
     rateLimitsHint: '0 bedeutet unbegrenzt. Änderungen werden sofort wirksam.',
     authRatePerMin: 'Auth-Rate (Anf./Min)',
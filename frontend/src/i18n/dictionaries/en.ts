@@ -280,6 +280,8 @@ export const dict: Dictionary = {
     maxTotalStorageBytes: 'Max Total Storage (bytes)',
     maxTablesPerWorkspace: 'Max Tables per Workspace',
     maxColumnsPerTable: 'Max Columns per Table',
+    maxNodeDepth: 'Max Node Depth',
+    maxChildrenPerNode: 'Max Children per Node',
     // Workspace quotas
     workspaceQuotas: 'Workspace Quotas',
     maxPages: 'Max Pages',
@@ -530,6 +532,8 @@ export const dict: Dictionary = {
     maxSessionsPerUser: 'Max Sessions per User',
     maxTablesPerWorkspace: 'Max Tables per Workspace',
     maxColumnsPerTable: 'Max Columns per Table',
+    maxNodeDepth: 'Max Node Depth',
+    maxChildrenPerNode: 'Max Children per Node',
     maxRecordsPerTable: 'Max Records per Table',
     maxPages: 'Max Pages',
     maxStorageBytes: 'Max Storage (bytes)',
@@ -539,6 +543,7 @@ export const dict: Dictionary = {
     maxTotalStorageBytes: 'Max Total Storage (bytes)',
     maxAssetSizeBytes: 'Max Asset Size (bytes)',
     maxEgressBandwidthBps: 'Max Egress Bandwidth (bytes/sec)',
+    minFreeDiskBytes: 'Min Free Disk Space (bytes)',
     rateLimits: 'Rate Limits',
     rateLimitsHint: '0 means unlimited. Changes take effect immediately.',
     authRatePerMin: 'Auth Rate (req/min)',
@@ -279,6 +279,8 @@ export const dict: Dictionary = {
     maxTotalStorageBytes: 'Almacenamiento total máx. (bytes)',
     maxTablesPerWorkspace: 'Tablas máx. por espacio',
     maxColumnsPerTable: 'Columnas máx. por tabla',
+    maxNodeDepth: 'Profundidad máx. de nodos',
+    maxChildrenPerNode: 'Hijos máx. por nodo',
     // Workspace quotas
     workspaceQuotas: 'Cuotas del espacio de trabajo',
     maxPages: 'Páginas máx.',
@@ -530,6 +532,8 @@ export const dict: Dictionary = {
     maxSessionsPerUser: 'Sesiones máx. por usuario',
     maxTablesPerWorkspace: 'Tablas máx. por espacio',
     maxColumnsPerTable: 'Columnas máx. por tabla',
+    maxNodeDepth: 'Profundidad máx. de nodos',
+    maxChildrenPerNode: 'Hijos máx. por nodo',
     maxRecordsPerTable: 'Registros máx. por tabla',
     maxPages: 'Páginas máx.',
     maxStorageBytes: 'Almacenamiento máx. (bytes)',
@@ -539,6 +543,7 @@ export const dict: Dictionary = {
     maxTotalStorageBytes: 'Almacenamiento total máx. (bytes)',
     maxAssetSizeBytes: 'Tamaño máx. archivo (bytes)',
     maxEgressBandwidthBps: 'Ancho de banda máx. (bytes/seg)',
+    minFreeDiskBytes: 'Espacio libre mín. en disco (bytes)',
     rateLimits: 'Límites de velocidad',
     rateLimitsHint: '0 significa ilimitado. Los cambios se aplican inmediatamente.',
     authRatePerMin: 'Tasa auth (sol/min)',
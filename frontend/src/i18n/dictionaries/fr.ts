@@ -279,6 +279,8 @@ export const dict: Dictionary = {
     maxTotalStorageBytes: 'Stockage total max (octets)',
     maxTablesPerWorkspace: 'Tables max par espace',
     maxColumnsPerTable: 'Colonnes max par table',
+    maxNodeDepth: 'Profondeur max des nœuds',
+    maxChildrenPerNode: 'Enfants max par nœud',
     // Workspace quotas
     workspaceQuotas: "Quotas de l'espace de travail",
     maxPages: 'Pages max',
@@ -530,6 +532,8 @@ export const dict: Dictionary = {
     maxSessionsPerUser: 'Sessions max par utilisateur',
     maxTablesPerWorkspace: 'Tables max par espace',
     maxColumnsPerTable: 'Colonnes max par table',
+    maxNodeDepth: 'Profondeur max des nœuds',
+    maxChildrenPerNode: 'Enfants max par nœud',
     maxRecordsPerTable: 'Enregistrements max par table',
     maxPages: 'Pages max',
     maxStorageBytes: 'Stockage max (octets)',
@@ -539,6 +543,7 @@ export const dict: Dictionary = {
     maxTotalStorageBytes: 'Stockage total max (octets)',
     maxAssetSizeBytes: 'Taille max fichier (octets)',
     maxEgressBandwidthBps: 'Bande passante max (octets/sec)',
+    minFreeDiskBytes: 'Espace disque libre min (octets)',
     rateLimits: 'Limites de débit',
     rateLimitsHint: '0 signifie illimité. Les modifications prennent effet immédiatement.',
     authRatePerMin: 'Débit auth (req/min)',
@@ -280,6 +280,8 @@ export interface Dictionary {
     maxTotalStorageBytes: string;
     maxTablesPerWorkspace: string;
     maxColumnsPerTable: string;
+    maxNodeDepth: string;
+    maxChildrenPerNode: string;
     // Workspace quotas
     workspaceQuotas: string;
     maxPages: string;
@@ -533,6 +535,8 @@ export interface Dictionary {
     maxSessionsPerUser: string;
     maxTablesPerWorkspace: string;
     maxColumnsPerTable: string;
+    maxNodeDepth: string;
+    maxChildrenPerNode: string;
     maxRecordsPerTable: string;
     maxPages: string;
     maxStorageBytes: string;
@@ -542,6 +546,7 @@ export interface Dictionary {
     maxTotalStorageBytes: string;
     maxAssetSizeBytes: string;
     maxEgressBandwidthBps: string;
+    minFreeDiskBytes: string;
     rateLimits: string;
     rateLimitsHint: string;
     authRatePerMin: string;
@@ -66,17 +66,32 @@ function withRetry(fetchFn: FetchFn): FetchFn {
  * Creates an authenticated fetch function for use with the API client.
  * @param getToken - Function that returns the current auth token
  * @param onUnauthorized - Callback when a 401 response is received
+ * @param refresh - Exchanges the refresh token for a new auth token; resolves to null when that fails.
+ *   On a 401 the request is retried once with the new token before onUnauthorized is called.
  */
-export function createAuthFetch(getToken: () => string | null, onUnauthorized?: () => void): FetchFn {
-  const baseFetch: FetchFn = async (url: string, init?: RequestInit) => {
-    const token = getToken();
+export function createAuthFetch(
+  getToken: () => string | null,
+  onUnauthorized?: () => void,
+  refresh?: () => Promise<string | null>
+): FetchFn {
+  const send = (url: string, init: RequestInit | undefined, token: string | null) => {
     const headers: HeadersInit = {
       ...init?.headers,
     };
     if (token) {
       (headers as Record<string, string>)['Authorization'] = `Bearer ${token}`;
     }
-    const res = await fetch(url, { ...init, headers });
+    return fetch(url, { ...init, headers });
+  };
+  const baseFetch: FetchFn = async (url: string, init?: RequestInit) => {
+    const token = getToken();
+    let res = await send(url, init, token);
+    if (res.status === 401 && token && refresh) {
+      const newToken = await refresh();
+      if (newToken) {
+        res = await send(url, init, newToken);
+      }
+    }
     if (res.status === 401 && onUnauthorized) {
       onUnauthorized();
     }
@@ -89,7 +104,12 @@ export function createAuthFetch(getToken: () => string | null, onUnauthorized?:
  * Creates an API client with authentication.
  * @param getToken - Function that returns the current auth token
  * @param onUnauthorized - Callback when a 401 response is received
+ * @param refresh - Renews the auth token on a 401, see createAuthFetch
  */
-export function createApi(getToken: () => string | null, onUnauthorized?: () => void) {
-  return createAPIClient(createAuthFetch(getToken, onUnauthorized));
+export function createApi(
+  getToken: () => string | null,
+  onUnauthorized?: () => void,
+  refresh?: () => Promise<string | null>
+) {
+  return createAPIClient(createAuthFetch(getToken, onUnauthorized, refresh));
 }
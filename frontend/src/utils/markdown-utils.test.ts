@@ -84,6 +84,17 @@ describe('relativeLinksToSpaUrls', () => {
     const content = 'No links here, just text.';
     expect(relativeLinksToSpaUrls(content, wsId)).toBe(content);
   });
+
+  it('converts wikilinks', () => {
+    expect(relativeLinksToSpaUrls('[[NODE123|Page]] and [[NODE456]]', wsId)).toBe(
+      '[Page](/w/@IVQKBFTG000/@NODE123) and [NODE456](/w/@IVQKBFTG000/@NODE456)'
+    );
+  });
+
+  it('leaves wikilinks to page names unchanged', () => {
+    const content = '[[Some Page]] and [[lowercase]]';
+    expect(relativeLinksToSpaUrls(content, wsId)).toBe(content);
+  });
 });
 
 describe('spaUrlsToRelativeLinks', () => {
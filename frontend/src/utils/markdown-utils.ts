@@ -12,18 +12,32 @@ export type NodeTitleMap = Record<string, string>;
 const RELATIVE_LINK_PATTERN = /\[([^\]]*)\]\(([^)]+\/index\.md)\)/g;
 
 /**
- * Convert relative file path links to SPA URLs.
+ * Pattern to match wikilinks to a node: [[nodeId]] or [[nodeId|text]]
+ * Captures: group 1 = nodeId, group 2 = text (optional)
+ */
+const WIKI_LINK_PATTERN = /\[\[([0-9A-V]{1,13})(?:\|([^[\]]*))?\]\]/g;
+
+/**
+ * Convert relative file path links and wikilinks to SPA URLs.
  * Called on the read path: API content (disk format) -> frontend SPA URLs.
  *
  * Extracts node IDs from directory names in paths like ../nodeId/index.md
- * and constructs /w/@wsId/@nodeId URLs.
+ * and from wikilinks like [[nodeId|text]], and constructs /w/@wsId/@nodeId
+ * URLs. Wikilinks without text use the node ID as text.
  *
- * @param content Markdown content with relative file path links
+ * @param content Markdown content with relative file path links or wikilinks
  * @param wsId Current workspace ID
- * @returns Content with relative paths replaced by SPA URLs
+ * @returns Content with relative paths and wikilinks replaced by SPA URLs
  */
 export function relativeLinksToSpaUrls(content: string, wsId: string): string {
-  if (!content || !wsId || !content.includes('/index.md)')) return content;
+  if (!content || !wsId) return content;
+  if (content.includes('[[')) {
+    content = content.replace(
+      WIKI_LINK_PATTERN,
+      (_match, nodeId: string, text: string | undefined) => `[${text ?? nodeId}](/w/@${wsId}/@${nodeId})`
+    );
+  }
+  if (!content.includes('/index.md)')) return content;
 
   return content.replace(RELATIVE_LINK_PATTERN, (match, text, href) => {
     // Skip absolute or external links.